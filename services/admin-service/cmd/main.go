@@ -9,9 +9,16 @@ import (
 	"glovo-backend/services/admin-service/internal/adapters/client"
 	"glovo-backend/services/admin-service/internal/adapters/db"
 	httpHandler "glovo-backend/services/admin-service/internal/adapters/http"
+	"glovo-backend/services/admin-service/internal/adapters/subscriber"
 	"glovo-backend/services/admin-service/internal/app"
 	"glovo-backend/services/admin-service/internal/domain"
+	"glovo-backend/shared/config"
 	"glovo-backend/shared/database"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/health"
+	"glovo-backend/shared/logging"
+	"glovo-backend/shared/metrics"
+	"glovo-backend/shared/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -28,11 +35,16 @@ import (
 // @in header
 // @name Authorization
 func main() {
+	logging.Setup("admin-service")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
 
+	// Fail fast on missing or malformed configuration
+	cfg := config.MustLoad("admin-service", config.Postgres(), config.Auth(), config.EventBus(), config.HTTPPort("ADMIN_SERVICE_PORT", "8010"))
+
 	// Database connections
 	postgresDB := database.ConnectPostgres()
 
@@ -53,6 +65,7 @@ func main() {
 	// Initialize external service clients (using mocks for development)
 	userService := client.NewMockUserService()
 	orderService := client.NewMockOrderService()
+	deliveryService := client.NewMockDeliveryService()
 	paymentService := client.NewMockPaymentService()
 	catalogService := client.NewMockCatalogService()
 	driverService := client.NewMockDriverService()
@@ -65,30 +78,39 @@ func main() {
 		auditLogRepo,
 		userService,
 		orderService,
+		deliveryService,
 		paymentService,
 		catalogService,
 		driverService,
 		analyticsService,
 	)
 
+	// Every service reports requests made with impersonation tokens
+	eventBus := events.FromEnv()
+	defer eventBus.Close()
+	middleware.AuditImpersonation(eventBus, "admin-service")
+	if err := subscriber.NewImpersonationSubscriber(adminService).Register(eventBus); err != nil {
+		log.Fatal("Failed to subscribe to impersonation events:", err)
+	}
+
 	// Initialize HTTP handler
 	adminHandler := httpHandler.NewAdminHandler(adminService)
 
 	// Setup Gin router
 	gin.SetMode(gin.ReleaseMode)
-	router := gin.Default()
-
-	// Simple CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "*")
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-		c.Next()
-	})
+	router := gin.New()
+	// Client IPs come from X-Forwarded-For only behind TRUSTED_PROXIES
+	if err := router.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	router.Use(gin.Recovery(), middleware.RequestID(), middleware.RequestLogger())
+	router.Use(metrics.Middleware("admin-service"))
+
+	// CORS, restricted to the origins in CORS_ALLOWED_ORIGINS
+	router.Use(middleware.CORS(middleware.CORSConfigFromEnv()))
+
+	// Prometheus metrics
+	router.GET("/metrics", metrics.Handler())
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
@@ -98,6 +120,9 @@ func main() {
 		})
 	})
 
+	// Readiness check, verifies database connectivity
+	router.GET("/health/ready", health.Ready("admin-service", map[string]health.Check{"postgres": health.Postgres(postgresDB)}))
+
 	// API routes
 	v1 := router.Group("/api/v1")
 	{
@@ -109,7 +134,7 @@ func main() {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Start server
-	port := getEnv("ADMIN_SERVICE_PORT", "8010")
+	port := cfg.String("ADMIN_SERVICE_PORT")
 	log.Printf("Admin Service starting on port %s", port)
 
 	if err := router.Run(":" + port); err != nil {
@@ -117,13 +142,6 @@ func main() {
 	}
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -2,6 +2,8 @@ package client
 
 import (
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"glovo-backend/services/admin-service/internal/domain"
@@ -63,37 +65,165 @@ func (m *mockUserService) UpdateUserStatus(userID string, status domain.UserStat
 	return nil
 }
 
-func (m *mockUserService) GetMerchants(limit, offset int) ([]domain.MerchantInfo, error) {
-	return []domain.MerchantInfo{
-		{
-			ID:           "merchant-1",
-			BusinessName: "Pizza Palace",
-			Profile: domain.MerchantProfile{
-				BusinessName: "Pizza Palace LLC",
-				Address:      "123 Main St",
-				Phone:        "+1234567890",
-			},
-			Status:    domain.MerchantStatusActive,
-			CreatedAt: time.Now().AddDate(0, -3, 0),
+var mockMerchants = []domain.MerchantInfo{
+	{
+		ID:           "merchant-1",
+		BusinessName: "Pizza Palace",
+		StoreInfo:    domain.StoreInfo{Name: "Pizza Palace Downtown", Category: "pizza", Rating: 4.6, OrderCount: 1520},
+		Profile: domain.MerchantProfile{
+			BusinessName: "Pizza Palace LLC",
+			Address:      "123 Main St",
+			Phone:        "+1234567890",
 		},
-	}, nil
+		Status:    domain.MerchantStatusActive,
+		CreatedAt: time.Now().AddDate(0, -3, 0),
+	},
+	{
+		ID:           "merchant-2",
+		BusinessName: "Sushi Corner",
+		StoreInfo:    domain.StoreInfo{Name: "Sushi Corner", Category: "japanese", Rating: 4.8, OrderCount: 870},
+		Status:       domain.MerchantStatusActive,
+		CreatedAt:    time.Now().AddDate(0, -1, 0),
+	},
+	{
+		ID:           "merchant-3",
+		BusinessName: "Burger Barn",
+		StoreInfo:    domain.StoreInfo{Name: "Burger Barn Express", Category: "burgers", Rating: 3.9, OrderCount: 240},
+		Status:       domain.MerchantStatusSuspended,
+		CreatedAt:    time.Now().AddDate(0, -6, 0),
+	},
+	{
+		ID:           "merchant-4",
+		BusinessName: "Green Bowl",
+		StoreInfo:    domain.StoreInfo{Name: "Green Bowl", Category: "healthy"},
+		Status:       domain.MerchantStatusPending,
+		CreatedAt:    time.Now().AddDate(0, 0, -3),
+	},
 }
 
-func (m *mockUserService) GetDrivers(limit, offset int) ([]domain.DriverInfo, error) {
-	return []domain.DriverInfo{
-		{
-			ID: "driver-1",
-			Profile: domain.DriverProfile{
-				FirstName: "Mike",
-				LastName:  "Wilson",
-				Phone:     "+1234567892",
-			},
-			Status:    domain.DriverStatusActive,
-			CreatedAt: time.Now().AddDate(0, -2, 0),
+var mockDrivers = []domain.DriverInfo{
+	{
+		ID: "driver-1",
+		Profile: domain.DriverProfile{
+			FirstName: "Mike",
+			LastName:  "Wilson",
+			Phone:     "+1234567892",
 		},
+		Status:      domain.DriverStatusActive,
+		Performance: domain.PerformanceInfo{Rating: 4.7, TotalDeliveries: 1240},
+		CreatedAt:   time.Now().AddDate(0, -2, 0),
+	},
+	{
+		ID:          "driver-2",
+		Profile:     domain.DriverProfile{FirstName: "Sara", LastName: "Lopez", Phone: "+1234567893"},
+		Status:      domain.DriverStatusActive,
+		Performance: domain.PerformanceInfo{Rating: 4.9, TotalDeliveries: 310},
+		CreatedAt:   time.Now().AddDate(0, -1, 0),
+	},
+	{
+		ID:          "driver-3",
+		Profile:     domain.DriverProfile{FirstName: "Tom", LastName: "Baker", Phone: "+1234567894"},
+		Status:      domain.DriverStatusSuspended,
+		Performance: domain.PerformanceInfo{Rating: 3.2, TotalDeliveries: 95},
+		CreatedAt:   time.Now().AddDate(0, -5, 0),
+	},
+}
+
+// GetMerchants filters, sorts and pages the fixtures the way the user service
+// does in SQL
+func (m *mockUserService) GetMerchants(req domain.MerchantSearchRequest) (*domain.MerchantPage, error) {
+	query := strings.ToLower(req.Query)
+	var matches []domain.MerchantInfo
+	for _, merchant := range mockMerchants {
+		if req.Status != "" && merchant.Status != req.Status {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(merchant.BusinessName), query) &&
+			!strings.Contains(strings.ToLower(merchant.StoreInfo.Name), query) {
+			continue
+		}
+		matches = append(matches, merchant)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if req.SortOrder == domain.SortDesc {
+			a, b = b, a
+		}
+		switch req.SortBy {
+		case domain.SortByName:
+			return strings.ToLower(a.BusinessName) < strings.ToLower(b.BusinessName)
+		case domain.SortByRating:
+			return a.StoreInfo.Rating < b.StoreInfo.Rating
+		case domain.SortByOrderCount:
+			return a.StoreInfo.OrderCount < b.StoreInfo.OrderCount
+		default:
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+	})
+
+	start, end := pageBounds(len(matches), req.Limit, req.Offset)
+	return &domain.MerchantPage{
+		Merchants: matches[start:end],
+		Total:     int64(len(matches)),
+		Limit:     req.Limit,
+		Offset:    req.Offset,
+	}, nil
+}
+
+func (m *mockUserService) GetDrivers(req domain.DriverSearchRequest) (*domain.DriverPage, error) {
+	query := strings.ToLower(req.Query)
+	var matches []domain.DriverInfo
+	for _, driver := range mockDrivers {
+		if req.Status != "" && driver.Status != req.Status {
+			continue
+		}
+		name := strings.ToLower(driver.Profile.FirstName + " " + driver.Profile.LastName)
+		if query != "" && !strings.Contains(name, query) && !strings.Contains(driver.Profile.Phone, query) {
+			continue
+		}
+		matches = append(matches, driver)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if req.SortOrder == domain.SortDesc {
+			a, b = b, a
+		}
+		switch req.SortBy {
+		case domain.SortByName:
+			return strings.ToLower(a.Profile.FirstName+" "+a.Profile.LastName) < strings.ToLower(b.Profile.FirstName+" "+b.Profile.LastName)
+		case domain.SortByRating:
+			return a.Performance.Rating < b.Performance.Rating
+		case domain.SortByTotalDeliveries:
+			return a.Performance.TotalDeliveries < b.Performance.TotalDeliveries
+		default:
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+	})
+
+	start, end := pageBounds(len(matches), req.Limit, req.Offset)
+	return &domain.DriverPage{
+		Drivers: matches[start:end],
+		Total:   int64(len(matches)),
+		Limit:   req.Limit,
+		Offset:  req.Offset,
 	}, nil
 }
 
+// pageBounds clamps a limit/offset window to n items
+func pageBounds(n, limit, offset int) (int, int) {
+	start := offset
+	if start > n {
+		start = n
+	}
+	end := start + limit
+	if limit <= 0 || end > n {
+		end = n
+	}
+	return start, end
+}
+
 // Mock OrderService
 type mockOrderService struct{}
 
@@ -121,6 +251,31 @@ func (m *mockOrderService) GetTotalOrdersCount() (int, error) {
 	return 12547, nil
 }
 
+func (m *mockOrderService) GetUserOrderEvents(userID string) ([]domain.TimelineEvent, error) {
+	placedAt := time.Now().Add(-2 * time.Hour)
+	total := 24.90
+	return []domain.TimelineEvent{
+		{Type: "order_placed", OrderID: "order-1", ResourceID: "order-1", Description: "Order placed", Amount: &total, OccurredAt: placedAt},
+		{Type: "order_delivered", OrderID: "order-1", ResourceID: "order-1", Description: "Order delivered", OccurredAt: placedAt.Add(45 * time.Minute)},
+	}, nil
+}
+
+// Mock DeliveryService
+type mockDeliveryService struct{}
+
+func NewMockDeliveryService() domain.DeliveryService {
+	return &mockDeliveryService{}
+}
+
+func (m *mockDeliveryService) GetUserDeliveryEvents(userID string) ([]domain.TimelineEvent, error) {
+	placedAt := time.Now().Add(-2 * time.Hour)
+	return []domain.TimelineEvent{
+		{Type: "delivery_assigned", OrderID: "order-1", ResourceID: "delivery-1", Description: "Driver assigned", OccurredAt: placedAt.Add(5 * time.Minute)},
+		{Type: "delivery_picked_up", OrderID: "order-1", ResourceID: "delivery-1", Description: "Order picked up", OccurredAt: placedAt.Add(25 * time.Minute)},
+		{Type: "delivery_delivered", OrderID: "order-1", ResourceID: "delivery-1", Description: "Order handed over", OccurredAt: placedAt.Add(45 * time.Minute)},
+	}, nil
+}
+
 // Mock PaymentService
 type mockPaymentService struct{}
 
@@ -156,6 +311,14 @@ func (m *mockPaymentService) GetAverageOrderValue() (float64, error) {
 	return 38.75, nil
 }
 
+func (m *mockPaymentService) GetUserPaymentEvents(userID string) ([]domain.TimelineEvent, error) {
+	placedAt := time.Now().Add(-2 * time.Hour)
+	amount := 24.90
+	return []domain.TimelineEvent{
+		{Type: "payment", OrderID: "order-1", ResourceID: "txn-1", Description: "Card payment completed", Amount: &amount, OccurredAt: placedAt.Add(time.Minute)},
+	}, nil
+}
+
 // Mock CatalogService
 type mockCatalogService struct{}
 
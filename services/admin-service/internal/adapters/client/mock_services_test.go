@@ -0,0 +1,94 @@
+package client
+
+import (
+	"testing"
+
+	"glovo-backend/services/admin-service/internal/domain"
+)
+
+func TestMockUserServiceGetMerchants(t *testing.T) {
+	users := NewMockUserService()
+
+	tests := []struct {
+		name      string
+		req       domain.MerchantSearchRequest
+		wantIDs   []string
+		wantTotal int64
+	}{
+		{name: "everyone", req: domain.MerchantSearchRequest{SortBy: domain.SortByCreatedAt, SortOrder: domain.SortDesc, Limit: 20},
+			wantIDs: []string{"merchant-4", "merchant-2", "merchant-1", "merchant-3"}, wantTotal: 4},
+		{name: "status filter", req: domain.MerchantSearchRequest{Status: domain.MerchantStatusActive, SortBy: domain.SortByName, SortOrder: domain.SortAsc, Limit: 20},
+			wantIDs: []string{"merchant-1", "merchant-2"}, wantTotal: 2},
+		{name: "store name search", req: domain.MerchantSearchRequest{Query: "EXPRESS", Limit: 20},
+			wantIDs: []string{"merchant-3"}, wantTotal: 1},
+		{name: "search and status", req: domain.MerchantSearchRequest{Query: "burger", Status: domain.MerchantStatusActive, Limit: 20},
+			wantTotal: 0},
+		{name: "page keeps the total", req: domain.MerchantSearchRequest{SortBy: domain.SortByRating, SortOrder: domain.SortDesc, Limit: 2, Offset: 1},
+			wantIDs: []string{"merchant-1", "merchant-3"}, wantTotal: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := users.GetMerchants(tt.req)
+			if err != nil {
+				t.Fatalf("GetMerchants: %v", err)
+			}
+			var ids []string
+			for _, merchant := range page.Merchants {
+				ids = append(ids, merchant.ID)
+			}
+			if page.Total != tt.wantTotal || !equalIDs(ids, tt.wantIDs) {
+				t.Fatalf("got %v of %d, want %v of %d", ids, page.Total, tt.wantIDs, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestMockUserServiceGetDrivers(t *testing.T) {
+	users := NewMockUserService()
+
+	tests := []struct {
+		name      string
+		req       domain.DriverSearchRequest
+		wantIDs   []string
+		wantTotal int64
+	}{
+		{name: "status filter", req: domain.DriverSearchRequest{Status: domain.DriverStatusActive, SortBy: domain.SortByRating, SortOrder: domain.SortDesc, Limit: 20},
+			wantIDs: []string{"driver-2", "driver-1"}, wantTotal: 2},
+		{name: "full name search", req: domain.DriverSearchRequest{Query: "sara lo", Limit: 20},
+			wantIDs: []string{"driver-2"}, wantTotal: 1},
+		{name: "phone search", req: domain.DriverSearchRequest{Query: "7894", Limit: 20},
+			wantIDs: []string{"driver-3"}, wantTotal: 1},
+		{name: "sorted by deliveries", req: domain.DriverSearchRequest{SortBy: domain.SortByTotalDeliveries, SortOrder: domain.SortAsc, Limit: 20},
+			wantIDs: []string{"driver-3", "driver-2", "driver-1"}, wantTotal: 3},
+		{name: "offset past the end", req: domain.DriverSearchRequest{Limit: 20, Offset: 10}, wantTotal: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := users.GetDrivers(tt.req)
+			if err != nil {
+				t.Fatalf("GetDrivers: %v", err)
+			}
+			var ids []string
+			for _, driver := range page.Drivers {
+				ids = append(ids, driver.ID)
+			}
+			if page.Total != tt.wantTotal || !equalIDs(ids, tt.wantIDs) {
+				t.Fatalf("got %v of %d, want %v of %d", ids, page.Total, tt.wantIDs, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func equalIDs(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -6,6 +6,7 @@ import (
 	"glovo-backend/services/admin-service/internal/domain"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type adminRepository struct {
@@ -38,6 +39,75 @@ func (r *adminRepository) GetByEmail(email string) (*domain.Admin, error) {
 	return &admin, nil
 }
 
+func (r *adminRepository) GetByLoginChallenge(challenge string) (*domain.Admin, error) {
+	var admin domain.Admin
+	err := r.db.Where("login_challenge = ?", challenge).First(&admin).Error
+	if err != nil {
+		return nil, err
+	}
+	return &admin, nil
+}
+
+func (r *adminRepository) ClaimLoginAttempt(challenge string, maxAttempts int) (bool, error) {
+	result := r.db.Model(&domain.Admin{}).
+		Where("login_challenge = ? AND login_challenge_attempts < ?", challenge, maxAttempts).
+		Update("login_challenge_attempts", gorm.Expr("login_challenge_attempts + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *adminRepository) ClearLoginChallenge(id, challenge string) (bool, error) {
+	result := r.db.Model(&domain.Admin{}).
+		Where("id = ? AND login_challenge = ?", id, challenge).
+		Updates(map[string]interface{}{
+			"login_challenge":            "",
+			"login_challenge_expires_at": nil,
+			"login_challenge_attempts":   0,
+			"updated_at":                 time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *adminRepository) ClaimTOTPStep(id string, step int64) (bool, error) {
+	result := r.db.Model(&domain.Admin{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *adminRepository) UseBackupCode(id, hash string) (bool, error) {
+	used := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var admin domain.Admin
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&admin).Error; err != nil {
+			return err
+		}
+		for i, code := range admin.BackupCodes {
+			if code == hash {
+				admin.BackupCodes = append(admin.BackupCodes[:i:i], admin.BackupCodes[i+1:]...)
+				used = true
+				break
+			}
+		}
+		if !used {
+			return nil
+		}
+		return tx.Model(&admin).Select("backup_codes").Updates(&admin).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return used, nil
+}
+
 func (r *adminRepository) Update(admin *domain.Admin) error {
 	return r.db.Save(admin).Error
 }
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"glovo-backend/services/admin-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestLoginChallengeClaims(t *testing.T) {
+	db := testDB(t)
+	repo := NewAdminRepository(db)
+
+	expiresAt := time.Now().Add(time.Minute)
+	admin := &domain.Admin{
+		ID: uuid.New().String(), Email: uuid.New().String() + "@glovo.test", Password: "hash", IsActive: true,
+		TOTPLastStep: 100, BackupCodes: []string{"code-1", "code-2"},
+		LoginChallenge: uuid.New().String(), LoginChallengeExpiresAt: &expiresAt,
+	}
+	if err := repo.Create(admin); err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+	reload := func() *domain.Admin {
+		t.Helper()
+		got, err := repo.GetByID(admin.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		return got
+	}
+
+	// Attempts stop counting at the limit
+	for i, want := range []bool{true, true, false} {
+		claimed, err := repo.ClaimLoginAttempt(admin.LoginChallenge, 2)
+		if err != nil {
+			t.Fatalf("ClaimLoginAttempt: %v", err)
+		}
+		if claimed != want {
+			t.Fatalf("attempt %d claimed = %v, want %v", i+1, claimed, want)
+		}
+	}
+	if got := reload().LoginChallengeAttempts; got != 2 {
+		t.Fatalf("attempts = %d, want 2", got)
+	}
+
+	// A TOTP step is used once, and never one older than the last
+	for _, tt := range []struct {
+		step int64
+		want bool
+	}{{101, true}, {101, false}, {100, false}, {103, true}} {
+		claimed, err := repo.ClaimTOTPStep(admin.ID, tt.step)
+		if err != nil {
+			t.Fatalf("ClaimTOTPStep: %v", err)
+		}
+		if claimed != tt.want {
+			t.Fatalf("step %d claimed = %v, want %v", tt.step, claimed, tt.want)
+		}
+	}
+	if got := reload().TOTPLastStep; got != 103 {
+		t.Fatalf("last step = %d, want 103", got)
+	}
+
+	// A backup code is used once
+	for _, want := range []bool{true, false} {
+		used, err := repo.UseBackupCode(admin.ID, "code-1")
+		if err != nil {
+			t.Fatalf("UseBackupCode: %v", err)
+		}
+		if used != want {
+			t.Fatalf("backup code used = %v, want %v", used, want)
+		}
+	}
+	if got := reload().BackupCodes; !reflect.DeepEqual(got, []string{"code-2"}) {
+		t.Fatalf("backup codes = %v, want [code-2]", got)
+	}
+
+	// The challenge completes once
+	for _, want := range []bool{true, false} {
+		cleared, err := repo.ClearLoginChallenge(admin.ID, admin.LoginChallenge)
+		if err != nil {
+			t.Fatalf("ClearLoginChallenge: %v", err)
+		}
+		if cleared != want {
+			t.Fatalf("challenge cleared = %v, want %v", cleared, want)
+		}
+	}
+	if got := reload(); got.LoginChallenge != "" || got.LoginChallengeExpiresAt != nil || got.LoginChallengeAttempts != 0 {
+		t.Fatalf("challenge left as %q, expires %v, %d attempts", got.LoginChallenge, got.LoginChallengeExpiresAt, got.LoginChallengeAttempts)
+	}
+}
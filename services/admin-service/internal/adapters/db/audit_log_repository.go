@@ -38,6 +38,38 @@ func (r *auditLogRepository) GetByResource(resource string, limit, offset int) (
 	return logs, err
 }
 
+// Search returns the page of logs matching the filter, newest first, and the total match count
+func (r *auditLogRepository) Search(filter domain.AuditLogFilter) ([]domain.AuditLog, int64, error) {
+	query := r.db.Model(&domain.AuditLog{})
+	if filter.AdminID != "" {
+		query = query.Where("admin_id = ?", filter.AdminID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Resource != "" {
+		query = query.Where("resource = ?", filter.Resource)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("created_at >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("created_at < ?", *filter.EndDate)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []domain.AuditLog
+	err := query.Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&logs).Error
+	return logs, total, err
+}
+
 func (r *auditLogRepository) List(limit, offset int) ([]domain.AuditLog, error) {
 	var logs []domain.AuditLog
 	err := r.db.Order("created_at DESC").
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"glovo-backend/services/admin-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestAuditLogSearch(t *testing.T) {
+	db := testDB(t)
+	repo := NewAuditLogRepository(db)
+
+	// Fresh admin IDs keep rows already in the database out of the results
+	alice, bob := uuid.New().String(), uuid.New().String()
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC) }
+	logs := []domain.AuditLog{
+		{AdminID: alice, Action: "suspend_user", Resource: "user", ResourceID: "user-1", CreatedAt: day(1)},
+		{AdminID: alice, Action: "suspend_user", Resource: "user", ResourceID: "user-2", CreatedAt: day(5)},
+		{AdminID: alice, Action: "update_config", Resource: "config", ResourceID: "commission_rate", CreatedAt: day(5).Add(time.Hour)},
+		{AdminID: bob, Action: "suspend_user", Resource: "user", ResourceID: "user-3", CreatedAt: day(10)},
+	}
+	for i := range logs {
+		logs[i].ID = uuid.New().String()
+		if err := repo.Create(&logs[i]); err != nil {
+			t.Fatalf("create log: %v", err)
+		}
+	}
+
+	start, end := day(2), day(10)
+	tests := []struct {
+		name      string
+		filter    domain.AuditLogFilter
+		wantTotal int64
+		wantIDs   []string
+	}{
+		{name: "by admin", filter: domain.AuditLogFilter{AdminID: alice, Limit: 20}, wantTotal: 3, wantIDs: []string{"commission_rate", "user-2", "user-1"}},
+		{name: "admin and action", filter: domain.AuditLogFilter{AdminID: alice, Action: "suspend_user", Limit: 20}, wantTotal: 2, wantIDs: []string{"user-2", "user-1"}},
+		{name: "admin and resource", filter: domain.AuditLogFilter{AdminID: alice, Resource: "config", Limit: 20}, wantTotal: 1, wantIDs: []string{"commission_rate"}},
+		{name: "admin, action and dates", filter: domain.AuditLogFilter{AdminID: alice, Action: "suspend_user", StartDate: &start, EndDate: &end, Limit: 20}, wantTotal: 1, wantIDs: []string{"user-2"}},
+		{name: "end date is exclusive", filter: domain.AuditLogFilter{AdminID: bob, StartDate: &start, EndDate: &end, Limit: 20}, wantTotal: 0},
+		{name: "no match", filter: domain.AuditLogFilter{AdminID: bob, Action: "update_config", Limit: 20}, wantTotal: 0},
+		{name: "page keeps the total", filter: domain.AuditLogFilter{AdminID: alice, Limit: 1, Offset: 1}, wantTotal: 3, wantIDs: []string{"user-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, total, err := repo.Search(tt.filter)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Fatalf("total = %d, want %d", total, tt.wantTotal)
+			}
+			var ids []string
+			for _, log := range found {
+				ids = append(ids, log.ResourceID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("found %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Fatalf("found %v, want %v", ids, tt.wantIDs)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -23,52 +24,72 @@ func NewAdminHandler(adminService domain.AdminService) *AdminHandler {
 }
 
 func (h *AdminHandler) SetupRoutes(router *gin.RouterGroup) {
-	// Public authentication routes
-	auth := router.Group("/auth")
+	// Public authentication routes, throttled per IP to slow down password
+	// and two-factor code guessing
+	authRoutes := router.Group("/auth")
+	authRoutes.Use(middleware.RateLimit(middleware.RateLimitConfig{Rate: 10.0 / 60, Burst: 10}))
 	{
-		auth.POST("/login", h.login)
+		authRoutes.POST("/login", h.login)
+		authRoutes.POST("/login/verify", h.verifyTwoFactorLogin)
 	}
 
 	// Protected admin routes
 	admin := router.Group("/admin")
+	admin.Use(h.auditTrail())
 	admin.Use(middleware.AuthMiddleware())
+	admin.Use(middleware.RequireRole(auth.RoleAdmin))
+	admin.Use(captureAuditBody())
 	{
 		// Profile management
 		admin.GET("/profile", h.getProfile)
 
-		// Admin management (super admin only)
+		// Two-factor authentication for the current admin
+		twoFactor := admin.Group("/2fa")
+		{
+			twoFactor.POST("/enroll", h.enrollTwoFactor)
+			twoFactor.POST("/confirm", h.confirmTwoFactor)
+			twoFactor.POST("/disable", h.disableTwoFactor)
+		}
+
+		// Admin management; admins can always edit their own profile
 		adminMgmt := admin.Group("/admins")
 		{
-			adminMgmt.POST("/", h.createAdmin)
-			adminMgmt.GET("/", h.listAdmins)
+			adminMgmt.POST("/", h.requirePermission(domain.PermissionManageAdmins), h.createAdmin)
+			adminMgmt.GET("/", h.requirePermission(domain.PermissionViewAdmins), h.listAdmins)
 			adminMgmt.PUT("/:id", h.updateAdmin)
-			adminMgmt.DELETE("/:id", h.deleteAdmin)
+			adminMgmt.DELETE("/:id", h.requirePermission(domain.PermissionManageAdmins), h.deleteAdmin)
 		}
 
 		// User management
 		users := admin.Group("/users")
 		{
-			users.GET("/", h.getUsers)
-			users.GET("/:id", h.getUser)
-			users.PUT("/:id/status", h.updateUserStatus)
-			users.POST("/:id/suspend", h.suspendUser)
-			users.POST("/:id/reactivate", h.reactivateUser)
+			users.GET("/", h.requirePermission(domain.PermissionViewUsers), h.getUsers)
+			users.GET("/:id", h.requirePermission(domain.PermissionViewUsers), h.getUser)
+			users.GET("/:id/timeline", h.requirePermission(domain.PermissionViewUsers), h.getUserTimeline)
+			users.POST("/bulk-status", h.requirePermission(domain.PermissionManageUsers), h.bulkUpdateUserStatus)
+			users.PUT("/:id/status", h.requirePermission(domain.PermissionManageUsers), h.updateUserStatus)
+			users.POST("/:id/suspend", h.requirePermission(domain.PermissionManageUsers), h.suspendUser)
+			users.POST("/:id/reactivate", h.requirePermission(domain.PermissionManageUsers), h.reactivateUser)
+			users.POST("/:id/impersonate", h.requireSuperAdmin(), h.requirePermission(domain.PermissionImpersonate), h.impersonateUser)
 		}
 
 		// Merchant management
 		merchants := admin.Group("/merchants")
+		merchants.Use(h.requirePermission(domain.PermissionViewUsers))
 		{
 			merchants.GET("/", h.getMerchants)
 		}
 
 		// Driver management
 		drivers := admin.Group("/drivers")
+		drivers.Use(h.requirePermission(domain.PermissionViewUsers))
 		{
 			drivers.GET("/", h.getDrivers)
 		}
 
 		// Platform analytics
 		analytics := admin.Group("/analytics")
+		analytics.Use(h.requirePermission(domain.PermissionViewAnalytics))
 		{
 			analytics.GET("/platform", h.getPlatformStats)
 			analytics.GET("/revenue", h.getRevenueStats)
@@ -78,13 +99,14 @@ func (h *AdminHandler) SetupRoutes(router *gin.RouterGroup) {
 		// System configuration
 		config := admin.Group("/config")
 		{
-			config.GET("/", h.getAllSystemConfigs)
-			config.GET("/:key", h.getSystemConfig)
-			config.PUT("/:key", h.setSystemConfig)
+			config.GET("/", h.requirePermission(domain.PermissionViewConfig), h.getAllSystemConfigs)
+			config.GET("/:key", h.requirePermission(domain.PermissionViewConfig), h.getSystemConfig)
+			config.PUT("/:key", h.requirePermission(domain.PermissionManageConfig), h.setSystemConfig)
 		}
 
 		// Audit logs
 		audit := admin.Group("/audit")
+		audit.Use(h.requirePermission(domain.PermissionViewAudit))
 		{
 			audit.GET("/", h.getAuditLogs)
 			audit.GET("/resource/:resource", h.getResourceAuditLogs)
@@ -92,6 +114,57 @@ func (h *AdminHandler) SetupRoutes(router *gin.RouterGroup) {
 	}
 }
 
+// requirePermission rejects requests from admins that are inactive or lack the permission
+func (h *AdminHandler) requirePermission(permission domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin, err := h.adminService.GetProfile(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin not found"})
+			c.Abort()
+			return
+		}
+
+		if !admin.IsActive || !admin.HasPermission(permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requireSuperAdmin rejects requests from admins that are inactive or not super admins
+func (h *AdminHandler) requireSuperAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin, err := h.adminService.GetProfile(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin not found"})
+			c.Abort()
+			return
+		}
+
+		if !admin.IsActive || admin.Role != domain.RoleSuperAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// errorStatus maps service errors to HTTP status codes
+func errorStatus(err error) int {
+	if errors.Is(err, domain.ErrInsufficientPermissions) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, domain.ErrInvalidConfigValue) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 // @Summary Admin login
 // @Description Authenticate admin user
 // @Tags auth
@@ -101,6 +174,7 @@ func (h *AdminHandler) SetupRoutes(router *gin.RouterGroup) {
 // @Success 200 {object} domain.AdminLoginResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /auth/login [post]
 func (h *AdminHandler) login(c *gin.Context) {
 	var req domain.AdminLoginRequest
@@ -118,6 +192,118 @@ func (h *AdminHandler) login(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// @Summary Verify two-factor login
+// @Description Complete a login that returned requires_2fa with a TOTP code or a backup code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body domain.VerifyTwoFactorRequest true "Challenge token and code"
+// @Success 200 {object} domain.AdminLoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/login/verify [post]
+func (h *AdminHandler) verifyTwoFactorLogin(c *gin.Context) {
+	var req domain.VerifyTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.adminService.VerifyTwoFactorLogin(req)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Enroll in two-factor authentication
+// @Description Generate a TOTP secret for the current admin; confirm it with a code to enable two-factor login
+// @Tags admin
+// @Produce json
+// @Success 200 {object} domain.TwoFactorEnrollment
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/2fa/enroll [post]
+func (h *AdminHandler) enrollTwoFactor(c *gin.Context) {
+	adminID := c.GetString("user_id")
+
+	enrollment, err := h.adminService.EnrollTwoFactor(adminID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// @Summary Confirm two-factor authentication
+// @Description Enable two-factor login with a code from the enrolled secret; returns single-use backup codes
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body domain.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} domain.TwoFactorBackupCodes
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/2fa/confirm [post]
+func (h *AdminHandler) confirmTwoFactor(c *gin.Context) {
+	adminID := c.GetString("user_id")
+
+	var req domain.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.adminService.ConfirmTwoFactor(adminID, req.Code)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor login for the current admin; requires a TOTP or backup code
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body domain.TwoFactorCodeRequest true "TOTP or backup code"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/2fa/disable [post]
+func (h *AdminHandler) disableTwoFactor(c *gin.Context) {
+	adminID := c.GetString("user_id")
+
+	var req domain.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.adminService.DisableTwoFactor(adminID, req.Code); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
 // @Summary Get admin profile
 // @Description Get current admin's profile
 // @Tags admin
@@ -160,7 +346,7 @@ func (h *AdminHandler) createAdmin(c *gin.Context) {
 
 	admin, err := h.adminService.CreateAdmin(adminID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -188,7 +374,7 @@ func (h *AdminHandler) listAdmins(c *gin.Context) {
 
 	admins, err := h.adminService.ListAdmins(adminID, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -219,7 +405,7 @@ func (h *AdminHandler) updateAdmin(c *gin.Context) {
 
 	admin, err := h.adminService.UpdateAdmin(adminID, targetAdminID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -241,7 +427,7 @@ func (h *AdminHandler) deleteAdmin(c *gin.Context) {
 
 	err := h.adminService.DeleteAdmin(adminID, targetAdminID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -304,6 +490,27 @@ func (h *AdminHandler) getUser(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// @Summary Get user timeline
+// @Description Get the user's order, delivery and payment events in one chronological timeline. Events from a service that can't be reached are left out and named in notes.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} domain.UserTimeline
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/users/{id}/timeline [get]
+func (h *AdminHandler) getUserTimeline(c *gin.Context) {
+	userID := c.Param("id")
+
+	timeline, err := h.adminService.GetUserTimeline(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, timeline)
+}
+
 // @Summary Update user status
 // @Description Update user account status
 // @Tags users
@@ -390,26 +597,110 @@ func (h *AdminHandler) reactivateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User reactivated successfully"})
 }
 
+// @Summary Bulk update user status
+// @Description Apply one status to many users. Every user is checked first; if any is invalid or an update fails, no user keeps the new status.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body domain.BulkUpdateUserStatusRequest true "User IDs and target status"
+// @Success 200 {object} domain.BulkUserStatusResponse
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} domain.BulkUserStatusResponse
+// @Security BearerAuth
+// @Router /admin/users/bulk-status [post]
+func (h *AdminHandler) bulkUpdateUserStatus(c *gin.Context) {
+	adminID := c.GetString("user_id")
+
+	var req domain.BulkUpdateUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.adminService.BulkUpdateUserStatus(adminID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if !response.Applied {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, response)
+}
+
+// @Summary Impersonate user
+// @Description Mint a 15-minute token acting as the user, carrying an impersonated_by claim. Admin accounts can't be impersonated.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body domain.ImpersonateUserRequest true "Reason for impersonation"
+// @Success 200 {object} domain.ImpersonationToken
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/users/{id}/impersonate [post]
+func (h *AdminHandler) impersonateUser(c *gin.Context) {
+	adminID := c.GetString("user_id")
+	userID := c.Param("id")
+
+	var req domain.ImpersonateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.adminService.ImpersonateUser(adminID, userID, req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, domain.ErrInsufficientPermissions) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
 // @Summary Get merchants
-// @Description Get list of merchants
+// @Description Get a filtered, sorted page of merchants with the total number of matches
 // @Tags merchants
 // @Produce json
+// @Param search query string false "Matches business or store name"
+// @Param status query string false "Merchant status"
+// @Param sort_by query string false "Sort field" Enums(name, created_at, rating, order_count) default(created_at)
+// @Param sort_order query string false "Sort order" Enums(asc, desc) default(desc)
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
-// @Success 200 {array} domain.MerchantInfo
+// @Success 200 {object} domain.MerchantPage
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Security BearerAuth
 // @Router /admin/merchants [get]
 func (h *AdminHandler) getMerchants(c *gin.Context) {
+	req := domain.MerchantSearchRequest{
+		Query:     c.Query("search"),
+		Status:    domain.UserStatus(c.Query("status")),
+		SortBy:    c.Query("sort_by"),
+		SortOrder: c.Query("sort_order"),
+	}
+
 	limitStr := c.DefaultQuery("limit", "20")
 	offsetStr := c.DefaultQuery("offset", "0")
 
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
+	req.Limit, _ = strconv.Atoi(limitStr)
+	req.Offset, _ = strconv.Atoi(offsetStr)
 
-	merchants, err := h.adminService.GetMerchants(limit, offset)
+	merchants, err := h.adminService.GetMerchants(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidSort) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -417,25 +708,41 @@ func (h *AdminHandler) getMerchants(c *gin.Context) {
 }
 
 // @Summary Get drivers
-// @Description Get list of drivers
+// @Description Get a filtered, sorted page of drivers with the total number of matches
 // @Tags drivers
 // @Produce json
+// @Param search query string false "Matches driver name or phone"
+// @Param status query string false "Driver status"
+// @Param sort_by query string false "Sort field" Enums(name, created_at, rating, total_deliveries) default(created_at)
+// @Param sort_order query string false "Sort order" Enums(asc, desc) default(desc)
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
-// @Success 200 {array} domain.DriverInfo
+// @Success 200 {object} domain.DriverPage
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Security BearerAuth
 // @Router /admin/drivers [get]
 func (h *AdminHandler) getDrivers(c *gin.Context) {
+	req := domain.DriverSearchRequest{
+		Query:     c.Query("search"),
+		Status:    domain.UserStatus(c.Query("status")),
+		SortBy:    c.Query("sort_by"),
+		SortOrder: c.Query("sort_order"),
+	}
+
 	limitStr := c.DefaultQuery("limit", "20")
 	offsetStr := c.DefaultQuery("offset", "0")
 
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
+	req.Limit, _ = strconv.Atoi(limitStr)
+	req.Offset, _ = strconv.Atoi(offsetStr)
 
-	drivers, err := h.adminService.GetDrivers(limit, offset)
+	drivers, err := h.adminService.GetDrivers(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidSort) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -564,7 +871,7 @@ func (h *AdminHandler) getSystemConfig(c *gin.Context) {
 }
 
 // @Summary Set system config
-// @Description Set system configuration value
+// @Description Set a typed system configuration value; values that don't match the entry's type or validation are rejected
 // @Tags config
 // @Accept json
 // @Produce json
@@ -590,7 +897,7 @@ func (h *AdminHandler) setSystemConfig(c *gin.Context) {
 
 	config, err := h.adminService.SetSystemConfig(adminID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -616,31 +923,61 @@ func (h *AdminHandler) getAllSystemConfigs(c *gin.Context) {
 }
 
 // @Summary Get audit logs
-// @Description Get audit logs
+// @Description Get audit logs, newest first; filters combine with AND
 // @Tags audit
 // @Produce json
+// @Param admin_id query string false "Acting admin ID"
+// @Param action query string false "Action, e.g. POST /api/v1/admin/users/:id/suspend"
+// @Param resource query string false "Resource type, e.g. user"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date, inclusive (YYYY-MM-DD)"
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
-// @Success 200 {array} domain.AuditLog
+// @Success 200 {object} domain.AuditLogPage
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Security BearerAuth
 // @Router /admin/audit [get]
 func (h *AdminHandler) getAuditLogs(c *gin.Context) {
-	adminID := c.GetString("user_id")
+	filter := domain.AuditLogFilter{
+		AdminID:  c.Query("admin_id"),
+		Action:   c.Query("action"),
+		Resource: c.Query("resource"),
+	}
 
 	limitStr := c.DefaultQuery("limit", "20")
 	offsetStr := c.DefaultQuery("offset", "0")
 
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
+	filter.Limit, _ = strconv.Atoi(limitStr)
+	filter.Offset, _ = strconv.Atoi(offsetStr)
+
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		startDate, err := time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format (YYYY-MM-DD)"})
+			return
+		}
+		filter.StartDate = &startDate
+	}
 
-	logs, err := h.adminService.GetAuditLogs(adminID, limit, offset)
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		endDate, err := time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format (YYYY-MM-DD)"})
+			return
+		}
+		// Include the whole end day
+		endDate = endDate.AddDate(0, 0, 1)
+		filter.EndDate = &endDate
+	}
+
+	page, err := h.adminService.GetAuditLogs(filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, logs)
+	c.JSON(http.StatusOK, page)
 }
 
 // @Summary Get resource audit logs
@@ -0,0 +1,263 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"glovo-backend/services/admin-service/internal/domain"
+	"glovo-backend/shared/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeAdminService struct {
+	domain.AdminService
+	admins      map[string]*domain.Admin
+	logged      []string // actions written to the audit log
+	audited     []domain.AuditLog
+	auditFilter *domain.AuditLogFilter
+	suspendErr  error
+}
+
+func (s *fakeAdminService) GetProfile(adminID string) (*domain.Admin, error) {
+	admin, ok := s.admins[adminID]
+	if !ok {
+		return nil, errors.New("admin not found")
+	}
+	return admin, nil
+}
+
+func (s *fakeAdminService) CreateAdmin(adminID string, req domain.CreateAdminRequest) (*domain.Admin, error) {
+	return &domain.Admin{ID: "admin-new", Email: req.Email, Role: req.Role}, nil
+}
+
+func (s *fakeAdminService) ListAdmins(adminID string, limit, offset int) ([]domain.Admin, error) {
+	return nil, nil
+}
+
+func (s *fakeAdminService) DeleteAdmin(adminID, targetAdminID string) error {
+	return nil
+}
+
+func (s *fakeAdminService) GetUsers(req domain.UserSearchRequest) ([]domain.UserInfo, error) {
+	return []domain.UserInfo{{ID: "user-1"}}, nil
+}
+
+func (s *fakeAdminService) SuspendUser(adminID, userID, reason string) error {
+	return s.suspendErr
+}
+
+func (s *fakeAdminService) SetSystemConfig(adminID string, req domain.SystemConfigRequest) (*domain.SystemConfig, error) {
+	return &domain.SystemConfig{Key: req.Key, Value: req.Value}, nil
+}
+
+func (s *fakeAdminService) LogAction(adminID, action, resource, resourceID string, details map[string]interface{}, ipAddress, userAgent string) error {
+	s.logged = append(s.logged, action)
+	s.audited = append(s.audited, domain.AuditLog{
+		AdminID: adminID, Action: action, Resource: resource, ResourceID: resourceID, Details: details, IPAddress: ipAddress, UserAgent: userAgent,
+	})
+	return nil
+}
+
+func newAdminRouter(t *testing.T, service *fakeAdminService) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+	router := gin.New()
+	NewAdminHandler(service).SetupRoutes(router.Group("/api/v1"))
+	return router
+}
+
+func adminRequest(t *testing.T, router *gin.Engine, adminID, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	token, err := auth.GenerateToken(adminID, auth.RoleAdmin)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSupportAdminPermissions(t *testing.T) {
+	service := &fakeAdminService{admins: map[string]*domain.Admin{
+		"support-1": {ID: "support-1", Role: domain.RoleSupport, IsActive: true},
+	}}
+	router := newAdminRouter(t, service)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "create admin", method: http.MethodPost, path: "/api/v1/admin/admins/",
+			body:       `{"email":"new@glovo.test","password":"password1","first_name":"New","last_name":"Admin","role":"support"}`,
+			wantStatus: http.StatusForbidden},
+		{name: "list admins", method: http.MethodGet, path: "/api/v1/admin/admins/", wantStatus: http.StatusForbidden},
+		{name: "delete admin", method: http.MethodDelete, path: "/api/v1/admin/admins/admin-2", wantStatus: http.StatusForbidden},
+		{name: "set system config", method: http.MethodPut, path: "/api/v1/admin/config/commission_rate", body: `{"key":"commission_rate","value":"0.2"}`, wantStatus: http.StatusForbidden},
+		{name: "list users", method: http.MethodGet, path: "/api/v1/admin/users/", wantStatus: http.StatusOK},
+		{name: "suspend user", method: http.MethodPost, path: "/api/v1/admin/users/user-1/suspend", body: `{"reason":"fraud"}`, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := adminRequest(t, router, "support-1", tt.method, tt.path, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func (s *fakeAdminService) ImpersonateUser(adminID, userID string, req domain.ImpersonateUserRequest) (*domain.ImpersonationToken, error) {
+	return &domain.ImpersonationToken{Token: "token", UserID: userID, ImpersonatedBy: adminID}, nil
+}
+
+func TestImpersonateRouteRequiresSuperAdmin(t *testing.T) {
+	service := &fakeAdminService{admins: map[string]*domain.Admin{
+		"super-1": {ID: "super-1", Role: domain.RoleSuperAdmin, IsActive: true},
+		"admin-1": {ID: "admin-1", Role: domain.RoleAdmin, Permissions: []string{string(domain.PermissionImpersonate)}, IsActive: true},
+		"admin-2": {ID: "admin-2", Role: domain.RoleAdmin, IsActive: true},
+	}}
+	router := newAdminRouter(t, service)
+
+	tests := []struct {
+		name       string
+		adminID    string
+		wantStatus int
+	}{
+		{name: "super admin", adminID: "super-1", wantStatus: http.StatusOK},
+		{name: "admin with the permission", adminID: "admin-1", wantStatus: http.StatusForbidden},
+		{name: "admin without it", adminID: "admin-2", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service.logged = nil
+			w := adminRequest(t, router, tt.adminID, http.MethodPost, "/api/v1/admin/users/customer-1/impersonate", `{"reason":"reproduce checkout bug"}`)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			// Rejected attempts are audited too, and each attempt once
+			if len(service.logged) != 1 || service.logged[0] != "impersonate_user" {
+				t.Fatalf("audit entries = %v, want one impersonate_user", service.logged)
+			}
+		})
+	}
+}
+
+func (s *fakeAdminService) GetAuditLogs(filter domain.AuditLogFilter) (*domain.AuditLogPage, error) {
+	s.auditFilter = &filter
+	return &domain.AuditLogPage{Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+func TestAuditLogsRouteFilters(t *testing.T) {
+	service := &fakeAdminService{admins: map[string]*domain.Admin{
+		"super-1": {ID: "super-1", Role: domain.RoleSuperAdmin, IsActive: true},
+	}}
+	router := newAdminRouter(t, service)
+
+	w := adminRequest(t, router, "super-1", http.MethodGet,
+		"/api/v1/admin/audit/?admin_id=admin-2&action=suspend_user&resource=user&start_date=2026-03-01&end_date=2026-03-31&limit=5&offset=10", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	filter := service.auditFilter
+	if filter.AdminID != "admin-2" || filter.Action != "suspend_user" || filter.Resource != "user" || filter.Limit != 5 || filter.Offset != 10 {
+		t.Fatalf("filter = %+v", filter)
+	}
+	// The end date covers the whole day
+	if !filter.StartDate.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) || !filter.EndDate.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("filter dates = %s to %s", filter.StartDate, filter.EndDate)
+	}
+
+	for _, query := range []string{"?start_date=March", "?end_date=2026-13-01"} {
+		service.auditFilter = nil
+		w := adminRequest(t, router, "super-1", http.MethodGet, "/api/v1/admin/audit/"+query, "")
+		if w.Code != http.StatusBadRequest || service.auditFilter != nil {
+			t.Fatalf("%s: status = %d, want %d: %s", query, w.Code, http.StatusBadRequest, w.Body.String())
+		}
+	}
+}
+
+func (s *fakeAdminService) BulkUpdateUserStatus(adminID string, req domain.BulkUpdateUserStatusRequest) (*domain.BulkUserStatusResponse, error) {
+	if !req.Status.IsValid() {
+		return nil, errors.New("invalid status")
+	}
+	response := &domain.BulkUserStatusResponse{Applied: true}
+	for _, userID := range req.UserIDs {
+		result := domain.BulkUserStatusResult{UserID: userID, Success: userID != "ghost"}
+		if !result.Success {
+			result.Error = "user not found"
+			response.Applied = false
+		}
+		response.Results = append(response.Results, result)
+	}
+	return response, nil
+}
+
+func TestBulkUserStatusRoute(t *testing.T) {
+	service := &fakeAdminService{admins: map[string]*domain.Admin{
+		"support-1": {ID: "support-1", Role: domain.RoleSupport, IsActive: true},
+	}}
+	router := newAdminRouter(t, service)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "applied", body: `{"user_ids":["user-1","user-2"],"status":"suspended","reason":"fraud"}`, wantStatus: http.StatusOK},
+		{name: "mixed batch", body: `{"user_ids":["user-1","ghost"],"status":"suspended"}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "no users", body: `{"user_ids":[],"status":"suspended"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown status", body: `{"user_ids":["user-1"],"status":"deleted"}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := adminRequest(t, router, "support-1", http.MethodPost, "/api/v1/admin/users/bulk-status", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func (s *fakeAdminService) VerifyTwoFactorLogin(req domain.VerifyTwoFactorRequest) (*domain.AdminLoginResponse, error) {
+	return nil, domain.ErrInvalidTwoFactorCode
+}
+
+func TestTwoFactorVerifyRateLimited(t *testing.T) {
+	router := newAdminRouter(t, &fakeAdminService{})
+	verify := func(remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login/verify", strings.NewReader(`{"challenge_token":"challenge","code":"000000"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < 10; i++ {
+		if code := verify("10.0.0.1:1234"); code != http.StatusUnauthorized {
+			t.Fatalf("guess %d: status = %d, want %d", i+1, code, http.StatusUnauthorized)
+		}
+	}
+	if code := verify("10.0.0.1:1234"); code != http.StatusTooManyRequests {
+		t.Fatalf("status after the burst = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := verify("10.0.0.2:1234"); code != http.StatusUnauthorized {
+		t.Fatalf("other client: status = %d, want %d", code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"glovo-backend/services/admin-service/internal/domain"
+)
+
+func TestAuditTrailRecordsSuspendUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		suspendErr error
+		wantStatus int
+	}{
+		{name: "suspended", wantStatus: http.StatusOK},
+		{name: "failed", suspendErr: errors.New("user service unavailable"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeAdminService{
+				admins:     map[string]*domain.Admin{"support-1": {ID: "support-1", Role: domain.RoleSupport, IsActive: true}},
+				suspendErr: tt.suspendErr,
+			}
+			router := newAdminRouter(t, service)
+
+			w := adminRequest(t, router, "support-1", http.MethodPost, "/api/v1/admin/users/user-1/suspend", `{"reason":"chargeback fraud"}`)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			if len(service.audited) != 1 {
+				t.Fatalf("%d audit entries, want 1", len(service.audited))
+			}
+			entry := service.audited[0]
+			if entry.AdminID != "support-1" || entry.Action != "POST /api/v1/admin/users/:id/suspend" || entry.Resource != "users" || entry.ResourceID != "user-1" {
+				t.Fatalf("entry = %+v", entry)
+			}
+			want := map[string]interface{}{
+				"method": http.MethodPost,
+				"path":   "/api/v1/admin/users/user-1/suspend",
+				"status": tt.wantStatus,
+				"body":   map[string]interface{}{"reason": "chargeback fraud"},
+			}
+			if !reflect.DeepEqual(entry.Details, want) {
+				t.Fatalf("details = %#v, want %#v", entry.Details, want)
+			}
+		})
+	}
+}
+
+func TestAuditTrail(t *testing.T) {
+	service := &fakeAdminService{admins: map[string]*domain.Admin{
+		"super-1": {ID: "super-1", Role: domain.RoleSuperAdmin, IsActive: true},
+	}}
+	router := newAdminRouter(t, service)
+
+	t.Run("reads are not audited", func(t *testing.T) {
+		service.audited = nil
+		if w := adminRequest(t, router, "super-1", http.MethodGet, "/api/v1/admin/users/", ""); w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		if len(service.audited) != 0 {
+			t.Fatalf("GET was audited: %+v", service.audited)
+		}
+	})
+
+	t.Run("secrets are redacted under the legacy action", func(t *testing.T) {
+		service.audited = nil
+		w := adminRequest(t, router, "super-1", http.MethodPost, "/api/v1/admin/admins/",
+			`{"email":"new@glovo.test","password":"hunter22","first_name":"New","last_name":"Admin","role":"support"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+		}
+		if len(service.audited) != 1 {
+			t.Fatalf("%d audit entries, want 1", len(service.audited))
+		}
+		entry := service.audited[0]
+		body := entry.Details["body"].(map[string]interface{})
+		if entry.Action != "create_admin" || entry.Resource != "admin" || body["password"] != redacted || body["email"] != "new@glovo.test" {
+			t.Fatalf("entry = %+v", entry)
+		}
+	})
+
+	t.Run("unauthenticated requests are recorded", func(t *testing.T) {
+		service.audited = nil
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/user-1/suspend", strings.NewReader(`{"reason":"fraud"}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+		if len(service.audited) != 1 || service.audited[0].AdminID != "" || service.audited[0].Details["status"] != http.StatusUnauthorized {
+			t.Fatalf("audited %+v", service.audited)
+		}
+		if _, ok := service.audited[0].Details["body"]; ok {
+			t.Fatalf("unauthenticated body was logged: %+v", service.audited[0].Details)
+		}
+	})
+
+	t.Run("unauthenticated bodies are never read", func(t *testing.T) {
+		service.audited = nil
+		body := `{"reason":"` + strings.Repeat("x", maxAuditedBody) + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/user-1/suspend", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+		if len(service.audited) != 1 || service.audited[0].Details["body"] != nil {
+			t.Fatalf("audited %+v", service.audited)
+		}
+	})
+
+	t.Run("oversized bodies are rejected", func(t *testing.T) {
+		service.audited = nil
+		body := `{"reason":"` + strings.Repeat("x", maxAuditedBody) + `"}`
+		w := adminRequest(t, router, "super-1", http.MethodPost, "/api/v1/admin/users/user-1/suspend", body)
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+		}
+		if len(service.audited) != 1 || service.audited[0].Details["body"] != nil {
+			t.Fatalf("audited %+v", service.audited)
+		}
+	})
+}
+
+func TestSanitizeBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want interface{}
+	}{
+		{name: "empty", body: "  "},
+		{name: "not JSON", body: "reason=fraud"},
+		{
+			name: "nested secrets",
+			body: `{"user":{"Password":"p","name":"Ana"},"api_token":"t","backup_codes":["a","b"],"items":[{"client_secret":"s"}]}`,
+			want: map[string]interface{}{
+				"user":         map[string]interface{}{"Password": redacted, "name": "Ana"},
+				"api_token":    redacted,
+				"backup_codes": redacted,
+				"items":        []interface{}{map[string]interface{}{"client_secret": redacted}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeBody([]byte(tt.body)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("sanitizeBody = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
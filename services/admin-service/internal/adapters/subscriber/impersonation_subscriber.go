@@ -0,0 +1,47 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+
+	"glovo-backend/services/admin-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// ImpersonationSubscriber writes requests made with impersonation tokens,
+// reported by every service, to the admin audit log
+type ImpersonationSubscriber struct {
+	adminService domain.AdminService
+}
+
+func NewImpersonationSubscriber(adminService domain.AdminService) *ImpersonationSubscriber {
+	return &ImpersonationSubscriber{adminService: adminService}
+}
+
+// Register subscribes to impersonated request events
+func (s *ImpersonationSubscriber) Register(bus events.Bus) error {
+	return bus.Subscribe(events.ImpersonatedRequest, s.handleImpersonatedRequest)
+}
+
+func (s *ImpersonationSubscriber) handleImpersonatedRequest(ctx context.Context, event events.Event) error {
+	var payload events.ImpersonatedRequestPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	details := map[string]interface{}{
+		"impersonated_user_id": payload.UserID,
+		"role":                 payload.Role,
+		"service":              payload.Service,
+		"method":               payload.Method,
+		"path":                 payload.Path,
+		"status":               payload.Status,
+		"token_id":             payload.TokenID,
+		"occurred_at":          payload.OccurredAt,
+	}
+	err := s.adminService.LogAction(payload.AdminID, "impersonated_request", "users", payload.UserID, details, payload.IPAddress, payload.UserAgent)
+	if err != nil {
+		return fmt.Errorf("failed to audit impersonated request with token %s: %w", payload.TokenID, err)
+	}
+	return nil
+}
@@ -18,6 +18,7 @@ type adminService struct {
 	auditLogRepo     domain.AuditLogRepository
 	userService      domain.UserService
 	orderService     domain.OrderService
+	deliveryService  domain.DeliveryService
 	paymentService   domain.PaymentService
 	catalogService   domain.CatalogService
 	driverService    domain.DriverService
@@ -30,6 +31,7 @@ func NewAdminService(
 	auditLogRepo domain.AuditLogRepository,
 	userService domain.UserService,
 	orderService domain.OrderService,
+	deliveryService domain.DeliveryService,
 	paymentService domain.PaymentService,
 	catalogService domain.CatalogService,
 	driverService domain.DriverService,
@@ -41,6 +43,7 @@ func NewAdminService(
 		auditLogRepo:     auditLogRepo,
 		userService:      userService,
 		orderService:     orderService,
+		deliveryService:  deliveryService,
 		paymentService:   paymentService,
 		catalogService:   catalogService,
 		driverService:    driverService,
@@ -64,6 +67,16 @@ func (s *adminService) Login(req domain.AdminLoginRequest) (*domain.AdminLoginRe
 		return nil, errors.New("invalid credentials")
 	}
 
+	// The session is only issued once the second factor is verified
+	if admin.TwoFactorEnabled {
+		return s.startLoginChallenge(admin)
+	}
+
+	return s.issueSession(admin)
+}
+
+// issueSession generates the admin's JWT and records the login
+func (s *adminService) issueSession(admin *domain.Admin) (*domain.AdminLoginResponse, error) {
 	// Generate JWT token
 	token, err := auth.GenerateToken(admin.ID, auth.RoleAdmin)
 	if err != nil {
@@ -80,8 +93,8 @@ func (s *adminService) Login(req domain.AdminLoginRequest) (*domain.AdminLoginRe
 
 	return &domain.AdminLoginResponse{
 		Token:     token,
-		Admin:     *admin,
-		ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
+		Admin:     admin,
+		ExpiresAt: time.Now().Add(auth.AccessTokenTTL()).Unix(),
 	}, nil
 }
 
@@ -92,13 +105,13 @@ func (s *adminService) GetProfile(adminID string) (*domain.Admin, error) {
 // Admin management
 func (s *adminService) CreateAdmin(adminID string, req domain.CreateAdminRequest) (*domain.Admin, error) {
 	// Check if requesting admin has permission
-	requestingAdmin, err := s.adminRepo.GetByID(adminID)
+	requestingAdmin, err := s.authorize(adminID, domain.PermissionManageAdmins)
 	if err != nil {
 		return nil, err
 	}
 
-	if requestingAdmin.Role != domain.RoleSuperAdmin {
-		return nil, errors.New("insufficient permissions to create admin")
+	if err := validateGrants(requestingAdmin, nil, req.Role, req.Permissions); err != nil {
+		return nil, err
 	}
 
 	// Check if email already exists
@@ -129,12 +142,6 @@ func (s *adminService) CreateAdmin(adminID string, req domain.CreateAdminRequest
 		return nil, fmt.Errorf("failed to create admin: %w", err)
 	}
 
-	// Log the action
-	s.LogAction(adminID, "create_admin", "admin", admin.ID, map[string]interface{}{
-		"email": admin.Email,
-		"role":  admin.Role,
-	}, "", "")
-
 	// Clear password before returning
 	admin.Password = ""
 	return admin, nil
@@ -151,9 +158,13 @@ func (s *adminService) UpdateAdmin(adminID, targetAdminID string, req domain.Upd
 		return nil, err
 	}
 
-	// Permission check
-	if requestingAdmin.Role != domain.RoleSuperAdmin && adminID != targetAdminID {
-		return nil, errors.New("insufficient permissions")
+	// Permission check; admins without admins.manage may only edit their own name
+	canManage := requestingAdmin.IsActive && requestingAdmin.HasPermission(domain.PermissionManageAdmins)
+	if !canManage && adminID != targetAdminID {
+		return nil, domain.ErrInsufficientPermissions
+	}
+	if canManage && targetAdmin.Role == domain.RoleSuperAdmin && requestingAdmin.Role != domain.RoleSuperAdmin {
+		return nil, domain.ErrInsufficientPermissions
 	}
 
 	// Apply updates
@@ -163,14 +174,22 @@ func (s *adminService) UpdateAdmin(adminID, targetAdminID string, req domain.Upd
 	if req.LastName != nil {
 		targetAdmin.LastName = *req.LastName
 	}
-	if req.Role != nil && requestingAdmin.Role == domain.RoleSuperAdmin {
-		targetAdmin.Role = *req.Role
-	}
-	if req.Permissions != nil && requestingAdmin.Role == domain.RoleSuperAdmin {
-		targetAdmin.Permissions = req.Permissions
-	}
-	if req.IsActive != nil && requestingAdmin.Role == domain.RoleSuperAdmin {
-		targetAdmin.IsActive = *req.IsActive
+	if canManage {
+		role, permissions := targetAdmin.Role, []string(targetAdmin.Permissions)
+		if req.Role != nil {
+			role = *req.Role
+		}
+		if req.Permissions != nil {
+			permissions = req.Permissions
+		}
+		if err := validateGrants(requestingAdmin, targetAdmin, role, permissions); err != nil {
+			return nil, err
+		}
+		targetAdmin.Role, targetAdmin.Permissions = role, permissions
+
+		if req.IsActive != nil {
+			targetAdmin.IsActive = *req.IsActive
+		}
 	}
 
 	targetAdmin.UpdatedAt = time.Now()
@@ -179,49 +198,40 @@ func (s *adminService) UpdateAdmin(adminID, targetAdminID string, req domain.Upd
 		return nil, fmt.Errorf("failed to update admin: %w", err)
 	}
 
-	// Log the action
-	s.LogAction(adminID, "update_admin", "admin", targetAdminID, map[string]interface{}{
-		"changes": req,
-	}, "", "")
-
 	targetAdmin.Password = ""
 	return targetAdmin, nil
 }
 
 func (s *adminService) DeleteAdmin(adminID, targetAdminID string) error {
-	requestingAdmin, err := s.adminRepo.GetByID(adminID)
+	requestingAdmin, err := s.authorize(adminID, domain.PermissionManageAdmins)
 	if err != nil {
 		return err
 	}
 
-	if requestingAdmin.Role != domain.RoleSuperAdmin {
-		return errors.New("insufficient permissions")
-	}
-
 	if adminID == targetAdminID {
 		return errors.New("cannot delete yourself")
 	}
 
+	targetAdmin, err := s.adminRepo.GetByID(targetAdminID)
+	if err != nil {
+		return err
+	}
+	if targetAdmin.Role == domain.RoleSuperAdmin && requestingAdmin.Role != domain.RoleSuperAdmin {
+		return domain.ErrInsufficientPermissions
+	}
+
 	if err := s.adminRepo.Delete(targetAdminID); err != nil {
 		return fmt.Errorf("failed to delete admin: %w", err)
 	}
 
-	// Log the action
-	s.LogAction(adminID, "delete_admin", "admin", targetAdminID, nil, "", "")
-
 	return nil
 }
 
 func (s *adminService) ListAdmins(adminID string, limit, offset int) ([]domain.Admin, error) {
-	requestingAdmin, err := s.adminRepo.GetByID(adminID)
-	if err != nil {
+	if _, err := s.authorize(adminID, domain.PermissionViewAdmins); err != nil {
 		return nil, err
 	}
 
-	if requestingAdmin.Role != domain.RoleSuperAdmin && requestingAdmin.Role != domain.RoleAdmin {
-		return nil, errors.New("insufficient permissions")
-	}
-
 	admins, err := s.adminRepo.List(limit, offset)
 	if err != nil {
 		return nil, err
@@ -235,6 +245,56 @@ func (s *adminService) ListAdmins(adminID string, limit, offset int) ([]domain.A
 	return admins, nil
 }
 
+// authorize loads the admin and checks they are active and hold the permission
+func (s *adminService) authorize(adminID string, permission domain.Permission) (*domain.Admin, error) {
+	admin, err := s.adminRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !admin.IsActive || !admin.HasPermission(permission) {
+		return nil, domain.ErrInsufficientPermissions
+	}
+	return admin, nil
+}
+
+// validateGrants checks a role and extra permissions being assigned to an
+// admin, current being the admin's existing grants or nil for a new admin.
+// Admins can only hand out permissions they hold themselves, and only super
+// admins can create other super admins.
+func validateGrants(requestingAdmin, current *domain.Admin, role domain.AdminRole, permissions []string) error {
+	if !role.IsValid() {
+		return fmt.Errorf("invalid admin role %q", role)
+	}
+	if role == domain.RoleSuperAdmin && requestingAdmin.Role != domain.RoleSuperAdmin {
+		return domain.ErrInsufficientPermissions
+	}
+
+	for _, p := range permissions {
+		known := false
+		for _, permission := range domain.AllPermissions {
+			if domain.Permission(p) == permission {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown permission %q", p)
+		}
+	}
+
+	granted := &domain.Admin{Role: role, Permissions: permissions}
+	for _, permission := range domain.AllPermissions {
+		if !granted.HasPermission(permission) || (current != nil && current.HasPermission(permission)) {
+			continue
+		}
+		if !requestingAdmin.HasPermission(permission) {
+			return fmt.Errorf("%w: cannot grant %s", domain.ErrInsufficientPermissions, permission)
+		}
+	}
+	return nil
+}
+
 // User management
 func (s *adminService) GetUsers(req domain.UserSearchRequest) ([]domain.UserInfo, error) {
 	return s.userService.GetUsers(req)
@@ -249,21 +309,59 @@ func (s *adminService) UpdateUserStatus(adminID, userID string, req domain.Updat
 		return err
 	}
 
-	// Log the action
-	s.LogAction(adminID, "update_user_status", "user", userID, map[string]interface{}{
-		"status": req.Status,
-		"reason": req.Reason,
-	}, "", "")
-
 	return nil
 }
 
-func (s *adminService) GetMerchants(limit, offset int) ([]domain.MerchantInfo, error) {
-	return s.userService.GetMerchants(limit, offset)
+var (
+	merchantSortFields = map[string]bool{domain.SortByName: true, domain.SortByCreatedAt: true, domain.SortByRating: true, domain.SortByOrderCount: true}
+	driverSortFields   = map[string]bool{domain.SortByName: true, domain.SortByCreatedAt: true, domain.SortByRating: true, domain.SortByTotalDeliveries: true}
+)
+
+func (s *adminService) GetMerchants(req domain.MerchantSearchRequest) (*domain.MerchantPage, error) {
+	var err error
+	if req.SortBy, req.SortOrder, err = normalizeSort(req.SortBy, req.SortOrder, merchantSortFields); err != nil {
+		return nil, err
+	}
+	req.Limit, req.Offset = normalizePage(req.Limit, req.Offset)
+	return s.userService.GetMerchants(req)
 }
 
-func (s *adminService) GetDrivers(limit, offset int) ([]domain.DriverInfo, error) {
-	return s.userService.GetDrivers(limit, offset)
+func (s *adminService) GetDrivers(req domain.DriverSearchRequest) (*domain.DriverPage, error) {
+	var err error
+	if req.SortBy, req.SortOrder, err = normalizeSort(req.SortBy, req.SortOrder, driverSortFields); err != nil {
+		return nil, err
+	}
+	req.Limit, req.Offset = normalizePage(req.Limit, req.Offset)
+	return s.userService.GetDrivers(req)
+}
+
+// normalizeSort defaults to newest first and rejects unknown fields or orders
+func normalizeSort(sortBy, sortOrder string, fields map[string]bool) (string, string, error) {
+	if sortBy == "" {
+		sortBy = domain.SortByCreatedAt
+	}
+	if !fields[sortBy] {
+		return "", "", fmt.Errorf("%w: cannot sort by %q", domain.ErrInvalidSort, sortBy)
+	}
+
+	switch sortOrder {
+	case "":
+		sortOrder = domain.SortDesc
+	case domain.SortAsc, domain.SortDesc:
+	default:
+		return "", "", fmt.Errorf("%w: order must be asc or desc", domain.ErrInvalidSort)
+	}
+	return sortBy, sortOrder, nil
+}
+
+func normalizePage(limit, offset int) (int, int) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
 }
 
 func (s *adminService) SuspendUser(adminID, userID string, reason string) error {
@@ -282,6 +380,118 @@ func (s *adminService) ReactivateUser(adminID, userID string) error {
 	return s.UpdateUserStatus(adminID, userID, req)
 }
 
+// BulkUpdateUserStatus validates every user before changing any of them. If
+// an update fails part-way, users already updated are reverted to their
+// previous status so the batch applies all-or-nothing.
+func (s *adminService) BulkUpdateUserStatus(adminID string, req domain.BulkUpdateUserStatusRequest) (*domain.BulkUserStatusResponse, error) {
+	if !req.Status.IsValid() {
+		return nil, fmt.Errorf("invalid status %q", req.Status)
+	}
+
+	seen := make(map[string]bool, len(req.UserIDs))
+	results := make([]domain.BulkUserStatusResult, 0, len(req.UserIDs))
+	valid := true
+	for _, userID := range req.UserIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		result := domain.BulkUserStatusResult{UserID: userID}
+		if user, err := s.userService.GetUser(userID); err != nil {
+			result.Error = "user not found"
+			valid = false
+		} else {
+			result.PreviousStatus = user.Status
+		}
+		results = append(results, result)
+	}
+
+	if !valid {
+		return &domain.BulkUserStatusResponse{Applied: false, Results: results}, nil
+	}
+
+	update := domain.UpdateUserStatusRequest{Status: req.Status, Reason: req.Reason}
+	for i := range results {
+		if err := s.UpdateUserStatus(adminID, results[i].UserID, update); err != nil {
+			results[i].Error = err.Error()
+			s.revertUserStatuses(adminID, results[:i])
+			return &domain.BulkUserStatusResponse{Applied: false, Results: results}, nil
+		}
+		results[i].Success = true
+	}
+
+	for _, result := range results {
+		s.LogAction(adminID, "bulk_update_user_status", "user", result.UserID, map[string]interface{}{
+			"previous_status": result.PreviousStatus,
+			"status":          req.Status,
+			"reason":          req.Reason,
+		}, "", "")
+	}
+
+	return &domain.BulkUserStatusResponse{Applied: true, Results: results}, nil
+}
+
+// revertUserStatuses restores the previous status of users updated by a failed batch
+func (s *adminService) revertUserStatuses(adminID string, results []domain.BulkUserStatusResult) {
+	for i := range results {
+		revert := domain.UpdateUserStatusRequest{
+			Status: results[i].PreviousStatus,
+			Reason: "Reverted: bulk status update failed",
+		}
+		if err := s.UpdateUserStatus(adminID, results[i].UserID, revert); err != nil {
+			results[i].Error = fmt.Sprintf("failed to revert: %v", err)
+			continue
+		}
+		results[i].Success = false
+		results[i].Error = "reverted: batch was not applied"
+	}
+}
+
+// impersonationTTL bounds how long an impersonation token stays valid
+const impersonationTTL = 15 * time.Minute
+
+// ImpersonateUser mints a short-lived token acting as the user. Only super
+// admins holding the impersonate permission can, and admin accounts can't be
+// impersonated.
+func (s *adminService) ImpersonateUser(adminID, userID string, req domain.ImpersonateUserRequest) (*domain.ImpersonationToken, error) {
+	admin, err := s.authorize(adminID, domain.PermissionImpersonate)
+	if err != nil {
+		return nil, err
+	}
+	if admin.Role != domain.RoleSuperAdmin {
+		return nil, domain.ErrInsufficientPermissions
+	}
+
+	user, err := s.userService.GetUser(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Role == auth.RoleAdmin {
+		return nil, domain.ErrInsufficientPermissions
+	}
+	if _, err := s.adminRepo.GetByID(userID); err == nil {
+		return nil, domain.ErrInsufficientPermissions
+	}
+	if user.Status != domain.UserStatusActive {
+		return nil, fmt.Errorf("cannot impersonate a %s user", user.Status)
+	}
+
+	expiresAt := time.Now().Add(impersonationTTL)
+	token, err := auth.GenerateImpersonationToken(user.ID, user.Role, adminID, impersonationTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &domain.ImpersonationToken{
+		Token:          token,
+		UserID:         user.ID,
+		Role:           user.Role,
+		ImpersonatedBy: adminID,
+		ExpiresAt:      expiresAt.Unix(),
+	}, nil
+}
+
 // Platform analytics
 func (s *adminService) GetPlatformStats() (*domain.PlatformStats, error) {
 	// If analytics service is available, use it
@@ -299,14 +509,14 @@ func (s *adminService) GetPlatformStats() (*domain.PlatformStats, error) {
 	}
 
 	// Get merchant and driver counts
-	merchants, err := s.GetMerchants(1000, 0)
+	merchants, err := s.GetMerchants(domain.MerchantSearchRequest{Limit: 1})
 	if err == nil {
-		stats.TotalMerchants = len(merchants)
+		stats.TotalMerchants = int(merchants.Total)
 	}
 
-	drivers, err := s.GetDrivers(1000, 0)
+	drivers, err := s.GetDrivers(domain.DriverSearchRequest{Limit: 1})
 	if err == nil {
-		stats.TotalDrivers = len(drivers)
+		stats.TotalDrivers = int(drivers.Total)
 	}
 
 	// Get order stats
@@ -367,34 +577,63 @@ func (s *adminService) GetOrderStats(startDate, endDate time.Time) (map[string]i
 
 // System configuration
 func (s *adminService) GetSystemConfig(key string) (*domain.SystemConfig, error) {
-	return s.systemConfigRepo.Get(key)
+	config, err := s.systemConfigRepo.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return withTypedValue(config), nil
 }
 
 func (s *adminService) SetSystemConfig(adminID string, req domain.SystemConfigRequest) (*domain.SystemConfig, error) {
-	config := &domain.SystemConfig{
-		ID:        uuid.New().String(),
-		Key:       req.Key,
-		Value:     req.Value,
-		UpdatedBy: adminID,
-		UpdatedAt: time.Now(),
-		CreatedAt: time.Now(),
+	if _, err := s.authorize(adminID, domain.PermissionManageConfig); err != nil {
+		return nil, err
+	}
+
+	// Updates keep the entry's ID, type and validation unless the request overrides them
+	config, err := s.systemConfigRepo.Get(req.Key)
+	if err != nil {
+		config = &domain.SystemConfig{
+			ID:        uuid.New().String(),
+			Key:       req.Key,
+			Type:      domain.ConfigTypeString,
+			CreatedAt: time.Now(),
+		}
+	}
+
+	config.Value = req.Value
+	if req.Type != "" {
+		config.Type = req.Type
+	}
+	if req.Validation != nil {
+		config.Validation = req.Validation
+	}
+	if req.Description != nil {
+		config.Description = *req.Description
+	}
+	config.UpdatedBy = adminID
+	config.UpdatedAt = time.Now()
+
+	config.TypedValue, err = validateConfig(config)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := s.systemConfigRepo.Set(config); err != nil {
 		return nil, fmt.Errorf("failed to set system config: %w", err)
 	}
 
-	// Log the action
-	s.LogAction(adminID, "set_system_config", "system_config", req.Key, map[string]interface{}{
-		"key":   req.Key,
-		"value": req.Value,
-	}, "", "")
-
 	return config, nil
 }
 
 func (s *adminService) GetAllSystemConfigs() ([]domain.SystemConfig, error) {
-	return s.systemConfigRepo.GetAll()
+	configs, err := s.systemConfigRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for i := range configs {
+		withTypedValue(&configs[i])
+	}
+	return configs, nil
 }
 
 // Audit logging
@@ -414,8 +653,28 @@ func (s *adminService) LogAction(adminID, action, resource, resourceID string, d
 	return s.auditLogRepo.Create(log)
 }
 
-func (s *adminService) GetAuditLogs(adminID string, limit, offset int) ([]domain.AuditLog, error) {
-	return s.auditLogRepo.GetByAdminID(adminID, limit, offset)
+func (s *adminService) GetAuditLogs(filter domain.AuditLogFilter) (*domain.AuditLogPage, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	if filter.StartDate != nil && filter.EndDate != nil && !filter.EndDate.After(*filter.StartDate) {
+		return nil, errors.New("end_date must be after start_date")
+	}
+
+	logs, total, err := s.auditLogRepo.Search(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AuditLogPage{
+		Logs:   logs,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
 }
 
 func (s *adminService) GetResourceAuditLogs(resource string, limit, offset int) ([]domain.AuditLog, error) {
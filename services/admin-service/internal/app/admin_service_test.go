@@ -0,0 +1,436 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"glovo-backend/services/admin-service/internal/domain"
+	"glovo-backend/shared/auth"
+)
+
+type fakeAdminRepo struct {
+	domain.AdminRepository
+	admins map[string]*domain.Admin
+}
+
+func (r *fakeAdminRepo) GetByID(id string) (*domain.Admin, error) {
+	admin, ok := r.admins[id]
+	if !ok {
+		return nil, errors.New("admin not found")
+	}
+	read := *admin
+	return &read, nil
+}
+
+func (r *fakeAdminRepo) GetByEmail(email string) (*domain.Admin, error) {
+	for _, admin := range r.admins {
+		if admin.Email == email {
+			read := *admin
+			return &read, nil
+		}
+	}
+	return nil, errors.New("admin not found")
+}
+
+func (r *fakeAdminRepo) Create(admin *domain.Admin) error {
+	r.admins[admin.ID] = admin
+	return nil
+}
+
+func (r *fakeAdminRepo) Update(admin *domain.Admin) error {
+	r.admins[admin.ID] = admin
+	return nil
+}
+
+func TestAdminGrants(t *testing.T) {
+	// manager-1 can manage admins through an extra grant but holds nothing
+	// else beyond the admin role
+	newService := func() (*adminService, *fakeAdminRepo) {
+		repo := &fakeAdminRepo{admins: map[string]*domain.Admin{
+			"super-1":   {ID: "super-1", Role: domain.RoleSuperAdmin, IsActive: true},
+			"manager-1": {ID: "manager-1", Role: domain.RoleAdmin, Permissions: []string{string(domain.PermissionManageAdmins)}, IsActive: true},
+			"finance-1": {ID: "finance-1", Email: "finance@glovo.test", Role: domain.RoleFinance, IsActive: true},
+		}}
+		return &adminService{adminRepo: repo}, repo
+	}
+	create := func(svc *adminService, requester string, role domain.AdminRole, permissions ...string) error {
+		_, err := svc.CreateAdmin(requester, domain.CreateAdminRequest{
+			Email: "new@glovo.test", Password: "password1", FirstName: "New", LastName: "Admin", Role: role, Permissions: permissions,
+		})
+		return err
+	}
+
+	tests := []struct {
+		name    string
+		run     func(svc *adminService) error
+		wantErr error
+	}{
+		{name: "grant a held permission", run: func(svc *adminService) error {
+			return create(svc, "manager-1", domain.RoleSupport, string(domain.PermissionViewAudit))
+		}},
+		{name: "grant an extra permission not held", run: func(svc *adminService) error {
+			return create(svc, "manager-1", domain.RoleSupport, string(domain.PermissionManageConfig))
+		}, wantErr: domain.ErrInsufficientPermissions},
+		{name: "grant impersonation not held", run: func(svc *adminService) error {
+			return create(svc, "manager-1", domain.RoleSupport, string(domain.PermissionImpersonate))
+		}, wantErr: domain.ErrInsufficientPermissions},
+		{name: "assign a role with permissions not held", run: func(svc *adminService) error {
+			return create(svc, "manager-1", domain.RoleFinance)
+		}, wantErr: domain.ErrInsufficientPermissions},
+		{name: "create a super admin", run: func(svc *adminService) error {
+			return create(svc, "manager-1", domain.RoleSuperAdmin)
+		}, wantErr: domain.ErrInsufficientPermissions},
+		{name: "super admin grants anything", run: func(svc *adminService) error {
+			return create(svc, "super-1", domain.RoleFinance, string(domain.PermissionImpersonate))
+		}},
+		{name: "update leaves existing grants alone", run: func(svc *adminService) error {
+			active := false
+			_, err := svc.UpdateAdmin("manager-1", "finance-1", domain.UpdateAdminRequest{IsActive: &active})
+			return err
+		}},
+		{name: "update adds a permission not held", run: func(svc *adminService) error {
+			_, err := svc.UpdateAdmin("manager-1", "finance-1", domain.UpdateAdminRequest{Permissions: []string{string(domain.PermissionImpersonate)}})
+			return err
+		}, wantErr: domain.ErrInsufficientPermissions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := newService()
+			err := tt.run(svc)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				if _, err := repo.GetByEmail("new@glovo.test"); err == nil {
+					t.Fatalf("admin created despite the rejected grant")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+type fakeUserService struct {
+	domain.UserService
+	users       map[string]*domain.UserInfo
+	failUpdate  string // user whose status update fails
+	merchantReq *domain.MerchantSearchRequest
+	driverReq   *domain.DriverSearchRequest
+}
+
+func (s *fakeUserService) GetMerchants(req domain.MerchantSearchRequest) (*domain.MerchantPage, error) {
+	s.merchantReq = &req
+	return &domain.MerchantPage{Limit: req.Limit, Offset: req.Offset}, nil
+}
+
+func (s *fakeUserService) GetDrivers(req domain.DriverSearchRequest) (*domain.DriverPage, error) {
+	s.driverReq = &req
+	return &domain.DriverPage{Limit: req.Limit, Offset: req.Offset}, nil
+}
+
+func (s *fakeUserService) GetUser(userID string) (*domain.UserInfo, error) {
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (s *fakeUserService) UpdateUserStatus(userID string, status domain.UserStatus, reason string) error {
+	if userID == s.failUpdate {
+		return errors.New("user service unavailable")
+	}
+	s.users[userID].Status = status
+	return nil
+}
+
+type fakeAuditLogRepo struct {
+	domain.AuditLogRepository
+	logs     []domain.AuditLog
+	searched []domain.AuditLogFilter
+}
+
+func (r *fakeAuditLogRepo) Create(log *domain.AuditLog) error {
+	r.logs = append(r.logs, *log)
+	return nil
+}
+
+func TestImpersonateUser(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	impersonate := []string{string(domain.PermissionImpersonate)}
+
+	tests := []struct {
+		name    string
+		adminID string
+		userID  string
+		wantErr error
+	}{
+		{name: "super admin", adminID: "super-1", userID: "customer-1"},
+		{name: "admin with the permission", adminID: "admin-1", userID: "customer-1", wantErr: domain.ErrInsufficientPermissions},
+		{name: "another admin", adminID: "super-1", userID: "admin-1", wantErr: domain.ErrInsufficientPermissions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &fakeAuditLogRepo{}
+			svc := &adminService{
+				adminRepo: &fakeAdminRepo{admins: map[string]*domain.Admin{
+					"super-1": {ID: "super-1", Role: domain.RoleSuperAdmin, IsActive: true},
+					"admin-1": {ID: "admin-1", Role: domain.RoleAdmin, Permissions: impersonate, IsActive: true},
+				}},
+				auditLogRepo: audit,
+				userService: &fakeUserService{users: map[string]*domain.UserInfo{
+					"customer-1": {ID: "customer-1", Role: auth.RoleCustomer, Status: domain.UserStatusActive},
+					"admin-1":    {ID: "admin-1", Role: auth.RoleAdmin, Status: domain.UserStatusActive},
+				}},
+			}
+
+			token, err := svc.ImpersonateUser(tt.adminID, tt.userID, domain.ImpersonateUserRequest{Reason: "reproduce checkout bug"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImpersonateUser: %v", err)
+			}
+
+			claims, err := auth.ValidateToken(token.Token)
+			if err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+			if claims.UserID != "customer-1" || claims.Role != string(auth.RoleCustomer) || claims.ImpersonatedBy != "super-1" {
+				t.Fatalf("unexpected claims: %+v", claims)
+			}
+			if token.ImpersonatedBy != "super-1" || token.ExpiresAt > time.Now().Add(impersonationTTL).Unix() {
+				t.Fatalf("unexpected token: %+v", token)
+			}
+
+			// The audit trail middleware records the request
+			if len(audit.logs) != 0 {
+				t.Fatalf("impersonation logged by the service: %+v", audit.logs)
+			}
+		})
+	}
+}
+
+func (r *fakeAuditLogRepo) Search(filter domain.AuditLogFilter) ([]domain.AuditLog, int64, error) {
+	r.searched = append(r.searched, filter)
+	return r.logs, int64(len(r.logs)), nil
+}
+
+func TestGetAuditLogs(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	before := start.AddDate(0, 0, -1)
+
+	tests := []struct {
+		name       string
+		filter     domain.AuditLogFilter
+		wantErr    bool
+		wantLimit  int
+		wantOffset int
+	}{
+		{name: "default page", filter: domain.AuditLogFilter{AdminID: "admin-1"}, wantLimit: 20},
+		{name: "page kept", filter: domain.AuditLogFilter{Limit: 50, Offset: 100}, wantLimit: 50, wantOffset: 100},
+		{name: "limit capped", filter: domain.AuditLogFilter{Limit: 1000, Offset: -5}, wantLimit: 20},
+		{name: "end before start", filter: domain.AuditLogFilter{StartDate: &start, EndDate: &before}, wantErr: true},
+		{name: "empty range", filter: domain.AuditLogFilter{StartDate: &start, EndDate: &start}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAuditLogRepo{logs: []domain.AuditLog{{ID: "log-1"}}}
+			svc := &adminService{auditLogRepo: repo}
+
+			page, err := svc.GetAuditLogs(tt.filter)
+			if tt.wantErr {
+				if err == nil || len(repo.searched) != 0 {
+					t.Fatalf("expected an error without searching, got %v after %d searches", err, len(repo.searched))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetAuditLogs: %v", err)
+			}
+
+			searched := repo.searched[0]
+			if searched.Limit != tt.wantLimit || searched.Offset != tt.wantOffset || searched.AdminID != tt.filter.AdminID {
+				t.Fatalf("searched %+v", searched)
+			}
+			if page.Total != 1 || len(page.Logs) != 1 || page.Limit != tt.wantLimit || page.Offset != tt.wantOffset {
+				t.Fatalf("page = %+v", page)
+			}
+		})
+	}
+}
+
+func TestBulkUpdateUserStatus(t *testing.T) {
+	newUsers := func() map[string]*domain.UserInfo {
+		return map[string]*domain.UserInfo{
+			"user-1": {ID: "user-1", Status: domain.UserStatusActive},
+			"user-2": {ID: "user-2", Status: domain.UserStatusPending},
+			"user-3": {ID: "user-3", Status: domain.UserStatusActive},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		userIDs     []string
+		failUpdate  string
+		wantApplied bool
+		wantResults []domain.BulkUserStatusResult
+		wantStatus  map[string]domain.UserStatus
+	}{
+		{
+			name:        "all valid",
+			userIDs:     []string{"user-1", "user-2", "user-1"},
+			wantApplied: true,
+			wantResults: []domain.BulkUserStatusResult{
+				{UserID: "user-1", PreviousStatus: domain.UserStatusActive, Success: true},
+				{UserID: "user-2", PreviousStatus: domain.UserStatusPending, Success: true},
+			},
+			wantStatus: map[string]domain.UserStatus{"user-1": domain.UserStatusSuspended, "user-2": domain.UserStatusSuspended, "user-3": domain.UserStatusActive},
+		},
+		{
+			name:    "mixed batch with unknown users",
+			userIDs: []string{"user-1", "ghost", "user-2", "nobody"},
+			wantResults: []domain.BulkUserStatusResult{
+				{UserID: "user-1", PreviousStatus: domain.UserStatusActive},
+				{UserID: "ghost", Error: "user not found"},
+				{UserID: "user-2", PreviousStatus: domain.UserStatusPending},
+				{UserID: "nobody", Error: "user not found"},
+			},
+			wantStatus: map[string]domain.UserStatus{"user-1": domain.UserStatusActive, "user-2": domain.UserStatusPending},
+		},
+		{
+			name:       "update fails part-way",
+			userIDs:    []string{"user-1", "user-2", "user-3"},
+			failUpdate: "user-3",
+			wantResults: []domain.BulkUserStatusResult{
+				{UserID: "user-1", PreviousStatus: domain.UserStatusActive, Error: "reverted: batch was not applied"},
+				{UserID: "user-2", PreviousStatus: domain.UserStatusPending, Error: "reverted: batch was not applied"},
+				{UserID: "user-3", PreviousStatus: domain.UserStatusActive, Error: "user service unavailable"},
+			},
+			wantStatus: map[string]domain.UserStatus{"user-1": domain.UserStatusActive, "user-2": domain.UserStatusPending, "user-3": domain.UserStatusActive},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUserService{users: newUsers(), failUpdate: tt.failUpdate}
+			audit := &fakeAuditLogRepo{}
+			svc := &adminService{userService: users, auditLogRepo: audit}
+
+			response, err := svc.BulkUpdateUserStatus("admin-1", domain.BulkUpdateUserStatusRequest{
+				UserIDs: tt.userIDs, Status: domain.UserStatusSuspended, Reason: "fraud ring",
+			})
+			if err != nil {
+				t.Fatalf("BulkUpdateUserStatus: %v", err)
+			}
+
+			if response.Applied != tt.wantApplied || !reflect.DeepEqual(response.Results, tt.wantResults) {
+				t.Fatalf("response = %+v, want applied %v with %+v", response, tt.wantApplied, tt.wantResults)
+			}
+			for userID, want := range tt.wantStatus {
+				if got := users.users[userID].Status; got != want {
+					t.Fatalf("%s is %s, want %s", userID, got, want)
+				}
+			}
+
+			// Only an applied batch is audited, once per user
+			if !tt.wantApplied {
+				if len(audit.logs) != 0 {
+					t.Fatalf("unapplied batch was audited: %+v", audit.logs)
+				}
+				return
+			}
+			if len(audit.logs) != len(tt.wantResults) {
+				t.Fatalf("%d audit entries, want %d", len(audit.logs), len(tt.wantResults))
+			}
+			for i, entry := range audit.logs {
+				if entry.AdminID != "admin-1" || entry.Action != "bulk_update_user_status" || entry.ResourceID != tt.wantResults[i].UserID ||
+					entry.Details["previous_status"] != tt.wantResults[i].PreviousStatus || entry.Details["reason"] != "fraud ring" {
+					t.Fatalf("audit entry = %+v", entry)
+				}
+			}
+		})
+	}
+}
+
+func TestBulkUpdateUserStatusRejectsUnknownStatus(t *testing.T) {
+	users := &fakeUserService{users: map[string]*domain.UserInfo{"user-1": {ID: "user-1", Status: domain.UserStatusActive}}}
+	svc := &adminService{userService: users, auditLogRepo: &fakeAuditLogRepo{}}
+
+	if _, err := svc.BulkUpdateUserStatus("admin-1", domain.BulkUpdateUserStatusRequest{UserIDs: []string{"user-1"}, Status: "deleted"}); err == nil {
+		t.Fatalf("expected an error for an unknown status")
+	}
+	if users.users["user-1"].Status != domain.UserStatusActive {
+		t.Fatalf("status changed to %s", users.users["user-1"].Status)
+	}
+}
+
+func TestGetMerchantsAndDriversNormalizeRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		sortBy    string
+		sortOrder string
+		limit     int
+		offset    int
+		want      domain.MerchantSearchRequest // Query and Status are passed through
+		wantErr   bool
+	}{
+		{name: "defaults", want: domain.MerchantSearchRequest{SortBy: domain.SortByCreatedAt, SortOrder: domain.SortDesc, Limit: 20}},
+		{name: "explicit", sortBy: domain.SortByRating, sortOrder: domain.SortAsc, limit: 50, offset: 10,
+			want: domain.MerchantSearchRequest{SortBy: domain.SortByRating, SortOrder: domain.SortAsc, Limit: 50, Offset: 10}},
+		{name: "page clamped", limit: 500, offset: -1, want: domain.MerchantSearchRequest{SortBy: domain.SortByCreatedAt, SortOrder: domain.SortDesc, Limit: 20}},
+		{name: "unknown field", sortBy: "password", wantErr: true},
+		{name: "unknown order", sortBy: domain.SortByName, sortOrder: "sideways", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUserService{}
+			svc := &adminService{userService: users}
+
+			_, err := svc.GetMerchants(domain.MerchantSearchRequest{Query: "pizza", Status: domain.MerchantStatusActive, SortBy: tt.sortBy, SortOrder: tt.sortOrder, Limit: tt.limit, Offset: tt.offset})
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrInvalidSort) || users.merchantReq != nil {
+					t.Fatalf("expected %v before querying, got %v", domain.ErrInvalidSort, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetMerchants: %v", err)
+			}
+			want := tt.want
+			want.Query, want.Status = "pizza", domain.MerchantStatusActive
+			if *users.merchantReq != want {
+				t.Fatalf("queried %+v, want %+v", *users.merchantReq, want)
+			}
+
+			if _, err := svc.GetDrivers(domain.DriverSearchRequest{SortBy: tt.sortBy, SortOrder: tt.sortOrder, Limit: tt.limit, Offset: tt.offset}); err != nil {
+				t.Fatalf("GetDrivers: %v", err)
+			}
+			driverReq := users.driverReq
+			if driverReq.SortBy != tt.want.SortBy || driverReq.SortOrder != tt.want.SortOrder || driverReq.Limit != tt.want.Limit || driverReq.Offset != tt.want.Offset {
+				t.Fatalf("queried drivers with %+v", *driverReq)
+			}
+		})
+	}
+
+	// Drivers and merchants sort on different fields
+	svc := &adminService{userService: &fakeUserService{}}
+	if _, err := svc.GetDrivers(domain.DriverSearchRequest{SortBy: domain.SortByOrderCount}); !errors.Is(err, domain.ErrInvalidSort) {
+		t.Fatalf("drivers by order count: expected %v, got %v", domain.ErrInvalidSort, err)
+	}
+	if _, err := svc.GetMerchants(domain.MerchantSearchRequest{SortBy: domain.SortByTotalDeliveries}); !errors.Is(err, domain.ErrInvalidSort) {
+		t.Fatalf("merchants by deliveries: expected %v, got %v", domain.ErrInvalidSort, err)
+	}
+}
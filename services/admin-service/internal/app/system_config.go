@@ -0,0 +1,102 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"glovo-backend/services/admin-service/internal/domain"
+)
+
+// parseConfigValue converts a raw config value to its declared type
+func parseConfigValue(configType domain.ConfigType, value string) (interface{}, error) {
+	switch configType {
+	case domain.ConfigTypeInt:
+		v, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not an integer", domain.ErrInvalidConfigValue, value)
+		}
+		return v, nil
+	case domain.ConfigTypeFloat:
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a number", domain.ErrInvalidConfigValue, value)
+		}
+		return v, nil
+	case domain.ConfigTypeBool:
+		v, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a boolean", domain.ErrInvalidConfigValue, value)
+		}
+		return v, nil
+	case domain.ConfigTypeJSON:
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, fmt.Errorf("%w: not valid JSON: %v", domain.ErrInvalidConfigValue, err)
+		}
+		return v, nil
+	case domain.ConfigTypeString, "":
+		return value, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", domain.ErrInvalidConfigValue, configType)
+	}
+}
+
+// validateConfig parses the config's value and checks it against its
+// validation rules, returning the typed value
+func validateConfig(config *domain.SystemConfig) (interface{}, error) {
+	typed, err := parseConfigValue(config.Type, config.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := config.Validation
+	if rules == nil {
+		return typed, nil
+	}
+
+	if len(rules.Enum) > 0 {
+		allowed := false
+		for _, option := range rules.Enum {
+			if option == config.Value {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf("%w: must be one of %s", domain.ErrInvalidConfigValue, strings.Join(rules.Enum, ", "))
+		}
+	}
+
+	if rules.Min != nil || rules.Max != nil {
+		var number float64
+		switch v := typed.(type) {
+		case int64:
+			number = float64(v)
+		case float64:
+			number = v
+		default:
+			return nil, fmt.Errorf("%w: min/max only apply to int and float configs", domain.ErrInvalidConfigValue)
+		}
+		if rules.Min != nil && number < *rules.Min {
+			return nil, fmt.Errorf("%w: must be at least %v", domain.ErrInvalidConfigValue, *rules.Min)
+		}
+		if rules.Max != nil && number > *rules.Max {
+			return nil, fmt.Errorf("%w: must be at most %v", domain.ErrInvalidConfigValue, *rules.Max)
+		}
+	}
+
+	return typed, nil
+}
+
+// withTypedValue fills in TypedValue; stored values are validated on write,
+// so a parse failure leaves the raw string
+func withTypedValue(config *domain.SystemConfig) *domain.SystemConfig {
+	typed, err := parseConfigValue(config.Type, config.Value)
+	if err != nil {
+		typed = config.Value
+	}
+	config.TypedValue = typed
+	return config
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"glovo-backend/services/admin-service/internal/domain"
+)
+
+type fakeSystemConfigRepo struct {
+	domain.SystemConfigRepository
+	configs map[string]domain.SystemConfig
+}
+
+func (r *fakeSystemConfigRepo) Get(key string) (*domain.SystemConfig, error) {
+	config, ok := r.configs[key]
+	if !ok {
+		return nil, errors.New("config not found")
+	}
+	return &config, nil
+}
+
+func (r *fakeSystemConfigRepo) Set(config *domain.SystemConfig) error {
+	r.configs[config.Key] = *config
+	return nil
+}
+
+func TestSetSystemConfigValidatesRange(t *testing.T) {
+	min, max := 0.0, 0.5
+	repo := &fakeSystemConfigRepo{configs: map[string]domain.SystemConfig{
+		"commission_rate": {ID: "config-1", Key: "commission_rate", Value: "0.15", Type: domain.ConfigTypeFloat, Validation: &domain.ConfigValidation{Min: &min, Max: &max}},
+	}}
+	svc := &adminService{
+		adminRepo:        &fakeAdminRepo{admins: map[string]*domain.Admin{"super-1": {ID: "super-1", Role: domain.RoleSuperAdmin, IsActive: true}}},
+		systemConfigRepo: repo,
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{name: "in range", value: "0.2", valid: true},
+		{name: "upper bound", value: "0.5", valid: true},
+		{name: "above max", value: "0.75"},
+		{name: "below min", value: "-0.1"},
+		{name: "not a number", value: "banana"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := repo.configs["commission_rate"].Value
+
+			config, err := svc.SetSystemConfig("super-1", domain.SystemConfigRequest{Key: "commission_rate", Value: tt.value})
+			if !tt.valid {
+				if !errors.Is(err, domain.ErrInvalidConfigValue) {
+					t.Fatalf("expected %v, got %v", domain.ErrInvalidConfigValue, err)
+				}
+				if repo.configs["commission_rate"].Value != before {
+					t.Fatalf("invalid value %q was stored", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetSystemConfig: %v", err)
+			}
+			// The stored type and rules survive an update that doesn't mention them
+			if config.ID != "config-1" || config.Type != domain.ConfigTypeFloat || config.Validation == nil || config.UpdatedBy != "super-1" {
+				t.Fatalf("config = %+v", config)
+			}
+
+			stored, err := svc.GetSystemConfig("commission_rate")
+			if err != nil {
+				t.Fatalf("GetSystemConfig: %v", err)
+			}
+			if stored.Value != tt.value || stored.TypedValue != config.TypedValue {
+				t.Fatalf("stored %q (%v), want %q (%v)", stored.Value, stored.TypedValue, tt.value, config.TypedValue)
+			}
+			if _, ok := stored.TypedValue.(float64); !ok {
+				t.Fatalf("typed value is %T, want float64", stored.TypedValue)
+			}
+		})
+	}
+}
+
+func TestSetSystemConfigRequiresPermission(t *testing.T) {
+	repo := &fakeSystemConfigRepo{configs: map[string]domain.SystemConfig{}}
+	svc := &adminService{
+		adminRepo:        &fakeAdminRepo{admins: map[string]*domain.Admin{"support-1": {ID: "support-1", Role: domain.RoleSupport, IsActive: true}}},
+		systemConfigRepo: repo,
+	}
+
+	if _, err := svc.SetSystemConfig("support-1", domain.SystemConfigRequest{Key: "maintenance_mode", Value: "true"}); !errors.Is(err, domain.ErrInsufficientPermissions) {
+		t.Fatalf("expected %v, got %v", domain.ErrInsufficientPermissions, err)
+	}
+	if len(repo.configs) != 0 {
+		t.Fatalf("config stored without permission")
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	min := 1.0
+	tests := []struct {
+		name    string
+		config  domain.SystemConfig
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "untyped is a string", config: domain.SystemConfig{Value: "hello"}, want: "hello"},
+		{name: "int", config: domain.SystemConfig{Type: domain.ConfigTypeInt, Value: " 42 "}, want: int64(42)},
+		{name: "fractional int", config: domain.SystemConfig{Type: domain.ConfigTypeInt, Value: "4.2"}, wantErr: true},
+		{name: "bool", config: domain.SystemConfig{Type: domain.ConfigTypeBool, Value: "true"}, want: true},
+		{name: "not a bool", config: domain.SystemConfig{Type: domain.ConfigTypeBool, Value: "yes please"}, wantErr: true},
+		{name: "json", config: domain.SystemConfig{Type: domain.ConfigTypeJSON, Value: `{"zones":["bcn"]}`}, want: map[string]interface{}{"zones": []interface{}{"bcn"}}},
+		{name: "broken json", config: domain.SystemConfig{Type: domain.ConfigTypeJSON, Value: `{"zones":`}, wantErr: true},
+		{name: "unknown type", config: domain.SystemConfig{Type: "date", Value: "2026-10-15"}, wantErr: true},
+		{name: "enum", config: domain.SystemConfig{Value: "eur", Validation: &domain.ConfigValidation{Enum: []string{"eur", "usd"}}}, want: "eur"},
+		{name: "outside enum", config: domain.SystemConfig{Value: "gbp", Validation: &domain.ConfigValidation{Enum: []string{"eur", "usd"}}}, wantErr: true},
+		{name: "int below min", config: domain.SystemConfig{Type: domain.ConfigTypeInt, Value: "0", Validation: &domain.ConfigValidation{Min: &min}}, wantErr: true},
+		{name: "range on a string", config: domain.SystemConfig{Value: "5", Validation: &domain.ConfigValidation{Min: &min}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateConfig(&tt.config)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrInvalidConfigValue) {
+					t.Fatalf("expected %v, got %v", domain.ErrInvalidConfigValue, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateConfig: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("typed value = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"glovo-backend/services/admin-service/internal/domain"
+)
+
+// GetUserTimeline asks the order, delivery and payment services for the
+// user's events in parallel and merges them oldest first. A service that
+// fails leaves a note instead of failing the whole timeline.
+func (s *adminService) GetUserTimeline(userID string) (*domain.UserTimeline, error) {
+	if _, err := s.userService.GetUser(userID); err != nil {
+		return nil, err
+	}
+
+	sources := []struct {
+		source domain.TimelineSource
+		fetch  func(userID string) ([]domain.TimelineEvent, error)
+	}{
+		{domain.TimelineOrder, s.orderService.GetUserOrderEvents},
+		{domain.TimelineDelivery, s.deliveryService.GetUserDeliveryEvents},
+		{domain.TimelinePayment, s.paymentService.GetUserPaymentEvents},
+	}
+
+	results := make([][]domain.TimelineEvent, len(sources))
+	failures := make([]error, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, fetch func(string) ([]domain.TimelineEvent, error)) {
+			defer wg.Done()
+			results[i], failures[i] = fetch(userID)
+		}(i, source.fetch)
+	}
+	wg.Wait()
+
+	timeline := &domain.UserTimeline{UserID: userID, Events: []domain.TimelineEvent{}}
+	for i, source := range sources {
+		if err := failures[i]; err != nil {
+			log.Printf("Timeline for user %s is missing %s events: %v", userID, source.source, err)
+			timeline.Notes = append(timeline.Notes, fmt.Sprintf("%s events unavailable: %v", source.source, err))
+			continue
+		}
+		for _, event := range results[i] {
+			event.Source = source.source
+			timeline.Events = append(timeline.Events, event)
+		}
+	}
+
+	// Stable, so events at the same instant keep order, delivery, payment order
+	sort.SliceStable(timeline.Events, func(i, j int) bool {
+		return timeline.Events[i].OccurredAt.Before(timeline.Events[j].OccurredAt)
+	})
+	return timeline, nil
+}
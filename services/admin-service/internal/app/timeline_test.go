@@ -0,0 +1,143 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"glovo-backend/services/admin-service/internal/domain"
+)
+
+type fakeOrderService struct {
+	domain.OrderService
+	events []domain.TimelineEvent
+	err    error
+}
+
+func (s *fakeOrderService) GetUserOrderEvents(userID string) ([]domain.TimelineEvent, error) {
+	return s.events, s.err
+}
+
+type fakeDeliveryService struct {
+	domain.DeliveryService
+	events []domain.TimelineEvent
+	err    error
+}
+
+func (s *fakeDeliveryService) GetUserDeliveryEvents(userID string) ([]domain.TimelineEvent, error) {
+	return s.events, s.err
+}
+
+type fakePaymentService struct {
+	domain.PaymentService
+	events []domain.TimelineEvent
+	err    error
+}
+
+func (s *fakePaymentService) GetUserPaymentEvents(userID string) ([]domain.TimelineEvent, error) {
+	return s.events, s.err
+}
+
+func TestGetUserTimeline(t *testing.T) {
+	at := func(minute int) time.Time { return time.Date(2026, 10, 15, 12, minute, 0, 0, time.UTC) }
+	orders := []domain.TimelineEvent{
+		{Type: "order_placed", ResourceID: "order-1", OccurredAt: at(0)},
+		{Type: "order_placed", ResourceID: "order-2", OccurredAt: at(40)},
+	}
+	deliveries := []domain.TimelineEvent{
+		{Type: "delivery_delivered", ResourceID: "delivery-1", OccurredAt: at(30)},
+		{Type: "delivery_picked_up", ResourceID: "delivery-1", OccurredAt: at(15)},
+	}
+	payments := []domain.TimelineEvent{
+		{Type: "payment", ResourceID: "payment-1", OccurredAt: at(0)},
+		{Type: "refund", ResourceID: "refund-1", OccurredAt: at(50)},
+	}
+	type event struct {
+		source domain.TimelineSource
+		id     string
+	}
+
+	tests := []struct {
+		name       string
+		paymentErr error
+		want       []event
+		wantNotes  int
+	}{
+		{
+			name: "merged oldest first",
+			want: []event{
+				// Ties keep order, delivery, payment order
+				{domain.TimelineOrder, "order-1"},
+				{domain.TimelinePayment, "payment-1"},
+				{domain.TimelineDelivery, "delivery-1"},
+				{domain.TimelineDelivery, "delivery-1"},
+				{domain.TimelineOrder, "order-2"},
+				{domain.TimelinePayment, "refund-1"},
+			},
+		},
+		{
+			name:       "payment service down",
+			paymentErr: errors.New("connection refused"),
+			want: []event{
+				{domain.TimelineOrder, "order-1"},
+				{domain.TimelineDelivery, "delivery-1"},
+				{domain.TimelineDelivery, "delivery-1"},
+				{domain.TimelineOrder, "order-2"},
+			},
+			wantNotes: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &adminService{
+				userService:     &fakeUserService{users: map[string]*domain.UserInfo{"customer-1": {ID: "customer-1"}}},
+				orderService:    &fakeOrderService{events: orders},
+				deliveryService: &fakeDeliveryService{events: deliveries},
+				paymentService:  &fakePaymentService{events: payments, err: tt.paymentErr},
+			}
+
+			timeline, err := svc.GetUserTimeline("customer-1")
+			if err != nil {
+				t.Fatalf("GetUserTimeline: %v", err)
+			}
+
+			var got []event
+			for i, e := range timeline.Events {
+				got = append(got, event{e.Source, e.ResourceID})
+				if i > 0 && e.OccurredAt.Before(timeline.Events[i-1].OccurredAt) {
+					t.Fatalf("event %d is out of order: %+v", i, timeline.Events)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("events = %v, want %v", got, tt.want)
+			}
+			if len(timeline.Notes) != tt.wantNotes {
+				t.Fatalf("notes = %v, want %d", timeline.Notes, tt.wantNotes)
+			}
+		})
+	}
+}
+
+func TestGetUserTimelineAllSourcesDown(t *testing.T) {
+	down := errors.New("timeout")
+	svc := &adminService{
+		userService:     &fakeUserService{users: map[string]*domain.UserInfo{"customer-1": {ID: "customer-1"}}},
+		orderService:    &fakeOrderService{err: down},
+		deliveryService: &fakeDeliveryService{err: down},
+		paymentService:  &fakePaymentService{err: down},
+	}
+
+	timeline, err := svc.GetUserTimeline("customer-1")
+	if err != nil {
+		t.Fatalf("GetUserTimeline: %v", err)
+	}
+	if timeline.Events == nil || len(timeline.Events) != 0 || len(timeline.Notes) != 3 {
+		t.Fatalf("timeline = %+v, want no events and 3 notes", timeline)
+	}
+
+	if _, err := svc.GetUserTimeline("ghost"); err == nil {
+		t.Fatalf("expected an error for an unknown user")
+	}
+}
@@ -0,0 +1,317 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"glovo-backend/services/admin-service/internal/domain"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	totpIssuer      = "Glovo Admin"
+	totpPeriod      = 30 // seconds per time step
+	totpDigits      = 6
+	totpSkew        = 1 // steps accepted either side of now, for clock drift
+	backupCodeCount = 10
+	challengeTTL    = 5 * time.Minute
+	challengeTries  = 5 // wrong codes allowed before the challenge is dropped
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EnrollTwoFactor generates a new TOTP secret; two-factor authentication is
+// only enabled once ConfirmTwoFactor verifies a code from it
+func (s *adminService) EnrollTwoFactor(adminID string) (*domain.TwoFactorEnrollment, error) {
+	admin, err := s.adminRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := randomBytes(20)
+	if err != nil {
+		return nil, err
+	}
+	admin.TOTPSecret = totpEncoding.EncodeToString(secret)
+	admin.TOTPLastStep = 0
+	admin.UpdatedAt = time.Now()
+
+	if err := s.adminRepo.Update(admin); err != nil {
+		return nil, fmt.Errorf("failed to save two-factor secret: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("secret", admin.TOTPSecret)
+	params.Set("issuer", totpIssuer)
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+
+	return &domain.TwoFactorEnrollment{
+		Secret:     admin.TOTPSecret,
+		OTPAuthURL: "otpauth://totp/" + url.PathEscape(totpIssuer+":"+admin.Email) + "?" + params.Encode(),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication after checking a code
+// from the enrolled secret, and returns a fresh set of backup codes
+func (s *adminService) ConfirmTwoFactor(adminID string, code string) (*domain.TwoFactorBackupCodes, error) {
+	admin, err := s.adminRepo.GetByID(adminID)
+	if err != nil {
+		return nil, err
+	}
+	if admin.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if admin.TOTPSecret == "" {
+		return nil, errors.New("two-factor enrollment has not been started")
+	}
+
+	step, ok := verifyTOTP(admin.TOTPSecret, code, time.Now(), admin.TOTPLastStep)
+	if !ok {
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	admin.TwoFactorEnabled = true
+	admin.TOTPLastStep = step
+	admin.BackupCodes = hashes
+	admin.UpdatedAt = time.Now()
+	if err := s.adminRepo.Update(admin); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	return &domain.TwoFactorBackupCodes{BackupCodes: codes}, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off; it requires a current
+// TOTP or backup code
+func (s *adminService) DisableTwoFactor(adminID string, code string) error {
+	admin, err := s.adminRepo.GetByID(adminID)
+	if err != nil {
+		return err
+	}
+	if !admin.TwoFactorEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if !consumeTwoFactorCode(admin, code, time.Now()) {
+		return domain.ErrInvalidTwoFactorCode
+	}
+
+	admin.TwoFactorEnabled = false
+	admin.TOTPSecret = ""
+	admin.TOTPLastStep = 0
+	admin.BackupCodes = nil
+	admin.UpdatedAt = time.Now()
+	return s.adminRepo.Update(admin)
+}
+
+// VerifyTwoFactorLogin completes a login challenge issued by Login. Attempts,
+// TOTP steps and backup codes are claimed with conditional writes, so parallel
+// requests can't exceed the attempt limit or use a code twice.
+func (s *adminService) VerifyTwoFactorLogin(req domain.VerifyTwoFactorRequest) (*domain.AdminLoginResponse, error) {
+	challenge := hashChallenge(req.ChallengeToken)
+	admin, err := s.adminRepo.GetByLoginChallenge(challenge)
+	if err != nil {
+		return nil, errors.New("invalid or expired login challenge")
+	}
+
+	now := time.Now()
+	if admin.LoginChallengeExpiresAt == nil || now.After(*admin.LoginChallengeExpiresAt) {
+		return nil, errors.New("invalid or expired login challenge")
+	}
+	if !admin.IsActive {
+		return nil, errors.New("account is deactivated")
+	}
+
+	claimed, err := s.adminRepo.ClaimLoginAttempt(challenge, challengeTries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record two-factor attempt: %w", err)
+	}
+	if !claimed {
+		return nil, errors.New("invalid or expired login challenge")
+	}
+
+	ok, err := s.useTwoFactorCode(admin, req.Code, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use two-factor code: %w", err)
+	}
+	if !ok {
+		// Allow a few retries, then require the password again
+		if admin.LoginChallengeAttempts+1 >= challengeTries {
+			if _, err := s.adminRepo.ClearLoginChallenge(admin.ID, challenge); err != nil {
+				return nil, fmt.Errorf("failed to drop login challenge: %w", err)
+			}
+		}
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
+
+	// Only one request completes the challenge
+	cleared, err := s.adminRepo.ClearLoginChallenge(admin.ID, challenge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete login: %w", err)
+	}
+	if !cleared {
+		return nil, errors.New("invalid or expired login challenge")
+	}
+
+	return s.issueSession(admin)
+}
+
+// startLoginChallenge stores a short-lived challenge for an admin whose
+// password checked out and returns the token to present with the code
+func (s *adminService) startLoginChallenge(admin *domain.Admin) (*domain.AdminLoginResponse, error) {
+	token, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	challenge := hex.EncodeToString(token)
+
+	expiresAt := time.Now().Add(challengeTTL)
+	admin.LoginChallenge = hashChallenge(challenge)
+	admin.LoginChallengeExpiresAt = &expiresAt
+	admin.LoginChallengeAttempts = 0
+	if err := s.adminRepo.Update(admin); err != nil {
+		return nil, fmt.Errorf("failed to start two-factor login: %w", err)
+	}
+
+	return &domain.AdminLoginResponse{
+		RequiresTwoFactor: true,
+		ChallengeToken:    challenge,
+		ExpiresAt:         expiresAt.Unix(),
+	}, nil
+}
+
+// consumeTwoFactorCode accepts a TOTP code newer than the last one used, or
+// an unused backup code, which is then removed. The caller saves the admin.
+func consumeTwoFactorCode(admin *domain.Admin, code string, now time.Time) bool {
+	if step, ok := verifyTOTP(admin.TOTPSecret, code, now, admin.TOTPLastStep); ok {
+		admin.TOTPLastStep = step
+		return true
+	}
+
+	if i := findBackupCode(admin.BackupCodes, code); i >= 0 {
+		admin.BackupCodes = append(admin.BackupCodes[:i:i], admin.BackupCodes[i+1:]...)
+		return true
+	}
+	return false
+}
+
+// useTwoFactorCode accepts the same codes as consumeTwoFactorCode but claims
+// the TOTP step or backup code in the repository, so it is used only once
+// even by concurrent logins
+func (s *adminService) useTwoFactorCode(admin *domain.Admin, code string, now time.Time) (bool, error) {
+	if step, ok := verifyTOTP(admin.TOTPSecret, code, now, admin.TOTPLastStep); ok {
+		return s.adminRepo.ClaimTOTPStep(admin.ID, step)
+	}
+
+	if i := findBackupCode(admin.BackupCodes, code); i >= 0 {
+		return s.adminRepo.UseBackupCode(admin.ID, admin.BackupCodes[i])
+	}
+	return false, nil
+}
+
+// findBackupCode returns the index of the hash matching code, or -1
+func findBackupCode(hashes []string, code string) int {
+	normalized := normalizeBackupCode(code)
+	for i, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(normalized)) == nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// verifyTOTP checks code against the steps around now, rejecting any step at
+// or before lastStep so a code can't be replayed. It returns the matched step.
+func verifyTOTP(secret string, code string, now time.Time, lastStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode computes the RFC 6238 code for a time step
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// generateBackupCodes returns codes formatted for display and their bcrypt hashes
+func generateBackupCodes() ([]string, []string, error) {
+	codes := make([]string, 0, backupCodeCount)
+	hashes := make([]string, 0, backupCodeCount)
+	for i := 0; i < backupCodeCount; i++ {
+		raw, err := randomBytes(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(raw)
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to hash backup code: %w", err)
+		}
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, string(hash))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeBackupCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func hashChallenge(challenge string) string {
+	sum := sha256.Sum256([]byte(challenge))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return b, nil
+}
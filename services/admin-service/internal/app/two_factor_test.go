@@ -0,0 +1,231 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"glovo-backend/services/admin-service/internal/domain"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (r *fakeAdminRepo) GetByLoginChallenge(challenge string) (*domain.Admin, error) {
+	for _, admin := range r.admins {
+		if challenge != "" && admin.LoginChallenge == challenge {
+			read := *admin
+			return &read, nil
+		}
+	}
+	return nil, errors.New("admin not found")
+}
+
+func (r *fakeAdminRepo) ClaimLoginAttempt(challenge string, maxAttempts int) (bool, error) {
+	for _, admin := range r.admins {
+		if challenge != "" && admin.LoginChallenge == challenge && admin.LoginChallengeAttempts < maxAttempts {
+			admin.LoginChallengeAttempts++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeAdminRepo) ClearLoginChallenge(id, challenge string) (bool, error) {
+	admin, ok := r.admins[id]
+	if !ok || admin.LoginChallenge != challenge {
+		return false, nil
+	}
+	admin.LoginChallenge = ""
+	admin.LoginChallengeExpiresAt = nil
+	admin.LoginChallengeAttempts = 0
+	return true, nil
+}
+
+func (r *fakeAdminRepo) ClaimTOTPStep(id string, step int64) (bool, error) {
+	admin, ok := r.admins[id]
+	if !ok || admin.TOTPLastStep >= step {
+		return false, nil
+	}
+	admin.TOTPLastStep = step
+	return true, nil
+}
+
+func (r *fakeAdminRepo) UseBackupCode(id, hash string) (bool, error) {
+	admin, ok := r.admins[id]
+	if !ok {
+		return false, nil
+	}
+	for i, code := range admin.BackupCodes {
+		if code == hash {
+			admin.BackupCodes = append(admin.BackupCodes[:i:i], admin.BackupCodes[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeAdminRepo) UpdateLastLogin(id string) error {
+	return nil
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	// RFC 6238 SHA-1 test secret "12345678901234567890"
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+	step := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		code     string
+		now      time.Time
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "RFC vector", code: "081804", now: now, wantStep: step, wantOK: true},
+		{name: "RFC vector at T=59", code: "287082", now: time.Unix(59, 0), wantStep: 1, wantOK: true},
+		{name: "previous step within skew", code: "081804", now: now.Add(totpPeriod * time.Second), wantStep: step, wantOK: true},
+		{name: "expired", code: "081804", now: now.Add(2 * totpPeriod * time.Second)},
+		{name: "replayed", code: "081804", now: now, lastStep: step},
+		{name: "wrong code", code: "123456", now: now},
+		{name: "wrong length", code: "81804", now: now},
+		{name: "surrounding spaces", code: " 081804 ", now: now, wantStep: step, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := verifyTOTP(secret, tt.code, tt.now, tt.lastStep)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Fatalf("verifyTOTP = %d, %v; want %d, %v", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	password, err := bcrypt.GenerateFromPassword([]byte("password1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	repo := &fakeAdminRepo{admins: map[string]*domain.Admin{
+		"admin-1": {ID: "admin-1", Email: "ops@glovo.test", Password: string(password), Role: domain.RoleAdmin, IsActive: true},
+	}}
+	svc := &adminService{adminRepo: repo, auditLogRepo: &fakeAuditLogRepo{}}
+	codeAt := func(now time.Time) string {
+		key, err := totpEncoding.DecodeString(repo.admins["admin-1"].TOTPSecret)
+		if err != nil {
+			t.Fatalf("decode secret: %v", err)
+		}
+		return totpCode(key, now.Unix()/totpPeriod)
+	}
+	login := func() string {
+		t.Helper()
+		resp, err := svc.Login(domain.AdminLoginRequest{Email: "ops@glovo.test", Password: "password1"})
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		if !resp.RequiresTwoFactor || resp.ChallengeToken == "" || resp.Token != "" {
+			t.Fatalf("login response = %+v, want a two-factor challenge", resp)
+		}
+		return resp.ChallengeToken
+	}
+
+	// Enrollment only takes effect once a code is confirmed
+	enrollment, err := svc.EnrollTwoFactor("admin-1")
+	if err != nil {
+		t.Fatalf("EnrollTwoFactor: %v", err)
+	}
+	if enrollment.Secret == "" || repo.admins["admin-1"].TwoFactorEnabled {
+		t.Fatalf("enrollment = %+v, enabled %v", enrollment, repo.admins["admin-1"].TwoFactorEnabled)
+	}
+	if _, err := svc.ConfirmTwoFactor("admin-1", "000000"); !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		t.Fatalf("confirm with a wrong code: expected %v, got %v", domain.ErrInvalidTwoFactorCode, err)
+	}
+	confirmCode := codeAt(time.Now())
+	backup, err := svc.ConfirmTwoFactor("admin-1", confirmCode)
+	if err != nil {
+		t.Fatalf("ConfirmTwoFactor: %v", err)
+	}
+	if len(backup.BackupCodes) != backupCodeCount || !repo.admins["admin-1"].TwoFactorEnabled {
+		t.Fatalf("%d backup codes, enabled %v", len(backup.BackupCodes), repo.admins["admin-1"].TwoFactorEnabled)
+	}
+
+	// The code used to confirm can't be replayed to log in
+	challenge := login()
+	if _, err := svc.VerifyTwoFactorLogin(domain.VerifyTwoFactorRequest{ChallengeToken: challenge, Code: confirmCode}); !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		t.Fatalf("replayed code: expected %v, got %v", domain.ErrInvalidTwoFactorCode, err)
+	}
+
+	// The next step's code is within the allowed skew
+	session, err := svc.VerifyTwoFactorLogin(domain.VerifyTwoFactorRequest{ChallengeToken: challenge, Code: codeAt(time.Now().Add(totpPeriod * time.Second))})
+	if err != nil {
+		t.Fatalf("VerifyTwoFactorLogin: %v", err)
+	}
+	if session.Token == "" || session.RequiresTwoFactor {
+		t.Fatalf("session = %+v", session)
+	}
+	if _, err := svc.VerifyTwoFactorLogin(domain.VerifyTwoFactorRequest{ChallengeToken: challenge, Code: backup.BackupCodes[0]}); err == nil {
+		t.Fatalf("challenge reused after a successful login")
+	}
+
+	// A backup code works once, in any case and without the dash
+	code := backup.BackupCodes[1]
+	if _, err := svc.VerifyTwoFactorLogin(domain.VerifyTwoFactorRequest{ChallengeToken: login(), Code: " " + strings.ToUpper(code[:5]+code[6:]) + " "}); err != nil {
+		t.Fatalf("backup code: %v", err)
+	}
+	if len(repo.admins["admin-1"].BackupCodes) != backupCodeCount-1 {
+		t.Fatalf("%d backup codes left, want %d", len(repo.admins["admin-1"].BackupCodes), backupCodeCount-1)
+	}
+	if _, err := svc.VerifyTwoFactorLogin(domain.VerifyTwoFactorRequest{ChallengeToken: login(), Code: code}); !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		t.Fatalf("reused backup code: expected %v, got %v", domain.ErrInvalidTwoFactorCode, err)
+	}
+}
+
+func TestTwoFactorChallengeLimits(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	valid := time.Now().Add(time.Minute)
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		name          string
+		expiresAt     *time.Time
+		attempts      int
+		code          string
+		wantChallenge bool // still present afterwards
+		wantAttempts  int
+	}{
+		{name: "expired", expiresAt: &expired, code: "000000", wantChallenge: true},
+		{name: "wrong code", expiresAt: &valid, code: "000000", wantChallenge: true, wantAttempts: 1},
+		{name: "last attempt", expiresAt: &valid, attempts: challengeTries - 1, code: "000000"},
+		// Parallel guesses used up the attempts after this request read the challenge
+		{name: "no attempts left", expiresAt: &valid, attempts: challengeTries, code: totpCode([]byte("12345678901234567890"), time.Now().Unix()/totpPeriod), wantChallenge: true, wantAttempts: challengeTries},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAdminRepo{admins: map[string]*domain.Admin{
+				"admin-1": {
+					ID: "admin-1", IsActive: true, TwoFactorEnabled: true, TOTPSecret: secret,
+					LoginChallenge: hashChallenge("challenge"), LoginChallengeExpiresAt: tt.expiresAt, LoginChallengeAttempts: tt.attempts,
+				},
+			}}
+			svc := &adminService{adminRepo: repo}
+
+			if _, err := svc.VerifyTwoFactorLogin(domain.VerifyTwoFactorRequest{ChallengeToken: "challenge", Code: tt.code}); err == nil {
+				t.Fatalf("challenge accepted")
+			}
+			admin := repo.admins["admin-1"]
+			if got := admin.LoginChallenge != ""; got != tt.wantChallenge {
+				t.Fatalf("challenge kept = %v, want %v", got, tt.wantChallenge)
+			}
+			if tt.wantChallenge && admin.LoginChallengeAttempts != tt.wantAttempts {
+				t.Fatalf("attempts = %d, want %d", admin.LoginChallengeAttempts, tt.wantAttempts)
+			}
+			if admin.TOTPLastStep != 0 {
+				t.Fatalf("TOTP step %d used by a rejected attempt", admin.TOTPLastStep)
+			}
+		})
+	}
+}
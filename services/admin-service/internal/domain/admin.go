@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"glovo-backend/shared/auth"
@@ -19,6 +20,15 @@ type Admin struct {
 	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Two-factor authentication
+	TwoFactorEnabled        bool       `json:"two_factor_enabled"`
+	TOTPSecret              string     `json:"-"`
+	TOTPLastStep            int64      `json:"-"`                        // last accepted time step, to reject replays
+	BackupCodes             []string   `json:"-" gorm:"serializer:json"` // bcrypt hashes of unused codes
+	LoginChallenge          string     `json:"-" gorm:"index"`           // SHA-256 of the pending challenge token
+	LoginChallengeExpiresAt *time.Time `json:"-"`
+	LoginChallengeAttempts  int        `json:"-"`
 }
 
 type AdminRole string
@@ -28,8 +38,84 @@ const (
 	RoleAdmin      AdminRole = "admin"
 	RoleModerator  AdminRole = "moderator"
 	RoleSupport    AdminRole = "support"
+	RoleFinance    AdminRole = "finance"
+)
+
+// Permission grants access to a group of admin operations
+type Permission string
+
+const (
+	PermissionViewAdmins    Permission = "admins.read"
+	PermissionManageAdmins  Permission = "admins.manage"
+	PermissionViewUsers     Permission = "users.read"
+	PermissionManageUsers   Permission = "users.manage"
+	PermissionViewAnalytics Permission = "analytics.read"
+	PermissionViewConfig    Permission = "config.read"
+	PermissionManageConfig  Permission = "config.write"
+	PermissionViewAudit     Permission = "audit.read"
+	PermissionImpersonate   Permission = "users.impersonate"
 )
 
+// RolePermissions lists the permissions each role grants. Super admins have
+// every permission; Admin.Permissions adds grants on top of the role's.
+var RolePermissions = map[AdminRole][]Permission{
+	RoleAdmin: {
+		PermissionViewAdmins, PermissionViewUsers, PermissionManageUsers,
+		PermissionViewAnalytics, PermissionViewConfig, PermissionViewAudit,
+	},
+	RoleModerator: {PermissionViewUsers, PermissionManageUsers},
+	RoleSupport:   {PermissionViewUsers, PermissionManageUsers},
+	RoleFinance: {
+		PermissionViewAnalytics, PermissionViewConfig, PermissionManageConfig, PermissionViewAudit,
+	},
+}
+
+// AllPermissions lists every permission that can be granted
+var AllPermissions = []Permission{
+	PermissionViewAdmins, PermissionManageAdmins, PermissionViewUsers, PermissionManageUsers,
+	PermissionViewAnalytics, PermissionViewConfig, PermissionManageConfig, PermissionViewAudit,
+	PermissionImpersonate,
+}
+
+// ErrInvalidTwoFactorCode is returned for a wrong, expired or already used two-factor code
+var ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+// ErrInvalidConfigValue is returned when a config value doesn't match its type or validation
+var ErrInvalidConfigValue = errors.New("invalid config value")
+
+// ErrInsufficientPermissions is returned when an admin lacks the permission for an operation
+var ErrInsufficientPermissions = errors.New("insufficient permissions")
+
+// ErrInvalidSort is returned for an unknown sort field or order
+var ErrInvalidSort = errors.New("invalid sort")
+
+// IsValid reports whether the role is one of the known admin roles
+func (r AdminRole) IsValid() bool {
+	if r == RoleSuperAdmin {
+		return true
+	}
+	_, ok := RolePermissions[r]
+	return ok
+}
+
+// HasPermission reports whether the admin's role or extra grants include the permission
+func (a *Admin) HasPermission(permission Permission) bool {
+	if a.Role == RoleSuperAdmin {
+		return true
+	}
+	for _, p := range RolePermissions[a.Role] {
+		if p == permission {
+			return true
+		}
+	}
+	for _, p := range a.Permissions {
+		if Permission(p) == permission {
+			return true
+		}
+	}
+	return false
+}
+
 // Platform stats and analytics
 type PlatformStats struct {
 	TotalUsers        int            `json:"total_users"`
@@ -102,6 +188,15 @@ const (
 	UserStatusPending   UserStatus = "pending"
 )
 
+// IsValid reports whether the status is one of the known user statuses
+func (s UserStatus) IsValid() bool {
+	switch s {
+	case UserStatusActive, UserStatusSuspended, UserStatusBanned, UserStatusPending:
+		return true
+	}
+	return false
+}
+
 const (
 	MerchantStatusActive    UserStatus = "active"
 	MerchantStatusSuspended UserStatus = "suspended"
@@ -177,10 +272,35 @@ type AdminLoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// AdminLoginResponse carries the session token, or for admins with two-factor
+// authentication enabled, a challenge to complete at /auth/login/verify
 type AdminLoginResponse struct {
-	Token     string `json:"token"`
-	Admin     Admin  `json:"admin"`
-	ExpiresAt int64  `json:"expires_at"`
+	Token             string `json:"token,omitempty"`
+	Admin             *Admin `json:"admin,omitempty"`
+	ExpiresAt         int64  `json:"expires_at,omitempty"`
+	RequiresTwoFactor bool   `json:"requires_2fa"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+}
+
+// VerifyTwoFactorRequest completes a login challenge with a TOTP or backup code
+type VerifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorEnrollment is the secret to add to an authenticator app
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// TwoFactorBackupCodes are shown once; each can replace a TOTP code one time
+type TwoFactorBackupCodes struct {
+	BackupCodes []string `json:"backup_codes"`
 }
 
 type CreateAdminRequest struct {
@@ -208,24 +328,165 @@ type UserSearchRequest struct {
 	Offset int           `json:"offset,omitempty"`
 }
 
+// Sort fields for the merchant and driver lists
+const (
+	SortByName            = "name"
+	SortByCreatedAt       = "created_at"
+	SortByRating          = "rating"
+	SortByOrderCount      = "order_count"      // merchants only
+	SortByTotalDeliveries = "total_deliveries" // drivers only
+)
+
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// MerchantSearchRequest filters and sorts the merchant list; Query matches the
+// business or store name
+type MerchantSearchRequest struct {
+	Query     string     `json:"query,omitempty"`
+	Status    UserStatus `json:"status,omitempty"`
+	SortBy    string     `json:"sort_by,omitempty"`
+	SortOrder string     `json:"sort_order,omitempty"`
+	Limit     int        `json:"limit,omitempty"`
+	Offset    int        `json:"offset,omitempty"`
+}
+
+// DriverSearchRequest filters and sorts the driver list; Query matches the
+// driver's name or phone
+type DriverSearchRequest struct {
+	Query     string     `json:"query,omitempty"`
+	Status    UserStatus `json:"status,omitempty"`
+	SortBy    string     `json:"sort_by,omitempty"`
+	SortOrder string     `json:"sort_order,omitempty"`
+	Limit     int        `json:"limit,omitempty"`
+	Offset    int        `json:"offset,omitempty"`
+}
+
+// MerchantPage is one page of merchants; Total counts every match
+type MerchantPage struct {
+	Merchants []MerchantInfo `json:"merchants"`
+	Total     int64          `json:"total"`
+	Limit     int            `json:"limit"`
+	Offset    int            `json:"offset"`
+}
+
+// DriverPage is one page of drivers; Total counts every match
+type DriverPage struct {
+	Drivers []DriverInfo `json:"drivers"`
+	Total   int64        `json:"total"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+}
+
 type UpdateUserStatusRequest struct {
 	Status UserStatus `json:"status" binding:"required"`
 	Reason string     `json:"reason,omitempty"`
 }
 
+// BulkUpdateUserStatusRequest applies one status to many users; either every
+// user is updated or none are
+type BulkUpdateUserStatusRequest struct {
+	UserIDs []string   `json:"user_ids" binding:"required,min=1,max=100"`
+	Status  UserStatus `json:"status" binding:"required"`
+	Reason  string     `json:"reason,omitempty"`
+}
+
+type BulkUserStatusResult struct {
+	UserID         string     `json:"user_id"`
+	PreviousStatus UserStatus `json:"previous_status,omitempty"`
+	Success        bool       `json:"success"`
+	Error          string     `json:"error,omitempty"`
+}
+
+type ImpersonateUserRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ImpersonationToken lets an admin act as a user until ExpiresAt
+type ImpersonationToken struct {
+	Token          string        `json:"token"`
+	UserID         string        `json:"user_id"`
+	Role           auth.UserRole `json:"role"`
+	ImpersonatedBy string        `json:"impersonated_by"`
+	ExpiresAt      int64         `json:"expires_at"`
+}
+
+type BulkUserStatusResponse struct {
+	Applied bool                   `json:"applied"`
+	Results []BulkUserStatusResult `json:"results"`
+}
+
+// TimelineSource is the service a timeline event came from
+type TimelineSource string
+
+const (
+	TimelineOrder    TimelineSource = "order"
+	TimelineDelivery TimelineSource = "delivery"
+	TimelinePayment  TimelineSource = "payment"
+)
+
+// TimelineEvent is one thing that happened to a customer's order, delivery
+// or payment
+type TimelineEvent struct {
+	Source      TimelineSource `json:"source"`
+	Type        string         `json:"type"` // e.g. "order_placed", "delivery_picked_up", "refund"
+	OrderID     string         `json:"order_id,omitempty"`
+	ResourceID  string         `json:"resource_id"`
+	Description string         `json:"description"`
+	Amount      *float64       `json:"amount,omitempty"`
+	OccurredAt  time.Time      `json:"occurred_at"`
+}
+
+// UserTimeline is a customer's events across services, oldest first. Notes
+// names any service that couldn't be reached, whose events are missing.
+type UserTimeline struct {
+	UserID string          `json:"user_id"`
+	Events []TimelineEvent `json:"events"`
+	Notes  []string        `json:"notes,omitempty"`
+}
+
+// SystemConfigRequest sets a config value. Type, Description and Validation
+// are optional and keep their current values when omitted; new entries
+// default to the string type.
 type SystemConfigRequest struct {
-	Key   string `json:"key" binding:"required"`
-	Value string `json:"value" binding:"required"`
+	Key         string            `json:"key" binding:"required"`
+	Value       string            `json:"value" binding:"required"`
+	Type        ConfigType        `json:"type,omitempty"`
+	Description *string           `json:"description,omitempty"`
+	Validation  *ConfigValidation `json:"validation,omitempty"`
 }
 
 type SystemConfig struct {
-	ID          string    `json:"id" gorm:"primaryKey"`
-	Key         string    `json:"key" gorm:"uniqueIndex"`
-	Value       string    `json:"value"`
-	Description string    `json:"description"`
-	UpdatedBy   string    `json:"updated_by"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          string            `json:"id" gorm:"primaryKey"`
+	Key         string            `json:"key" gorm:"uniqueIndex"`
+	Value       string            `json:"value"`
+	Type        ConfigType        `json:"type" gorm:"default:string"`
+	Validation  *ConfigValidation `json:"validation,omitempty" gorm:"serializer:json"`
+	TypedValue  interface{}       `json:"typed_value" gorm:"-"` // Value parsed according to Type
+	Description string            `json:"description"`
+	UpdatedBy   string            `json:"updated_by"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+type ConfigType string
+
+const (
+	ConfigTypeInt    ConfigType = "int"
+	ConfigTypeFloat  ConfigType = "float"
+	ConfigTypeBool   ConfigType = "bool"
+	ConfigTypeString ConfigType = "string"
+	ConfigTypeJSON   ConfigType = "json"
+)
+
+// ConfigValidation restricts config values. Min and Max apply to int and
+// float values; Enum lists the allowed raw values of any type.
+type ConfigValidation struct {
+	Min  *float64 `json:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty"`
+	Enum []string `json:"enum,omitempty"`
 }
 
 type AuditLog struct {
@@ -240,6 +501,25 @@ type AuditLog struct {
 	CreatedAt  time.Time              `json:"created_at"`
 }
 
+// AuditLogFilter narrows audit log queries; empty fields match everything and
+// set fields combine with AND. EndDate is exclusive.
+type AuditLogFilter struct {
+	AdminID   string     `json:"admin_id,omitempty"`
+	Action    string     `json:"action,omitempty"`
+	Resource  string     `json:"resource,omitempty"`
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+	Limit     int        `json:"limit"`
+	Offset    int        `json:"offset"`
+}
+
+type AuditLogPage struct {
+	Logs   []AuditLog `json:"logs"`
+	Total  int64      `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
+
 // Repository interfaces (ports)
 type AdminRepository interface {
 	Create(admin *Admin) error
@@ -249,6 +529,19 @@ type AdminRepository interface {
 	Delete(id string) error
 	List(limit, offset int) ([]Admin, error)
 	UpdateLastLogin(id string) error
+	GetByLoginChallenge(challenge string) (*Admin, error)
+	// ClaimLoginAttempt counts an attempt at a login challenge; it reports
+	// false, changing nothing, once the challenge has had maxAttempts
+	ClaimLoginAttempt(challenge string, maxAttempts int) (bool, error)
+	// ClearLoginChallenge drops an admin's login challenge; it reports false
+	// when that challenge was already cleared or replaced
+	ClearLoginChallenge(id, challenge string) (bool, error)
+	// ClaimTOTPStep records step as the admin's last used TOTP step; it
+	// reports false, changing nothing, when that step was already used
+	ClaimTOTPStep(id string, step int64) (bool, error)
+	// UseBackupCode removes a backup code hash; it reports false when the
+	// code was already used
+	UseBackupCode(id, hash string) (bool, error)
 }
 
 type SystemConfigRepository interface {
@@ -263,14 +556,21 @@ type AuditLogRepository interface {
 	GetByAdminID(adminID string, limit, offset int) ([]AuditLog, error)
 	GetByResource(resource string, limit, offset int) ([]AuditLog, error)
 	List(limit, offset int) ([]AuditLog, error)
+	Search(filter AuditLogFilter) ([]AuditLog, int64, error)
 }
 
 // Service interfaces (ports)
 type AdminService interface {
 	// Admin authentication
 	Login(req AdminLoginRequest) (*AdminLoginResponse, error)
+	VerifyTwoFactorLogin(req VerifyTwoFactorRequest) (*AdminLoginResponse, error)
 	GetProfile(adminID string) (*Admin, error)
 
+	// Two-factor authentication
+	EnrollTwoFactor(adminID string) (*TwoFactorEnrollment, error)
+	ConfirmTwoFactor(adminID string, code string) (*TwoFactorBackupCodes, error)
+	DisableTwoFactor(adminID string, code string) error
+
 	// Admin management
 	CreateAdmin(adminID string, req CreateAdminRequest) (*Admin, error)
 	UpdateAdmin(adminID, targetAdminID string, req UpdateAdminRequest) (*Admin, error)
@@ -281,10 +581,14 @@ type AdminService interface {
 	GetUsers(req UserSearchRequest) ([]UserInfo, error)
 	GetUser(userID string) (*UserInfo, error)
 	UpdateUserStatus(adminID, userID string, req UpdateUserStatusRequest) error
-	GetMerchants(limit, offset int) ([]MerchantInfo, error)
-	GetDrivers(limit, offset int) ([]DriverInfo, error)
+	GetMerchants(req MerchantSearchRequest) (*MerchantPage, error)
+	GetDrivers(req DriverSearchRequest) (*DriverPage, error)
 	SuspendUser(adminID, userID string, reason string) error
 	ReactivateUser(adminID, userID string) error
+	BulkUpdateUserStatus(adminID string, req BulkUpdateUserStatusRequest) (*BulkUserStatusResponse, error)
+	ImpersonateUser(adminID, userID string, req ImpersonateUserRequest) (*ImpersonationToken, error)
+	// GetUserTimeline merges the user's order, delivery and payment events
+	GetUserTimeline(userID string) (*UserTimeline, error)
 
 	// Platform analytics
 	GetPlatformStats() (*PlatformStats, error)
@@ -298,7 +602,7 @@ type AdminService interface {
 
 	// Audit logging
 	LogAction(adminID, action, resource, resourceID string, details map[string]interface{}, ipAddress, userAgent string) error
-	GetAuditLogs(adminID string, limit, offset int) ([]AuditLog, error)
+	GetAuditLogs(filter AuditLogFilter) (*AuditLogPage, error)
 	GetResourceAuditLogs(resource string, limit, offset int) ([]AuditLog, error)
 }
 
@@ -307,20 +611,26 @@ type UserService interface {
 	GetUser(userID string) (*UserInfo, error)
 	GetUsers(req UserSearchRequest) ([]UserInfo, error)
 	UpdateUserStatus(userID string, status UserStatus, reason string) error
-	GetMerchants(limit, offset int) ([]MerchantInfo, error)
-	GetDrivers(limit, offset int) ([]DriverInfo, error)
+	GetMerchants(req MerchantSearchRequest) (*MerchantPage, error)
+	GetDrivers(req DriverSearchRequest) (*DriverPage, error)
 }
 
 type OrderService interface {
 	GetOrderStats(startDate, endDate time.Time) (map[string]int, error)
 	GetActiveOrdersCount() (int, error)
 	GetTotalOrdersCount() (int, error)
+	GetUserOrderEvents(userID string) ([]TimelineEvent, error)
 }
 
 type PaymentService interface {
 	GetRevenueStats(startDate, endDate time.Time) ([]RevenueStat, error)
 	GetTotalRevenue() (float64, error)
 	GetAverageOrderValue() (float64, error)
+	GetUserPaymentEvents(userID string) ([]TimelineEvent, error)
+}
+
+type DeliveryService interface {
+	GetUserDeliveryEvents(userID string) ([]TimelineEvent, error)
 }
 
 type CatalogService interface {
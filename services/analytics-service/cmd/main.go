@@ -4,12 +4,21 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
+	"glovo-backend/services/analytics-service/internal/adapters/cache"
 	httpHandler "glovo-backend/services/analytics-service/internal/adapters/http"
+	"glovo-backend/services/analytics-service/internal/adapters/subscriber"
 	"glovo-backend/services/analytics-service/internal/app"
 	"glovo-backend/services/analytics-service/internal/domain"
+	"glovo-backend/shared/config"
 	"glovo-backend/shared/database"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/health"
+	"glovo-backend/shared/logging"
+	"glovo-backend/shared/metrics"
+	"glovo-backend/shared/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -24,11 +33,17 @@ import (
 // @BasePath /
 
 func main() {
+	logging.Setup("analytics-service")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
 	}
 
+	// Fail fast on missing or malformed configuration
+	cfg := config.MustLoad("analytics-service", config.Postgres(), config.Auth(), config.EventBus(), config.HTTPPort("ANALYTICS_SERVICE_PORT", "8011"),
+		[]config.Var{{Key: "ANALYTICS_ROLLUP_AT", Kind: config.Duration, Default: "2h"}}) // nightly rollup time after UTC midnight
+
 	// Initialize database connection
 	postgresDB := database.ConnectPostgres()
 
@@ -38,17 +53,32 @@ func main() {
 		&domain.RevenueMetrics{},
 		&domain.DriverMetrics{},
 		&domain.MerchantMetrics{},
+		&domain.AnalyticsReport{},
+		&domain.AnalyticsEvent{},
+		&domain.OrderFact{},
+		&domain.OrderItemFact{},
+		&domain.UserFact{},
+		&domain.StoreRatingFact{},
 	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
-	// Initialize mock repositories and services for now
+	// Initialize repositories (build with -tags mock for in-memory fakes)
+	repos := newRepositories(postgresDB)
+
+	// Initialize mock services for now
 	// In a real implementation, these would be proper implementations
 	analyticsService := app.NewAnalyticsService(
-		NewMockPlatformRepo(),
-		NewMockRevenueRepo(),
-		NewMockDriverRepo(),
-		NewMockMerchantRepo(),
+		repos.platform,
+		repos.revenue,
+		repos.driver,
+		repos.merchant,
+		repos.source,
+		repos.rollup,
+		repos.report,
+		repos.event,
+		cache.NewMemoryCache(time.Duration(getEnvInt("ANALYTICS_CACHE_TTL_SECONDS", 60))*time.Second),
+		time.Duration(getEnvInt("ANALYTICS_REALTIME_INTERVAL_SECONDS", 5))*time.Second,
 		NewMockUserService(),
 		NewMockOrderService(),
 		NewMockPaymentService(),
@@ -57,20 +87,40 @@ func main() {
 		NewMockLocationService(),
 	)
 
+	// Orders, signups and store ratings arrive as events from the services that own them
+	eventBus := events.FromEnv()
+	defer eventBus.Close()
+	middleware.AuditImpersonation(eventBus, "analytics-service")
+	if err := subscriber.NewOrderSubscriber(analyticsService).Register(eventBus); err != nil {
+		log.Fatal("Failed to subscribe to order events:", err)
+	}
+	if err := subscriber.NewUserSubscriber(analyticsService).Register(eventBus); err != nil {
+		log.Fatal("Failed to subscribe to user events:", err)
+	}
+	if err := subscriber.NewStoreSubscriber(analyticsService).Register(eventBus); err != nil {
+		log.Fatal("Failed to subscribe to store events:", err)
+	}
+
+	// Nightly job: roll up the previous day into the metrics tables
+	go analyticsService.RunNightlyRollup(cfg.Duration("ANALYTICS_ROLLUP_AT"))
+
+	// Build queued custom reports in the background
+	go analyticsService.RunReportWorker(time.Minute)
+
 	// Setup Gin router
-	router := gin.Default()
-
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type,Authorization")
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-		c.Next()
-	})
+	router := gin.New()
+	// Client IPs come from X-Forwarded-For only behind TRUSTED_PROXIES
+	if err := router.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	router.Use(gin.Recovery(), middleware.RequestID(), middleware.RequestLogger())
+	router.Use(metrics.Middleware("analytics-service"))
+
+	// CORS, restricted to the origins in CORS_ALLOWED_ORIGINS
+	router.Use(middleware.CORS(middleware.CORSConfigFromEnv()))
+
+	// Prometheus metrics
+	router.GET("/metrics", metrics.Handler())
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -80,6 +130,9 @@ func main() {
 		})
 	})
 
+	// Readiness check, verifies database connectivity
+	router.GET("/health/ready", health.Ready("analytics-service", map[string]health.Check{"postgres": health.Postgres(postgresDB)}))
+
 	// Initialize HTTP handler
 	handler := httpHandler.NewAnalyticsHandler(analyticsService)
 
@@ -91,7 +144,7 @@ func main() {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Start server
-	port := getEnv("ANALYTICS_SERVICE_PORT", "8011")
+	port := cfg.String("ANALYTICS_SERVICE_PORT")
 	log.Printf("Analytics Service starting on port %s", port)
 
 	if err := router.Run(":" + port); err != nil {
@@ -99,72 +152,24 @@ func main() {
 	}
 }
 
-func getEnv(key, defaultValue string) string {
+func getEnvInt(key string, defaultValue int) int {
 	if value, exists := os.LookupEnv(key); exists {
-		return value
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
 	}
 	return defaultValue
 }
 
-// Mock implementations for quick startup
-type mockPlatformRepo struct{}
-
-func NewMockPlatformRepo() domain.PlatformMetricsRepository              { return &mockPlatformRepo{} }
-func (m *mockPlatformRepo) Create(metrics *domain.PlatformMetrics) error { return nil }
-func (m *mockPlatformRepo) GetByDate(date time.Time) (*domain.PlatformMetrics, error) {
-	return nil, nil
-}
-func (m *mockPlatformRepo) GetByDateRange(startDate, endDate time.Time) ([]domain.PlatformMetrics, error) {
-	return nil, nil
-}
-func (m *mockPlatformRepo) GetLatest() (*domain.PlatformMetrics, error)  { return nil, nil }
-func (m *mockPlatformRepo) Update(metrics *domain.PlatformMetrics) error { return nil }
-
-type mockRevenueRepo struct{}
-
-func NewMockRevenueRepo() domain.RevenueMetricsRepository                           { return &mockRevenueRepo{} }
-func (m *mockRevenueRepo) Create(metrics *domain.RevenueMetrics) error              { return nil }
-func (m *mockRevenueRepo) GetByDate(date time.Time) (*domain.RevenueMetrics, error) { return nil, nil }
-func (m *mockRevenueRepo) GetByDateRange(startDate, endDate time.Time) ([]domain.RevenueMetrics, error) {
-	return nil, nil
-}
-func (m *mockRevenueRepo) GetTotalRevenue() (float64, error) { return 125000.50, nil }
-func (m *mockRevenueRepo) GetRevenueByPeriod(startDate, endDate time.Time, interval string) ([]domain.RevenueStat, error) {
-	return nil, nil
-}
-
-type mockDriverRepo struct{}
-
-func NewMockDriverRepo() domain.DriverMetricsRepository              { return &mockDriverRepo{} }
-func (m *mockDriverRepo) Create(metrics *domain.DriverMetrics) error { return nil }
-func (m *mockDriverRepo) GetByDriverID(driverID string, startDate, endDate time.Time) ([]domain.DriverMetrics, error) {
-	return nil, nil
-}
-func (m *mockDriverRepo) GetTopDrivers(period string, limit int) ([]domain.DriverSummary, error) {
-	return nil, nil
-}
-func (m *mockDriverRepo) GetDriverPerformance(driverID string) (*domain.DriverMetrics, error) {
-	return nil, nil
-}
-func (m *mockDriverRepo) AggregateDriverMetrics(startDate, endDate time.Time) (*domain.DriverMetrics, error) {
-	return nil, nil
-}
-
-type mockMerchantRepo struct{}
-
-func NewMockMerchantRepo() domain.MerchantMetricsRepository              { return &mockMerchantRepo{} }
-func (m *mockMerchantRepo) Create(metrics *domain.MerchantMetrics) error { return nil }
-func (m *mockMerchantRepo) GetByMerchantID(merchantID string, startDate, endDate time.Time) ([]domain.MerchantMetrics, error) {
-	return nil, nil
-}
-func (m *mockMerchantRepo) GetTopMerchants(period string, limit int) ([]domain.MerchantSummary, error) {
-	return nil, nil
-}
-func (m *mockMerchantRepo) GetMerchantPerformance(merchantID string) (*domain.MerchantMetrics, error) {
-	return nil, nil
-}
-func (m *mockMerchantRepo) AggregateMerchantMetrics(startDate, endDate time.Time) (*domain.MerchantMetrics, error) {
-	return nil, nil
+type repositories struct {
+	platform domain.PlatformMetricsRepository
+	revenue  domain.RevenueMetricsRepository
+	driver   domain.DriverMetricsRepository
+	merchant domain.MerchantMetricsRepository
+	source   domain.SourceDataRepository
+	rollup   domain.RollupRepository
+	report   domain.ReportRepository
+	event    domain.EventRepository
 }
 
 // Mock services
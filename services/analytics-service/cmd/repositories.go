@@ -0,0 +1,22 @@
+//go:build !mock
+
+package main
+
+import (
+	"glovo-backend/services/analytics-service/internal/adapters/db"
+
+	"gorm.io/gorm"
+)
+
+func newRepositories(postgresDB *gorm.DB) repositories {
+	return repositories{
+		platform: db.NewPlatformMetricsRepository(postgresDB),
+		revenue:  db.NewRevenueMetricsRepository(postgresDB),
+		driver:   db.NewDriverMetricsRepository(postgresDB),
+		merchant: db.NewMerchantMetricsRepository(postgresDB),
+		source:   db.NewSourceDataRepository(postgresDB),
+		rollup:   db.NewRollupRepository(postgresDB),
+		report:   db.NewReportRepository(postgresDB),
+		event:    db.NewEventRepository(postgresDB),
+	}
+}
@@ -0,0 +1,163 @@
+//go:build mock
+
+package main
+
+import (
+	"errors"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+func newRepositories(_ *gorm.DB) repositories {
+	return repositories{
+		platform: NewMockPlatformRepo(),
+		revenue:  NewMockRevenueRepo(),
+		driver:   NewMockDriverRepo(),
+		merchant: NewMockMerchantRepo(),
+		source:   NewMockSourceRepo(),
+		rollup:   NewMockRollupRepo(),
+		report:   NewMockReportRepo(),
+		event:    NewMockEventRepo(),
+	}
+}
+
+// Mock implementations for quick startup
+type mockPlatformRepo struct{}
+
+func NewMockPlatformRepo() domain.PlatformMetricsRepository              { return &mockPlatformRepo{} }
+func (m *mockPlatformRepo) Create(metrics *domain.PlatformMetrics) error { return nil }
+func (m *mockPlatformRepo) GetByDate(date time.Time) (*domain.PlatformMetrics, error) {
+	return nil, nil
+}
+func (m *mockPlatformRepo) GetByDateRange(startDate, endDate time.Time) ([]domain.PlatformMetrics, error) {
+	return nil, nil
+}
+func (m *mockPlatformRepo) GetLatest() (*domain.PlatformMetrics, error)  { return nil, nil }
+func (m *mockPlatformRepo) Update(metrics *domain.PlatformMetrics) error { return nil }
+
+type mockRevenueRepo struct{}
+
+func NewMockRevenueRepo() domain.RevenueMetricsRepository                           { return &mockRevenueRepo{} }
+func (m *mockRevenueRepo) Create(metrics *domain.RevenueMetrics) error              { return nil }
+func (m *mockRevenueRepo) GetByDate(date time.Time) (*domain.RevenueMetrics, error) { return nil, nil }
+func (m *mockRevenueRepo) GetByDateRange(startDate, endDate time.Time) ([]domain.RevenueMetrics, error) {
+	return nil, nil
+}
+func (m *mockRevenueRepo) GetTotalRevenue() (float64, error) { return 125000.50, nil }
+func (m *mockRevenueRepo) GetRevenueByPeriod(startDate, endDate time.Time, interval string) ([]domain.RevenueStat, error) {
+	return nil, nil
+}
+
+type mockDriverRepo struct{}
+
+func NewMockDriverRepo() domain.DriverMetricsRepository              { return &mockDriverRepo{} }
+func (m *mockDriverRepo) Create(metrics *domain.DriverMetrics) error { return nil }
+func (m *mockDriverRepo) GetByDriverID(driverID string, startDate, endDate time.Time) ([]domain.DriverMetrics, error) {
+	return nil, nil
+}
+func (m *mockDriverRepo) GetTopDrivers(period string, limit int) ([]domain.DriverSummary, error) {
+	return nil, nil
+}
+func (m *mockDriverRepo) GetDriverPerformance(driverID string) (*domain.DriverMetrics, error) {
+	return nil, nil
+}
+func (m *mockDriverRepo) AggregateDriverMetrics(startDate, endDate time.Time) (*domain.DriverMetrics, error) {
+	return nil, nil
+}
+func (m *mockDriverRepo) AggregateByDriver(startDate, endDate time.Time) ([]domain.DriverMetrics, error) {
+	return nil, nil
+}
+
+type mockMerchantRepo struct{}
+
+func NewMockMerchantRepo() domain.MerchantMetricsRepository              { return &mockMerchantRepo{} }
+func (m *mockMerchantRepo) Create(metrics *domain.MerchantMetrics) error { return nil }
+func (m *mockMerchantRepo) GetByMerchantID(merchantID string, startDate, endDate time.Time) ([]domain.MerchantMetrics, error) {
+	return nil, nil
+}
+func (m *mockMerchantRepo) GetTopMerchants(period string, limit int) ([]domain.MerchantSummary, error) {
+	return nil, nil
+}
+func (m *mockMerchantRepo) GetMerchantPerformance(merchantID string) (*domain.MerchantMetrics, error) {
+	return nil, nil
+}
+func (m *mockMerchantRepo) AggregateMerchantMetrics(startDate, endDate time.Time) (*domain.MerchantMetrics, error) {
+	return nil, nil
+}
+func (m *mockMerchantRepo) AggregateByMerchant(startDate, endDate time.Time) ([]domain.MerchantMetrics, error) {
+	return nil, nil
+}
+
+type mockSourceRepo struct{}
+
+func NewMockSourceRepo() domain.SourceDataRepository                { return &mockSourceRepo{} }
+func (m *mockSourceRepo) UpsertOrder(order *domain.OrderFact) error { return nil }
+func (m *mockSourceRepo) UpsertUser(user *domain.UserFact) error    { return nil }
+func (m *mockSourceRepo) UpsertStoreRating(rating *domain.StoreRatingFact) error {
+	return nil
+}
+func (m *mockSourceRepo) GetPlatformRollup(startDate, endDate time.Time) (*domain.PlatformMetrics, error) {
+	return &domain.PlatformMetrics{}, nil
+}
+func (m *mockSourceRepo) GetRevenueRollup(startDate, endDate time.Time) (*domain.RevenueMetrics, error) {
+	return &domain.RevenueMetrics{}, nil
+}
+func (m *mockSourceRepo) GetDriverRollups(startDate, endDate time.Time) ([]domain.DriverMetrics, error) {
+	return nil, nil
+}
+func (m *mockSourceRepo) GetMerchantRollups(startDate, endDate time.Time) ([]domain.MerchantMetrics, error) {
+	return nil, nil
+}
+func (m *mockSourceRepo) GetCohortSizes(granularity string, since time.Time) ([]domain.CohortActivity, error) {
+	return nil, nil
+}
+func (m *mockSourceRepo) GetCohortActivity(granularity string, since time.Time) ([]domain.CohortActivity, error) {
+	return nil, nil
+}
+func (m *mockSourceRepo) GetRevenueTrend(unit string, loc *time.Location, startDate, endDate time.Time) ([]domain.TrendPoint, error) {
+	return nil, nil
+}
+func (m *mockSourceRepo) GetOrderTrend(unit string, loc *time.Location, startDate, endDate time.Time) ([]domain.TrendPoint, error) {
+	return nil, nil
+}
+func (m *mockSourceRepo) GetOrdersByHour(loc *time.Location, startDate, endDate time.Time) ([]domain.HourlyOrders, error) {
+	return nil, nil
+}
+func (m *mockSourceRepo) GetMerchantDailySales(merchantID string, startDate, endDate time.Time) ([]domain.DailySales, error) {
+	return nil, nil
+}
+
+type mockRollupRepo struct{}
+
+func NewMockRollupRepo() domain.RollupRepository                { return &mockRollupRepo{} }
+func (m *mockRollupRepo) Save(rollup *domain.DailyRollup) error { return nil }
+
+type mockReportRepo struct{}
+
+func NewMockReportRepo() domain.ReportRepository                      { return &mockReportRepo{} }
+func (m *mockReportRepo) Create(report *domain.AnalyticsReport) error { return nil }
+func (m *mockReportRepo) GetByID(id string) (*domain.AnalyticsReport, error) {
+	return nil, errors.New("report not found")
+}
+func (m *mockReportRepo) GetByCreator(createdBy string) ([]domain.AnalyticsReport, error) {
+	return nil, nil
+}
+func (m *mockReportRepo) GetByStatus(status domain.ReportStatus) ([]domain.AnalyticsReport, error) {
+	return nil, nil
+}
+func (m *mockReportRepo) Update(report *domain.AnalyticsReport) error { return nil }
+func (m *mockReportRepo) Delete(id string) error                      { return nil }
+
+type mockEventRepo struct{}
+
+func NewMockEventRepo() domain.EventRepository                     { return &mockEventRepo{} }
+func (m *mockEventRepo) Create(event *domain.AnalyticsEvent) error { return nil }
+func (m *mockEventRepo) CountFunnel(stages []string, startDate, endDate time.Time) ([]int, error) {
+	return make([]int, len(stages)), nil
+}
+func (m *mockEventRepo) CountByPickupCell(eventTypes []string, cellSize float64, startDate, endDate time.Time) ([]domain.HeatmapCell, error) {
+	return nil, nil
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+// memoryCache is an in-process TTL cache. Values are stored JSON-encoded so
+// it behaves like a networked cache (e.g. Redis) implementing the same port.
+// Expired entries are swept out on write, at most once per TTL, so keys that
+// are never read again don't accumulate.
+type memoryCache struct {
+	ttl       time.Duration
+	mu        sync.RWMutex
+	entries   map[string]cacheEntry
+	lastSweep time.Time
+}
+
+type cacheEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+func NewMemoryCache(ttl time.Duration) domain.Cache {
+	return &memoryCache{
+		ttl:       ttl,
+		entries:   make(map[string]cacheEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+func (c *memoryCache) Get(key string, dest interface{}) bool {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		return false
+	}
+
+	return json.Unmarshal(entry.data, dest) == nil
+}
+
+func (c *memoryCache) Set(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	if now.Sub(c.lastSweep) >= c.ttl {
+		c.sweep(now)
+	}
+	c.entries[key] = cacheEntry{data: data, expiresAt: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return nil
+}
+
+// sweep drops expired entries; the caller must hold the write lock
+func (c *memoryCache) sweep(now time.Time) {
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	c.lastSweep = now
+}
+
+func (c *memoryCache) Clear() error {
+	c.mu.Lock()
+	c.entries = make(map[string]cacheEntry)
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	c := NewMemoryCache(20 * time.Millisecond)
+
+	if err := c.Set("platform_stats", map[string]int{"orders": 3}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	var got map[string]int
+	if !c.Get("platform_stats", &got) {
+		t.Fatalf("miss within the TTL")
+	}
+	if got["orders"] != 3 {
+		t.Fatalf("orders = %d, want 3", got["orders"])
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if c.Get("platform_stats", &got) {
+		t.Fatalf("hit after the TTL expired")
+	}
+}
+
+func TestMemoryCacheEvictsExpiredEntries(t *testing.T) {
+	c := NewMemoryCache(20 * time.Millisecond).(*memoryCache)
+
+	c.Set("revenue_overview:day", 1)
+	c.Set("revenue_overview:week", 2)
+
+	time.Sleep(30 * time.Millisecond)
+	c.Set("revenue_overview:month", 3)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.entries) != 1 {
+		t.Fatalf("%d entries after the sweep, want 1", len(c.entries))
+	}
+	if _, ok := c.entries["revenue_overview:month"]; !ok {
+		t.Fatalf("live entry was evicted")
+	}
+}
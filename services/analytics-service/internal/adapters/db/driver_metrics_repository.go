@@ -0,0 +1,88 @@
+package db
+
+import (
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type driverMetricsRepository struct {
+	db *gorm.DB
+}
+
+func NewDriverMetricsRepository(db *gorm.DB) domain.DriverMetricsRepository {
+	return &driverMetricsRepository{db: db}
+}
+
+// driverAggregate sums daily driver rows into a single DriverMetrics
+const driverAggregate = `COALESCE(SUM(total_deliveries), 0) AS total_deliveries,
+	COALESCE(SUM(completed_deliveries), 0) AS completed_deliveries,
+	COALESCE(SUM(cancelled_deliveries), 0) AS cancelled_deliveries,
+	COALESCE(SUM(on_time_deliveries), 0) AS on_time_deliveries,
+	COALESCE(SUM(total_earnings), 0) AS total_earnings,
+	COALESCE(AVG(average_rating), 0) AS average_rating,
+	COALESCE(SUM(online_hours), 0) AS online_hours,
+	COALESCE(SUM(distance_traveled), 0) AS distance_traveled,
+	COALESCE(AVG(acceptance_rate), 0) AS acceptance_rate`
+
+func (r *driverMetricsRepository) Create(metrics *domain.DriverMetrics) error {
+	return r.db.Create(metrics).Error
+}
+
+func (r *driverMetricsRepository) GetByDriverID(driverID string, startDate, endDate time.Time) ([]domain.DriverMetrics, error) {
+	var metrics []domain.DriverMetrics
+	err := r.db.Where("driver_id = ? AND date >= ? AND date <= ?", driverID, startDate, endDate).
+		Order("date ASC").
+		Find(&metrics).Error
+	return metrics, err
+}
+
+func (r *driverMetricsRepository) GetTopDrivers(period string, limit int) ([]domain.DriverSummary, error) {
+	var drivers []domain.DriverSummary
+	err := r.db.Model(&domain.DriverMetrics{}).
+		Select("driver_id AS id, SUM(completed_deliveries) AS deliveries, AVG(average_rating) AS rating, SUM(total_earnings) AS earnings").
+		Where("date >= ?", periodStart(period)).
+		Group("driver_id").
+		Order("deliveries DESC").
+		Limit(limit).
+		Scan(&drivers).Error
+	return drivers, err
+}
+
+func (r *driverMetricsRepository) GetDriverPerformance(driverID string) (*domain.DriverMetrics, error) {
+	var metrics domain.DriverMetrics
+	err := r.db.Model(&domain.DriverMetrics{}).
+		Select("driver_id, "+driverAggregate).
+		Where("driver_id = ?", driverID).
+		Group("driver_id").
+		Scan(&metrics).Error
+	if err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+func (r *driverMetricsRepository) AggregateDriverMetrics(startDate, endDate time.Time) (*domain.DriverMetrics, error) {
+	var metrics domain.DriverMetrics
+	err := r.db.Model(&domain.DriverMetrics{}).
+		Select(driverAggregate).
+		Where("date >= ? AND date <= ?", startDate, endDate).
+		Scan(&metrics).Error
+	if err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+// AggregateByDriver returns one aggregated row per driver with activity in the range
+func (r *driverMetricsRepository) AggregateByDriver(startDate, endDate time.Time) ([]domain.DriverMetrics, error) {
+	var metrics []domain.DriverMetrics
+	err := r.db.Model(&domain.DriverMetrics{}).
+		Select("driver_id, "+driverAggregate).
+		Where("date >= ? AND date <= ?", startDate, endDate).
+		Group("driver_id").
+		Scan(&metrics).Error
+	return metrics, err
+}
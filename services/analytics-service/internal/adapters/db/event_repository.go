@@ -0,0 +1,86 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type eventRepository struct {
+	db *gorm.DB
+}
+
+func NewEventRepository(db *gorm.DB) domain.EventRepository {
+	return &eventRepository{db: db}
+}
+
+func (r *eventRepository) Create(event *domain.AnalyticsEvent) error {
+	return r.db.Create(event).Error
+}
+
+// CountFunnel walks the stages in order: a user reaches a stage at their
+// first event of its type no earlier than when they reached the previous one
+func (r *eventRepository) CountFunnel(stages []string, startDate, endDate time.Time) ([]int, error) {
+	if len(stages) == 0 {
+		return nil, nil
+	}
+
+	var ctes, counts []string
+	var args []interface{}
+	for i, stage := range stages {
+		if i == 0 {
+			ctes = append(ctes, `stage_0 AS (
+				SELECT user_id, MIN(occurred_at) AS reached_at FROM analytics_events
+				WHERE event_type = ? AND occurred_at >= ? AND occurred_at < ?
+				GROUP BY user_id)`)
+		} else {
+			ctes = append(ctes, fmt.Sprintf(`stage_%d AS (
+				SELECT e.user_id, MIN(e.occurred_at) AS reached_at FROM analytics_events e
+				JOIN stage_%d p ON p.user_id = e.user_id AND e.occurred_at >= p.reached_at
+				WHERE e.event_type = ? AND e.occurred_at >= ? AND e.occurred_at < ?
+				GROUP BY e.user_id)`, i, i-1))
+		}
+		args = append(args, stage, startDate, endDate)
+		counts = append(counts, fmt.Sprintf("SELECT %d AS stage, COUNT(*) AS users FROM stage_%d", i, i))
+	}
+
+	var rows []struct {
+		Stage int
+		Users int
+	}
+	query := "WITH " + strings.Join(ctes, ", ") + " " + strings.Join(counts, " UNION ALL ")
+	if err := r.db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	users := make([]int, len(stages))
+	for _, row := range rows {
+		users[row.Stage] = row.Users
+	}
+	return users, nil
+}
+
+// numericProperty matches property values that can be cast to a number
+const numericProperty = `^-?[0-9]+(\.[0-9]+)?$`
+
+func (r *eventRepository) CountByPickupCell(eventTypes []string, cellSize float64, startDate, endDate time.Time) ([]domain.HeatmapCell, error) {
+	// Properties is stored as JSON text
+	latitude := "CAST(properties AS jsonb)->>'" + domain.PropertyPickupLatitude + "'"
+	longitude := "CAST(properties AS jsonb)->>'" + domain.PropertyPickupLongitude + "'"
+
+	var cells []domain.HeatmapCell
+	err := r.db.Model(&domain.AnalyticsEvent{}).
+		Select(`FLOOR(CAST(`+latitude+` AS double precision) / ?) AS cell_row,
+			FLOOR(CAST(`+longitude+` AS double precision) / ?) AS cell_column,
+			COUNT(*) AS count`, cellSize, cellSize).
+		Where("event_type IN ? AND occurred_at >= ? AND occurred_at < ?", eventTypes, startDate, endDate).
+		// Events without numeric pickup coordinates are skipped
+		Where(latitude+" ~ ? AND "+longitude+" ~ ?", numericProperty, numericProperty).
+		Group("cell_row, cell_column").
+		Scan(&cells).Error
+	return cells, err
+}
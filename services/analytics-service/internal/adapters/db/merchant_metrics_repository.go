@@ -0,0 +1,89 @@
+package db
+
+import (
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type merchantMetricsRepository struct {
+	db *gorm.DB
+}
+
+func NewMerchantMetricsRepository(db *gorm.DB) domain.MerchantMetricsRepository {
+	return &merchantMetricsRepository{db: db}
+}
+
+// merchantAggregate sums daily merchant rows into a single MerchantMetrics,
+// weighting each day's rating by how many ratings it had
+const merchantAggregate = `COALESCE(SUM(total_orders), 0) AS total_orders,
+	COALESCE(SUM(completed_orders), 0) AS completed_orders,
+	COALESCE(SUM(cancelled_orders), 0) AS cancelled_orders,
+	COALESCE(SUM(on_time_orders), 0) AS on_time_orders,
+	COALESCE(SUM(total_revenue), 0) AS total_revenue,
+	COALESCE(SUM(commission), 0) AS commission,
+	COALESCE(SUM(net_revenue), 0) AS net_revenue,
+	COALESCE(SUM(average_rating * total_ratings) / NULLIF(SUM(total_ratings), 0), 0) AS average_rating,
+	COALESCE(SUM(total_ratings), 0) AS total_ratings`
+
+func (r *merchantMetricsRepository) Create(metrics *domain.MerchantMetrics) error {
+	return r.db.Create(metrics).Error
+}
+
+func (r *merchantMetricsRepository) GetByMerchantID(merchantID string, startDate, endDate time.Time) ([]domain.MerchantMetrics, error) {
+	var metrics []domain.MerchantMetrics
+	err := r.db.Where("merchant_id = ? AND date >= ? AND date <= ?", merchantID, startDate, endDate).
+		Order("date ASC").
+		Find(&metrics).Error
+	return metrics, err
+}
+
+func (r *merchantMetricsRepository) GetTopMerchants(period string, limit int) ([]domain.MerchantSummary, error) {
+	var merchants []domain.MerchantSummary
+	err := r.db.Model(&domain.MerchantMetrics{}).
+		Select("merchant_id AS id, SUM(completed_orders) AS orders, SUM(total_revenue) AS revenue, AVG(average_rating) AS rating").
+		Where("date >= ?", periodStart(period)).
+		Group("merchant_id").
+		Order("revenue DESC").
+		Limit(limit).
+		Scan(&merchants).Error
+	return merchants, err
+}
+
+func (r *merchantMetricsRepository) GetMerchantPerformance(merchantID string) (*domain.MerchantMetrics, error) {
+	var metrics domain.MerchantMetrics
+	err := r.db.Model(&domain.MerchantMetrics{}).
+		Select("merchant_id, "+merchantAggregate).
+		Where("merchant_id = ?", merchantID).
+		Group("merchant_id").
+		Scan(&metrics).Error
+	if err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+func (r *merchantMetricsRepository) AggregateMerchantMetrics(startDate, endDate time.Time) (*domain.MerchantMetrics, error) {
+	var metrics domain.MerchantMetrics
+	err := r.db.Model(&domain.MerchantMetrics{}).
+		Select(merchantAggregate).
+		Where("date >= ? AND date <= ?", startDate, endDate).
+		Scan(&metrics).Error
+	if err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+// AggregateByMerchant returns one aggregated row per merchant with activity in the range
+func (r *merchantMetricsRepository) AggregateByMerchant(startDate, endDate time.Time) ([]domain.MerchantMetrics, error) {
+	var metrics []domain.MerchantMetrics
+	err := r.db.Model(&domain.MerchantMetrics{}).
+		Select("merchant_id, "+merchantAggregate).
+		Where("date >= ? AND date <= ?", startDate, endDate).
+		Group("merchant_id").
+		Scan(&metrics).Error
+	return metrics, err
+}
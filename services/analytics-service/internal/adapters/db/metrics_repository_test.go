@@ -0,0 +1,91 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+func day(d int) time.Time {
+	return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPlatformMetricsGetByDateRange(t *testing.T) {
+	repo := NewPlatformMetricsRepository(testDB(t))
+	// Created out of order to check the range comes back sorted
+	for _, d := range []int{12, 10, 14, 11, 9} {
+		if err := repo.Create(&domain.PlatformMetrics{ID: day(d).Format("platform-2006-01-02"), Date: day(d), CompletedOrders: d}); err != nil {
+			t.Fatalf("seed day %d: %v", d, err)
+		}
+	}
+
+	metrics, err := repo.GetByDateRange(day(10), day(12))
+	if err != nil {
+		t.Fatalf("GetByDateRange: %v", err)
+	}
+	// Both ends are inclusive
+	want := []int{10, 11, 12}
+	if len(metrics) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(metrics), len(want))
+	}
+	for i, row := range metrics {
+		if !row.Date.Equal(day(want[i])) || row.CompletedOrders != want[i] {
+			t.Fatalf("row %d = %s with %d orders, want day %d", i, row.Date, row.CompletedOrders, want[i])
+		}
+	}
+
+	metrics, err = repo.GetByDateRange(day(20), day(25))
+	if err != nil {
+		t.Fatalf("GetByDateRange: %v", err)
+	}
+	if len(metrics) != 0 {
+		t.Fatalf("got %d rows for an empty range, want none", len(metrics))
+	}
+}
+
+func TestRevenueMetricsGetByDateRange(t *testing.T) {
+	repo := NewRevenueMetricsRepository(testDB(t))
+	for _, d := range []int{9, 10, 11, 12} {
+		if err := repo.Create(&domain.RevenueMetrics{ID: day(d).Format("revenue-2006-01-02"), Date: day(d), TotalRevenue: float64(d * 100), OrderCount: d}); err != nil {
+			t.Fatalf("seed day %d: %v", d, err)
+		}
+	}
+
+	metrics, err := repo.GetByDateRange(day(10), day(11))
+	if err != nil {
+		t.Fatalf("GetByDateRange: %v", err)
+	}
+	if len(metrics) != 2 || !metrics[0].Date.Equal(day(10)) || !metrics[1].Date.Equal(day(11)) {
+		t.Fatalf("rows = %+v, want days 10 and 11", metrics)
+	}
+	if metrics[0].TotalRevenue != 1000 || metrics[1].TotalRevenue != 1100 {
+		t.Fatalf("revenue = %v and %v, want 1000 and 1100", metrics[0].TotalRevenue, metrics[1].TotalRevenue)
+	}
+}
+
+func TestGetTotalRevenue(t *testing.T) {
+	repo := NewRevenueMetricsRepository(testDB(t))
+
+	total, err := repo.GetTotalRevenue()
+	if err != nil {
+		t.Fatalf("GetTotalRevenue: %v", err)
+	}
+	if total != 0 {
+		t.Fatalf("total = %v without rows, want 0", total)
+	}
+
+	for i, revenue := range []float64{1250.5, 980.25, 0, 1500} {
+		if err := repo.Create(&domain.RevenueMetrics{ID: day(i + 1).Format("revenue-2006-01-02"), Date: day(i + 1), TotalRevenue: revenue}); err != nil {
+			t.Fatalf("seed revenue %v: %v", revenue, err)
+		}
+	}
+
+	total, err = repo.GetTotalRevenue()
+	if err != nil {
+		t.Fatalf("GetTotalRevenue: %v", err)
+	}
+	if total != 3730.75 {
+		t.Fatalf("total = %v, want 3730.75", total)
+	}
+}
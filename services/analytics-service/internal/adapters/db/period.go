@@ -0,0 +1,30 @@
+package db
+
+import "time"
+
+// periodStart returns the start of a named reporting period ending now
+func periodStart(period string) time.Time {
+	now := time.Now()
+	switch period {
+	case "today", "day":
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	case "week":
+		return now.AddDate(0, 0, -7)
+	case "year":
+		return now.AddDate(-1, 0, 0)
+	default:
+		return now.AddDate(0, -1, 0)
+	}
+}
+
+// truncUnit maps a reporting interval to a Postgres date_trunc unit
+func truncUnit(interval string) string {
+	switch interval {
+	case "weekly", "week":
+		return "week"
+	case "monthly", "month":
+		return "month"
+	default:
+		return "day"
+	}
+}
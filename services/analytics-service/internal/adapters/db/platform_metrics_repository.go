@@ -0,0 +1,51 @@
+package db
+
+import (
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type platformMetricsRepository struct {
+	db *gorm.DB
+}
+
+func NewPlatformMetricsRepository(db *gorm.DB) domain.PlatformMetricsRepository {
+	return &platformMetricsRepository{db: db}
+}
+
+func (r *platformMetricsRepository) Create(metrics *domain.PlatformMetrics) error {
+	return r.db.Create(metrics).Error
+}
+
+func (r *platformMetricsRepository) GetByDate(date time.Time) (*domain.PlatformMetrics, error) {
+	var metrics domain.PlatformMetrics
+	err := r.db.Where("date = ?", date).First(&metrics).Error
+	if err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+func (r *platformMetricsRepository) GetByDateRange(startDate, endDate time.Time) ([]domain.PlatformMetrics, error) {
+	var metrics []domain.PlatformMetrics
+	err := r.db.Where("date >= ? AND date <= ?", startDate, endDate).
+		Order("date ASC").
+		Find(&metrics).Error
+	return metrics, err
+}
+
+func (r *platformMetricsRepository) GetLatest() (*domain.PlatformMetrics, error) {
+	var metrics domain.PlatformMetrics
+	err := r.db.Order("date DESC").First(&metrics).Error
+	if err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+func (r *platformMetricsRepository) Update(metrics *domain.PlatformMetrics) error {
+	return r.db.Save(metrics).Error
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"glovo-backend/services/analytics-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type reportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) domain.ReportRepository {
+	return &reportRepository{db: db}
+}
+
+func (r *reportRepository) Create(report *domain.AnalyticsReport) error {
+	return r.db.Create(report).Error
+}
+
+func (r *reportRepository) GetByID(id string) (*domain.AnalyticsReport, error) {
+	var report domain.AnalyticsReport
+	err := r.db.Where("id = ?", id).First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *reportRepository) GetByCreator(createdBy string) ([]domain.AnalyticsReport, error) {
+	var reports []domain.AnalyticsReport
+	err := r.db.Where("created_by = ?", createdBy).
+		Order("created_at DESC").
+		Find(&reports).Error
+	return reports, err
+}
+
+func (r *reportRepository) GetByStatus(status domain.ReportStatus) ([]domain.AnalyticsReport, error) {
+	var reports []domain.AnalyticsReport
+	err := r.db.Where("status = ?", status).
+		Order("created_at ASC").
+		Find(&reports).Error
+	return reports, err
+}
+
+func (r *reportRepository) Update(report *domain.AnalyticsReport) error {
+	return r.db.Save(report).Error
+}
+
+func (r *reportRepository) Delete(id string) error {
+	return r.db.Delete(&domain.AnalyticsReport{}, "id = ?", id).Error
+}
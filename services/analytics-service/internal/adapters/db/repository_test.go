@@ -0,0 +1,28 @@
+package db
+
+import (
+	"testing"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+	"glovo-backend/shared/database/dbtest"
+
+	"gorm.io/gorm"
+)
+
+// testDB returns a dbtest transaction with the analytics service's tables migrated
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return dbtest.Open(t, func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&domain.PlatformMetrics{},
+			&domain.RevenueMetrics{},
+			&domain.DriverMetrics{},
+			&domain.MerchantMetrics{},
+			&domain.AnalyticsEvent{},
+			&domain.OrderFact{},
+			&domain.OrderItemFact{},
+			&domain.UserFact{},
+			&domain.StoreRatingFact{},
+		)
+	})
+}
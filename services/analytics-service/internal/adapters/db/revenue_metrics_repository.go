@@ -0,0 +1,74 @@
+package db
+
+import (
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type revenueMetricsRepository struct {
+	db *gorm.DB
+}
+
+func NewRevenueMetricsRepository(db *gorm.DB) domain.RevenueMetricsRepository {
+	return &revenueMetricsRepository{db: db}
+}
+
+func (r *revenueMetricsRepository) Create(metrics *domain.RevenueMetrics) error {
+	return r.db.Create(metrics).Error
+}
+
+func (r *revenueMetricsRepository) GetByDate(date time.Time) (*domain.RevenueMetrics, error) {
+	var metrics domain.RevenueMetrics
+	err := r.db.Where("date = ?", date).First(&metrics).Error
+	if err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+func (r *revenueMetricsRepository) GetByDateRange(startDate, endDate time.Time) ([]domain.RevenueMetrics, error) {
+	var metrics []domain.RevenueMetrics
+	err := r.db.Where("date >= ? AND date <= ?", startDate, endDate).
+		Order("date ASC").
+		Find(&metrics).Error
+	return metrics, err
+}
+
+func (r *revenueMetricsRepository) GetTotalRevenue() (float64, error) {
+	var total float64
+	err := r.db.Model(&domain.RevenueMetrics{}).
+		Select("COALESCE(SUM(total_revenue), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *revenueMetricsRepository) GetRevenueByPeriod(startDate, endDate time.Time, interval string) ([]domain.RevenueStat, error) {
+	var rows []struct {
+		Period  time.Time
+		Revenue float64
+		Orders  int
+	}
+
+	err := r.db.Model(&domain.RevenueMetrics{}).
+		Select("date_trunc(?, date) AS period, SUM(total_revenue) AS revenue, SUM(order_count) AS orders", truncUnit(interval)).
+		Where("date >= ? AND date <= ?", startDate, endDate).
+		Group("period").
+		Order("period ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]domain.RevenueStat, len(rows))
+	for i, row := range rows {
+		stats[i] = domain.RevenueStat{
+			Period:  row.Period.Format("2006-01-02"),
+			Revenue: row.Revenue,
+			Orders:  row.Orders,
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"glovo-backend/services/analytics-service/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type rollupRepository struct {
+	db *gorm.DB
+}
+
+func NewRollupRepository(db *gorm.DB) domain.RollupRepository {
+	return &rollupRepository{db: db}
+}
+
+// platformMetricsColumns are refreshed when a rollup for an existing date is saved
+var platformMetricsColumns = []string{"total_users", "total_merchants", "total_drivers", "active_orders", "completed_orders", "cancelled_orders", "total_revenue", "daily_revenue", "average_order_value", "updated_at"}
+
+// revenueMetricsColumns are refreshed when a rollup for an existing date is saved
+var revenueMetricsColumns = []string{"total_revenue", "order_commission", "delivery_fees", "driver_payouts", "merchant_payouts", "net_profit", "order_count"}
+
+// Save replaces the metrics of rollup.Date in one transaction. Driver and
+// merchant rows for the date are rewritten, so ones that no longer have
+// orders that day are dropped.
+func (r *rollupRepository) Save(rollup *domain.DailyRollup) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "date"}},
+			DoUpdates: clause.AssignmentColumns(platformMetricsColumns),
+		}).Create(rollup.Platform).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "date"}},
+			DoUpdates: clause.AssignmentColumns(revenueMetricsColumns),
+		}).Create(rollup.Revenue).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("date = ?", rollup.Date).Delete(&domain.DriverMetrics{}).Error; err != nil {
+			return err
+		}
+		if len(rollup.Drivers) > 0 {
+			if err := tx.Create(&rollup.Drivers).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("date = ?", rollup.Date).Delete(&domain.MerchantMetrics{}).Error; err != nil {
+			return err
+		}
+		if len(rollup.Merchants) > 0 {
+			if err := tx.Create(&rollup.Merchants).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+func minutes(m int) *int { return &m }
+
+func at(d, hour, minute int) *time.Time {
+	t := day(d).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	return &t
+}
+
+// seedRollupSources records users and orders around day 10
+func seedRollupSources(t *testing.T, sources domain.SourceDataRepository) {
+	t.Helper()
+	users := []domain.UserFact{
+		{ID: "customer-1", Role: "customer", RegisteredAt: day(1)},
+		{ID: "customer-2", Role: "customer", RegisteredAt: *at(10, 9, 0)},
+		{ID: "customer-3", Role: "customer", RegisteredAt: day(11)},
+		{ID: "merchant-1", Role: "merchant", RegisteredAt: day(1)},
+		{ID: "driver-1", Role: "driver", RegisteredAt: day(1)},
+	}
+	for i := range users {
+		if err := sources.UpsertUser(&users[i]); err != nil {
+			t.Fatalf("seed user %s: %v", users[i].ID, err)
+		}
+	}
+
+	orders := []domain.OrderFact{
+		// Delivered on time
+		{ID: "order-1", CustomerID: "customer-1", MerchantID: "merchant-1", DriverID: "driver-1", Status: orderStatusDelivered,
+			TotalAmount: 20, DeliveryFee: 3, ServiceFee: 2, TaxAmount: 1, FinalAmount: 26, EstimatedTime: minutes(45),
+			PlacedAt: *at(10, 12, 0), CompletedAt: at(10, 12, 30), UpdatedAt: *at(10, 12, 30)},
+		// Delivered late
+		{ID: "order-2", CustomerID: "customer-2", MerchantID: "merchant-1", DriverID: "driver-2", Status: orderStatusDelivered,
+			TotalAmount: 30, DeliveryFee: 4, ServiceFee: 3, TaxAmount: 1.5, FinalAmount: 38.5, EstimatedTime: minutes(30),
+			PlacedAt: *at(10, 18, 0), CompletedAt: at(10, 19, 30), UpdatedAt: *at(10, 19, 30)},
+		{ID: "order-3", CustomerID: "customer-1", MerchantID: "merchant-1", DriverID: "driver-1", Status: orderStatusCancelled,
+			FinalAmount: 15, PlacedAt: *at(10, 20, 0), CancelledAt: at(10, 20, 10), UpdatedAt: *at(10, 20, 10)},
+		{ID: "order-4", CustomerID: "customer-2", MerchantID: "merchant-1", Status: "preparing",
+			TotalAmount: 10, DeliveryFee: 2, FinalAmount: 12, PlacedAt: *at(10, 22, 0), UpdatedAt: *at(10, 22, 0)},
+		// The day before only adds to the running total
+		{ID: "order-5", CustomerID: "customer-1", MerchantID: "merchant-1", DriverID: "driver-1", Status: orderStatusDelivered,
+			TotalAmount: 8, DeliveryFee: 2, FinalAmount: 10, PlacedAt: *at(9, 12, 0), CompletedAt: at(9, 12, 40), UpdatedAt: *at(9, 12, 40)},
+	}
+	for i := range orders {
+		if err := sources.UpsertOrder(&orders[i]); err != nil {
+			t.Fatalf("seed order %s: %v", orders[i].ID, err)
+		}
+	}
+}
+
+// rollUp builds and saves one day's metrics the way AggregateDate does,
+// with new row IDs on every run
+func rollUp(t *testing.T, sources domain.SourceDataRepository, rollups domain.RollupRepository, date time.Time, run int) {
+	t.Helper()
+	end := date.AddDate(0, 0, 1)
+	id := func(kind string, i int) string { return fmt.Sprintf("%s-%d-%d", kind, run, i) }
+
+	platform, err := sources.GetPlatformRollup(date, end)
+	if err != nil {
+		t.Fatalf("GetPlatformRollup: %v", err)
+	}
+	platform.ID, platform.Date = id("platform", 0), date
+	revenue, err := sources.GetRevenueRollup(date, end)
+	if err != nil {
+		t.Fatalf("GetRevenueRollup: %v", err)
+	}
+	revenue.ID, revenue.Date = id("revenue", 0), date
+	drivers, err := sources.GetDriverRollups(date, end)
+	if err != nil {
+		t.Fatalf("GetDriverRollups: %v", err)
+	}
+	for i := range drivers {
+		drivers[i].ID, drivers[i].Date = id("driver", i), date
+	}
+	merchants, err := sources.GetMerchantRollups(date, end)
+	if err != nil {
+		t.Fatalf("GetMerchantRollups: %v", err)
+	}
+	for i := range merchants {
+		merchants[i].ID, merchants[i].Date = id("merchant", i), date
+	}
+
+	err = rollups.Save(&domain.DailyRollup{Date: date, Platform: platform, Revenue: revenue, Drivers: drivers, Merchants: merchants})
+	if err != nil {
+		t.Fatalf("Save run %d: %v", run, err)
+	}
+}
+
+// countRows counts the rows of model saved for date
+func countRows(t *testing.T, db *gorm.DB, model interface{}, date time.Time) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(model).Where("date = ?", date).Count(&n).Error; err != nil {
+		t.Fatalf("count %T: %v", model, err)
+	}
+	return n
+}
+
+func TestDailyRollup(t *testing.T) {
+	db := testDB(t)
+	sources := NewSourceDataRepository(db)
+	rollups := NewRollupRepository(db)
+	seedRollupSources(t, sources)
+
+	// Running the same day twice leaves one set of metrics
+	rollUp(t, sources, rollups, day(10), 1)
+	rollUp(t, sources, rollups, day(10), 2)
+
+	for _, model := range []interface{}{&domain.PlatformMetrics{}, &domain.RevenueMetrics{}, &domain.MerchantMetrics{}} {
+		if n := countRows(t, db, model, day(10)); n != 1 {
+			t.Fatalf("%d %T rows for the day, want 1", n, model)
+		}
+	}
+	if n := countRows(t, db, &domain.DriverMetrics{}, day(10)); n != 2 {
+		t.Fatalf("%d driver rows for the day, want 2", n)
+	}
+
+	platform, err := NewPlatformMetricsRepository(db).GetByDate(day(10))
+	if err != nil {
+		t.Fatalf("GetByDate: %v", err)
+	}
+	wantPlatform := domain.PlatformMetrics{
+		TotalUsers: 2, TotalMerchants: 1, TotalDrivers: 1,
+		ActiveOrders: 1, CompletedOrders: 2, CancelledOrders: 1,
+		TotalRevenue: 74.5, DailyRevenue: 64.5, AverageOrderValue: 32.25,
+	}
+	platform.ID, platform.Date, platform.CreatedAt, platform.UpdatedAt = "", time.Time{}, time.Time{}, time.Time{}
+	if *platform != wantPlatform {
+		t.Fatalf("platform = %+v, want %+v", *platform, wantPlatform)
+	}
+
+	var revenue domain.RevenueMetrics
+	if err := db.Where("date = ?", day(10)).First(&revenue).Error; err != nil {
+		t.Fatalf("read revenue: %v", err)
+	}
+	if revenue.TotalRevenue != 64.5 || revenue.OrderCommission != 5 || revenue.DeliveryFees != 7 ||
+		revenue.MerchantPayouts != 50 || revenue.NetProfit != 5 || revenue.OrderCount != 2 {
+		t.Fatalf("revenue = %+v", revenue)
+	}
+
+	var drivers []domain.DriverMetrics
+	if err := db.Where("date = ?", day(10)).Order("driver_id ASC").Find(&drivers).Error; err != nil {
+		t.Fatalf("read drivers: %v", err)
+	}
+	d1, d2 := drivers[0], drivers[1]
+	if d1.DriverID != "driver-1" || d1.TotalDeliveries != 2 || d1.CompletedDeliveries != 1 || d1.CancelledDeliveries != 1 || d1.OnTimeDeliveries != 1 || d1.TotalEarnings != 3 {
+		t.Fatalf("driver-1 = %+v", d1)
+	}
+	if d2.DriverID != "driver-2" || d2.TotalDeliveries != 1 || d2.OnTimeDeliveries != 0 || d2.TotalEarnings != 4 {
+		t.Fatalf("driver-2 = %+v", d2)
+	}
+
+	var merchant domain.MerchantMetrics
+	if err := db.Where("date = ?", day(10)).First(&merchant).Error; err != nil {
+		t.Fatalf("read merchant: %v", err)
+	}
+	if merchant.TotalOrders != 4 || merchant.CompletedOrders != 2 || merchant.CancelledOrders != 1 ||
+		merchant.OnTimeOrders != 1 || merchant.TotalRevenue != 50 || merchant.Commission != 5 {
+		t.Fatalf("merchant = %+v", merchant)
+	}
+
+	// A late event for the day is picked up by the next run, in place
+	delivered := domain.OrderFact{ID: "order-4", CustomerID: "customer-2", MerchantID: "merchant-1", DriverID: "driver-1", Status: orderStatusDelivered,
+		TotalAmount: 10, DeliveryFee: 2, FinalAmount: 12, PlacedAt: *at(10, 22, 0), CompletedAt: at(10, 23, 0), UpdatedAt: *at(10, 23, 0)}
+	if err := sources.UpsertOrder(&delivered); err != nil {
+		t.Fatalf("UpsertOrder: %v", err)
+	}
+	rollUp(t, sources, rollups, day(10), 3)
+
+	if n := countRows(t, db, &domain.PlatformMetrics{}, day(10)); n != 1 {
+		t.Fatalf("%d platform rows after the re-run, want 1", n)
+	}
+	platform, err = NewPlatformMetricsRepository(db).GetByDate(day(10))
+	if err != nil {
+		t.Fatalf("GetByDate: %v", err)
+	}
+	if platform.ActiveOrders != 0 || platform.CompletedOrders != 3 || platform.DailyRevenue != 76.5 {
+		t.Fatalf("platform after the re-run = %+v", *platform)
+	}
+	if err := db.Where("date = ?", day(10)).First(&revenue).Error; err != nil {
+		t.Fatalf("read revenue: %v", err)
+	}
+	if revenue.OrderCount != 3 || revenue.TotalRevenue != 76.5 {
+		t.Fatalf("revenue after the re-run = %+v", revenue)
+	}
+	if n := countRows(t, db, &domain.DriverMetrics{}, day(10)); n != 2 {
+		t.Fatalf("%d driver rows after the re-run, want 2", n)
+	}
+
+	// Other days are untouched
+	if n := countRows(t, db, &domain.PlatformMetrics{}, day(9)); n != 0 {
+		t.Fatalf("%d platform rows for day 9, want none", n)
+	}
+}
@@ -0,0 +1,346 @@
+package db
+
+import (
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sourceDataRepository keeps analytics' copies of orders and users, fed by
+// the order and user services' events, and aggregates them
+type sourceDataRepository struct {
+	db *gorm.DB
+}
+
+func NewSourceDataRepository(db *gorm.DB) domain.SourceDataRepository {
+	return &sourceDataRepository{db: db}
+}
+
+const (
+	orderStatusDelivered = "delivered"
+	orderStatusCancelled = "cancelled"
+)
+
+// orderFactColumns are refreshed when a newer snapshot of an order arrives
+var orderFactColumns = []string{"customer_id", "merchant_id", "driver_id", "status", "total_amount", "delivery_fee", "service_fee", "tax_amount", "final_amount", "estimated_time", "placed_at", "completed_at", "cancelled_at", "updated_at"}
+
+func (r *sourceDataRepository) UpsertOrder(order *domain.OrderFact) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Omit("Items").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns(orderFactColumns),
+			// Events can be delivered out of order
+			Where: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "order_facts.updated_at <= excluded.updated_at"}}},
+		}).Create(order).Error
+		if err != nil {
+			return err
+		}
+
+		// Items don't change after the order is placed
+		if len(order.Items) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&order.Items).Error
+	})
+}
+
+func (r *sourceDataRepository) UpsertUser(user *domain.UserFact) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(user).Error
+}
+
+func (r *sourceDataRepository) UpsertStoreRating(rating *domain.StoreRatingFact) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(rating).Error
+}
+
+// onTimeFilter matches delivered orders completed within their estimated time
+const onTimeFilter = "status = ? AND estimated_time IS NOT NULL AND completed_at <= placed_at + estimated_time * interval '1 minute'"
+
+func (r *sourceDataRepository) GetPlatformRollup(startDate, endDate time.Time) (*domain.PlatformMetrics, error) {
+	var users struct {
+		TotalUsers     int
+		TotalMerchants int
+		TotalDrivers   int
+	}
+	err := r.db.Model(&domain.UserFact{}).
+		Select(`COUNT(*) FILTER (WHERE role = 'customer') AS total_users,
+			COUNT(*) FILTER (WHERE role = 'merchant') AS total_merchants,
+			COUNT(*) FILTER (WHERE role = 'driver') AS total_drivers`).
+		Where("registered_at < ?", endDate).
+		Scan(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var orders struct {
+		ActiveOrders    int
+		CompletedOrders int
+		CancelledOrders int
+		TotalRevenue    float64
+		DailyRevenue    float64
+	}
+	err = r.db.Model(&domain.OrderFact{}).
+		Select(`COUNT(*) FILTER (WHERE status NOT IN (?, ?) AND placed_at < ?) AS active_orders,
+			COUNT(*) FILTER (WHERE status = ? AND completed_at >= ? AND completed_at < ?) AS completed_orders,
+			COUNT(*) FILTER (WHERE status = ? AND cancelled_at >= ? AND cancelled_at < ?) AS cancelled_orders,
+			COALESCE(SUM(final_amount) FILTER (WHERE status = ? AND completed_at < ?), 0) AS total_revenue,
+			COALESCE(SUM(final_amount) FILTER (WHERE status = ? AND completed_at >= ? AND completed_at < ?), 0) AS daily_revenue`,
+			orderStatusDelivered, orderStatusCancelled, endDate,
+			orderStatusDelivered, startDate, endDate,
+			orderStatusCancelled, startDate, endDate,
+			orderStatusDelivered, endDate,
+			orderStatusDelivered, startDate, endDate).
+		Scan(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &domain.PlatformMetrics{
+		TotalUsers:      users.TotalUsers,
+		TotalMerchants:  users.TotalMerchants,
+		TotalDrivers:    users.TotalDrivers,
+		ActiveOrders:    orders.ActiveOrders,
+		CompletedOrders: orders.CompletedOrders,
+		CancelledOrders: orders.CancelledOrders,
+		TotalRevenue:    orders.TotalRevenue,
+		DailyRevenue:    orders.DailyRevenue,
+	}
+	if orders.CompletedOrders > 0 {
+		metrics.AverageOrderValue = orders.DailyRevenue / float64(orders.CompletedOrders)
+	}
+	return metrics, nil
+}
+
+// GetRevenueRollup sums the orders delivered in the range. The platform's
+// commission is the service fee charged to the customer on top of the
+// merchant's subtotal: merchants are paid the subtotal in full, drivers the
+// delivery fee and tax is passed on, so net profit is the commission.
+func (r *sourceDataRepository) GetRevenueRollup(startDate, endDate time.Time) (*domain.RevenueMetrics, error) {
+	var row struct {
+		TotalRevenue    float64
+		OrderCommission float64
+		DeliveryFees    float64
+		MerchantPayouts float64
+		Taxes           float64
+		OrderCount      int
+	}
+	err := r.db.Model(&domain.OrderFact{}).
+		Select(`COALESCE(SUM(final_amount), 0) AS total_revenue,
+			COALESCE(SUM(service_fee), 0) AS order_commission,
+			COALESCE(SUM(delivery_fee), 0) AS delivery_fees,
+			COALESCE(SUM(total_amount), 0) AS merchant_payouts,
+			COALESCE(SUM(tax_amount), 0) AS taxes,
+			COUNT(*) AS order_count`).
+		Where("status = ? AND completed_at >= ? AND completed_at < ?", orderStatusDelivered, startDate, endDate).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RevenueMetrics{
+		TotalRevenue:    row.TotalRevenue,
+		OrderCommission: row.OrderCommission,
+		DeliveryFees:    row.DeliveryFees,
+		DriverPayouts:   row.DeliveryFees,
+		MerchantPayouts: row.MerchantPayouts,
+		NetProfit:       row.TotalRevenue - row.DeliveryFees - row.MerchantPayouts - row.Taxes,
+		OrderCount:      row.OrderCount,
+	}, nil
+}
+
+func (r *sourceDataRepository) GetDriverRollups(startDate, endDate time.Time) ([]domain.DriverMetrics, error) {
+	var metrics []domain.DriverMetrics
+	err := r.db.Model(&domain.OrderFact{}).
+		Select(`driver_id,
+			COUNT(*) AS total_deliveries,
+			COUNT(*) FILTER (WHERE status = ?) AS completed_deliveries,
+			COUNT(*) FILTER (WHERE status = ?) AS cancelled_deliveries,
+			COUNT(*) FILTER (WHERE `+onTimeFilter+`) AS on_time_deliveries,
+			COALESCE(SUM(delivery_fee) FILTER (WHERE status = ?), 0) AS total_earnings`,
+			orderStatusDelivered, orderStatusCancelled, orderStatusDelivered, orderStatusDelivered).
+		Where("driver_id <> '' AND placed_at >= ? AND placed_at < ?", startDate, endDate).
+		Group("driver_id").
+		Scan(&metrics).Error
+	return metrics, err
+}
+
+func (r *sourceDataRepository) GetMerchantRollups(startDate, endDate time.Time) ([]domain.MerchantMetrics, error) {
+	var metrics []domain.MerchantMetrics
+	err := r.db.Model(&domain.OrderFact{}).
+		Select(`merchant_id,
+			COUNT(*) AS total_orders,
+			COUNT(*) FILTER (WHERE status = ?) AS completed_orders,
+			COUNT(*) FILTER (WHERE status = ?) AS cancelled_orders,
+			COUNT(*) FILTER (WHERE `+onTimeFilter+`) AS on_time_orders,
+			COALESCE(SUM(total_amount) FILTER (WHERE status = ?), 0) AS total_revenue,
+			COALESCE(SUM(service_fee) FILTER (WHERE status = ?), 0) AS commission`,
+			orderStatusDelivered, orderStatusCancelled, orderStatusDelivered, orderStatusDelivered, orderStatusDelivered).
+		Where("placed_at >= ? AND placed_at < ?", startDate, endDate).
+		Group("merchant_id").
+		Scan(&metrics).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// The commission is charged to the customer, not deducted from the
+	// subtotal, so this matches MerchantPayouts in the revenue rollup
+	for i := range metrics {
+		metrics[i].NetRevenue = metrics[i].TotalRevenue
+	}
+
+	// Ratings belong to the day they were given, which is usually later
+	// than the day the order was placed
+	var ratings []struct {
+		MerchantID    string
+		AverageRating float64
+		TotalRatings  int
+	}
+	err = r.db.Model(&domain.StoreRatingFact{}).
+		Select("merchant_id, AVG(rating) AS average_rating, COUNT(*) AS total_ratings").
+		Where("rated_at >= ? AND rated_at < ?", startDate, endDate).
+		Group("merchant_id").
+		Scan(&ratings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(metrics))
+	for i, m := range metrics {
+		index[m.MerchantID] = i
+	}
+	for _, rating := range ratings {
+		i, ok := index[rating.MerchantID]
+		if !ok {
+			metrics = append(metrics, domain.MerchantMetrics{MerchantID: rating.MerchantID})
+			i = len(metrics) - 1
+		}
+		metrics[i].AverageRating = rating.AverageRating
+		metrics[i].TotalRatings = rating.TotalRatings
+	}
+	return metrics, nil
+}
+
+// GetCohortSizes counts customers by signup period; Period equals Cohort
+func (r *sourceDataRepository) GetCohortSizes(granularity string, since time.Time) ([]domain.CohortActivity, error) {
+	var sizes []domain.CohortActivity
+	err := r.db.Model(&domain.UserFact{}).
+		Select("date_trunc(?, registered_at) AS cohort, date_trunc(?, registered_at) AS period, COUNT(*) AS users", granularity, granularity).
+		Where("role = ? AND registered_at >= ?", "customer", since).
+		Group("cohort, period").
+		Order("cohort ASC").
+		Scan(&sizes).Error
+	return sizes, err
+}
+
+// GetCohortActivity counts distinct customers of each signup cohort placing orders in each period
+func (r *sourceDataRepository) GetCohortActivity(granularity string, since time.Time) ([]domain.CohortActivity, error) {
+	var activity []domain.CohortActivity
+	err := r.db.Model(&domain.UserFact{}).
+		Select("date_trunc(?, user_facts.registered_at) AS cohort, date_trunc(?, order_facts.placed_at) AS period, COUNT(DISTINCT user_facts.id) AS users", granularity, granularity).
+		Joins("JOIN order_facts ON order_facts.customer_id = user_facts.id").
+		Where("user_facts.role = ? AND user_facts.registered_at >= ?", "customer", since).
+		Group("cohort, period").
+		Scan(&activity).Error
+	return activity, err
+}
+
+// GetRevenueTrend buckets delivered orders by completion time in loc
+func (r *sourceDataRepository) GetRevenueTrend(unit string, loc *time.Location, startDate, endDate time.Time) ([]domain.TrendPoint, error) {
+	var points []domain.TrendPoint
+	err := r.db.Model(&domain.OrderFact{}).
+		Select("date_trunc(?, completed_at AT TIME ZONE ?) AS period, COUNT(*) AS order_count, COALESCE(SUM(final_amount), 0) AS revenue", unit, loc.String()).
+		Where("status = ? AND completed_at >= ? AND completed_at < ?", orderStatusDelivered, startDate, endDate).
+		Group("period").
+		Order("period ASC").
+		Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+	return localizePeriods(points, loc), nil
+}
+
+// GetOrderTrend buckets placed orders by placement time in loc; revenue counts delivered orders only
+func (r *sourceDataRepository) GetOrderTrend(unit string, loc *time.Location, startDate, endDate time.Time) ([]domain.TrendPoint, error) {
+	var points []domain.TrendPoint
+	err := r.db.Model(&domain.OrderFact{}).
+		Select(`date_trunc(?, placed_at AT TIME ZONE ?) AS period, COUNT(*) AS order_count,
+			COALESCE(SUM(final_amount) FILTER (WHERE status = ?), 0) AS revenue`, unit, loc.String(), orderStatusDelivered).
+		Where("placed_at >= ? AND placed_at < ?", startDate, endDate).
+		Group("period").
+		Order("period ASC").
+		Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+	return localizePeriods(points, loc), nil
+}
+
+// GetOrdersByHour groups placed orders by local hour of day in loc
+func (r *sourceDataRepository) GetOrdersByHour(loc *time.Location, startDate, endDate time.Time) ([]domain.HourlyOrders, error) {
+	var hours []domain.HourlyOrders
+	err := r.db.Model(&domain.OrderFact{}).
+		Select(`EXTRACT(HOUR FROM placed_at AT TIME ZONE ?)::int AS hour, COUNT(*) AS order_count,
+			COALESCE(SUM(final_amount) FILTER (WHERE status = ?), 0) AS revenue`, loc.String(), orderStatusDelivered).
+		Where("placed_at >= ? AND placed_at < ?", startDate, endDate).
+		Group("hour").
+		Order("hour ASC").
+		Scan(&hours).Error
+	return hours, err
+}
+
+func (r *sourceDataRepository) GetMerchantDailySales(merchantID string, startDate, endDate time.Time) ([]domain.DailySales, error) {
+	const day = "date_trunc('day', order_facts.completed_at AT TIME ZONE 'UTC')"
+	delivered := "order_facts.merchant_id = ? AND order_facts.status = ? AND order_facts.completed_at >= ? AND order_facts.completed_at < ?"
+
+	var days []domain.DailySales
+	err := r.db.Model(&domain.OrderFact{}).
+		Select(day+" AS date, COUNT(*) AS orders, COALESCE(SUM(order_facts.total_amount), 0) AS revenue").
+		Where(delivered, merchantID, orderStatusDelivered, startDate, endDate).
+		Group("date").
+		Order("date ASC").
+		Scan(&days).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var items []struct {
+		Date time.Time
+		domain.ProductSales
+	}
+	err = r.db.Model(&domain.OrderItemFact{}).
+		Select(day+` AS date, order_item_facts.product_id, MAX(order_item_facts.name) AS name,
+			SUM(order_item_facts.quantity) AS quantity, SUM(order_item_facts.price * order_item_facts.quantity) AS revenue`).
+		Joins("JOIN order_facts ON order_facts.id = order_item_facts.order_id").
+		Where(delivered, merchantID, orderStatusDelivered, startDate, endDate).
+		Group("date, order_item_facts.product_id").
+		Order("date ASC, revenue DESC").
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[time.Time]int, len(days))
+	for i, d := range days {
+		days[i].Date = time.Date(d.Date.Year(), d.Date.Month(), d.Date.Day(), 0, 0, 0, 0, time.UTC)
+		days[i].Products = []domain.ProductSales{}
+		index[days[i].Date] = i
+	}
+	for _, item := range items {
+		date := time.Date(item.Date.Year(), item.Date.Month(), item.Date.Day(), 0, 0, 0, 0, time.UTC)
+		if i, ok := index[date]; ok {
+			days[i].Products = append(days[i].Products, item.ProductSales)
+		}
+	}
+	return days, nil
+}
+
+// localizePeriods re-labels date_trunc results, which come back as wall-clock
+// times without a zone, with the location they were bucketed in
+func localizePeriods(points []domain.TrendPoint, loc *time.Location) []domain.TrendPoint {
+	for i, p := range points {
+		points[i].Period = time.Date(p.Period.Year(), p.Period.Month(), p.Period.Day(), p.Period.Hour(), 0, 0, 0, loc)
+	}
+	return points
+}
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+func TestLocalizePeriods(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		loc  *time.Location
+		want time.Time
+	}{
+		{name: "UTC", loc: time.UTC, want: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "New York", loc: newYork, want: time.Date(2026, 3, 1, 5, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// date_trunc returns the local midnight without a zone, which the
+			// driver scans as UTC
+			points := localizePeriods([]domain.TrendPoint{{Period: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}}, tt.loc)
+			if !points[0].Period.Equal(tt.want) {
+				t.Fatalf("period = %s, want %s", points[0].Period.UTC(), tt.want)
+			}
+			if points[0].Period.Location() != tt.loc {
+				t.Fatalf("period labelled %s, want %s", points[0].Period.Location(), tt.loc)
+			}
+		})
+	}
+}
+
+func TestCohortQueries(t *testing.T) {
+	sources := NewSourceDataRepository(testDB(t))
+	date := func(month, day int) time.Time { return time.Date(2026, time.Month(month), day, 15, 0, 0, 0, time.UTC) }
+	period := func(month, day int) time.Time { return time.Date(2026, time.Month(month), day, 0, 0, 0, 0, time.UTC) }
+
+	users := []domain.UserFact{
+		{ID: "a", Role: "customer", RegisteredAt: date(9, 8)},
+		{ID: "b", Role: "customer", RegisteredAt: date(9, 13)},
+		{ID: "c", Role: "customer", RegisteredAt: date(9, 16)},
+		// Before the window and not a customer
+		{ID: "old", Role: "customer", RegisteredAt: date(8, 30)},
+		{ID: "merchant", Role: "merchant", RegisteredAt: date(9, 8)},
+	}
+	for i := range users {
+		if err := sources.UpsertUser(&users[i]); err != nil {
+			t.Fatalf("seed user %s: %v", users[i].ID, err)
+		}
+	}
+	orders := []domain.OrderFact{
+		{ID: "order-1", CustomerID: "a", PlacedAt: date(9, 9)},
+		{ID: "order-2", CustomerID: "a", PlacedAt: date(9, 15)},
+		{ID: "order-3", CustomerID: "a", PlacedAt: date(9, 16)},
+		{ID: "order-4", CustomerID: "b", PlacedAt: date(9, 22)},
+		{ID: "order-5", CustomerID: "c", PlacedAt: date(9, 16)},
+		{ID: "order-6", CustomerID: "old", PlacedAt: date(9, 15)},
+		{ID: "order-7", CustomerID: "merchant", PlacedAt: date(9, 15)},
+	}
+	for i := range orders {
+		orders[i].Status = orderStatusDelivered
+		orders[i].UpdatedAt = orders[i].PlacedAt
+		if err := sources.UpsertOrder(&orders[i]); err != nil {
+			t.Fatalf("seed order %s: %v", orders[i].ID, err)
+		}
+	}
+
+	tests := []struct {
+		granularity  string
+		wantSizes    []domain.CohortActivity
+		wantActivity []domain.CohortActivity
+	}{
+		{
+			// Weeks start on Monday 7 September
+			granularity: "week",
+			wantSizes: []domain.CohortActivity{
+				{Cohort: period(9, 7), Period: period(9, 7), Users: 2},
+				{Cohort: period(9, 14), Period: period(9, 14), Users: 1},
+			},
+			wantActivity: []domain.CohortActivity{
+				{Cohort: period(9, 7), Period: period(9, 7), Users: 1},
+				{Cohort: period(9, 7), Period: period(9, 14), Users: 1},
+				{Cohort: period(9, 7), Period: period(9, 21), Users: 1},
+				{Cohort: period(9, 14), Period: period(9, 14), Users: 1},
+			},
+		},
+		{
+			granularity:  "month",
+			wantSizes:    []domain.CohortActivity{{Cohort: period(9, 1), Period: period(9, 1), Users: 3}},
+			wantActivity: []domain.CohortActivity{{Cohort: period(9, 1), Period: period(9, 1), Users: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.granularity, func(t *testing.T) {
+			sizes, err := sources.GetCohortSizes(tt.granularity, period(9, 1))
+			if err != nil {
+				t.Fatalf("GetCohortSizes: %v", err)
+			}
+			if !sameCohorts(sizes, tt.wantSizes) {
+				t.Fatalf("sizes = %+v, want %+v", sizes, tt.wantSizes)
+			}
+
+			activity, err := sources.GetCohortActivity(tt.granularity, period(9, 1))
+			if err != nil {
+				t.Fatalf("GetCohortActivity: %v", err)
+			}
+			sort.Slice(activity, func(i, j int) bool {
+				if !activity[i].Cohort.Equal(activity[j].Cohort) {
+					return activity[i].Cohort.Before(activity[j].Cohort)
+				}
+				return activity[i].Period.Before(activity[j].Period)
+			})
+			if !sameCohorts(activity, tt.wantActivity) {
+				t.Fatalf("activity = %+v, want %+v", activity, tt.wantActivity)
+			}
+		})
+	}
+}
+
+// sameCohorts compares cohort rows by instant, whatever zone they were scanned in
+func sameCohorts(got, want []domain.CohortActivity) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if !got[i].Cohort.Equal(want[i].Cohort) || !got[i].Period.Equal(want[i].Period) || got[i].Users != want[i].Users {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGetMerchantDailySales(t *testing.T) {
+	sources := NewSourceDataRepository(testDB(t))
+	completed := func(d, hour int) *time.Time {
+		at := day(d).Add(time.Duration(hour) * time.Hour)
+		return &at
+	}
+	pizza := func(quantity int) domain.OrderItemFact {
+		return domain.OrderItemFact{ProductID: "pizza", Name: "Pizza", Price: 12, Quantity: quantity}
+	}
+	cola := func(quantity int) domain.OrderItemFact {
+		return domain.OrderItemFact{ProductID: "cola", Name: "Cola", Price: 2.5, Quantity: quantity}
+	}
+
+	orders := []domain.OrderFact{
+		{ID: "order-1", MerchantID: "merchant-1", Status: orderStatusDelivered, TotalAmount: 29, CompletedAt: completed(12, 13), Items: []domain.OrderItemFact{pizza(2), cola(2)}},
+		// Completed just before midnight UTC
+		{ID: "order-2", MerchantID: "merchant-1", Status: orderStatusDelivered, TotalAmount: 12, CompletedAt: completed(12, 23), Items: []domain.OrderItemFact{pizza(1)}},
+		{ID: "order-3", MerchantID: "merchant-1", Status: orderStatusDelivered, TotalAmount: 10, CompletedAt: completed(14, 1), Items: []domain.OrderItemFact{cola(4)}},
+		// Not delivered, out of range or another merchant's
+		{ID: "order-4", MerchantID: "merchant-1", Status: orderStatusCancelled, TotalAmount: 12, Items: []domain.OrderItemFact{pizza(1)}},
+		{ID: "order-5", MerchantID: "merchant-1", Status: orderStatusDelivered, TotalAmount: 12, CompletedAt: completed(9, 12), Items: []domain.OrderItemFact{pizza(1)}},
+		{ID: "order-6", MerchantID: "merchant-2", Status: orderStatusDelivered, TotalAmount: 24, CompletedAt: completed(12, 12), Items: []domain.OrderItemFact{pizza(2)}},
+	}
+	for i := range orders {
+		order := &orders[i]
+		order.PlacedAt = day(12)
+		order.UpdatedAt = day(12)
+		for line := range order.Items {
+			order.Items[line].OrderID = order.ID
+			order.Items[line].Line = line + 1
+		}
+		if err := sources.UpsertOrder(order); err != nil {
+			t.Fatalf("seed order %s: %v", order.ID, err)
+		}
+	}
+
+	days, err := sources.GetMerchantDailySales("merchant-1", day(10), day(15))
+	if err != nil {
+		t.Fatalf("GetMerchantDailySales: %v", err)
+	}
+	want := []domain.DailySales{
+		{Date: day(12), Orders: 2, Revenue: 41, Products: []domain.ProductSales{
+			{ProductID: "pizza", Name: "Pizza", Quantity: 3, Revenue: 36},
+			{ProductID: "cola", Name: "Cola", Quantity: 2, Revenue: 5},
+		}},
+		{Date: day(14), Orders: 1, Revenue: 10, Products: []domain.ProductSales{
+			{ProductID: "cola", Name: "Cola", Quantity: 4, Revenue: 10},
+		}},
+	}
+	if !reflect.DeepEqual(days, want) {
+		t.Fatalf("days = %+v, want %+v", days, want)
+	}
+
+	days, err = sources.GetMerchantDailySales("merchant-3", day(10), day(15))
+	if err != nil {
+		t.Fatalf("GetMerchantDailySales: %v", err)
+	}
+	if len(days) != 0 {
+		t.Fatalf("got %d days for a merchant without sales, want none", len(days))
+	}
+}
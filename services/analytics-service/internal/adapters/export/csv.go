@@ -0,0 +1,67 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+// CSV flattens the report's sections into one CSV document. Each row is
+// prefixed with its section title so sections with different columns can
+// share the file; sections are separated by their own header row.
+func CSV(report *domain.AnalyticsReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	for _, section := range report.Sections {
+		if err := w.Write(append([]string{"section"}, section.Columns...)); err != nil {
+			return nil, err
+		}
+		for _, row := range section.Rows {
+			if err := w.Write(append([]string{section.Title}, row...)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MerchantSalesCSV writes one row per product sold each day, preceded by
+// that day's totals under the product "ALL"
+func MerchantSalesCSV(sales *domain.MerchantSales) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	amount := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	if err := w.Write([]string{"date", "product_id", "product_name", "orders", "quantity", "revenue"}); err != nil {
+		return nil, err
+	}
+	for _, day := range sales.Days {
+		date := day.Date.Format("2006-01-02")
+		quantity := 0
+		for _, product := range day.Products {
+			quantity += product.Quantity
+		}
+		if err := w.Write([]string{date, "ALL", "", strconv.Itoa(day.Orders), strconv.Itoa(quantity), amount(day.Revenue)}); err != nil {
+			return nil, err
+		}
+		for _, product := range day.Products {
+			if err := w.Write([]string{date, product.ProductID, product.Name, "", strconv.Itoa(product.Quantity), amount(product.Revenue)}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
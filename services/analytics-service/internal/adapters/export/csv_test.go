@@ -0,0 +1,122 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+func TestCSV(t *testing.T) {
+	report := &domain.AnalyticsReport{
+		Sections: []domain.ReportSection{
+			{Title: "Totals", Columns: []string{"orders", "revenue"}, Rows: [][]string{{"12", "340.50"}}},
+			{Title: "By day", Columns: []string{"date", "orders"}, Rows: [][]string{{"2024-01-01", "5"}, {"2024-01-02", "7"}}},
+			{Title: "Empty", Columns: []string{"metric"}},
+		},
+	}
+
+	data, err := CSV(report)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1 // sections have their own columns
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+
+	want := [][]string{
+		{"section", "orders", "revenue"},
+		{"Totals", "12", "340.50"},
+		{"section", "date", "orders"},
+		{"By day", "2024-01-01", "5"},
+		{"By day", "2024-01-02", "7"},
+		{"section", "metric"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("CSV rows = %v, want %v", records, want)
+	}
+}
+
+func TestCSVQuotesCells(t *testing.T) {
+	report := &domain.AnalyticsReport{
+		Sections: []domain.ReportSection{
+			{Title: "Stores", Columns: []string{"name"}, Rows: [][]string{{"Pizza, Pasta \"& more\""}}},
+		},
+	}
+
+	data, err := CSV(report)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), `"Pizza, Pasta ""& more"""`) {
+		t.Fatalf("cell was not quoted: %s", data)
+	}
+}
+
+func TestPDF(t *testing.T) {
+	report := &domain.AnalyticsReport{
+		Name:     "Weekly",
+		Type:     domain.ReportTypeRevenue,
+		Sections: []domain.ReportSection{{Title: "Totals", Columns: []string{"orders"}, Rows: [][]string{{"12"}}}},
+	}
+
+	data, err := PDF(report)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.Contains(data, []byte("%%EOF")) {
+		t.Fatalf("output is not a PDF document")
+	}
+	if !bytes.Contains(data, []byte("Totals")) {
+		t.Fatalf("section title missing from the PDF")
+	}
+}
+
+func TestMerchantSalesCSV(t *testing.T) {
+	sales := &domain.MerchantSales{Days: []domain.DailySales{
+		{Date: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), Orders: 2, Revenue: 35, Products: []domain.ProductSales{
+			{ProductID: "pizza", Name: "Pizza, large", Quantity: 2, Revenue: 24},
+			{ProductID: "cola", Name: "Cola", Quantity: 4, Revenue: 11},
+		}},
+		{Date: time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), Orders: 1, Revenue: 9.5, Products: []domain.ProductSales{
+			{ProductID: "salad", Name: "Salad", Quantity: 1, Revenue: 9.5},
+		}},
+	}}
+
+	data, err := MerchantSalesCSV(sales)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+
+	want := [][]string{
+		{"date", "product_id", "product_name", "orders", "quantity", "revenue"},
+		{"2026-10-12", "ALL", "", "2", "6", "35.00"},
+		{"2026-10-12", "pizza", "Pizza, large", "", "2", "24.00"},
+		{"2026-10-12", "cola", "Cola", "", "4", "11.00"},
+		{"2026-10-14", "ALL", "", "1", "1", "9.50"},
+		{"2026-10-14", "salad", "Salad", "", "1", "9.50"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("CSV rows = %v, want %v", records, want)
+	}
+
+	// No sales is just the header
+	data, err = MerchantSalesCSV(&domain.MerchantSales{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "date,product_id,product_name,orders,quantity,revenue\n" {
+		t.Fatalf("empty export = %q, want only the header", data)
+	}
+}
@@ -0,0 +1,131 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+const (
+	pdfPageWidth    = 842 // A4 landscape, in points
+	pdfPageHeight   = 595
+	pdfMargin       = 36
+	pdfFontSize     = 8
+	pdfLineHeight   = 11
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+)
+
+// PDF renders the report as fixed-width text tables, one column per field
+func PDF(report *domain.AnalyticsReport) ([]byte, error) {
+	lines := []string{
+		report.Name,
+		fmt.Sprintf("%s report, %s to %s", report.Type, report.StartDate.Format("2006-01-02"), report.EndDate.Format("2006-01-02")),
+		"",
+	}
+	for _, section := range report.Sections {
+		lines = append(lines, section.Title)
+		lines = append(lines, tableLines(section)...)
+		lines = append(lines, "")
+	}
+
+	var pages [][]string
+	for len(lines) > 0 {
+		n := pdfLinesPerPage
+		if n > len(lines) {
+			n = len(lines)
+		}
+		pages = append(pages, lines[:n])
+		lines = lines[n:]
+	}
+
+	return renderPDF(pages), nil
+}
+
+// tableLines pads every cell to its column's widest value
+func tableLines(section domain.ReportSection) []string {
+	widths := make([]int, len(section.Columns))
+	for i, col := range section.Columns {
+		widths[i] = len(col)
+	}
+	for _, row := range section.Rows {
+		for i, cell := range row {
+			if i < len(widths) && len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	format := func(cells []string) string {
+		padded := make([]string, len(widths))
+		for i := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			padded[i] = cell + strings.Repeat(" ", widths[i]-len(cell))
+		}
+		return strings.TrimRight(strings.Join(padded, "  "), " ")
+	}
+
+	separator := make([]string, len(widths))
+	for i, w := range widths {
+		separator[i] = strings.Repeat("-", w)
+	}
+
+	lines := []string{format(section.Columns), format(separator)}
+	for _, row := range section.Rows {
+		lines = append(lines, format(row))
+	}
+	return lines
+}
+
+// renderPDF writes a minimal PDF 1.4 document using the built-in Courier font
+func renderPDF(pages [][]string) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	writeObject := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// Objects 1-3: catalog, page tree and font; each page then adds a page and a content object
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>")
+
+	for i, lines := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range lines {
+			fmt.Fprintf(&content, "(%s) '\n", escapePDF(line))
+		}
+		content.WriteString("ET")
+
+		writeObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+2*i))
+		writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+func escapePDF(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+	return replacer.Replace(s)
+}
@@ -1,15 +1,20 @@
 package http
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"glovo-backend/services/analytics-service/internal/adapters/export"
 	"glovo-backend/services/analytics-service/internal/domain"
 	"glovo-backend/shared/auth"
 	"glovo-backend/shared/middleware"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
 )
 
 type AnalyticsHandler struct {
@@ -23,12 +28,11 @@ func NewAnalyticsHandler(analyticsService domain.AnalyticsService) *AnalyticsHan
 }
 
 func (h *AnalyticsHandler) SetupRoutes(router *gin.RouterGroup) {
-	// Public analytics endpoints (for internal service integration)
-	public := router.Group("/analytics")
+	// Client events, attributed to the authenticated user
+	tracking := router.Group("/analytics")
+	tracking.Use(middleware.AuthMiddleware())
 	{
-		public.POST("/track-event", h.trackEvent)
-		public.POST("/track-order", h.trackOrderEvent)
-		public.POST("/track-delivery", h.trackDeliveryEvent)
+		tracking.POST("/track-event", h.trackEvent)
 	}
 
 	// Admin analytics dashboard
@@ -39,41 +43,27 @@ func (h *AnalyticsHandler) SetupRoutes(router *gin.RouterGroup) {
 		// Platform overview
 		admin.GET("/dashboard", h.getDashboard)
 		admin.GET("/platform-stats", h.getPlatformStats)
+		admin.GET("/funnel", h.getFunnel)
 
 		// Revenue analytics
 		admin.GET("/revenue/overview", h.getRevenueOverview)
 		admin.GET("/revenue/trends", h.getRevenueTrends)
-		admin.GET("/revenue/by-category", h.getRevenueByCategory)
-		admin.GET("/revenue/by-location", h.getRevenueByLocation)
 
 		// Order analytics
-		admin.GET("/orders/overview", h.getOrderOverview)
 		admin.GET("/orders/trends", h.getOrderTrends)
-		admin.GET("/orders/by-status", h.getOrdersByStatus)
 		admin.GET("/orders/peak-hours", h.getPeakHours)
 
 		// User analytics
-		admin.GET("/users/overview", h.getUserOverview)
-		admin.GET("/users/activity", h.getUserActivity)
 		admin.GET("/users/retention", h.getUserRetention)
-		admin.GET("/users/demographics", h.getUserDemographics)
 
 		// Delivery analytics
-		admin.GET("/deliveries/overview", h.getDeliveryOverview)
-		admin.GET("/deliveries/performance", h.getDeliveryPerformance)
-		admin.GET("/deliveries/time-analysis", h.getDeliveryTimeAnalysis)
-		admin.GET("/deliveries/driver-stats", h.getDriverStats)
-
-		// Business insights
-		admin.GET("/insights/popular-items", h.getPopularItems)
-		admin.GET("/insights/customer-segments", h.getCustomerSegments)
-		admin.GET("/insights/market-trends", h.getMarketTrends)
-		admin.GET("/insights/recommendations", h.getBusinessRecommendations)
+		admin.GET("/deliveries/heatmap", h.getDemandHeatmap)
 
 		// Custom reports
 		admin.POST("/reports/custom", h.generateCustomReport)
 		admin.GET("/reports", h.getReports)
 		admin.GET("/reports/:id", h.getReport)
+		admin.GET("/reports/:id/export", h.exportReport)
 		admin.DELETE("/reports/:id", h.deleteReport)
 
 		// Real-time metrics
@@ -82,16 +72,22 @@ func (h *AnalyticsHandler) SetupRoutes(router *gin.RouterGroup) {
 		admin.GET("/real-time/revenue", h.getRealTimeRevenue)
 	}
 
+	// Browsers can't send an Authorization header when opening a WebSocket,
+	// so the stream also accepts the token as a subprotocol
+	stream := router.Group("/admin/analytics")
+	stream.Use(middleware.WebSocketAuth())
+	stream.Use(middleware.RequireRole(auth.RoleAdmin))
+	{
+		stream.GET("/real-time/ws", h.streamRealTime)
+	}
+
 	// Merchant analytics
 	merchant := router.Group("/merchant/analytics")
 	merchant.Use(middleware.AuthMiddleware())
 	merchant.Use(middleware.RequireRole(auth.RoleMerchant))
 	{
-		merchant.GET("/dashboard", h.getMerchantDashboard)
 		merchant.GET("/sales", h.getMerchantSales)
-		merchant.GET("/orders", h.getMerchantOrders)
-		merchant.GET("/popular-items", h.getMerchantPopularItems)
-		merchant.GET("/customer-insights", h.getMerchantCustomerInsights)
+		merchant.GET("/sales/export", h.exportMerchantSales)
 		merchant.GET("/performance", h.getMerchantPerformance)
 	}
 
@@ -100,92 +96,49 @@ func (h *AnalyticsHandler) SetupRoutes(router *gin.RouterGroup) {
 	driver.Use(middleware.AuthMiddleware())
 	driver.Use(middleware.RequireRole(auth.RoleDriver))
 	{
-		driver.GET("/dashboard", h.getDriverDashboard)
-		driver.GET("/earnings", h.getDriverEarnings)
 		driver.GET("/performance", h.getDriverPerformance)
-		driver.GET("/delivery-stats", h.getDriverDeliveryStats)
-		driver.GET("/ratings", h.getDriverRatings)
+		driver.GET("/heatmap", h.getDemandHeatmap)
 	}
 }
 
 // Public endpoints
 
 // @Summary Track event
-// @Description Track a general analytics event
+// @Description Track a general analytics event for the authenticated user. The event type must be a tracked type carrying its entity IDs, or "custom." followed by a snake_case name.
 // @Tags analytics
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param request body domain.TrackEventRequest true "Event data"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/analytics/track-event [post]
 func (h *AnalyticsHandler) trackEvent(c *gin.Context) {
-	var req domain.TrackEventRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	err := h.analyticsService.TrackEvent(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Event tracked successfully"})
-}
-
-// @Summary Track order event
-// @Description Track an order-related analytics event
-// @Tags analytics
-// @Accept json
-// @Produce json
-// @Param request body domain.TrackOrderEventRequest true "Order event data"
-// @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/analytics/track-order [post]
-func (h *AnalyticsHandler) trackOrderEvent(c *gin.Context) {
-	var req domain.TrackOrderEventRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
 		return
 	}
 
-	err := h.analyticsService.TrackOrderEvent(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Order event tracked successfully"})
-}
-
-// @Summary Track delivery event
-// @Description Track a delivery-related analytics event
-// @Tags analytics
-// @Accept json
-// @Produce json
-// @Param request body domain.TrackDeliveryEventRequest true "Delivery event data"
-// @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/analytics/track-delivery [post]
-func (h *AnalyticsHandler) trackDeliveryEvent(c *gin.Context) {
-	var req domain.TrackDeliveryEventRequest
+	var req domain.TrackEventRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	req.UserID = userID
 
-	err := h.analyticsService.TrackDeliveryEvent(req)
+	err := h.analyticsService.TrackEvent(req)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidEvent) || errors.Is(err, domain.ErrUnknownEventType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Delivery event tracked successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Event tracked successfully"})
 }
 
 // Admin endpoints
@@ -226,45 +179,21 @@ func (h *AnalyticsHandler) getPlatformStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
-// @Summary Get revenue overview
-// @Description Get revenue overview analytics
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Param period query string false "Time period (day|week|month|year)"
-// @Success 200 {object} domain.RevenueOverview
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/revenue/overview [get]
-func (h *AnalyticsHandler) getRevenueOverview(c *gin.Context) {
-	period := c.DefaultQuery("period", "month")
-
-	overview, err := h.analyticsService.GetRevenueOverview(domain.RevenueRequest{
-		Period: period,
-	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, overview)
-}
-
-// @Summary Get revenue trends
-// @Description Get revenue trends over time
+// @Summary Get conversion funnel
+// @Description Get user counts and step conversion rates through the ordering funnel
 // @Tags admin
 // @Produce json
 // @Security BearerAuth
 // @Param start_date query string true "Start date (YYYY-MM-DD)"
-// @Param end_date query string true "End date (YYYY-MM-DD)"
-// @Param granularity query string false "Granularity (day|week|month)"
-// @Success 200 {object} domain.RevenueTrends
+// @Param end_date query string true "End date, inclusive (YYYY-MM-DD)"
+// @Param stages query string false "Comma-separated event types (default app_open,store_view,add_to_cart,checkout,paid)"
+// @Success 200 {object} domain.Funnel
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/revenue/trends [get]
-func (h *AnalyticsHandler) getRevenueTrends(c *gin.Context) {
+// @Router /api/v1/admin/analytics/funnel [get]
+func (h *AnalyticsHandler) getFunnel(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
-	granularity := c.DefaultQuery("granularity", "day")
 
 	if startDateStr == "" || endDateStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
@@ -283,76 +212,91 @@ func (h *AnalyticsHandler) getRevenueTrends(c *gin.Context) {
 		return
 	}
 
-	req := domain.RevenueTrendsRequest{
-		StartDate:   startDate,
-		EndDate:     endDate,
-		Granularity: granularity,
+	req := domain.FunnelRequest{
+		StartDate: startDate,
+		EndDate:   endDate.AddDate(0, 0, 1),
+	}
+	if stages := c.Query("stages"); stages != "" {
+		req.Stages = strings.Split(stages, ",")
 	}
 
-	trends, err := h.analyticsService.GetRevenueTrends(req)
+	funnel, err := h.analyticsService.GetFunnel(req)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidFunnel) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, trends)
+	c.JSON(http.StatusOK, funnel)
 }
 
-// @Summary Get revenue by category
-// @Description Get revenue breakdown by product category
-// @Tags admin
+// @Summary Get demand heatmap
+// @Description Get recent order pickup hotspots as weighted grid cells, busiest first, so drivers can position themselves
+// @Tags analytics
 // @Produce json
 // @Security BearerAuth
-// @Param period query string false "Time period (day|week|month|year)"
-// @Success 200 {object} domain.RevenueByCategory
+// @Param window_minutes query int false "How many minutes back to look, up to 1440" default(60)
+// @Param cell_size query number false "Grid cell size in degrees" default(0.01)
+// @Param event_types query string false "Comma-separated demand event types (default paid)"
+// @Param limit query int false "Maximum number of cells" default(200)
+// @Success 200 {object} domain.Heatmap
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/revenue/by-category [get]
-func (h *AnalyticsHandler) getRevenueByCategory(c *gin.Context) {
-	period := c.DefaultQuery("period", "month")
+// @Router /api/v1/admin/analytics/deliveries/heatmap [get]
+// @Router /api/v1/driver/analytics/heatmap [get]
+func (h *AnalyticsHandler) getDemandHeatmap(c *gin.Context) {
+	var req domain.HeatmapRequest
 
-	revenueByCategory, err := h.analyticsService.GetRevenueByCategory(period)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if window := c.Query("window_minutes"); window != "" {
+		minutes, err := strconv.Atoi(window)
+		if err != nil || minutes <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window_minutes"})
+			return
+		}
+		req.Window = time.Duration(minutes) * time.Minute
 	}
 
-	c.JSON(http.StatusOK, revenueByCategory)
-}
+	if size := c.Query("cell_size"); size != "" {
+		cellSize, err := strconv.ParseFloat(size, 64)
+		if err != nil || cellSize <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cell_size"})
+			return
+		}
+		req.CellSize = cellSize
+	}
 
-// @Summary Get revenue by location
-// @Description Get revenue breakdown by geographic location
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Param period query string false "Time period (day|week|month|year)"
-// @Success 200 {object} domain.RevenueByLocation
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/revenue/by-location [get]
-func (h *AnalyticsHandler) getRevenueByLocation(c *gin.Context) {
-	period := c.DefaultQuery("period", "month")
+	if eventTypes := c.Query("event_types"); eventTypes != "" {
+		req.EventTypes = strings.Split(eventTypes, ",")
+	}
+	req.Limit, _ = strconv.Atoi(c.Query("limit"))
 
-	revenueByLocation, err := h.analyticsService.GetRevenueByLocation(period)
+	heatmap, err := h.analyticsService.GetDemandHeatmap(req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, revenueByLocation)
+	c.JSON(http.StatusOK, heatmap)
 }
 
-// @Summary Get order overview
-// @Description Get order analytics overview
+// @Summary Get revenue overview
+// @Description Get revenue overview analytics
 // @Tags admin
 // @Produce json
 // @Security BearerAuth
 // @Param period query string false "Time period (day|week|month|year)"
-// @Success 200 {object} domain.OrderOverview
+// @Success 200 {object} domain.RevenueOverview
 // @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/orders/overview [get]
-func (h *AnalyticsHandler) getOrderOverview(c *gin.Context) {
+// @Router /api/v1/admin/analytics/revenue/overview [get]
+func (h *AnalyticsHandler) getRevenueOverview(c *gin.Context) {
 	period := c.DefaultQuery("period", "month")
 
-	overview, err := h.analyticsService.GetOrderOverview(period)
+	overview, err := h.analyticsService.GetRevenueOverview(domain.RevenueRequest{
+		Period: period,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -361,333 +305,208 @@ func (h *AnalyticsHandler) getOrderOverview(c *gin.Context) {
 	c.JSON(http.StatusOK, overview)
 }
 
-// @Summary Get order trends
-// @Description Get order trends over time
+// @Summary Get revenue trends
+// @Description Get revenue trends over time
 // @Tags admin
 // @Produce json
 // @Security BearerAuth
 // @Param start_date query string true "Start date (YYYY-MM-DD)"
 // @Param end_date query string true "End date (YYYY-MM-DD)"
-// @Success 200 {object} domain.OrderTrends
+// @Param granularity query string false "Granularity (day|week|month)"
+// @Param timezone query string false "IANA timezone used for bucketing (default UTC)"
+// @Success 200 {object} domain.RevenueTrends
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/orders/trends [get]
-func (h *AnalyticsHandler) getOrderTrends(c *gin.Context) {
+// @Router /api/v1/admin/analytics/revenue/trends [get]
+func (h *AnalyticsHandler) getRevenueTrends(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
+	granularity := c.DefaultQuery("granularity", "day")
 
 	if startDateStr == "" || endDateStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
 		return
 	}
 
-	startDate, err := time.Parse("2006-01-02", startDateStr)
+	loc, err := parseLocation(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
 		return
 	}
 
-	endDate, err := time.Parse("2006-01-02", endDateStr)
+	startDate, err := time.ParseInLocation("2006-01-02", startDateStr, loc)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
 		return
 	}
 
-	req := domain.OrderTrendsRequest{
-		StartDate: startDate,
-		EndDate:   endDate,
-	}
-
-	trends, err := h.analyticsService.GetOrderTrends(req)
+	endDate, err := time.ParseInLocation("2006-01-02", endDateStr, loc)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, trends)
-}
-
-// @Summary Get orders by status
-// @Description Get order count breakdown by status
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Param period query string false "Time period (day|week|month|year)"
-// @Success 200 {object} domain.OrdersByStatus
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/orders/by-status [get]
-func (h *AnalyticsHandler) getOrdersByStatus(c *gin.Context) {
-	period := c.DefaultQuery("period", "month")
-
-	ordersByStatus, err := h.analyticsService.GetOrdersByStatus(period)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
 		return
 	}
 
-	c.JSON(http.StatusOK, ordersByStatus)
-}
-
-// @Summary Get peak hours
-// @Description Get peak ordering hours analytics
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Success 200 {object} domain.PeakHours
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/orders/peak-hours [get]
-func (h *AnalyticsHandler) getPeakHours(c *gin.Context) {
-	peakHours, err := h.analyticsService.GetPeakHours()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	// end_date is inclusive
+	req := domain.RevenueTrendsRequest{
+		StartDate:   startDate,
+		EndDate:     endDate.AddDate(0, 0, 1),
+		Granularity: granularity,
+		Location:    loc,
 	}
 
-	c.JSON(http.StatusOK, peakHours)
-}
-
-// @Summary Get user overview
-// @Description Get user analytics overview
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Success 200 {object} domain.UserOverview
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/users/overview [get]
-func (h *AnalyticsHandler) getUserOverview(c *gin.Context) {
-	overview, err := h.analyticsService.GetUserOverview()
+	trends, err := h.analyticsService.GetRevenueTrends(req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, overview)
+	c.JSON(http.StatusOK, trends)
 }
 
-// @Summary Get user activity
-// @Description Get user activity patterns
+// @Summary Get order trends
+// @Description Get order trends over time
 // @Tags admin
 // @Produce json
 // @Security BearerAuth
-// @Param period query string false "Time period (day|week|month|year)"
-// @Success 200 {object} domain.UserActivity
+// @Param start_date query string true "Start date (YYYY-MM-DD)"
+// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Param granularity query string false "Granularity (day|week|month)"
+// @Param timezone query string false "IANA timezone used for bucketing (default UTC)"
+// @Success 200 {object} domain.OrderTrends
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/users/activity [get]
-func (h *AnalyticsHandler) getUserActivity(c *gin.Context) {
-	period := c.DefaultQuery("period", "month")
+// @Router /api/v1/admin/analytics/orders/trends [get]
+func (h *AnalyticsHandler) getOrderTrends(c *gin.Context) {
+	startDateStr := c.Query("start_date")
+	endDateStr := c.Query("end_date")
+	granularity := c.DefaultQuery("granularity", "day")
 
-	activity, err := h.analyticsService.GetUserActivity(period)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if startDateStr == "" || endDateStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
 		return
 	}
 
-	c.JSON(http.StatusOK, activity)
-}
-
-// @Summary Get user retention
-// @Description Get user retention analytics
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Success 200 {object} domain.UserRetention
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/users/retention [get]
-func (h *AnalyticsHandler) getUserRetention(c *gin.Context) {
-	retention, err := h.analyticsService.GetUserRetention()
+	loc, err := parseLocation(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
 		return
 	}
 
-	c.JSON(http.StatusOK, retention)
-}
-
-// @Summary Get user demographics
-// @Description Get user demographic analytics
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Success 200 {object} domain.UserDemographics
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/users/demographics [get]
-func (h *AnalyticsHandler) getUserDemographics(c *gin.Context) {
-	demographics, err := h.analyticsService.GetUserDemographics()
+	startDate, err := time.ParseInLocation("2006-01-02", startDateStr, loc)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
 		return
 	}
 
-	c.JSON(http.StatusOK, demographics)
-}
-
-// @Summary Get delivery overview
-// @Description Get delivery analytics overview
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Success 200 {object} domain.DeliveryOverview
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/deliveries/overview [get]
-func (h *AnalyticsHandler) getDeliveryOverview(c *gin.Context) {
-	overview, err := h.analyticsService.GetDeliveryOverview()
+	endDate, err := time.ParseInLocation("2006-01-02", endDateStr, loc)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
 		return
 	}
 
-	c.JSON(http.StatusOK, overview)
-}
-
-// @Summary Get delivery performance
-// @Description Get delivery performance metrics
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Param period query string false "Time period (day|week|month|year)"
-// @Success 200 {object} domain.DeliveryPerformance
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/deliveries/performance [get]
-func (h *AnalyticsHandler) getDeliveryPerformance(c *gin.Context) {
-	period := c.DefaultQuery("period", "month")
-
-	performance, err := h.analyticsService.GetDeliveryPerformance(period)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	// end_date is inclusive
+	req := domain.OrderTrendsRequest{
+		StartDate:   startDate,
+		EndDate:     endDate.AddDate(0, 0, 1),
+		Granularity: granularity,
+		Location:    loc,
 	}
 
-	c.JSON(http.StatusOK, performance)
-}
-
-// @Summary Get delivery time analysis
-// @Description Get delivery time analysis
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Success 200 {object} domain.DeliveryTimeAnalysis
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/deliveries/time-analysis [get]
-func (h *AnalyticsHandler) getDeliveryTimeAnalysis(c *gin.Context) {
-	analysis, err := h.analyticsService.GetDeliveryTimeAnalysis()
+	trends, err := h.analyticsService.GetOrderTrends(req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, analysis)
+	c.JSON(http.StatusOK, trends)
 }
 
-// @Summary Get driver statistics
-// @Description Get driver performance statistics
+// @Summary Get peak hours
+// @Description Get peak ordering hours analytics
 // @Tags admin
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} domain.DriverStats
+// @Param start_date query string false "Start date (YYYY-MM-DD), defaults to 30 days ago"
+// @Param end_date query string false "End date (YYYY-MM-DD), defaults to today"
+// @Param timezone query string false "IANA timezone used for hour of day (default UTC)"
+// @Success 200 {object} domain.PeakHours
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/deliveries/driver-stats [get]
-func (h *AnalyticsHandler) getDriverStats(c *gin.Context) {
-	stats, err := h.analyticsService.GetDriverStats()
+// @Router /api/v1/admin/analytics/orders/peak-hours [get]
+func (h *AnalyticsHandler) getPeakHours(c *gin.Context) {
+	loc, err := parseLocation(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
 		return
 	}
 
-	c.JSON(http.StatusOK, stats)
-}
-
-// @Summary Get popular items
-// @Description Get most popular items insights
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Param limit query int false "Limit results"
-// @Param period query string false "Time period"
-// @Success 200 {object} domain.PopularItems
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/insights/popular-items [get]
-func (h *AnalyticsHandler) getPopularItems(c *gin.Context) {
-	limitStr := c.DefaultQuery("limit", "20")
-	period := c.DefaultQuery("period", "month")
+	now := time.Now().In(loc)
+	endDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	startDate := endDate.AddDate(0, 0, -30)
 
-	limit, _ := strconv.Atoi(limitStr)
-
-	req := domain.PopularItemsRequest{
-		Limit:  limit,
-		Period: period,
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		startDate, err = time.ParseInLocation("2006-01-02", startDateStr, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+			return
+		}
 	}
 
-	items, err := h.analyticsService.GetPopularItems(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		endDate, err = time.ParseInLocation("2006-01-02", endDateStr, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+			return
+		}
 	}
 
-	c.JSON(http.StatusOK, items)
-}
-
-// @Summary Get customer segments
-// @Description Get customer segmentation insights
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Success 200 {object} domain.CustomerSegments
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/insights/customer-segments [get]
-func (h *AnalyticsHandler) getCustomerSegments(c *gin.Context) {
-	segments, err := h.analyticsService.GetCustomerSegments()
+	// end_date is inclusive
+	peakHours, err := h.analyticsService.GetPeakHours(domain.PeakHoursRequest{
+		StartDate: startDate,
+		EndDate:   endDate.AddDate(0, 0, 1),
+		Location:  loc,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, segments)
+	c.JSON(http.StatusOK, peakHours)
 }
 
-// @Summary Get market trends
-// @Description Get market trends insights
+// @Summary Get user retention
+// @Description Get cohort retention: share of each signup cohort still ordering in later periods
 // @Tags admin
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} domain.MarketTrends
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/insights/market-trends [get]
-func (h *AnalyticsHandler) getMarketTrends(c *gin.Context) {
-	trends, err := h.analyticsService.GetMarketTrends()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, trends)
-}
+// @Param granularity query string false "Cohort granularity (week|month)"
+// @Param periods query int false "Number of cohorts and follow-up periods"
+// @Success 200 {object} domain.UserRetention
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/analytics/users/retention [get]
+func (h *AnalyticsHandler) getUserRetention(c *gin.Context) {
+	periods, _ := strconv.Atoi(c.DefaultQuery("periods", "8"))
 
-// @Summary Get business recommendations
-// @Description Get AI-powered business recommendations
-// @Tags admin
-// @Produce json
-// @Security BearerAuth
-// @Success 200 {object} domain.BusinessRecommendations
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/admin/analytics/insights/recommendations [get]
-func (h *AnalyticsHandler) getBusinessRecommendations(c *gin.Context) {
-	recommendations, err := h.analyticsService.GetBusinessRecommendations()
+	retention, err := h.analyticsService.GetUserRetention(domain.RetentionRequest{
+		Granularity: c.DefaultQuery("granularity", "week"),
+		Periods:     periods,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, recommendations)
+	c.JSON(http.StatusOK, retention)
 }
 
 // @Summary Generate custom report
-// @Description Generate a custom analytics report
+// @Description Queue a custom analytics report; poll the report until its status is completed or failed
 // @Tags admin
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body domain.CustomReportRequest true "Report configuration"
-// @Success 201 {object} domain.AnalyticsReport
+// @Success 202 {object} domain.AnalyticsReport
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/admin/analytics/reports/custom [post]
@@ -698,8 +517,11 @@ func (h *AnalyticsHandler) generateCustomReport(c *gin.Context) {
 		return
 	}
 
-	adminID, _ := c.Get("user_id")
-	req.CreatedBy = adminID.(string)
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+	req.CreatedBy = adminID
 
 	report, err := h.analyticsService.GenerateCustomReport(req)
 	if err != nil {
@@ -707,7 +529,7 @@ func (h *AnalyticsHandler) generateCustomReport(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, report)
+	c.JSON(http.StatusAccepted, report)
 }
 
 // @Summary Get reports
@@ -719,9 +541,12 @@ func (h *AnalyticsHandler) generateCustomReport(c *gin.Context) {
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/admin/analytics/reports [get]
 func (h *AnalyticsHandler) getReports(c *gin.Context) {
-	adminID, _ := c.Get("user_id")
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	reports, err := h.analyticsService.GetReports(adminID.(string))
+	reports, err := h.analyticsService.GetReports(adminID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -742,9 +567,12 @@ func (h *AnalyticsHandler) getReports(c *gin.Context) {
 // @Router /api/v1/admin/analytics/reports/{id} [get]
 func (h *AnalyticsHandler) getReport(c *gin.Context) {
 	reportID := c.Param("id")
-	adminID, _ := c.Get("user_id")
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	report, err := h.analyticsService.GetReport(reportID, adminID.(string))
+	report, err := h.analyticsService.GetReport(reportID, adminID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -753,6 +581,61 @@ func (h *AnalyticsHandler) getReport(c *gin.Context) {
 	c.JSON(http.StatusOK, report)
 }
 
+// @Summary Export report
+// @Description Download a report as CSV or PDF
+// @Tags admin
+// @Produce text/csv,application/pdf
+// @Security BearerAuth
+// @Param id path string true "Report ID"
+// @Param format query string true "Export format (csv|pdf)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/analytics/reports/{id}/export [get]
+func (h *AnalyticsHandler) exportReport(c *gin.Context) {
+	reportID := c.Param("id")
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+	format := c.Query("format")
+
+	if format != "csv" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or pdf"})
+		return
+	}
+
+	report, err := h.analyticsService.GetReport(reportID, adminID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if report.Status != domain.ReportStatusCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("report is %s", report.Status)})
+		return
+	}
+
+	var data []byte
+	var contentType string
+	if format == "csv" {
+		data, err = export.CSV(report)
+		contentType = "text/csv"
+	} else {
+		data, err = export.PDF(report)
+		contentType = "application/pdf"
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"report-%s.%s\"", report.ID, format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
 // @Summary Delete report
 // @Description Delete a report
 // @Tags admin
@@ -764,9 +647,12 @@ func (h *AnalyticsHandler) getReport(c *gin.Context) {
 // @Router /api/v1/admin/analytics/reports/{id} [delete]
 func (h *AnalyticsHandler) deleteReport(c *gin.Context) {
 	reportID := c.Param("id")
-	adminID, _ := c.Get("user_id")
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	err := h.analyticsService.DeleteReport(reportID, adminID.(string))
+	err := h.analyticsService.DeleteReport(reportID, adminID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -831,28 +717,66 @@ func (h *AnalyticsHandler) getRealTimeRevenue(c *gin.Context) {
 	c.JSON(http.StatusOK, realTimeRevenue)
 }
 
-// Merchant endpoints
-
-// @Summary Get merchant dashboard
-// @Description Get merchant analytics dashboard
-// @Tags merchant
-// @Produce json
+// @Summary Stream real-time metrics
+// @Description Upgrade to a WebSocket that receives order, delivery and revenue metrics as JSON frames. Browsers pass the token as subprotocols: new WebSocket(url, ["bearer", token]).
+// @Tags admin
 // @Security BearerAuth
-// @Success 200 {object} domain.MerchantDashboard
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/merchant/analytics/dashboard [get]
-func (h *AnalyticsHandler) getMerchantDashboard(c *gin.Context) {
-	merchantID, _ := c.Get("user_id")
-
-	dashboard, err := h.analyticsService.GetMerchantDashboard(merchantID.(string))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, dashboard)
+// @Param Sec-WebSocket-Protocol header string false "bearer, <token> when no Authorization header is sent"
+// @Success 101 {object} domain.RealTimeSnapshot
+// @Router /api/v1/admin/analytics/real-time/ws [get]
+func (h *AnalyticsHandler) streamRealTime(c *gin.Context) {
+	// With a custom handshake websocket.Server skips the Origin check; the
+	// route is already behind admin auth, which doesn't rely on cookies
+	server := websocket.Server{Handshake: acceptBearerProtocol, Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		updates, unsubscribe := h.analyticsService.SubscribeRealTime()
+		defer unsubscribe()
+
+		// Clients don't send anything; reading only detects disconnects
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var msg string
+			for {
+				if err := websocket.Message.Receive(ws, &msg); err != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case snapshot, ok := <-updates:
+				if !ok {
+					return
+				}
+				if err := websocket.JSON.Send(ws, snapshot); err != nil {
+					return
+				}
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// acceptBearerProtocol selects the bearer subprotocol a browser offered with
+// its token; browsers drop the connection unless the server echoes it
+func acceptBearerProtocol(config *websocket.Config, req *http.Request) error {
+	for _, protocol := range config.Protocol {
+		if protocol == middleware.WebSocketProtocol {
+			config.Protocol = []string{protocol}
+			return nil
+		}
+	}
+	config.Protocol = nil
+	return nil
 }
 
+// Merchant endpoints
+
 // @Summary Get merchant sales
 // @Description Get merchant sales analytics
 // @Tags merchant
@@ -863,11 +787,14 @@ func (h *AnalyticsHandler) getMerchantDashboard(c *gin.Context) {
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/merchant/analytics/sales [get]
 func (h *AnalyticsHandler) getMerchantSales(c *gin.Context) {
-	merchantID, _ := c.Get("user_id")
+	merchantID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 	period := c.DefaultQuery("period", "month")
 
 	req := domain.MerchantAnalyticsRequest{
-		MerchantID: merchantID.(string),
+		MerchantID: merchantID,
 		Period:     period,
 	}
 
@@ -880,75 +807,46 @@ func (h *AnalyticsHandler) getMerchantSales(c *gin.Context) {
 	c.JSON(http.StatusOK, sales)
 }
 
-// @Summary Get merchant orders
-// @Description Get merchant order analytics
+// @Summary Export merchant sales
+// @Description Download the authenticated merchant's sales for the period as CSV, by day and product
 // @Tags merchant
-// @Produce json
+// @Produce text/csv
 // @Security BearerAuth
-// @Param period query string false "Time period"
-// @Success 200 {object} domain.MerchantOrders
+// @Param period query string false "Time period (day|week|month|year)"
+// @Param format query string false "Export format (csv)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /api/v1/merchant/analytics/orders [get]
-func (h *AnalyticsHandler) getMerchantOrders(c *gin.Context) {
-	merchantID, _ := c.Get("user_id")
-	period := c.DefaultQuery("period", "month")
-
-	req := domain.MerchantAnalyticsRequest{
-		MerchantID: merchantID.(string),
-		Period:     period,
+// @Router /api/v1/merchant/analytics/sales/export [get]
+func (h *AnalyticsHandler) exportMerchantSales(c *gin.Context) {
+	merchantID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
 	}
+	period := c.DefaultQuery("period", "month")
 
-	orders, err := h.analyticsService.GetMerchantOrders(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if format := c.DefaultQuery("format", "csv"); format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv"})
 		return
 	}
 
-	c.JSON(http.StatusOK, orders)
-}
-
-// @Summary Get merchant popular items
-// @Description Get merchant's most popular items
-// @Tags merchant
-// @Produce json
-// @Security BearerAuth
-// @Param limit query int false "Limit results"
-// @Success 200 {object} domain.MerchantPopularItems
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/merchant/analytics/popular-items [get]
-func (h *AnalyticsHandler) getMerchantPopularItems(c *gin.Context) {
-	merchantID, _ := c.Get("user_id")
-	limitStr := c.DefaultQuery("limit", "10")
-
-	limit, _ := strconv.Atoi(limitStr)
-
-	items, err := h.analyticsService.GetMerchantPopularItems(merchantID.(string), limit)
+	sales, err := h.analyticsService.GetMerchantSales(domain.MerchantAnalyticsRequest{
+		MerchantID: merchantID,
+		Period:     period,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, items)
-}
-
-// @Summary Get merchant customer insights
-// @Description Get merchant customer analytics insights
-// @Tags merchant
-// @Produce json
-// @Security BearerAuth
-// @Success 200 {object} domain.MerchantCustomerInsights
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/merchant/analytics/customer-insights [get]
-func (h *AnalyticsHandler) getMerchantCustomerInsights(c *gin.Context) {
-	merchantID, _ := c.Get("user_id")
-
-	insights, err := h.analyticsService.GetMerchantCustomerInsights(merchantID.(string))
+	data, err := export.MerchantSalesCSV(sales)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, insights)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"sales-%s.csv\"", period))
+	c.Data(http.StatusOK, "text/csv", data)
 }
 
 // @Summary Get merchant performance
@@ -960,65 +858,21 @@ func (h *AnalyticsHandler) getMerchantCustomerInsights(c *gin.Context) {
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/merchant/analytics/performance [get]
 func (h *AnalyticsHandler) getMerchantPerformance(c *gin.Context) {
-	merchantID, _ := c.Get("user_id")
-
-	performance, err := h.analyticsService.GetMerchantPerformance(merchantID.(string))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	merchantID, ok := auth.RequireUserID(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, performance)
-}
-
-// Driver endpoints
-
-// @Summary Get driver dashboard
-// @Description Get driver analytics dashboard
-// @Tags driver
-// @Produce json
-// @Security BearerAuth
-// @Success 200 {object} domain.DriverDashboard
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/driver/analytics/dashboard [get]
-func (h *AnalyticsHandler) getDriverDashboard(c *gin.Context) {
-	driverID, _ := c.Get("user_id")
-
-	dashboard, err := h.analyticsService.GetDriverDashboard(driverID.(string))
+	performance, err := h.analyticsService.GetMerchantPerformance(merchantID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, dashboard)
+	c.JSON(http.StatusOK, performance)
 }
 
-// @Summary Get driver earnings
-// @Description Get driver earnings analytics
-// @Tags driver
-// @Produce json
-// @Security BearerAuth
-// @Param period query string false "Time period"
-// @Success 200 {object} domain.DriverEarnings
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/driver/analytics/earnings [get]
-func (h *AnalyticsHandler) getDriverEarnings(c *gin.Context) {
-	driverID, _ := c.Get("user_id")
-	period := c.DefaultQuery("period", "month")
-
-	req := domain.DriverAnalyticsRequest{
-		DriverID: driverID.(string),
-		Period:   period,
-	}
-
-	earnings, err := h.analyticsService.GetDriverEarnings(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, earnings)
-}
+// Driver endpoints
 
 // @Summary Get driver performance
 // @Description Get driver performance analytics
@@ -1029,60 +883,26 @@ func (h *AnalyticsHandler) getDriverEarnings(c *gin.Context) {
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/driver/analytics/performance [get]
 func (h *AnalyticsHandler) getDriverPerformance(c *gin.Context) {
-	driverID, _ := c.Get("user_id")
-
-	performance, err := h.analyticsService.GetDriverPerformanceAnalytics(driverID.(string))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	driverID, ok := auth.RequireUserID(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, performance)
-}
-
-// @Summary Get driver delivery stats
-// @Description Get driver delivery statistics
-// @Tags driver
-// @Produce json
-// @Security BearerAuth
-// @Param period query string false "Time period"
-// @Success 200 {object} domain.DriverDeliveryStats
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/driver/analytics/delivery-stats [get]
-func (h *AnalyticsHandler) getDriverDeliveryStats(c *gin.Context) {
-	driverID, _ := c.Get("user_id")
-	period := c.DefaultQuery("period", "month")
-
-	req := domain.DriverAnalyticsRequest{
-		DriverID: driverID.(string),
-		Period:   period,
-	}
-
-	stats, err := h.analyticsService.GetDriverDeliveryStats(req)
+	performance, err := h.analyticsService.GetDriverPerformanceAnalytics(driverID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, stats)
+	c.JSON(http.StatusOK, performance)
 }
 
-// @Summary Get driver ratings
-// @Description Get driver ratings analytics
-// @Tags driver
-// @Produce json
-// @Security BearerAuth
-// @Success 200 {object} domain.DriverRatings
-// @Failure 500 {object} map[string]string
-// @Router /api/v1/driver/analytics/ratings [get]
-func (h *AnalyticsHandler) getDriverRatings(c *gin.Context) {
-	driverID, _ := c.Get("user_id")
-
-	ratings, err := h.analyticsService.GetDriverRatings(driverID.(string))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+// parseLocation reads the optional IANA timezone query param, defaulting to UTC
+func parseLocation(c *gin.Context) (*time.Location, error) {
+	name := c.DefaultQuery("timezone", "UTC")
+	// "Local" would resolve to the server's zone, which Postgres doesn't know by that name
+	if name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %s", name)
 	}
-
-	c.JSON(http.StatusOK, ratings)
+	return time.LoadLocation(name)
 }
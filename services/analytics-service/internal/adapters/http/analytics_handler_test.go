@@ -0,0 +1,332 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+	"glovo-backend/shared/auth"
+	"glovo-backend/shared/middleware"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+type fakeAnalyticsService struct {
+	domain.AnalyticsService
+	reports  map[string]*domain.AnalyticsReport
+	interval time.Duration
+	trendReq *domain.OrderTrendsRequest
+	trackErr error
+	tracked  []domain.TrackEventRequest
+	salesReq *domain.MerchantAnalyticsRequest
+}
+
+func (s *fakeAnalyticsService) TrackEvent(req domain.TrackEventRequest) error {
+	if s.trackErr != nil {
+		return s.trackErr
+	}
+	s.tracked = append(s.tracked, req)
+	return nil
+}
+
+func (s *fakeAnalyticsService) GetOrderTrends(req domain.OrderTrendsRequest) (*domain.OrderTrends, error) {
+	s.trendReq = &req
+	return &domain.OrderTrends{Timezone: req.Location.String()}, nil
+}
+
+// SubscribeRealTime publishes a snapshot every interval until unsubscribed
+func (s *fakeAnalyticsService) SubscribeRealTime() (<-chan *domain.RealTimeSnapshot, func()) {
+	updates := make(chan *domain.RealTimeSnapshot)
+	stop := make(chan struct{})
+	go func() {
+		defer close(updates)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for count := 1; ; count++ {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			snapshot := &domain.RealTimeSnapshot{Orders: &domain.RealTimeOrders{ActiveOrders: count}}
+			select {
+			case <-stop:
+				return
+			case updates <- snapshot:
+			}
+		}
+	}()
+	return updates, func() { close(stop) }
+}
+
+func (s *fakeAnalyticsService) GetReport(reportID, adminID string) (*domain.AnalyticsReport, error) {
+	report, ok := s.reports[reportID]
+	if !ok {
+		return nil, errors.New("report not found")
+	}
+	return report, nil
+}
+
+func (s *fakeAnalyticsService) GetMerchantSales(req domain.MerchantAnalyticsRequest) (*domain.MerchantSales, error) {
+	s.salesReq = &req
+	return &domain.MerchantSales{MerchantID: req.MerchantID, Period: req.Period, Days: []domain.DailySales{
+		{Date: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), Orders: 2, Revenue: 35, Products: []domain.ProductSales{
+			{ProductID: "pizza", Name: "Pizza", Quantity: 2, Revenue: 24},
+			{ProductID: "cola", Name: "Cola", Quantity: 4, Revenue: 11},
+		}},
+	}}, nil
+}
+
+// serve runs one request through handle with an authenticated admin
+func serve(handle gin.HandlerFunc, method, route, target string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Handle(method, route, func(c *gin.Context) {
+		c.Set(auth.ContextUserID, "admin-1")
+		c.Set(auth.ContextRole, string(auth.RoleAdmin))
+	}, handle)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestExportReport(t *testing.T) {
+	handler := NewAnalyticsHandler(&fakeAnalyticsService{reports: map[string]*domain.AnalyticsReport{
+		"done": {
+			ID:       "done",
+			Status:   domain.ReportStatusCompleted,
+			Sections: []domain.ReportSection{{Title: "Totals", Columns: []string{"orders"}, Rows: [][]string{{"12"}}}},
+		},
+		"running": {ID: "running", Status: domain.ReportStatusProcessing},
+	}})
+
+	tests := []struct {
+		name            string
+		target          string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{name: "csv", target: "/reports/done/export?format=csv", wantStatus: http.StatusOK, wantContentType: "text/csv", wantBody: "section,orders\nTotals,12\n"},
+		{name: "pdf", target: "/reports/done/export?format=pdf", wantStatus: http.StatusOK, wantContentType: "application/pdf", wantBody: "%PDF-"},
+		{name: "unsupported format", target: "/reports/done/export?format=xlsx", wantStatus: http.StatusBadRequest},
+		{name: "missing format", target: "/reports/done/export", wantStatus: http.StatusBadRequest},
+		{name: "unknown report", target: "/reports/missing/export?format=csv", wantStatus: http.StatusNotFound},
+		{name: "report still processing", target: "/reports/running/export?format=csv", wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler.exportReport, http.MethodGet, "/reports/:id/export", tt.target)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != tt.wantContentType {
+				t.Fatalf("Content-Type = %q, want %q", contentType, tt.wantContentType)
+			}
+			if !strings.HasPrefix(w.Body.String(), tt.wantBody) {
+				t.Fatalf("body = %q, want prefix %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestStreamRealTime(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/real-time/ws", NewAnalyticsHandler(&fakeAnalyticsService{interval: 10 * time.Millisecond}).streamRealTime)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Browsers send the token as the subprotocol after "bearer"
+	config, err := websocket.NewConfig("ws"+strings.TrimPrefix(server.URL, "http")+"/real-time/ws", server.URL)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+	config.Protocol = []string{middleware.WebSocketProtocol, "token"}
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer ws.Close()
+
+	if protocols := ws.Config().Protocol; len(protocols) != 1 || protocols[0] != middleware.WebSocketProtocol {
+		t.Fatalf("server selected protocols %v, want [%s]", protocols, middleware.WebSocketProtocol)
+	}
+
+	if err := ws.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("SetReadDeadline: %v", err)
+	}
+	for want := 1; want <= 3; want++ {
+		var snapshot domain.RealTimeSnapshot
+		if err := websocket.JSON.Receive(ws, &snapshot); err != nil {
+			t.Fatalf("frame %d: %v", want, err)
+		}
+		if snapshot.Orders == nil || snapshot.Orders.ActiveOrders != want {
+			t.Fatalf("frame %d = %+v, want active orders %d", want, snapshot.Orders, want)
+		}
+	}
+}
+
+func TestOrderTrendsTimezone(t *testing.T) {
+	tests := []struct {
+		name       string
+		timezone   string
+		wantStatus int
+		wantStart  time.Time
+		wantEnd    time.Time
+		wantZone   string
+	}{
+		{name: "UTC by default", wantStatus: http.StatusOK, wantStart: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), wantEnd: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), wantZone: "UTC"},
+		{name: "New York days start at 5am UTC", timezone: "America/New_York", wantStatus: http.StatusOK, wantStart: time.Date(2026, 3, 1, 5, 0, 0, 0, time.UTC), wantEnd: time.Date(2026, 3, 3, 5, 0, 0, 0, time.UTC), wantZone: "America/New_York"},
+		{name: "unknown timezone", timezone: "Mars/Olympus_Mons", wantStatus: http.StatusBadRequest},
+		{name: "server local time", timezone: "Local", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeAnalyticsService{}
+			target := "/orders/trends?start_date=2026-03-01&end_date=2026-03-02"
+			if tt.timezone != "" {
+				target += "&timezone=" + tt.timezone
+			}
+
+			w := serve(NewAnalyticsHandler(svc).getOrderTrends, http.MethodGet, "/orders/trends", target)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if svc.trendReq != nil {
+					t.Fatalf("service called for a rejected request")
+				}
+				return
+			}
+			if !svc.trendReq.StartDate.Equal(tt.wantStart) || !svc.trendReq.EndDate.Equal(tt.wantEnd) {
+				t.Fatalf("range = %s to %s, want %s to %s", svc.trendReq.StartDate.UTC(), svc.trendReq.EndDate.UTC(), tt.wantStart, tt.wantEnd)
+			}
+			if zone := svc.trendReq.Location.String(); zone != tt.wantZone {
+				t.Fatalf("bucketed in %s, want %s", zone, tt.wantZone)
+			}
+		})
+	}
+}
+
+func TestTrackEvent(t *testing.T) {
+	valid := `{"event_type":"app_open","occurred_at":"2026-03-01T12:00:00Z"}`
+
+	tests := []struct {
+		name       string
+		body       string
+		trackErr   error
+		wantStatus int
+	}{
+		{name: "valid event", body: valid, wantStatus: http.StatusOK},
+		{name: "malformed JSON", body: `{"event_type":`, wantStatus: http.StatusBadRequest},
+		{name: "missing occurred_at", body: `{"event_type":"app_open"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown type", body: valid, trackErr: fmt.Errorf("%w: %q", domain.ErrUnknownEventType, "nope"), wantStatus: http.StatusBadRequest},
+		{name: "fails the schema", body: valid, trackErr: fmt.Errorf("%w: store_id", domain.ErrInvalidEvent), wantStatus: http.StatusBadRequest},
+		{name: "storage failure", body: valid, trackErr: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			svc := &fakeAnalyticsService{trackErr: tt.trackErr}
+			router := gin.New()
+			router.POST("/track-event", func(c *gin.Context) {
+				c.Set(auth.ContextUserID, "user-1")
+			}, NewAnalyticsHandler(svc).trackEvent)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/track-event", strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				// Events are attributed to the caller, never to a user in the body
+				if len(svc.tracked) != 1 || svc.tracked[0].UserID != "user-1" {
+					t.Fatalf("tracked %+v, want one event for user-1", svc.tracked)
+				}
+			}
+		})
+	}
+}
+
+func TestExportMerchantSales(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	merchantToken, err := auth.GenerateToken("merchant-1", auth.RoleMerchant)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	customerToken, err := auth.GenerateToken("customer-1", auth.RoleCustomer)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		target     string
+		wantStatus int
+		wantPeriod string
+	}{
+		{name: "month by default", token: merchantToken, target: "/sales/export", wantStatus: http.StatusOK, wantPeriod: "month"},
+		// Merchants only ever export their own sales
+		{name: "other merchant in the query", token: merchantToken, target: "/sales/export?period=week&merchant_id=merchant-2", wantStatus: http.StatusOK, wantPeriod: "week"},
+		{name: "unsupported format", token: merchantToken, target: "/sales/export?format=xlsx", wantStatus: http.StatusBadRequest},
+		{name: "not a merchant", token: customerToken, target: "/sales/export", wantStatus: http.StatusForbidden},
+		{name: "anonymous", target: "/sales/export", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			svc := &fakeAnalyticsService{}
+			router := gin.New()
+			NewAnalyticsHandler(svc).SetupRoutes(router.Group(""))
+
+			req := httptest.NewRequest(http.MethodGet, "/merchant/analytics"+tt.target, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if svc.salesReq != nil {
+					t.Fatalf("service called for a rejected request")
+				}
+				return
+			}
+
+			if svc.salesReq.MerchantID != "merchant-1" || svc.salesReq.Period != tt.wantPeriod {
+				t.Fatalf("request = %+v, want merchant-1 over %s", *svc.salesReq, tt.wantPeriod)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != "text/csv" {
+				t.Fatalf("Content-Type = %q, want text/csv", contentType)
+			}
+			if disposition, want := w.Header().Get("Content-Disposition"), fmt.Sprintf("attachment; filename=\"sales-%s.csv\"", tt.wantPeriod); disposition != want {
+				t.Fatalf("Content-Disposition = %q, want %q", disposition, want)
+			}
+			want := "date,product_id,product_name,orders,quantity,revenue\n" +
+				"2026-10-12,ALL,,2,6,35.00\n" +
+				"2026-10-12,pizza,Pizza,,2,24.00\n" +
+				"2026-10-12,cola,Cola,,4,11.00\n"
+			if w.Body.String() != want {
+				t.Fatalf("body = %q, want %q", w.Body.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// OrderSubscriber keeps analytics' copy of orders current from the order
+// service's status events
+type OrderSubscriber struct {
+	analyticsService domain.AnalyticsService
+}
+
+func NewOrderSubscriber(analyticsService domain.AnalyticsService) *OrderSubscriber {
+	return &OrderSubscriber{analyticsService: analyticsService}
+}
+
+// Register subscribes to the order events analytics aggregates
+func (s *OrderSubscriber) Register(bus events.Bus) error {
+	return bus.Subscribe(events.OrderStatusChanged, s.handleOrderStatusChanged)
+}
+
+func (s *OrderSubscriber) handleOrderStatusChanged(ctx context.Context, event events.Event) error {
+	var payload events.OrderStatusChangedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	order := &domain.OrderFact{
+		ID:            payload.OrderID,
+		CustomerID:    payload.CustomerID,
+		MerchantID:    payload.MerchantID,
+		DriverID:      payload.DriverID,
+		Status:        payload.Status,
+		TotalAmount:   payload.TotalAmount,
+		DeliveryFee:   payload.DeliveryFee,
+		ServiceFee:    payload.ServiceFee,
+		TaxAmount:     payload.TaxAmount,
+		FinalAmount:   payload.FinalAmount,
+		EstimatedTime: payload.EstimatedTime,
+		PlacedAt:      payload.PlacedAt,
+		CompletedAt:   payload.CompletedAt,
+		CancelledAt:   payload.CancelledAt,
+		UpdatedAt:     payload.ChangedAt,
+	}
+	for i, item := range payload.Items {
+		order.Items = append(order.Items, domain.OrderItemFact{
+			OrderID:   payload.OrderID,
+			Line:      i,
+			ProductID: item.ProductID,
+			Name:      item.Name,
+			Price:     item.Price,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	if err := s.analyticsService.RecordOrder(order); err != nil {
+		return fmt.Errorf("failed to record order %s: %w", payload.OrderID, err)
+	}
+	return nil
+}
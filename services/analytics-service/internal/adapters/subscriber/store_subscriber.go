@@ -0,0 +1,43 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// StoreSubscriber records customers' store ratings from the catalog
+// service's events
+type StoreSubscriber struct {
+	analyticsService domain.AnalyticsService
+}
+
+func NewStoreSubscriber(analyticsService domain.AnalyticsService) *StoreSubscriber {
+	return &StoreSubscriber{analyticsService: analyticsService}
+}
+
+// Register subscribes to the catalog events analytics aggregates
+func (s *StoreSubscriber) Register(bus events.Bus) error {
+	return bus.Subscribe(events.StoreRated, s.handleStoreRated)
+}
+
+func (s *StoreSubscriber) handleStoreRated(ctx context.Context, event events.Event) error {
+	var payload events.StoreRatedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	rating := &domain.StoreRatingFact{
+		OrderID:    payload.OrderID,
+		StoreID:    payload.StoreID,
+		MerchantID: payload.MerchantID,
+		Rating:     payload.Rating,
+		RatedAt:    payload.RatedAt,
+	}
+	if err := s.analyticsService.RecordStoreRating(rating); err != nil {
+		return fmt.Errorf("failed to record rating for order %s: %w", payload.OrderID, err)
+	}
+	return nil
+}
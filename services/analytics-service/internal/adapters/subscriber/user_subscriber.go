@@ -0,0 +1,40 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// UserSubscriber records signups from the user service's events
+type UserSubscriber struct {
+	analyticsService domain.AnalyticsService
+}
+
+func NewUserSubscriber(analyticsService domain.AnalyticsService) *UserSubscriber {
+	return &UserSubscriber{analyticsService: analyticsService}
+}
+
+// Register subscribes to the user events analytics aggregates
+func (s *UserSubscriber) Register(bus events.Bus) error {
+	return bus.Subscribe(events.UserRegistered, s.handleUserRegistered)
+}
+
+func (s *UserSubscriber) handleUserRegistered(ctx context.Context, event events.Event) error {
+	var payload events.UserRegisteredPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	user := &domain.UserFact{
+		ID:           payload.UserID,
+		Role:         payload.Role,
+		RegisteredAt: payload.RegisteredAt,
+	}
+	if err := s.analyticsService.RecordUser(user); err != nil {
+		return fmt.Errorf("failed to record user %s: %w", payload.UserID, err)
+	}
+	return nil
+}
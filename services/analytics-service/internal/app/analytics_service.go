@@ -1,6 +1,11 @@
 package app
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
 	"time"
 
 	"glovo-backend/services/analytics-service/internal/domain"
@@ -13,6 +18,13 @@ type analyticsService struct {
 	revenueRepo     domain.RevenueMetricsRepository
 	driverRepo      domain.DriverMetricsRepository
 	merchantRepo    domain.MerchantMetricsRepository
+	sourceRepo      domain.SourceDataRepository
+	rollupRepo      domain.RollupRepository
+	reportRepo      domain.ReportRepository
+	eventRepo       domain.EventRepository
+	cache           domain.Cache
+	realTime        *realTimeHub
+	reportQueued    chan struct{}
 	userService     domain.UserService
 	orderService    domain.OrderService
 	paymentService  domain.PaymentService
@@ -26,6 +38,12 @@ func NewAnalyticsService(
 	revenueRepo domain.RevenueMetricsRepository,
 	driverRepo domain.DriverMetricsRepository,
 	merchantRepo domain.MerchantMetricsRepository,
+	sourceRepo domain.SourceDataRepository,
+	rollupRepo domain.RollupRepository,
+	reportRepo domain.ReportRepository,
+	eventRepo domain.EventRepository,
+	cache domain.Cache,
+	realTimeInterval time.Duration,
 	userService domain.UserService,
 	orderService domain.OrderService,
 	paymentService domain.PaymentService,
@@ -33,108 +51,340 @@ func NewAnalyticsService(
 	driverService domain.DriverService,
 	locationService domain.LocationService,
 ) domain.AnalyticsService {
-	return &analyticsService{
+	s := &analyticsService{
 		platformRepo:    platformRepo,
 		revenueRepo:     revenueRepo,
 		driverRepo:      driverRepo,
 		merchantRepo:    merchantRepo,
+		sourceRepo:      sourceRepo,
+		rollupRepo:      rollupRepo,
+		reportRepo:      reportRepo,
+		eventRepo:       eventRepo,
+		cache:           cache,
 		userService:     userService,
 		orderService:    orderService,
 		paymentService:  paymentService,
 		catalogService:  catalogService,
 		driverService:   driverService,
 		locationService: locationService,
+		reportQueued:    make(chan struct{}, 1),
 	}
+	s.realTime = newRealTimeHub(realTimeInterval, s.GetRealTimeSnapshot)
+	return s
+}
+
+// Event tracking
+func (s *analyticsService) TrackEvent(req domain.TrackEventRequest) error {
+	event, err := newTrackedEvent(req, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return s.eventRepo.Create(event)
+}
+
+// GetFunnel counts, for each stage, the users who reached it after every
+// stage before it, in order, within the date range
+func (s *analyticsService) GetFunnel(req domain.FunnelRequest) (*domain.Funnel, error) {
+	stages := req.Stages
+	if len(stages) == 0 {
+		stages = domain.DefaultFunnelStages
+	}
+
+	seen := make(map[string]bool, len(stages))
+	for _, stage := range stages {
+		if stage == "" {
+			return nil, fmt.Errorf("%w: stages must not be empty", domain.ErrInvalidFunnel)
+		}
+		if seen[stage] {
+			return nil, fmt.Errorf("%w: stage %q is repeated", domain.ErrInvalidFunnel, stage)
+		}
+		seen[stage] = true
+	}
+
+	counts, err := s.eventRepo.CountFunnel(stages, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	funnel := &domain.Funnel{
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+	}
+
+	for i, stage := range stages {
+		users := counts[i]
+		step := domain.FunnelStage{Stage: stage, Users: users}
+		if i == 0 {
+			if users > 0 {
+				step.ConversionRate = 100
+				step.OverallRate = 100
+			}
+		} else {
+			if previous := funnel.Stages[i-1].Users; previous > 0 {
+				step.ConversionRate = float64(users) / float64(previous) * 100
+			}
+			if first := funnel.Stages[0].Users; first > 0 {
+				step.OverallRate = float64(users) / float64(first) * 100
+			}
+		}
+		funnel.Stages = append(funnel.Stages, step)
+	}
+
+	return funnel, nil
 }
 
 // Platform analytics
+func (s *analyticsService) GetAdminDashboard() (*domain.AdminDashboard, error) {
+	var dashboard domain.AdminDashboard
+	if s.cache.Get("dashboard", &dashboard) {
+		return &dashboard, nil
+	}
+
+	stats, complete := s.cachedPlatformStats()
+
+	overview, err := s.GetRevenueOverview(domain.RevenueRequest{Period: "month"})
+	if err != nil {
+		return nil, err
+	}
+
+	realTime, err := s.realTimeStats()
+	if err != nil {
+		log.Printf("Real-time stats incomplete: %v", err)
+	}
+
+	dashboard = domain.AdminDashboard{
+		PlatformStats:   stats,
+		RevenueOverview: overview,
+		RealTime:        realTime,
+		GeneratedAt:     time.Now(),
+	}
+	// A dashboard missing an upstream service's figures is served but not
+	// cached, so the next request tries again
+	if complete && err == nil {
+		s.cache.Set("dashboard", &dashboard)
+	}
+
+	return &dashboard, nil
+}
+
 func (s *analyticsService) GetPlatformStats() (*domain.PlatformStats, error) {
+	stats, _ := s.cachedPlatformStats()
+	return stats, nil
+}
+
+// cachedPlatformStats returns the cached platform stats, or gathers them and
+// caches them when every upstream service answered. It reports whether the
+// stats are complete.
+func (s *analyticsService) cachedPlatformStats() (*domain.PlatformStats, bool) {
 	stats := &domain.PlatformStats{}
+	if s.cache.Get("platform_stats", stats) {
+		return stats, true
+	}
+
+	stats, err := s.platformStats()
+	if err != nil {
+		log.Printf("Platform stats incomplete, not caching them: %v", err)
+		return stats, false
+	}
+	s.cache.Set("platform_stats", stats)
+	return stats, true
+}
+
+// platformStats gathers the platform stats from the upstream services. The
+// figures of a service that fails are left zero and its error is returned
+// with the stats.
+func (s *analyticsService) platformStats() (*domain.PlatformStats, error) {
+	stats := &domain.PlatformStats{}
+	var failed upstreamErrors
 
 	// Get user counts
-	if totalUsers, err := s.userService.GetUserCount(); err == nil {
+	if totalUsers, err := s.userService.GetUserCount(); failed.ok("user count", err) {
 		stats.TotalUsers = totalUsers
 	}
 
-	if totalMerchants, err := s.userService.GetMerchantCount(); err == nil {
+	if totalMerchants, err := s.userService.GetMerchantCount(); failed.ok("merchant count", err) {
 		stats.TotalMerchants = totalMerchants
 	}
 
-	if totalDrivers, err := s.userService.GetDriverCount(); err == nil {
+	if totalDrivers, err := s.userService.GetDriverCount(); failed.ok("driver count", err) {
 		stats.TotalDrivers = totalDrivers
 	}
 
 	// Get order stats
-	if activeOrders, err := s.orderService.GetActiveOrdersCount(); err == nil {
+	if activeOrders, err := s.orderService.GetActiveOrdersCount(); failed.ok("active orders", err) {
 		stats.ActiveOrders = activeOrders
 	}
 
-	if totalOrders, err := s.orderService.GetOrderCount(); err == nil {
+	if totalOrders, err := s.orderService.GetOrderCount(); failed.ok("order count", err) {
 		stats.TotalOrders = totalOrders
 	}
 
-	if ordersByStatus, err := s.orderService.GetOrdersByStatus(); err == nil {
+	if ordersByStatus, err := s.orderService.GetOrdersByStatus(); failed.ok("orders by status", err) {
 		stats.OrdersByStatus = ordersByStatus
 	}
 
 	// Get revenue stats
-	if totalRevenue, err := s.paymentService.GetTotalRevenue(); err == nil {
+	if totalRevenue, err := s.paymentService.GetTotalRevenue(); failed.ok("total revenue", err) {
 		stats.TotalRevenue = totalRevenue
 	}
 
-	if dailyRevenue, err := s.paymentService.GetDailyRevenue(); err == nil {
+	if dailyRevenue, err := s.paymentService.GetDailyRevenue(); failed.ok("daily revenue", err) {
 		stats.DailyRevenue = dailyRevenue
 	}
 
-	if monthlyRevenue, err := s.paymentService.GetMonthlyRevenue(); err == nil {
+	if monthlyRevenue, err := s.paymentService.GetMonthlyRevenue(); failed.ok("monthly revenue", err) {
 		stats.MonthlyRevenue = monthlyRevenue
 	}
 
-	if avgOrderValue, err := s.paymentService.GetAverageOrderValue(); err == nil {
+	if avgOrderValue, err := s.paymentService.GetAverageOrderValue(); failed.ok("average order value", err) {
 		stats.AverageOrderValue = avgOrderValue
 	}
 
 	// Get top performers
-	if topMerchants, err := s.catalogService.GetTopMerchants(5); err == nil {
+	if topMerchants, err := s.catalogService.GetTopMerchants(5); failed.ok("top merchants", err) {
 		stats.TopMerchants = topMerchants
 	}
 
-	if topDrivers, err := s.driverService.GetTopDrivers(5); err == nil {
+	if topDrivers, err := s.driverService.GetTopDrivers(5); failed.ok("top drivers", err) {
 		stats.TopDrivers = topDrivers
 	}
 
 	// Get revenue by period (last 30 days)
 	endDate := time.Now()
 	startDate := endDate.AddDate(0, 0, -30)
-	if revenuePeriod, err := s.paymentService.GetRevenueByPeriod(startDate, endDate); err == nil {
+	if revenuePeriod, err := s.paymentService.GetRevenueByPeriod(startDate, endDate); failed.ok("revenue by period", err) {
 		stats.RevenueByPeriod = revenuePeriod
 	}
 
 	// Get growth metrics
-	if growthMetrics, err := s.GetGrowthMetrics("month"); err == nil {
-		stats.GrowthMetrics = *growthMetrics
+	growthMetrics, err := s.growthMetrics("month")
+	failed.ok("growth metrics", err)
+	stats.GrowthMetrics = *growthMetrics
+
+	return stats, failed.err()
+}
+
+// upstreamErrors collects the failed upstream calls of an aggregation whose
+// figures were left zero
+type upstreamErrors []error
+
+// ok records err, if any, under what and reports whether the call succeeded
+func (e *upstreamErrors) ok(what string, err error) bool {
+	if err != nil {
+		*e = append(*e, fmt.Errorf("%s: %w", what, err))
+		return false
 	}
+	return true
+}
 
-	return stats, nil
+func (e upstreamErrors) err() error {
+	return errors.Join(e...)
+}
+
+func (s *analyticsService) GetRevenueOverview(req domain.RevenueRequest) (*domain.RevenueOverview, error) {
+	if req.Period == "" {
+		req.Period = "month"
+	}
+
+	key := "revenue_overview:" + req.Period
+	overview := &domain.RevenueOverview{}
+	if s.cache.Get(key, overview) {
+		return overview, nil
+	}
+
+	endDate := time.Now()
+	var startDate time.Time
+	interval := "daily"
+	switch req.Period {
+	case "day":
+		startDate = endDate.AddDate(0, 0, -1)
+	case "week":
+		startDate = endDate.AddDate(0, 0, -7)
+	case "month":
+		startDate = endDate.AddDate(0, -1, 0)
+	case "year":
+		startDate = endDate.AddDate(-1, 0, 0)
+		interval = "monthly"
+	default:
+		return nil, fmt.Errorf("unsupported period: %s", req.Period)
+	}
+
+	metrics, err := s.revenueRepo.GetByDateRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	overview = &domain.RevenueOverview{
+		Period:    req.Period,
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+	for _, m := range metrics {
+		overview.TotalRevenue += m.TotalRevenue
+		overview.Commission += m.OrderCommission
+		overview.DeliveryFees += m.DeliveryFees
+		overview.NetProfit += m.NetProfit
+		overview.OrderCount += m.OrderCount
+	}
+	if overview.OrderCount > 0 {
+		overview.AverageOrderValue = overview.TotalRevenue / float64(overview.OrderCount)
+	}
+
+	if byPeriod, err := s.revenueRepo.GetRevenueByPeriod(startDate, endDate, interval); err == nil {
+		overview.RevenueByPeriod = byPeriod
+	}
+
+	s.cache.Set(key, overview)
+	return overview, nil
 }
 
 func (s *analyticsService) GetRevenueAnalytics(startDate, endDate time.Time) ([]domain.RevenueStat, error) {
 	return s.paymentService.GetRevenueByPeriod(startDate, endDate)
 }
 
+func (s *analyticsService) GetRevenueTrends(req domain.RevenueTrendsRequest) (*domain.RevenueTrends, error) {
+	unit, err := trendUnit(req.Granularity)
+	if err != nil {
+		return nil, err
+	}
+	loc := locationOrUTC(req.Location)
+
+	points, err := s.sourceRepo.GetRevenueTrend(unit, loc, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RevenueTrends{
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Granularity: unit,
+		Timezone:    loc.String(),
+		Points:      points,
+	}, nil
+}
+
 func (s *analyticsService) GetGrowthMetrics(period string) (*domain.GrowthMetrics, error) {
+	metrics, _ := s.growthMetrics(period)
+	return metrics, nil
+}
+
+// growthMetrics returns the growth rates, with the errors of the services
+// whose rates were left zero
+func (s *analyticsService) growthMetrics(period string) (*domain.GrowthMetrics, error) {
 	metrics := &domain.GrowthMetrics{}
+	var failed upstreamErrors
 
 	// Get growth rates from external services
-	if userGrowth, err := s.userService.GetUserGrowthRate(period); err == nil {
+	if userGrowth, err := s.userService.GetUserGrowthRate(period); failed.ok("user growth", err) {
 		metrics.UserGrowthRate = userGrowth
 	}
 
-	if orderGrowth, err := s.orderService.GetOrderGrowthRate(period); err == nil {
+	if orderGrowth, err := s.orderService.GetOrderGrowthRate(period); failed.ok("order growth", err) {
 		metrics.OrderGrowthRate = orderGrowth
 	}
 
-	if revenueGrowth, err := s.paymentService.GetRevenueGrowthRate(period); err == nil {
+	if revenueGrowth, err := s.paymentService.GetRevenueGrowthRate(period); failed.ok("revenue growth", err) {
 		metrics.RevenueGrowthRate = revenueGrowth
 	}
 
@@ -142,7 +392,7 @@ func (s *analyticsService) GetGrowthMetrics(period string) (*domain.GrowthMetric
 	metrics.MerchantGrowthRate = 5.2
 	metrics.DriverGrowthRate = 8.1
 
-	return metrics, nil
+	return metrics, failed.err()
 }
 
 func (s *analyticsService) GetTimeSeriesData(req domain.TimeSeriesRequest) (interface{}, error) {
@@ -170,30 +420,198 @@ func (s *analyticsService) GetMerchantAnalytics(merchantID string, startDate, en
 	return s.merchantRepo.AggregateMerchantMetrics(startDate, endDate)
 }
 
+// GetMerchantSales reads the merchant's sales straight from the orders, so
+// today's deliveries are included before the daily rollup runs
+func (s *analyticsService) GetMerchantSales(req domain.MerchantAnalyticsRequest) (*domain.MerchantSales, error) {
+	if req.Period == "" {
+		req.Period = "month"
+	}
+
+	endDate := time.Now()
+	var startDate time.Time
+	switch req.Period {
+	case "day":
+		startDate = endDate.AddDate(0, 0, -1)
+	case "week":
+		startDate = endDate.AddDate(0, 0, -7)
+	case "month":
+		startDate = endDate.AddDate(0, -1, 0)
+	case "year":
+		startDate = endDate.AddDate(-1, 0, 0)
+	default:
+		return nil, fmt.Errorf("unsupported period: %s", req.Period)
+	}
+
+	days, err := s.sourceRepo.GetMerchantDailySales(req.MerchantID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	sales := &domain.MerchantSales{
+		MerchantID: req.MerchantID,
+		Period:     req.Period,
+		StartDate:  startDate,
+		EndDate:    endDate,
+		Days:       days,
+	}
+	for _, day := range days {
+		sales.TotalOrders += day.Orders
+		sales.TotalRevenue += day.Revenue
+	}
+	sales.TotalRevenue = math.Round(sales.TotalRevenue*100) / 100
+	return sales, nil
+}
+
 func (s *analyticsService) GetDriverAnalytics(driverID string, startDate, endDate time.Time) (*domain.DriverMetrics, error) {
 	// Get aggregated metrics for the driver
 	return s.driverRepo.AggregateDriverMetrics(startDate, endDate)
 }
 
+// User analytics
+func (s *analyticsService) GetUserRetention(req domain.RetentionRequest) (*domain.UserRetention, error) {
+	if req.Granularity == "" {
+		req.Granularity = "week"
+	}
+	if req.Granularity != "week" && req.Granularity != "month" {
+		return nil, fmt.Errorf("unsupported granularity: %s", req.Granularity)
+	}
+	if req.Periods <= 0 {
+		req.Periods = 8
+	}
+
+	// Start at a period boundary so the oldest cohort is a whole period
+	current := cohortPeriodStart(time.Now().UTC(), req.Granularity)
+	since := current.AddDate(0, 0, -7*(req.Periods-1))
+	if req.Granularity == "month" {
+		since = current.AddDate(0, -(req.Periods - 1), 0)
+	}
+
+	sizes, err := s.sourceRepo.GetCohortSizes(req.Granularity, since)
+	if err != nil {
+		return nil, err
+	}
+
+	activity, err := s.sourceRepo.GetCohortActivity(req.Granularity, since)
+	if err != nil {
+		return nil, err
+	}
+
+	retention := &domain.UserRetention{
+		Granularity: req.Granularity,
+		Periods:     req.Periods,
+	}
+
+	index := make(map[time.Time]int)
+	for _, size := range sizes {
+		index[size.Cohort] = len(retention.Cohorts)
+		retention.Cohorts = append(retention.Cohorts, domain.CohortRetention{
+			Cohort:      size.Cohort.Format("2006-01-02"),
+			Users:       size.Users,
+			ActiveUsers: make([]int, req.Periods),
+			Retention:   make([]float64, req.Periods),
+		})
+	}
+
+	for _, a := range activity {
+		i, ok := index[a.Cohort]
+		if !ok {
+			continue
+		}
+		offset := periodsBetween(a.Cohort, a.Period, req.Granularity)
+		if offset < 0 || offset >= req.Periods {
+			continue
+		}
+		retention.Cohorts[i].ActiveUsers[offset] = a.Users
+	}
+
+	for i := range retention.Cohorts {
+		cohort := &retention.Cohorts[i]
+		for p, active := range cohort.ActiveUsers {
+			if cohort.Users > 0 {
+				cohort.Retention[p] = float64(active) / float64(cohort.Users) * 100
+			}
+		}
+	}
+
+	return retention, nil
+}
+
 // Order analytics
 func (s *analyticsService) GetOrderStatistics(startDate, endDate time.Time) (map[string]int, error) {
 	return s.orderService.GetOrdersByStatus()
 }
 
-func (s *analyticsService) GetOrderTrends(startDate, endDate time.Time) ([]interface{}, error) {
-	return s.orderService.GetOrderTrends(startDate, endDate)
+func (s *analyticsService) GetOrderTrends(req domain.OrderTrendsRequest) (*domain.OrderTrends, error) {
+	unit, err := trendUnit(req.Granularity)
+	if err != nil {
+		return nil, err
+	}
+	loc := locationOrUTC(req.Location)
+
+	points, err := s.sourceRepo.GetOrderTrend(unit, loc, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.OrderTrends{
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Granularity: unit,
+		Timezone:    loc.String(),
+		Points:      points,
+	}, nil
+}
+
+func (s *analyticsService) GetPeakHours(req domain.PeakHoursRequest) (*domain.PeakHours, error) {
+	loc := locationOrUTC(req.Location)
+
+	counts, err := s.sourceRepo.GetOrdersByHour(loc, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Report every hour of the day, including those without orders
+	peakHours := &domain.PeakHours{
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Timezone:  loc.String(),
+		Hours:     make([]domain.HourlyOrders, 24),
+	}
+	for hour := range peakHours.Hours {
+		peakHours.Hours[hour].Hour = hour
+	}
+	for _, c := range counts {
+		if c.Hour >= 0 && c.Hour < 24 {
+			peakHours.Hours[c.Hour] = c
+		}
+	}
+	for _, h := range peakHours.Hours {
+		if h.OrderCount > peakHours.Hours[peakHours.PeakHour].OrderCount {
+			peakHours.PeakHour = h.Hour
+		}
+	}
+
+	return peakHours, nil
 }
 
 // Real-time metrics
 func (s *analyticsService) GetRealTimeStats() (map[string]interface{}, error) {
+	stats, _ := s.realTimeStats()
+	return stats, nil
+}
+
+// realTimeStats returns the live counts, with the errors of the services
+// whose counts are missing
+func (s *analyticsService) realTimeStats() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
+	var failed upstreamErrors
 
 	// Get real-time counts
-	if activeOrders, err := s.orderService.GetActiveOrdersCount(); err == nil {
+	if activeOrders, err := s.orderService.GetActiveOrdersCount(); failed.ok("active orders", err) {
 		stats["active_orders"] = activeOrders
 	}
 
-	if dailyRevenue, err := s.paymentService.GetDailyRevenue(); err == nil {
+	if dailyRevenue, err := s.paymentService.GetDailyRevenue(); failed.ok("daily revenue", err) {
 		stats["daily_revenue"] = dailyRevenue
 	}
 
@@ -201,90 +619,329 @@ func (s *analyticsService) GetRealTimeStats() (map[string]interface{}, error) {
 	stats["timestamp"] = time.Now()
 	stats["status"] = "live"
 
-	return stats, nil
+	return stats, failed.err()
 }
 
-// Data aggregation
-func (s *analyticsService) AggregateDaily() error {
-	today := time.Now().Truncate(24 * time.Hour)
+func (s *analyticsService) GetRealTimeOrders() (*domain.RealTimeOrders, error) {
+	activeOrders, err := s.orderService.GetActiveOrdersCount()
+	if err != nil {
+		return nil, err
+	}
 
-	// Check if metrics already exist for today
-	if existing, _ := s.platformRepo.GetByDate(today); existing != nil {
-		return s.updateDailyMetrics(existing)
+	byStatus, err := s.orderService.GetOrdersByStatus()
+	if err != nil {
+		return nil, err
 	}
 
-	// Create new daily metrics
-	return s.createDailyMetrics(today)
+	return &domain.RealTimeOrders{
+		ActiveOrders:   activeOrders,
+		OrdersByStatus: byStatus,
+		Timestamp:      time.Now(),
+	}, nil
 }
 
-func (s *analyticsService) RefreshMetrics() error {
-	// Refresh all cached metrics
-	return s.AggregateDaily()
+func (s *analyticsService) GetRealTimeDeliveries() (*domain.RealTimeDeliveries, error) {
+	byStatus, err := s.orderService.GetOrdersByStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := &domain.RealTimeDeliveries{
+		Assigned:  byStatus["assigned"],
+		PickedUp:  byStatus["picked_up"],
+		InTransit: byStatus["in_transit"],
+		Timestamp: time.Now(),
+	}
+	deliveries.ActiveDeliveries = deliveries.Assigned + deliveries.PickedUp + deliveries.InTransit
+	return deliveries, nil
 }
 
-// Helper methods
-func (s *analyticsService) createDailyMetrics(date time.Time) error {
-	metrics := &domain.PlatformMetrics{
+func (s *analyticsService) GetRealTimeRevenue() (*domain.RealTimeRevenue, error) {
+	dailyRevenue, err := s.paymentService.GetDailyRevenue()
+	if err != nil {
+		return nil, err
+	}
+
+	averageOrderValue, err := s.paymentService.GetAverageOrderValue()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RealTimeRevenue{
+		DailyRevenue:      dailyRevenue,
+		AverageOrderValue: averageOrderValue,
+		Timestamp:         time.Now(),
+	}, nil
+}
+
+func (s *analyticsService) GetRealTimeSnapshot() (*domain.RealTimeSnapshot, error) {
+	orders, err := s.GetRealTimeOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.GetRealTimeDeliveries()
+	if err != nil {
+		return nil, err
+	}
+
+	revenue, err := s.GetRealTimeRevenue()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RealTimeSnapshot{
+		Orders:     orders,
+		Deliveries: deliveries,
+		Revenue:    revenue,
+		Timestamp:  time.Now(),
+	}, nil
+}
+
+// SubscribeRealTime streams snapshots until the returned unsubscribe func is called
+func (s *analyticsService) SubscribeRealTime() (<-chan *domain.RealTimeSnapshot, func()) {
+	return s.realTime.subscribe()
+}
+
+// Reports
+
+// GenerateCustomReport stores the report as processing and leaves building it
+// to the report worker; poll GetReport for the result
+func (s *analyticsService) GenerateCustomReport(req domain.CustomReportRequest) (*domain.AnalyticsReport, error) {
+	if req.EndDate.Before(req.StartDate) {
+		return nil, errors.New("end_date must be after start_date")
+	}
+	if req.Type != domain.ReportTypePlatform && req.Type != domain.ReportTypeRevenue {
+		return nil, fmt.Errorf("unsupported report type: %s", req.Type)
+	}
+
+	report := &domain.AnalyticsReport{
 		ID:        uuid.New().String(),
-		Date:      date,
+		Name:      req.Name,
+		Type:      req.Type,
+		Status:    domain.ReportStatusProcessing,
+		CreatedBy: req.CreatedBy,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
 		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
 	}
 
-	// Populate metrics from external services
-	if totalUsers, err := s.userService.GetUserCount(); err == nil {
-		metrics.TotalUsers = totalUsers
+	if err := s.reportRepo.Create(report); err != nil {
+		return nil, fmt.Errorf("failed to save report: %w", err)
 	}
 
-	if totalMerchants, err := s.userService.GetMerchantCount(); err == nil {
-		metrics.TotalMerchants = totalMerchants
+	// Wake the worker; a pending signal already covers this report
+	select {
+	case s.reportQueued <- struct{}{}:
+	default:
 	}
 
-	if totalDrivers, err := s.userService.GetDriverCount(); err == nil {
-		metrics.TotalDrivers = totalDrivers
+	return report, nil
+}
+
+func (s *analyticsService) buildReportSections(report *domain.AnalyticsReport) ([]domain.ReportSection, error) {
+	switch report.Type {
+	case domain.ReportTypePlatform:
+		metrics, err := s.platformRepo.GetByDateRange(report.StartDate, report.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		section := domain.ReportSection{
+			Title:   "Platform metrics",
+			Columns: []string{"date", "total_users", "total_merchants", "total_drivers", "completed_orders", "cancelled_orders", "daily_revenue", "average_order_value"},
+		}
+		for _, m := range metrics {
+			section.Rows = append(section.Rows, []string{
+				m.Date.Format("2006-01-02"),
+				strconv.Itoa(m.TotalUsers),
+				strconv.Itoa(m.TotalMerchants),
+				strconv.Itoa(m.TotalDrivers),
+				strconv.Itoa(m.CompletedOrders),
+				strconv.Itoa(m.CancelledOrders),
+				formatAmount(m.DailyRevenue),
+				formatAmount(m.AverageOrderValue),
+			})
+		}
+		return []domain.ReportSection{section}, nil
+	case domain.ReportTypeRevenue:
+		metrics, err := s.revenueRepo.GetByDateRange(report.StartDate, report.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		section := domain.ReportSection{
+			Title:   "Revenue",
+			Columns: []string{"date", "orders", "total_revenue", "commission", "delivery_fees", "driver_payouts", "merchant_payouts", "net_profit"},
+		}
+		for _, m := range metrics {
+			section.Rows = append(section.Rows, []string{
+				m.Date.Format("2006-01-02"),
+				strconv.Itoa(m.OrderCount),
+				formatAmount(m.TotalRevenue),
+				formatAmount(m.OrderCommission),
+				formatAmount(m.DeliveryFees),
+				formatAmount(m.DriverPayouts),
+				formatAmount(m.MerchantPayouts),
+				formatAmount(m.NetProfit),
+			})
+		}
+		return []domain.ReportSection{section}, nil
+	default:
+		return nil, fmt.Errorf("unsupported report type: %s", report.Type)
 	}
+}
 
-	if activeOrders, err := s.orderService.GetActiveOrdersCount(); err == nil {
-		metrics.ActiveOrders = activeOrders
+func (s *analyticsService) GetReports(adminID string) ([]domain.AnalyticsReport, error) {
+	return s.reportRepo.GetByCreator(adminID)
+}
+
+func (s *analyticsService) GetReport(reportID, adminID string) (*domain.AnalyticsReport, error) {
+	report, err := s.reportRepo.GetByID(reportID)
+	if err != nil {
+		return nil, errors.New("report not found")
 	}
 
-	if completedOrders, err := s.orderService.GetCompletedOrdersCount(); err == nil {
-		metrics.CompletedOrders = completedOrders
+	if report.CreatedBy != adminID {
+		return nil, errors.New("report not found")
+	}
+
+	return report, nil
+}
+
+func (s *analyticsService) DeleteReport(reportID, adminID string) error {
+	if _, err := s.GetReport(reportID, adminID); err != nil {
+		return err
+	}
+	return s.reportRepo.Delete(reportID)
+}
+
+// Source data
+
+// RecordOrder stores the latest snapshot of an order from the order service
+func (s *analyticsService) RecordOrder(order *domain.OrderFact) error {
+	return s.sourceRepo.UpsertOrder(order)
+}
+
+// RecordUser stores a signup from the user service
+func (s *analyticsService) RecordUser(user *domain.UserFact) error {
+	return s.sourceRepo.UpsertUser(user)
+}
+
+// RecordStoreRating stores a customer's store rating from the catalog service
+func (s *analyticsService) RecordStoreRating(rating *domain.StoreRatingFact) error {
+	return s.sourceRepo.UpsertStoreRating(rating)
+}
+
+// Data aggregation
+
+// AggregateDaily rolls up the previous (completed) day
+func (s *analyticsService) AggregateDaily() error {
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	return s.AggregateDate(yesterday)
+}
+
+// AggregateDate rolls up one day of source data into the metrics tables.
+// The day is saved in one transaction and re-running a date replaces its
+// metrics.
+func (s *analyticsService) AggregateDate(date time.Time) error {
+	startDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 1)
+	now := time.Now()
+
+	platform, err := s.sourceRepo.GetPlatformRollup(startDate, endDate)
+	if err != nil {
+		return fmt.Errorf("failed to roll up platform metrics: %w", err)
 	}
+	platform.ID = uuid.New().String()
+	platform.Date = startDate
+	platform.CreatedAt = now
+	platform.UpdatedAt = now
 
-	if cancelledOrders, err := s.orderService.GetCancelledOrdersCount(); err == nil {
-		metrics.CancelledOrders = cancelledOrders
+	revenue, err := s.sourceRepo.GetRevenueRollup(startDate, endDate)
+	if err != nil {
+		return fmt.Errorf("failed to roll up revenue metrics: %w", err)
 	}
+	revenue.ID = uuid.New().String()
+	revenue.Date = startDate
+	revenue.CreatedAt = now
 
-	if totalRevenue, err := s.paymentService.GetTotalRevenue(); err == nil {
-		metrics.TotalRevenue = totalRevenue
+	drivers, err := s.sourceRepo.GetDriverRollups(startDate, endDate)
+	if err != nil {
+		return fmt.Errorf("failed to roll up driver metrics: %w", err)
+	}
+	for i := range drivers {
+		drivers[i].ID = uuid.New().String()
+		drivers[i].Date = startDate
+		drivers[i].CreatedAt = now
 	}
 
-	if dailyRevenue, err := s.paymentService.GetDailyRevenue(); err == nil {
-		metrics.DailyRevenue = dailyRevenue
+	merchants, err := s.sourceRepo.GetMerchantRollups(startDate, endDate)
+	if err != nil {
+		return fmt.Errorf("failed to roll up merchant metrics: %w", err)
+	}
+	for i := range merchants {
+		merchants[i].ID = uuid.New().String()
+		merchants[i].Date = startDate
+		merchants[i].CreatedAt = now
 	}
 
-	if avgOrderValue, err := s.paymentService.GetAverageOrderValue(); err == nil {
-		metrics.AverageOrderValue = avgOrderValue
+	rollup := &domain.DailyRollup{
+		Date:      startDate,
+		Platform:  platform,
+		Revenue:   revenue,
+		Drivers:   drivers,
+		Merchants: merchants,
+	}
+	if err := s.rollupRepo.Save(rollup); err != nil {
+		return fmt.Errorf("failed to save metrics for %s: %w", startDate.Format("2006-01-02"), err)
 	}
 
-	return s.platformRepo.Create(metrics)
+	// Cached aggregations are stale once new metrics are written
+	return s.cache.Clear()
 }
 
-func (s *analyticsService) updateDailyMetrics(metrics *domain.PlatformMetrics) error {
-	// Update existing metrics with current data
-	if totalUsers, err := s.userService.GetUserCount(); err == nil {
-		metrics.TotalUsers = totalUsers
+func (s *analyticsService) RefreshMetrics() error {
+	// Refresh all cached metrics
+	return s.AggregateDaily()
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// cohortPeriodStart truncates t to the start of its week (Monday, as
+// Postgres date_trunc does) or month in UTC
+func cohortPeriodStart(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	if granularity == "month" {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
 	}
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+}
 
-	if activeOrders, err := s.orderService.GetActiveOrdersCount(); err == nil {
-		metrics.ActiveOrders = activeOrders
+// periodsBetween returns how many whole weeks or months separate two period starts
+func periodsBetween(from, to time.Time, granularity string) int {
+	if granularity == "month" {
+		return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
 	}
+	return int(math.Round(to.Sub(from).Hours() / (24 * 7)))
+}
 
-	if dailyRevenue, err := s.paymentService.GetDailyRevenue(); err == nil {
-		metrics.DailyRevenue = dailyRevenue
+// trendUnit validates a trend granularity, defaulting to day
+func trendUnit(granularity string) (string, error) {
+	switch granularity {
+	case "":
+		return "day", nil
+	case "day", "week", "month":
+		return granularity, nil
+	default:
+		return "", fmt.Errorf("unsupported granularity: %s", granularity)
 	}
+}
 
-	metrics.UpdatedAt = time.Now()
-	return s.platformRepo.Update(metrics)
+func locationOrUTC(loc *time.Location) *time.Location {
+	if loc == nil {
+		return time.UTC
+	}
+	return loc
 }
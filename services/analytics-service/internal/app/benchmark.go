@@ -0,0 +1,148 @@
+package app
+
+import (
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+// benchmarkWindow is how far back performance benchmarks look
+const benchmarkWindow = 30 * 24 * time.Hour
+
+// GetMerchantPerformance benchmarks the merchant's last 30 days against every
+// merchant with activity in the same window
+func (s *analyticsService) GetMerchantPerformance(merchantID string) (*domain.MerchantPerformance, error) {
+	endDate := time.Now().UTC()
+	startDate := endDate.Add(-benchmarkWindow)
+
+	peers, err := s.merchantRepo.AggregateByMerchant(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	performance := &domain.MerchantPerformance{
+		MerchantID: merchantID,
+		StartDate:  startDate,
+		EndDate:    endDate,
+		Metrics:    domain.MerchantMetrics{MerchantID: merchantID},
+	}
+	for _, p := range peers {
+		if p.MerchantID == merchantID {
+			performance.Metrics = p
+		}
+	}
+
+	metrics := []struct {
+		name  string
+		value func(m domain.MerchantMetrics) float64
+		// applies limits the peers to those the metric applies to; nil means all
+		applies func(m domain.MerchantMetrics) bool
+	}{
+		{"average_order_value", func(m domain.MerchantMetrics) float64 { return ratio(m.TotalRevenue, m.CompletedOrders) }, nil},
+		{"on_time_rate", func(m domain.MerchantMetrics) float64 { return ratio(float64(m.OnTimeOrders), m.CompletedOrders) * 100 }, nil},
+		// Unrated merchants would pull the average towards zero
+		{"average_rating", func(m domain.MerchantMetrics) float64 { return m.AverageRating }, func(m domain.MerchantMetrics) bool { return m.TotalRatings > 0 }},
+	}
+	for _, metric := range metrics {
+		values := make([]float64, 0, len(peers))
+		for _, p := range peers {
+			if metric.applies == nil || metric.applies(p) {
+				values = append(values, metric.value(p))
+			}
+		}
+		performance.Benchmarks = append(performance.Benchmarks, benchmark(metric.name, metric.value(performance.Metrics), values))
+	}
+
+	return performance, nil
+}
+
+// GetDriverPerformanceAnalytics benchmarks the driver's last 30 days against
+// every driver with deliveries in the same window
+func (s *analyticsService) GetDriverPerformanceAnalytics(driverID string) (*domain.DriverPerformanceAnalytics, error) {
+	endDate := time.Now().UTC()
+	startDate := endDate.Add(-benchmarkWindow)
+
+	peers, err := s.driverRepo.AggregateByDriver(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	performance := &domain.DriverPerformanceAnalytics{
+		DriverID:  driverID,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Metrics:   domain.DriverMetrics{DriverID: driverID},
+	}
+	for _, p := range peers {
+		if p.DriverID == driverID {
+			performance.Metrics = p
+		}
+	}
+
+	metrics := []struct {
+		name  string
+		value func(m domain.DriverMetrics) float64
+	}{
+		{"earnings_per_delivery", func(m domain.DriverMetrics) float64 { return ratio(m.TotalEarnings, m.CompletedDeliveries) }},
+		{"on_time_rate", func(m domain.DriverMetrics) float64 {
+			return ratio(float64(m.OnTimeDeliveries), m.CompletedDeliveries) * 100
+		}},
+	}
+	for _, metric := range metrics {
+		values := make([]float64, len(peers))
+		for i, p := range peers {
+			values[i] = metric.value(p)
+		}
+		performance.Benchmarks = append(performance.Benchmarks, benchmark(metric.name, metric.value(performance.Metrics), values))
+	}
+
+	return performance, nil
+}
+
+func benchmark(metric string, value float64, peers []float64) domain.MetricBenchmark {
+	average := mean(peers)
+	return domain.MetricBenchmark{
+		Metric:          metric,
+		Value:           value,
+		PlatformAverage: average,
+		Delta:           value - average,
+		PercentileRank:  percentileRank(peers, value),
+	}
+}
+
+// percentileRank is the percentage of values below v, counting ties as half
+func percentileRank(values []float64, v float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	var below, equal int
+	for _, value := range values {
+		switch {
+		case value < v:
+			below++
+		case value == v:
+			equal++
+		}
+	}
+	return (float64(below) + float64(equal)/2) / float64(len(values)) * 100
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+func ratio(total float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+func TestPercentileRank(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		v      float64
+		want   float64
+	}{
+		{name: "no peers", values: nil, v: 10, want: 0},
+		{name: "only peer", values: []float64{10}, v: 10, want: 50},
+		{name: "above everyone", values: []float64{1, 2, 3, 4}, v: 5, want: 100},
+		{name: "below everyone", values: []float64{1, 2, 3, 4}, v: 0, want: 0},
+		{name: "middle", values: []float64{1, 2, 3, 4}, v: 2.5, want: 50},
+		{name: "ties count half", values: []float64{1, 2, 2, 4}, v: 2, want: 50},
+		{name: "all tied", values: []float64{3, 3, 3}, v: 3, want: 50},
+		{name: "top of ties", values: []float64{1, 2, 3, 3, 3}, v: 3, want: 70},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentileRank(tt.values, tt.v); math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("percentileRank(%v, %v) = %v, want %v", tt.values, tt.v, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       float64
+		peers       []float64
+		wantAverage float64
+		wantDelta   float64
+	}{
+		{name: "no peers", value: 4, peers: nil, wantAverage: 0, wantDelta: 4},
+		{name: "above average", value: 30, peers: []float64{10, 20, 30}, wantAverage: 20, wantDelta: 10},
+		{name: "below average", value: 10, peers: []float64{10, 20, 30}, wantAverage: 20, wantDelta: -10},
+		{name: "at average", value: 4.5, peers: []float64{4, 5}, wantAverage: 4.5, wantDelta: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := benchmark("metric", tt.value, tt.peers)
+			if got.Metric != "metric" || got.Value != tt.value {
+				t.Fatalf("unexpected benchmark: %+v", got)
+			}
+			if math.Abs(got.PlatformAverage-tt.wantAverage) > 1e-9 {
+				t.Fatalf("PlatformAverage = %v, want %v", got.PlatformAverage, tt.wantAverage)
+			}
+			if math.Abs(got.Delta-tt.wantDelta) > 1e-9 {
+				t.Fatalf("Delta = %v, want %v", got.Delta, tt.wantDelta)
+			}
+		})
+	}
+}
+
+type fakeMerchantMetricsRepo struct {
+	domain.MerchantMetricsRepository
+	peers []domain.MerchantMetrics
+}
+
+func (r *fakeMerchantMetricsRepo) AggregateByMerchant(startDate, endDate time.Time) ([]domain.MerchantMetrics, error) {
+	return r.peers, nil
+}
+
+func TestGetMerchantPerformanceRating(t *testing.T) {
+	peers := []domain.MerchantMetrics{
+		{MerchantID: "m1", CompletedOrders: 10, TotalRevenue: 200, AverageRating: 4, TotalRatings: 5},
+		{MerchantID: "m2", CompletedOrders: 10, TotalRevenue: 100, AverageRating: 5, TotalRatings: 2},
+		{MerchantID: "m3", CompletedOrders: 10, TotalRevenue: 300},
+	}
+
+	tests := []struct {
+		name           string
+		merchantID     string
+		wantRating     float64
+		wantAverage    float64
+		wantPercentile float64
+	}{
+		{name: "rated merchant", merchantID: "m1", wantRating: 4, wantAverage: 4.5, wantPercentile: 25},
+		{name: "best rated merchant", merchantID: "m2", wantRating: 5, wantAverage: 4.5, wantPercentile: 75},
+		{name: "unrated merchant", merchantID: "m3", wantRating: 0, wantAverage: 4.5, wantPercentile: 0},
+		{name: "merchant without activity", merchantID: "m4", wantRating: 0, wantAverage: 4.5, wantPercentile: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &analyticsService{merchantRepo: &fakeMerchantMetricsRepo{peers: peers}}
+
+			performance, err := svc.GetMerchantPerformance(tt.merchantID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var rating *domain.MetricBenchmark
+			for i := range performance.Benchmarks {
+				if performance.Benchmarks[i].Metric == "average_rating" {
+					rating = &performance.Benchmarks[i]
+				}
+			}
+			if rating == nil {
+				t.Fatalf("no average_rating benchmark in %+v", performance.Benchmarks)
+			}
+			if rating.Value != tt.wantRating || rating.PlatformAverage != tt.wantAverage || rating.PercentileRank != tt.wantPercentile {
+				t.Fatalf("unexpected rating benchmark: %+v", rating)
+			}
+			if rating.Delta != tt.wantRating-tt.wantAverage {
+				t.Fatalf("Delta = %v, want %v", rating.Delta, tt.wantRating-tt.wantAverage)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/adapters/cache"
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+type countingRevenueRepo struct {
+	fakeRevenueMetricsRepo
+	calls int
+}
+
+func (r *countingRevenueRepo) GetByDateRange(startDate, endDate time.Time) ([]domain.RevenueMetrics, error) {
+	r.calls++
+	return r.fakeRevenueMetricsRepo.GetByDateRange(startDate, endDate)
+}
+
+func (r *countingRevenueRepo) GetRevenueByPeriod(startDate, endDate time.Time, interval string) ([]domain.RevenueStat, error) {
+	return nil, nil
+}
+
+// fakePlatformSources stands in for every upstream service the dashboard
+// aggregates, counting how often the platform stats are recomputed
+type fakePlatformSources struct {
+	userCountCalls int
+	topDriversErr  error
+}
+
+var errUnavailable = errors.New("unavailable")
+
+func (s *fakePlatformSources) GetUserCount() (int, error) {
+	s.userCountCalls++
+	return 120, nil
+}
+func (s *fakePlatformSources) GetMerchantCount() (int, error) { return 8, nil }
+func (s *fakePlatformSources) GetDriverCount() (int, error)   { return 15, nil }
+func (s *fakePlatformSources) GetUserGrowthRate(period string) (float64, error) {
+	return 4, nil
+}
+func (s *fakePlatformSources) GetOrderCount() (int, error)           { return 40, nil }
+func (s *fakePlatformSources) GetActiveOrdersCount() (int, error)    { return 3, nil }
+func (s *fakePlatformSources) GetCompletedOrdersCount() (int, error) { return 35, nil }
+func (s *fakePlatformSources) GetCancelledOrdersCount() (int, error) { return 2, nil }
+func (s *fakePlatformSources) GetOrdersByStatus() (map[string]int, error) {
+	return map[string]int{"delivered": 35}, nil
+}
+func (s *fakePlatformSources) GetOrderGrowthRate(period string) (float64, error) {
+	return 6, nil
+}
+func (s *fakePlatformSources) GetOrderTrends(startDate, endDate time.Time) ([]interface{}, error) {
+	return nil, errUnavailable
+}
+func (s *fakePlatformSources) GetTotalRevenue() (float64, error)      { return 900, nil }
+func (s *fakePlatformSources) GetDailyRevenue() (float64, error)      { return 60, nil }
+func (s *fakePlatformSources) GetMonthlyRevenue() (float64, error)    { return 900, nil }
+func (s *fakePlatformSources) GetAverageOrderValue() (float64, error) { return 22.5, nil }
+func (s *fakePlatformSources) GetRevenueByPeriod(startDate, endDate time.Time) ([]domain.RevenueStat, error) {
+	return []domain.RevenueStat{{Revenue: 900}}, nil
+}
+func (s *fakePlatformSources) GetRevenueGrowthRate(period string) (float64, error) {
+	return 7, nil
+}
+func (s *fakePlatformSources) GetTopMerchants(limit int) ([]domain.MerchantSummary, error) {
+	return []domain.MerchantSummary{{ID: "merchant-1"}}, nil
+}
+func (s *fakePlatformSources) GetMerchantPerformance(merchantID string) (*domain.MerchantMetrics, error) {
+	return nil, errUnavailable
+}
+func (s *fakePlatformSources) GetTopDrivers(limit int) ([]domain.DriverSummary, error) {
+	if s.topDriversErr != nil {
+		return nil, s.topDriversErr
+	}
+	return []domain.DriverSummary{{ID: "driver-1"}}, nil
+}
+func (s *fakePlatformSources) GetDriverPerformance(driverID string) (*domain.DriverMetrics, error) {
+	return nil, errUnavailable
+}
+
+func TestAdminDashboardCache(t *testing.T) {
+	const ttl = 50 * time.Millisecond
+
+	sources := &fakePlatformSources{}
+	revenue := &countingRevenueRepo{fakeRevenueMetricsRepo: fakeRevenueMetricsRepo{
+		metrics: []domain.RevenueMetrics{{TotalRevenue: 900, OrderCount: 40}},
+	}}
+	svc := &analyticsService{
+		revenueRepo:    revenue,
+		cache:          cache.NewMemoryCache(ttl),
+		userService:    sources,
+		orderService:   sources,
+		paymentService: sources,
+		catalogService: sources,
+		driverService:  sources,
+	}
+
+	first, err := svc.GetAdminDashboard()
+	if err != nil {
+		t.Fatalf("GetAdminDashboard: %v", err)
+	}
+	second, err := svc.GetAdminDashboard()
+	if err != nil {
+		t.Fatalf("GetAdminDashboard: %v", err)
+	}
+	if revenue.calls != 1 || sources.userCountCalls != 1 {
+		t.Fatalf("within the TTL: revenue repo called %d times, user service %d times, want 1 each", revenue.calls, sources.userCountCalls)
+	}
+	if !second.GeneratedAt.Equal(first.GeneratedAt) {
+		t.Fatalf("second dashboard generated at %v, want the cached %v", second.GeneratedAt, first.GeneratedAt)
+	}
+	if second.PlatformStats.TotalUsers != 120 || second.RevenueOverview.TotalRevenue != 900 {
+		t.Fatalf("cached dashboard = %+v, %+v", second.PlatformStats, second.RevenueOverview)
+	}
+
+	time.Sleep(2 * ttl)
+	if _, err := svc.GetAdminDashboard(); err != nil {
+		t.Fatalf("GetAdminDashboard: %v", err)
+	}
+	if revenue.calls != 2 || sources.userCountCalls != 2 {
+		t.Fatalf("after expiry: revenue repo called %d times, user service %d times, want 2 each", revenue.calls, sources.userCountCalls)
+	}
+}
+
+func TestAdminDashboardSkipsCacheOnFailure(t *testing.T) {
+	sources := &fakePlatformSources{topDriversErr: errUnavailable}
+	revenue := &countingRevenueRepo{fakeRevenueMetricsRepo: fakeRevenueMetricsRepo{
+		metrics: []domain.RevenueMetrics{{TotalRevenue: 900, OrderCount: 40}},
+	}}
+	svc := &analyticsService{
+		revenueRepo:    revenue,
+		cache:          cache.NewMemoryCache(time.Minute),
+		userService:    sources,
+		orderService:   sources,
+		paymentService: sources,
+		catalogService: sources,
+		driverService:  sources,
+	}
+
+	// The partial figures are served
+	dashboard, err := svc.GetAdminDashboard()
+	if err != nil {
+		t.Fatalf("GetAdminDashboard: %v", err)
+	}
+	if dashboard.PlatformStats.TotalUsers != 120 || dashboard.PlatformStats.TopDrivers != nil {
+		t.Fatalf("platform stats = %+v, want the users without top drivers", dashboard.PlatformStats)
+	}
+	if _, err := svc.GetPlatformStats(); err != nil {
+		t.Fatalf("GetPlatformStats: %v", err)
+	}
+	if sources.userCountCalls != 2 {
+		t.Fatalf("user service called %d times, want the stats recomputed each time", sources.userCountCalls)
+	}
+
+	// Once the driver service is back, the complete stats are cached
+	sources.topDriversErr = nil
+	for i := 0; i < 2; i++ {
+		dashboard, err = svc.GetAdminDashboard()
+		if err != nil {
+			t.Fatalf("GetAdminDashboard: %v", err)
+		}
+	}
+	if sources.userCountCalls != 3 || len(dashboard.PlatformStats.TopDrivers) != 1 {
+		t.Fatalf("user service called %d times with %d top drivers, want 3 calls and the recovered drivers", sources.userCountCalls, len(dashboard.PlatformStats.TopDrivers))
+	}
+	if _, err := svc.GetPlatformStats(); err != nil || sources.userCountCalls != 3 {
+		t.Fatalf("GetPlatformStats after recovery: user service called %d times (%v), want the cached stats", sources.userCountCalls, err)
+	}
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// newTrackedEvent validates req against domain.EventSchemas and builds the
+// event to store. Custom events are filed under domain.EventCustom.
+func newTrackedEvent(req domain.TrackEventRequest, now time.Time) (*domain.AnalyticsEvent, error) {
+	if strings.TrimSpace(req.UserID) == "" {
+		return nil, fmt.Errorf("%w: user_id is required", domain.ErrInvalidEvent)
+	}
+	if req.OccurredAt.IsZero() {
+		return nil, fmt.Errorf("%w: occurred_at is required", domain.ErrInvalidEvent)
+	}
+	if req.OccurredAt.After(now.Add(domain.MaxEventClockSkew)) {
+		return nil, fmt.Errorf("%w: occurred_at is in the future", domain.ErrInvalidEvent)
+	}
+	if req.OccurredAt.Before(now.Add(-domain.MaxEventAge)) {
+		return nil, fmt.Errorf("%w: occurred_at is older than %s", domain.ErrInvalidEvent, domain.MaxEventAge)
+	}
+
+	properties := make(map[string]string, len(req.Properties)+1)
+	for key, value := range req.Properties {
+		properties[key] = value
+	}
+
+	eventType := req.EventType
+	if name, ok := strings.CutPrefix(eventType, domain.CustomEventPrefix); ok {
+		if !validCustomEventName(name) {
+			return nil, fmt.Errorf("%w: custom event name %q must be lower snake_case of at most %d characters", domain.ErrInvalidEvent, name, domain.MaxCustomEventName)
+		}
+		eventType = domain.EventCustom
+		properties[domain.PropertyCustomEvent] = name
+	} else {
+		required, known := domain.EventSchemas[eventType]
+		if !known {
+			return nil, fmt.Errorf("%w: %q", domain.ErrUnknownEventType, eventType)
+		}
+		var missing []string
+		for _, key := range required {
+			if strings.TrimSpace(properties[key]) == "" {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("%w: %s requires properties %s", domain.ErrInvalidEvent, eventType, strings.Join(missing, ", "))
+		}
+	}
+
+	return &domain.AnalyticsEvent{
+		ID:         uuid.New().String(),
+		EventType:  eventType,
+		UserID:     req.UserID,
+		SessionID:  req.SessionID,
+		Properties: properties,
+		OccurredAt: req.OccurredAt,
+		CreatedAt:  now,
+	}, nil
+}
+
+func validCustomEventName(name string) bool {
+	if name == "" || len(name) > domain.MaxCustomEventName {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
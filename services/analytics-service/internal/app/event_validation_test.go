@@ -0,0 +1,65 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+func TestNewTrackedEvent(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		req        domain.TrackEventRequest
+		wantErr    error
+		wantType   string
+		wantCustom string
+	}{
+		{name: "tracked type", req: domain.TrackEventRequest{EventType: domain.EventStoreView, Properties: map[string]string{"store_id": "s1"}}, wantType: domain.EventStoreView},
+		{name: "type without entity IDs", req: domain.TrackEventRequest{EventType: domain.EventAppOpen}, wantType: domain.EventAppOpen},
+		{name: "late event from an offline client", req: domain.TrackEventRequest{EventType: domain.EventAppOpen, OccurredAt: now.Add(-29 * 24 * time.Hour)}, wantType: domain.EventAppOpen},
+		{name: "custom event", req: domain.TrackEventRequest{EventType: "custom.promo_banner_tap"}, wantType: domain.EventCustom, wantCustom: "promo_banner_tap"},
+		{name: "unknown type", req: domain.TrackEventRequest{EventType: "store_vieww"}, wantErr: domain.ErrUnknownEventType},
+		{name: "custom name posing as a tracked type", req: domain.TrackEventRequest{EventType: "custom.Paid!"}, wantErr: domain.ErrInvalidEvent},
+		{name: "empty custom name", req: domain.TrackEventRequest{EventType: "custom."}, wantErr: domain.ErrInvalidEvent},
+		{name: "custom name too long", req: domain.TrackEventRequest{EventType: "custom." + strings.Repeat("a", domain.MaxCustomEventName+1)}, wantErr: domain.ErrInvalidEvent},
+		{name: "missing entity ID", req: domain.TrackEventRequest{EventType: domain.EventAddToCart, Properties: map[string]string{"store_id": "s1"}}, wantErr: domain.ErrInvalidEvent},
+		{name: "blank entity ID", req: domain.TrackEventRequest{EventType: domain.EventPaid, Properties: map[string]string{"order_id": " "}}, wantErr: domain.ErrInvalidEvent},
+		{name: "no user", req: domain.TrackEventRequest{EventType: domain.EventAppOpen, UserID: " "}, wantErr: domain.ErrInvalidEvent},
+		{name: "from the future", req: domain.TrackEventRequest{EventType: domain.EventAppOpen, OccurredAt: now.Add(time.Hour)}, wantErr: domain.ErrInvalidEvent},
+		{name: "too old", req: domain.TrackEventRequest{EventType: domain.EventAppOpen, OccurredAt: now.Add(-31 * 24 * time.Hour)}, wantErr: domain.ErrInvalidEvent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			if req.UserID == "" {
+				req.UserID = "user-1"
+			}
+			if req.OccurredAt.IsZero() {
+				req.OccurredAt = now.Add(-time.Minute)
+			}
+
+			event, err := newTrackedEvent(req, now)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if event.EventType != tt.wantType || event.UserID != req.UserID || !event.OccurredAt.Equal(req.OccurredAt) {
+				t.Fatalf("unexpected event: %+v", event)
+			}
+			if custom := event.Properties[domain.PropertyCustomEvent]; custom != tt.wantCustom {
+				t.Fatalf("custom event name = %q, want %q", custom, tt.wantCustom)
+			}
+		})
+	}
+}
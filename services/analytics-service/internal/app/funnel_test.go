@@ -0,0 +1,140 @@
+package app
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+// fakeEventRepo keeps events in memory and walks the funnel the way the
+// stage_N queries in CountFunnel do
+type fakeEventRepo struct {
+	domain.EventRepository
+	events []domain.AnalyticsEvent
+}
+
+func (r *fakeEventRepo) Create(event *domain.AnalyticsEvent) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeEventRepo) CountFunnel(stages []string, startDate, endDate time.Time) ([]int, error) {
+	counts := make([]int, len(stages))
+	var reached map[string]time.Time
+	for i, stage := range stages {
+		next := make(map[string]time.Time)
+		for _, event := range r.events {
+			if event.EventType != stage || event.OccurredAt.Before(startDate) || !event.OccurredAt.Before(endDate) {
+				continue
+			}
+			if i > 0 {
+				previous, ok := reached[event.UserID]
+				if !ok || event.OccurredAt.Before(previous) {
+					continue
+				}
+			}
+			if first, ok := next[event.UserID]; !ok || event.OccurredAt.Before(first) {
+				next[event.UserID] = event.OccurredAt
+			}
+		}
+		counts[i] = len(next)
+		reached = next
+	}
+	return counts, nil
+}
+
+func TestGetFunnel(t *testing.T) {
+	start := time.Now().Add(-24 * time.Hour)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	repo := &fakeEventRepo{}
+	svc := &analyticsService{eventRepo: repo}
+	seed := []domain.TrackEventRequest{
+		// user-1 converts
+		{UserID: "user-1", EventType: domain.EventAppOpen, OccurredAt: at(1)},
+		{UserID: "user-1", EventType: domain.EventStoreView, Properties: map[string]string{"store_id": "s1"}, OccurredAt: at(2)},
+		{UserID: "user-1", EventType: domain.EventAddToCart, Properties: map[string]string{"store_id": "s1", "product_id": "p1"}, OccurredAt: at(3)},
+		{UserID: "user-1", EventType: domain.EventCheckout, Properties: map[string]string{"store_id": "s1"}, OccurredAt: at(4)},
+		{UserID: "user-1", EventType: domain.EventPaid, Properties: map[string]string{"order_id": "o1"}, OccurredAt: at(5)},
+		// user-2 abandons the cart
+		{UserID: "user-2", EventType: domain.EventAppOpen, OccurredAt: at(1)},
+		{UserID: "user-2", EventType: domain.EventStoreView, Properties: map[string]string{"store_id": "s1"}, OccurredAt: at(2)},
+		{UserID: "user-2", EventType: domain.EventAddToCart, Properties: map[string]string{"store_id": "s1", "product_id": "p1"}, OccurredAt: at(3)},
+		// user-3 added to cart before viewing the store, which doesn't count
+		{UserID: "user-3", EventType: domain.EventAppOpen, OccurredAt: at(1)},
+		{UserID: "user-3", EventType: domain.EventAddToCart, Properties: map[string]string{"store_id": "s1", "product_id": "p1"}, OccurredAt: at(2)},
+		{UserID: "user-3", EventType: domain.EventStoreView, Properties: map[string]string{"store_id": "s1"}, OccurredAt: at(3)},
+		// user-4 only opened the app
+		{UserID: "user-4", EventType: domain.EventAppOpen, OccurredAt: at(1)},
+		// user-5 never opened the app
+		{UserID: "user-5", EventType: domain.EventStoreView, Properties: map[string]string{"store_id": "s1"}, OccurredAt: at(2)},
+		// user-6 opened the app before the range
+		{UserID: "user-6", EventType: domain.EventAppOpen, OccurredAt: at(-10)},
+	}
+	for _, req := range seed {
+		if err := svc.TrackEvent(req); err != nil {
+			t.Fatalf("TrackEvent(%+v): %v", req, err)
+		}
+	}
+
+	funnel, err := svc.GetFunnel(domain.FunnelRequest{StartDate: start, EndDate: time.Now()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []domain.FunnelStage{
+		{Stage: domain.EventAppOpen, Users: 4, ConversionRate: 100, OverallRate: 100},
+		{Stage: domain.EventStoreView, Users: 3, ConversionRate: 75, OverallRate: 75},
+		{Stage: domain.EventAddToCart, Users: 2, ConversionRate: 200.0 / 3, OverallRate: 50},
+		{Stage: domain.EventCheckout, Users: 1, ConversionRate: 50, OverallRate: 25},
+		{Stage: domain.EventPaid, Users: 1, ConversionRate: 100, OverallRate: 25},
+	}
+	if len(funnel.Stages) != len(want) {
+		t.Fatalf("got %d stages, want %d", len(funnel.Stages), len(want))
+	}
+	for i, stage := range funnel.Stages {
+		w := want[i]
+		if stage.Stage != w.Stage || stage.Users != w.Users ||
+			math.Abs(stage.ConversionRate-w.ConversionRate) > 1e-9 || math.Abs(stage.OverallRate-w.OverallRate) > 1e-9 {
+			t.Fatalf("stage %d = %+v, want %+v", i, stage, w)
+		}
+	}
+}
+
+func TestGetFunnelStages(t *testing.T) {
+	tests := []struct {
+		name    string
+		stages  []string
+		wantErr error
+	}{
+		{name: "custom stages", stages: []string{domain.EventSearch, domain.EventPaid}},
+		{name: "repeated stage", stages: []string{domain.EventAppOpen, domain.EventPaid, domain.EventAppOpen}, wantErr: domain.ErrInvalidFunnel},
+		{name: "empty stage", stages: []string{domain.EventAppOpen, ""}, wantErr: domain.ErrInvalidFunnel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &analyticsService{eventRepo: &fakeEventRepo{}}
+
+			funnel, err := svc.GetFunnel(domain.FunnelRequest{Stages: tt.stages, StartDate: time.Now().Add(-time.Hour), EndDate: time.Now()})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// No events means no users and no rates, not a division by zero
+			for _, stage := range funnel.Stages {
+				if stage.Users != 0 || stage.ConversionRate != 0 || stage.OverallRate != 0 {
+					t.Fatalf("unexpected stage with no events: %+v", stage)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+const (
+	defaultHeatmapWindow   = time.Hour
+	maxHeatmapWindow       = 24 * time.Hour
+	defaultHeatmapCellSize = 0.01 // degrees, about 1 km
+	minHeatmapCellSize     = 0.001
+	defaultHeatmapLimit    = 200
+)
+
+// GetDemandHeatmap counts recent demand events per pickup grid cell and
+// weights each cell against the busiest one
+func (s *analyticsService) GetDemandHeatmap(req domain.HeatmapRequest) (*domain.Heatmap, error) {
+	if req.Window <= 0 {
+		req.Window = defaultHeatmapWindow
+	}
+	if req.Window > maxHeatmapWindow {
+		req.Window = maxHeatmapWindow
+	}
+	if req.CellSize <= 0 {
+		req.CellSize = defaultHeatmapCellSize
+	}
+	if req.CellSize < minHeatmapCellSize {
+		req.CellSize = minHeatmapCellSize
+	}
+	if len(req.EventTypes) == 0 {
+		req.EventTypes = domain.DefaultDemandEvents
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultHeatmapLimit
+	}
+
+	end := time.Now()
+	start := end.Add(-req.Window)
+	cells, err := s.eventRepo.CountByPickupCell(req.EventTypes, req.CellSize, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate demand: %w", err)
+	}
+
+	return &domain.Heatmap{
+		StartTime: start,
+		EndTime:   end,
+		CellSize:  req.CellSize,
+		Points:    heatmapPoints(cells, req.CellSize, req.Limit),
+	}, nil
+}
+
+// heatmapPoints turns cell counts into weighted points at the cell centers,
+// busiest first, keeping at most limit
+func heatmapPoints(cells []domain.HeatmapCell, cellSize float64, limit int) []domain.HeatmapPoint {
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Count != cells[j].Count {
+			return cells[i].Count > cells[j].Count
+		}
+		if cells[i].Row != cells[j].Row {
+			return cells[i].Row < cells[j].Row
+		}
+		return cells[i].Column < cells[j].Column
+	})
+	if len(cells) > limit {
+		cells = cells[:limit]
+	}
+
+	points := make([]domain.HeatmapPoint, 0, len(cells))
+	if len(cells) == 0 || cells[0].Count == 0 {
+		return points
+	}
+	busiest := float64(cells[0].Count)
+	for _, cell := range cells {
+		points = append(points, domain.HeatmapPoint{
+			Latitude:  (float64(cell.Row) + 0.5) * cellSize,
+			Longitude: (float64(cell.Column) + 0.5) * cellSize,
+			Count:     cell.Count,
+			Intensity: float64(cell.Count) / busiest,
+		})
+	}
+	return points
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+// CountByPickupCell buckets events the way the SQL query does, skipping
+// events without numeric pickup coordinates
+func (r *fakeEventRepo) CountByPickupCell(eventTypes []string, cellSize float64, startDate, endDate time.Time) ([]domain.HeatmapCell, error) {
+	counts := make(map[[2]int]int)
+	for _, event := range r.events {
+		if !contains(eventTypes, event.EventType) || event.OccurredAt.Before(startDate) || !event.OccurredAt.Before(endDate) {
+			continue
+		}
+		latitude, err := strconv.ParseFloat(event.Properties[domain.PropertyPickupLatitude], 64)
+		if err != nil {
+			continue
+		}
+		longitude, err := strconv.ParseFloat(event.Properties[domain.PropertyPickupLongitude], 64)
+		if err != nil {
+			continue
+		}
+		counts[[2]int{int(math.Floor(latitude / cellSize)), int(math.Floor(longitude / cellSize))}]++
+	}
+
+	var cells []domain.HeatmapCell
+	for cell, count := range counts {
+		cells = append(cells, domain.HeatmapCell{Row: cell[0], Column: cell[1], Count: count})
+	}
+	return cells, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGetDemandHeatmap(t *testing.T) {
+	repo := &fakeEventRepo{}
+	svc := &analyticsService{eventRepo: repo}
+
+	now := time.Now()
+	paid := func(orderID, latitude, longitude string, ago time.Duration) domain.TrackEventRequest {
+		return domain.TrackEventRequest{
+			UserID:    "user-1",
+			EventType: domain.EventPaid,
+			Properties: map[string]string{
+				"order_id":                     orderID,
+				domain.PropertyPickupLatitude:  latitude,
+				domain.PropertyPickupLongitude: longitude,
+			},
+			OccurredAt: now.Add(-ago),
+		}
+	}
+	seed := []domain.TrackEventRequest{
+		// Three orders in the cell at (41.38, 2.17)
+		paid("o1", "41.381", "2.171", time.Minute),
+		paid("o2", "41.385", "2.175", 10*time.Minute),
+		paid("o3", "41.389", "2.179", 50*time.Minute),
+		// One order in the neighbouring cell
+		paid("o4", "41.391", "2.171", 5*time.Minute),
+		// Outside the window, no coordinates and another event type don't count
+		paid("o5", "41.391", "2.171", 2*time.Hour),
+		paid("o6", "", "", time.Minute),
+		{UserID: "user-1", EventType: domain.EventCheckout, Properties: map[string]string{
+			"store_id": "s1", domain.PropertyPickupLatitude: "41.391", domain.PropertyPickupLongitude: "2.171",
+		}, OccurredAt: now.Add(-time.Minute)},
+	}
+	for _, req := range seed {
+		if err := svc.TrackEvent(req); err != nil {
+			t.Fatalf("TrackEvent(%+v): %v", req, err)
+		}
+	}
+
+	heatmap, err := svc.GetDemandHeatmap(domain.HeatmapRequest{})
+	if err != nil {
+		t.Fatalf("GetDemandHeatmap: %v", err)
+	}
+	if got := heatmap.EndTime.Sub(heatmap.StartTime); got != time.Hour {
+		t.Fatalf("window = %v, want the 1h default", got)
+	}
+	if len(heatmap.Points) != 2 {
+		t.Fatalf("points = %+v, want 2", heatmap.Points)
+	}
+
+	busiest, other := heatmap.Points[0], heatmap.Points[1]
+	if busiest.Count != 3 || busiest.Intensity != 1 {
+		t.Fatalf("busiest = %+v, want 3 orders at intensity 1", busiest)
+	}
+	if other.Count != 1 || math.Abs(other.Intensity-1.0/3) > 1e-9 {
+		t.Fatalf("other = %+v, want 1 order at intensity 1/3", other)
+	}
+	// Points sit at the center of their cell
+	if math.Abs(busiest.Latitude-41.385) > 1e-9 || math.Abs(busiest.Longitude-2.175) > 1e-9 {
+		t.Fatalf("busiest cell center = %v,%v, want 41.385,2.175", busiest.Latitude, busiest.Longitude)
+	}
+
+	top, err := svc.GetDemandHeatmap(domain.HeatmapRequest{Limit: 1})
+	if err != nil {
+		t.Fatalf("GetDemandHeatmap: %v", err)
+	}
+	if len(top.Points) != 1 || top.Points[0].Count != 3 {
+		t.Fatalf("top points = %+v, want the busiest cell only", top.Points)
+	}
+}
+
+func TestHeatmapPointsTies(t *testing.T) {
+	points := heatmapPoints([]domain.HeatmapCell{
+		{Row: 2, Column: 1, Count: 4},
+		{Row: 1, Column: 5, Count: 4},
+		{Row: 1, Column: 3, Count: 4},
+		{Row: 0, Column: 0, Count: 8},
+	}, 1, 10)
+
+	want := []struct{ latitude, longitude float64 }{{0.5, 0.5}, {1.5, 3.5}, {1.5, 5.5}, {2.5, 1.5}}
+	for i, point := range points {
+		if point.Latitude != want[i].latitude || point.Longitude != want[i].longitude {
+			t.Fatalf("point %d = %v,%v, want %v,%v", i, point.Latitude, point.Longitude, want[i].latitude, want[i].longitude)
+		}
+	}
+	if points[1].Intensity != 0.5 {
+		t.Fatalf("intensity = %v, want 0.5", points[1].Intensity)
+	}
+}
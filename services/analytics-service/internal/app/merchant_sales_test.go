@@ -0,0 +1,78 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+// fakeSalesSource returns the days it holds for one merchant and records the range asked for
+type fakeSalesSource struct {
+	domain.SourceDataRepository
+	merchantID         string
+	days               []domain.DailySales
+	startDate, endDate time.Time
+}
+
+func (r *fakeSalesSource) GetMerchantDailySales(merchantID string, startDate, endDate time.Time) ([]domain.DailySales, error) {
+	r.startDate, r.endDate = startDate, endDate
+	if merchantID != r.merchantID {
+		return nil, nil
+	}
+	return r.days, nil
+}
+
+func TestGetMerchantSales(t *testing.T) {
+	source := &fakeSalesSource{merchantID: "merchant-1", days: []domain.DailySales{
+		{Date: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), Orders: 2, Revenue: 35.7},
+		{Date: time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), Orders: 1, Revenue: 9.6},
+	}}
+	svc := &analyticsService{sourceRepo: source}
+
+	tests := []struct {
+		period   string
+		wantDays int
+	}{
+		{period: "", wantDays: 30},
+		{period: "day", wantDays: 1},
+		{period: "week", wantDays: 7},
+		{period: "year", wantDays: 365},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			sales, err := svc.GetMerchantSales(domain.MerchantAnalyticsRequest{MerchantID: "merchant-1", Period: tt.period})
+			if err != nil {
+				t.Fatalf("GetMerchantSales: %v", err)
+			}
+			if sales.MerchantID != "merchant-1" || len(sales.Days) != 2 {
+				t.Fatalf("sales = %+v, want merchant-1's 2 days", sales)
+			}
+			// The float sum is 45.300000000000004
+			if sales.TotalOrders != 3 || sales.TotalRevenue != 45.3 {
+				t.Fatalf("totals = %d orders and %v, want 3 and 45.3", sales.TotalOrders, sales.TotalRevenue)
+			}
+			if !source.startDate.Equal(sales.StartDate) || !source.endDate.Equal(sales.EndDate) {
+				t.Fatalf("read %s to %s, reported %s to %s", source.startDate, source.endDate, sales.StartDate, sales.EndDate)
+			}
+			// Months and years vary in length
+			days := sales.EndDate.Sub(sales.StartDate).Hours() / 24
+			if days < float64(tt.wantDays)-3 || days > float64(tt.wantDays)+1 {
+				t.Fatalf("period covers %.1f days, want about %d", days, tt.wantDays)
+			}
+		})
+	}
+
+	sales, err := svc.GetMerchantSales(domain.MerchantAnalyticsRequest{MerchantID: "merchant-2", Period: "week"})
+	if err != nil {
+		t.Fatalf("GetMerchantSales: %v", err)
+	}
+	if len(sales.Days) != 0 || sales.TotalOrders != 0 {
+		t.Fatalf("merchant-2 sales = %+v, want none", sales)
+	}
+
+	if _, err := svc.GetMerchantSales(domain.MerchantAnalyticsRequest{MerchantID: "merchant-1", Period: "decade"}); err == nil {
+		t.Fatalf("accepted an unsupported period")
+	}
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"log"
+	"time"
+)
+
+// RunNightlyRollup rolls up the previous day every night at the given offset
+// from UTC midnight, e.g. 2h for 02:00 UTC, leaving time for late events to
+// arrive. If yesterday has no metrics yet when it starts, for example after
+// downtime over the scheduled time, it rolls that day up right away. It
+// blocks and is meant to be started in its own goroutine.
+func (s *analyticsService) RunNightlyRollup(at time.Duration) {
+	yesterday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	if _, err := s.platformRepo.GetByDate(yesterday); err != nil {
+		s.runDailyRollup()
+	}
+
+	for {
+		time.Sleep(time.Until(nextRollup(time.Now(), at)))
+		s.runDailyRollup()
+	}
+}
+
+func (s *analyticsService) runDailyRollup() {
+	if err := s.AggregateDaily(); err != nil {
+		log.Printf("Daily analytics aggregation failed: %v", err)
+	}
+}
+
+// nextRollup returns the first UTC midnight plus at that is after now
+func nextRollup(now time.Time, at time.Duration) time.Time {
+	next := now.UTC().Truncate(24 * time.Hour).Add(at)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
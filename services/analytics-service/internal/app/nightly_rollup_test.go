@@ -0,0 +1,153 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+// fakeSourceRepo returns a fixed rollup for every day and counts the days asked for
+type fakeSourceRepo struct {
+	domain.SourceDataRepository
+	completedOrders int
+	err             error
+	days            []time.Time
+}
+
+func (r *fakeSourceRepo) GetPlatformRollup(startDate, endDate time.Time) (*domain.PlatformMetrics, error) {
+	r.days = append(r.days, startDate)
+	if endDate.Sub(startDate) != 24*time.Hour {
+		return nil, errors.New("rollup range is not one day")
+	}
+	return &domain.PlatformMetrics{CompletedOrders: r.completedOrders}, r.err
+}
+
+func (r *fakeSourceRepo) GetRevenueRollup(startDate, endDate time.Time) (*domain.RevenueMetrics, error) {
+	return &domain.RevenueMetrics{OrderCount: r.completedOrders}, nil
+}
+
+func (r *fakeSourceRepo) GetDriverRollups(startDate, endDate time.Time) ([]domain.DriverMetrics, error) {
+	return []domain.DriverMetrics{{DriverID: "driver-1"}, {DriverID: "driver-2"}}, nil
+}
+
+func (r *fakeSourceRepo) GetMerchantRollups(startDate, endDate time.Time) ([]domain.MerchantMetrics, error) {
+	return []domain.MerchantMetrics{{MerchantID: "merchant-1"}}, nil
+}
+
+// fakeRollupRepo keeps one rollup per date, replacing it the way Save's
+// upserts do
+type fakeRollupRepo struct {
+	saved map[time.Time]*domain.DailyRollup
+}
+
+func (r *fakeRollupRepo) Save(rollup *domain.DailyRollup) error {
+	r.saved[rollup.Date] = rollup
+	return nil
+}
+
+type fakeCache struct {
+	domain.Cache
+	clears int
+}
+
+func (c *fakeCache) Clear() error {
+	c.clears++
+	return nil
+}
+
+func TestAggregateDate(t *testing.T) {
+	sources := &fakeSourceRepo{completedOrders: 2}
+	rollups := &fakeRollupRepo{saved: make(map[time.Time]*domain.DailyRollup)}
+	cache := &fakeCache{}
+	svc := &analyticsService{sourceRepo: sources, rollupRepo: rollups, cache: cache}
+
+	madrid := time.FixedZone("CEST", 2*60*60)
+	date := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+
+	// Any time on the day rolls up the same UTC day
+	if err := svc.AggregateDate(time.Date(2026, 10, 10, 17, 45, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AggregateDate: %v", err)
+	}
+	sources.completedOrders = 3
+	if err := svc.AggregateDate(time.Date(2026, 10, 10, 9, 0, 0, 0, madrid)); err != nil {
+		t.Fatalf("AggregateDate: %v", err)
+	}
+
+	for _, day := range sources.days {
+		if !day.Equal(date) || day.Location() != time.UTC {
+			t.Fatalf("rolled up %s, want %s", day, date)
+		}
+	}
+	if len(rollups.saved) != 1 {
+		t.Fatalf("saved %d days, want 1", len(rollups.saved))
+	}
+	rollup, ok := rollups.saved[date]
+	if !ok {
+		t.Fatalf("no rollup saved for %s", date)
+	}
+	if rollup.Platform.CompletedOrders != 3 || rollup.Revenue.OrderCount != 3 {
+		t.Fatalf("rollup has %d orders and %d revenue orders, want the re-run's 3", rollup.Platform.CompletedOrders, rollup.Revenue.OrderCount)
+	}
+	if !rollup.Platform.Date.Equal(date) || !rollup.Revenue.Date.Equal(date) {
+		t.Fatalf("metrics dated %s and %s, want %s", rollup.Platform.Date, rollup.Revenue.Date, date)
+	}
+	ids := map[string]bool{rollup.Platform.ID: true, rollup.Revenue.ID: true}
+	for _, d := range rollup.Drivers {
+		if !d.Date.Equal(date) {
+			t.Fatalf("driver %s dated %s, want %s", d.DriverID, d.Date, date)
+		}
+		ids[d.ID] = true
+	}
+	for _, m := range rollup.Merchants {
+		if !m.Date.Equal(date) {
+			t.Fatalf("merchant %s dated %s, want %s", m.MerchantID, m.Date, date)
+		}
+		ids[m.ID] = true
+	}
+	if ids[""] || len(ids) != 5 {
+		t.Fatalf("metrics IDs = %v, want 5 distinct IDs", ids)
+	}
+	if cache.clears != 2 {
+		t.Fatalf("cache cleared %d times, want once per run", cache.clears)
+	}
+}
+
+func TestAggregateDateSourceError(t *testing.T) {
+	sources := &fakeSourceRepo{err: errors.New("connection refused")}
+	rollups := &fakeRollupRepo{saved: make(map[time.Time]*domain.DailyRollup)}
+	cache := &fakeCache{}
+	svc := &analyticsService{sourceRepo: sources, rollupRepo: rollups, cache: cache}
+
+	if err := svc.AggregateDate(time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)); !errors.Is(err, sources.err) {
+		t.Fatalf("error = %v, want %v", err, sources.err)
+	}
+	if len(rollups.saved) != 0 || cache.clears != 0 {
+		t.Fatalf("saved %d days and cleared the cache %d times after a failed rollup", len(rollups.saved), cache.clears)
+	}
+}
+
+func TestNextRollup(t *testing.T) {
+	at := 2 * time.Hour
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{name: "before the time", now: time.Date(2026, 10, 15, 1, 30, 0, 0, time.UTC), want: time.Date(2026, 10, 15, 2, 0, 0, 0, time.UTC)},
+		{name: "at the time", now: time.Date(2026, 10, 15, 2, 0, 0, 0, time.UTC), want: time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC)},
+		{name: "after the time", now: time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC), want: time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC)},
+		// 01:30 UTC
+		{name: "local time", now: time.Date(2026, 10, 15, 3, 30, 0, 0, time.FixedZone("CEST", 2*60*60)), want: time.Date(2026, 10, 15, 2, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextRollup(tt.now, at); !got.Equal(tt.want) {
+				t.Fatalf("next rollup = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+// realTimeHub runs a single aggregation loop while there are subscribers and
+// fans each snapshot out to all of them, so connected dashboards don't each
+// hit the upstream services
+type realTimeHub struct {
+	interval    time.Duration
+	fetch       func() (*domain.RealTimeSnapshot, error)
+	mu          sync.Mutex
+	subscribers map[chan *domain.RealTimeSnapshot]struct{}
+	latest      *domain.RealTimeSnapshot
+	stop        chan struct{}
+}
+
+func newRealTimeHub(interval time.Duration, fetch func() (*domain.RealTimeSnapshot, error)) *realTimeHub {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &realTimeHub{
+		interval:    interval,
+		fetch:       fetch,
+		subscribers: make(map[chan *domain.RealTimeSnapshot]struct{}),
+	}
+}
+
+func (h *realTimeHub) subscribe() (<-chan *domain.RealTimeSnapshot, func()) {
+	ch := make(chan *domain.RealTimeSnapshot, 1)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	if h.stop == nil {
+		h.stop = make(chan struct{})
+		go h.run(h.stop)
+	} else if h.latest != nil {
+		ch <- h.latest
+	}
+	h.mu.Unlock()
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subscribers[ch]; !ok {
+			return
+		}
+		delete(h.subscribers, ch)
+		close(ch)
+		if len(h.subscribers) == 0 {
+			close(h.stop)
+			h.stop = nil
+			h.latest = nil
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+func (h *realTimeHub) run(stop chan struct{}) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		snapshot, err := h.fetch()
+		if err != nil {
+			log.Printf("Failed to collect real-time metrics: %v", err)
+		} else {
+			h.publish(stop, snapshot)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publish sends the snapshot to every subscriber, replacing any snapshot a
+// slow subscriber has not consumed yet
+func (h *realTimeHub) publish(stop chan struct{}, snapshot *domain.RealTimeSnapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// The loop may have been stopped (and a new one started) while fetching
+	if h.stop != stop {
+		return
+	}
+
+	h.latest = snapshot
+	for ch := range h.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- snapshot
+	}
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+func TestRealTimeHub(t *testing.T) {
+	var fetches atomic.Int64
+	hub := newRealTimeHub(10*time.Millisecond, func() (*domain.RealTimeSnapshot, error) {
+		fetches.Add(1)
+		return &domain.RealTimeSnapshot{Timestamp: time.Now()}, nil
+	})
+
+	receive := func(updates <-chan *domain.RealTimeSnapshot) *domain.RealTimeSnapshot {
+		t.Helper()
+		select {
+		case snapshot := <-updates:
+			return snapshot
+		case <-time.After(time.Second):
+			t.Fatalf("no snapshot received")
+			return nil
+		}
+	}
+
+	first, unsubscribeFirst := hub.subscribe()
+	previous := receive(first)
+	for i := 0; i < 3; i++ {
+		snapshot := receive(first)
+		if !snapshot.Timestamp.After(previous.Timestamp) {
+			t.Fatalf("snapshot %d is not newer than the one before it", i)
+		}
+		previous = snapshot
+	}
+
+	// A second dashboard shares the loop and starts with the latest snapshot
+	second, unsubscribeSecond := hub.subscribe()
+	receive(second)
+	receive(first)
+
+	unsubscribeFirst()
+	unsubscribeSecond()
+	unsubscribeSecond() // unsubscribing twice is harmless
+
+	if _, ok := <-first; ok {
+		// Drain the snapshot that may have been buffered before closing
+		if _, ok := <-first; ok {
+			t.Fatalf("channel still open after unsubscribing")
+		}
+	}
+
+	// With no subscribers left the loop stops fetching
+	time.Sleep(30 * time.Millisecond)
+	stopped := fetches.Load()
+	time.Sleep(50 * time.Millisecond)
+	if fetches.Load() != stopped {
+		t.Fatalf("still fetching after the last subscriber left")
+	}
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"log"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+// RunReportWorker builds processing reports as they are queued, and every
+// pollInterval to pick up reports left over from a previous run. It blocks
+// and is meant to be started in its own goroutine.
+func (s *analyticsService) RunReportWorker(pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.processPendingReports()
+
+		select {
+		case <-s.reportQueued:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *analyticsService) processPendingReports() {
+	reports, err := s.reportRepo.GetByStatus(domain.ReportStatusProcessing)
+	if err != nil {
+		log.Printf("Failed to load pending reports: %v", err)
+		return
+	}
+
+	for i := range reports {
+		s.processReport(&reports[i])
+	}
+}
+
+func (s *analyticsService) processReport(report *domain.AnalyticsReport) {
+	sections, err := s.buildReportSections(report)
+	if err != nil {
+		report.Status = domain.ReportStatusFailed
+		report.Error = err.Error()
+	} else {
+		report.Status = domain.ReportStatusCompleted
+		report.Sections = sections
+	}
+
+	now := time.Now()
+	report.CompletedAt = &now
+
+	if err := s.reportRepo.Update(report); err != nil {
+		log.Printf("Failed to save report %s: %v", report.ID, err)
+	}
+}
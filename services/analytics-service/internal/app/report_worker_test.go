@@ -0,0 +1,139 @@
+package app
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+// fakeReportRepo is shared with the worker goroutine, so it hands out copies
+type fakeReportRepo struct {
+	domain.ReportRepository
+	mu      sync.Mutex
+	reports map[string]domain.AnalyticsReport
+}
+
+func (r *fakeReportRepo) Create(report *domain.AnalyticsReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports[report.ID] = *report
+	return nil
+}
+
+func (r *fakeReportRepo) GetByID(id string) (*domain.AnalyticsReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report, ok := r.reports[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &report, nil
+}
+
+func (r *fakeReportRepo) GetByStatus(status domain.ReportStatus) ([]domain.AnalyticsReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var reports []domain.AnalyticsReport
+	for _, report := range r.reports {
+		if report.Status == status {
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+func (r *fakeReportRepo) Update(report *domain.AnalyticsReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports[report.ID] = *report
+	return nil
+}
+
+type fakeRevenueMetricsRepo struct {
+	domain.RevenueMetricsRepository
+	metrics []domain.RevenueMetrics
+	err     error
+}
+
+func (r *fakeRevenueMetricsRepo) GetByDateRange(startDate, endDate time.Time) ([]domain.RevenueMetrics, error) {
+	return r.metrics, r.err
+}
+
+func TestReportWorker(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		metricsErr error
+		wantStatus domain.ReportStatus
+		wantRows   int
+	}{
+		{name: "completed", wantStatus: domain.ReportStatusCompleted, wantRows: 1},
+		{name: "failed", metricsErr: errors.New("db down"), wantStatus: domain.ReportStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reports := &fakeReportRepo{reports: make(map[string]domain.AnalyticsReport)}
+			svc := &analyticsService{
+				reportRepo:   reports,
+				revenueRepo:  &fakeRevenueMetricsRepo{metrics: []domain.RevenueMetrics{{Date: day, TotalRevenue: 120, OrderCount: 4}}, err: tt.metricsErr},
+				reportQueued: make(chan struct{}, 1),
+			}
+
+			report, err := svc.GenerateCustomReport(domain.CustomReportRequest{
+				Name:      "March revenue",
+				Type:      domain.ReportTypeRevenue,
+				CreatedBy: "admin-1",
+				StartDate: day,
+				EndDate:   day.AddDate(0, 1, 0),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if report.Status != domain.ReportStatusProcessing {
+				t.Fatalf("new report status = %s, want %s", report.Status, domain.ReportStatusProcessing)
+			}
+			if saved, _ := svc.GetReport(report.ID, "admin-1"); saved.Status != domain.ReportStatusProcessing {
+				t.Fatalf("saved report status = %s before the worker ran", saved.Status)
+			}
+
+			// The queued signal wakes the worker well before the poll interval
+			go svc.RunReportWorker(time.Hour)
+
+			deadline := time.Now().Add(time.Second)
+			for {
+				saved, err := svc.GetReport(report.ID, "admin-1")
+				if err != nil {
+					t.Fatalf("GetReport: %v", err)
+				}
+				if saved.Status != domain.ReportStatusProcessing {
+					if saved.Status != tt.wantStatus {
+						t.Fatalf("status = %s, want %s (error %q)", saved.Status, tt.wantStatus, saved.Error)
+					}
+					if saved.CompletedAt == nil {
+						t.Fatalf("CompletedAt not set")
+					}
+					if tt.wantStatus == domain.ReportStatusFailed && saved.Error == "" {
+						t.Fatalf("failed report has no error")
+					}
+					rows := 0
+					for _, section := range saved.Sections {
+						rows += len(section.Rows)
+					}
+					if rows != tt.wantRows {
+						t.Fatalf("%d rows, want %d", rows, tt.wantRows)
+					}
+					return
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("report still processing after the worker ran")
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		})
+	}
+}
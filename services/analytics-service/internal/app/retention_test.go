@@ -0,0 +1,178 @@
+package app
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"glovo-backend/services/analytics-service/internal/domain"
+)
+
+// fakeCohortSource groups in-memory users and orders the way the
+// date_trunc queries behind GetCohortSizes and GetCohortActivity do
+type fakeCohortSource struct {
+	domain.SourceDataRepository
+	users  []domain.UserFact
+	orders []domain.OrderFact
+}
+
+func (r *fakeCohortSource) customers(granularity string, since time.Time) map[string]time.Time {
+	cohorts := make(map[string]time.Time)
+	for _, user := range r.users {
+		if user.Role == "customer" && !user.RegisteredAt.Before(since) {
+			cohorts[user.ID] = cohortPeriodStart(user.RegisteredAt, granularity)
+		}
+	}
+	return cohorts
+}
+
+func (r *fakeCohortSource) GetCohortSizes(granularity string, since time.Time) ([]domain.CohortActivity, error) {
+	counts := make(map[time.Time]int)
+	for _, cohort := range r.customers(granularity, since) {
+		counts[cohort]++
+	}
+	var sizes []domain.CohortActivity
+	for cohort, users := range counts {
+		sizes = append(sizes, domain.CohortActivity{Cohort: cohort, Period: cohort, Users: users})
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i].Cohort.Before(sizes[j].Cohort) })
+	return sizes, nil
+}
+
+func (r *fakeCohortSource) GetCohortActivity(granularity string, since time.Time) ([]domain.CohortActivity, error) {
+	type key struct{ cohort, period time.Time }
+	active := make(map[key]map[string]bool)
+	cohorts := r.customers(granularity, since)
+	for _, order := range r.orders {
+		cohort, ok := cohorts[order.CustomerID]
+		if !ok {
+			continue
+		}
+		k := key{cohort, cohortPeriodStart(order.PlacedAt, granularity)}
+		if active[k] == nil {
+			active[k] = make(map[string]bool)
+		}
+		active[k][order.CustomerID] = true
+	}
+	var activity []domain.CohortActivity
+	for k, users := range active {
+		activity = append(activity, domain.CohortActivity{Cohort: k.cohort, Period: k.period, Users: len(users)})
+	}
+	return activity, nil
+}
+
+func TestGetUserRetention(t *testing.T) {
+	now := time.Now().UTC()
+	week := func(n int) time.Time {
+		// Mid-week, n weeks from the current one
+		return cohortPeriodStart(now, "week").AddDate(0, 0, 7*n).Add(34 * time.Hour)
+	}
+	month := func(n int) time.Time {
+		return cohortPeriodStart(now, "month").AddDate(0, n, 0).Add(time.Hour)
+	}
+	weekCohort := func(n int) string { return cohortPeriodStart(now, "week").AddDate(0, 0, 7*n).Format("2006-01-02") }
+	monthCohort := func(n int) string { return cohortPeriodStart(now, "month").AddDate(0, n, 0).Format("2006-01-02") }
+
+	weekly := &fakeCohortSource{
+		users: []domain.UserFact{
+			{ID: "a", Role: "customer", RegisteredAt: week(-3)},
+			{ID: "b", Role: "customer", RegisteredAt: week(-3)},
+			{ID: "c", Role: "customer", RegisteredAt: week(-3)},
+			{ID: "d", Role: "customer", RegisteredAt: week(-3)},
+			{ID: "e", Role: "customer", RegisteredAt: week(-1)},
+			{ID: "f", Role: "customer", RegisteredAt: week(-1)},
+			// Signed up before the window
+			{ID: "g", Role: "customer", RegisteredAt: week(-4)},
+			{ID: "merchant", Role: "merchant", RegisteredAt: week(-3)},
+		},
+		orders: []domain.OrderFact{
+			{CustomerID: "a", PlacedAt: week(-3)},
+			{CustomerID: "a", PlacedAt: week(-2)},
+			{CustomerID: "a", PlacedAt: week(-1)},
+			{CustomerID: "a", PlacedAt: week(-1).Add(time.Hour)},
+			{CustomerID: "b", PlacedAt: week(-2)},
+			{CustomerID: "c", PlacedAt: week(0)},
+			{CustomerID: "e", PlacedAt: week(-1)},
+			{CustomerID: "e", PlacedAt: week(0)},
+			{CustomerID: "g", PlacedAt: week(-1)},
+			{CustomerID: "merchant", PlacedAt: week(-2)},
+		},
+	}
+	monthly := &fakeCohortSource{
+		users: []domain.UserFact{
+			{ID: "a", Role: "customer", RegisteredAt: month(-2)},
+			{ID: "b", Role: "customer", RegisteredAt: month(-2)},
+			{ID: "c", Role: "customer", RegisteredAt: month(0)},
+		},
+		orders: []domain.OrderFact{
+			{CustomerID: "a", PlacedAt: month(-1)},
+			{CustomerID: "b", PlacedAt: month(-1)},
+			{CustomerID: "b", PlacedAt: month(0)},
+			{CustomerID: "c", PlacedAt: month(0)},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		source *fakeCohortSource
+		req    domain.RetentionRequest
+		want   *domain.UserRetention
+	}{
+		{
+			name:   "weekly",
+			source: weekly,
+			req:    domain.RetentionRequest{Granularity: "week", Periods: 4},
+			want: &domain.UserRetention{Granularity: "week", Periods: 4, Cohorts: []domain.CohortRetention{
+				{Cohort: weekCohort(-3), Users: 4, ActiveUsers: []int{1, 2, 1, 1}, Retention: []float64{25, 50, 25, 25}},
+				{Cohort: weekCohort(-1), Users: 2, ActiveUsers: []int{1, 1, 0, 0}, Retention: []float64{50, 50, 0, 0}},
+			}},
+		},
+		{
+			name:   "shorter window",
+			source: weekly,
+			req:    domain.RetentionRequest{Granularity: "week", Periods: 2},
+			want: &domain.UserRetention{Granularity: "week", Periods: 2, Cohorts: []domain.CohortRetention{
+				{Cohort: weekCohort(-1), Users: 2, ActiveUsers: []int{1, 1}, Retention: []float64{50, 50}},
+			}},
+		},
+		{
+			name:   "monthly",
+			source: monthly,
+			req:    domain.RetentionRequest{Granularity: "month", Periods: 3},
+			want: &domain.UserRetention{Granularity: "month", Periods: 3, Cohorts: []domain.CohortRetention{
+				{Cohort: monthCohort(-2), Users: 2, ActiveUsers: []int{0, 2, 1}, Retention: []float64{0, 100, 50}},
+				{Cohort: monthCohort(0), Users: 1, ActiveUsers: []int{1, 0, 0}, Retention: []float64{100, 0, 0}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &analyticsService{sourceRepo: tt.source}
+			got, err := svc.GetUserRetention(tt.req)
+			if err != nil {
+				t.Fatalf("GetUserRetention: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("retention = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetUserRetentionRequest(t *testing.T) {
+	svc := &analyticsService{sourceRepo: &fakeCohortSource{}}
+
+	got, err := svc.GetUserRetention(domain.RetentionRequest{})
+	if err != nil {
+		t.Fatalf("GetUserRetention: %v", err)
+	}
+	if got.Granularity != "week" || got.Periods != 8 {
+		t.Fatalf("defaults = %s over %d periods, want week over 8", got.Granularity, got.Periods)
+	}
+
+	if _, err := svc.GetUserRetention(domain.RetentionRequest{Granularity: "day"}); err == nil {
+		t.Fatalf("accepted a daily retention matrix")
+	}
+}
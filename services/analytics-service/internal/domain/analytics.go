@@ -7,7 +7,7 @@ import (
 // Analytics entities
 type PlatformMetrics struct {
 	ID                string    `json:"id" gorm:"primaryKey"`
-	Date              time.Time `json:"date" gorm:"index"`
+	Date              time.Time `json:"date" gorm:"uniqueIndex"`
 	TotalUsers        int       `json:"total_users"`
 	TotalMerchants    int       `json:"total_merchants"`
 	TotalDrivers      int       `json:"total_drivers"`
@@ -23,7 +23,7 @@ type PlatformMetrics struct {
 
 type RevenueMetrics struct {
 	ID              string    `json:"id" gorm:"primaryKey"`
-	Date            time.Time `json:"date" gorm:"index"`
+	Date            time.Time `json:"date" gorm:"uniqueIndex"`
 	TotalRevenue    float64   `json:"total_revenue"`
 	OrderCommission float64   `json:"order_commission"`
 	DeliveryFees    float64   `json:"delivery_fees"`
@@ -36,11 +36,12 @@ type RevenueMetrics struct {
 
 type DriverMetrics struct {
 	ID                  string    `json:"id" gorm:"primaryKey"`
-	DriverID            string    `json:"driver_id" gorm:"index"`
-	Date                time.Time `json:"date" gorm:"index"`
+	DriverID            string    `json:"driver_id" gorm:"uniqueIndex:idx_driver_metrics_driver_date"`
+	Date                time.Time `json:"date" gorm:"uniqueIndex:idx_driver_metrics_driver_date"`
 	TotalDeliveries     int       `json:"total_deliveries"`
 	CompletedDeliveries int       `json:"completed_deliveries"`
 	CancelledDeliveries int       `json:"cancelled_deliveries"`
+	OnTimeDeliveries    int       `json:"on_time_deliveries"`
 	TotalEarnings       float64   `json:"total_earnings"`
 	AverageRating       float64   `json:"average_rating"`
 	OnlineHours         float64   `json:"online_hours"`
@@ -51,18 +52,81 @@ type DriverMetrics struct {
 
 type MerchantMetrics struct {
 	ID              string    `json:"id" gorm:"primaryKey"`
-	MerchantID      string    `json:"merchant_id" gorm:"index"`
-	Date            time.Time `json:"date" gorm:"index"`
+	MerchantID      string    `json:"merchant_id" gorm:"uniqueIndex:idx_merchant_metrics_merchant_date"`
+	Date            time.Time `json:"date" gorm:"uniqueIndex:idx_merchant_metrics_merchant_date"`
 	TotalOrders     int       `json:"total_orders"`
 	CompletedOrders int       `json:"completed_orders"`
 	CancelledOrders int       `json:"cancelled_orders"`
+	OnTimeOrders    int       `json:"on_time_orders"`
 	TotalRevenue    float64   `json:"total_revenue"`
 	Commission      float64   `json:"commission"`
 	NetRevenue      float64   `json:"net_revenue"`
 	AverageRating   float64   `json:"average_rating"`
+	TotalRatings    int       `json:"total_ratings"` // store ratings behind AverageRating
 	CreatedAt       time.Time `json:"created_at"`
 }
 
+// AnalyticsEvent is a tracked client or service event
+type AnalyticsEvent struct {
+	ID         string            `json:"id" gorm:"primaryKey"`
+	EventType  string            `json:"event_type" gorm:"index"`
+	UserID     string            `json:"user_id" gorm:"index"`
+	SessionID  string            `json:"session_id,omitempty"`
+	Properties map[string]string `json:"properties,omitempty" gorm:"serializer:json"`
+	OccurredAt time.Time         `json:"occurred_at" gorm:"index"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// Ordering funnel stages, in order
+var DefaultFunnelStages = []string{EventAppOpen, EventStoreView, EventAddToCart, EventCheckout, EventPaid}
+
+// DefaultDemandEvents are the tracked events counted as demand on the heatmap
+var DefaultDemandEvents = []string{EventPaid}
+
+// Event properties holding the pickup coordinates of order and delivery events
+const (
+	PropertyPickupLatitude  = "pickup_latitude"
+	PropertyPickupLongitude = "pickup_longitude"
+)
+
+// AnalyticsReport is a report generated in the background; Sections holds
+// the tabular result once Status is completed
+type AnalyticsReport struct {
+	ID          string          `json:"id" gorm:"primaryKey"`
+	Name        string          `json:"name"`
+	Type        ReportType      `json:"type"`
+	Status      ReportStatus    `json:"status" gorm:"index;default:completed"`
+	Error       string          `json:"error,omitempty"`
+	CreatedBy   string          `json:"created_by" gorm:"index"`
+	StartDate   time.Time       `json:"start_date"`
+	EndDate     time.Time       `json:"end_date"`
+	Sections    []ReportSection `json:"sections,omitempty" gorm:"serializer:json"`
+	CreatedAt   time.Time       `json:"created_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+}
+
+// ReportSection is one table of a report
+type ReportSection struct {
+	Title   string     `json:"title"`
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+type ReportType string
+
+const (
+	ReportTypePlatform ReportType = "platform"
+	ReportTypeRevenue  ReportType = "revenue"
+)
+
+type ReportStatus string
+
+const (
+	ReportStatusProcessing ReportStatus = "processing"
+	ReportStatusCompleted  ReportStatus = "completed"
+	ReportStatusFailed     ReportStatus = "failed"
+)
+
 // Response DTOs
 type PlatformStats struct {
 	TotalUsers        int               `json:"total_users"`
@@ -111,6 +175,260 @@ type GrowthMetrics struct {
 	DriverGrowthRate   float64 `json:"driver_growth_rate"`
 }
 
+// MetricBenchmark compares one metric against the average across all peers
+type MetricBenchmark struct {
+	Metric          string  `json:"metric"`
+	Value           float64 `json:"value"`
+	PlatformAverage float64 `json:"platform_average"`
+	Delta           float64 `json:"delta"`           // value minus platform average
+	PercentileRank  float64 `json:"percentile_rank"` // percent of peers below value, ties count half
+}
+
+type MerchantPerformance struct {
+	MerchantID string            `json:"merchant_id"`
+	StartDate  time.Time         `json:"start_date"`
+	EndDate    time.Time         `json:"end_date"`
+	Metrics    MerchantMetrics   `json:"metrics"`
+	Benchmarks []MetricBenchmark `json:"benchmarks"`
+}
+
+type MerchantAnalyticsRequest struct {
+	MerchantID string `json:"merchant_id"`
+	Period     string `json:"period"` // day, week, month or year
+}
+
+// MerchantSales is a merchant's delivered orders over a period, by UTC day
+// and by product within each day
+type MerchantSales struct {
+	MerchantID   string       `json:"merchant_id"`
+	Period       string       `json:"period"`
+	StartDate    time.Time    `json:"start_date"`
+	EndDate      time.Time    `json:"end_date"`
+	TotalOrders  int          `json:"total_orders"`
+	TotalRevenue float64      `json:"total_revenue"`
+	Days         []DailySales `json:"days"`
+}
+
+type DailySales struct {
+	Date     time.Time      `json:"date"`
+	Orders   int            `json:"orders"`
+	Revenue  float64        `json:"revenue"`
+	Products []ProductSales `json:"products"`
+}
+
+type ProductSales struct {
+	ProductID string  `json:"product_id"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	Revenue   float64 `json:"revenue"`
+}
+
+type DriverPerformanceAnalytics struct {
+	DriverID   string            `json:"driver_id"`
+	StartDate  time.Time         `json:"start_date"`
+	EndDate    time.Time         `json:"end_date"`
+	Metrics    DriverMetrics     `json:"metrics"`
+	Benchmarks []MetricBenchmark `json:"benchmarks"`
+}
+
+// Trend and peak-hour requests bucket timestamps in Location, defaulting to UTC
+type RevenueTrendsRequest struct {
+	StartDate   time.Time      `json:"start_date"`
+	EndDate     time.Time      `json:"end_date"`
+	Granularity string         `json:"granularity"` // day, week, month
+	Location    *time.Location `json:"-"`
+}
+
+type OrderTrendsRequest struct {
+	StartDate   time.Time      `json:"start_date"`
+	EndDate     time.Time      `json:"end_date"`
+	Granularity string         `json:"granularity"` // day, week, month
+	Location    *time.Location `json:"-"`
+}
+
+type PeakHoursRequest struct {
+	StartDate time.Time      `json:"start_date"`
+	EndDate   time.Time      `json:"end_date"`
+	Location  *time.Location `json:"-"`
+}
+
+type TrendPoint struct {
+	Period     time.Time `json:"period"`
+	OrderCount int       `json:"order_count"`
+	Revenue    float64   `json:"revenue"`
+}
+
+type RevenueTrends struct {
+	StartDate   time.Time    `json:"start_date"`
+	EndDate     time.Time    `json:"end_date"`
+	Granularity string       `json:"granularity"`
+	Timezone    string       `json:"timezone"`
+	Points      []TrendPoint `json:"points"`
+}
+
+type OrderTrends struct {
+	StartDate   time.Time    `json:"start_date"`
+	EndDate     time.Time    `json:"end_date"`
+	Granularity string       `json:"granularity"`
+	Timezone    string       `json:"timezone"`
+	Points      []TrendPoint `json:"points"`
+}
+
+type HourlyOrders struct {
+	Hour       int     `json:"hour"` // 0-23 in the requested timezone
+	OrderCount int     `json:"order_count"`
+	Revenue    float64 `json:"revenue"`
+}
+
+type PeakHours struct {
+	StartDate time.Time      `json:"start_date"`
+	EndDate   time.Time      `json:"end_date"`
+	Timezone  string         `json:"timezone"`
+	Hours     []HourlyOrders `json:"hours"`
+	PeakHour  int            `json:"peak_hour"`
+}
+
+type RevenueRequest struct {
+	Period string `json:"period"` // day, week, month, year
+}
+
+type RevenueOverview struct {
+	Period            string        `json:"period"`
+	StartDate         time.Time     `json:"start_date"`
+	EndDate           time.Time     `json:"end_date"`
+	TotalRevenue      float64       `json:"total_revenue"`
+	Commission        float64       `json:"commission"`
+	DeliveryFees      float64       `json:"delivery_fees"`
+	NetProfit         float64       `json:"net_profit"`
+	OrderCount        int           `json:"order_count"`
+	AverageOrderValue float64       `json:"average_order_value"`
+	RevenueByPeriod   []RevenueStat `json:"revenue_by_period"`
+}
+
+type AdminDashboard struct {
+	PlatformStats   *PlatformStats         `json:"platform_stats"`
+	RevenueOverview *RevenueOverview       `json:"revenue_overview"`
+	RealTime        map[string]interface{} `json:"real_time"`
+	GeneratedAt     time.Time              `json:"generated_at"`
+}
+
+type RealTimeOrders struct {
+	ActiveOrders   int            `json:"active_orders"`
+	OrdersByStatus map[string]int `json:"orders_by_status"`
+	Timestamp      time.Time      `json:"timestamp"`
+}
+
+type RealTimeDeliveries struct {
+	ActiveDeliveries int       `json:"active_deliveries"`
+	Assigned         int       `json:"assigned"`
+	PickedUp         int       `json:"picked_up"`
+	InTransit        int       `json:"in_transit"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+type RealTimeRevenue struct {
+	DailyRevenue      float64   `json:"daily_revenue"`
+	AverageOrderValue float64   `json:"average_order_value"`
+	Timestamp         time.Time `json:"timestamp"`
+}
+
+// RealTimeSnapshot is pushed to real-time WebSocket subscribers
+type RealTimeSnapshot struct {
+	Orders     *RealTimeOrders     `json:"orders"`
+	Deliveries *RealTimeDeliveries `json:"deliveries"`
+	Revenue    *RealTimeRevenue    `json:"revenue"`
+	Timestamp  time.Time           `json:"timestamp"`
+}
+
+// TrackEventRequest is checked against EventSchemas: EventType must be a
+// tracked type or a custom one, and Properties must hold the type's entity IDs
+type TrackEventRequest struct {
+	EventType  string            `json:"event_type" binding:"required"`
+	UserID     string            `json:"-"` // the authenticated user
+	SessionID  string            `json:"session_id,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+	OccurredAt time.Time         `json:"occurred_at" binding:"required"`
+}
+
+type FunnelRequest struct {
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Stages    []string  `json:"stages,omitempty"` // defaults to DefaultFunnelStages
+}
+
+type FunnelStage struct {
+	Stage          string  `json:"stage"`
+	Users          int     `json:"users"`
+	ConversionRate float64 `json:"conversion_rate"` // percent of the previous stage
+	OverallRate    float64 `json:"overall_rate"`    // percent of the first stage
+}
+
+type Funnel struct {
+	StartDate time.Time     `json:"start_date"`
+	EndDate   time.Time     `json:"end_date"`
+	Stages    []FunnelStage `json:"stages"`
+}
+
+// HeatmapRequest aggregates demand events from the last Window into square
+// grid cells CellSize degrees wide
+type HeatmapRequest struct {
+	Window     time.Duration `json:"window"`
+	CellSize   float64       `json:"cell_size"`
+	EventTypes []string      `json:"event_types,omitempty"` // defaults to DefaultDemandEvents
+	Limit      int           `json:"limit,omitempty"`
+}
+
+// HeatmapCell is the number of demand events in one grid cell; Row and
+// Column index the cell by floor(coordinate / cell size)
+type HeatmapCell struct {
+	Row    int `json:"row" gorm:"column:cell_row"`
+	Column int `json:"column" gorm:"column:cell_column"`
+	Count  int `json:"count"`
+}
+
+// HeatmapPoint is a weighted grid cell, located at its center
+type HeatmapPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Count     int     `json:"count"`
+	Intensity float64 `json:"intensity"` // relative to the busiest cell, in (0, 1]
+}
+
+// Heatmap lists demand hotspots, busiest first
+type Heatmap struct {
+	StartTime time.Time      `json:"start_time"`
+	EndTime   time.Time      `json:"end_time"`
+	CellSize  float64        `json:"cell_size"`
+	Points    []HeatmapPoint `json:"points"`
+}
+
+type RetentionRequest struct {
+	Granularity string `json:"granularity"` // week, month
+	Periods     int    `json:"periods"`     // number of cohorts and follow-up periods
+}
+
+// CohortRetention is one row of the retention matrix: users who signed up in
+// the cohort period, and the share of them ordering in each following period
+type CohortRetention struct {
+	Cohort      string    `json:"cohort"`
+	Users       int       `json:"users"`
+	ActiveUsers []int     `json:"active_users"`
+	Retention   []float64 `json:"retention"` // percentages, index 0 is the signup period
+}
+
+type UserRetention struct {
+	Granularity string            `json:"granularity"`
+	Periods     int               `json:"periods"`
+	Cohorts     []CohortRetention `json:"cohorts"`
+}
+
+// CohortActivity counts users of a signup cohort active in a given period
+type CohortActivity struct {
+	Cohort time.Time `json:"cohort"`
+	Period time.Time `json:"period"`
+	Users  int       `json:"users"`
+}
+
 type TimeSeriesRequest struct {
 	StartDate time.Time `json:"start_date" binding:"required"`
 	EndDate   time.Time `json:"end_date" binding:"required"`
@@ -131,6 +449,14 @@ type TopPerformersRequest struct {
 	Type   string `json:"type"` // merchants, drivers
 }
 
+type CustomReportRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Type      ReportType `json:"type" binding:"required"` // platform, revenue
+	StartDate time.Time  `json:"start_date" binding:"required"`
+	EndDate   time.Time  `json:"end_date" binding:"required"`
+	CreatedBy string     `json:"-"`
+}
+
 // Repository interfaces (ports)
 type PlatformMetricsRepository interface {
 	Create(metrics *PlatformMetrics) error
@@ -154,6 +480,7 @@ type DriverMetricsRepository interface {
 	GetTopDrivers(period string, limit int) ([]DriverSummary, error)
 	GetDriverPerformance(driverID string) (*DriverMetrics, error)
 	AggregateDriverMetrics(startDate, endDate time.Time) (*DriverMetrics, error)
+	AggregateByDriver(startDate, endDate time.Time) ([]DriverMetrics, error)
 }
 
 type MerchantMetricsRepository interface {
@@ -162,12 +489,73 @@ type MerchantMetricsRepository interface {
 	GetTopMerchants(period string, limit int) ([]MerchantSummary, error)
 	GetMerchantPerformance(merchantID string) (*MerchantMetrics, error)
 	AggregateMerchantMetrics(startDate, endDate time.Time) (*MerchantMetrics, error)
+	AggregateByMerchant(startDate, endDate time.Time) ([]MerchantMetrics, error)
+}
+
+type EventRepository interface {
+	Create(event *AnalyticsEvent) error
+	// CountFunnel counts, for each stage, the users who fired its event type
+	// after reaching every stage before it, in order, within the range
+	CountFunnel(stages []string, startDate, endDate time.Time) ([]int, error)
+	// CountByPickupCell counts events with pickup coordinates per grid cell
+	CountByPickupCell(eventTypes []string, cellSize float64, startDate, endDate time.Time) ([]HeatmapCell, error)
+}
+
+type ReportRepository interface {
+	Create(report *AnalyticsReport) error
+	GetByID(id string) (*AnalyticsReport, error)
+	GetByCreator(createdBy string) ([]AnalyticsReport, error)
+	GetByStatus(status ReportStatus) ([]AnalyticsReport, error)
+	Update(report *AnalyticsReport) error
+	Delete(id string) error
+}
+
+// SourceDataRepository stores the order and user facts analytics keeps from
+// other services' events, and aggregates them for rollups and trends
+type SourceDataRepository interface {
+	// UpsertOrder saves the latest snapshot of an order; older snapshots
+	// arriving out of order are ignored
+	UpsertOrder(order *OrderFact) error
+	UpsertUser(user *UserFact) error
+	UpsertStoreRating(rating *StoreRatingFact) error
+	GetPlatformRollup(startDate, endDate time.Time) (*PlatformMetrics, error)
+	GetRevenueRollup(startDate, endDate time.Time) (*RevenueMetrics, error)
+	GetDriverRollups(startDate, endDate time.Time) ([]DriverMetrics, error)
+	GetMerchantRollups(startDate, endDate time.Time) ([]MerchantMetrics, error)
+	GetCohortSizes(granularity string, since time.Time) ([]CohortActivity, error)
+	GetCohortActivity(granularity string, since time.Time) ([]CohortActivity, error)
+	GetRevenueTrend(unit string, loc *time.Location, startDate, endDate time.Time) ([]TrendPoint, error)
+	GetOrderTrend(unit string, loc *time.Location, startDate, endDate time.Time) ([]TrendPoint, error)
+	GetOrdersByHour(loc *time.Location, startDate, endDate time.Time) ([]HourlyOrders, error)
+	// GetMerchantDailySales groups the merchant's delivered orders and their
+	// items by UTC completion day, oldest first
+	GetMerchantDailySales(merchantID string, startDate, endDate time.Time) ([]DailySales, error)
+}
+
+// RollupRepository saves a day of metrics in a single transaction
+type RollupRepository interface {
+	Save(rollup *DailyRollup) error
+}
+
+// Cache stores expensive aggregation results for a short TTL
+type Cache interface {
+	Get(key string, dest interface{}) bool
+	Set(key string, value interface{}) error
+	Clear() error
 }
 
 // Service interfaces (ports)
 type AnalyticsService interface {
+	// Event tracking
+	TrackEvent(req TrackEventRequest) error
+	GetFunnel(req FunnelRequest) (*Funnel, error)
+	GetDemandHeatmap(req HeatmapRequest) (*Heatmap, error)
+
 	// Platform analytics
+	GetAdminDashboard() (*AdminDashboard, error)
 	GetPlatformStats() (*PlatformStats, error)
+	GetRevenueOverview(req RevenueRequest) (*RevenueOverview, error)
+	GetRevenueTrends(req RevenueTrendsRequest) (*RevenueTrends, error)
 	GetRevenueAnalytics(startDate, endDate time.Time) ([]RevenueStat, error)
 	GetGrowthMetrics(period string) (*GrowthMetrics, error)
 	GetTimeSeriesData(req TimeSeriesRequest) (interface{}, error)
@@ -177,16 +565,42 @@ type AnalyticsService interface {
 	GetTopDrivers(req TopPerformersRequest) ([]DriverSummary, error)
 	GetMerchantAnalytics(merchantID string, startDate, endDate time.Time) (*MerchantMetrics, error)
 	GetDriverAnalytics(driverID string, startDate, endDate time.Time) (*DriverMetrics, error)
+	GetMerchantPerformance(merchantID string) (*MerchantPerformance, error)
+	GetMerchantSales(req MerchantAnalyticsRequest) (*MerchantSales, error)
+	GetDriverPerformanceAnalytics(driverID string) (*DriverPerformanceAnalytics, error)
+
+	// User analytics
+	GetUserRetention(req RetentionRequest) (*UserRetention, error)
 
 	// Order analytics
 	GetOrderStatistics(startDate, endDate time.Time) (map[string]int, error)
-	GetOrderTrends(startDate, endDate time.Time) ([]interface{}, error)
+	GetOrderTrends(req OrderTrendsRequest) (*OrderTrends, error)
+	GetPeakHours(req PeakHoursRequest) (*PeakHours, error)
 
 	// Real-time metrics
 	GetRealTimeStats() (map[string]interface{}, error)
+	GetRealTimeOrders() (*RealTimeOrders, error)
+	GetRealTimeDeliveries() (*RealTimeDeliveries, error)
+	GetRealTimeRevenue() (*RealTimeRevenue, error)
+	GetRealTimeSnapshot() (*RealTimeSnapshot, error)
+	SubscribeRealTime() (<-chan *RealTimeSnapshot, func())
+
+	// Reports
+	GenerateCustomReport(req CustomReportRequest) (*AnalyticsReport, error)
+	GetReports(adminID string) ([]AnalyticsReport, error)
+	GetReport(reportID, adminID string) (*AnalyticsReport, error)
+	DeleteReport(reportID, adminID string) error
+	RunReportWorker(pollInterval time.Duration)
+
+	// Source data from other services' events
+	RecordOrder(order *OrderFact) error
+	RecordUser(user *UserFact) error
+	RecordStoreRating(rating *StoreRatingFact) error
 
 	// Data aggregation
 	AggregateDaily() error
+	AggregateDate(date time.Time) error
+	RunNightlyRollup(at time.Duration)
 	RefreshMetrics() error
 }
 
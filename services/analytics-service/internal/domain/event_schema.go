@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Tracked event types
+const (
+	EventAppOpen     = "app_open"
+	EventSearch      = "search"
+	EventStoreView   = "store_view"
+	EventProductView = "product_view"
+	EventAddToCart   = "add_to_cart"
+	EventCheckout    = "checkout"
+	EventPaid        = "paid"
+)
+
+// Custom events are named CustomEventPrefix plus a snake_case name. They are
+// stored under EventCustom with the name in the PropertyCustomEvent property,
+// so they never mix with the tracked types above.
+const (
+	CustomEventPrefix   = "custom."
+	EventCustom         = "custom"
+	PropertyCustomEvent = "custom_event"
+)
+
+// EventSchemas lists the allowed event types with the entity ID properties
+// each one must carry
+var EventSchemas = map[string][]string{
+	EventAppOpen:     {},
+	EventSearch:      {},
+	EventStoreView:   {"store_id"},
+	EventProductView: {"store_id", "product_id"},
+	EventAddToCart:   {"store_id", "product_id"},
+	EventCheckout:    {"store_id"},
+	EventPaid:        {"order_id"},
+}
+
+// Tracked events may arrive late from offline clients, but not from the
+// future beyond clock skew
+const (
+	MaxEventAge        = 30 * 24 * time.Hour
+	MaxEventClockSkew  = 5 * time.Minute
+	MaxCustomEventName = 64
+)
+
+var (
+	// ErrInvalidEvent is returned when a tracked event is missing fields or has a bad timestamp
+	ErrInvalidEvent = errors.New("invalid analytics event")
+	// ErrUnknownEventType is returned when an event type is neither tracked nor custom
+	ErrUnknownEventType = errors.New("unknown event type")
+	// ErrInvalidFunnel is returned when funnel stages are empty or repeated
+	ErrInvalidFunnel = errors.New("invalid funnel")
+)
@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+// OrderFact is analytics' own copy of an order, kept current from the order
+// service's status events. Analytics has its own database, so rollups and
+// trends aggregate these rows rather than the order service's tables.
+type OrderFact struct {
+	ID            string          `json:"id" gorm:"primaryKey"` // order ID
+	CustomerID    string          `json:"customer_id" gorm:"index"`
+	MerchantID    string          `json:"merchant_id" gorm:"index"`
+	DriverID      string          `json:"driver_id,omitempty" gorm:"index"`
+	Status        string          `json:"status" gorm:"index"`
+	Items         []OrderItemFact `json:"items" gorm:"foreignKey:OrderID"`
+	TotalAmount   float64         `json:"total_amount"` // item subtotal, paid to the merchant
+	DeliveryFee   float64         `json:"delivery_fee"`
+	ServiceFee    float64         `json:"service_fee"` // the platform's commission
+	TaxAmount     float64         `json:"tax_amount"`
+	FinalAmount   float64         `json:"final_amount"`
+	EstimatedTime *int            `json:"estimated_time,omitempty"` // in minutes
+	PlacedAt      time.Time       `json:"placed_at" gorm:"index"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty" gorm:"index"`
+	CancelledAt   *time.Time      `json:"cancelled_at,omitempty"`
+	UpdatedAt     time.Time       `json:"updated_at"` // when the order last changed, not when the row was written
+}
+
+// OrderItemFact is one line of an OrderFact
+type OrderItemFact struct {
+	OrderID   string  `json:"order_id" gorm:"primaryKey"`
+	Line      int     `json:"line" gorm:"primaryKey"` // position in the order
+	ProductID string  `json:"product_id" gorm:"index"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	Quantity  int     `json:"quantity"`
+}
+
+// StoreRatingFact is a customer's rating of a store for one order, from the
+// catalog service's review events
+type StoreRatingFact struct {
+	OrderID    string    `json:"order_id" gorm:"primaryKey"` // each order is rated once
+	StoreID    string    `json:"store_id"`
+	MerchantID string    `json:"merchant_id" gorm:"index"`
+	Rating     int       `json:"rating"` // 1 to 5
+	RatedAt    time.Time `json:"rated_at" gorm:"index"`
+}
+
+// UserFact records a signup from the user service's events
+type UserFact struct {
+	ID           string    `json:"id" gorm:"primaryKey"` // user ID
+	Role         string    `json:"role" gorm:"index"`
+	RegisteredAt time.Time `json:"registered_at" gorm:"index"`
+}
+
+// DailyRollup is one day of metrics, saved atomically so a day is never
+// left half rolled up
+type DailyRollup struct {
+	Date      time.Time
+	Platform  *PlatformMetrics
+	Revenue   *RevenueMetrics
+	Drivers   []DriverMetrics
+	Merchants []MerchantMetrics
+}
@@ -7,9 +7,17 @@ import (
 
 	"glovo-backend/services/catalog-service/internal/adapters/db"
 	httpAdapter "glovo-backend/services/catalog-service/internal/adapters/http"
+	"glovo-backend/services/catalog-service/internal/adapters/storage"
+	"glovo-backend/services/catalog-service/internal/adapters/subscriber"
 	"glovo-backend/services/catalog-service/internal/app"
 	"glovo-backend/services/catalog-service/internal/domain"
+	"glovo-backend/shared/config"
 	"glovo-backend/shared/database"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/health"
+	"glovo-backend/shared/logging"
+	"glovo-backend/shared/metrics"
+	"glovo-backend/shared/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -37,12 +45,22 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
+// @securityDefinitions.apikey ServiceToken
+// @in header
+// @name X-Service-Token
+// @description Signed service token for internal service-to-service calls.
+
 func main() {
+	logging.Setup("catalog-service")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
 	}
 
+	// Fail fast on missing or malformed configuration
+	cfg := config.MustLoad("catalog-service", config.Postgres(), config.Auth(), config.EventBus(), config.HTTPPort("PORT", "8003"))
+
 	// Initialize database connection
 	postgresDB := database.ConnectPostgres()
 
@@ -52,38 +70,59 @@ func main() {
 		&domain.Product{},
 		&domain.ProductOption{},
 		&domain.ProductOptionChoice{},
+		&domain.ProductImage{},
 		&domain.Category{},
+		&domain.StoreReview{},
+		&domain.DeliveredOrder{},
+		&domain.StockReservation{},
 	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
+	if err := db.EnsureSearchIndexes(postgresDB); err != nil {
+		log.Fatal("Failed to create search indexes:", err)
+	}
 
 	// Initialize repositories
 	storeRepo := db.NewStoreRepository(postgresDB)
 	productRepo := db.NewProductRepository(postgresDB)
+	imageRepo := db.NewProductImageRepository(postgresDB)
 	categoryRepo := db.NewCategoryRepository(postgresDB)
+	reviewRepo := db.NewStoreReviewRepository(postgresDB)
+	deliveredOrderRepo := db.NewDeliveredOrderRepository(postgresDB)
+
+	// Initialize image storage
+	uploadDir := getEnv("UPLOAD_DIR", "./uploads")
+	fileStorage := storage.NewLocalStorage(uploadDir, getEnv("UPLOAD_BASE_URL", "/uploads"))
+
+	eventBus := events.FromEnv()
+	defer eventBus.Close()
+	middleware.AuditImpersonation(eventBus, "catalog-service")
 
 	// Initialize use case
-	catalogService := app.NewCatalogService(storeRepo, productRepo, categoryRepo)
+	catalogService := app.NewCatalogService(storeRepo, productRepo, imageRepo, categoryRepo, reviewRepo, deliveredOrderRepo, fileStorage, eventBus)
+
+	// Record delivered orders so customers can review the store
+	if err := subscriber.NewDeliverySubscriber(catalogService).Register(eventBus); err != nil {
+		log.Fatal("Failed to subscribe to delivery events:", err)
+	}
 
 	// Initialize HTTP handler
 	catalogHandler := httpAdapter.NewCatalogHandler(catalogService)
 
 	// Setup Gin router
-	router := gin.Default()
-
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type,Authorization")
+	router := gin.New()
+	// Client IPs come from X-Forwarded-For only behind TRUSTED_PROXIES
+	if err := router.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	router.Use(gin.Recovery(), middleware.RequestID(), middleware.RequestLogger())
+	router.Use(metrics.Middleware("catalog-service"))
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// CORS, restricted to the origins in CORS_ALLOWED_ORIGINS
+	router.Use(middleware.CORS(middleware.CORSConfigFromEnv()))
 
-		c.Next()
-	})
+	// Prometheus metrics
+	router.GET("/metrics", metrics.Handler())
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -93,14 +132,18 @@ func main() {
 		})
 	})
 
+	// Readiness check, verifies database connectivity
+	router.GET("/health/ready", health.Ready("catalog-service", map[string]health.Check{"postgres": health.Postgres(postgresDB)}))
+
 	// Setup routes
 	catalogHandler.SetupRoutes(router)
+	router.Static("/uploads", uploadDir)
 
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Start server
-	port := getEnv("PORT", "8003")
+	port := cfg.String("PORT")
 	log.Printf("Catalog Service starting on port %s", port)
 	log.Printf("Swagger documentation available at http://localhost:%s/swagger/index.html", port)
 
@@ -14,6 +14,14 @@ func NewCategoryRepository(db *gorm.DB) domain.CategoryRepository {
 	return &categoryRepository{db: db}
 }
 
+// categorySubtree selects the ID of a category and all of its descendants
+const categorySubtree = `WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = ? AND deleted_at IS NULL
+		UNION
+		SELECT categories.id FROM categories JOIN subtree ON categories.parent_id = subtree.id
+		WHERE categories.deleted_at IS NULL
+	) SELECT id FROM subtree`
+
 func (r *categoryRepository) Create(category *domain.Category) error {
 	return r.db.Create(category).Error
 }
@@ -54,3 +62,22 @@ func (r *categoryRepository) Update(category *domain.Category) error {
 func (r *categoryRepository) Delete(id string) error {
 	return r.db.Where("id = ?", id).Delete(&domain.Category{}).Error
 }
+
+// Restore undoes a soft delete
+func (r *categoryRepository) Restore(id string) error {
+	result := r.db.Unscoped().Model(&domain.Category{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotDeleted
+	}
+	return nil
+}
+
+// GetSubtreeIDs returns the category's ID followed by the IDs of all its descendants
+func (r *categoryRepository) GetSubtreeIDs(id string) ([]string, error) {
+	var ids []string
+	err := r.db.Raw(categorySubtree, id).Scan(&ids).Error
+	return ids, err
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func createTestCategory(t *testing.T, db *gorm.DB, name string, parentID *string) *domain.Category {
+	t.Helper()
+	category := &domain.Category{
+		ID:        uuid.New().String(),
+		Name:      name,
+		ParentID:  parentID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := NewCategoryRepository(db).Create(category); err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	return category
+}
+
+func TestCategorySubtreeSearch(t *testing.T) {
+	db := testDB(t)
+
+	food := createTestCategory(t, db, "Food", nil)
+	pizza := createTestCategory(t, db, "Pizza", &food.ID)
+	drinks := createTestCategory(t, db, "Drinks", nil)
+
+	pizzeria := createTestStore(t, db, "Pizzeria", domain.StatusOpen)
+	bar := createTestStore(t, db, "Bar", domain.StatusOpen)
+	if err := db.Model(pizzeria).Association("Categories").Append(pizza); err != nil {
+		t.Fatalf("assign store category: %v", err)
+	}
+	if err := db.Model(bar).Association("Categories").Append(drinks); err != nil {
+		t.Fatalf("assign store category: %v", err)
+	}
+
+	margherita := createTestProduct(t, db, domain.Product{StoreID: pizzeria.ID, CategoryID: pizza.ID, Name: "Margherita"})
+	createTestProduct(t, db, domain.Product{StoreID: bar.ID, CategoryID: drinks.ID, Name: "Lemonade"})
+
+	subtree, err := NewCategoryRepository(db).GetSubtreeIDs(food.ID)
+	if err != nil {
+		t.Fatalf("GetSubtreeIDs: %v", err)
+	}
+	sort.Strings(subtree)
+	want := []string{food.ID, pizza.ID}
+	sort.Strings(want)
+	if len(subtree) != 2 || subtree[0] != want[0] || subtree[1] != want[1] {
+		t.Fatalf("subtree = %v, want %v", subtree, want)
+	}
+
+	products, err := NewProductRepository(db).RankedSearch(domain.ProductSearchRequest{CategoryID: food.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("RankedSearch: %v", err)
+	}
+	if len(products) != 1 || products[0].ID != margherita.ID {
+		t.Fatalf("products under food = %+v, want only the pizza", products)
+	}
+
+	stores, err := NewStoreRepository(db).Search(domain.StoreSearchRequest{CategoryID: food.ID})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(stores) != 1 || stores[0].ID != pizzeria.ID {
+		t.Fatalf("stores under food = %+v, want only the pizzeria", stores)
+	}
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func TestDietarySearchFilters(t *testing.T) {
+	db := testDB(t)
+	products := NewProductRepository(db)
+	stores := NewStoreRepository(db)
+
+	store := createTestStore(t, db, "Green Kitchen", domain.StatusOpen)
+	nutty := createTestStore(t, db, "Nut House", domain.StatusOpen)
+	salad := createTestProduct(t, db, domain.Product{StoreID: store.ID, Name: "Zucchini salad", DietaryTags: []domain.DietaryTag{domain.DietaryVegan, domain.DietaryGlutenFree}})
+	pesto := createTestProduct(t, db, domain.Product{StoreID: store.ID, Name: "Zucchini pesto", Allergens: []domain.Allergen{domain.AllergenNuts}, DietaryTags: []domain.DietaryTag{domain.DietaryVegan}})
+	gratin := createTestProduct(t, db, domain.Product{StoreID: store.ID, Name: "Zucchini gratin", Allergens: []domain.Allergen{domain.AllergenDairy}, DietaryTags: []domain.DietaryTag{domain.DietaryVegetarian}})
+	createTestProduct(t, db, domain.Product{StoreID: nutty.ID, Name: "Praline", Allergens: []domain.Allergen{domain.AllergenNuts}})
+
+	tests := []struct {
+		name string
+		req  domain.ProductSearchRequest
+		want []string
+	}{
+		{name: "excluding nuts", req: domain.ProductSearchRequest{Query: "zucchini", ExcludeAllergens: []domain.Allergen{domain.AllergenNuts}}, want: []string{salad.ID, gratin.ID}},
+		{name: "vegan only", req: domain.ProductSearchRequest{Query: "zucchini", Dietary: []domain.DietaryTag{domain.DietaryVegan}}, want: []string{salad.ID, pesto.ID}},
+		{
+			name: "vegan without nuts",
+			req:  domain.ProductSearchRequest{Query: "zucchini", Dietary: []domain.DietaryTag{domain.DietaryVegan}, ExcludeAllergens: []domain.Allergen{domain.AllergenNuts}},
+			want: []string{salad.ID},
+		},
+		{name: "every tag is required", req: domain.ProductSearchRequest{Query: "zucchini", Dietary: []domain.DietaryTag{domain.DietaryVegan, domain.DietaryGlutenFree}}, want: []string{salad.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Limit = 10
+			results, err := products.RankedSearch(tt.req)
+			if err != nil {
+				t.Fatalf("RankedSearch: %v", err)
+			}
+			got := make(map[string]bool)
+			for _, result := range results {
+				got[result.ID] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d products, want %d", len(got), len(tt.want))
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Fatalf("product %s is missing", id)
+				}
+			}
+		})
+	}
+
+	found, err := stores.Search(domain.StoreSearchRequest{Query: "Nut House", ExcludeAllergens: []domain.Allergen{domain.AllergenNuts}})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(found) != 0 {
+		t.Fatalf("store with only nut products matched a nut-free search")
+	}
+	found, err = stores.Search(domain.StoreSearchRequest{Query: "Green Kitchen", Dietary: []domain.DietaryTag{domain.DietaryVegan}})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != store.ID {
+		t.Fatalf("stores = %+v, want the store with vegan products", found)
+	}
+}
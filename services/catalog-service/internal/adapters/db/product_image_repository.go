@@ -0,0 +1,58 @@
+package db
+
+import (
+	"glovo-backend/services/catalog-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type productImageRepository struct {
+	db *gorm.DB
+}
+
+func NewProductImageRepository(db *gorm.DB) domain.ProductImageRepository {
+	return &productImageRepository{db: db}
+}
+
+func (r *productImageRepository) Create(image *domain.ProductImage) error {
+	return r.db.Create(image).Error
+}
+
+func (r *productImageRepository) GetByID(id string) (*domain.ProductImage, error) {
+	var image domain.ProductImage
+	err := r.db.Where("id = ?", id).First(&image).Error
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *productImageRepository) GetByProductID(productID string) ([]domain.ProductImage, error) {
+	var images []domain.ProductImage
+	err := r.db.Where("product_id = ?", productID).
+		Order("display_order ASC").
+		Find(&images).Error
+	return images, err
+}
+
+// UpdateAll saves the order and primary flag of several images in one transaction
+func (r *productImageRepository) UpdateAll(images []domain.ProductImage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, image := range images {
+			err := tx.Model(&domain.ProductImage{}).
+				Where("id = ?", image.ID).
+				Updates(map[string]interface{}{
+					"display_order": image.DisplayOrder,
+					"is_primary":    image.IsPrimary,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *productImageRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&domain.ProductImage{}).Error
+}
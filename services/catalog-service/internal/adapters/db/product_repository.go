@@ -1,17 +1,23 @@
 package db
 
 import (
+	"fmt"
 	"strings"
 
 	"glovo-backend/services/catalog-service/internal/domain"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type productRepository struct {
 	db *gorm.DB
 }
 
+func orderImages(db *gorm.DB) *gorm.DB {
+	return db.Order("display_order ASC")
+}
+
 func NewProductRepository(db *gorm.DB) domain.ProductRepository {
 	return &productRepository{db: db}
 }
@@ -20,9 +26,19 @@ func (r *productRepository) Create(product *domain.Product) error {
 	return r.db.Create(product).Error
 }
 
+// CreateBatch inserts all products in one transaction
+func (r *productRepository) CreateBatch(products []domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(products, 100).Error
+	})
+}
+
 func (r *productRepository) GetByID(id string) (*domain.Product, error) {
 	var product domain.Product
-	err := r.db.Preload("Options.Options").Where("id = ?", id).First(&product).Error
+	err := r.db.Preload("Options.Options").Preload("Images", orderImages).Where("id = ?", id).First(&product).Error
 	if err != nil {
 		return nil, err
 	}
@@ -31,7 +47,7 @@ func (r *productRepository) GetByID(id string) (*domain.Product, error) {
 
 func (r *productRepository) GetByStoreID(storeID string, limit, offset int) ([]domain.Product, error) {
 	var products []domain.Product
-	err := r.db.Preload("Options.Options").
+	err := r.db.Preload("Options.Options").Preload("Images", orderImages).
 		Where("store_id = ?", storeID).
 		Order("created_at DESC").
 		Limit(limit).
@@ -42,7 +58,7 @@ func (r *productRepository) GetByStoreID(storeID string, limit, offset int) ([]d
 
 func (r *productRepository) GetByCategoryID(categoryID string, limit, offset int) ([]domain.Product, error) {
 	var products []domain.Product
-	err := r.db.Preload("Options.Options").
+	err := r.db.Preload("Options.Options").Preload("Images", orderImages).
 		Where("category_id = ?", categoryID).
 		Order("created_at DESC").
 		Limit(limit).
@@ -55,18 +71,25 @@ func (r *productRepository) Update(product *domain.Product) error {
 	return r.db.Save(product).Error
 }
 
+// Delete soft-deletes the product; its options and images are kept so it can be restored
 func (r *productRepository) Delete(id string) error {
-	// Delete associated options and choices first
-	if err := r.db.Where("product_id = ?", id).Delete(&domain.ProductOption{}).Error; err != nil {
-		return err
-	}
-
-	// Delete the product
 	return r.db.Where("id = ?", id).Delete(&domain.Product{}).Error
 }
 
+// Restore undoes a soft delete
+func (r *productRepository) Restore(id string) error {
+	result := r.db.Unscoped().Model(&domain.Product{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotDeleted
+	}
+	return nil
+}
+
 func (r *productRepository) Search(query string, storeID string, limit, offset int) ([]domain.Product, error) {
-	dbQuery := r.db.Model(&domain.Product{}).Preload("Options.Options")
+	dbQuery := r.db.Model(&domain.Product{}).Preload("Options.Options").Preload("Images", orderImages)
 
 	// Apply search filters
 	if query != "" {
@@ -81,8 +104,9 @@ func (r *productRepository) Search(query string, storeID string, limit, offset i
 		dbQuery = dbQuery.Where("store_id = ?", storeID)
 	}
 
-	// Only show available products in search results
-	dbQuery = dbQuery.Where("status = ?", domain.ProductStatusAvailable)
+	// Only show available products of approved stores in search results
+	dbQuery = dbQuery.Where("status = ?", domain.ProductStatusAvailable).
+		Where("store_id NOT IN (?)", r.db.Model(&domain.Store{}).Select("id").Where("status IN ?", domain.UnapprovedStoreStatuses))
 
 	var products []domain.Product
 	err := dbQuery.Order("created_at DESC").
@@ -92,3 +116,86 @@ func (r *productRepository) Search(query string, storeID string, limit, offset i
 
 	return products, err
 }
+
+// DecrementStock subtracts the ordered quantities in one transaction, failing
+// with domain.ErrInsufficientStock if any tracked product would go below zero.
+// Products reaching zero are marked sold out. The order's reservation row is
+// written in the same transaction, so a repeated or concurrent call for the
+// order changes nothing.
+func (r *productRepository) DecrementStock(storeID, orderID string, items []domain.OrderItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		reservation := domain.StockReservation{OrderID: orderID, StoreID: storeID}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reservation)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		for _, item := range items {
+			result := tx.Model(&domain.Product{}).
+				Where("id = ? AND store_id = ? AND track_stock = ? AND stock_quantity >= ?", item.ProductID, storeID, true, item.Quantity).
+				Updates(map[string]interface{}{
+					"stock_quantity": gorm.Expr("stock_quantity - ?", item.Quantity),
+					"status":         gorm.Expr("CASE WHEN stock_quantity - ? = 0 THEN ? ELSE status END", item.Quantity, domain.ProductStatusSoldOut),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				continue
+			}
+
+			// No row updated: either the product doesn't track stock or there isn't enough
+			var product domain.Product
+			if err := tx.Select("track_stock").Where("id = ? AND store_id = ?", item.ProductID, storeID).First(&product).Error; err != nil {
+				return err
+			}
+			if product.TrackStock {
+				return fmt.Errorf("%w for product %s", domain.ErrInsufficientStock, item.ProductID)
+			}
+		}
+		return nil
+	})
+}
+
+// RestoreStock adds the quantities back, e.g. when an order is cancelled, and
+// makes sold-out products available again. Only a reservation that hasn't
+// been released gives stock back; with no reservation yet, a released one is
+// recorded so the order can't reserve stock afterwards.
+func (r *productRepository) RestoreStock(storeID, orderID string, items []domain.OrderItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		reservation := domain.StockReservation{OrderID: orderID, StoreID: storeID, Released: true}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reservation)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+
+		result = tx.Model(&domain.StockReservation{}).
+			Where("order_id = ? AND released = ?", orderID, false).
+			Update("released", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		for _, item := range items {
+			err := tx.Model(&domain.Product{}).
+				Where("id = ? AND store_id = ? AND track_stock = ?", item.ProductID, storeID, true).
+				Updates(map[string]interface{}{
+					"stock_quantity": gorm.Expr("stock_quantity + ?", item.Quantity),
+					"status":         gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END", domain.ProductStatusSoldOut, domain.ProductStatusAvailable),
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
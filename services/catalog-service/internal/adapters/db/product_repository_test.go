@@ -0,0 +1,52 @@
+package db
+
+import (
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func TestStockReservedOncePerOrder(t *testing.T) {
+	db := testDB(t)
+	products := NewProductRepository(db)
+
+	category := createTestCategory(t, db, "Bakery", nil)
+	store := createTestStore(t, db, "Corner Bakery", domain.StatusOpen)
+	product := createTestProduct(t, db, domain.Product{StoreID: store.ID, CategoryID: category.ID, Name: "Croissant", TrackStock: true, StockQuantity: 5})
+	items := []domain.OrderItem{{ProductID: product.ID, Quantity: 2}}
+
+	stock := func() int {
+		t.Helper()
+		got, err := products.GetByID(product.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		return got.StockQuantity
+	}
+
+	steps := []struct {
+		name      string
+		apply     func() error
+		wantStock int
+	}{
+		{name: "reserve", apply: func() error { return products.DecrementStock(store.ID, "order-1", items) }, wantStock: 3},
+		{name: "reserve again", apply: func() error { return products.DecrementStock(store.ID, "order-1", items) }, wantStock: 3},
+		{name: "another order", apply: func() error { return products.DecrementStock(store.ID, "order-2", items) }, wantStock: 1},
+		{name: "release", apply: func() error { return products.RestoreStock(store.ID, "order-1", items) }, wantStock: 3},
+		{name: "release again", apply: func() error { return products.RestoreStock(store.ID, "order-1", items) }, wantStock: 3},
+		{name: "reserve after release", apply: func() error { return products.DecrementStock(store.ID, "order-1", items) }, wantStock: 3},
+		// Released before it was reserved: nothing to give back, and no
+		// reservation afterwards
+		{name: "release unreserved", apply: func() error { return products.RestoreStock(store.ID, "order-3", items) }, wantStock: 3},
+		{name: "reserve released", apply: func() error { return products.DecrementStock(store.ID, "order-3", items) }, wantStock: 3},
+	}
+
+	for _, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := stock(); got != step.wantStock {
+			t.Fatalf("%s: stock = %d, want %d", step.name, got, step.wantStock)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+	"glovo-backend/shared/database/dbtest"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// testDB returns a dbtest transaction with the catalog's tables and the
+// pg_trgm search indexes its queries rely on
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return dbtest.Open(t, func(db *gorm.DB) error {
+		err := db.AutoMigrate(
+			&domain.Store{},
+			&domain.Product{},
+			&domain.ProductOption{},
+			&domain.ProductOptionChoice{},
+			&domain.ProductImage{},
+			&domain.Category{},
+			&domain.StoreReview{},
+			&domain.DeliveredOrder{},
+			&domain.StockReservation{},
+		)
+		if err != nil {
+			return err
+		}
+		return EnsureSearchIndexes(db)
+	})
+}
+
+func createTestStore(t *testing.T, db *gorm.DB, name string, status domain.StoreStatus) *domain.Store {
+	t.Helper()
+	store := &domain.Store{
+		ID:         uuid.New().String(),
+		MerchantID: uuid.New().String(),
+		Name:       name,
+		Status:     status,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := NewStoreRepository(db).Create(store); err != nil {
+		t.Fatalf("create store: %v", err)
+	}
+	return store
+}
+
+func createTestProduct(t *testing.T, db *gorm.DB, product domain.Product) *domain.Product {
+	t.Helper()
+	product.ID = uuid.New().String()
+	if product.Status == "" {
+		product.Status = domain.ProductStatusAvailable
+	}
+	product.CreatedAt = time.Now()
+	product.UpdatedAt = time.Now()
+	if err := NewProductRepository(db).Create(&product); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	return &product
+}
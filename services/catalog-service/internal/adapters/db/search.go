@@ -0,0 +1,105 @@
+package db
+
+import (
+	"encoding/json"
+	"strings"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// productDocument weights product names above descriptions for ts_rank
+const productDocument = `setweight(to_tsvector('simple', coalesce(products.name, '')), 'A') ||
+	setweight(to_tsvector('simple', coalesce(products.description, '')), 'B')`
+
+// trigramThreshold is the minimum pg_trgm word similarity counted as a typo-tolerant match
+const trigramThreshold = 0.3
+
+// EnsureSearchIndexes installs pg_trgm and the indexes backing ranked search.
+// AutoMigrate can't express expression or trigram indexes.
+func EnsureSearchIndexes(db *gorm.DB) error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE INDEX IF NOT EXISTS idx_products_search ON products USING gin ((` + productDocument + `))`,
+		`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING gin (lower(name) gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_stores_name_trgm ON stores USING gin (lower(name) gin_trgm_ops)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RankedSearch matches the query against product names and descriptions by
+// full-text search, with trigram similarity on the name to tolerate typos.
+// An exact name match always ranks first. Without a query, products are only
+// filtered and come back newest first.
+func (r *productRepository) RankedSearch(req domain.ProductSearchRequest) ([]domain.ProductSearchResult, error) {
+	query := r.db.Table("products").
+		Joins("JOIN stores ON stores.id = products.store_id").
+		Where("products.status = ?", domain.ProductStatusAvailable).
+		Where("stores.status NOT IN ?", domain.UnapprovedStoreStatuses).
+		// Table queries skip GORM's soft-delete scope
+		Where("products.deleted_at IS NULL AND stores.deleted_at IS NULL")
+
+	if req.Query != "" {
+		query = query.Select(`products.*, stores.name AS store_name,
+			(CASE WHEN lower(products.name) = lower(?) THEN 2 ELSE 0 END)
+			+ ts_rank(`+productDocument+`, plainto_tsquery('simple', ?))
+			+ word_similarity(lower(?), lower(products.name)) AS score`, req.Query, req.Query, req.Query).
+			Where(`(`+productDocument+`) @@ plainto_tsquery('simple', ?)
+			OR word_similarity(lower(?), lower(products.name)) >= ?
+			OR lower(products.description) LIKE ?`,
+				req.Query, req.Query, trigramThreshold, "%"+strings.ToLower(req.Query)+"%").
+			Order("score DESC")
+	} else {
+		query = query.Select("products.*, stores.name AS store_name, 0 AS score")
+	}
+
+	// A parent category also matches products in any of its subcategories
+	if req.CategoryID != "" {
+		query = query.Where("products.category_id IN ("+categorySubtree+")", req.CategoryID)
+	}
+
+	if req.Latitude != 0 && req.Longitude != 0 && req.Radius > 0 {
+		query = query.Where(
+			`(6371 * acos(cos(radians(?)) * cos(radians(stores.latitude)) * cos(radians(stores.longitude) - radians(?)) + sin(radians(?)) * sin(radians(stores.latitude)))) <= ?`,
+			req.Latitude, req.Longitude, req.Latitude, req.Radius,
+		)
+	}
+
+	query, err := whereDietary(query, req.Dietary, req.ExcludeAllergens)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.ProductSearchResult
+	err = query.Order("products.created_at DESC").
+		Limit(req.Limit).
+		Offset(req.Offset).
+		Scan(&results).Error
+	return results, err
+}
+
+// whereDietary keeps products carrying every dietary tag and declaring none of
+// the excluded allergens. Products that declare no allergens are not excluded.
+func whereDietary(query *gorm.DB, dietary []domain.DietaryTag, excluded []domain.Allergen) (*gorm.DB, error) {
+	if len(dietary) > 0 {
+		tags, err := json.Marshal(dietary)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("products.dietary_tags @> ?::jsonb", string(tags))
+	}
+	if len(excluded) > 0 {
+		allergens := make([]string, len(excluded))
+		for i, allergen := range excluded {
+			allergens[i] = string(allergen)
+		}
+		query = query.Where("NOT jsonb_exists_any(coalesce(products.allergens, '[]'::jsonb), ARRAY[?]::text[])", allergens)
+	}
+	return query, nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func TestRankedSearch(t *testing.T) {
+	db := testDB(t)
+	repo := NewProductRepository(db)
+
+	store := createTestStore(t, db, "Quokka Diner", domain.StatusOpen)
+	described := createTestProduct(t, db, domain.Product{StoreID: store.ID, Name: "Classic combo", Description: "Fries and a quokkaburger"})
+	named := createTestProduct(t, db, domain.Product{StoreID: store.ID, Name: "Quokkaburger"})
+
+	tests := []struct {
+		name string
+		req  domain.ProductSearchRequest
+		want []string
+	}{
+		{
+			name: "exact name ranks above a description match",
+			req:  domain.ProductSearchRequest{Query: "QUOKKABURGER", Limit: 10},
+			want: []string{named.ID, described.ID},
+		},
+		{
+			name: "typo still matches the name",
+			req:  domain.ProductSearchRequest{Query: "quokaburger", Limit: 10},
+			want: []string{named.ID},
+		},
+		{
+			name: "second page",
+			req:  domain.ProductSearchRequest{Query: "quokkaburger", Limit: 1, Offset: 1},
+			want: []string{described.ID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := repo.RankedSearch(tt.req)
+			if err != nil {
+				t.Fatalf("RankedSearch: %v", err)
+			}
+			if len(results) < len(tt.want) {
+				t.Fatalf("got %d results, want at least %d", len(results), len(tt.want))
+			}
+			for i, id := range tt.want {
+				if results[i].ID != id {
+					t.Fatalf("result %d = %s (%s), want %s", i, results[i].Name, results[i].ID, id)
+				}
+				if results[i].StoreName != store.Name {
+					t.Fatalf("store name = %q, want %q", results[i].StoreName, store.Name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	db := testDB(t)
+	stores := NewStoreRepository(db)
+	products := NewProductRepository(db)
+	categories := NewCategoryRepository(db)
+
+	category := createTestCategory(t, db, "Soups", nil)
+	store := createTestStore(t, db, "Soup Kitchen", domain.StatusOpen)
+	product := createTestProduct(t, db, domain.Product{StoreID: store.ID, CategoryID: category.ID, Name: "Gazpacho"})
+
+	if err := products.Delete(product.ID); err != nil {
+		t.Fatalf("delete product: %v", err)
+	}
+	if _, err := products.GetByID(product.ID); err == nil {
+		t.Fatalf("deleted product is still returned")
+	}
+	if listed, _ := products.GetByStoreID(store.ID, 10, 0); len(listed) != 0 {
+		t.Fatalf("deleted product is still listed")
+	}
+	if listed, _ := products.GetByCategoryID(category.ID, 10, 0); len(listed) != 0 {
+		t.Fatalf("deleted product still counts towards its category")
+	}
+	var rows int64
+	db.Unscoped().Model(&domain.Product{}).Where("id = ?", product.ID).Count(&rows)
+	if rows != 1 {
+		t.Fatalf("product row was removed")
+	}
+
+	if err := products.Restore(product.ID); err != nil {
+		t.Fatalf("restore product: %v", err)
+	}
+	if _, err := products.GetByID(product.ID); err != nil {
+		t.Fatalf("restored product: %v", err)
+	}
+	if err := products.Restore(product.ID); !errors.Is(err, domain.ErrNotDeleted) {
+		t.Fatalf("restoring a live product: expected %v, got %v", domain.ErrNotDeleted, err)
+	}
+
+	if err := stores.Delete(store.ID); err != nil {
+		t.Fatalf("delete store: %v", err)
+	}
+	if found, _ := stores.Search(domain.StoreSearchRequest{Query: "Soup Kitchen"}); len(found) != 0 {
+		t.Fatalf("deleted store is still searchable")
+	}
+	if err := stores.Restore(store.ID); err != nil {
+		t.Fatalf("restore store: %v", err)
+	}
+	if _, err := stores.GetByID(store.ID); err != nil {
+		t.Fatalf("restored store: %v", err)
+	}
+
+	if err := categories.Delete(category.ID); err != nil {
+		t.Fatalf("delete category: %v", err)
+	}
+	if _, err := categories.GetByID(category.ID); err == nil {
+		t.Fatalf("deleted category is still returned")
+	}
+	if err := categories.Restore(category.ID); err != nil {
+		t.Fatalf("restore category: %v", err)
+	}
+	if err := categories.Restore("missing"); !errors.Is(err, domain.ErrNotDeleted) {
+		t.Fatalf("restoring an unknown category: expected %v, got %v", domain.ErrNotDeleted, err)
+	}
+}
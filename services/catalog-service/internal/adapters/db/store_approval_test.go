@@ -0,0 +1,58 @@
+package db
+
+import (
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func TestPendingStoreHiddenUntilApproved(t *testing.T) {
+	db := testDB(t)
+	stores := NewStoreRepository(db)
+	products := NewProductRepository(db)
+
+	store := createTestStore(t, db, "Fresh Bakery", domain.StatusPendingApproval)
+	createTestProduct(t, db, domain.Product{StoreID: store.ID, Name: "Sourdough loaf"})
+
+	visible := func() (storeFound, productFound, rankedFound bool) {
+		t.Helper()
+		found, err := stores.Search(domain.StoreSearchRequest{Query: "Fresh Bakery"})
+		if err != nil {
+			t.Fatalf("search stores: %v", err)
+		}
+		matches, err := products.Search("Sourdough", "", 10, 0)
+		if err != nil {
+			t.Fatalf("search products: %v", err)
+		}
+		ranked, err := products.RankedSearch(domain.ProductSearchRequest{Query: "sourdough", Limit: 10})
+		if err != nil {
+			t.Fatalf("ranked search: %v", err)
+		}
+		return len(found) > 0, len(matches) > 0, len(ranked) > 0
+	}
+
+	if storeFound, productFound, rankedFound := visible(); storeFound || productFound || rankedFound {
+		t.Fatalf("pending store is searchable: store %v, products %v, ranked %v", storeFound, productFound, rankedFound)
+	}
+
+	// Admins still see it in the approval queue
+	queue, err := stores.Search(domain.StoreSearchRequest{Status: domain.StatusPendingApproval, Limit: 100})
+	if err != nil {
+		t.Fatalf("search pending stores: %v", err)
+	}
+	inQueue := false
+	for _, pending := range queue {
+		inQueue = inQueue || pending.ID == store.ID
+	}
+	if !inQueue {
+		t.Fatalf("pending store is missing from the approval queue")
+	}
+
+	store.Status = domain.StatusOpen
+	if err := stores.Update(store); err != nil {
+		t.Fatalf("approve store: %v", err)
+	}
+	if storeFound, productFound, rankedFound := visible(); !storeFound || !productFound || !rankedFound {
+		t.Fatalf("approved store isn't searchable: store %v, products %v, ranked %v", storeFound, productFound, rankedFound)
+	}
+}
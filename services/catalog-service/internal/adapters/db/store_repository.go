@@ -7,6 +7,7 @@ import (
 	"glovo-backend/services/catalog-service/internal/domain"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type storeRepository struct {
@@ -47,24 +48,54 @@ func (r *storeRepository) Delete(id string) error {
 	return r.db.Where("id = ?", id).Delete(&domain.Store{}).Error
 }
 
+// Restore undoes a soft delete
+func (r *storeRepository) Restore(id string) error {
+	result := r.db.Unscoped().Model(&domain.Store{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotDeleted
+	}
+	return nil
+}
+
 func (r *storeRepository) Search(req domain.StoreSearchRequest) ([]domain.Store, error) {
 	query := r.db.Model(&domain.Store{}).Preload("Categories")
 
 	// Apply filters
+	if req.Status != "" {
+		query = query.Where("stores.status = ?", req.Status)
+	} else if !req.IncludeUnapproved {
+		query = query.Where("stores.status NOT IN ?", domain.UnapprovedStoreStatuses)
+	}
+
 	if req.Query != "" {
 		searchTerm := "%" + strings.ToLower(req.Query) + "%"
-		query = query.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ?", searchTerm, searchTerm)
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ? OR word_similarity(LOWER(?), LOWER(name)) >= ?",
+			searchTerm, searchTerm, req.Query, trigramThreshold)
 	}
 
+	// A parent category also matches stores in any of its subcategories
 	if req.CategoryID != "" {
-		query = query.Joins("JOIN store_categories ON stores.id = store_categories.store_id").
-			Where("store_categories.category_id = ?", req.CategoryID)
+		query = query.Where("stores.id IN (SELECT store_id FROM store_categories WHERE category_id IN ("+categorySubtree+"))", req.CategoryID)
 	}
 
 	if req.MinRating > 0 {
 		query = query.Where("rating >= ?", req.MinRating)
 	}
 
+	// A store matches the dietary filters when any available product does
+	if len(req.Dietary) > 0 || len(req.ExcludeAllergens) > 0 {
+		products := r.db.Table("products").Select("products.store_id").
+			Where("products.status = ? AND products.deleted_at IS NULL", domain.ProductStatusAvailable)
+		products, err := whereDietary(products, req.Dietary, req.ExcludeAllergens)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("stores.id IN (?)", products)
+	}
+
 	// Location-based filtering
 	if req.Latitude != 0 && req.Longitude != 0 && req.Radius > 0 {
 		// Using Haversine formula for distance calculation
@@ -89,6 +120,13 @@ func (r *storeRepository) Search(req domain.StoreSearchRequest) ([]domain.Store,
 	case "delivery_time":
 		query = query.Order("delivery_info_estimated_time ASC")
 	default:
+		if req.Query != "" {
+			// Rank by name similarity so typo'd queries still surface the intended store
+			query = query.Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:  "word_similarity(LOWER(?), LOWER(name)) DESC",
+				Vars: []interface{}{req.Query},
+			}})
+		}
 		query = query.Order("created_at DESC")
 	}
 
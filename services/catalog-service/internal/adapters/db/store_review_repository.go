@@ -0,0 +1,87 @@
+package db
+
+import (
+	"math"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type storeReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewStoreReviewRepository(db *gorm.DB) domain.StoreReviewRepository {
+	return &storeReviewRepository{db: db}
+}
+
+// Create saves the review and refreshes the store's rating and review count
+// from all of its reviews in one transaction
+func (r *storeReviewRepository) Create(review *domain.StoreReview) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+
+		var aggregate struct {
+			Rating float64
+			Count  int
+		}
+		err := tx.Model(&domain.StoreReview{}).
+			Select("COALESCE(AVG(rating), 0) AS rating, COUNT(*) AS count").
+			Where("store_id = ?", review.StoreID).
+			Scan(&aggregate).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.Store{}).
+			Where("id = ?", review.StoreID).
+			Updates(map[string]interface{}{
+				"rating":       math.Round(aggregate.Rating*100) / 100,
+				"review_count": aggregate.Count,
+			}).Error
+	})
+}
+
+func (r *storeReviewRepository) GetByOrderID(orderID string) (*domain.StoreReview, error) {
+	var review domain.StoreReview
+	err := r.db.Where("order_id = ?", orderID).First(&review).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *storeReviewRepository) GetByStoreID(storeID string, limit, offset int) ([]domain.StoreReview, error) {
+	var reviews []domain.StoreReview
+	err := r.db.Where("store_id = ?", storeID).
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&reviews).Error
+	return reviews, err
+}
+
+type deliveredOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewDeliveredOrderRepository(db *gorm.DB) domain.DeliveredOrderRepository {
+	return &deliveredOrderRepository{db: db}
+}
+
+// Save inserts the order, ignoring redelivered completion events
+func (r *deliveredOrderRepository) Save(order *domain.DeliveredOrder) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(order).Error
+}
+
+func (r *deliveredOrderRepository) GetByOrderID(orderID string) (*domain.DeliveredOrder, error) {
+	var order domain.DeliveredOrder
+	err := r.db.Where("order_id = ?", orderID).First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
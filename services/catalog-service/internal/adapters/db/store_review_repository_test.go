@@ -0,0 +1,62 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestStoreReviewAggregation(t *testing.T) {
+	db := testDB(t)
+	reviews := NewStoreReviewRepository(db)
+	stores := NewStoreRepository(db)
+	store := createTestStore(t, db, "Rated Diner", domain.StatusOpen)
+
+	for _, rating := range []int{5, 4, 4} {
+		review := &domain.StoreReview{ID: uuid.New().String(), StoreID: store.ID, OrderID: uuid.New().String(), CustomerID: "customer-1", Rating: rating, CreatedAt: time.Now()}
+		if err := reviews.Create(review); err != nil {
+			t.Fatalf("create review: %v", err)
+		}
+	}
+
+	got, err := stores.GetByID(store.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Rating != 4.33 || got.ReviewCount != 3 {
+		t.Fatalf("rating = %v from %d reviews, want 4.33 from 3", got.Rating, got.ReviewCount)
+	}
+
+	// The unique order index backs the one-review-per-order rule
+	first, _ := reviews.GetByStoreID(store.ID, 1, 0)
+	duplicate := &domain.StoreReview{ID: uuid.New().String(), StoreID: store.ID, OrderID: first[0].OrderID, Rating: 1, CreatedAt: time.Now()}
+	if err := reviews.Create(duplicate); err == nil {
+		t.Fatalf("second review of the same order was saved")
+	}
+}
+
+func TestDeliveredOrderSaveIsIdempotent(t *testing.T) {
+	db := testDB(t)
+	orders := NewDeliveredOrderRepository(db)
+	order := &domain.DeliveredOrder{OrderID: uuid.New().String(), CustomerID: "customer-1", MerchantID: "merchant-1", DeliveredAt: time.Now()}
+
+	if err := orders.Save(order); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	redelivered := *order
+	redelivered.CustomerID = "customer-2"
+	if err := orders.Save(&redelivered); err != nil {
+		t.Fatalf("Save redelivered event: %v", err)
+	}
+
+	got, err := orders.GetByOrderID(order.OrderID)
+	if err != nil {
+		t.Fatalf("GetByOrderID: %v", err)
+	}
+	if got.CustomerID != "customer-1" {
+		t.Fatalf("customer = %q, want the first event's customer", got.CustomerID)
+	}
+}
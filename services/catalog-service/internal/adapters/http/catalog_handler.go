@@ -1,8 +1,10 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"glovo-backend/services/catalog-service/internal/domain"
 	"glovo-backend/shared/auth"
@@ -26,10 +28,24 @@ func (h *CatalogHandler) SetupRoutes(router *gin.Engine) {
 		v1.GET("/stores", h.SearchStores)
 		v1.GET("/stores/:id", h.GetStore)
 		v1.GET("/stores/:id/products", h.GetStoreProducts)
+		v1.GET("/stores/:id/reviews", h.GetStoreReviews)
+		v1.GET("/products/search", h.SearchProducts)
 		v1.GET("/products/:id", h.GetProduct)
 		v1.GET("/categories", h.GetCategories)
 		v1.GET("/categories/:id", h.GetCategory)
-		v1.POST("/stores/:id/validate-order", h.ValidateOrder)
+
+		// Internal routes, for order-service to check orders and to reserve
+		// and release stock
+		internal := v1.Group("/internal")
+		internal.Use(middleware.ServiceAuth("order-service"))
+		{
+			internal.POST("/stores/:id/validate-order", h.ValidateOrder)
+			internal.POST("/stores/:id/stock/decrement", h.DecrementStock)
+			internal.POST("/stores/:id/stock/restore", h.RestoreStock)
+		}
+
+		// Customer routes
+		v1.POST("/stores/:id/reviews", middleware.AuthMiddleware(), middleware.RequireRole(auth.RoleCustomer), h.SubmitStoreReview)
 
 		// Merchant routes
 		merchant := v1.Group("/merchant")
@@ -40,8 +56,15 @@ func (h *CatalogHandler) SetupRoutes(router *gin.Engine) {
 			merchant.GET("/store", h.GetMerchantStore)
 			merchant.PUT("/store", h.UpdateStore)
 			merchant.POST("/store/products", h.CreateProduct)
+			merchant.POST("/store/products/import", h.ImportProducts)
 			merchant.PUT("/products/:id", h.UpdateProduct)
 			merchant.DELETE("/products/:id", h.DeleteProduct)
+			merchant.POST("/products/:id/images", h.UploadProductImage)
+			merchant.PUT("/products/:id/images/order", h.ReorderProductImages)
+			merchant.PUT("/products/:id/images/:imageId/primary", h.SetPrimaryProductImage)
+			merchant.DELETE("/products/:id/images/:imageId", h.DeleteProductImage)
+			merchant.POST("/products/:id/promotions", h.AddProductPromotion)
+			merchant.DELETE("/products/:id/promotions/:promotionId", h.RemoveProductPromotion)
 		}
 
 		// Admin routes
@@ -52,26 +75,34 @@ func (h *CatalogHandler) SetupRoutes(router *gin.Engine) {
 			admin.POST("/categories", h.CreateCategory)
 			admin.PUT("/categories/:id", h.UpdateCategory)
 			admin.DELETE("/categories/:id", h.DeleteCategory)
+			admin.POST("/categories/:id/restore", h.RestoreCategory)
 			admin.GET("/stores", h.GetAllStores)
+			admin.POST("/stores/:id/review", h.ReviewStore)
+			admin.DELETE("/stores/:id", h.DeleteStore)
+			admin.POST("/stores/:id/restore", h.RestoreStore)
+			admin.POST("/products/:id/restore", h.RestoreProduct)
 		}
 	}
 }
 
 // SearchStores godoc
 // @Summary Search stores
-// @Description Search for stores based on location, category, and other filters
+// @Description Search for stores based on location, category, and other filters. With latitude and longitude, stores that don't deliver there are hidden.
 // @Tags Stores
 // @Produce json
 // @Param query query string false "Search query"
-// @Param category_id query string false "Category ID"
-// @Param latitude query number false "User latitude"
-// @Param longitude query number false "User longitude"
+// @Param category_id query string false "Category ID, including its subcategories"
+// @Param latitude query number false "User latitude (delivery location)"
+// @Param longitude query number false "User longitude (delivery location)"
 // @Param radius query number false "Search radius in km"
 // @Param min_rating query number false "Minimum rating"
 // @Param sort_by query string false "Sort by: rating, distance, delivery_time"
+// @Param dietary query string false "Comma-separated dietary tags; only stores with an available product carrying all of them"
+// @Param exclude_allergens query string false "Comma-separated allergens; only stores with an available product free of all of them"
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
 // @Success 200 {array} domain.Store
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/stores [get]
 func (h *CatalogHandler) SearchStores(c *gin.Context) {
@@ -80,6 +111,12 @@ func (h *CatalogHandler) SearchStores(c *gin.Context) {
 		CategoryID: c.Query("category_id"),
 		SortBy:     c.Query("sort_by"),
 	}
+	for _, tag := range queryList(c, "dietary") {
+		req.Dietary = append(req.Dietary, domain.DietaryTag(tag))
+	}
+	for _, allergen := range queryList(c, "exclude_allergens") {
+		req.ExcludeAllergens = append(req.ExcludeAllergens, domain.Allergen(allergen))
+	}
 
 	if lat := c.Query("latitude"); lat != "" {
 		if latFloat, err := strconv.ParseFloat(lat, 64); err == nil {
@@ -110,6 +147,10 @@ func (h *CatalogHandler) SearchStores(c *gin.Context) {
 
 	stores, err := h.catalogService.SearchStores(req)
 	if err != nil {
+		if errors.Is(err, domain.ErrUnknownDietaryTag) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -140,7 +181,7 @@ func (h *CatalogHandler) GetStore(c *gin.Context) {
 
 // CreateStore godoc
 // @Summary Create a new store
-// @Description Create a new store for the authenticated merchant
+// @Description Create a new store for the authenticated merchant. The store starts pending approval and is hidden from search until an admin approves it.
 // @Tags Merchant
 // @Accept json
 // @Produce json
@@ -166,6 +207,10 @@ func (h *CatalogHandler) CreateStore(c *gin.Context) {
 
 	store, err := h.catalogService.CreateStore(merchantID, req)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidDeliveryZone) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -197,7 +242,7 @@ func (h *CatalogHandler) GetMerchantStore(c *gin.Context) {
 
 // UpdateStore godoc
 // @Summary Update store
-// @Description Update store information
+// @Description Update store information. Status can only be changed once the store is approved; updating a rejected store resubmits it for approval.
 // @Tags Merchant
 // @Accept json
 // @Produce json
@@ -207,6 +252,7 @@ func (h *CatalogHandler) GetMerchantStore(c *gin.Context) {
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /api/v1/merchant/store [put]
 func (h *CatalogHandler) UpdateStore(c *gin.Context) {
 	merchantID := c.GetString("user_id")
@@ -226,7 +272,14 @@ func (h *CatalogHandler) UpdateStore(c *gin.Context) {
 
 	updatedStore, err := h.catalogService.UpdateStore(store.ID, merchantID, updates)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, domain.ErrInvalidDeliveryZone), errors.Is(err, domain.ErrInvalidStoreStatus):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrStoreNotApproved):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -264,6 +317,10 @@ func (h *CatalogHandler) CreateProduct(c *gin.Context) {
 
 	product, err := h.catalogService.CreateProduct(store.ID, merchantID, req)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidOptionLimits) || errors.Is(err, domain.ErrUnknownDietaryTag) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -271,14 +328,79 @@ func (h *CatalogHandler) CreateProduct(c *gin.Context) {
 	c.JSON(http.StatusCreated, product)
 }
 
+// ImportProducts godoc
+// @Summary Import products from CSV
+// @Description Bulk-create products from a CSV of up to 2 MB with columns name, price, category (ID or name), description, available. Returns 422 with the report when no rows were imported.
+// @Tags Merchant
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "CSV file"
+// @Param mode query string false "all_or_nothing (default) or best_effort"
+// @Success 200 {object} domain.ProductImportReport
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 422 {object} domain.ProductImportReport "No rows were imported"
+// @Router /api/v1/merchant/store/products/import [post]
+func (h *CatalogHandler) ImportProducts(c *gin.Context) {
+	merchantID := c.GetString("user_id")
+
+	store, err := h.catalogService.GetMerchantStore(merchantID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Store not found"})
+		return
+	}
+
+	// Leave room for the multipart headers around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, domain.MaxProductImportSize+64<<10)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": domain.ErrImportTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is required"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	mode := domain.ImportMode(c.DefaultQuery("mode", string(domain.ImportAllOrNothing)))
+	report, err := h.catalogService.ImportProducts(store.ID, merchantID, file, mode)
+	if err != nil {
+		if errors.Is(err, domain.ErrImportTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The report says which rows were rejected and why
+	if report.Imported == 0 && report.Failed > 0 {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
 // GetStoreProducts godoc
 // @Summary Get store products
-// @Description Get all products for a specific store
+// @Description Get the products of a specific store that are available now, or all of them with include_unavailable=true
 // @Tags Stores
 // @Produce json
 // @Param id path string true "Store ID"
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
+// @Param include_unavailable query bool false "Include products outside their availability windows"
 // @Success 200 {array} domain.Product
 // @Failure 404 {object} map[string]string
 // @Router /api/v1/stores/{id}/products [get]
@@ -287,7 +409,9 @@ func (h *CatalogHandler) GetStoreProducts(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	products, err := h.catalogService.GetStoreProducts(storeID, limit, offset)
+	includeUnavailable, _ := strconv.ParseBool(c.Query("include_unavailable"))
+
+	products, err := h.catalogService.GetStoreProducts(storeID, limit, offset, includeUnavailable)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -296,6 +420,138 @@ func (h *CatalogHandler) GetStoreProducts(c *gin.Context) {
 	c.JSON(http.StatusOK, products)
 }
 
+// GetStoreReviews godoc
+// @Summary Get store reviews
+// @Description Get a store's customer reviews, newest first
+// @Tags Stores
+// @Produce json
+// @Param id path string true "Store ID"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {array} domain.StoreReview
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/stores/{id}/reviews [get]
+func (h *CatalogHandler) GetStoreReviews(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	reviews, err := h.catalogService.GetStoreReviews(c.Param("id"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// SubmitStoreReview godoc
+// @Summary Review a store
+// @Description Rate a store from 1 to 5 for one of your delivered orders. Each order can be reviewed once.
+// @Tags Stores
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Store ID"
+// @Param request body domain.CreateStoreReviewRequest true "Review"
+// @Success 201 {object} domain.StoreReview
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /api/v1/stores/{id}/reviews [post]
+func (h *CatalogHandler) SubmitStoreReview(c *gin.Context) {
+	customerID := c.GetString("user_id")
+
+	var req domain.CreateStoreReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := h.catalogService.SubmitStoreReview(c.Param("id"), customerID, req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, domain.ErrReviewExists):
+			status = http.StatusConflict
+		case errors.Is(err, domain.ErrOrderNotReviewable):
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
+
+// SearchProducts godoc
+// @Summary Search products
+// @Description Ranked, typo-tolerant search over product names and descriptions across all stores
+// @Tags Products
+// @Produce json
+// @Param q query string false "Search query (required unless category_id or dietary is set)"
+// @Param category_id query string false "Category ID, including its subcategories"
+// @Param dietary query string false "Comma-separated dietary tags the product must all carry, e.g. vegan,gluten_free"
+// @Param exclude_allergens query string false "Comma-separated allergens the product must not contain, e.g. nuts,dairy"
+// @Param latitude query number false "User latitude"
+// @Param longitude query number false "User longitude"
+// @Param radius query number false "Only stores within this radius in km"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {array} domain.ProductSearchResult
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/products/search [get]
+func (h *CatalogHandler) SearchProducts(c *gin.Context) {
+	req := domain.ProductSearchRequest{
+		Query:      c.Query("q"),
+		CategoryID: c.Query("category_id"),
+	}
+	for _, tag := range queryList(c, "dietary") {
+		req.Dietary = append(req.Dietary, domain.DietaryTag(tag))
+	}
+	for _, allergen := range queryList(c, "exclude_allergens") {
+		req.ExcludeAllergens = append(req.ExcludeAllergens, domain.Allergen(allergen))
+	}
+	if req.Query == "" && req.CategoryID == "" && len(req.Dietary) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q, category_id or dietary is required"})
+		return
+	}
+
+	if lat := c.Query("latitude"); lat != "" {
+		if latFloat, err := strconv.ParseFloat(lat, 64); err == nil {
+			req.Latitude = latFloat
+		}
+	}
+
+	if lng := c.Query("longitude"); lng != "" {
+		if lngFloat, err := strconv.ParseFloat(lng, 64); err == nil {
+			req.Longitude = lngFloat
+		}
+	}
+
+	if radius := c.Query("radius"); radius != "" {
+		if radiusFloat, err := strconv.ParseFloat(radius, 64); err == nil {
+			req.Radius = radiusFloat
+		}
+	}
+
+	req.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	req.Offset, _ = strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	results, err := h.catalogService.SearchProductsRanked(req)
+	if err != nil {
+		if errors.Is(err, domain.ErrUnknownDietaryTag) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
 // GetProduct godoc
 // @Summary Get product by ID
 // @Description Get detailed information about a product
@@ -343,6 +599,10 @@ func (h *CatalogHandler) UpdateProduct(c *gin.Context) {
 
 	product, err := h.catalogService.UpdateProduct(productID, merchantID, updates)
 	if err != nil {
+		if errors.Is(err, domain.ErrUnknownDietaryTag) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -373,16 +633,199 @@ func (h *CatalogHandler) DeleteProduct(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
 
+// UploadProductImage godoc
+// @Summary Upload product image
+// @Description Upload a JPEG, PNG or WebP image (max 5 MB) for a product. The first image becomes the primary image.
+// @Tags Merchant
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param image formData file true "Image file"
+// @Success 201 {object} domain.ProductImage
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/merchant/products/{id}/images [post]
+func (h *CatalogHandler) UploadProductImage(c *gin.Context) {
+	productID := c.Param("id")
+	merchantID := c.GetString("user_id")
+
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Image file is required"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	image, err := h.catalogService.UploadProductImage(productID, merchantID, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, image)
+}
+
+// ReorderProductImages godoc
+// @Summary Reorder product images
+// @Description Set the display order of a product's images; image_ids must list every image
+// @Tags Merchant
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param request body domain.ReorderProductImagesRequest true "Image IDs in display order"
+// @Success 200 {array} domain.ProductImage
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/merchant/products/{id}/images/order [put]
+func (h *CatalogHandler) ReorderProductImages(c *gin.Context) {
+	productID := c.Param("id")
+	merchantID := c.GetString("user_id")
+
+	var req domain.ReorderProductImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	images, err := h.catalogService.ReorderProductImages(productID, merchantID, req.ImageIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, images)
+}
+
+// SetPrimaryProductImage godoc
+// @Summary Set primary product image
+// @Description Mark an image as the product's primary image
+// @Tags Merchant
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param imageId path string true "Image ID"
+// @Success 200 {array} domain.ProductImage
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/merchant/products/{id}/images/{imageId}/primary [put]
+func (h *CatalogHandler) SetPrimaryProductImage(c *gin.Context) {
+	productID := c.Param("id")
+	merchantID := c.GetString("user_id")
+
+	images, err := h.catalogService.SetPrimaryProductImage(productID, merchantID, c.Param("imageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, images)
+}
+
+// DeleteProductImage godoc
+// @Summary Delete product image
+// @Description Delete a product image; if it was primary, the next image becomes primary
+// @Tags Merchant
+// @Security BearerAuth
+// @Param id path string true "Product ID"
+// @Param imageId path string true "Image ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/merchant/products/{id}/images/{imageId} [delete]
+func (h *CatalogHandler) DeleteProductImage(c *gin.Context) {
+	productID := c.Param("id")
+	merchantID := c.GetString("user_id")
+
+	if err := h.catalogService.DeleteProductImage(productID, merchantID, c.Param("imageId")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
+}
+
+// AddProductPromotion godoc
+// @Summary Add product promotion
+// @Description Schedule a percentage or fixed discount on a product. When promotions overlap, the best discount applies.
+// @Tags Merchant
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param request body domain.CreatePromotionRequest true "Promotion"
+// @Success 201 {object} domain.Product
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/merchant/products/{id}/promotions [post]
+func (h *CatalogHandler) AddProductPromotion(c *gin.Context) {
+	productID := c.Param("id")
+	merchantID := c.GetString("user_id")
+
+	var req domain.CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := h.catalogService.AddProductPromotion(productID, merchantID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, product)
+}
+
+// RemoveProductPromotion godoc
+// @Summary Remove product promotion
+// @Description Remove a scheduled or active promotion from a product
+// @Tags Merchant
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param promotionId path string true "Promotion ID"
+// @Success 200 {object} domain.Product
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/merchant/products/{id}/promotions/{promotionId} [delete]
+func (h *CatalogHandler) RemoveProductPromotion(c *gin.Context) {
+	productID := c.Param("id")
+	merchantID := c.GetString("user_id")
+
+	product, err := h.catalogService.RemoveProductPromotion(productID, merchantID, c.Param("promotionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
 // GetCategories godoc
 // @Summary Get all categories
-// @Description Get all product categories
+// @Description Get all product categories, or the category tree with nested=true
 // @Tags Categories
 // @Produce json
+// @Param nested query bool false "Return root categories with subcategories nested"
 // @Success 200 {array} domain.Category
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/categories [get]
 func (h *CatalogHandler) GetCategories(c *gin.Context) {
-	categories, err := h.catalogService.GetCategories()
+	var categories []domain.Category
+	var err error
+	if nested, _ := strconv.ParseBool(c.Query("nested")); nested {
+		categories, err = h.catalogService.GetCategoryTree()
+	} else {
+		categories, err = h.catalogService.GetCategories()
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -467,6 +910,10 @@ func (h *CatalogHandler) UpdateCategory(c *gin.Context) {
 
 	category, err := h.catalogService.UpdateCategory(categoryID, updates)
 	if err != nil {
+		if errors.Is(err, domain.ErrCategoryCycle) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -497,28 +944,118 @@ func (h *CatalogHandler) DeleteCategory(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
 }
 
+// RestoreCategory godoc
+// @Summary Restore category
+// @Description Restore a deleted category (Admin only)
+// @Tags Admin
+// @Security BearerAuth
+// @Param id path string true "Category ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/categories/{id}/restore [post]
+func (h *CatalogHandler) RestoreCategory(c *gin.Context) {
+	if err := h.catalogService.RestoreCategory(c.Param("id")); err != nil {
+		h.restoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category restored successfully"})
+}
+
+// DeleteStore godoc
+// @Summary Delete store
+// @Description Soft-delete a store, hiding it from listings (Admin only)
+// @Tags Admin
+// @Security BearerAuth
+// @Param id path string true "Store ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/stores/{id} [delete]
+func (h *CatalogHandler) DeleteStore(c *gin.Context) {
+	if err := h.catalogService.DeleteStore(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Store not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Store deleted successfully"})
+}
+
+// RestoreStore godoc
+// @Summary Restore store
+// @Description Restore a deleted store (Admin only)
+// @Tags Admin
+// @Security BearerAuth
+// @Param id path string true "Store ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/stores/{id}/restore [post]
+func (h *CatalogHandler) RestoreStore(c *gin.Context) {
+	if err := h.catalogService.RestoreStore(c.Param("id")); err != nil {
+		h.restoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Store restored successfully"})
+}
+
+// RestoreProduct godoc
+// @Summary Restore product
+// @Description Restore a deleted product (Admin only)
+// @Tags Admin
+// @Security BearerAuth
+// @Param id path string true "Product ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/admin/products/{id}/restore [post]
+func (h *CatalogHandler) RestoreProduct(c *gin.Context) {
+	if err := h.catalogService.RestoreProduct(c.Param("id")); err != nil {
+		h.restoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Product restored successfully"})
+}
+
+func (h *CatalogHandler) restoreError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrNotDeleted) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
 // ValidateOrder godoc
 // @Summary Validate order items
-// @Description Validate order items for a specific store (used by Order Service)
-// @Tags Stores
+// @Description Validate order items for a specific store (used by Order Service). Each line reports whether the product exists and is available, its current price, whether the selected options are valid and whether the price differs from the requested unit_price. With a delivery_location, the order is invalid when it falls outside the store's delivery zone or radius.
+// @Tags Internal
 // @Accept json
 // @Produce json
+// @Security ServiceToken
 // @Param id path string true "Store ID"
-// @Param items body []domain.OrderItem true "Order items to validate"
+// @Param request body domain.ValidateOrderRequest true "Order items and delivery location to validate"
 // @Success 200 {object} domain.OrderValidation
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
 // @Failure 404 {object} map[string]string
-// @Router /api/v1/stores/{id}/validate-order [post]
+// @Router /api/v1/internal/stores/{id}/validate-order [post]
 func (h *CatalogHandler) ValidateOrder(c *gin.Context) {
 	storeID := c.Param("id")
 
-	var items []domain.OrderItem
-	if err := c.ShouldBindJSON(&items); err != nil {
+	var req domain.ValidateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	validation, err := h.catalogService.ValidateOrderItems(storeID, items)
+	validation, err := h.catalogService.ValidateOrderItems(storeID, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -527,12 +1064,80 @@ func (h *CatalogHandler) ValidateOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, validation)
 }
 
+// DecrementStock godoc
+// @Summary Decrement stock for an order
+// @Description Atomically subtract ordered quantities from tracked product stock when an order is confirmed, once per order (for Order Service)
+// @Tags Internal
+// @Accept json
+// @Produce json
+// @Security ServiceToken
+// @Param id path string true "Store ID"
+// @Param request body domain.StockRequest true "Order and its items"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/internal/stores/{id}/stock/decrement [post]
+func (h *CatalogHandler) DecrementStock(c *gin.Context) {
+	storeID := c.Param("id")
+
+	var req domain.StockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.catalogService.DecrementStock(storeID, req); err != nil {
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreStock godoc
+// @Summary Restore stock for a cancelled order
+// @Description Add the quantities of a cancelled order back to tracked product stock, once per order (for Order Service)
+// @Tags Internal
+// @Accept json
+// @Produce json
+// @Security ServiceToken
+// @Param id path string true "Store ID"
+// @Param request body domain.StockRequest true "Order and its items"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/internal/stores/{id}/stock/restore [post]
+func (h *CatalogHandler) RestoreStock(c *gin.Context) {
+	storeID := c.Param("id")
+
+	var req domain.StockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.catalogService.RestoreStock(storeID, req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // GetAllStores godoc
 // @Summary Get all stores (Admin only)
-// @Description Get all stores in the system with pagination
+// @Description Get all stores in the system with pagination, including those awaiting approval
 // @Tags Admin
 // @Produce json
 // @Security BearerAuth
+// @Param status query string false "Only stores in this status, e.g. pending_approval"
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
 // @Success 200 {array} domain.Store
@@ -541,7 +1146,10 @@ func (h *CatalogHandler) ValidateOrder(c *gin.Context) {
 // @Router /api/v1/admin/stores [get]
 func (h *CatalogHandler) GetAllStores(c *gin.Context) {
 	// For admin, use search with no filters to get all stores
-	req := domain.StoreSearchRequest{}
+	req := domain.StoreSearchRequest{
+		Status:            domain.StoreStatus(c.Query("status")),
+		IncludeUnapproved: true,
+	}
 	req.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
 	req.Offset, _ = strconv.Atoi(c.DefaultQuery("offset", "0"))
 
@@ -553,3 +1161,57 @@ func (h *CatalogHandler) GetAllStores(c *gin.Context) {
 
 	c.JSON(http.StatusOK, stores)
 }
+
+// ReviewStore godoc
+// @Summary Review store
+// @Description Approve or reject a store pending approval; the merchant is notified. A reason is required to reject. (Admin only)
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Store ID"
+// @Param request body domain.ReviewStoreRequest true "Decision"
+// @Success 200 {object} domain.Store
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/v1/admin/stores/{id}/review [post]
+func (h *CatalogHandler) ReviewStore(c *gin.Context) {
+	adminID := c.GetString("user_id")
+
+	var req domain.ReviewStoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.catalogService.GetStore(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Store not found"})
+		return
+	}
+
+	store, err := h.catalogService.ReviewStore(c.Param("id"), adminID, req)
+	if err != nil {
+		if errors.Is(err, domain.ErrStoreNotPending) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, store)
+}
+
+// queryList splits a comma-separated query parameter, dropping empty items
+func queryList(c *gin.Context, key string) []string {
+	var items []string
+	for _, item := range strings.Split(c.Query(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,304 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+	"glovo-backend/shared/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeCatalogService struct {
+	domain.CatalogService
+	importReport *domain.ProductImportReport
+	importErr    error
+	importedCSV  string
+	importMode   domain.ImportMode
+	restoreErr   error
+	restored     []string
+	searchReq    *domain.ProductSearchRequest
+	searchErr    error
+	reviewErr    error
+	reviewReq    *domain.ReviewStoreRequest
+}
+
+func (s *fakeCatalogService) GetStore(storeID string) (*domain.Store, error) {
+	if storeID == "missing" {
+		return nil, errors.New("record not found")
+	}
+	return &domain.Store{ID: storeID, Status: domain.StatusPendingApproval}, nil
+}
+
+func (s *fakeCatalogService) ReviewStore(storeID string, adminID string, req domain.ReviewStoreRequest) (*domain.Store, error) {
+	s.reviewReq = &req
+	if s.reviewErr != nil {
+		return nil, s.reviewErr
+	}
+	return &domain.Store{ID: storeID, Status: domain.StatusOpen, ReviewedBy: adminID}, nil
+}
+
+func (s *fakeCatalogService) GetMerchantStore(merchantID string) (*domain.Store, error) {
+	return &domain.Store{ID: "store-1", MerchantID: merchantID}, nil
+}
+
+func (s *fakeCatalogService) ImportProducts(storeID string, merchantID string, csv io.Reader, mode domain.ImportMode) (*domain.ProductImportReport, error) {
+	data, _ := io.ReadAll(csv)
+	s.importedCSV = string(data)
+	s.importMode = mode
+	return s.importReport, s.importErr
+}
+
+func (s *fakeCatalogService) RestoreStore(storeID string) error {
+	return s.restore("store " + storeID)
+}
+
+func (s *fakeCatalogService) RestoreProduct(productID string) error {
+	return s.restore("product " + productID)
+}
+
+func (s *fakeCatalogService) RestoreCategory(categoryID string) error {
+	return s.restore("category " + categoryID)
+}
+
+func (s *fakeCatalogService) restore(record string) error {
+	if s.restoreErr != nil {
+		return s.restoreErr
+	}
+	s.restored = append(s.restored, record)
+	return nil
+}
+
+func (s *fakeCatalogService) SearchProductsRanked(req domain.ProductSearchRequest) ([]domain.ProductSearchResult, error) {
+	s.searchReq = &req
+	return []domain.ProductSearchResult{}, s.searchErr
+}
+
+func newCatalogRouter(t *testing.T, service domain.CatalogService) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "user-secret")
+	router := gin.New()
+	NewCatalogHandler(service).SetupRoutes(router)
+	return router
+}
+
+func bearer(t *testing.T, userID string, role auth.UserRole) string {
+	t.Helper()
+	token, err := auth.GenerateToken(userID, role)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	return "Bearer " + token
+}
+
+// multipartFile builds a multipart body with one file field
+func multipartFile(t *testing.T, field, filename string, content []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	part.Write(content)
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+func TestImportProductsRoute(t *testing.T) {
+	const csv = "name,price,category,description,available\nCola,2,Drinks,,true\n"
+
+	tests := []struct {
+		name       string
+		query      string
+		withFile   bool
+		report     *domain.ProductImportReport
+		importErr  error
+		wantStatus int
+		wantMode   domain.ImportMode
+	}{
+		{name: "imported", withFile: true, report: &domain.ProductImportReport{Total: 1, Imported: 1}, wantStatus: http.StatusOK, wantMode: domain.ImportAllOrNothing},
+		{name: "partly imported", query: "?mode=best_effort", withFile: true, report: &domain.ProductImportReport{Total: 2, Imported: 1, Failed: 1}, wantStatus: http.StatusOK, wantMode: domain.ImportBestEffort},
+		{name: "nothing imported", withFile: true, report: &domain.ProductImportReport{Total: 1, Failed: 1}, wantStatus: http.StatusUnprocessableEntity, wantMode: domain.ImportAllOrNothing},
+		{name: "too large", withFile: true, importErr: domain.ErrImportTooLarge, wantStatus: http.StatusRequestEntityTooLarge, wantMode: domain.ImportAllOrNothing},
+		{name: "no file", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeCatalogService{importReport: tt.report, importErr: tt.importErr}
+			router := newCatalogRouter(t, service)
+
+			field := "other"
+			if tt.withFile {
+				field = "file"
+			}
+			body, contentType := multipartFile(t, field, "menu.csv", []byte(csv))
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/merchant/store/products/import"+tt.query, body)
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("Authorization", bearer(t, "merchant-1", auth.RoleMerchant))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if service.importMode != tt.wantMode {
+				t.Fatalf("mode = %q, want %q", service.importMode, tt.wantMode)
+			}
+			if tt.withFile && service.importedCSV != csv {
+				t.Fatalf("service read %q, want the uploaded CSV", service.importedCSV)
+			}
+		})
+	}
+}
+
+func TestImportProductsRequiresMerchant(t *testing.T) {
+	service := &fakeCatalogService{}
+	router := newCatalogRouter(t, service)
+
+	body, contentType := multipartFile(t, "file", "menu.csv", []byte("name\n"))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/merchant/store/products/import", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", bearer(t, "customer-1", auth.RoleCustomer))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusForbidden, w.Body.String())
+	}
+	if service.importMode != "" {
+		t.Fatalf("customer import reached the service")
+	}
+}
+
+func TestRestoreRoutes(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		role        auth.UserRole
+		restoreErr  error
+		wantStatus  int
+		wantRestore string
+	}{
+		{name: "store", path: "/api/v1/admin/stores/s1/restore", role: auth.RoleAdmin, wantStatus: http.StatusOK, wantRestore: "store s1"},
+		{name: "product", path: "/api/v1/admin/products/p1/restore", role: auth.RoleAdmin, wantStatus: http.StatusOK, wantRestore: "product p1"},
+		{name: "category", path: "/api/v1/admin/categories/c1/restore", role: auth.RoleAdmin, wantStatus: http.StatusOK, wantRestore: "category c1"},
+		{name: "not deleted", path: "/api/v1/admin/products/p1/restore", role: auth.RoleAdmin, restoreErr: domain.ErrNotDeleted, wantStatus: http.StatusNotFound},
+		{name: "merchant", path: "/api/v1/admin/products/p1/restore", role: auth.RoleMerchant, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeCatalogService{restoreErr: tt.restoreErr}
+			router := newCatalogRouter(t, service)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			req.Header.Set("Authorization", bearer(t, "user-1", tt.role))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantRestore != "" && (len(service.restored) != 1 || service.restored[0] != tt.wantRestore) {
+				t.Fatalf("restored %v, want %s", service.restored, tt.wantRestore)
+			}
+		})
+	}
+}
+
+func TestSearchProductsRoute(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		searchErr     error
+		wantStatus    int
+		wantDietary   []domain.DietaryTag
+		wantAllergens []domain.Allergen
+	}{
+		{
+			name:          "dietary filters",
+			query:         "?q=curry&dietary=vegan,+gluten_free&exclude_allergens=nuts,,dairy",
+			wantStatus:    http.StatusOK,
+			wantDietary:   []domain.DietaryTag{domain.DietaryVegan, domain.DietaryGlutenFree},
+			wantAllergens: []domain.Allergen{domain.AllergenNuts, domain.AllergenDairy},
+		},
+		{name: "dietary without a query", query: "?dietary=vegan", wantStatus: http.StatusOK, wantDietary: []domain.DietaryTag{domain.DietaryVegan}},
+		{name: "unknown tag", query: "?q=curry&dietary=paleo", searchErr: domain.ErrUnknownDietaryTag, wantStatus: http.StatusBadRequest, wantDietary: []domain.DietaryTag{"paleo"}},
+		{name: "nothing to search for", query: "?exclude_allergens=nuts", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeCatalogService{searchErr: tt.searchErr}
+			router := newCatalogRouter(t, service)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/products/search"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantDietary == nil && tt.wantAllergens == nil {
+				if service.searchReq != nil {
+					t.Fatalf("searched despite a missing query")
+				}
+				return
+			}
+			if fmt.Sprint(service.searchReq.Dietary) != fmt.Sprint(tt.wantDietary) ||
+				fmt.Sprint(service.searchReq.ExcludeAllergens) != fmt.Sprint(tt.wantAllergens) {
+				t.Fatalf("filters = %v / %v, want %v / %v",
+					service.searchReq.Dietary, service.searchReq.ExcludeAllergens, tt.wantDietary, tt.wantAllergens)
+			}
+		})
+	}
+}
+
+func TestReviewStoreRoute(t *testing.T) {
+	tests := []struct {
+		name       string
+		storeID    string
+		role       auth.UserRole
+		body       string
+		reviewErr  error
+		wantStatus int
+		wantReview bool
+	}{
+		{name: "approve", storeID: "store-1", role: auth.RoleAdmin, body: `{"decision":"approve"}`, wantStatus: http.StatusOK, wantReview: true},
+		{name: "reject with reason", storeID: "store-1", role: auth.RoleAdmin, body: `{"decision":"reject","reason":"Missing food licence"}`, wantStatus: http.StatusOK, wantReview: true},
+		{name: "reject without reason", storeID: "store-1", role: auth.RoleAdmin, body: `{"decision":"reject"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown decision", storeID: "store-1", role: auth.RoleAdmin, body: `{"decision":"maybe"}`, wantStatus: http.StatusBadRequest},
+		{name: "already reviewed", storeID: "store-1", role: auth.RoleAdmin, body: `{"decision":"approve"}`, reviewErr: domain.ErrStoreNotPending, wantStatus: http.StatusConflict, wantReview: true},
+		{name: "missing store", storeID: "missing", role: auth.RoleAdmin, body: `{"decision":"approve"}`, wantStatus: http.StatusNotFound},
+		{name: "merchant", storeID: "store-1", role: auth.RoleMerchant, body: `{"decision":"approve"}`, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeCatalogService{reviewErr: tt.reviewErr}
+			router := newCatalogRouter(t, service)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/stores/"+tt.storeID+"/review", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", bearer(t, "admin-1", tt.role))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if (service.reviewReq != nil) != tt.wantReview {
+				t.Fatalf("reviewed = %v, want %v", service.reviewReq != nil, tt.wantReview)
+			}
+		})
+	}
+}
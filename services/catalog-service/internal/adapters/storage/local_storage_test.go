@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir := t.TempDir()
+	storage := NewLocalStorage(dir, "https://cdn.test/uploads/")
+
+	url, err := storage.Save("products/p1/image.png", "image/png", strings.NewReader("png-data"))
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if url != "https://cdn.test/uploads/products/p1/image.png" {
+		t.Fatalf("url = %q", url)
+	}
+
+	path := filepath.Join(dir, "products", "p1", "image.png")
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "png-data" {
+		t.Fatalf("stored file = %q, %v", data, err)
+	}
+
+	if err := storage.Delete("products/p1/image.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("file still exists after Delete: %v", err)
+	}
+	if err := storage.Delete("products/p1/image.png"); err != nil {
+		t.Fatalf("deleting a missing file: %v", err)
+	}
+}
+
+func TestLocalStorageRejectsEscapingKeys(t *testing.T) {
+	storage := NewLocalStorage(t.TempDir(), "https://cdn.test")
+
+	for _, key := range []string{"../outside.png", "products/../../outside.png"} {
+		if _, err := storage.Save(key, "image/png", strings.NewReader("x")); err == nil {
+			t.Fatalf("Save(%q): expected an error", key)
+		}
+		if err := storage.Delete(key); err == nil {
+			t.Fatalf("Delete(%q): expected an error", key)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// DeliverySubscriber records delivered orders so customers can review stores
+type DeliverySubscriber struct {
+	catalogService domain.CatalogService
+}
+
+func NewDeliverySubscriber(catalogService domain.CatalogService) *DeliverySubscriber {
+	return &DeliverySubscriber{catalogService: catalogService}
+}
+
+// Register subscribes to the delivery events catalog reacts to
+func (s *DeliverySubscriber) Register(bus events.Bus) error {
+	return bus.Subscribe(events.DeliveryCompleted, s.handleDeliveryCompleted)
+}
+
+func (s *DeliverySubscriber) handleDeliveryCompleted(ctx context.Context, event events.Event) error {
+	var payload events.DeliveryCompletedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	if payload.CustomerID == "" || payload.MerchantID == "" {
+		return nil
+	}
+
+	err := s.catalogService.RecordDeliveredOrder(domain.DeliveredOrder{
+		OrderID:     payload.OrderID,
+		CustomerID:  payload.CustomerID,
+		MerchantID:  payload.MerchantID,
+		DeliveredAt: payload.DeliveredAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record delivered order %s: %w", payload.OrderID, err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package subscriber
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+type fakeCatalogService struct {
+	domain.CatalogService
+	recorded []domain.DeliveredOrder
+}
+
+func (s *fakeCatalogService) RecordDeliveredOrder(order domain.DeliveredOrder) error {
+	s.recorded = append(s.recorded, order)
+	return nil
+}
+
+func TestDeliveryCompletedRecordsOrder(t *testing.T) {
+	deliveredAt := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		payload events.DeliveryCompletedPayload
+		want    bool
+	}{
+		{name: "complete payload", payload: events.DeliveryCompletedPayload{OrderID: "order-1", CustomerID: "customer-1", MerchantID: "merchant-1", DeliveredAt: deliveredAt}, want: true},
+		{name: "no customer", payload: events.DeliveryCompletedPayload{OrderID: "order-1", MerchantID: "merchant-1"}},
+		{name: "no merchant", payload: events.DeliveryCompletedPayload{OrderID: "order-1", CustomerID: "customer-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeCatalogService{}
+			bus := events.NewInMemoryBus()
+			if err := NewDeliverySubscriber(service).Register(bus); err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+
+			if err := bus.Publish(context.Background(), events.DeliveryCompleted, tt.payload); err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			bus.Close()
+
+			if !tt.want {
+				if len(service.recorded) != 0 {
+					t.Fatalf("recorded %+v, want nothing", service.recorded)
+				}
+				return
+			}
+			want := domain.DeliveredOrder{OrderID: "order-1", CustomerID: "customer-1", MerchantID: "merchant-1", DeliveredAt: deliveredAt}
+			if len(service.recorded) != 1 || service.recorded[0] != want {
+				t.Fatalf("recorded %+v, want %+v", service.recorded, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func TestIsProductAvailableAt(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	store := &domain.Store{Timezone: "Europe/Madrid"}
+	breakfast := &domain.Product{Availability: []domain.AvailabilityWindow{
+		{Days: []string{"monday", "tuesday", "wednesday", "thursday", "friday"}, Hours: "07:00-11:00"},
+	}}
+
+	tests := []struct {
+		name    string
+		product *domain.Product
+		at      time.Time
+		want    bool
+	}{
+		{name: "no windows", product: &domain.Product{}, at: time.Date(2026, 10, 12, 3, 0, 0, 0, madrid), want: true},
+		{name: "before the cutoff", product: breakfast, at: time.Date(2026, 10, 12, 10, 59, 0, 0, madrid), want: true},
+		{name: "at the cutoff", product: breakfast, at: time.Date(2026, 10, 12, 11, 0, 0, 0, madrid), want: false},
+		{name: "before opening", product: breakfast, at: time.Date(2026, 10, 12, 6, 30, 0, 0, madrid), want: false},
+		{name: "weekend", product: breakfast, at: time.Date(2026, 10, 11, 9, 0, 0, 0, madrid), want: false},
+		// 08:30 UTC is 10:30 in Madrid, inside the window, while 09:30 UTC is past it
+		{name: "evaluated in the store's timezone", product: breakfast, at: time.Date(2026, 10, 12, 8, 30, 0, 0, time.UTC), want: true},
+		{name: "past the cutoff in the store's timezone", product: breakfast, at: time.Date(2026, 10, 12, 9, 30, 0, 0, time.UTC), want: false},
+		{
+			name:    "late window running past midnight",
+			product: &domain.Product{Availability: []domain.AvailabilityWindow{{Days: []string{"friday"}, Hours: "22:00-02:00"}}},
+			at:      time.Date(2026, 10, 17, 1, 0, 0, 0, madrid), // Saturday
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isProductAvailableAt(tt.product, store, tt.at); got != tt.want {
+				t.Fatalf("available = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAvailability(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows []domain.AvailabilityWindow
+		wantErr bool
+	}{
+		{name: "valid", windows: []domain.AvailabilityWindow{{Days: []string{"monday"}, Hours: "07:00-11:00"}}},
+		{name: "every day", windows: []domain.AvailabilityWindow{{Hours: "07:00-11:00"}}},
+		{name: "unknown day", windows: []domain.AvailabilityWindow{{Days: []string{"funday"}, Hours: "07:00-11:00"}}, wantErr: true},
+		{name: "no hours", windows: []domain.AvailabilityWindow{{Days: []string{"monday"}}}, wantErr: true},
+		{name: "bad hours", windows: []domain.AvailabilityWindow{{Hours: "7am-11am"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAvailability(tt.windows); (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// windowAround returns hours covering from start to end hours from now, in UTC
+func windowAround(start, end time.Duration) []domain.AvailabilityWindow {
+	now := time.Now().UTC()
+	return []domain.AvailabilityWindow{{Hours: now.Add(start).Format("15:04") + "-" + now.Add(end).Format("15:04")}}
+}
+
+func TestAvailabilityWindowsInCatalog(t *testing.T) {
+	stores := []domain.Store{{ID: "store-1", Status: domain.StatusOpen}}
+	products := []domain.Product{
+		{ID: "now", StoreID: "store-1", Name: "Lunch", Price: 8, Status: domain.ProductStatusAvailable, Availability: windowAround(-time.Hour, time.Hour)},
+		{ID: "later", StoreID: "store-1", Name: "Dinner", Price: 12, Status: domain.ProductStatusAvailable, Availability: windowAround(time.Hour, 2*time.Hour)},
+		{ID: "always", StoreID: "store-1", Name: "Water", Price: 1, Status: domain.ProductStatusAvailable},
+	}
+	svc, _, _ := newTestCatalogService(stores, products)
+
+	listed, err := svc.GetStoreProducts("store-1", 20, 0, false)
+	if err != nil {
+		t.Fatalf("GetStoreProducts: %v", err)
+	}
+	if len(listed) != 2 || listed[0].ID != "now" || listed[1].ID != "always" {
+		t.Fatalf("listed = %+v, want the in-window and unrestricted products", listed)
+	}
+
+	all, err := svc.GetStoreProducts("store-1", 20, 0, true)
+	if err != nil {
+		t.Fatalf("GetStoreProducts: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("include_unavailable listed %d products, want 3", len(all))
+	}
+
+	paged, err := svc.GetStoreProducts("store-1", 1, 1, false)
+	if err != nil {
+		t.Fatalf("GetStoreProducts: %v", err)
+	}
+	if len(paged) != 1 || paged[0].ID != "always" {
+		t.Fatalf("second page = %+v, want only the unrestricted product", paged)
+	}
+
+	for _, tt := range []struct {
+		product   string
+		wantValid bool
+		wantIssue domain.ItemIssue
+	}{
+		{product: "now", wantValid: true},
+		{product: "later", wantIssue: domain.ItemIssueOutsideHours},
+	} {
+		validation, err := svc.ValidateOrderItems("store-1", domain.ValidateOrderRequest{
+			Items: []domain.OrderItem{{ProductID: tt.product, Quantity: 1}},
+		})
+		if err != nil {
+			t.Fatalf("ValidateOrderItems: %v", err)
+		}
+		if validation.Valid != tt.wantValid || validation.Items[0].Issue != tt.wantIssue {
+			t.Fatalf("%s: valid = %v issue %q, want %v %q", tt.product, validation.Valid, validation.Items[0].Issue, tt.wantValid, tt.wantIssue)
+		}
+	}
+}
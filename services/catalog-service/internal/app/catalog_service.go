@@ -1,30 +1,50 @@
 package app
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"glovo-backend/services/catalog-service/internal/domain"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/geo"
 
 	"github.com/google/uuid"
 )
 
 type catalogService struct {
-	storeRepo    domain.StoreRepository
-	productRepo  domain.ProductRepository
-	categoryRepo domain.CategoryRepository
+	storeRepo          domain.StoreRepository
+	productRepo        domain.ProductRepository
+	imageRepo          domain.ProductImageRepository
+	categoryRepo       domain.CategoryRepository
+	reviewRepo         domain.StoreReviewRepository
+	deliveredOrderRepo domain.DeliveredOrderRepository
+	fileStorage        domain.FileStorage
+	eventBus           events.Bus
 }
 
 func NewCatalogService(
 	storeRepo domain.StoreRepository,
 	productRepo domain.ProductRepository,
+	imageRepo domain.ProductImageRepository,
 	categoryRepo domain.CategoryRepository,
+	reviewRepo domain.StoreReviewRepository,
+	deliveredOrderRepo domain.DeliveredOrderRepository,
+	fileStorage domain.FileStorage,
+	eventBus events.Bus,
 ) domain.CatalogService {
 	return &catalogService{
-		storeRepo:    storeRepo,
-		productRepo:  productRepo,
-		categoryRepo: categoryRepo,
+		storeRepo:          storeRepo,
+		productRepo:        productRepo,
+		imageRepo:          imageRepo,
+		categoryRepo:       categoryRepo,
+		reviewRepo:         reviewRepo,
+		deliveredOrderRepo: deliveredOrderRepo,
+		fileStorage:        fileStorage,
+		eventBus:           eventBus,
 	}
 }
 
@@ -37,22 +57,32 @@ func (s *catalogService) CreateStore(merchantID string, req domain.CreateStoreRe
 	}
 
 	store := &domain.Store{
-		ID:           uuid.New().String(),
-		MerchantID:   merchantID,
-		Name:         req.Name,
-		Description:  req.Description,
-		Address:      req.Address,
-		Latitude:     req.Latitude,
-		Longitude:    req.Longitude,
-		Phone:        req.Phone,
-		Email:        req.Email,
-		Status:       domain.StatusOpen,
-		OpeningHours: req.OpeningHours,
-		DeliveryInfo: req.DeliveryInfo,
-		Rating:       0.0,
-		ReviewCount:  0,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:               uuid.New().String(),
+		MerchantID:       merchantID,
+		Name:             req.Name,
+		Description:      req.Description,
+		Address:          req.Address,
+		Latitude:         req.Latitude,
+		Longitude:        req.Longitude,
+		Phone:            req.Phone,
+		Email:            req.Email,
+		Status:           domain.StatusPendingApproval,
+		OpeningHours:     req.OpeningHours,
+		Timezone:         req.Timezone,
+		HolidayOverrides: req.HolidayOverrides,
+		DeliveryInfo:     req.DeliveryInfo,
+		DeliveryZone:     req.DeliveryZone,
+		Rating:           0.0,
+		ReviewCount:      0,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+
+	if err := validateStoreHours(store); err != nil {
+		return nil, err
+	}
+	if err := validateDeliveryZone(store.DeliveryZone); err != nil {
+		return nil, err
 	}
 
 	// Add categories if provided
@@ -69,15 +99,28 @@ func (s *catalogService) CreateStore(merchantID string, req domain.CreateStoreRe
 		return nil, fmt.Errorf("failed to create store: %w", err)
 	}
 
+	store.IsOpen = isStoreOpen(store, time.Now())
 	return store, nil
 }
 
 func (s *catalogService) GetStore(storeID string) (*domain.Store, error) {
-	return s.storeRepo.GetByID(storeID)
+	store, err := s.storeRepo.GetByID(storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	store.IsOpen = isStoreOpen(store, time.Now())
+	return store, nil
 }
 
 func (s *catalogService) GetMerchantStore(merchantID string) (*domain.Store, error) {
-	return s.storeRepo.GetByMerchantID(merchantID)
+	store, err := s.storeRepo.GetByMerchantID(merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	store.IsOpen = isStoreOpen(store, time.Now())
+	return store, nil
 }
 
 func (s *catalogService) UpdateStore(storeID string, merchantID string, updates map[string]interface{}) (*domain.Store, error) {
@@ -99,7 +142,15 @@ func (s *catalogService) UpdateStore(storeID string, merchantID string, updates
 		store.Description = description
 	}
 	if status, ok := updates["status"].(string); ok {
-		store.Status = domain.StoreStatus(status)
+		if !store.IsApproved() {
+			return nil, domain.ErrStoreNotApproved
+		}
+		switch domain.StoreStatus(status) {
+		case domain.StatusOpen, domain.StatusClosed, domain.StatusPaused:
+			store.Status = domain.StoreStatus(status)
+		default:
+			return nil, fmt.Errorf("%w: %s", domain.ErrInvalidStoreStatus, status)
+		}
 	}
 	if phone, ok := updates["phone"].(string); ok {
 		store.Phone = phone
@@ -107,18 +158,88 @@ func (s *catalogService) UpdateStore(storeID string, merchantID string, updates
 	if email, ok := updates["email"].(string); ok {
 		store.Email = email
 	}
+	if timezone, ok := updates["timezone"].(string); ok {
+		store.Timezone = timezone
+	}
+	if hours, ok := updates["opening_hours"]; ok {
+		if err := decodeUpdate(hours, &store.OpeningHours); err != nil {
+			return nil, fmt.Errorf("invalid opening_hours: %w", err)
+		}
+	}
+	if overrides, ok := updates["holiday_overrides"]; ok {
+		store.HolidayOverrides = nil
+		if err := decodeUpdate(overrides, &store.HolidayOverrides); err != nil {
+			return nil, fmt.Errorf("invalid holiday_overrides: %w", err)
+		}
+	}
+	if info, ok := updates["delivery_info"]; ok {
+		if err := decodeUpdate(info, &store.DeliveryInfo); err != nil {
+			return nil, fmt.Errorf("invalid delivery_info: %w", err)
+		}
+	}
+	if zone, ok := updates["delivery_zone"]; ok {
+		store.DeliveryZone = nil
+		if err := decodeUpdate(zone, &store.DeliveryZone); err != nil {
+			return nil, fmt.Errorf("invalid delivery_zone: %w", err)
+		}
+	}
+
+	if err := validateStoreHours(store); err != nil {
+		return nil, err
+	}
+	if err := validateDeliveryZone(store.DeliveryZone); err != nil {
+		return nil, err
+	}
 
+	// Updating a rejected store resubmits it for approval
+	if store.Status == domain.StatusRejected {
+		store.Status = domain.StatusPendingApproval
+		store.RejectionReason = ""
+	}
 	store.UpdatedAt = time.Now()
 
 	if err := s.storeRepo.Update(store); err != nil {
 		return nil, fmt.Errorf("failed to update store: %w", err)
 	}
 
+	store.IsOpen = isStoreOpen(store, time.Now())
 	return store, nil
 }
 
 func (s *catalogService) SearchStores(req domain.StoreSearchRequest) ([]domain.Store, error) {
-	return s.storeRepo.Search(req)
+	if err := validateDietary(req.ExcludeAllergens, req.Dietary); err != nil {
+		return nil, err
+	}
+
+	stores, err := s.storeRepo.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	located := req.Latitude != 0 && req.Longitude != 0
+	customer := geo.Point{Latitude: req.Latitude, Longitude: req.Longitude}
+	deliverable := stores[:0]
+	for i := range stores {
+		if located && !deliversTo(&stores[i], customer) {
+			continue
+		}
+		stores[i].IsOpen = isStoreOpen(&stores[i], now)
+		deliverable = append(deliverable, stores[i])
+	}
+	return deliverable, nil
+}
+
+// DeleteStore soft-deletes a store; its products stay in place for historical orders
+func (s *catalogService) DeleteStore(storeID string) error {
+	if _, err := s.storeRepo.GetByID(storeID); err != nil {
+		return err
+	}
+	return s.storeRepo.Delete(storeID)
+}
+
+func (s *catalogService) RestoreStore(storeID string) error {
+	return s.storeRepo.Restore(storeID)
 }
 
 // Product management
@@ -139,29 +260,49 @@ func (s *catalogService) CreateProduct(storeID string, merchantID string, req do
 		return nil, errors.New("category not found")
 	}
 
+	if err := validateAvailability(req.Availability); err != nil {
+		return nil, err
+	}
+	if err := validateDietary(req.Allergens, req.DietaryTags); err != nil {
+		return nil, err
+	}
+
 	product := &domain.Product{
-		ID:          uuid.New().String(),
-		StoreID:     storeID,
-		CategoryID:  req.CategoryID,
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		Image:       req.Image,
-		Status:      domain.ProductStatusAvailable,
-		Nutrition:   req.Nutrition,
-		Tags:        req.Tags,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:            uuid.New().String(),
+		StoreID:       storeID,
+		CategoryID:    req.CategoryID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Price:         req.Price,
+		Image:         req.Image,
+		Status:        domain.ProductStatusAvailable,
+		TrackStock:    req.TrackStock,
+		StockQuantity: req.StockQuantity,
+		Availability:  req.Availability,
+		Nutrition:     req.Nutrition,
+		Tags:          req.Tags,
+		Allergens:     req.Allergens,
+		DietaryTags:   req.DietaryTags,
+		PrepTime:      req.PrepTime,
+		Bulky:         req.Bulky,
+		RequiresCold:  req.RequiresCold,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if product.TrackStock && product.StockQuantity == 0 {
+		product.Status = domain.ProductStatusSoldOut
 	}
 
 	// Add options if provided
 	for _, optionReq := range req.Options {
 		option := domain.ProductOption{
-			ID:        uuid.New().String(),
-			ProductID: product.ID,
-			Name:      optionReq.Name,
-			Type:      optionReq.Type,
-			Required:  optionReq.Required,
+			ID:            uuid.New().String(),
+			ProductID:     product.ID,
+			Name:          optionReq.Name,
+			Type:          optionReq.Type,
+			Required:      optionReq.Required,
+			MinSelections: optionReq.MinSelections,
+			MaxSelections: optionReq.MaxSelections,
 		}
 
 		for _, choiceReq := range optionReq.Options {
@@ -174,6 +315,9 @@ func (s *catalogService) CreateProduct(storeID string, merchantID string, req do
 			option.Options = append(option.Options, choice)
 		}
 
+		if err := validateOptionLimits(&option); err != nil {
+			return nil, err
+		}
 		product.Options = append(product.Options, option)
 	}
 
@@ -185,11 +329,57 @@ func (s *catalogService) CreateProduct(storeID string, merchantID string, req do
 }
 
 func (s *catalogService) GetProduct(productID string) (*domain.Product, error) {
-	return s.productRepo.GetByID(productID)
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	applyPricing(product, time.Now())
+	return product, nil
 }
 
-func (s *catalogService) GetStoreProducts(storeID string, limit, offset int) ([]domain.Product, error) {
-	return s.productRepo.GetByStoreID(storeID, limit, offset)
+// GetStoreProducts lists the store's products; unless includeUnavailable is set,
+// products outside their availability windows right now are left out
+func (s *catalogService) GetStoreProducts(storeID string, limit, offset int, includeUnavailable bool) ([]domain.Product, error) {
+	store, err := s.storeRepo.GetByID(storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if includeUnavailable {
+		products, err := s.productRepo.GetByStoreID(storeID, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		for i := range products {
+			applyPricing(&products[i], now)
+		}
+		return products, nil
+	}
+
+	// Filter before paginating so pages stay full
+	products, err := s.productRepo.GetByStoreID(storeID, -1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]domain.Product, 0, len(products))
+	for i := range products {
+		if isProductAvailableAt(&products[i], store, now) {
+			applyPricing(&products[i], now)
+			available = append(available, products[i])
+		}
+	}
+
+	if offset >= len(available) {
+		return []domain.Product{}, nil
+	}
+	available = available[offset:]
+	if limit > 0 && limit < len(available) {
+		available = available[:limit]
+	}
+	return available, nil
 }
 
 func (s *catalogService) UpdateProduct(productID string, merchantID string, updates map[string]interface{}) (*domain.Product, error) {
@@ -224,6 +414,58 @@ func (s *catalogService) UpdateProduct(productID string, merchantID string, upda
 	if image, ok := updates["image"].(string); ok {
 		product.Image = image
 	}
+	if trackStock, ok := updates["track_stock"].(bool); ok {
+		product.TrackStock = trackStock
+	}
+	if stock, ok := updates["stock_quantity"].(float64); ok {
+		if stock < 0 {
+			return nil, errors.New("stock_quantity cannot be negative")
+		}
+		product.StockQuantity = int(stock)
+	}
+	if prepTime, ok := updates["prep_time"].(float64); ok {
+		if prepTime < 0 {
+			return nil, errors.New("prep_time cannot be negative")
+		}
+		product.PrepTime = int(prepTime)
+	}
+	if availability, ok := updates["availability"]; ok {
+		var windows []domain.AvailabilityWindow
+		if err := decodeUpdate(availability, &windows); err != nil {
+			return nil, fmt.Errorf("invalid availability: %w", err)
+		}
+		if err := validateAvailability(windows); err != nil {
+			return nil, err
+		}
+		product.Availability = windows
+	}
+	if value, ok := updates["allergens"]; ok {
+		var allergens []domain.Allergen
+		if err := decodeUpdate(value, &allergens); err != nil {
+			return nil, fmt.Errorf("invalid allergens: %w", err)
+		}
+		if err := validateDietary(allergens, nil); err != nil {
+			return nil, err
+		}
+		product.Allergens = allergens
+	}
+	if value, ok := updates["dietary_tags"]; ok {
+		var tags []domain.DietaryTag
+		if err := decodeUpdate(value, &tags); err != nil {
+			return nil, fmt.Errorf("invalid dietary_tags: %w", err)
+		}
+		if err := validateDietary(nil, tags); err != nil {
+			return nil, err
+		}
+		product.DietaryTags = tags
+	}
+	if product.TrackStock {
+		if product.StockQuantity == 0 {
+			product.Status = domain.ProductStatusSoldOut
+		} else if product.Status == domain.ProductStatusSoldOut {
+			product.Status = domain.ProductStatusAvailable
+		}
+	}
 
 	product.UpdatedAt = time.Now()
 
@@ -231,6 +473,7 @@ func (s *catalogService) UpdateProduct(productID string, merchantID string, upda
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
 
+	applyPricing(product, time.Now())
 	return product, nil
 }
 
@@ -253,8 +496,49 @@ func (s *catalogService) DeleteProduct(productID string, merchantID string) erro
 	return s.productRepo.Delete(productID)
 }
 
+func (s *catalogService) RestoreProduct(productID string) error {
+	return s.productRepo.Restore(productID)
+}
+
 func (s *catalogService) SearchProducts(query string, storeID string, limit, offset int) ([]domain.Product, error) {
-	return s.productRepo.Search(query, storeID, limit, offset)
+	products, err := s.productRepo.Search(query, storeID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range products {
+		applyPricing(&products[i], now)
+	}
+	return products, nil
+}
+
+// SearchProductsRanked searches available products across stores, best matches first
+func (s *catalogService) SearchProductsRanked(req domain.ProductSearchRequest) ([]domain.ProductSearchResult, error) {
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" && req.CategoryID == "" && len(req.Dietary) == 0 {
+		return nil, errors.New("search query, category or dietary filter is required")
+	}
+	if err := validateDietary(req.ExcludeAllergens, req.Dietary); err != nil {
+		return nil, err
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	results, err := s.productRepo.RankedSearch(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range results {
+		applyPricing(&results[i].Product, now)
+	}
+	return results, nil
 }
 
 // Category management
@@ -293,6 +577,36 @@ func (s *catalogService) GetCategories() ([]domain.Category, error) {
 	return s.categoryRepo.GetAll()
 }
 
+// GetCategoryTree returns the root categories with all descendants nested in Children
+func (s *catalogService) GetCategoryTree() ([]domain.Category, error) {
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	// GetAll is ordered by sort_order, so children keep that order within each parent
+	children := make(map[string][]domain.Category)
+	var roots []domain.Category
+	for _, category := range categories {
+		category.Children = nil
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	var attach func(nodes []domain.Category) []domain.Category
+	attach = func(nodes []domain.Category) []domain.Category {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+
+	return attach(roots), nil
+}
+
 func (s *catalogService) UpdateCategory(categoryID string, updates map[string]interface{}) (*domain.Category, error) {
 	category, err := s.categoryRepo.GetByID(categoryID)
 	if err != nil {
@@ -312,6 +626,19 @@ func (s *catalogService) UpdateCategory(categoryID string, updates map[string]in
 	if sortOrder, ok := updates["sort_order"].(int); ok {
 		category.SortOrder = sortOrder
 	}
+	if parentID, ok := updates["parent_id"]; ok {
+		switch parentID := parentID.(type) {
+		case nil:
+			category.ParentID = nil
+		case string:
+			if err := s.checkCategoryParent(categoryID, parentID); err != nil {
+				return nil, err
+			}
+			category.ParentID = &parentID
+		default:
+			return nil, errors.New("parent_id must be a string or null")
+		}
+	}
 
 	category.UpdatedAt = time.Now()
 
@@ -322,6 +649,24 @@ func (s *catalogService) UpdateCategory(categoryID string, updates map[string]in
 	return category, nil
 }
 
+// checkCategoryParent rejects parents that don't exist or would create a cycle
+func (s *catalogService) checkCategoryParent(categoryID, parentID string) error {
+	if _, err := s.categoryRepo.GetByID(parentID); err != nil {
+		return errors.New("parent category not found")
+	}
+
+	subtree, err := s.categoryRepo.GetSubtreeIDs(categoryID)
+	if err != nil {
+		return err
+	}
+	for _, id := range subtree {
+		if id == parentID {
+			return domain.ErrCategoryCycle
+		}
+	}
+	return nil
+}
+
 func (s *catalogService) DeleteCategory(categoryID string) error {
 	// Check if category has children
 	children, err := s.categoryRepo.GetByParentID(&categoryID)
@@ -329,17 +674,21 @@ func (s *catalogService) DeleteCategory(categoryID string) error {
 		return errors.New("cannot delete category with subcategories")
 	}
 
-	// Check if category has products
+	// Check if category has products; soft-deleted products don't count
 	products, err := s.productRepo.GetByCategoryID(categoryID, 1, 0)
 	if err == nil && len(products) > 0 {
-		return errors.New("cannot delete category with products")
+		return errors.New("cannot delete category with active products")
 	}
 
 	return s.categoryRepo.Delete(categoryID)
 }
 
+func (s *catalogService) RestoreCategory(categoryID string) error {
+	return s.categoryRepo.Restore(categoryID)
+}
+
 // Order validation (for Order Service)
-func (s *catalogService) ValidateOrderItems(storeID string, items []domain.OrderItem) (*domain.OrderValidation, error) {
+func (s *catalogService) ValidateOrderItems(storeID string, req domain.ValidateOrderRequest) (*domain.OrderValidation, error) {
 	var validatedItems []domain.ValidatedOrderItem
 	var totalAmount float64
 	var errors []string
@@ -353,44 +702,90 @@ func (s *catalogService) ValidateOrderItems(storeID string, items []domain.Order
 		}, nil
 	}
 
-	if store.Status != domain.StatusOpen {
+	now := time.Now()
+	if !isStoreOpen(store, now) {
 		return &domain.OrderValidation{
 			Valid:  false,
 			Errors: []string{"Store is currently closed"},
 		}, nil
 	}
 
-	for _, item := range items {
+	if req.DeliveryLocation != nil && !deliversTo(store, *req.DeliveryLocation) {
+		return &domain.OrderValidation{
+			Valid:  false,
+			Errors: []string{"Delivery address is outside the store's delivery area"},
+		}, nil
+	}
+
+	priceChanged := false
+	hasLargeItems, hasColdItems := false, false
+	prepTime := store.DeliveryInfo.PrepTime
+	for _, item := range req.Items {
+		validatedItem := domain.ValidatedOrderItem{
+			ProductID:     item.ProductID,
+			Quantity:      item.Quantity,
+			ExpectedPrice: item.UnitPrice,
+		}
+
 		product, err := s.productRepo.GetByID(item.ProductID)
 		if err != nil {
+			validatedItem.Issue = domain.ItemIssueNotFound
+			validatedItems = append(validatedItems, validatedItem)
 			errors = append(errors, fmt.Sprintf("Product %s not found", item.ProductID))
 			continue
 		}
 
+		validatedItem.Name = product.Name
 		if product.StoreID != storeID {
+			validatedItem.Issue = domain.ItemIssueWrongStore
+			validatedItems = append(validatedItems, validatedItem)
 			errors = append(errors, fmt.Sprintf("Product %s does not belong to this store", item.ProductID))
 			continue
 		}
 
 		available := product.Status == domain.ProductStatusAvailable
-		subtotal := product.Price * float64(item.Quantity)
-
-		validatedItem := domain.ValidatedOrderItem{
-			ProductID: item.ProductID,
-			Name:      product.Name,
-			Price:     product.Price,
-			Quantity:  item.Quantity,
-			Available: available,
-			Subtotal:  subtotal,
+		inWindow := isProductAvailableAt(product, store, now)
+		inStock := !product.TrackStock || item.Quantity <= product.StockQuantity
+		optionsPrice, optionErr := validateSelectedOptions(product, item.Options)
+		price := effectivePrice(product, now) + optionsPrice
+
+		validatedItem.Exists = true
+		validatedItem.Available = available && inWindow && inStock
+		validatedItem.OptionsValid = optionErr == nil
+		validatedItem.Price = price
+		validatedItem.OriginalPrice = product.Price
+		validatedItem.OptionsPrice = optionsPrice
+		validatedItem.Subtotal = price * float64(item.Quantity)
+		validatedItem.PriceChanged = item.UnitPrice > 0 && math.Abs(item.UnitPrice-price) >= 0.005
+
+		switch {
+		case !available:
+			validatedItem.Issue = domain.ItemIssueUnavailable
+			errors = append(errors, fmt.Sprintf("Product %s is not available", product.Name))
+		case !inWindow:
+			validatedItem.Issue = domain.ItemIssueOutsideHours
+			errors = append(errors, fmt.Sprintf("Product %s is not available at this time", product.Name))
+		case !inStock:
+			validatedItem.Issue = domain.ItemIssueOutOfStock
+			errors = append(errors, fmt.Sprintf("Only %d of %s left in stock", product.StockQuantity, product.Name))
+		case optionErr != nil:
+			validatedItem.Issue = domain.ItemIssueInvalidOptions
+			errors = append(errors, fmt.Sprintf("Invalid options for %s: %v", product.Name, optionErr))
+		default:
+			totalAmount += validatedItem.Subtotal
+			// Lines are prepared in parallel, so the slowest one sets the pace
+			if product.PrepTime > prepTime {
+				prepTime = product.PrepTime
+			}
+			hasLargeItems = hasLargeItems || product.Bulky
+			hasColdItems = hasColdItems || product.RequiresCold
+			if validatedItem.PriceChanged {
+				priceChanged = true
+				errors = append(errors, fmt.Sprintf("Price of %s changed from $%.2f to $%.2f", product.Name, item.UnitPrice, price))
+			}
 		}
 
 		validatedItems = append(validatedItems, validatedItem)
-
-		if available {
-			totalAmount += subtotal
-		} else {
-			errors = append(errors, fmt.Sprintf("Product %s is not available", product.Name))
-		}
 	}
 
 	// Check minimum order amount
@@ -399,9 +794,49 @@ func (s *catalogService) ValidateOrderItems(storeID string, items []domain.Order
 	}
 
 	return &domain.OrderValidation{
-		Valid:       len(errors) == 0,
-		Items:       validatedItems,
-		TotalAmount: totalAmount,
-		Errors:      errors,
+		Valid:         len(errors) == 0,
+		Items:         validatedItems,
+		TotalAmount:   totalAmount,
+		PriceChanged:  priceChanged,
+		PrepTime:      prepTime,
+		HasLargeItems: hasLargeItems,
+		HasColdItems:  hasColdItems,
+		Errors:        errors,
 	}, nil
 }
+
+// DecrementStock reserves stock for a confirmed order; it fails without
+// changing anything if any tracked product has too little stock, and
+// reserves an order's stock only once
+func (s *catalogService) DecrementStock(storeID string, req domain.StockRequest) error {
+	if err := validateStockItems(req.Items); err != nil {
+		return err
+	}
+	return s.productRepo.DecrementStock(storeID, req.OrderID, req.Items)
+}
+
+// RestoreStock returns stock reserved by an order that was cancelled, once
+func (s *catalogService) RestoreStock(storeID string, req domain.StockRequest) error {
+	if err := validateStockItems(req.Items); err != nil {
+		return err
+	}
+	return s.productRepo.RestoreStock(storeID, req.OrderID, req.Items)
+}
+
+func validateStockItems(items []domain.OrderItem) error {
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("invalid quantity for product %s", item.ProductID)
+		}
+	}
+	return nil
+}
+
+// decodeUpdate converts a decoded JSON update value into a typed field
+func decodeUpdate(value interface{}, dest interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
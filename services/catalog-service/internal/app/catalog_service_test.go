@@ -0,0 +1,194 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+var errNotFound = errors.New("record not found")
+
+// fakeStoreRepo keeps stores in memory; Search returns every store and leaves
+// filtering to the service
+type fakeStoreRepo struct {
+	domain.StoreRepository
+	stores []domain.Store
+}
+
+func (r *fakeStoreRepo) GetByID(id string) (*domain.Store, error) {
+	for _, store := range r.stores {
+		if store.ID == id {
+			return &store, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (r *fakeStoreRepo) GetByMerchantID(merchantID string) (*domain.Store, error) {
+	for _, store := range r.stores {
+		if store.MerchantID == merchantID {
+			return &store, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (r *fakeStoreRepo) Create(store *domain.Store) error {
+	r.stores = append(r.stores, *store)
+	return nil
+}
+
+func (r *fakeStoreRepo) Update(store *domain.Store) error {
+	for i := range r.stores {
+		if r.stores[i].ID == store.ID {
+			r.stores[i] = *store
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (r *fakeStoreRepo) Search(req domain.StoreSearchRequest) ([]domain.Store, error) {
+	return append([]domain.Store(nil), r.stores...), nil
+}
+
+func (r *fakeProductRepo) GetByID(id string) (*domain.Product, error) {
+	for _, product := range r.products {
+		if product.ID == id && !product.DeletedAt.Valid {
+			return &product, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (r *fakeProductRepo) GetByStoreID(storeID string, limit, offset int) ([]domain.Product, error) {
+	var products []domain.Product
+	for _, product := range r.products {
+		if product.StoreID == storeID && !product.DeletedAt.Valid {
+			products = append(products, product)
+		}
+	}
+	if offset >= len(products) {
+		return nil, nil
+	}
+	products = products[offset:]
+	if limit >= 0 && limit < len(products) {
+		products = products[:limit]
+	}
+	return products, nil
+}
+
+func (r *fakeProductRepo) GetByCategoryID(categoryID string, limit, offset int) ([]domain.Product, error) {
+	var products []domain.Product
+	for _, product := range r.products {
+		if product.CategoryID == categoryID && !product.DeletedAt.Valid {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (r *fakeProductRepo) Delete(id string) error {
+	for i := range r.products {
+		if r.products[i].ID == id {
+			r.products[i].DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (r *fakeProductRepo) Restore(id string) error {
+	for i := range r.products {
+		if r.products[i].ID == id && r.products[i].DeletedAt.Valid {
+			r.products[i].DeletedAt = gorm.DeletedAt{}
+			return nil
+		}
+	}
+	return domain.ErrNotDeleted
+}
+
+func (r *fakeProductRepo) CreateBatch(products []domain.Product) error {
+	r.calls++
+	r.products = append(r.products, products...)
+	return nil
+}
+
+func (r *fakeProductRepo) Update(product *domain.Product) error {
+	for i := range r.products {
+		if r.products[i].ID == product.ID {
+			r.products[i] = *product
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+type fakeCategoryRepo struct {
+	domain.CategoryRepository
+	categories []domain.Category
+}
+
+func (r *fakeCategoryRepo) GetByID(id string) (*domain.Category, error) {
+	for _, category := range r.categories {
+		if category.ID == id && !category.DeletedAt.Valid {
+			return &category, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (r *fakeCategoryRepo) GetAll() ([]domain.Category, error) {
+	return append([]domain.Category(nil), r.categories...), nil
+}
+
+func (r *fakeCategoryRepo) GetByParentID(parentID *string) ([]domain.Category, error) {
+	var children []domain.Category
+	for _, category := range r.categories {
+		if category.ParentID != nil && parentID != nil && *category.ParentID == *parentID && !category.DeletedAt.Valid {
+			children = append(children, category)
+		}
+	}
+	return children, nil
+}
+
+func (r *fakeCategoryRepo) GetSubtreeIDs(id string) ([]string, error) {
+	ids := []string{id}
+	for i := 0; i < len(ids); i++ {
+		children, _ := r.GetByParentID(&ids[i])
+		for _, child := range children {
+			ids = append(ids, child.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeCategoryRepo) Update(category *domain.Category) error {
+	for i := range r.categories {
+		if r.categories[i].ID == category.ID {
+			r.categories[i] = *category
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (r *fakeCategoryRepo) Delete(id string) error {
+	for i := range r.categories {
+		if r.categories[i].ID == id {
+			r.categories[i].DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+// newTestCatalogService wires the service to in-memory stores and products
+func newTestCatalogService(stores []domain.Store, products []domain.Product) (domain.CatalogService, *fakeStoreRepo, *fakeProductRepo) {
+	storeRepo := &fakeStoreRepo{stores: stores}
+	productRepo := &fakeProductRepo{products: products}
+	return NewCatalogService(storeRepo, productRepo, nil, nil, nil, nil, nil, nil), storeRepo, productRepo
+}
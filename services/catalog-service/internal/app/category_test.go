@@ -0,0 +1,93 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func categoryTree() *fakeCategoryRepo {
+	food, pizza, drinks := "food", "pizza", "drinks"
+	return &fakeCategoryRepo{categories: []domain.Category{
+		{ID: food, Name: "Food", SortOrder: 1},
+		{ID: drinks, Name: "Drinks", SortOrder: 2},
+		{ID: pizza, Name: "Pizza", ParentID: &food, SortOrder: 3},
+		{ID: "neapolitan", Name: "Neapolitan", ParentID: &pizza, SortOrder: 4},
+		{ID: "juice", Name: "Juice", ParentID: &drinks, SortOrder: 5},
+	}}
+}
+
+func TestGetCategoryTree(t *testing.T) {
+	svc := NewCatalogService(nil, nil, nil, categoryTree(), nil, nil, nil, nil)
+
+	roots, err := svc.GetCategoryTree()
+	if err != nil {
+		t.Fatalf("GetCategoryTree: %v", err)
+	}
+
+	if len(roots) != 2 || roots[0].ID != "food" || roots[1].ID != "drinks" {
+		t.Fatalf("roots = %+v, want food and drinks", roots)
+	}
+	food := roots[0]
+	if len(food.Children) != 1 || food.Children[0].ID != "pizza" {
+		t.Fatalf("food children = %+v, want pizza", food.Children)
+	}
+	if grandchildren := food.Children[0].Children; len(grandchildren) != 1 || grandchildren[0].ID != "neapolitan" {
+		t.Fatalf("pizza children = %+v, want neapolitan", grandchildren)
+	}
+	if len(roots[1].Children) != 1 || roots[1].Children[0].ID != "juice" {
+		t.Fatalf("drinks children = %+v, want juice", roots[1].Children)
+	}
+}
+
+func TestUpdateCategoryParent(t *testing.T) {
+	tests := []struct {
+		name       string
+		category   string
+		parent     interface{}
+		wantErr    bool
+		wantCycle  bool
+		wantParent string
+	}{
+		{name: "move under another branch", category: "pizza", parent: "drinks", wantParent: "drinks"},
+		{name: "move to the root", category: "pizza", parent: nil},
+		{name: "own parent", category: "pizza", parent: "pizza", wantErr: true, wantCycle: true},
+		{name: "under its child", category: "food", parent: "pizza", wantErr: true, wantCycle: true},
+		{name: "under its grandchild", category: "food", parent: "neapolitan", wantErr: true, wantCycle: true},
+		{name: "unknown parent", category: "pizza", parent: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := categoryTree()
+			svc := NewCatalogService(nil, nil, nil, repo, nil, nil, nil, nil)
+
+			category, err := svc.UpdateCategory(tt.category, map[string]interface{}{"parent_id": tt.parent})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				if errors.Is(err, domain.ErrCategoryCycle) != tt.wantCycle {
+					t.Fatalf("cycle error = %v, want %v: %v", !tt.wantCycle, tt.wantCycle, err)
+				}
+				stored, _ := repo.GetByID(tt.category)
+				if stored.ParentID != nil && *stored.ParentID == tt.parent {
+					t.Fatalf("rejected parent was saved")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateCategory: %v", err)
+			}
+
+			got := ""
+			if category.ParentID != nil {
+				got = *category.ParentID
+			}
+			if got != tt.wantParent {
+				t.Fatalf("parent = %q, want %q", got, tt.wantParent)
+			}
+		})
+	}
+}
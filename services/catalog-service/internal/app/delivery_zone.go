@@ -0,0 +1,37 @@
+package app
+
+import (
+	"fmt"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+	"glovo-backend/shared/geo"
+)
+
+// deliversTo reports whether location is inside the store's delivery area:
+// its zone polygon when it has one, otherwise its delivery radius. A store
+// with neither delivers everywhere.
+func deliversTo(store *domain.Store, location geo.Point) bool {
+	if len(store.DeliveryZone) > 0 {
+		return geo.InPolygon(location, store.DeliveryZone)
+	}
+	if store.DeliveryInfo.DeliveryRadius > 0 {
+		origin := geo.Point{Latitude: store.Latitude, Longitude: store.Longitude}
+		return geo.DistanceKm(origin, location) <= store.DeliveryInfo.DeliveryRadius
+	}
+	return true
+}
+
+func validateDeliveryZone(zone []geo.Point) error {
+	if len(zone) == 0 {
+		return nil
+	}
+	if len(zone) < 3 {
+		return fmt.Errorf("%w: a polygon needs at least three points", domain.ErrInvalidDeliveryZone)
+	}
+	for _, point := range zone {
+		if point.Latitude < -90 || point.Latitude > 90 || point.Longitude < -180 || point.Longitude > 180 {
+			return fmt.Errorf("%w: (%g, %g) is not a valid coordinate", domain.ErrInvalidDeliveryZone, point.Latitude, point.Longitude)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+	"glovo-backend/shared/geo"
+)
+
+// Roughly central Madrid; the stores below sit inside it
+var madridZone = []geo.Point{
+	{Latitude: 40.40, Longitude: -3.73},
+	{Latitude: 40.40, Longitude: -3.67},
+	{Latitude: 40.44, Longitude: -3.67},
+	{Latitude: 40.44, Longitude: -3.73},
+}
+
+var (
+	inZone      = geo.Point{Latitude: 40.42, Longitude: -3.70}
+	outsideZone = geo.Point{Latitude: 40.48, Longitude: -3.70} // about 6.7 km north of inZone
+)
+
+func TestDeliversTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		store    domain.Store
+		location geo.Point
+		want     bool
+	}{
+		{name: "inside the zone", store: domain.Store{DeliveryZone: madridZone}, location: inZone, want: true},
+		{name: "outside the zone", store: domain.Store{DeliveryZone: madridZone}, location: outsideZone, want: false},
+		{
+			name:     "zone wins over radius",
+			store:    domain.Store{DeliveryZone: madridZone, Latitude: 40.42, Longitude: -3.70, DeliveryInfo: domain.DeliveryInfo{DeliveryRadius: 50}},
+			location: outsideZone,
+			want:     false,
+		},
+		{name: "within radius", store: domain.Store{Latitude: 40.42, Longitude: -3.70, DeliveryInfo: domain.DeliveryInfo{DeliveryRadius: 10}}, location: outsideZone, want: true},
+		{name: "beyond radius", store: domain.Store{Latitude: 40.42, Longitude: -3.70, DeliveryInfo: domain.DeliveryInfo{DeliveryRadius: 5}}, location: outsideZone, want: false},
+		{name: "no area delivers everywhere", store: domain.Store{}, location: outsideZone, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deliversTo(&tt.store, tt.location); got != tt.want {
+				t.Fatalf("deliversTo = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDeliveryZone(t *testing.T) {
+	tests := []struct {
+		name    string
+		zone    []geo.Point
+		wantErr bool
+	}{
+		{name: "no zone"},
+		{name: "polygon", zone: madridZone},
+		{name: "two points", zone: madridZone[:2], wantErr: true},
+		{name: "invalid latitude", zone: []geo.Point{{Latitude: 91}, {Latitude: 0}, {Latitude: 1, Longitude: 1}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDeliveryZone(tt.zone)
+			if tt.wantErr != errors.Is(err, domain.ErrInvalidDeliveryZone) {
+				t.Fatalf("error = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeliveryZoneGatesOrders(t *testing.T) {
+	stores := []domain.Store{
+		{ID: "zoned", Status: domain.StatusOpen, DeliveryZone: madridZone},
+		{ID: "everywhere", Status: domain.StatusOpen},
+	}
+	products := []domain.Product{{ID: "tortilla", StoreID: "zoned", Name: "Tortilla", Price: 6, Status: domain.ProductStatusAvailable}}
+	svc, _, _ := newTestCatalogService(stores, products)
+
+	tests := []struct {
+		name       string
+		location   geo.Point
+		wantValid  bool
+		wantStores int
+	}{
+		{name: "in-zone customer", location: inZone, wantValid: true, wantStores: 2},
+		{name: "out-of-zone customer", location: outsideZone, wantValid: false, wantStores: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := tt.location
+			validation, err := svc.ValidateOrderItems("zoned", domain.ValidateOrderRequest{
+				Items:            []domain.OrderItem{{ProductID: "tortilla", Quantity: 1}},
+				DeliveryLocation: &location,
+			})
+			if err != nil {
+				t.Fatalf("ValidateOrderItems: %v", err)
+			}
+			if validation.Valid != tt.wantValid {
+				t.Fatalf("valid = %v, want %v (errors %v)", validation.Valid, tt.wantValid, validation.Errors)
+			}
+
+			found, err := svc.SearchStores(domain.StoreSearchRequest{Latitude: location.Latitude, Longitude: location.Longitude})
+			if err != nil {
+				t.Fatalf("SearchStores: %v", err)
+			}
+			if len(found) != tt.wantStores {
+				t.Fatalf("found %d stores, want %d", len(found), tt.wantStores)
+			}
+		})
+	}
+
+	// Without a delivery location the area isn't checked
+	validation, err := svc.ValidateOrderItems("zoned", domain.ValidateOrderRequest{Items: []domain.OrderItem{{ProductID: "tortilla", Quantity: 1}}})
+	if err != nil || !validation.Valid {
+		t.Fatalf("validation without a location = %+v, %v", validation, err)
+	}
+}
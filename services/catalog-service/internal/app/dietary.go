@@ -0,0 +1,40 @@
+package app
+
+import (
+	"fmt"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+// validateDietary rejects allergens and dietary tags outside the supported lists
+func validateDietary(allergens []domain.Allergen, dietary []domain.DietaryTag) error {
+	for _, allergen := range allergens {
+		if !isAllergen(allergen) {
+			return fmt.Errorf("%w: allergen %q", domain.ErrUnknownDietaryTag, allergen)
+		}
+	}
+	for _, tag := range dietary {
+		if !isDietaryTag(tag) {
+			return fmt.Errorf("%w: dietary tag %q", domain.ErrUnknownDietaryTag, tag)
+		}
+	}
+	return nil
+}
+
+func isAllergen(allergen domain.Allergen) bool {
+	for _, a := range domain.Allergens {
+		if a == allergen {
+			return true
+		}
+	}
+	return false
+}
+
+func isDietaryTag(tag domain.DietaryTag) bool {
+	for _, t := range domain.DietaryTags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func TestValidateDietary(t *testing.T) {
+	tests := []struct {
+		name      string
+		allergens []domain.Allergen
+		dietary   []domain.DietaryTag
+		wantErr   bool
+	}{
+		{name: "known", allergens: []domain.Allergen{domain.AllergenNuts, domain.AllergenSesame}, dietary: []domain.DietaryTag{domain.DietaryVegan}},
+		{name: "none"},
+		{name: "unknown allergen", allergens: []domain.Allergen{"contains-nuts"}, wantErr: true},
+		{name: "unknown dietary tag", dietary: []domain.DietaryTag{"Vegan"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDietary(tt.allergens, tt.dietary)
+			if tt.wantErr != errors.Is(err, domain.ErrUnknownDietaryTag) {
+				t.Fatalf("error = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDietaryTagsOnProducts(t *testing.T) {
+	stores := []domain.Store{{ID: "store-1", MerchantID: "merchant-1"}}
+	products := []domain.Product{{ID: "curry", StoreID: "store-1", Name: "Curry"}}
+	svc, _, productRepo := newTestCatalogService(stores, products)
+
+	product, err := svc.UpdateProduct("curry", "merchant-1", map[string]interface{}{
+		"allergens":    []interface{}{"nuts"},
+		"dietary_tags": []interface{}{"vegan", "gluten_free"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+	saved := productRepo.products[0]
+	if len(product.Allergens) != 1 || len(saved.DietaryTags) != 2 || saved.DietaryTags[0] != domain.DietaryVegan {
+		t.Fatalf("saved tags = %v / %v", saved.Allergens, saved.DietaryTags)
+	}
+
+	if _, err := svc.UpdateProduct("curry", "merchant-1", map[string]interface{}{"dietary_tags": []interface{}{"paleo"}}); !errors.Is(err, domain.ErrUnknownDietaryTag) {
+		t.Fatalf("expected %v, got %v", domain.ErrUnknownDietaryTag, err)
+	}
+	if _, err := svc.SearchStores(domain.StoreSearchRequest{ExcludeAllergens: []domain.Allergen{"gluten-free"}}); !errors.Is(err, domain.ErrUnknownDietaryTag) {
+		t.Fatalf("expected %v, got %v", domain.ErrUnknownDietaryTag, err)
+	}
+}
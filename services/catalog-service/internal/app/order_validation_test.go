@@ -0,0 +1,141 @@
+package app
+
+import (
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func TestValidateOrderItemsDetail(t *testing.T) {
+	stores := []domain.Store{{ID: "store-1", Status: domain.StatusOpen}}
+	products := []domain.Product{
+		{ID: "burger", StoreID: "store-1", Name: "Burger", Price: 10, Status: domain.ProductStatusAvailable},
+		{ID: "fries", StoreID: "store-1", Name: "Fries", Price: 3, Status: domain.ProductStatusAvailable, TrackStock: true, StockQuantity: 1},
+		{ID: "shake", StoreID: "store-1", Name: "Shake", Price: 5, Status: domain.ProductStatusUnavailable},
+		{ID: "sushi", StoreID: "store-2", Name: "Sushi", Price: 15, Status: domain.ProductStatusAvailable},
+	}
+
+	tests := []struct {
+		name             string
+		item             domain.OrderItem
+		wantValid        bool
+		wantExists       bool
+		wantAvailable    bool
+		wantIssue        domain.ItemIssue
+		wantPrice        float64
+		wantPriceChanged bool
+		wantTotal        float64
+	}{
+		{name: "orderable", item: domain.OrderItem{ProductID: "burger", Quantity: 2, UnitPrice: 10}, wantValid: true, wantExists: true, wantAvailable: true, wantPrice: 10, wantTotal: 20},
+		{name: "out of stock", item: domain.OrderItem{ProductID: "fries", Quantity: 2}, wantExists: true, wantIssue: domain.ItemIssueOutOfStock, wantPrice: 3},
+		{name: "removed", item: domain.OrderItem{ProductID: "deleted", Quantity: 1}, wantIssue: domain.ItemIssueNotFound},
+		{name: "unavailable", item: domain.OrderItem{ProductID: "shake", Quantity: 1}, wantExists: true, wantIssue: domain.ItemIssueUnavailable, wantPrice: 5},
+		{name: "other store", item: domain.OrderItem{ProductID: "sushi", Quantity: 1}, wantIssue: domain.ItemIssueWrongStore},
+		{name: "price changed", item: domain.OrderItem{ProductID: "burger", Quantity: 1, UnitPrice: 8}, wantExists: true, wantAvailable: true, wantPrice: 10, wantPriceChanged: true, wantTotal: 10},
+		{name: "sub-cent difference", item: domain.OrderItem{ProductID: "burger", Quantity: 1, UnitPrice: 10.001}, wantValid: true, wantExists: true, wantAvailable: true, wantPrice: 10, wantTotal: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _ := newTestCatalogService(stores, products)
+
+			validation, err := svc.ValidateOrderItems("store-1", domain.ValidateOrderRequest{Items: []domain.OrderItem{tt.item}})
+			if err != nil {
+				t.Fatalf("ValidateOrderItems: %v", err)
+			}
+
+			if validation.Valid != tt.wantValid || validation.PriceChanged != tt.wantPriceChanged || validation.TotalAmount != tt.wantTotal {
+				t.Fatalf("validation = valid %v, price changed %v, total %v; want %v, %v, %v (errors %v)",
+					validation.Valid, validation.PriceChanged, validation.TotalAmount, tt.wantValid, tt.wantPriceChanged, tt.wantTotal, validation.Errors)
+			}
+			if !tt.wantValid && len(validation.Errors) == 0 {
+				t.Fatalf("invalid order without errors")
+			}
+
+			if len(validation.Items) != 1 {
+				t.Fatalf("%d item reports, want 1", len(validation.Items))
+			}
+			item := validation.Items[0]
+			if item.ProductID != tt.item.ProductID || item.Exists != tt.wantExists || item.Available != tt.wantAvailable ||
+				item.Issue != tt.wantIssue || item.Price != tt.wantPrice || item.PriceChanged != tt.wantPriceChanged ||
+				item.ExpectedPrice != tt.item.UnitPrice || item.Quantity != tt.item.Quantity {
+				t.Fatalf("item = %+v", item)
+			}
+		})
+	}
+}
+
+func TestValidateOrderItemsTotals(t *testing.T) {
+	stores := []domain.Store{{ID: "store-1", Status: domain.StatusOpen, DeliveryInfo: domain.DeliveryInfo{MinOrderAmount: 15}}}
+	products := []domain.Product{
+		{ID: "burger", StoreID: "store-1", Name: "Burger", Price: 10, Status: domain.ProductStatusAvailable},
+		{ID: "shake", StoreID: "store-1", Name: "Shake", Price: 5, Status: domain.ProductStatusUnavailable},
+	}
+	svc, _, _ := newTestCatalogService(stores, products)
+
+	// Lines that can't be ordered don't count towards the subtotal or minimum
+	validation, err := svc.ValidateOrderItems("store-1", domain.ValidateOrderRequest{Items: []domain.OrderItem{
+		{ProductID: "burger", Quantity: 1},
+		{ProductID: "shake", Quantity: 1},
+		{ProductID: "removed", Quantity: 1},
+	}})
+	if err != nil {
+		t.Fatalf("ValidateOrderItems: %v", err)
+	}
+	if validation.Valid || validation.TotalAmount != 10 || len(validation.Items) != 3 || len(validation.Errors) != 3 {
+		t.Fatalf("validation = %+v, want a subtotal of 10 with the unavailable, removed and minimum order errors", validation)
+	}
+
+	validation, err = svc.ValidateOrderItems("missing", domain.ValidateOrderRequest{Items: []domain.OrderItem{{ProductID: "burger", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("ValidateOrderItems: %v", err)
+	}
+	if validation.Valid {
+		t.Fatalf("order for a missing store is valid")
+	}
+}
+
+func TestValidateOrderItemsPrepTime(t *testing.T) {
+	stores := []domain.Store{{ID: "store-1", Status: domain.StatusOpen, DeliveryInfo: domain.DeliveryInfo{PrepTime: 15}}}
+	products := []domain.Product{
+		{ID: "salad", StoreID: "store-1", Name: "Salad", Price: 8, Status: domain.ProductStatusAvailable},
+		{ID: "pizza", StoreID: "store-1", Name: "Pizza", Price: 12, Status: domain.ProductStatusAvailable, PrepTime: 25},
+		{ID: "ice-cream", StoreID: "store-1", Name: "Ice cream", Price: 4, Status: domain.ProductStatusAvailable, PrepTime: 5, RequiresCold: true},
+		{ID: "water-pack", StoreID: "store-1", Name: "Water pack", Price: 6, Status: domain.ProductStatusAvailable, Bulky: true},
+		{ID: "roast", StoreID: "store-1", Name: "Roast", Price: 30, Status: domain.ProductStatusUnavailable, PrepTime: 90, Bulky: true},
+	}
+
+	tests := []struct {
+		name      string
+		items     []string
+		wantPrep  int
+		wantLarge bool
+		wantCold  bool
+	}{
+		{name: "store default", items: []string{"salad"}, wantPrep: 15},
+		{name: "faster product keeps the store default", items: []string{"ice-cream"}, wantPrep: 15, wantCold: true},
+		{name: "slowest line sets the pace", items: []string{"salad", "pizza", "ice-cream"}, wantPrep: 25, wantCold: true},
+		{name: "bulky item", items: []string{"salad", "water-pack"}, wantPrep: 15, wantLarge: true},
+		{name: "unorderable lines don't count", items: []string{"salad", "roast"}, wantPrep: 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _ := newTestCatalogService(stores, products)
+
+			var items []domain.OrderItem
+			for _, id := range tt.items {
+				items = append(items, domain.OrderItem{ProductID: id, Quantity: 1})
+			}
+			validation, err := svc.ValidateOrderItems("store-1", domain.ValidateOrderRequest{Items: items})
+			if err != nil {
+				t.Fatalf("ValidateOrderItems: %v", err)
+			}
+
+			if validation.PrepTime != tt.wantPrep || validation.HasLargeItems != tt.wantLarge || validation.HasColdItems != tt.wantCold {
+				t.Fatalf("prep %d, large %v, cold %v; want %d, %v, %v",
+					validation.PrepTime, validation.HasLargeItems, validation.HasColdItems, tt.wantPrep, tt.wantLarge, tt.wantCold)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// maxImageSize is the largest product image accepted, in bytes
+const maxImageSize = 5 << 20
+
+// imageExtensions maps accepted image content types to file extensions
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// UploadProductImage stores an image and appends it to the product's images.
+// The content type is sniffed from the data rather than trusted from the client.
+// A product's first image becomes its primary image.
+func (s *catalogService) UploadProductImage(productID string, merchantID string, data io.Reader) (*domain.ProductImage, error) {
+	product, err := s.merchantProduct(productID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(data, maxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(content) > maxImageSize {
+		return nil, fmt.Errorf("image exceeds the %d MB limit", maxImageSize>>20)
+	}
+
+	contentType := http.DetectContentType(content)
+	extension, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported image type %s; use JPEG, PNG or WebP", contentType)
+	}
+
+	images, err := s.imageRepo.GetByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &domain.ProductImage{
+		ID:           uuid.New().String(),
+		ProductID:    productID,
+		ContentType:  contentType,
+		Size:         int64(len(content)),
+		DisplayOrder: len(images),
+		IsPrimary:    len(images) == 0,
+		CreatedAt:    time.Now(),
+	}
+	image.StorageKey = fmt.Sprintf("products/%s/%s%s", productID, image.ID, extension)
+
+	image.URL, err = s.fileStorage.Save(image.StorageKey, contentType, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.imageRepo.Create(image); err != nil {
+		s.fileStorage.Delete(image.StorageKey)
+		return nil, fmt.Errorf("failed to save image: %w", err)
+	}
+
+	if image.IsPrimary {
+		if err := s.syncPrimaryImage(product, image.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	return image, nil
+}
+
+// ReorderProductImages sets the display order; imageIDs must list every image of the product
+func (s *catalogService) ReorderProductImages(productID string, merchantID string, imageIDs []string) ([]domain.ProductImage, error) {
+	if _, err := s.merchantProduct(productID, merchantID); err != nil {
+		return nil, err
+	}
+
+	images, err := s.imageRepo.GetByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(imageIDs) != len(images) {
+		return nil, errors.New("image_ids must list every image of the product exactly once")
+	}
+
+	byID := make(map[string]domain.ProductImage, len(images))
+	for _, image := range images {
+		byID[image.ID] = image
+	}
+
+	ordered := make([]domain.ProductImage, 0, len(imageIDs))
+	for i, id := range imageIDs {
+		image, ok := byID[id]
+		if !ok {
+			return nil, errors.New("image_ids must list every image of the product exactly once")
+		}
+		delete(byID, id)
+		image.DisplayOrder = i
+		ordered = append(ordered, image)
+	}
+
+	if err := s.imageRepo.UpdateAll(ordered); err != nil {
+		return nil, fmt.Errorf("failed to reorder images: %w", err)
+	}
+
+	return ordered, nil
+}
+
+// SetPrimaryProductImage marks one image as primary and clears the flag on the others
+func (s *catalogService) SetPrimaryProductImage(productID string, merchantID string, imageID string) ([]domain.ProductImage, error) {
+	product, err := s.merchantProduct(productID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := s.imageRepo.GetByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	var primaryURL string
+	for i := range images {
+		images[i].IsPrimary = images[i].ID == imageID
+		if images[i].IsPrimary {
+			primaryURL = images[i].URL
+		}
+	}
+	if primaryURL == "" {
+		return nil, errors.New("image not found")
+	}
+
+	if err := s.imageRepo.UpdateAll(images); err != nil {
+		return nil, fmt.Errorf("failed to update images: %w", err)
+	}
+
+	if err := s.syncPrimaryImage(product, primaryURL); err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// DeleteProductImage removes an image; if it was primary, the next image takes over
+func (s *catalogService) DeleteProductImage(productID string, merchantID string, imageID string) error {
+	product, err := s.merchantProduct(productID, merchantID)
+	if err != nil {
+		return err
+	}
+
+	image, err := s.imageRepo.GetByID(imageID)
+	if err != nil || image.ProductID != productID {
+		return errors.New("image not found")
+	}
+
+	if err := s.imageRepo.Delete(imageID); err != nil {
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+	s.fileStorage.Delete(image.StorageKey)
+
+	remaining, err := s.imageRepo.GetByProductID(productID)
+	if err != nil {
+		return err
+	}
+	for i := range remaining {
+		remaining[i].DisplayOrder = i
+		if image.IsPrimary {
+			remaining[i].IsPrimary = i == 0
+		}
+	}
+	if err := s.imageRepo.UpdateAll(remaining); err != nil {
+		return fmt.Errorf("failed to update images: %w", err)
+	}
+
+	if image.IsPrimary {
+		primaryURL := ""
+		if len(remaining) > 0 {
+			primaryURL = remaining[0].URL
+		}
+		return s.syncPrimaryImage(product, primaryURL)
+	}
+	return nil
+}
+
+// merchantProduct loads a product and checks it belongs to the merchant's store
+func (s *catalogService) merchantProduct(productID string, merchantID string) (*domain.Product, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := s.storeRepo.GetByID(product.StoreID)
+	if err != nil {
+		return nil, err
+	}
+
+	if store.MerchantID != merchantID {
+		return nil, errors.New("unauthorized to update this product")
+	}
+	return product, nil
+}
+
+// syncPrimaryImage keeps Product.Image pointing at the primary image
+func (s *catalogService) syncPrimaryImage(product *domain.Product, url string) error {
+	product.Image = url
+	product.Images = nil
+	product.UpdatedAt = time.Now()
+	if err := s.productRepo.Update(product); err != nil {
+		return fmt.Errorf("failed to update product: %w", err)
+	}
+	return nil
+}
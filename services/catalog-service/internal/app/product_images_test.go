@@ -0,0 +1,195 @@
+package app
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+type fakeImageRepo struct {
+	domain.ProductImageRepository
+	images []domain.ProductImage
+}
+
+func (r *fakeImageRepo) Create(image *domain.ProductImage) error {
+	r.images = append(r.images, *image)
+	return nil
+}
+
+func (r *fakeImageRepo) GetByID(id string) (*domain.ProductImage, error) {
+	for _, image := range r.images {
+		if image.ID == id {
+			return &image, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+// GetByProductID returns the images in display order, like the database query
+func (r *fakeImageRepo) GetByProductID(productID string) ([]domain.ProductImage, error) {
+	var images []domain.ProductImage
+	for _, image := range r.images {
+		if image.ProductID == productID {
+			images = append(images, image)
+		}
+	}
+	sort.SliceStable(images, func(i, j int) bool { return images[i].DisplayOrder < images[j].DisplayOrder })
+	return images, nil
+}
+
+func (r *fakeImageRepo) UpdateAll(images []domain.ProductImage) error {
+	for _, updated := range images {
+		for i := range r.images {
+			if r.images[i].ID == updated.ID {
+				r.images[i] = updated
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeImageRepo) Delete(id string) error {
+	for i := range r.images {
+		if r.images[i].ID == id {
+			r.images = append(r.images[:i], r.images[i+1:]...)
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+type fakeFileStorage struct {
+	files map[string][]byte
+}
+
+func (s *fakeFileStorage) Save(key string, contentType string, data io.Reader) (string, error) {
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return "", err
+	}
+	s.files[key] = content
+	return "https://cdn.test/" + key, nil
+}
+
+func (s *fakeFileStorage) Delete(key string) error {
+	delete(s.files, key)
+	return nil
+}
+
+var (
+	pngImage  = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	jpegImage = []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00")
+)
+
+func newTestImageService() (domain.CatalogService, *fakeProductRepo, *fakeImageRepo, *fakeFileStorage) {
+	stores := &fakeStoreRepo{stores: []domain.Store{{ID: "store-1", MerchantID: "merchant-1"}}}
+	products := &fakeProductRepo{products: []domain.Product{{ID: "product-1", StoreID: "store-1"}}}
+	images := &fakeImageRepo{}
+	storage := &fakeFileStorage{files: make(map[string][]byte)}
+	return NewCatalogService(stores, products, images, nil, nil, nil, storage, nil), products, images, storage
+}
+
+func TestProductImages(t *testing.T) {
+	svc, products, images, storage := newTestImageService()
+
+	first, err := svc.UploadProductImage("product-1", "merchant-1", bytes.NewReader(pngImage))
+	if err != nil {
+		t.Fatalf("upload first image: %v", err)
+	}
+	second, err := svc.UploadProductImage("product-1", "merchant-1", bytes.NewReader(jpegImage))
+	if err != nil {
+		t.Fatalf("upload second image: %v", err)
+	}
+
+	if first.ContentType != "image/png" || !strings.HasSuffix(first.StorageKey, ".png") || !bytes.Equal(storage.files[first.StorageKey], pngImage) {
+		t.Fatalf("first image = %+v, stored %d bytes", first, len(storage.files[first.StorageKey]))
+	}
+	if second.ContentType != "image/jpeg" || !strings.HasSuffix(second.StorageKey, ".jpg") {
+		t.Fatalf("second image = %+v", second)
+	}
+	if !first.IsPrimary || first.DisplayOrder != 0 || second.IsPrimary || second.DisplayOrder != 1 {
+		t.Fatalf("first %+v, second %+v: want the first upload primary and shown first", first, second)
+	}
+	if products.products[0].Image != first.URL {
+		t.Fatalf("product image = %q, want the primary image %q", products.products[0].Image, first.URL)
+	}
+
+	ordered, err := svc.ReorderProductImages("product-1", "merchant-1", []string{second.ID, first.ID})
+	if err != nil {
+		t.Fatalf("ReorderProductImages: %v", err)
+	}
+	if ordered[0].ID != second.ID || ordered[0].DisplayOrder != 0 || ordered[1].DisplayOrder != 1 {
+		t.Fatalf("order = %+v, want the second image first", ordered)
+	}
+
+	updated, err := svc.SetPrimaryProductImage("product-1", "merchant-1", second.ID)
+	if err != nil {
+		t.Fatalf("SetPrimaryProductImage: %v", err)
+	}
+	for _, image := range updated {
+		if image.IsPrimary != (image.ID == second.ID) {
+			t.Fatalf("image %s primary = %v", image.ID, image.IsPrimary)
+		}
+	}
+	if products.products[0].Image != second.URL {
+		t.Fatalf("product image = %q, want the new primary %q", products.products[0].Image, second.URL)
+	}
+
+	// Deleting the primary image hands the flag to the next image
+	if err := svc.DeleteProductImage("product-1", "merchant-1", second.ID); err != nil {
+		t.Fatalf("DeleteProductImage: %v", err)
+	}
+	if _, stored := storage.files[second.StorageKey]; stored {
+		t.Fatalf("deleted image is still stored")
+	}
+	if len(images.images) != 1 || !images.images[0].IsPrimary || images.images[0].DisplayOrder != 0 {
+		t.Fatalf("remaining images = %+v, want the first image primary", images.images)
+	}
+	if products.products[0].Image != first.URL {
+		t.Fatalf("product image = %q, want %q", products.products[0].Image, first.URL)
+	}
+}
+
+func TestProductImageValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		merchant string
+		data     []byte
+	}{
+		{name: "not an image", merchant: "merchant-1", data: []byte("%PDF-1.7 not an image")},
+		{name: "too large", merchant: "merchant-1", data: append(append([]byte{}, pngImage...), make([]byte, maxImageSize)...)},
+		{name: "another merchant", merchant: "merchant-2", data: pngImage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, images, storage := newTestImageService()
+
+			if _, err := svc.UploadProductImage("product-1", tt.merchant, bytes.NewReader(tt.data)); err == nil {
+				t.Fatalf("expected the upload to be rejected")
+			}
+			if len(images.images) != 0 || len(storage.files) != 0 {
+				t.Fatalf("rejected upload was stored")
+			}
+		})
+	}
+}
+
+func TestReorderProductImagesRequiresEveryImage(t *testing.T) {
+	svc, _, _, _ := newTestImageService()
+	first, _ := svc.UploadProductImage("product-1", "merchant-1", bytes.NewReader(pngImage))
+	second, _ := svc.UploadProductImage("product-1", "merchant-1", bytes.NewReader(pngImage))
+
+	for _, ids := range [][]string{{first.ID}, {first.ID, first.ID}, {first.ID, "unknown"}} {
+		if _, err := svc.ReorderProductImages("product-1", "merchant-1", ids); err == nil {
+			t.Fatalf("reorder %v: expected an error", ids)
+		}
+	}
+	if _, err := svc.ReorderProductImages("product-1", "merchant-1", []string{second.ID, first.ID}); err != nil {
+		t.Fatalf("ReorderProductImages: %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// importColumns are the CSV columns a product import requires, in any order
+var importColumns = []string{"name", "price", "category", "description", "available"}
+
+// ImportProducts creates products from a CSV with a header row. The category
+// column accepts a category ID or name.
+func (s *catalogService) ImportProducts(storeID string, merchantID string, r io.Reader, mode domain.ImportMode) (*domain.ProductImportReport, error) {
+	if mode == "" {
+		mode = domain.ImportAllOrNothing
+	}
+	if mode != domain.ImportAllOrNothing && mode != domain.ImportBestEffort {
+		return nil, fmt.Errorf("unsupported import mode: %s", mode)
+	}
+
+	store, err := s.storeRepo.GetByID(storeID)
+	if err != nil {
+		return nil, err
+	}
+	if store.MerchantID != merchantID {
+		return nil, errors.New("unauthorized to add products to this store")
+	}
+
+	content, err := io.ReadAll(io.LimitReader(r, domain.MaxProductImportSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(content) > domain.MaxProductImportSize {
+		return nil, fmt.Errorf("%w: the limit is %d MB", domain.ErrImportTooLarge, domain.MaxProductImportSize>>20)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("CSV is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV is missing the %s column", name)
+		}
+	}
+
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	categoryIDs := make(map[string]string)
+	for _, category := range categories {
+		categoryIDs[category.ID] = category.ID
+		categoryIDs[strings.ToLower(category.Name)] = category.ID
+	}
+
+	report := &domain.ProductImportReport{Mode: mode, Total: len(records) - 1}
+	var products []domain.Product
+	var productRows []int
+	for i, record := range records[1:] {
+		row := domain.ProductImportRow{Row: i + 1}
+		field := func(name string) string {
+			if index := columns[name]; index < len(record) {
+				return strings.TrimSpace(record[index])
+			}
+			return ""
+		}
+		row.Name = field("name")
+
+		product, err := importProduct(storeID, field, categoryIDs)
+		if err != nil {
+			row.Error = err.Error()
+			report.Failed++
+		} else {
+			row.ProductID = product.ID
+			products = append(products, *product)
+			productRows = append(productRows, len(report.Rows))
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	if mode == domain.ImportAllOrNothing && report.Failed > 0 {
+		products = nil
+		for _, i := range productRows {
+			report.Rows[i].ProductID = ""
+			report.Rows[i].Error = "not imported: other rows are invalid"
+		}
+		report.Failed = report.Total
+		return report, nil
+	}
+
+	if err := s.productRepo.CreateBatch(products); err != nil {
+		return nil, fmt.Errorf("failed to import products: %w", err)
+	}
+
+	report.Imported = len(products)
+	return report, nil
+}
+
+func importProduct(storeID string, field func(string) string, categoryIDs map[string]string) (*domain.Product, error) {
+	name := field("name")
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	price, err := strconv.ParseFloat(field("price"), 64)
+	if err != nil || price < 0 {
+		return nil, errors.New("price must be a non-negative number")
+	}
+
+	category := field("category")
+	categoryID, ok := categoryIDs[category]
+	if !ok {
+		categoryID, ok = categoryIDs[strings.ToLower(category)]
+	}
+	if !ok {
+		return nil, fmt.Errorf("category %q not found", category)
+	}
+
+	status := domain.ProductStatusAvailable
+	if available := field("available"); available != "" {
+		isAvailable, err := strconv.ParseBool(available)
+		if err != nil {
+			return nil, errors.New("available must be true or false")
+		}
+		if !isAvailable {
+			status = domain.ProductStatusUnavailable
+		}
+	}
+
+	return &domain.Product{
+		ID:          uuid.New().String(),
+		StoreID:     storeID,
+		CategoryID:  categoryID,
+		Name:        name,
+		Description: field("description"),
+		Price:       price,
+		Status:      status,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}, nil
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func TestImportProducts(t *testing.T) {
+	const header = "name,price,category,description,available\n"
+	clean := header +
+		"Margherita,9.50,Pizza,Tomato and mozzarella,true\n" +
+		"Cola,2,drinks-id,,false\n"
+	mixed := header +
+		"Margherita,9.50,pizza,,true\n" +
+		",3,Pizza,,true\n" +
+		"Calzone,abc,Pizza,,true\n" +
+		"Lemonade,2,Cocktails,,true\n"
+
+	tests := []struct {
+		name         string
+		csv          string
+		mode         domain.ImportMode
+		wantErr      bool
+		wantImported int
+		wantFailed   int
+		wantErrors   []string // per row, "" for rows without an error
+	}{
+		{name: "clean import", csv: clean, wantImported: 2, wantErrors: []string{"", ""}},
+		{
+			name:       "all or nothing rejects every row",
+			csv:        mixed,
+			wantFailed: 4,
+			wantErrors: []string{"not imported", "name is required", "price must be", "category \"Cocktails\" not found"},
+		},
+		{
+			name:         "best effort imports the valid rows",
+			csv:          mixed,
+			mode:         domain.ImportBestEffort,
+			wantImported: 1,
+			wantFailed:   3,
+			wantErrors:   []string{"", "name is required", "price must be", "category \"Cocktails\" not found"},
+		},
+		{name: "missing column", csv: "name,price\nCola,2\n", wantErr: true},
+		{name: "unknown mode", csv: clean, mode: "partial", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := &fakeStoreRepo{stores: []domain.Store{{ID: "store-1", MerchantID: "merchant-1"}}}
+			products := &fakeProductRepo{}
+			categories := &fakeCategoryRepo{categories: []domain.Category{{ID: "pizza-id", Name: "Pizza"}, {ID: "drinks-id", Name: "Drinks"}}}
+			svc := NewCatalogService(stores, products, nil, categories, nil, nil, nil, nil)
+
+			report, err := svc.ImportProducts("store-1", "merchant-1", strings.NewReader(tt.csv), tt.mode)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImportProducts: %v", err)
+			}
+
+			if report.Imported != tt.wantImported || report.Failed != tt.wantFailed || report.Total != len(tt.wantErrors) {
+				t.Fatalf("report = %d imported, %d failed of %d; want %d, %d of %d",
+					report.Imported, report.Failed, report.Total, tt.wantImported, tt.wantFailed, len(tt.wantErrors))
+			}
+			if len(products.products) != tt.wantImported {
+				t.Fatalf("%d products saved, want %d", len(products.products), tt.wantImported)
+			}
+			for i, want := range tt.wantErrors {
+				row := report.Rows[i]
+				if row.Row != i+1 {
+					t.Fatalf("row %d numbered %d", i+1, row.Row)
+				}
+				if want == "" && (row.Error != "" || row.ProductID == "") {
+					t.Fatalf("row %d = %+v, want imported", row.Row, row)
+				}
+				if want != "" && (!strings.Contains(row.Error, want) || row.ProductID != "") {
+					t.Fatalf("row %d = %+v, want error containing %q", row.Row, row, want)
+				}
+			}
+		})
+	}
+}
+
+func TestImportProductsFields(t *testing.T) {
+	stores := &fakeStoreRepo{stores: []domain.Store{{ID: "store-1", MerchantID: "merchant-1"}}}
+	products := &fakeProductRepo{}
+	categories := &fakeCategoryRepo{categories: []domain.Category{{ID: "pizza-id", Name: "Pizza"}}}
+	svc := NewCatalogService(stores, products, nil, categories, nil, nil, nil, nil)
+
+	// Columns can come in any order
+	csv := "available,description,category,price,name\nfalse,Folded,PIZZA,11,Calzone\n"
+	if _, err := svc.ImportProducts("store-1", "merchant-1", strings.NewReader(csv), ""); err != nil {
+		t.Fatalf("ImportProducts: %v", err)
+	}
+
+	product := products.products[0]
+	if product.Name != "Calzone" || product.Price != 11 || product.CategoryID != "pizza-id" ||
+		product.Description != "Folded" || product.Status != domain.ProductStatusUnavailable || product.StoreID != "store-1" {
+		t.Fatalf("product = %+v", product)
+	}
+}
+
+func TestImportProductsRejects(t *testing.T) {
+	stores := &fakeStoreRepo{stores: []domain.Store{{ID: "store-1", MerchantID: "merchant-1"}}}
+	products := &fakeProductRepo{}
+	svc := NewCatalogService(stores, products, nil, &fakeCategoryRepo{}, nil, nil, nil, nil)
+
+	if _, err := svc.ImportProducts("store-1", "someone-else", strings.NewReader("name\n"), ""); err == nil {
+		t.Fatalf("expected another merchant's import to be rejected")
+	}
+
+	large := strings.NewReader(strings.Repeat("x", domain.MaxProductImportSize+1))
+	if _, err := svc.ImportProducts("store-1", "merchant-1", large, ""); !errors.Is(err, domain.ErrImportTooLarge) {
+		t.Fatalf("expected %v, got %v", domain.ErrImportTooLarge, err)
+	}
+	if products.calls != 0 {
+		t.Fatalf("rejected imports saved products")
+	}
+}
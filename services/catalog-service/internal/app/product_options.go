@@ -0,0 +1,103 @@
+package app
+
+import (
+	"fmt"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+// selectionLimits returns how many choices of option a customer must and may
+// pick. A required option needs at least one; a single option allows one; a
+// multiple option with no MaxSelections allows all of its choices.
+func selectionLimits(option *domain.ProductOption) (int, int) {
+	min := option.MinSelections
+	if option.Required && min < 1 {
+		min = 1
+	}
+
+	max := option.MaxSelections
+	if option.Type == domain.OptionTypeSingle && (max == 0 || max > 1) {
+		max = 1
+	}
+	if max == 0 || max > len(option.Options) {
+		max = len(option.Options)
+	}
+	return min, max
+}
+
+// validateOptionLimits rejects options whose limits no selection could meet
+func validateOptionLimits(option *domain.ProductOption) error {
+	min, max := selectionLimits(option)
+	if min > max {
+		return fmt.Errorf("%w: %s needs %d selections but allows at most %d", domain.ErrInvalidOptionLimits, option.Name, min, max)
+	}
+	return nil
+}
+
+// validateSelectedOptions checks the selection against the product's options:
+// every option and choice must belong to the product, required options must
+// be chosen and each option's selection count must be within its limits. It
+// returns the per-unit surcharge of the selected choices.
+func validateSelectedOptions(product *domain.Product, selected []domain.SelectedOption) (float64, error) {
+	options := make(map[string]*domain.ProductOption, len(product.Options))
+	for i := range product.Options {
+		options[product.Options[i].ID] = &product.Options[i]
+	}
+
+	surcharge := 0.0
+	counts := make(map[string]int, len(selected))
+	seen := make(map[string]bool, len(selected))
+	for _, selection := range selected {
+		option, ok := options[selection.OptionID]
+		if !ok {
+			return 0, fmt.Errorf("option %s does not belong to this product", selection.OptionID)
+		}
+		if seen[selection.OptionID] {
+			return 0, fmt.Errorf("option %s is selected more than once", option.Name)
+		}
+		seen[selection.OptionID] = true
+
+		chosen := make(map[string]bool, len(selection.ChoiceIDs))
+		for _, choiceID := range selection.ChoiceIDs {
+			choice := findChoice(option, choiceID)
+			if choice == nil {
+				return 0, fmt.Errorf("choice %s is not available for %s", choiceID, option.Name)
+			}
+			if chosen[choiceID] {
+				return 0, fmt.Errorf("%s is chosen more than once for %s", choice.Name, option.Name)
+			}
+			chosen[choiceID] = true
+			surcharge += choice.PriceExtra
+		}
+		counts[option.ID] = len(chosen)
+	}
+
+	for i := range product.Options {
+		option := &product.Options[i]
+		count := counts[option.ID]
+		if count == 0 && !option.Required {
+			continue
+		}
+
+		min, max := selectionLimits(option)
+		switch {
+		case count == 0:
+			return 0, fmt.Errorf("%s is required", option.Name)
+		case count < min:
+			return 0, fmt.Errorf("choose at least %d for %s", min, option.Name)
+		case count > max:
+			return 0, fmt.Errorf("choose at most %d for %s", max, option.Name)
+		}
+	}
+
+	return surcharge, nil
+}
+
+func findChoice(option *domain.ProductOption, choiceID string) *domain.ProductOptionChoice {
+	for i := range option.Options {
+		if option.Options[i].ID == choiceID {
+			return &option.Options[i]
+		}
+	}
+	return nil
+}
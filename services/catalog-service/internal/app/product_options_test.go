@@ -0,0 +1,165 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+// pizzaWithOptions has a required single size and up to two extra toppings
+func pizzaWithOptions() domain.Product {
+	return domain.Product{
+		ID: "pizza", StoreID: "store-1", Name: "Pizza", Price: 10, Status: domain.ProductStatusAvailable,
+		Options: []domain.ProductOption{
+			{
+				ID: "size", Name: "Size", Type: domain.OptionTypeSingle, Required: true,
+				Options: []domain.ProductOptionChoice{{ID: "small", Name: "Small"}, {ID: "large", Name: "Large", PriceExtra: 4}},
+			},
+			{
+				ID: "toppings", Name: "Toppings", Type: domain.OptionTypeMultiple, MaxSelections: 2,
+				Options: []domain.ProductOptionChoice{
+					{ID: "olives", Name: "Olives", PriceExtra: 1},
+					{ID: "ham", Name: "Ham", PriceExtra: 1.5},
+					{ID: "corn", Name: "Corn", PriceExtra: 0.5},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateSelectedOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		selected      []domain.SelectedOption
+		wantSurcharge float64
+		wantErr       string
+	}{
+		{name: "required only", selected: []domain.SelectedOption{{OptionID: "size", ChoiceIDs: []string{"small"}}}},
+		{
+			name: "surcharges add up",
+			selected: []domain.SelectedOption{
+				{OptionID: "size", ChoiceIDs: []string{"large"}},
+				{OptionID: "toppings", ChoiceIDs: []string{"olives", "ham"}},
+			},
+			wantSurcharge: 6.5,
+		},
+		{name: "missing required option", wantErr: "Size is required"},
+		{name: "required option with no choice", selected: []domain.SelectedOption{{OptionID: "size"}}, wantErr: "Size is required"},
+		{name: "two sizes", selected: []domain.SelectedOption{{OptionID: "size", ChoiceIDs: []string{"small", "large"}}}, wantErr: "at most 1"},
+		{
+			name: "over the maximum",
+			selected: []domain.SelectedOption{
+				{OptionID: "size", ChoiceIDs: []string{"small"}},
+				{OptionID: "toppings", ChoiceIDs: []string{"olives", "ham", "corn"}},
+			},
+			wantErr: "at most 2 for Toppings",
+		},
+		{
+			name: "option of another product",
+			selected: []domain.SelectedOption{
+				{OptionID: "size", ChoiceIDs: []string{"small"}},
+				{OptionID: "sauce", ChoiceIDs: []string{"bbq"}},
+			},
+			wantErr: "does not belong",
+		},
+		{name: "choice of another option", selected: []domain.SelectedOption{{OptionID: "size", ChoiceIDs: []string{"ham"}}}, wantErr: "not available"},
+		{name: "repeated choice", selected: []domain.SelectedOption{{OptionID: "size", ChoiceIDs: []string{"small"}}, {OptionID: "toppings", ChoiceIDs: []string{"ham", "ham"}}}, wantErr: "more than once"},
+		{name: "repeated option", selected: []domain.SelectedOption{{OptionID: "size", ChoiceIDs: []string{"small"}}, {OptionID: "size", ChoiceIDs: []string{"large"}}}, wantErr: "more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := pizzaWithOptions()
+			surcharge, err := validateSelectedOptions(&product, tt.selected)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if surcharge != tt.wantSurcharge {
+				t.Fatalf("surcharge = %v, want %v", surcharge, tt.wantSurcharge)
+			}
+		})
+	}
+}
+
+func TestValidateOptionLimits(t *testing.T) {
+	choices := []domain.ProductOptionChoice{{ID: "a"}, {ID: "b"}}
+	tests := []struct {
+		name    string
+		option  domain.ProductOption
+		wantErr bool
+	}{
+		{name: "required single", option: domain.ProductOption{Type: domain.OptionTypeSingle, Required: true, Options: choices}},
+		{name: "minimum within choices", option: domain.ProductOption{Type: domain.OptionTypeMultiple, MinSelections: 2, Options: choices}},
+		{name: "minimum above choices", option: domain.ProductOption{Type: domain.OptionTypeMultiple, MinSelections: 3, Options: choices}, wantErr: true},
+		{name: "minimum above maximum", option: domain.ProductOption{Type: domain.OptionTypeMultiple, MinSelections: 2, MaxSelections: 1, Options: choices}, wantErr: true},
+		{name: "single needing two", option: domain.ProductOption{Type: domain.OptionTypeSingle, MinSelections: 2, Options: choices}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOptionLimits(&tt.option)
+			if tt.wantErr != errors.Is(err, domain.ErrInvalidOptionLimits) {
+				t.Fatalf("error = %v, want %v: %v", err != nil, tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateOrderItemsOptions(t *testing.T) {
+	stores := []domain.Store{{ID: "store-1", Status: domain.StatusOpen}}
+	svc, _, _ := newTestCatalogService(stores, []domain.Product{pizzaWithOptions()})
+
+	validation, err := svc.ValidateOrderItems("store-1", domain.ValidateOrderRequest{Items: []domain.OrderItem{{
+		ProductID: "pizza",
+		Quantity:  2,
+		UnitPrice: 15,
+		Options: []domain.SelectedOption{
+			{OptionID: "size", ChoiceIDs: []string{"large"}},
+			{OptionID: "toppings", ChoiceIDs: []string{"olives"}},
+		},
+	}}})
+	if err != nil {
+		t.Fatalf("ValidateOrderItems: %v", err)
+	}
+	item := validation.Items[0]
+	if !validation.Valid || !item.OptionsValid || item.Price != 15 || item.OptionsPrice != 5 || item.OriginalPrice != 10 || validation.TotalAmount != 30 {
+		t.Fatalf("validation = %+v, item = %+v; want 15 per pizza including 5 of options", validation, item)
+	}
+
+	validation, err = svc.ValidateOrderItems("store-1", domain.ValidateOrderRequest{Items: []domain.OrderItem{{ProductID: "pizza", Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("ValidateOrderItems: %v", err)
+	}
+	item = validation.Items[0]
+	if validation.Valid || item.OptionsValid || item.Issue != domain.ItemIssueInvalidOptions || validation.TotalAmount != 0 {
+		t.Fatalf("validation = %+v, want the missing size rejected", validation)
+	}
+}
+
+func TestCreateProductRejectsImpossibleOptions(t *testing.T) {
+	stores := &fakeStoreRepo{stores: []domain.Store{{ID: "store-1", MerchantID: "merchant-1"}}}
+	categories := &fakeCategoryRepo{categories: []domain.Category{{ID: "pizza"}}}
+	products := &fakeProductRepo{}
+	svc := NewCatalogService(stores, products, nil, categories, nil, nil, nil, nil)
+
+	_, err := svc.CreateProduct("store-1", "merchant-1", domain.CreateProductRequest{
+		CategoryID: "pizza",
+		Name:       "Pizza",
+		Price:      10,
+		Options: []domain.ProductOptionReq{{
+			Name: "Toppings", Type: domain.OptionTypeMultiple, MinSelections: 3,
+			Options: []domain.ProductOptionChoiceReq{{Name: "Olives"}, {Name: "Ham"}},
+		}},
+	})
+	if !errors.Is(err, domain.ErrInvalidOptionLimits) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidOptionLimits, err)
+	}
+}
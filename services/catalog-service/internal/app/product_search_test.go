@@ -0,0 +1,66 @@
+package app
+
+import (
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+// rankedSearchRepo records the request the service passes down
+type rankedSearchRepo struct {
+	fakeProductRepo
+	req     *domain.ProductSearchRequest
+	results []domain.ProductSearchResult
+}
+
+func (r *rankedSearchRepo) RankedSearch(req domain.ProductSearchRequest) ([]domain.ProductSearchResult, error) {
+	r.req = &req
+	return r.results, nil
+}
+
+func TestSearchProductsRanked(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        domain.ProductSearchRequest
+		wantErr    bool
+		wantQuery  string
+		wantLimit  int
+		wantOffset int
+	}{
+		{name: "blank query", req: domain.ProductSearchRequest{Query: "   "}, wantErr: true},
+		{name: "query is trimmed and limit defaulted", req: domain.ProductSearchRequest{Query: " pizza "}, wantQuery: "pizza", wantLimit: 20},
+		{name: "limit is capped", req: domain.ProductSearchRequest{Query: "pizza", Limit: 500, Offset: -3}, wantQuery: "pizza", wantLimit: 20},
+		{name: "page is kept", req: domain.ProductSearchRequest{Query: "pizza", Limit: 5, Offset: 10}, wantQuery: "pizza", wantLimit: 5, wantOffset: 10},
+		{name: "category without query", req: domain.ProductSearchRequest{CategoryID: "pizza"}, wantLimit: 20},
+		{name: "unknown dietary tag", req: domain.ProductSearchRequest{Query: "pizza", Dietary: []domain.DietaryTag{"paleo"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &rankedSearchRepo{results: []domain.ProductSearchResult{{Product: domain.Product{ID: "p", Price: 10}}}}
+			svc := NewCatalogService(nil, repo, nil, nil, nil, nil, nil, nil)
+
+			results, err := svc.SearchProductsRanked(tt.req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				if repo.req != nil {
+					t.Fatalf("searched despite an invalid request")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SearchProductsRanked: %v", err)
+			}
+
+			if repo.req.Query != tt.wantQuery || repo.req.Limit != tt.wantLimit || repo.req.Offset != tt.wantOffset {
+				t.Fatalf("repository got query %q limit %d offset %d, want %q %d %d",
+					repo.req.Query, repo.req.Limit, repo.req.Offset, tt.wantQuery, tt.wantLimit, tt.wantOffset)
+			}
+			if results[0].EffectivePrice != 10 || results[0].OriginalPrice != 10 {
+				t.Fatalf("pricing not applied: %+v", results[0].Product)
+			}
+		})
+	}
+}
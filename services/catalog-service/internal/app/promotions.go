@@ -0,0 +1,110 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// AddProductPromotion schedules a discount on the product
+func (s *catalogService) AddProductPromotion(productID string, merchantID string, req domain.CreatePromotionRequest) (*domain.Product, error) {
+	product, err := s.merchantProduct(productID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion := domain.Promotion{
+		ID:       uuid.New().String(),
+		Type:     req.Type,
+		Value:    req.Value,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+	}
+	if err := validatePromotion(promotion); err != nil {
+		return nil, err
+	}
+
+	product.Promotions = append(product.Promotions, promotion)
+	product.UpdatedAt = time.Now()
+	if err := s.productRepo.Update(product); err != nil {
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
+
+	applyPricing(product, time.Now())
+	return product, nil
+}
+
+func (s *catalogService) RemoveProductPromotion(productID string, merchantID string, promotionID string) (*domain.Product, error) {
+	product, err := s.merchantProduct(productID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	promotions := make([]domain.Promotion, 0, len(product.Promotions))
+	for _, promotion := range product.Promotions {
+		if promotion.ID != promotionID {
+			promotions = append(promotions, promotion)
+		}
+	}
+	if len(promotions) == len(product.Promotions) {
+		return nil, errors.New("promotion not found")
+	}
+
+	product.Promotions = promotions
+	product.UpdatedAt = time.Now()
+	if err := s.productRepo.Update(product); err != nil {
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
+
+	applyPricing(product, time.Now())
+	return product, nil
+}
+
+func validatePromotion(promotion domain.Promotion) error {
+	switch promotion.Type {
+	case domain.DiscountPercentage:
+		if promotion.Value <= 0 || promotion.Value > 100 {
+			return errors.New("percentage discount must be between 0 and 100")
+		}
+	case domain.DiscountFixed:
+		if promotion.Value <= 0 {
+			return errors.New("fixed discount must be positive")
+		}
+	default:
+		return fmt.Errorf("invalid discount type %q, expected percentage or fixed", promotion.Type)
+	}
+
+	if !promotion.EndsAt.After(promotion.StartsAt) {
+		return errors.New("promotion must end after it starts")
+	}
+	return nil
+}
+
+// effectivePrice returns the product's price under the best promotion active
+// at now; overlapping promotions don't stack
+func effectivePrice(product *domain.Product, now time.Time) float64 {
+	best := product.Price
+	for _, promotion := range product.Promotions {
+		if now.Before(promotion.StartsAt) || !now.Before(promotion.EndsAt) {
+			continue
+		}
+
+		price := product.Price - promotion.Value
+		if promotion.Type == domain.DiscountPercentage {
+			price = product.Price * (1 - promotion.Value/100)
+		}
+		best = math.Min(best, math.Max(price, 0))
+	}
+	return math.Round(best*100) / 100
+}
+
+// applyPricing fills in the product's original and effective prices
+func applyPricing(product *domain.Product, now time.Time) {
+	product.OriginalPrice = product.Price
+	product.EffectivePrice = effectivePrice(product, now)
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func TestEffectivePrice(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	active := func(discount domain.DiscountType, value float64) domain.Promotion {
+		return domain.Promotion{Type: discount, Value: value, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}
+	}
+
+	tests := []struct {
+		name       string
+		promotions []domain.Promotion
+		want       float64
+	}{
+		{name: "no promotion", want: 10},
+		{name: "active percentage", promotions: []domain.Promotion{active(domain.DiscountPercentage, 25)}, want: 7.5},
+		{name: "active fixed", promotions: []domain.Promotion{active(domain.DiscountFixed, 3)}, want: 7},
+		{
+			name:       "expired",
+			promotions: []domain.Promotion{{Type: domain.DiscountPercentage, Value: 50, StartsAt: now.Add(-48 * time.Hour), EndsAt: now.Add(-24 * time.Hour)}},
+			want:       10,
+		},
+		{
+			name:       "ends now",
+			promotions: []domain.Promotion{{Type: domain.DiscountPercentage, Value: 50, StartsAt: now.Add(-time.Hour), EndsAt: now}},
+			want:       10,
+		},
+		{
+			name:       "not started",
+			promotions: []domain.Promotion{{Type: domain.DiscountFixed, Value: 5, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)}},
+			want:       10,
+		},
+		{name: "overlapping uses the best", promotions: []domain.Promotion{active(domain.DiscountFixed, 2), active(domain.DiscountPercentage, 30), active(domain.DiscountFixed, 1)}, want: 7},
+		{name: "never below zero", promotions: []domain.Promotion{active(domain.DiscountFixed, 15)}, want: 0},
+		{name: "rounded to cents", promotions: []domain.Promotion{active(domain.DiscountPercentage, 33)}, want: 6.7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := &domain.Product{Price: 10, Promotions: tt.promotions}
+			if got := effectivePrice(product, now); got != tt.want {
+				t.Fatalf("effective price = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductPromotions(t *testing.T) {
+	now := time.Now()
+	stores := []domain.Store{{ID: "store-1", MerchantID: "merchant-1", Status: domain.StatusOpen}}
+	products := []domain.Product{{ID: "product-1", StoreID: "store-1", Name: "Burger", Price: 12, Status: domain.ProductStatusAvailable}}
+	svc, _, productRepo := newTestCatalogService(stores, products)
+
+	invalid := []domain.CreatePromotionRequest{
+		{Type: domain.DiscountPercentage, Value: 120, StartsAt: now, EndsAt: now.Add(time.Hour)},
+		{Type: "bogo", Value: 1, StartsAt: now, EndsAt: now.Add(time.Hour)},
+		{Type: domain.DiscountFixed, Value: 2, StartsAt: now, EndsAt: now.Add(-time.Hour)},
+	}
+	for _, req := range invalid {
+		if _, err := svc.AddProductPromotion("product-1", "merchant-1", req); err == nil {
+			t.Fatalf("promotion %+v: expected an error", req)
+		}
+	}
+	if _, err := svc.AddProductPromotion("product-1", "merchant-2", domain.CreatePromotionRequest{Type: domain.DiscountFixed, Value: 1, StartsAt: now, EndsAt: now.Add(time.Hour)}); err == nil {
+		t.Fatalf("another merchant added a promotion")
+	}
+
+	// An expired promotion is kept but ignored
+	expired := domain.CreatePromotionRequest{Type: domain.DiscountPercentage, Value: 50, StartsAt: now.Add(-48 * time.Hour), EndsAt: now.Add(-24 * time.Hour)}
+	product, err := svc.AddProductPromotion("product-1", "merchant-1", expired)
+	if err != nil {
+		t.Fatalf("add expired promotion: %v", err)
+	}
+	if product.EffectivePrice != 12 || product.OriginalPrice != 12 {
+		t.Fatalf("prices with an expired promotion = %v/%v, want 12/12", product.EffectivePrice, product.OriginalPrice)
+	}
+
+	active := domain.CreatePromotionRequest{Type: domain.DiscountPercentage, Value: 25, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}
+	product, err = svc.AddProductPromotion("product-1", "merchant-1", active)
+	if err != nil {
+		t.Fatalf("add active promotion: %v", err)
+	}
+	if product.EffectivePrice != 9 || product.OriginalPrice != 12 || len(productRepo.products[0].Promotions) != 2 {
+		t.Fatalf("product = %+v, want 9 instead of 12 with two promotions saved", product)
+	}
+
+	got, err := svc.GetProduct("product-1")
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if got.EffectivePrice != 9 || got.OriginalPrice != 12 {
+		t.Fatalf("GetProduct prices = %v/%v, want 9/12", got.EffectivePrice, got.OriginalPrice)
+	}
+
+	validation, err := svc.ValidateOrderItems("store-1", domain.ValidateOrderRequest{
+		Items: []domain.OrderItem{{ProductID: "product-1", Quantity: 2, UnitPrice: 9}},
+	})
+	if err != nil {
+		t.Fatalf("ValidateOrderItems: %v", err)
+	}
+	if !validation.Valid || validation.TotalAmount != 18 || validation.Items[0].OriginalPrice != 12 {
+		t.Fatalf("validation = %+v, want the promoted total of 18", validation)
+	}
+
+	product, err = svc.RemoveProductPromotion("product-1", "merchant-1", product.Promotions[1].ID)
+	if err != nil {
+		t.Fatalf("RemoveProductPromotion: %v", err)
+	}
+	if product.EffectivePrice != 12 || len(product.Promotions) != 1 {
+		t.Fatalf("after removing the active promotion: %+v", product)
+	}
+	if _, err := svc.RemoveProductPromotion("product-1", "merchant-1", "missing"); err == nil {
+		t.Fatalf("removing an unknown promotion: expected an error")
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func TestDeleteAndRestoreProduct(t *testing.T) {
+	stores := &fakeStoreRepo{stores: []domain.Store{{ID: "store-1", MerchantID: "merchant-1"}}}
+	products := &fakeProductRepo{products: []domain.Product{{ID: "product-1", StoreID: "store-1", CategoryID: "pizza"}}}
+	svc := NewCatalogService(stores, products, nil, nil, nil, nil, nil, nil)
+
+	if err := svc.DeleteProduct("product-1", "merchant-2"); err == nil {
+		t.Fatalf("another merchant deleted the product")
+	}
+	if err := svc.DeleteProduct("product-1", "merchant-1"); err != nil {
+		t.Fatalf("DeleteProduct: %v", err)
+	}
+
+	// The row is kept for historical orders but hidden
+	if len(products.products) != 1 {
+		t.Fatalf("product row was removed")
+	}
+	if _, err := svc.GetProduct("product-1"); err == nil {
+		t.Fatalf("deleted product is still returned")
+	}
+	if listed, _ := svc.GetStoreProducts("store-1", 20, 0, true); len(listed) != 0 {
+		t.Fatalf("deleted product is still listed: %+v", listed)
+	}
+
+	if err := svc.RestoreProduct("product-1"); err != nil {
+		t.Fatalf("RestoreProduct: %v", err)
+	}
+	if _, err := svc.GetProduct("product-1"); err != nil {
+		t.Fatalf("restored product: %v", err)
+	}
+	if err := svc.RestoreProduct("product-1"); !errors.Is(err, domain.ErrNotDeleted) {
+		t.Fatalf("restoring a live product: expected %v, got %v", domain.ErrNotDeleted, err)
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	food := "food"
+	tests := []struct {
+		name     string
+		category string
+		products []domain.Product
+		deleted  bool // the products were soft-deleted
+		wantErr  bool
+	}{
+		{name: "empty category", category: "drinks"},
+		{name: "with active products", category: "drinks", products: []domain.Product{{ID: "cola", CategoryID: "drinks"}}, wantErr: true},
+		{name: "with only deleted products", category: "drinks", products: []domain.Product{{ID: "cola", CategoryID: "drinks"}}, deleted: true},
+		{name: "with subcategories", category: "food", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := &fakeProductRepo{products: tt.products}
+			if tt.deleted {
+				for _, product := range tt.products {
+					products.Delete(product.ID)
+				}
+			}
+			categories := &fakeCategoryRepo{categories: []domain.Category{
+				{ID: "food"}, {ID: "pizza", ParentID: &food}, {ID: "drinks"},
+			}}
+			svc := NewCatalogService(nil, products, nil, categories, nil, nil, nil, nil)
+
+			err := svc.DeleteCategory(tt.category)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error: %v", err, tt.wantErr)
+			}
+
+			_, getErr := categories.GetByID(tt.category)
+			if deleted := getErr != nil; deleted == tt.wantErr {
+				t.Fatalf("category deleted = %v, want %v", deleted, !tt.wantErr)
+			}
+		})
+	}
+}
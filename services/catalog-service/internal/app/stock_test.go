@@ -0,0 +1,85 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+// fakeProductRepo applies stock changes all or nothing, like the database
+// transaction does
+type fakeProductRepo struct {
+	domain.ProductRepository
+	products []domain.Product
+	stock    map[string]int // tracked products only
+	calls    int
+}
+
+func (r *fakeProductRepo) DecrementStock(storeID, orderID string, items []domain.OrderItem) error {
+	r.calls++
+	next := make(map[string]int, len(r.stock))
+	for id, quantity := range r.stock {
+		next[id] = quantity
+	}
+	for _, item := range items {
+		quantity, tracked := next[item.ProductID]
+		if !tracked {
+			continue
+		}
+		if quantity < item.Quantity {
+			return fmt.Errorf("%w for product %s", domain.ErrInsufficientStock, item.ProductID)
+		}
+		next[item.ProductID] = quantity - item.Quantity
+	}
+	r.stock = next
+	return nil
+}
+
+func TestDecrementStock(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     []domain.OrderItem
+		wantErr   error
+		wantRepo  bool
+		wantStock map[string]int
+	}{
+		{name: "enough stock", items: []domain.OrderItem{{ProductID: "a", Quantity: 2}, {ProductID: "b", Quantity: 1}}, wantRepo: true, wantStock: map[string]int{"a": 3, "b": 0}},
+		{name: "exact stock", items: []domain.OrderItem{{ProductID: "a", Quantity: 5}}, wantRepo: true, wantStock: map[string]int{"a": 0, "b": 1}},
+		{name: "untracked product", items: []domain.OrderItem{{ProductID: "untracked", Quantity: 100}}, wantRepo: true, wantStock: map[string]int{"a": 5, "b": 1}},
+		{name: "short on one item rolls back all", items: []domain.OrderItem{{ProductID: "a", Quantity: 1}, {ProductID: "b", Quantity: 2}}, wantErr: domain.ErrInsufficientStock, wantRepo: true, wantStock: map[string]int{"a": 5, "b": 1}},
+		{name: "zero quantity", items: []domain.OrderItem{{ProductID: "a", Quantity: 0}}, wantStock: map[string]int{"a": 5, "b": 1}},
+		{name: "negative quantity", items: []domain.OrderItem{{ProductID: "a", Quantity: 1}, {ProductID: "b", Quantity: -1}}, wantStock: map[string]int{"a": 5, "b": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeProductRepo{stock: map[string]int{"a": 5, "b": 1}}
+			svc := NewCatalogService(nil, repo, nil, nil, nil, nil, nil, nil)
+
+			err := svc.DecrementStock("store-1", domain.StockRequest{OrderID: "order-1", Items: tt.items})
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			case !tt.wantRepo:
+				if err == nil {
+					t.Fatalf("expected invalid items to be rejected")
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if (repo.calls > 0) != tt.wantRepo {
+				t.Fatalf("repository called %d times, want called: %v", repo.calls, tt.wantRepo)
+			}
+			for id, want := range tt.wantStock {
+				if repo.stock[id] != want {
+					t.Fatalf("stock of %s = %d, want %d", id, repo.stock[id], want)
+				}
+			}
+		})
+	}
+}
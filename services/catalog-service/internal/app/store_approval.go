@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// ReviewStore opens an approved store right away; a rejected one stays hidden
+// until the merchant updates it, which resubmits it
+func (s *catalogService) ReviewStore(storeID string, adminID string, req domain.ReviewStoreRequest) (*domain.Store, error) {
+	store, err := s.storeRepo.GetByID(storeID)
+	if err != nil {
+		return nil, err
+	}
+	if store.Status != domain.StatusPendingApproval {
+		return nil, fmt.Errorf("%w: store is %s", domain.ErrStoreNotPending, store.Status)
+	}
+
+	now := time.Now()
+	if req.Decision == domain.DecisionApprove {
+		store.Status = domain.StatusOpen
+		store.RejectionReason = ""
+	} else {
+		store.Status = domain.StatusRejected
+		store.RejectionReason = req.Reason
+	}
+	store.ReviewedBy = adminID
+	store.ReviewedAt = &now
+	store.UpdatedAt = now
+
+	if err := s.storeRepo.Update(store); err != nil {
+		return nil, fmt.Errorf("failed to review store: %w", err)
+	}
+
+	payload := events.StoreReviewedPayload{
+		StoreID:    store.ID,
+		MerchantID: store.MerchantID,
+		StoreName:  store.Name,
+		Approved:   req.Decision == domain.DecisionApprove,
+		Reason:     store.RejectionReason,
+		ReviewedAt: now,
+	}
+	if err := s.eventBus.Publish(context.Background(), events.StoreReviewed, payload); err != nil {
+		log.Printf("Failed to publish %s for store %s: %v", events.StoreReviewed, store.ID, err)
+	}
+
+	store.IsOpen = isStoreOpen(store, now)
+	return store, nil
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+func TestCreateStorePendsApproval(t *testing.T) {
+	svc, stores, _ := newTestCatalogService(nil, nil)
+
+	store, err := svc.CreateStore("merchant-1", domain.CreateStoreRequest{Name: "Fresh Bakery"})
+	if err != nil {
+		t.Fatalf("CreateStore: %v", err)
+	}
+	if store.Status != domain.StatusPendingApproval || store.IsOpen {
+		t.Fatalf("new store is %s (open %v), want pending approval and closed", store.Status, store.IsOpen)
+	}
+
+	// Merchants can't open it themselves
+	if _, err := svc.UpdateStore(store.ID, "merchant-1", map[string]interface{}{"status": "open"}); !errors.Is(err, domain.ErrStoreNotApproved) {
+		t.Fatalf("opening a pending store: expected %v, got %v", domain.ErrStoreNotApproved, err)
+	}
+	if saved, _ := stores.GetByID(store.ID); saved.Status != domain.StatusPendingApproval {
+		t.Fatalf("store status = %s, want %s", saved.Status, domain.StatusPendingApproval)
+	}
+}
+
+func TestReviewStore(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          domain.ReviewStoreRequest
+		wantStatus   domain.StoreStatus
+		wantApproved bool
+		wantReason   string
+	}{
+		{name: "approve", req: domain.ReviewStoreRequest{Decision: domain.DecisionApprove}, wantStatus: domain.StatusOpen, wantApproved: true},
+		{name: "reject", req: domain.ReviewStoreRequest{Decision: domain.DecisionReject, Reason: "Missing food licence"}, wantStatus: domain.StatusRejected, wantReason: "Missing food licence"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := &fakeStoreRepo{stores: []domain.Store{{ID: "store-1", MerchantID: "merchant-1", Name: "Fresh Bakery", Status: domain.StatusPendingApproval}}}
+			bus := events.NewInMemoryBus()
+			reviewed := make(chan events.StoreReviewedPayload, 1)
+			bus.Subscribe(events.StoreReviewed, func(ctx context.Context, event events.Event) error {
+				var payload events.StoreReviewedPayload
+				if err := event.Decode(&payload); err != nil {
+					return err
+				}
+				reviewed <- payload
+				return nil
+			})
+			svc := NewCatalogService(stores, nil, nil, nil, nil, nil, nil, bus)
+
+			store, err := svc.ReviewStore("store-1", "admin-1", tt.req)
+			if err != nil {
+				t.Fatalf("ReviewStore: %v", err)
+			}
+			if store.Status != tt.wantStatus || store.RejectionReason != tt.wantReason || store.ReviewedBy != "admin-1" || store.ReviewedAt == nil {
+				t.Fatalf("store = %+v", store)
+			}
+			if saved, _ := stores.GetByID("store-1"); saved.Status != tt.wantStatus {
+				t.Fatalf("saved status = %s, want %s", saved.Status, tt.wantStatus)
+			}
+
+			bus.Close()
+			select {
+			case payload := <-reviewed:
+				if payload.StoreID != "store-1" || payload.MerchantID != "merchant-1" || payload.Approved != tt.wantApproved || payload.Reason != tt.wantReason {
+					t.Fatalf("payload = %+v", payload)
+				}
+			default:
+				t.Fatalf("no %s event published", events.StoreReviewed)
+			}
+
+			// A store is only reviewed once
+			if _, err := svc.ReviewStore("store-1", "admin-1", tt.req); !errors.Is(err, domain.ErrStoreNotPending) {
+				t.Fatalf("second review: expected %v, got %v", domain.ErrStoreNotPending, err)
+			}
+		})
+	}
+}
+
+func TestUpdateRejectedStoreResubmits(t *testing.T) {
+	svc, stores, _ := newTestCatalogService([]domain.Store{
+		{ID: "store-1", MerchantID: "merchant-1", Name: "Bakery", Status: domain.StatusRejected, RejectionReason: "Missing food licence"},
+	}, nil)
+
+	store, err := svc.UpdateStore("store-1", "merchant-1", map[string]interface{}{"name": "Fresh Bakery"})
+	if err != nil {
+		t.Fatalf("UpdateStore: %v", err)
+	}
+	if store.Status != domain.StatusPendingApproval || store.RejectionReason != "" {
+		t.Fatalf("store is %s (reason %q), want pending approval with no reason", store.Status, store.RejectionReason)
+	}
+	if saved, _ := stores.GetByID("store-1"); saved.Status != domain.StatusPendingApproval || saved.Name != "Fresh Bakery" {
+		t.Fatalf("saved store = %+v", saved)
+	}
+}
+
+func TestUpdateApprovedStoreStatus(t *testing.T) {
+	svc, _, _ := newTestCatalogService([]domain.Store{{ID: "store-1", MerchantID: "merchant-1", Status: domain.StatusOpen}}, nil)
+
+	store, err := svc.UpdateStore("store-1", "merchant-1", map[string]interface{}{"status": "paused"})
+	if err != nil {
+		t.Fatalf("UpdateStore: %v", err)
+	}
+	if store.Status != domain.StatusPaused {
+		t.Fatalf("status = %s, want %s", store.Status, domain.StatusPaused)
+	}
+
+	// Merchants can't approve their own store
+	if _, err := svc.UpdateStore("store-1", "merchant-1", map[string]interface{}{"status": "pending_approval"}); !errors.Is(err, domain.ErrInvalidStoreStatus) {
+		t.Fatalf("expected %v, got %v", domain.ErrInvalidStoreStatus, err)
+	}
+}
@@ -0,0 +1,220 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+// timeRange is an opening range in minutes since midnight; close <= open
+// means the range runs past midnight into the next day
+type timeRange struct {
+	open, close int
+}
+
+// parseHours parses a day's "HH:MM-HH:MM[,HH:MM-HH:MM...]" ranges
+func parseHours(hours string) ([]timeRange, error) {
+	var ranges []timeRange
+	for _, part := range strings.Split(hours, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		bounds := strings.Split(part, "-")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("invalid opening range %q, expected HH:MM-HH:MM", part)
+		}
+
+		openAt, err := parseClock(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		closeAt, err := parseClock(bounds[1])
+		if err != nil {
+			return nil, err
+		}
+
+		ranges = append(ranges, timeRange{open: openAt, close: closeAt})
+	}
+	return ranges, nil
+}
+
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func dayHours(hours domain.OpeningHours, day time.Weekday) string {
+	switch day {
+	case time.Monday:
+		return hours.Monday
+	case time.Tuesday:
+		return hours.Tuesday
+	case time.Wednesday:
+		return hours.Wednesday
+	case time.Thursday:
+		return hours.Thursday
+	case time.Friday:
+		return hours.Friday
+	case time.Saturday:
+		return hours.Saturday
+	default:
+		return hours.Sunday
+	}
+}
+
+func hasHours(hours domain.OpeningHours) bool {
+	return hours != domain.OpeningHours{}
+}
+
+// validateStoreHours checks the timezone, weekly hours and holiday overrides
+func validateStoreHours(store *domain.Store) error {
+	if _, err := time.LoadLocation(store.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q", store.Timezone)
+	}
+
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if _, err := parseHours(dayHours(store.OpeningHours, day)); err != nil {
+			return fmt.Errorf("%s: %w", strings.ToLower(day.String()), err)
+		}
+	}
+
+	for _, override := range store.HolidayOverrides {
+		if _, err := time.Parse("2006-01-02", override.Date); err != nil {
+			return fmt.Errorf("invalid holiday date %q, expected YYYY-MM-DD", override.Date)
+		}
+		if _, err := parseHours(override.Hours); err != nil {
+			return fmt.Errorf("holiday %s: %w", override.Date, err)
+		}
+	}
+	return nil
+}
+
+// hoursOn returns the store's ranges for a local date, preferring a holiday
+// override. Stores without weekly hours are open all day.
+func hoursOn(store *domain.Store, date time.Time) []timeRange {
+	hours := "00:00-00:00"
+	if hasHours(store.OpeningHours) {
+		hours = dayHours(store.OpeningHours, date.Weekday())
+	}
+	day := date.Format("2006-01-02")
+	for _, override := range store.HolidayOverrides {
+		if override.Date == day {
+			hours = override.Hours
+			break
+		}
+	}
+
+	// Hours are validated on save, so parse errors only come from bad legacy data
+	ranges, _ := parseHours(hours)
+	return ranges
+}
+
+// isStoreOpen reports whether the store accepts orders at the given instant,
+// evaluating its hours in the store's timezone
+func isStoreOpen(store *domain.Store, now time.Time) bool {
+	if store.Status != domain.StatusOpen {
+		return false
+	}
+
+	local := now.In(storeLocation(store))
+	return withinRanges(hoursOn(store, local), hoursOn(store, local.AddDate(0, 0, -1)), local)
+}
+
+func storeLocation(store *domain.Store) *time.Location {
+	loc, err := time.LoadLocation(store.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// withinRanges reports whether local's time of day falls in today's ranges or
+// in one of yesterday's ranges that runs past midnight
+func withinRanges(today, yesterday []timeRange, local time.Time) bool {
+	minute := local.Hour()*60 + local.Minute()
+
+	for _, r := range today {
+		if r.close > r.open && minute >= r.open && minute < r.close {
+			return true
+		}
+		if r.close <= r.open && minute >= r.open {
+			return true
+		}
+	}
+
+	for _, r := range yesterday {
+		if r.close <= r.open && minute < r.close {
+			return true
+		}
+	}
+	return false
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// validateAvailability checks a product's availability windows
+func validateAvailability(windows []domain.AvailabilityWindow) error {
+	for i, window := range windows {
+		for _, day := range window.Days {
+			if _, ok := weekdays[day]; !ok {
+				return fmt.Errorf("availability window %d: invalid day %q", i+1, day)
+			}
+		}
+		ranges, err := parseHours(window.Hours)
+		if err != nil {
+			return fmt.Errorf("availability window %d: %w", i+1, err)
+		}
+		if len(ranges) == 0 {
+			return fmt.Errorf("availability window %d: hours are required", i+1)
+		}
+	}
+	return nil
+}
+
+// availabilityOn returns the ranges of the windows that apply on day
+func availabilityOn(windows []domain.AvailabilityWindow, day time.Weekday) []timeRange {
+	var ranges []timeRange
+	for _, window := range windows {
+		applies := len(window.Days) == 0
+		for _, name := range window.Days {
+			if weekdays[name] == day {
+				applies = true
+				break
+			}
+		}
+		if applies {
+			// Windows are validated on save
+			parsed, _ := parseHours(window.Hours)
+			ranges = append(ranges, parsed...)
+		}
+	}
+	return ranges
+}
+
+// isProductAvailableAt reports whether the product's availability windows,
+// evaluated in the store's timezone, include the given instant
+func isProductAvailableAt(product *domain.Product, store *domain.Store, now time.Time) bool {
+	if len(product.Availability) == 0 {
+		return true
+	}
+
+	local := now.In(storeLocation(store))
+	today := availabilityOn(product.Availability, local.Weekday())
+	yesterday := availabilityOn(product.Availability, local.AddDate(0, 0, -1).Weekday())
+	return withinRanges(today, yesterday, local)
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+)
+
+func TestIsStoreOpen(t *testing.T) {
+	// 2026-10-11 is a Sunday in UTC but already Monday in Tokyo (UTC+9)
+	sundayUTC := time.Date(2026, 10, 11, 23, 30, 0, 0, time.UTC) // Monday 08:30 in Tokyo
+
+	tests := []struct {
+		name      string
+		status    domain.StoreStatus
+		timezone  string
+		hours     domain.OpeningHours
+		overrides []domain.HolidayOverride
+		at        time.Time
+		want      bool
+	}{
+		{name: "no hours is always open", at: sundayUTC, want: true},
+		{name: "open in the store's timezone", timezone: "Asia/Tokyo", hours: domain.OpeningHours{Monday: "08:00-12:00"}, at: sundayUTC, want: true},
+		{name: "closed when the UTC day has no hours", timezone: "UTC", hours: domain.OpeningHours{Monday: "08:00-12:00"}, at: sundayUTC, want: false},
+		{name: "before opening", timezone: "Asia/Tokyo", hours: domain.OpeningHours{Monday: "09:00-12:00"}, at: sundayUTC, want: false},
+		{name: "second range of the day", timezone: "Asia/Tokyo", hours: domain.OpeningHours{Monday: "06:00-07:00,08:00-09:00"}, at: sundayUTC, want: true},
+		{name: "range running past midnight", timezone: "Asia/Tokyo", hours: domain.OpeningHours{Sunday: "20:00-09:00"}, at: sundayUTC, want: true},
+		{
+			name:      "holiday closes a normally open day",
+			timezone:  "Asia/Tokyo",
+			hours:     domain.OpeningHours{Monday: "08:00-12:00"},
+			overrides: []domain.HolidayOverride{{Date: "2026-10-12", Hours: ""}},
+			at:        sundayUTC,
+			want:      false,
+		},
+		{
+			name:      "holiday opens a normally closed day",
+			timezone:  "Asia/Tokyo",
+			hours:     domain.OpeningHours{Tuesday: "08:00-12:00"},
+			overrides: []domain.HolidayOverride{{Date: "2026-10-12", Hours: "08:00-09:00"}},
+			at:        sundayUTC,
+			want:      true,
+		},
+		{
+			name:      "holiday is matched on the local date",
+			timezone:  "Asia/Tokyo",
+			hours:     domain.OpeningHours{Monday: "08:00-12:00"},
+			overrides: []domain.HolidayOverride{{Date: "2026-10-11", Hours: ""}},
+			at:        sundayUTC,
+			want:      true,
+		},
+		{name: "paused store", status: domain.StatusPaused, at: sundayUTC, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			if status == "" {
+				status = domain.StatusOpen
+			}
+			store := &domain.Store{Status: status, Timezone: tt.timezone, OpeningHours: tt.hours, HolidayOverrides: tt.overrides}
+			if err := validateStoreHours(store); err != nil {
+				t.Fatalf("validateStoreHours: %v", err)
+			}
+
+			if got := isStoreOpen(store, tt.at); got != tt.want {
+				t.Fatalf("isStoreOpen = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateStoreHours(t *testing.T) {
+	tests := []struct {
+		name  string
+		store domain.Store
+	}{
+		{name: "unknown timezone", store: domain.Store{Timezone: "Mars/Olympus"}},
+		{name: "malformed range", store: domain.Store{OpeningHours: domain.OpeningHours{Monday: "9-17"}}},
+		{name: "malformed holiday date", store: domain.Store{HolidayOverrides: []domain.HolidayOverride{{Date: "12/10/2026"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateStoreHours(&tt.store); err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+// closedAllDay overrides yesterday and today so the store is closed now,
+// including ranges that would run past midnight
+func closedAllDay() []domain.HolidayOverride {
+	now := time.Now().UTC()
+	return []domain.HolidayOverride{
+		{Date: now.AddDate(0, 0, -1).Format("2006-01-02")},
+		{Date: now.Format("2006-01-02")},
+	}
+}
+
+func TestStoreHoursEnforcement(t *testing.T) {
+	allDay := "00:00-00:00"
+	hours := domain.OpeningHours{Monday: allDay, Tuesday: allDay, Wednesday: allDay, Thursday: allDay, Friday: allDay, Saturday: allDay, Sunday: allDay}
+	stores := []domain.Store{
+		{ID: "open", Status: domain.StatusOpen, OpeningHours: hours},
+		{ID: "closed", Status: domain.StatusOpen, OpeningHours: hours, HolidayOverrides: closedAllDay()},
+	}
+	products := []domain.Product{
+		{ID: "open-product", StoreID: "open", Name: "Soup", Price: 5, Status: domain.ProductStatusAvailable},
+		{ID: "closed-product", StoreID: "closed", Name: "Soup", Price: 5, Status: domain.ProductStatusAvailable},
+	}
+	svc, _, _ := newTestCatalogService(stores, products)
+
+	results, err := svc.SearchStores(domain.StoreSearchRequest{})
+	if err != nil {
+		t.Fatalf("SearchStores: %v", err)
+	}
+	isOpen := make(map[string]bool)
+	for _, store := range results {
+		isOpen[store.ID] = store.IsOpen
+	}
+	if !isOpen["open"] || isOpen["closed"] {
+		t.Fatalf("is_open = %v, want open store open and holiday store closed", isOpen)
+	}
+
+	for _, tt := range []struct {
+		store, product string
+		want           bool
+	}{
+		{store: "open", product: "open-product", want: true},
+		{store: "closed", product: "closed-product", want: false},
+	} {
+		validation, err := svc.ValidateOrderItems(tt.store, domain.ValidateOrderRequest{
+			Items: []domain.OrderItem{{ProductID: tt.product, Quantity: 1}},
+		})
+		if err != nil {
+			t.Fatalf("ValidateOrderItems: %v", err)
+		}
+		if validation.Valid != tt.want {
+			t.Fatalf("%s store: valid = %v, want %v (errors %v)", tt.store, validation.Valid, tt.want, validation.Errors)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+	"glovo-backend/shared/events"
+
+	"github.com/google/uuid"
+)
+
+// SubmitStoreReview rates a store for one of the customer's delivered orders.
+// Each order can be reviewed once, and the store's rating and review count are
+// recomputed from its reviews.
+func (s *catalogService) SubmitStoreReview(storeID string, customerID string, req domain.CreateStoreReviewRequest) (*domain.StoreReview, error) {
+	store, err := s.storeRepo.GetByID(storeID)
+	if err != nil {
+		return nil, fmt.Errorf("store not found: %w", err)
+	}
+
+	order, err := s.deliveredOrderRepo.GetByOrderID(req.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: order %s has not been delivered", domain.ErrOrderNotReviewable, req.OrderID)
+	}
+	if order.CustomerID != customerID || order.MerchantID != store.MerchantID {
+		return nil, fmt.Errorf("%w: order %s was not delivered to you from this store", domain.ErrOrderNotReviewable, req.OrderID)
+	}
+
+	if existing, _ := s.reviewRepo.GetByOrderID(req.OrderID); existing != nil {
+		return nil, domain.ErrReviewExists
+	}
+
+	review := &domain.StoreReview{
+		ID:         uuid.New().String(),
+		StoreID:    store.ID,
+		OrderID:    req.OrderID,
+		CustomerID: customerID,
+		Rating:     req.Rating,
+		Comment:    req.Comment,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.reviewRepo.Create(review); err != nil {
+		return nil, fmt.Errorf("failed to save review: %w", err)
+	}
+
+	payload := events.StoreRatedPayload{
+		ReviewID:   review.ID,
+		StoreID:    store.ID,
+		MerchantID: store.MerchantID,
+		OrderID:    review.OrderID,
+		Rating:     review.Rating,
+		RatedAt:    review.CreatedAt,
+	}
+	if err := s.eventBus.Publish(context.Background(), events.StoreRated, payload); err != nil {
+		log.Printf("Failed to publish %s for review %s: %v", events.StoreRated, review.ID, err)
+	}
+	return review, nil
+}
+
+func (s *catalogService) GetStoreReviews(storeID string, limit, offset int) ([]domain.StoreReview, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.reviewRepo.GetByStoreID(storeID, limit, offset)
+}
+
+// RecordDeliveredOrder remembers a completed delivery so its customer can
+// review the store afterwards
+func (s *catalogService) RecordDeliveredOrder(order domain.DeliveredOrder) error {
+	if order.DeliveredAt.IsZero() {
+		order.DeliveredAt = time.Now()
+	}
+	return s.deliveredOrderRepo.Save(&order)
+}
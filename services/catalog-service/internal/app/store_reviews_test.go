@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"glovo-backend/services/catalog-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// fakeReviewRepo recomputes the store's rating on Create, like the repository's transaction
+type fakeReviewRepo struct {
+	domain.StoreReviewRepository
+	stores  *fakeStoreRepo
+	reviews []domain.StoreReview
+}
+
+func (r *fakeReviewRepo) Create(review *domain.StoreReview) error {
+	r.reviews = append(r.reviews, *review)
+
+	total, count := 0, 0
+	for _, existing := range r.reviews {
+		if existing.StoreID == review.StoreID {
+			total += existing.Rating
+			count++
+		}
+	}
+	store, err := r.stores.GetByID(review.StoreID)
+	if err != nil {
+		return err
+	}
+	store.Rating = float64(total) / float64(count)
+	store.ReviewCount = count
+	return r.stores.Update(store)
+}
+
+func (r *fakeReviewRepo) GetByOrderID(orderID string) (*domain.StoreReview, error) {
+	for _, review := range r.reviews {
+		if review.OrderID == orderID {
+			return &review, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+type fakeDeliveredOrderRepo struct {
+	domain.DeliveredOrderRepository
+	orders map[string]domain.DeliveredOrder
+}
+
+func (r *fakeDeliveredOrderRepo) Save(order *domain.DeliveredOrder) error {
+	if _, exists := r.orders[order.OrderID]; !exists {
+		r.orders[order.OrderID] = *order
+	}
+	return nil
+}
+
+func (r *fakeDeliveredOrderRepo) GetByOrderID(orderID string) (*domain.DeliveredOrder, error) {
+	order, ok := r.orders[orderID]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &order, nil
+}
+
+func TestSubmitStoreReview(t *testing.T) {
+	stores := &fakeStoreRepo{stores: []domain.Store{{ID: "store-1", MerchantID: "merchant-1"}}}
+	reviews := &fakeReviewRepo{stores: stores}
+	delivered := &fakeDeliveredOrderRepo{orders: make(map[string]domain.DeliveredOrder)}
+	bus := events.NewInMemoryBus()
+	rated := make(chan events.StoreRatedPayload, 4)
+	bus.Subscribe(events.StoreRated, func(ctx context.Context, event events.Event) error {
+		var payload events.StoreRatedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		rated <- payload
+		return nil
+	})
+	svc := NewCatalogService(stores, nil, nil, nil, reviews, delivered, nil, bus)
+
+	for _, order := range []domain.DeliveredOrder{
+		{OrderID: "order-1", CustomerID: "customer-1", MerchantID: "merchant-1"},
+		{OrderID: "order-2", CustomerID: "customer-2", MerchantID: "merchant-1"},
+		{OrderID: "other-store", CustomerID: "customer-1", MerchantID: "merchant-2"},
+	} {
+		if err := svc.RecordDeliveredOrder(order); err != nil {
+			t.Fatalf("RecordDeliveredOrder: %v", err)
+		}
+	}
+
+	review, err := svc.SubmitStoreReview("store-1", "customer-1", domain.CreateStoreReviewRequest{OrderID: "order-1", Rating: 5, Comment: "Great"})
+	if err != nil {
+		t.Fatalf("SubmitStoreReview: %v", err)
+	}
+	if review.StoreID != "store-1" || review.CustomerID != "customer-1" || review.Rating != 5 {
+		t.Fatalf("review = %+v", review)
+	}
+
+	if _, err := svc.SubmitStoreReview("store-1", "customer-1", domain.CreateStoreReviewRequest{OrderID: "order-1", Rating: 1}); !errors.Is(err, domain.ErrReviewExists) {
+		t.Fatalf("duplicate review: expected %v, got %v", domain.ErrReviewExists, err)
+	}
+
+	if _, err := svc.SubmitStoreReview("store-1", "customer-2", domain.CreateStoreReviewRequest{OrderID: "order-2", Rating: 2}); err != nil {
+		t.Fatalf("second review: %v", err)
+	}
+
+	store, _ := stores.GetByID("store-1")
+	if store.Rating != 3.5 || store.ReviewCount != 2 {
+		t.Fatalf("store rating = %v from %d reviews, want 3.5 from 2", store.Rating, store.ReviewCount)
+	}
+
+	bus.Close()
+	close(rated)
+	var published []events.StoreRatedPayload
+	for payload := range rated {
+		published = append(published, payload)
+	}
+	if len(published) != 2 || published[0].MerchantID != "merchant-1" {
+		t.Fatalf("published %+v, want one event per accepted review", published)
+	}
+}
+
+func TestSubmitStoreReviewRequiresDeliveredOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		customer string
+		orderID  string
+	}{
+		{name: "not delivered", customer: "customer-1", orderID: "order-9"},
+		{name: "someone else's order", customer: "customer-2", orderID: "order-1"},
+		{name: "order from another store", customer: "customer-1", orderID: "other-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := &fakeStoreRepo{stores: []domain.Store{{ID: "store-1", MerchantID: "merchant-1"}}}
+			reviews := &fakeReviewRepo{stores: stores}
+			delivered := &fakeDeliveredOrderRepo{orders: map[string]domain.DeliveredOrder{
+				"order-1":     {OrderID: "order-1", CustomerID: "customer-1", MerchantID: "merchant-1"},
+				"other-store": {OrderID: "other-store", CustomerID: "customer-1", MerchantID: "merchant-2"},
+			}}
+			svc := NewCatalogService(stores, nil, nil, nil, reviews, delivered, nil, events.NewInMemoryBus())
+
+			_, err := svc.SubmitStoreReview("store-1", tt.customer, domain.CreateStoreReviewRequest{OrderID: tt.orderID, Rating: 4})
+			if !errors.Is(err, domain.ErrOrderNotReviewable) {
+				t.Fatalf("expected %v, got %v", domain.ErrOrderNotReviewable, err)
+			}
+			if len(reviews.reviews) != 0 {
+				t.Fatalf("review was saved")
+			}
+		})
+	}
+}
@@ -1,29 +1,73 @@
 package domain
 
 import (
+	"errors"
+	"io"
 	"time"
+
+	"glovo-backend/shared/geo"
+
+	"gorm.io/gorm"
 )
 
 // Store represents a merchant's store/restaurant
 type Store struct {
-	ID           string       `json:"id" gorm:"primaryKey"`
-	MerchantID   string       `json:"merchant_id" gorm:"index"`
-	Name         string       `json:"name"`
-	Description  string       `json:"description"`
-	Address      string       `json:"address"`
-	Latitude     float64      `json:"latitude"`
-	Longitude    float64      `json:"longitude"`
-	Phone        string       `json:"phone"`
-	Email        string       `json:"email"`
-	Status       StoreStatus  `json:"status"`
-	Categories   []Category   `json:"categories" gorm:"many2many:store_categories;"`
-	Products     []Product    `json:"products" gorm:"foreignKey:StoreID"`
-	Rating       float64      `json:"rating"`
-	ReviewCount  int          `json:"review_count"`
-	OpeningHours OpeningHours `json:"opening_hours" gorm:"embedded"`
-	DeliveryInfo DeliveryInfo `json:"delivery_info" gorm:"embedded"`
-	CreatedAt    time.Time    `json:"created_at"`
-	UpdatedAt    time.Time    `json:"updated_at"`
+	ID               string            `json:"id" gorm:"primaryKey"`
+	MerchantID       string            `json:"merchant_id" gorm:"index"`
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	Address          string            `json:"address"`
+	Latitude         float64           `json:"latitude"`
+	Longitude        float64           `json:"longitude"`
+	Phone            string            `json:"phone"`
+	Email            string            `json:"email"`
+	Status           StoreStatus       `json:"status"`
+	RejectionReason  string            `json:"rejection_reason,omitempty"`
+	ReviewedBy       string            `json:"reviewed_by,omitempty"` // admin who approved or rejected the store
+	ReviewedAt       *time.Time        `json:"reviewed_at,omitempty"`
+	Categories       []Category        `json:"categories" gorm:"many2many:store_categories;"`
+	Products         []Product         `json:"products" gorm:"foreignKey:StoreID"`
+	Rating           float64           `json:"rating"`
+	ReviewCount      int               `json:"review_count"`
+	OpeningHours     OpeningHours      `json:"opening_hours" gorm:"embedded"`
+	Timezone         string            `json:"timezone"` // IANA zone of the opening hours, UTC if empty
+	HolidayOverrides []HolidayOverride `json:"holiday_overrides" gorm:"serializer:json"`
+	DeliveryInfo     DeliveryInfo      `json:"delivery_info" gorm:"embedded"`
+	DeliveryZone     []geo.Point       `json:"delivery_zone" gorm:"serializer:json"` // polygon outline; empty falls back to DeliveryInfo.DeliveryRadius
+	IsOpen           bool              `json:"is_open" gorm:"-"`                     // computed from status and hours
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt    `json:"-" gorm:"index"`
+}
+
+// StoreReview is a customer's rating of a store for one delivered order
+type StoreReview struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	StoreID    string    `json:"store_id" gorm:"index"`
+	OrderID    string    `json:"order_id" gorm:"uniqueIndex"` // one review per order
+	CustomerID string    `json:"customer_id" gorm:"index"`
+	Rating     int       `json:"rating"` // 1 to 5
+	Comment    string    `json:"comment,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DeliveredOrder records a completed delivery so its customer can review the
+// merchant's store
+type DeliveredOrder struct {
+	OrderID     string    `json:"order_id" gorm:"primaryKey"`
+	CustomerID  string    `json:"customer_id" gorm:"index"`
+	MerchantID  string    `json:"merchant_id" gorm:"index"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+// StockReservation records that an order's stock was reserved, or released,
+// so each is applied at most once per order
+type StockReservation struct {
+	OrderID   string    `json:"order_id" gorm:"primaryKey"`
+	StoreID   string    `json:"store_id" gorm:"index"`
+	Released  bool      `json:"released" gorm:"default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type StoreStatus string
@@ -32,8 +76,23 @@ const (
 	StatusOpen   StoreStatus = "open"
 	StatusClosed StoreStatus = "closed"
 	StatusPaused StoreStatus = "paused"
+
+	// New stores wait for an admin and are hidden from search until approved
+	StatusPendingApproval StoreStatus = "pending_approval"
+	StatusRejected        StoreStatus = "rejected"
 )
 
+// UnapprovedStoreStatuses are hidden from public search
+var UnapprovedStoreStatuses = []StoreStatus{StatusPendingApproval, StatusRejected}
+
+// IsApproved reports whether an admin has approved the store
+func (s *Store) IsApproved() bool {
+	return s.Status != StatusPendingApproval && s.Status != StatusRejected
+}
+
+// OpeningHours holds each day's comma-separated "HH:MM-HH:MM" ranges, e.g.
+// "12:00-15:00,19:00-23:30". A range closing at or before it opens runs past
+// midnight. An empty day is closed; a store with no hours at all is always open.
 type OpeningHours struct {
 	Monday    string `json:"monday"`
 	Tuesday   string `json:"tuesday"`
@@ -44,28 +103,48 @@ type OpeningHours struct {
 	Sunday    string `json:"sunday"`
 }
 
+// HolidayOverride replaces the weekly hours on one date; empty Hours means closed all day
+type HolidayOverride struct {
+	Date  string `json:"date"`  // YYYY-MM-DD in the store's timezone
+	Hours string `json:"hours"` // same format as an OpeningHours day
+}
+
 type DeliveryInfo struct {
 	MinOrderAmount float64 `json:"min_order_amount"`
 	DeliveryFee    float64 `json:"delivery_fee"`
-	DeliveryRadius float64 `json:"delivery_radius"` // in kilometers
+	DeliveryRadius float64 `json:"delivery_radius"` // in kilometers from the store; 0 delivers everywhere
 	EstimatedTime  int     `json:"estimated_time"`  // in minutes
+	PrepTime       int     `json:"prep_time"`       // default minutes to prepare an order
 }
 
 // Product represents an item that can be ordered
 type Product struct {
-	ID          string          `json:"id" gorm:"primaryKey"`
-	StoreID     string          `json:"store_id" gorm:"index"`
-	CategoryID  string          `json:"category_id" gorm:"index"`
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	Price       float64         `json:"price"`
-	Image       string          `json:"image,omitempty"`
-	Status      ProductStatus   `json:"status"`
-	Options     []ProductOption `json:"options" gorm:"foreignKey:ProductID"`
-	Nutrition   NutritionInfo   `json:"nutrition" gorm:"embedded"`
-	Tags        []string        `json:"tags" gorm:"serializer:json"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
+	ID             string               `json:"id" gorm:"primaryKey"`
+	StoreID        string               `json:"store_id" gorm:"index"`
+	CategoryID     string               `json:"category_id" gorm:"index"`
+	Name           string               `json:"name"`
+	Description    string               `json:"description"`
+	Price          float64              `json:"price"`
+	Image          string               `json:"image,omitempty"`
+	Status         ProductStatus        `json:"status"`
+	TrackStock     bool                 `json:"track_stock"` // false means unlimited stock
+	StockQuantity  int                  `json:"stock_quantity"`
+	Availability   []AvailabilityWindow `json:"availability" gorm:"serializer:json"` // empty means always available
+	Promotions     []Promotion          `json:"promotions" gorm:"serializer:json"`
+	OriginalPrice  float64              `json:"original_price" gorm:"-"`  // Price before promotions
+	EffectivePrice float64              `json:"effective_price" gorm:"-"` // Price with the best active promotion
+	Options        []ProductOption      `json:"options" gorm:"foreignKey:ProductID"`
+	Images         []ProductImage       `json:"images" gorm:"foreignKey:ProductID"`
+	Nutrition      NutritionInfo        `json:"nutrition" gorm:"embedded"`
+	Tags           []string             `json:"tags" gorm:"serializer:json"`
+	Allergens      []Allergen           `json:"allergens" gorm:"serializer:json;type:jsonb"`
+	DietaryTags    []DietaryTag         `json:"dietary_tags" gorm:"serializer:json;type:jsonb"`
+	PrepTime       int                  `json:"prep_time"`     // minutes; 0 uses the store's prep time
+	Bulky          bool                 `json:"bulky"`         // too large for a bicycle
+	RequiresCold   bool                 `json:"requires_cold"` // must be kept chilled in transit
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt       `json:"-" gorm:"index"`
 }
 
 type ProductStatus string
@@ -76,13 +155,94 @@ const (
 	ProductStatusSoldOut     ProductStatus = "sold_out"
 )
 
+// Allergen is an allergen a product contains
+type Allergen string
+
+const (
+	AllergenNuts      Allergen = "nuts"
+	AllergenPeanuts   Allergen = "peanuts"
+	AllergenGluten    Allergen = "gluten"
+	AllergenDairy     Allergen = "dairy"
+	AllergenEggs      Allergen = "eggs"
+	AllergenSoy       Allergen = "soy"
+	AllergenFish      Allergen = "fish"
+	AllergenShellfish Allergen = "shellfish"
+	AllergenSesame    Allergen = "sesame"
+)
+
+// Allergens lists every allergen a product can declare
+var Allergens = []Allergen{
+	AllergenNuts, AllergenPeanuts, AllergenGluten, AllergenDairy, AllergenEggs,
+	AllergenSoy, AllergenFish, AllergenShellfish, AllergenSesame,
+}
+
+// DietaryTag is a diet a product is suitable for
+type DietaryTag string
+
+const (
+	DietaryVegan      DietaryTag = "vegan"
+	DietaryVegetarian DietaryTag = "vegetarian"
+	DietaryGlutenFree DietaryTag = "gluten_free"
+	DietaryDairyFree  DietaryTag = "dairy_free"
+	DietaryHalal      DietaryTag = "halal"
+	DietaryKosher     DietaryTag = "kosher"
+)
+
+// DietaryTags lists every dietary tag a product can declare
+var DietaryTags = []DietaryTag{
+	DietaryVegan, DietaryVegetarian, DietaryGlutenFree, DietaryDairyFree, DietaryHalal, DietaryKosher,
+}
+
+// AvailabilityWindow limits when a product can be ordered, e.g. breakfast items
+// on weekdays "07:00-11:00". Days are lowercase weekday names; empty Days means
+// every day. Hours use the OpeningHours day format and the store's timezone.
+type AvailabilityWindow struct {
+	Days  []string `json:"days"`
+	Hours string   `json:"hours"`
+}
+
+// Promotion is a scheduled discount on a product, active from StartsAt until EndsAt
+type Promotion struct {
+	ID       string       `json:"id"`
+	Type     DiscountType `json:"type"`
+	Value    float64      `json:"value"` // percent off for percentage, amount off for fixed
+	StartsAt time.Time    `json:"starts_at"`
+	EndsAt   time.Time    `json:"ends_at"`
+}
+
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "percentage"
+	DiscountFixed      DiscountType = "fixed"
+)
+
+// ProductImage is an uploaded product photo; Product.Image mirrors the primary image's URL
+type ProductImage struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	ProductID    string    `json:"product_id" gorm:"index"`
+	URL          string    `json:"url"`
+	StorageKey   string    `json:"-"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	DisplayOrder int       `json:"display_order"`
+	IsPrimary    bool      `json:"is_primary"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ProductOption is a group of choices, e.g. "Size" or "Extra toppings".
+// Required options need at least one choice; MinSelections and MaxSelections
+// narrow that further, and a zero MaxSelections means no upper limit for
+// multiple options (single options always allow one).
 type ProductOption struct {
-	ID        string                `json:"id" gorm:"primaryKey"`
-	ProductID string                `json:"product_id"`
-	Name      string                `json:"name"`
-	Type      ProductOptionType     `json:"type"`
-	Required  bool                  `json:"required"`
-	Options   []ProductOptionChoice `json:"options" gorm:"foreignKey:OptionID"`
+	ID            string                `json:"id" gorm:"primaryKey"`
+	ProductID     string                `json:"product_id"`
+	Name          string                `json:"name"`
+	Type          ProductOptionType     `json:"type"`
+	Required      bool                  `json:"required"`
+	MinSelections int                   `json:"min_selections"`
+	MaxSelections int                   `json:"max_selections"`
+	Options       []ProductOptionChoice `json:"options" gorm:"foreignKey:OptionID"`
 }
 
 type ProductOptionType string
@@ -111,47 +271,80 @@ type NutritionInfo struct {
 
 // Category represents product categories
 type Category struct {
-	ID          string     `json:"id" gorm:"primaryKey"`
-	Name        string     `json:"name"`
-	Description string     `json:"description"`
-	Image       string     `json:"image,omitempty"`
-	ParentID    *string    `json:"parent_id,omitempty"`
-	Children    []Category `json:"children" gorm:"foreignKey:ParentID"`
-	SortOrder   int        `json:"sort_order"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID          string         `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Image       string         `json:"image,omitempty"`
+	ParentID    *string        `json:"parent_id,omitempty"`
+	Children    []Category     `json:"children" gorm:"foreignKey:ParentID"`
+	SortOrder   int            `json:"sort_order"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // Request/Response DTOs
 type CreateStoreRequest struct {
-	Name         string       `json:"name" binding:"required"`
-	Description  string       `json:"description"`
-	Address      string       `json:"address" binding:"required"`
-	Latitude     float64      `json:"latitude" binding:"required"`
-	Longitude    float64      `json:"longitude" binding:"required"`
-	Phone        string       `json:"phone" binding:"required"`
-	Email        string       `json:"email" binding:"required,email"`
-	CategoryIDs  []string     `json:"category_ids"`
-	OpeningHours OpeningHours `json:"opening_hours"`
-	DeliveryInfo DeliveryInfo `json:"delivery_info"`
+	Name             string            `json:"name" binding:"required"`
+	Description      string            `json:"description"`
+	Address          string            `json:"address" binding:"required"`
+	Latitude         float64           `json:"latitude" binding:"required"`
+	Longitude        float64           `json:"longitude" binding:"required"`
+	Phone            string            `json:"phone" binding:"required"`
+	Email            string            `json:"email" binding:"required,email"`
+	CategoryIDs      []string          `json:"category_ids"`
+	OpeningHours     OpeningHours      `json:"opening_hours"`
+	Timezone         string            `json:"timezone"`
+	HolidayOverrides []HolidayOverride `json:"holiday_overrides"`
+	DeliveryInfo     DeliveryInfo      `json:"delivery_info"`
+	DeliveryZone     []geo.Point       `json:"delivery_zone"`
+}
+
+// StoreReviewDecision is an admin's verdict on a store awaiting approval
+type StoreReviewDecision string
+
+const (
+	DecisionApprove StoreReviewDecision = "approve"
+	DecisionReject  StoreReviewDecision = "reject"
+)
+
+type ReviewStoreRequest struct {
+	Decision StoreReviewDecision `json:"decision" binding:"required,oneof=approve reject"`
+	Reason   string              `json:"reason" binding:"required_if=Decision reject,max=1000"`
 }
 
 type CreateProductRequest struct {
-	CategoryID  string             `json:"category_id" binding:"required"`
-	Name        string             `json:"name" binding:"required"`
-	Description string             `json:"description"`
-	Price       float64            `json:"price" binding:"required,min=0"`
-	Image       string             `json:"image"`
-	Options     []ProductOptionReq `json:"options"`
-	Nutrition   NutritionInfo      `json:"nutrition"`
-	Tags        []string           `json:"tags"`
+	CategoryID    string               `json:"category_id" binding:"required"`
+	Name          string               `json:"name" binding:"required"`
+	Description   string               `json:"description"`
+	Price         float64              `json:"price" binding:"required,min=0"`
+	Image         string               `json:"image"`
+	TrackStock    bool                 `json:"track_stock"`
+	StockQuantity int                  `json:"stock_quantity" binding:"min=0"`
+	Availability  []AvailabilityWindow `json:"availability"`
+	Options       []ProductOptionReq   `json:"options"`
+	Nutrition     NutritionInfo        `json:"nutrition"`
+	Tags          []string             `json:"tags"`
+	Allergens     []Allergen           `json:"allergens"`
+	DietaryTags   []DietaryTag         `json:"dietary_tags"`
+	PrepTime      int                  `json:"prep_time" binding:"min=0"`
+	Bulky         bool                 `json:"bulky"`
+	RequiresCold  bool                 `json:"requires_cold"`
+}
+
+type CreateStoreReviewRequest struct {
+	OrderID string `json:"order_id" binding:"required"`
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment" binding:"max=1000"`
 }
 
 type ProductOptionReq struct {
-	Name     string                   `json:"name" binding:"required"`
-	Type     ProductOptionType        `json:"type" binding:"required"`
-	Required bool                     `json:"required"`
-	Options  []ProductOptionChoiceReq `json:"options" binding:"required,min=1"`
+	Name          string                   `json:"name" binding:"required"`
+	Type          ProductOptionType        `json:"type" binding:"required"`
+	Required      bool                     `json:"required"`
+	MinSelections int                      `json:"min_selections" binding:"min=0"`
+	MaxSelections int                      `json:"max_selections" binding:"min=0"`
+	Options       []ProductOptionChoiceReq `json:"options" binding:"required,min=1"`
 }
 
 type ProductOptionChoiceReq struct {
@@ -159,6 +352,17 @@ type ProductOptionChoiceReq struct {
 	PriceExtra float64 `json:"price_extra"`
 }
 
+type CreatePromotionRequest struct {
+	Type     DiscountType `json:"type" binding:"required"`
+	Value    float64      `json:"value" binding:"required,gt=0"`
+	StartsAt time.Time    `json:"starts_at" binding:"required"`
+	EndsAt   time.Time    `json:"ends_at" binding:"required"`
+}
+
+type ReorderProductImagesRequest struct {
+	ImageIDs []string `json:"image_ids" binding:"required,min=1"` // every image of the product, in display order
+}
+
 type CreateCategoryRequest struct {
 	Name        string  `json:"name" binding:"required"`
 	Description string  `json:"description"`
@@ -167,6 +371,8 @@ type CreateCategoryRequest struct {
 	SortOrder   int     `json:"sort_order"`
 }
 
+// StoreSearchRequest filters stores. With coordinates, stores whose delivery
+// area doesn't reach them are left out.
 type StoreSearchRequest struct {
 	Query      string  `json:"query,omitempty"`
 	CategoryID string  `json:"category_id,omitempty"`
@@ -177,6 +383,61 @@ type StoreSearchRequest struct {
 	SortBy     string  `json:"sort_by,omitempty"` // rating, distance, delivery_time
 	Limit      int     `json:"limit,omitempty"`
 	Offset     int     `json:"offset,omitempty"`
+
+	// Only stores with at least one available product matching the dietary filters
+	Dietary          []DietaryTag `json:"dietary,omitempty"`
+	ExcludeAllergens []Allergen   `json:"exclude_allergens,omitempty"`
+
+	// Admin listings only; public searches never return unapproved stores
+	Status            StoreStatus `json:"-"`
+	IncludeUnapproved bool        `json:"-"`
+}
+
+// ImportMode controls how a bulk product import treats invalid rows
+type ImportMode string
+
+const (
+	ImportAllOrNothing ImportMode = "all_or_nothing" // any invalid row aborts the import
+	ImportBestEffort   ImportMode = "best_effort"    // valid rows are imported, invalid ones reported
+)
+
+// MaxProductImportSize is the largest product import CSV accepted, in bytes
+const MaxProductImportSize = 2 << 20
+
+type ProductImportRow struct {
+	Row       int    `json:"row"` // 1-based data row, excluding the header
+	Name      string `json:"name"`
+	ProductID string `json:"product_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ProductImportReport struct {
+	Mode     ImportMode         `json:"mode"`
+	Total    int                `json:"total"`
+	Imported int                `json:"imported"`
+	Failed   int                `json:"failed"`
+	Rows     []ProductImportRow `json:"rows"`
+}
+
+// ProductSearchRequest is a ranked, typo-tolerant search across all stores,
+// optionally limited to stores within Radius km of the coordinates
+type ProductSearchRequest struct {
+	Query      string  `json:"q"`
+	CategoryID string  `json:"category_id,omitempty"` // includes subcategories
+	Latitude   float64 `json:"latitude,omitempty"`
+	Longitude  float64 `json:"longitude,omitempty"`
+	Radius     float64 `json:"radius,omitempty"` // in kilometers
+	Limit      int     `json:"limit,omitempty"`
+	Offset     int     `json:"offset,omitempty"`
+
+	Dietary          []DietaryTag `json:"dietary,omitempty"`           // product must carry every tag
+	ExcludeAllergens []Allergen   `json:"exclude_allergens,omitempty"` // product must declare none of these
+}
+
+type ProductSearchResult struct {
+	Product
+	StoreName string  `json:"store_name"`
+	Score     float64 `json:"score"`
 }
 
 // Repository interfaces (ports)
@@ -186,18 +447,56 @@ type StoreRepository interface {
 	GetByMerchantID(merchantID string) (*Store, error)
 	Update(store *Store) error
 	Delete(id string) error
+	Restore(id string) error
 	Search(req StoreSearchRequest) ([]Store, error)
 	List(limit, offset int) ([]Store, error)
 }
 
+type StoreReviewRepository interface {
+	// Create saves the review and recomputes the store's rating and review
+	// count in the same transaction
+	Create(review *StoreReview) error
+	GetByOrderID(orderID string) (*StoreReview, error)
+	GetByStoreID(storeID string, limit, offset int) ([]StoreReview, error)
+}
+
+type DeliveredOrderRepository interface {
+	Save(order *DeliveredOrder) error
+	GetByOrderID(orderID string) (*DeliveredOrder, error)
+}
+
 type ProductRepository interface {
 	Create(product *Product) error
+	CreateBatch(products []Product) error
 	GetByID(id string) (*Product, error)
 	GetByStoreID(storeID string, limit, offset int) ([]Product, error)
 	GetByCategoryID(categoryID string, limit, offset int) ([]Product, error)
 	Update(product *Product) error
 	Delete(id string) error
+	Restore(id string) error
 	Search(query string, storeID string, limit, offset int) ([]Product, error)
+	RankedSearch(req ProductSearchRequest) ([]ProductSearchResult, error)
+	// DecrementStock reserves the items for an order; it does nothing if the
+	// order's stock was already reserved or released
+	DecrementStock(storeID, orderID string, items []OrderItem) error
+	// RestoreStock releases the stock reserved for an order; it does nothing
+	// if it was already released, and stops a later DecrementStock for the
+	// order if nothing was reserved yet
+	RestoreStock(storeID, orderID string, items []OrderItem) error
+}
+
+type ProductImageRepository interface {
+	Create(image *ProductImage) error
+	GetByID(id string) (*ProductImage, error)
+	GetByProductID(productID string) ([]ProductImage, error)
+	UpdateAll(images []ProductImage) error
+	Delete(id string) error
+}
+
+// FileStorage stores uploaded files and returns the URL they are served from
+type FileStorage interface {
+	Save(key string, contentType string, data io.Reader) (string, error)
+	Delete(key string) error
 }
 
 type CategoryRepository interface {
@@ -205,8 +504,10 @@ type CategoryRepository interface {
 	GetByID(id string) (*Category, error)
 	GetAll() ([]Category, error)
 	GetByParentID(parentID *string) ([]Category, error)
+	GetSubtreeIDs(id string) ([]string, error)
 	Update(category *Category) error
 	Delete(id string) error
+	Restore(id string) error
 }
 
 // Service interfaces (ports)
@@ -217,44 +518,151 @@ type CatalogService interface {
 	GetMerchantStore(merchantID string) (*Store, error)
 	UpdateStore(storeID string, merchantID string, updates map[string]interface{}) (*Store, error)
 	SearchStores(req StoreSearchRequest) ([]Store, error)
+	DeleteStore(storeID string) error
+	RestoreStore(storeID string) error
+	// ReviewStore approves or rejects a store pending approval and notifies its merchant
+	ReviewStore(storeID string, adminID string, req ReviewStoreRequest) (*Store, error)
 
 	// Product management
 	CreateProduct(storeID string, merchantID string, req CreateProductRequest) (*Product, error)
+	ImportProducts(storeID string, merchantID string, csv io.Reader, mode ImportMode) (*ProductImportReport, error)
 	GetProduct(productID string) (*Product, error)
-	GetStoreProducts(storeID string, limit, offset int) ([]Product, error)
+	GetStoreProducts(storeID string, limit, offset int, includeUnavailable bool) ([]Product, error)
 	UpdateProduct(productID string, merchantID string, updates map[string]interface{}) (*Product, error)
 	DeleteProduct(productID string, merchantID string) error
+	RestoreProduct(productID string) error
+	AddProductPromotion(productID string, merchantID string, req CreatePromotionRequest) (*Product, error)
+	RemoveProductPromotion(productID string, merchantID string, promotionID string) (*Product, error)
 	SearchProducts(query string, storeID string, limit, offset int) ([]Product, error)
+	SearchProductsRanked(req ProductSearchRequest) ([]ProductSearchResult, error)
+
+	// Product images
+	UploadProductImage(productID string, merchantID string, data io.Reader) (*ProductImage, error)
+	ReorderProductImages(productID string, merchantID string, imageIDs []string) ([]ProductImage, error)
+	SetPrimaryProductImage(productID string, merchantID string, imageID string) ([]ProductImage, error)
+	DeleteProductImage(productID string, merchantID string, imageID string) error
 
 	// Category management
 	CreateCategory(req CreateCategoryRequest) (*Category, error)
 	GetCategory(categoryID string) (*Category, error)
 	GetCategories() ([]Category, error)
+	GetCategoryTree() ([]Category, error)
 	UpdateCategory(categoryID string, updates map[string]interface{}) (*Category, error)
 	DeleteCategory(categoryID string) error
+	RestoreCategory(categoryID string) error
+
+	// Store reviews
+	SubmitStoreReview(storeID string, customerID string, req CreateStoreReviewRequest) (*StoreReview, error)
+	GetStoreReviews(storeID string, limit, offset int) ([]StoreReview, error)
+	RecordDeliveredOrder(order DeliveredOrder) error
 
 	// Order validation (for Order Service)
-	ValidateOrderItems(storeID string, items []OrderItem) (*OrderValidation, error)
+	ValidateOrderItems(storeID string, req ValidateOrderRequest) (*OrderValidation, error)
+	DecrementStock(storeID string, req StockRequest) error
+	RestoreStock(storeID string, req StockRequest) error
 }
 
+// ErrCategoryCycle is returned when a category would become its own ancestor
+var ErrCategoryCycle = errors.New("category cannot be moved under itself or one of its subcategories")
+
+// ErrNotDeleted is returned when restoring a record that does not exist or was not deleted
+var ErrNotDeleted = errors.New("no deleted record with this ID")
+
+// ErrInsufficientStock is returned when a stock decrement would go below zero
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrInvalidOptionLimits is returned when an option's selection limits can't be satisfied
+var ErrInvalidOptionLimits = errors.New("invalid option selection limits")
+
+// ErrInvalidDeliveryZone is returned for a delivery zone that isn't a polygon of valid coordinates
+var ErrInvalidDeliveryZone = errors.New("invalid delivery zone")
+
+// ErrReviewExists is returned when the order has already been reviewed
+var ErrReviewExists = errors.New("order has already been reviewed")
+
+// ErrOrderNotReviewable is returned when the order wasn't delivered to the customer from this store
+var ErrOrderNotReviewable = errors.New("order cannot be reviewed")
+
+// ErrStoreNotPending is returned when reviewing a store that isn't awaiting approval
+var ErrStoreNotPending = errors.New("store is not pending approval")
+
+// ErrStoreNotApproved is returned when a merchant opens a store an admin hasn't approved
+var ErrStoreNotApproved = errors.New("store has not been approved")
+
+// ErrInvalidStoreStatus is returned when a merchant sets a status other than open, closed or paused
+var ErrInvalidStoreStatus = errors.New("invalid store status")
+
+// ErrUnknownDietaryTag is returned for an allergen or dietary tag outside the supported lists
+var ErrUnknownDietaryTag = errors.New("unknown allergen or dietary tag")
+
+// ErrImportTooLarge is returned for a product import CSV over MaxProductImportSize
+var ErrImportTooLarge = errors.New("import file is too large")
+
 // External DTOs (for Order Service integration)
 type OrderItem struct {
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
+	ProductID string           `json:"product_id"`
+	Quantity  int              `json:"quantity"`
+	UnitPrice float64          `json:"unit_price,omitempty"` // price the customer saw; checked for drift when set
+	Options   []SelectedOption `json:"options,omitempty"`
+}
+
+// SelectedOption is the customer's choice(s) for one of the product's options
+type SelectedOption struct {
+	OptionID  string   `json:"option_id"`
+	ChoiceIDs []string `json:"choice_ids"`
+}
+
+// ValidateOrderRequest is an order to check before it is placed. Without a
+// delivery location the store's delivery area is not checked.
+type ValidateOrderRequest struct {
+	Items            []OrderItem `json:"items"`
+	DeliveryLocation *geo.Point  `json:"delivery_location,omitempty"`
+}
+
+// StockRequest is an order's items to reserve or release
+type StockRequest struct {
+	OrderID string      `json:"order_id" binding:"required"`
+	Items   []OrderItem `json:"items" binding:"required"`
 }
 
 type OrderValidation struct {
-	Valid       bool                 `json:"valid"`
-	Items       []ValidatedOrderItem `json:"items"`
-	TotalAmount float64              `json:"total_amount"`
-	Errors      []string             `json:"errors,omitempty"`
+	Valid        bool                 `json:"valid"`
+	Items        []ValidatedOrderItem `json:"items"`
+	TotalAmount  float64              `json:"total_amount"`  // recomputed subtotal of the orderable lines
+	PriceChanged bool                 `json:"price_changed"` // some line's price differs from its unit_price
+	PrepTime     int                  `json:"prep_time"`     // minutes, the slowest line's prep time
+	// HasLargeItems and HasColdItems tell the delivery service the order needs more than a bicycle
+	HasLargeItems bool     `json:"has_large_items"`
+	HasColdItems  bool     `json:"has_cold_items"`
+	Errors        []string `json:"errors,omitempty"`
 }
 
+// ValidatedOrderItem reports on one requested line. Removed products are
+// still listed, with Exists false.
 type ValidatedOrderItem struct {
-	ProductID string  `json:"product_id"`
-	Name      string  `json:"name"`
-	Price     float64 `json:"price"`
-	Quantity  int     `json:"quantity"`
-	Available bool    `json:"available"`
-	Subtotal  float64 `json:"subtotal"`
+	ProductID     string    `json:"product_id"`
+	Name          string    `json:"name,omitempty"`
+	Exists        bool      `json:"exists"`
+	Available     bool      `json:"available"`
+	OptionsValid  bool      `json:"options_valid"`
+	Issue         ItemIssue `json:"issue,omitempty"`          // why the line can't be ordered
+	Price         float64   `json:"price"`                    // current unit price: the promoted price plus option surcharges
+	OriginalPrice float64   `json:"original_price"`           // list price without promotions or surcharges
+	OptionsPrice  float64   `json:"options_price"`            // surcharge of the selected choices, per unit
+	ExpectedPrice float64   `json:"expected_price,omitempty"` // the requested unit_price
+	PriceChanged  bool      `json:"price_changed"`
+	Quantity      int       `json:"quantity"`
+	Subtotal      float64   `json:"subtotal"`
 }
+
+// ItemIssue is the first problem found with an order line
+type ItemIssue string
+
+const (
+	ItemIssueNotFound       ItemIssue = "not_found"
+	ItemIssueWrongStore     ItemIssue = "wrong_store"
+	ItemIssueUnavailable    ItemIssue = "unavailable"
+	ItemIssueOutsideHours   ItemIssue = "outside_hours"
+	ItemIssueOutOfStock     ItemIssue = "out_of_stock"
+	ItemIssueInvalidOptions ItemIssue = "invalid_options"
+)
@@ -3,14 +3,22 @@ package main
 import (
 	"log"
 	"net/http"
-	"os"
 
 	"glovo-backend/services/delivery-service/internal/adapters/client"
 	"glovo-backend/services/delivery-service/internal/adapters/db"
 	httpHandler "glovo-backend/services/delivery-service/internal/adapters/http"
+	"glovo-backend/services/delivery-service/internal/adapters/subscriber"
 	"glovo-backend/services/delivery-service/internal/app"
 	"glovo-backend/services/delivery-service/internal/domain"
+	"glovo-backend/shared/config"
 	"glovo-backend/shared/database"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/health"
+	"glovo-backend/shared/idempotency"
+	"glovo-backend/shared/logging"
+	"glovo-backend/shared/metrics"
+	"glovo-backend/shared/middleware"
+	"glovo-backend/shared/server"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -38,12 +46,23 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
+// @securityDefinitions.apikey ServiceToken
+// @in header
+// @name X-Service-Token
+// @description Signed service token for internal service-to-service calls.
+
 func main() {
+	logging.Setup("delivery-service")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
 	}
 
+	// Fail fast on missing or malformed configuration
+	cfg := config.MustLoad("delivery-service", config.Postgres(), config.Auth(), config.EventBus(), config.HTTPPort("PORT", "8004"),
+		[]config.Var{{Key: "MAX_ACTIVE_DELIVERIES_PER_DRIVER", Kind: config.Int, Default: "3"}})
+
 	// Initialize database connection
 	postgresDB := database.ConnectPostgres()
 
@@ -52,6 +71,7 @@ func main() {
 		&domain.Delivery{},
 		&domain.DeliveryAssignment{},
 		&domain.DriverPerformance{},
+		&idempotency.Record{},
 	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
@@ -67,6 +87,16 @@ func main() {
 	locationService := client.NewMockLocationService()
 	notificationService := client.NewMockNotificationService()
 	paymentService := client.NewMockPaymentService()
+	configService := client.NewMockConfigService()
+
+	// Delivery fees are computed here from the delivery.* system config
+	pricingEngine := app.NewPricingEngine(configService)
+
+	// Delivery events are consumed by the payment and notification services;
+	// set EVENT_BUS=redis to deliver them across processes
+	eventBus := events.FromEnv()
+	defer eventBus.Close()
+	middleware.AuditImpersonation(eventBus, "delivery-service")
 
 	// Initialize use case
 	deliveryService := app.NewDeliveryService(
@@ -78,24 +108,30 @@ func main() {
 		locationService,
 		notificationService,
 		paymentService,
+		pricingEngine,
+		eventBus,
+		cfg.Int("MAX_ACTIVE_DELIVERIES_PER_DRIVER"),
 	)
 
-	// Setup Gin router
-	router := gin.Default()
+	// Keep customer ETAs in step with the location service's recalculations
+	if err := subscriber.NewLocationSubscriber(deliveryService).Register(eventBus); err != nil {
+		log.Fatal("Failed to subscribe to location events:", err)
+	}
 
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type,Authorization")
+	// Setup Gin router
+	router := gin.New()
+	// Client IPs come from X-Forwarded-For only behind TRUSTED_PROXIES
+	if err := router.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	router.Use(gin.Recovery(), middleware.RequestID(), middleware.RequestLogger())
+	router.Use(metrics.Middleware("delivery-service"))
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// CORS, restricted to the origins in CORS_ALLOWED_ORIGINS
+	router.Use(middleware.CORS(middleware.CORSConfigFromEnv()))
 
-		c.Next()
-	})
+	// Prometheus metrics
+	router.GET("/metrics", metrics.Handler())
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -105,8 +141,11 @@ func main() {
 		})
 	})
 
+	// Readiness check, verifies database connectivity
+	router.GET("/health/ready", health.Ready("delivery-service", map[string]health.Check{"postgres": health.Postgres(postgresDB)}))
+
 	// Initialize HTTP handler
-	handler := httpHandler.NewDeliveryHandler(deliveryService)
+	handler := httpHandler.NewDeliveryHandler(deliveryService, idempotency.NewGormStore(postgresDB, "delivery-service"))
 
 	// Setup routes
 	v1 := router.Group("/api/v1")
@@ -116,17 +155,11 @@ func main() {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Start server
-	port := getEnv("PORT", "8004")
+	port := cfg.String("PORT")
 	log.Printf("Delivery Service starting on port %s", port)
 
-	if err := router.Run(":" + port); err != nil {
+	if err := server.RunGraceful(":"+port, router, server.DefaultShutdownTimeout); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
-}
-
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultValue
+	log.Println("Server stopped")
 }
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"time"
+
 	"glovo-backend/services/delivery-service/internal/domain"
 )
 
@@ -14,9 +16,12 @@ func NewMockOrderService() domain.OrderService {
 func (m *mockOrderService) GetOrder(orderID string) (*domain.OrderInfo, error) {
 	return &domain.OrderInfo{
 		ID:           orderID,
+		CustomerID:   "customer-123",
+		MerchantID:   "merchant-123",
 		CustomerName: "John Doe",
 		Items:        3,
 		TotalAmount:  29.99,
+		PrepTime:     15,
 	}, nil
 }
 
@@ -54,6 +59,8 @@ func (m *mockDriverService) GetAvailableDrivers(latitude, longitude, radius floa
 			Distance:    2.5,
 			Rating:      4.8,
 			ETA:         10,
+			VehicleType: domain.VehicleMotorcycle,
+			OnShift:     true,
 			IsOnline:    true,
 			IsAvailable: true,
 		},
@@ -95,6 +102,14 @@ func (m *mockLocationService) GetDeliveryTracking(deliveryID string) (*domain.Tr
 	}, nil
 }
 
+func (m *mockLocationService) GetLocationHistory(driverID string, startTime, endTime time.Time) ([]domain.Location, error) {
+	return []domain.Location{
+		{Latitude: 40.7128, Longitude: -74.0060, Timestamp: startTime},
+		{Latitude: 40.7150, Longitude: -74.0030, Timestamp: startTime.Add(endTime.Sub(startTime) / 2)},
+		{Latitude: 40.7170, Longitude: -74.0000, Timestamp: endTime},
+	}, nil
+}
+
 func (m *mockLocationService) CalculateETA(from, to domain.Location) (int, error) {
 	return 15, nil
 }
@@ -106,15 +121,15 @@ func NewMockNotificationService() domain.NotificationService {
 	return &mockNotificationService{}
 }
 
-func (m *mockNotificationService) SendDeliveryAssignment(driverID string, delivery *domain.Delivery) error {
+func (m *mockNotificationService) SendDeliveryUpdate(orderID string, status domain.DeliveryStatus) error {
 	return nil
 }
 
-func (m *mockNotificationService) SendDeliveryUpdate(orderID string, status domain.DeliveryStatus) error {
+func (m *mockNotificationService) SendDriverNotification(driverID string, message string) error {
 	return nil
 }
 
-func (m *mockNotificationService) SendDriverNotification(driverID string, message string) error {
+func (m *mockNotificationService) SendETAUpdate(orderID, customerID string, arrival time.Time) error {
 	return nil
 }
 
@@ -132,3 +147,14 @@ func (m *mockPaymentService) ProcessDeliveryPayment(deliveryID string) error {
 func (m *mockPaymentService) CalculateDriverPayout(deliveryID string) (float64, error) {
 	return 15.50, nil
 }
+
+// Mock Config Service, with no overrides so pricing uses its defaults
+type mockConfigService struct{}
+
+func NewMockConfigService() domain.ConfigService {
+	return &mockConfigService{}
+}
+
+func (m *mockConfigService) GetFloat(key string) (float64, bool, error) {
+	return 0, false, nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"glovo-backend/services/delivery-service/internal/domain"
+	"glovo-backend/shared/pagination"
 
 	"gorm.io/gorm"
 )
@@ -56,7 +57,9 @@ func (r *deliveryRepository) GetByStatus(status domain.DeliveryStatus, limit, of
 	return deliveries, err
 }
 
-func (r *deliveryRepository) Search(req domain.DeliverySearchRequest) ([]domain.Delivery, error) {
+// Search returns up to req.Limit+1 matching deliveries after cursor, newest
+// first; the extra row tells the caller whether there is a next page
+func (r *deliveryRepository) Search(req domain.DeliverySearchRequest, cursor *pagination.Cursor) ([]domain.Delivery, error) {
 	query := r.db.Model(&domain.Delivery{})
 
 	if req.Status != "" {
@@ -65,6 +68,9 @@ func (r *deliveryRepository) Search(req domain.DeliverySearchRequest) ([]domain.
 	if req.DriverID != "" {
 		query = query.Where("driver_id = ?", req.DriverID)
 	}
+	if req.CustomerID != "" {
+		query = query.Where("customer_id = ?", req.CustomerID)
+	}
 	if req.Priority != "" {
 		query = query.Where("priority = ?", req.Priority)
 	}
@@ -75,14 +81,8 @@ func (r *deliveryRepository) Search(req domain.DeliverySearchRequest) ([]domain.
 		query = query.Where("created_at <= ?", req.DateTo)
 	}
 
-	if req.Limit == 0 {
-		req.Limit = 20
-	}
-
 	var deliveries []domain.Delivery
-	err := query.Order("created_at DESC").
-		Limit(req.Limit).
-		Offset(req.Offset).
+	err := pagination.Apply(query, cursor, req.Limit, "created_at", "id").
 		Find(&deliveries).Error
 	return deliveries, err
 }
@@ -103,11 +103,14 @@ func (r *deliveryRepository) GetPendingDeliveries() ([]domain.Delivery, error) {
 
 func (r *deliveryRepository) GetActiveDeliveries() ([]domain.Delivery, error) {
 	var deliveries []domain.Delivery
-	err := r.db.Where("status IN ?", []domain.DeliveryStatus{
-		domain.StatusAssigned,
-		domain.StatusAccepted,
-		domain.StatusPickedUp,
-		domain.StatusInTransit,
-	}).Find(&deliveries).Error
+	err := r.db.Where("status IN ?", domain.ActiveStatuses).Find(&deliveries).Error
 	return deliveries, err
 }
+
+func (r *deliveryRepository) CountActiveByDriverID(driverID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.Delivery{}).
+		Where("driver_id = ? AND status IN ?", driverID, domain.ActiveStatuses).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+)
+
+func TestDeliverySearchByCustomer(t *testing.T) {
+	tx := testDB(t)
+	repo := NewDeliveryRepository(tx)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, delivery := range []domain.Delivery{
+		{ID: "delivery-a", OrderID: "order-a", CustomerID: "customer-1"},
+		{ID: "delivery-b", OrderID: "order-b", CustomerID: "customer-2"},
+		{ID: "delivery-c", OrderID: "order-c", CustomerID: "customer-1"},
+	} {
+		delivery.Status = domain.StatusPending
+		delivery.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := repo.Create(&delivery); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	deliveries, err := repo.Search(domain.DeliverySearchRequest{CustomerID: "customer-1", Limit: 10}, nil)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0].ID != "delivery-c" || deliveries[1].ID != "delivery-a" {
+		t.Fatalf("deliveries = %+v, want delivery-c and delivery-a", deliveries)
+	}
+	for _, delivery := range deliveries {
+		if delivery.CustomerID != "customer-1" {
+			t.Fatalf("search returned %s of %s", delivery.ID, delivery.CustomerID)
+		}
+	}
+}
@@ -39,6 +39,17 @@ func (r *driverPerformanceRepository) GetTopDrivers(limit int) ([]domain.DriverP
 	return performances, err
 }
 
+// GetPunctualityRankings orders drivers from least to most late on average,
+// skipping drivers with no completed deliveries
+func (r *driverPerformanceRepository) GetPunctualityRankings(limit int) ([]domain.DriverPerformance, error) {
+	var performances []domain.DriverPerformance
+	err := r.db.Where("completed_deliveries > 0").
+		Order("average_lateness ASC, completed_deliveries DESC").
+		Limit(limit).
+		Find(&performances).Error
+	return performances, err
+}
+
 func (r *driverPerformanceRepository) GetDriverRankings() ([]domain.DriverPerformance, error) {
 	var performances []domain.DriverPerformance
 	err := r.db.Order("average_rating DESC, completed_deliveries DESC").
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"testing"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+)
+
+func TestGetPunctualityRankings(t *testing.T) {
+	tx := testDB(t)
+	repo := NewDriverPerformanceRepository(tx)
+
+	for _, performance := range []domain.DriverPerformance{
+		{ID: "p1", DriverID: "late", CompletedDeliveries: 10, AverageLateness: 12},
+		{ID: "p2", DriverID: "early", CompletedDeliveries: 3, AverageLateness: -4},
+		{ID: "p3", DriverID: "punctual-busy", CompletedDeliveries: 20, AverageLateness: 1},
+		{ID: "p4", DriverID: "punctual", CompletedDeliveries: 5, AverageLateness: 1},
+		{ID: "p5", DriverID: "new", AverageLateness: 0},
+	} {
+		if err := repo.Create(&performance); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	rankings, err := repo.GetPunctualityRankings(10)
+	if err != nil {
+		t.Fatalf("GetPunctualityRankings: %v", err)
+	}
+	// Ties on lateness go to the driver with more deliveries; drivers
+	// without completed deliveries aren't ranked
+	want := []string{"early", "punctual-busy", "punctual", "late"}
+	if len(rankings) != len(want) {
+		t.Fatalf("got %d rankings, want %d", len(rankings), len(want))
+	}
+	for i, performance := range rankings {
+		if performance.DriverID != want[i] {
+			t.Fatalf("rank %d = %s, want %s", i+1, performance.DriverID, want[i])
+		}
+	}
+
+	if top, _ := repo.GetPunctualityRankings(1); len(top) != 1 || top[0].DriverID != "early" {
+		t.Fatalf("top 1 = %+v, want early", top)
+	}
+}
@@ -0,0 +1,22 @@
+package db
+
+import (
+	"testing"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+	"glovo-backend/shared/database/dbtest"
+
+	"gorm.io/gorm"
+)
+
+// testDB returns a dbtest transaction with the delivery service's tables migrated
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return dbtest.Open(t, func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&domain.Delivery{},
+			&domain.DeliveryAssignment{},
+			&domain.DriverPerformance{},
+		)
+	})
+}
@@ -1,32 +1,59 @@
 package http
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
 	"glovo-backend/services/delivery-service/internal/domain"
 	"glovo-backend/shared/auth"
+	"glovo-backend/shared/idempotency"
 	"glovo-backend/shared/middleware"
+	"glovo-backend/shared/pagination"
+	"glovo-backend/shared/response"
 
 	"github.com/gin-gonic/gin"
 )
 
 type DeliveryHandler struct {
-	deliveryService domain.DeliveryService
+	deliveryService  domain.DeliveryService
+	idempotencyStore idempotency.Store
 }
 
-func NewDeliveryHandler(deliveryService domain.DeliveryService) *DeliveryHandler {
+func init() {
+	response.Register(domain.ErrDeliveryExists, response.CodeConflict)
+	response.Register(domain.ErrNotAssignedDriver, response.CodeForbidden)
+	response.Register(domain.ErrInvalidStatusTransition, response.CodeConflict)
+	response.Register(domain.ErrNoAvailableDrivers, response.CodeUnprocessable)
+	response.Register(domain.ErrDriverUnavailable, response.CodeConflict)
+	response.Register(domain.ErrVehicleTooSmall, response.CodeUnprocessable)
+	response.Register(domain.ErrDriverAtCapacity, response.CodeConflict)
+	response.Register(domain.ErrAssignmentNotFound, response.CodeNotFound)
+	response.Register(domain.ErrAssignmentExpired, response.CodeConflict)
+	response.Register(domain.ErrProofRequired, response.CodeUnprocessable)
+	response.Register(domain.ErrNoTrail, response.CodeConflict)
+	response.Register(domain.ErrInvalidPricing, response.CodeInvalidRequest)
+	response.Register(pagination.ErrInvalidCursor, response.CodeInvalidRequest)
+}
+
+func NewDeliveryHandler(deliveryService domain.DeliveryService, idempotencyStore idempotency.Store) *DeliveryHandler {
 	return &DeliveryHandler{
-		deliveryService: deliveryService,
+		deliveryService:  deliveryService,
+		idempotencyStore: idempotencyStore,
 	}
 }
 
 func (h *DeliveryHandler) SetupRoutes(router *gin.RouterGroup) {
-	// Public delivery endpoints (for order service integration)
-	public := router.Group("/deliveries")
+	// Callers may retry these with the same Idempotency-Key
+	idempotent := idempotency.Middleware(h.idempotencyStore, idempotency.DefaultTTL)
+
+	// Internal delivery endpoints (for order service integration)
+	internal := router.Group("/deliveries")
+	internal.Use(middleware.ServiceAuth())
 	{
-		public.POST("/create", h.createDelivery)
-		public.GET("/:id/status", h.getDeliveryStatus)
+		internal.POST("/create", idempotent, h.createDelivery)
+		internal.GET("/:id/status", h.getDeliveryStatus)
 	}
 
 	// Driver delivery management
@@ -60,12 +87,14 @@ func (h *DeliveryHandler) SetupRoutes(router *gin.RouterGroup) {
 	{
 		admin.GET("/", h.searchDeliveries)
 		admin.GET("/:id", h.getDelivery)
+		admin.GET("/:id/trail", h.getDeliveryTrail)
 		admin.PUT("/:id/assign", h.assignDelivery)
 		admin.PUT("/:id/reassign", h.reassignDelivery)
 		admin.PUT("/:id/cancel", h.cancelDelivery)
 		admin.GET("/metrics", h.getDeliveryMetrics)
 		admin.GET("/drivers/:id/performance", h.getDriverPerformance)
 		admin.GET("/drivers/rankings", h.getDriverRankings)
+		admin.GET("/drivers/punctuality", h.getPunctualityRankings)
 		admin.GET("/system/stats", h.getSystemStats)
 	}
 }
@@ -75,21 +104,22 @@ func (h *DeliveryHandler) SetupRoutes(router *gin.RouterGroup) {
 // @Tags deliveries
 // @Accept json
 // @Produce json
+// @Security ServiceToken
 // @Param request body domain.CreateDeliveryRequest true "Delivery data"
 // @Success 201 {object} domain.Delivery
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/deliveries/create [post]
 func (h *DeliveryHandler) createDelivery(c *gin.Context) {
 	var req domain.CreateDeliveryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
 	delivery, err := h.deliveryService.CreateDelivery(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -100,17 +130,18 @@ func (h *DeliveryHandler) createDelivery(c *gin.Context) {
 // @Description Get the current status of a delivery
 // @Tags deliveries
 // @Produce json
+// @Security ServiceToken
 // @Param id path string true "Delivery ID"
 // @Success 200 {object} domain.DeliveryStatusResponse
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 404 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/deliveries/{id}/status [get]
 func (h *DeliveryHandler) getDeliveryStatus(c *gin.Context) {
 	deliveryID := c.Param("id")
 
 	delivery, err := h.deliveryService.GetDelivery(deliveryID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -126,14 +157,14 @@ func (h *DeliveryHandler) getDeliveryStatus(c *gin.Context) {
 // @Security BearerAuth
 // @Param limit query int false "Limit results"
 // @Success 200 {array} domain.Delivery
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/driver/deliveries/available [get]
 func (h *DeliveryHandler) getAvailableDeliveries(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
 	assignments, err := h.deliveryService.GetPendingAssignments(userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -147,8 +178,8 @@ func (h *DeliveryHandler) getAvailableDeliveries(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "Delivery ID"
 // @Success 200 {object} domain.Delivery
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/driver/deliveries/{id}/accept [post]
 func (h *DeliveryHandler) acceptDelivery(c *gin.Context) {
 	deliveryID := c.Param("id")
@@ -161,14 +192,14 @@ func (h *DeliveryHandler) acceptDelivery(c *gin.Context) {
 
 	err := h.deliveryService.RespondToAssignment(driverID.(string), req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
 	// Get updated delivery to return
 	delivery, err := h.deliveryService.GetDelivery(deliveryID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -182,8 +213,8 @@ func (h *DeliveryHandler) acceptDelivery(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "Delivery ID"
 // @Success 200 {object} domain.Delivery
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/driver/deliveries/{id}/pickup [post]
 func (h *DeliveryHandler) markPickedUp(c *gin.Context) {
 	deliveryID := c.Param("id")
@@ -191,7 +222,7 @@ func (h *DeliveryHandler) markPickedUp(c *gin.Context) {
 
 	delivery, err := h.deliveryService.PickupOrder(deliveryID, driverID.(string))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -201,20 +232,30 @@ func (h *DeliveryHandler) markPickedUp(c *gin.Context) {
 // @Summary Mark as delivered
 // @Description Mark delivery as delivered to customer
 // @Tags driver
+// @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Delivery ID"
+// @Param request body domain.CompleteDeliveryRequest false "Proof of delivery"
 // @Success 200 {object} domain.Delivery
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 422 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/driver/deliveries/{id}/deliver [post]
 func (h *DeliveryHandler) markDelivered(c *gin.Context) {
 	deliveryID := c.Param("id")
 	driverID, _ := c.Get("user_id")
 
-	delivery, err := h.deliveryService.CompleteDelivery(deliveryID, driverID.(string))
+	// The body is optional; contactless deliveries need the proof photo
+	var req domain.CompleteDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err)
+		return
+	}
+
+	delivery, err := h.deliveryService.CompleteDelivery(deliveryID, driverID.(string), req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -224,24 +265,34 @@ func (h *DeliveryHandler) markDelivered(c *gin.Context) {
 // @Summary Complete delivery
 // @Description Complete the delivery process
 // @Tags driver
+// @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Delivery ID"
+// @Param request body domain.CompleteDeliveryRequest false "Proof of delivery"
 // @Success 200 {object} domain.DeliveryResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 422 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/driver/deliveries/{id}/complete [post]
 func (h *DeliveryHandler) completeDelivery(c *gin.Context) {
 	deliveryID := c.Param("id")
 	driverID, _ := c.Get("user_id")
 
-	response, err := h.deliveryService.CompleteDelivery(deliveryID, driverID.(string))
+	// The body is optional; contactless deliveries need the proof photo
+	var req domain.CompleteDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err)
+		return
+	}
+
+	delivery, err := h.deliveryService.CompleteDelivery(deliveryID, driverID.(string), req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, delivery)
 }
 
 // @Summary Report delivery issue
@@ -253,8 +304,8 @@ func (h *DeliveryHandler) completeDelivery(c *gin.Context) {
 // @Param id path string true "Delivery ID"
 // @Param request body map[string]string true "Issue description"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/driver/deliveries/{id}/issue [post]
 func (h *DeliveryHandler) reportIssue(c *gin.Context) {
 	deliveryID := c.Param("id")
@@ -264,13 +315,13 @@ func (h *DeliveryHandler) reportIssue(c *gin.Context) {
 		Issue string `json:"issue" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
 	err := h.deliveryService.ReportIssue(deliveryID, driverID.(string), req.Issue)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -283,14 +334,14 @@ func (h *DeliveryHandler) reportIssue(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {array} domain.Delivery
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/driver/deliveries/active [get]
 func (h *DeliveryHandler) getActiveDeliveries(c *gin.Context) {
 	driverID, _ := c.Get("user_id")
 
 	deliveries, err := h.deliveryService.GetDriverAssignments(driverID.(string), 50, 0)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -305,7 +356,7 @@ func (h *DeliveryHandler) getActiveDeliveries(c *gin.Context) {
 // @Param limit query int false "Limit results"
 // @Param offset query int false "Offset for pagination"
 // @Success 200 {array} domain.Delivery
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/driver/deliveries/history [get]
 func (h *DeliveryHandler) getDeliveryHistory(c *gin.Context) {
 	driverID, _ := c.Get("user_id")
@@ -317,7 +368,7 @@ func (h *DeliveryHandler) getDeliveryHistory(c *gin.Context) {
 
 	deliveries, err := h.deliveryService.GetDriverAssignments(driverID.(string), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -327,22 +378,28 @@ func (h *DeliveryHandler) getDeliveryHistory(c *gin.Context) {
 // Customer endpoints
 
 // @Summary Track delivery
-// @Description Track delivery progress for customer
+// @Description Track delivery progress for customer. The eta combines the merchant's remaining prep time with the travel time.
 // @Tags customer
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Delivery ID"
 // @Success 200 {object} domain.TrackingInfo
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 404 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/customer/deliveries/{id}/track [get]
 func (h *DeliveryHandler) trackDelivery(c *gin.Context) {
 	deliveryID := c.Param("id")
-	_, _ = c.Get("user_id") // Customer validation can be added here if needed
+	userID := c.GetString("user_id")
 
 	delivery, err := h.deliveryService.GetDelivery(deliveryID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		response.FromError(c, err)
+		return
+	}
+
+	// Don't reveal other customers' deliveries
+	if delivery.Delivery.CustomerID != userID {
+		response.Error(c, response.CodeNotFound, "Delivery not found", nil)
 		return
 	}
 
@@ -353,6 +410,9 @@ func (h *DeliveryHandler) trackDelivery(c *gin.Context) {
 		"pickup_address":   delivery.Delivery.PickupAddress,
 		"delivery_address": delivery.Delivery.DeliveryAddress,
 		"estimated_time":   delivery.Delivery.EstimatedTime,
+		"prep_time":        delivery.Delivery.PrepTime,
+		"eta":              delivery.TrackingInfo.ETA,
+		"instructions":     delivery.Delivery.Instructions,
 		"driver_id":        delivery.Delivery.DriverID,
 		"picked_up_at":     delivery.Delivery.PickedUpAt,
 		"delivered_at":     delivery.Delivery.DeliveredAt,
@@ -368,33 +428,30 @@ func (h *DeliveryHandler) trackDelivery(c *gin.Context) {
 // @Security BearerAuth
 // @Param status query string false "Delivery status filter"
 // @Param limit query int false "Limit results"
-// @Param offset query int false "Offset for pagination"
-// @Success 200 {array} domain.Delivery
-// @Failure 500 {object} map[string]string
+// @Param cursor query string false "Cursor from the previous page's next_cursor"
+// @Success 200 {object} domain.DeliveryPage
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/customer/deliveries [get]
 func (h *DeliveryHandler) getCustomerDeliveries(c *gin.Context) {
-	_, _ = c.Get("user_id") // Customer filtering can be added here if needed
 	status := c.Query("status")
-	limitStr := c.DefaultQuery("limit", "50")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 
 	var deliveryStatus domain.DeliveryStatus
 	if status != "" {
 		deliveryStatus = domain.DeliveryStatus(status)
 	}
 
+	// Customers only ever see their own deliveries
 	req := domain.DeliverySearchRequest{
-		Status: deliveryStatus,
-		Limit:  limit,
-		Offset: offset,
+		Status:     deliveryStatus,
+		CustomerID: c.GetString("user_id"),
+		Limit:      limit,
+		Cursor:     c.Query("cursor"),
 	}
 
 	deliveries, err := h.deliveryService.SearchDeliveries(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -412,19 +469,15 @@ func (h *DeliveryHandler) getCustomerDeliveries(c *gin.Context) {
 // @Param driver_id query string false "Driver ID filter"
 // @Param customer_id query string false "Customer ID filter"
 // @Param limit query int false "Limit results"
-// @Param offset query int false "Offset for pagination"
-// @Success 200 {array} domain.Delivery
-// @Failure 500 {object} map[string]string
+// @Param cursor query string false "Cursor from the previous page's next_cursor"
+// @Success 200 {object} domain.DeliveryPage
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/admin/deliveries [get]
 func (h *DeliveryHandler) searchDeliveries(c *gin.Context) {
 	status := c.Query("status")
 	driverID := c.Query("driver_id")
-	_ = c.Query("customer_id") // Customer filtering not supported in current domain model
-	limitStr := c.DefaultQuery("limit", "100")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
+	customerID := c.Query("customer_id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
 
 	var deliveryStatus domain.DeliveryStatus
 	if status != "" {
@@ -432,15 +485,16 @@ func (h *DeliveryHandler) searchDeliveries(c *gin.Context) {
 	}
 
 	req := domain.DeliverySearchRequest{
-		Status:   deliveryStatus,
-		DriverID: driverID,
-		Limit:    limit,
-		Offset:   offset,
+		Status:     deliveryStatus,
+		DriverID:   driverID,
+		CustomerID: customerID,
+		Limit:      limit,
+		Cursor:     c.Query("cursor"),
 	}
 
 	deliveries, err := h.deliveryService.SearchDeliveries(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -454,21 +508,54 @@ func (h *DeliveryHandler) searchDeliveries(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "Delivery ID"
 // @Success 200 {object} domain.Delivery
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 404 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/admin/deliveries/{id} [get]
 func (h *DeliveryHandler) getDelivery(c *gin.Context) {
 	deliveryID := c.Param("id")
 
 	delivery, err := h.deliveryService.GetDelivery(deliveryID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, delivery)
 }
 
+// @Summary Get delivery GPS trail
+// @Description Get the driver's recorded locations from pickup to drop-off, oldest first, as JSON or as a GeoJSON LineString feature (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Delivery ID"
+// @Param format query string false "json or geojson" default(json)
+// @Success 200 {object} domain.DeliveryTrail
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 404 {object} response.ErrorEnvelope
+// @Failure 409 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/deliveries/{id}/trail [get]
+func (h *DeliveryHandler) getDeliveryTrail(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "geojson" {
+		response.Error(c, response.CodeInvalidRequest, "format must be json or geojson", nil)
+		return
+	}
+
+	trail, err := h.deliveryService.GetDeliveryTrail(c.Param("id"))
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	if format == "geojson" {
+		c.Header("Content-Type", "application/geo+json")
+		c.JSON(http.StatusOK, trail.GeoJSON())
+		return
+	}
+	c.JSON(http.StatusOK, trail)
+}
+
 // @Summary Assign delivery
 // @Description Assign delivery to a specific driver (admin only)
 // @Tags admin
@@ -478,8 +565,8 @@ func (h *DeliveryHandler) getDelivery(c *gin.Context) {
 // @Param id path string true "Delivery ID"
 // @Param request body map[string]string true "Driver assignment"
 // @Success 200 {object} domain.Delivery
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/admin/deliveries/{id}/assign [put]
 func (h *DeliveryHandler) assignDelivery(c *gin.Context) {
 	deliveryID := c.Param("id")
@@ -489,7 +576,7 @@ func (h *DeliveryHandler) assignDelivery(c *gin.Context) {
 		DriverID string `json:"driver_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
@@ -501,7 +588,7 @@ func (h *DeliveryHandler) assignDelivery(c *gin.Context) {
 
 	delivery, err := h.deliveryService.ManualAssignDriver(assignReq, adminID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -517,8 +604,8 @@ func (h *DeliveryHandler) assignDelivery(c *gin.Context) {
 // @Param id path string true "Delivery ID"
 // @Param request body map[string]string true "New driver assignment"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/admin/deliveries/{id}/reassign [put]
 func (h *DeliveryHandler) reassignDelivery(c *gin.Context) {
 	deliveryID := c.Param("id")
@@ -528,13 +615,13 @@ func (h *DeliveryHandler) reassignDelivery(c *gin.Context) {
 		NewDriverID string `json:"new_driver_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
 	err := h.deliveryService.ReassignDelivery(deliveryID, req.NewDriverID, adminID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -542,7 +629,7 @@ func (h *DeliveryHandler) reassignDelivery(c *gin.Context) {
 }
 
 // @Summary Cancel delivery
-// @Description Cancel a delivery (admin only)
+// @Description Cancel a delivery (admin only). Once a driver has accepted, the customer is charged a share of the delivery fee and the driver compensated, per the cancellation policy.
 // @Tags admin
 // @Accept json
 // @Produce json
@@ -550,8 +637,8 @@ func (h *DeliveryHandler) reassignDelivery(c *gin.Context) {
 // @Param id path string true "Delivery ID"
 // @Param request body map[string]string true "Cancellation reason"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/admin/deliveries/{id}/cancel [put]
 func (h *DeliveryHandler) cancelDelivery(c *gin.Context) {
 	deliveryID := c.Param("id")
@@ -561,13 +648,13 @@ func (h *DeliveryHandler) cancelDelivery(c *gin.Context) {
 		Reason string `json:"reason" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
 	err := h.deliveryService.CancelDelivery(deliveryID, req.Reason, adminID.(string), auth.RoleAdmin)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -580,12 +667,12 @@ func (h *DeliveryHandler) cancelDelivery(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} domain.DeliveryMetrics
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/admin/deliveries/metrics [get]
 func (h *DeliveryHandler) getDeliveryMetrics(c *gin.Context) {
 	metrics, err := h.deliveryService.GetDeliveryMetrics()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -599,15 +686,15 @@ func (h *DeliveryHandler) getDeliveryMetrics(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "Driver ID"
 // @Success 200 {object} domain.DriverPerformance
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 404 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/admin/deliveries/drivers/{id}/performance [get]
 func (h *DeliveryHandler) getDriverPerformance(c *gin.Context) {
 	driverID := c.Param("id")
 
 	performance, err := h.deliveryService.GetDriverPerformance(driverID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -620,12 +707,33 @@ func (h *DeliveryHandler) getDriverPerformance(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {array} domain.DriverPerformance
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/admin/deliveries/drivers/rankings [get]
 func (h *DeliveryHandler) getDriverRankings(c *gin.Context) {
 	rankings, err := h.deliveryService.GetDriverRankings()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rankings)
+}
+
+// @Summary Get driver punctuality rankings
+// @Description Rank drivers by average lateness (actual minus estimated delivery time), most punctual first (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit results" default(20)
+// @Success 200 {array} domain.DriverPerformance
+// @Failure 500 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/deliveries/drivers/punctuality [get]
+func (h *DeliveryHandler) getPunctualityRankings(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	rankings, err := h.deliveryService.GetPunctualityRankings(limit)
+	if err != nil {
+		response.FromError(c, err)
 		return
 	}
 
@@ -638,12 +746,12 @@ func (h *DeliveryHandler) getDriverRankings(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} domain.DeliveryMetrics
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/admin/deliveries/system/stats [get]
 func (h *DeliveryHandler) getSystemStats(c *gin.Context) {
 	stats, err := h.deliveryService.GetSystemStats()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -0,0 +1,195 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+	"glovo-backend/shared/auth"
+	"glovo-backend/shared/idempotency"
+	"glovo-backend/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type fakeDeliveryService struct {
+	domain.DeliveryService
+	respondErr error
+	deliveries map[string]domain.Delivery
+	searched   []domain.DeliverySearchRequest
+}
+
+func (s *fakeDeliveryService) RespondToAssignment(driverID string, req domain.DriverResponseRequest) error {
+	return s.respondErr
+}
+
+func (s *fakeDeliveryService) GetDelivery(deliveryID string) (*domain.DeliveryResponse, error) {
+	if s.deliveries == nil {
+		return &domain.DeliveryResponse{}, nil
+	}
+	delivery, ok := s.deliveries[deliveryID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &domain.DeliveryResponse{Delivery: delivery, TrackingInfo: &domain.TrackingInfo{}}, nil
+}
+
+func (s *fakeDeliveryService) SearchDeliveries(req domain.DeliverySearchRequest) (*domain.DeliveryPage, error) {
+	s.searched = append(s.searched, req)
+	return &domain.DeliveryPage{}, nil
+}
+
+func TestAcceptDeliveryErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "user-secret")
+	token, err := auth.GenerateToken("driver-1", auth.RoleDriver)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		respondErr error
+		wantStatus int
+		wantCode   response.Code
+	}{
+		{name: "accepted", wantStatus: http.StatusOK},
+		{name: "expired", respondErr: domain.ErrAssignmentExpired, wantStatus: http.StatusConflict, wantCode: response.CodeConflict},
+		{name: "not offered", respondErr: fmt.Errorf("respond: %w", domain.ErrAssignmentNotFound), wantStatus: http.StatusNotFound, wantCode: response.CodeNotFound},
+		{name: "vehicle too small", respondErr: domain.ErrVehicleTooSmall, wantStatus: http.StatusUnprocessableEntity, wantCode: response.CodeUnprocessable},
+		{name: "unexpected", respondErr: fmt.Errorf("database is down"), wantStatus: http.StatusInternalServerError, wantCode: response.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			NewDeliveryHandler(&fakeDeliveryService{respondErr: tt.respondErr}, idempotency.NewMemoryStore()).SetupRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/driver/deliveries/delivery-1/accept", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var envelope response.ErrorEnvelope
+			if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if envelope.Error.Code != tt.wantCode || envelope.Error.Message != tt.respondErr.Error() {
+				t.Fatalf("envelope = %+v", envelope)
+			}
+		})
+	}
+}
+
+func TestCustomerDeliveriesAreScoped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "user-secret")
+	token, err := auth.GenerateToken("customer-1", auth.RoleCustomer)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	service := &fakeDeliveryService{deliveries: map[string]domain.Delivery{
+		"mine":   {ID: "mine", CustomerID: "customer-1"},
+		"theirs": {ID: "theirs", CustomerID: "customer-2"},
+	}}
+	router := gin.New()
+	NewDeliveryHandler(service, idempotency.NewMemoryStore()).SetupRoutes(router.Group("/api/v1"))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// A customer_id parameter can't widen the list to someone else's deliveries
+	if w := get("/api/v1/customer/deliveries/?customer_id=customer-2"); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if len(service.searched) != 1 || service.searched[0].CustomerID != "customer-1" {
+		t.Fatalf("searched %+v, want customer-1 only", service.searched)
+	}
+
+	tests := []struct {
+		id         string
+		wantStatus int
+	}{
+		{id: "mine", wantStatus: http.StatusOK},
+		{id: "theirs", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := get("/api/v1/customer/deliveries/" + tt.id + "/track"); w.Code != tt.wantStatus {
+			t.Fatalf("track %s: status = %d, want %d: %s", tt.id, w.Code, tt.wantStatus, w.Body.String())
+		}
+	}
+}
+
+func (s *fakeDeliveryService) GetDeliveryTrail(deliveryID string) (*domain.DeliveryTrail, error) {
+	return &domain.DeliveryTrail{DeliveryID: deliveryID, Points: []domain.Location{{Latitude: 41.38, Longitude: 2.17}}}, nil
+}
+
+func TestDeliveryTrailFormats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "user-secret")
+	token, err := auth.GenerateToken("admin-1", auth.RoleAdmin)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	tests := []struct {
+		format          string
+		wantStatus      int
+		wantContentType string
+	}{
+		{format: "", wantStatus: http.StatusOK, wantContentType: "application/json; charset=utf-8"},
+		{format: "geojson", wantStatus: http.StatusOK, wantContentType: "application/geo+json"},
+		{format: "kml", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			router := gin.New()
+			NewDeliveryHandler(&fakeDeliveryService{}, idempotency.NewMemoryStore()).SetupRoutes(router.Group("/api/v1"))
+
+			path := "/api/v1/admin/deliveries/delivery-1/trail"
+			if tt.format != "" {
+				path += "?format=" + tt.format
+			}
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantContentType == "" {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Fatalf("content type = %q, want %q", got, tt.wantContentType)
+			}
+			if tt.format == "geojson" {
+				var feature domain.GeoJSONFeature
+				if err := json.Unmarshal(w.Body.Bytes(), &feature); err != nil {
+					t.Fatalf("decode body: %v", err)
+				}
+				if feature.Type != "Feature" || len(feature.Geometry.Coordinates) != 1 {
+					t.Fatalf("feature = %+v", feature)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// LocationSubscriber applies the ETA changes the location service computes
+// from driver location updates
+type LocationSubscriber struct {
+	deliveryService domain.DeliveryService
+}
+
+func NewLocationSubscriber(deliveryService domain.DeliveryService) *LocationSubscriber {
+	return &LocationSubscriber{deliveryService: deliveryService}
+}
+
+// Register subscribes to the location events delivery reacts to
+func (s *LocationSubscriber) Register(bus events.Bus) error {
+	return bus.Subscribe(events.DeliveryETAChanged, s.handleETAChanged)
+}
+
+func (s *LocationSubscriber) handleETAChanged(ctx context.Context, event events.Event) error {
+	var payload events.DeliveryETAChangedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	if err := s.deliveryService.UpdateETA(payload.OrderID, payload.EstimatedArrival); err != nil {
+		return fmt.Errorf("failed to update ETA for order %s: %w", payload.OrderID, err)
+	}
+	return nil
+}
@@ -1,12 +1,16 @@
 package app
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"log"
+	"sort"
 	"time"
 
 	"glovo-backend/services/delivery-service/internal/domain"
 	"glovo-backend/shared/auth"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/pagination"
 
 	"github.com/google/uuid"
 )
@@ -20,6 +24,10 @@ type deliveryService struct {
 	locationService     domain.LocationService
 	notificationService domain.NotificationService
 	paymentService      domain.PaymentService
+	pricingEngine       domain.PricingEngine
+	eventBus            events.Bus
+	maxActivePerDriver  int
+	sideEffects         *sideEffectQueue
 }
 
 func NewDeliveryService(
@@ -31,7 +39,14 @@ func NewDeliveryService(
 	locationService domain.LocationService,
 	notificationService domain.NotificationService,
 	paymentService domain.PaymentService,
+	pricingEngine domain.PricingEngine,
+	eventBus events.Bus,
+	maxActivePerDriver int,
 ) domain.DeliveryService {
+	if maxActivePerDriver <= 0 {
+		maxActivePerDriver = domain.DefaultMaxActiveDeliveries
+	}
+
 	return &deliveryService{
 		deliveryRepo:        deliveryRepo,
 		assignmentRepo:      assignmentRepo,
@@ -41,6 +56,10 @@ func NewDeliveryService(
 		locationService:     locationService,
 		notificationService: notificationService,
 		paymentService:      paymentService,
+		pricingEngine:       pricingEngine,
+		eventBus:            eventBus,
+		maxActivePerDriver:  maxActivePerDriver,
+		sideEffects:         newSideEffectQueue(sideEffectWorkers, sideEffectQueueSize, sideEffectMaxAttempts, sideEffectBackoff),
 	}
 }
 
@@ -48,21 +67,56 @@ func NewDeliveryService(
 func (s *deliveryService) CreateDelivery(req domain.CreateDeliveryRequest) (*domain.DeliveryResponse, error) {
 	// Check if delivery already exists for this order
 	if existing, _ := s.deliveryRepo.GetByOrderID(req.OrderID); existing != nil {
-		return nil, errors.New("delivery already exists for this order")
+		return nil, domain.ErrDeliveryExists
+	}
+
+	customerID := req.CustomerID
+	merchantID := req.MerchantID
+	requiredVehicle := req.RequiredVehicle
+	prepTime := req.PrepTime
+	if customerID == "" || merchantID == "" || requiredVehicle == "" || prepTime == 0 {
+		order, err := s.orderService.GetOrder(req.OrderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get order: %w", err)
+		}
+		if customerID == "" && order != nil {
+			customerID = order.CustomerID
+		}
+		if merchantID == "" && order != nil {
+			merchantID = order.MerchantID
+		}
+		if requiredVehicle == "" {
+			requiredVehicle = domain.RequiredVehicleForOrder(order)
+		}
+		if prepTime == 0 && order != nil {
+			prepTime = order.PrepTime
+		}
+	}
+
+	quote, err := s.pricingEngine.Quote(req.Distance, req.EstimatedTime, req.SurgeMultiplier)
+	if err != nil {
+		return nil, err
 	}
 
 	delivery := &domain.Delivery{
 		ID:              uuid.New().String(),
 		OrderID:         req.OrderID,
+		CustomerID:      customerID,
+		MerchantID:      merchantID,
 		Status:          domain.StatusPending,
 		AssignmentType:  domain.AssignmentAuto, // Default to auto assignment
 		PickupAddress:   req.PickupAddress,
 		DeliveryAddress: req.DeliveryAddress,
 		EstimatedTime:   req.EstimatedTime,
+		PrepTime:        prepTime,
 		Distance:        req.Distance,
-		DeliveryFee:     req.DeliveryFee,
+		DeliveryFee:     quote.Total,
+		SurgeMultiplier: quote.SurgeMultiplier,
+		Tip:             req.Tip,
 		Priority:        req.Priority,
+		RequiredVehicle: requiredVehicle,
 		Notes:           req.Notes,
+		Instructions:    req.Instructions,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 	}
@@ -86,6 +140,49 @@ func (s *deliveryService) GetDelivery(deliveryID string) (*domain.DeliveryRespon
 	return s.buildDeliveryResponse(delivery)
 }
 
+// GetDeliveryTrail returns the driver's recorded locations between pickup
+// and drop-off, ordered by time
+func (s *deliveryService) GetDeliveryTrail(deliveryID string) (*domain.DeliveryTrail, error) {
+	delivery, err := s.deliveryRepo.GetByID(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.DriverID == nil || delivery.PickedUpAt == nil {
+		return nil, domain.ErrNoTrail
+	}
+
+	end := time.Now()
+	switch {
+	case delivery.DeliveredAt != nil:
+		end = *delivery.DeliveredAt
+	case delivery.CancelledAt != nil:
+		end = *delivery.CancelledAt
+	}
+	start := *delivery.PickedUpAt
+
+	history, err := s.locationService.GetLocationHistory(*delivery.DriverID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location history: %w", err)
+	}
+
+	points := make([]domain.Location, 0, len(history))
+	for _, point := range history {
+		if !point.Timestamp.Before(start) && !point.Timestamp.After(end) {
+			points = append(points, point)
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+
+	return &domain.DeliveryTrail{
+		DeliveryID: delivery.ID,
+		OrderID:    delivery.OrderID,
+		DriverID:   *delivery.DriverID,
+		StartTime:  start,
+		EndTime:    end,
+		Points:     points,
+	}, nil
+}
+
 func (s *deliveryService) GetDeliveryByOrder(orderID string) (*domain.DeliveryResponse, error) {
 	delivery, err := s.deliveryRepo.GetByOrderID(orderID)
 	if err != nil {
@@ -103,15 +200,16 @@ func (s *deliveryService) UpdateDeliveryStatus(deliveryID string, req domain.Upd
 
 	// Authorization check
 	if role == auth.RoleDriver && (delivery.DriverID == nil || *delivery.DriverID != userID) {
-		return nil, errors.New("unauthorized: driver can only update their own deliveries")
+		return nil, domain.ErrNotAssignedDriver
 	}
 
 	// Validate status transition
 	if !s.isValidStatusTransition(delivery.Status, req.Status) {
-		return nil, fmt.Errorf("invalid status transition from %s to %s", delivery.Status, req.Status)
+		return nil, fmt.Errorf("%w from %s to %s", domain.ErrInvalidStatusTransition, delivery.Status, req.Status)
 	}
 
 	// Update delivery
+	previousStatus := delivery.Status
 	delivery.Status = req.Status
 	delivery.UpdatedAt = time.Now()
 
@@ -129,6 +227,7 @@ func (s *deliveryService) UpdateDeliveryStatus(deliveryID string, req domain.Upd
 	case domain.StatusCancelled:
 		delivery.CancelledAt = &now
 		delivery.CancellationReason = &req.Notes
+		s.applyCancellationPolicy(delivery, previousStatus, role)
 	}
 
 	if err := s.deliveryRepo.Update(delivery); err != nil {
@@ -136,10 +235,17 @@ func (s *deliveryService) UpdateDeliveryStatus(deliveryID string, req domain.Upd
 	}
 
 	// Update order status
-	go s.updateOrderStatus(delivery.OrderID, req.Status)
+	s.updateOrderStatus(delivery.OrderID, req.Status)
 
 	// Send notifications
-	go s.sendStatusNotification(delivery, req.Status)
+	s.sendStatusNotification(delivery, req.Status)
+
+	switch req.Status {
+	case domain.StatusDelivered:
+		s.publishCompleted(delivery)
+	case domain.StatusCancelled:
+		s.publishCancelled(delivery)
+	}
 
 	// Update driver performance if delivery completed
 	if req.Status == domain.StatusDelivered && delivery.DriverID != nil {
@@ -157,13 +263,14 @@ func (s *deliveryService) CancelDelivery(deliveryID string, reason string, userI
 
 	// Authorization check
 	if role == auth.RoleDriver && (delivery.DriverID == nil || *delivery.DriverID != userID) {
-		return errors.New("unauthorized")
+		return domain.ErrNotAssignedDriver
 	}
 
 	if delivery.Status == domain.StatusDelivered {
-		return errors.New("cannot cancel delivered order")
+		return fmt.Errorf("%w: cannot cancel delivered order", domain.ErrInvalidStatusTransition)
 	}
 
+	s.applyCancellationPolicy(delivery, delivery.Status, role)
 	delivery.Status = domain.StatusCancelled
 	now := time.Now()
 	delivery.CancelledAt = &now
@@ -175,14 +282,30 @@ func (s *deliveryService) CancelDelivery(deliveryID string, reason string, userI
 	}
 
 	// Update order status
-	go s.updateOrderStatus(delivery.OrderID, domain.StatusCancelled)
+	s.updateOrderStatus(delivery.OrderID, domain.StatusCancelled)
 
 	// Send notifications
-	go s.sendStatusNotification(delivery, domain.StatusCancelled)
+	s.sendStatusNotification(delivery, domain.StatusCancelled)
+
+	s.publishCancelled(delivery)
 
 	return nil
 }
 
+// applyCancellationPolicy sets the fee and compensation for a delivery
+// cancelled from status. A driver who cancels their own delivery neither
+// charges the customer nor gets compensated.
+func (s *deliveryService) applyCancellationPolicy(delivery *domain.Delivery, status domain.DeliveryStatus, role auth.UserRole) {
+	if role == auth.RoleDriver {
+		return
+	}
+	charges := s.pricingEngine.CancellationCharges(status, delivery.DeliveryFee)
+	delivery.CancellationFee = charges.CustomerFee
+	if delivery.DriverID != nil {
+		delivery.DriverCompensation = charges.DriverCompensation
+	}
+}
+
 // Driver assignment
 func (s *deliveryService) AutoAssignDriver(req domain.AutoAssignmentRequest) (*domain.DeliveryResponse, error) {
 	delivery, err := s.deliveryRepo.GetByID(req.DeliveryID)
@@ -191,7 +314,7 @@ func (s *deliveryService) AutoAssignDriver(req domain.AutoAssignmentRequest) (*d
 	}
 
 	if delivery.Status != domain.StatusPending {
-		return nil, errors.New("delivery is not in pending status")
+		return nil, fmt.Errorf("%w: delivery is not in pending status", domain.ErrInvalidStatusTransition)
 	}
 
 	// Get available drivers
@@ -201,11 +324,19 @@ func (s *deliveryService) AutoAssignDriver(req domain.AutoAssignmentRequest) (*d
 	}
 
 	if len(drivers) == 0 {
-		return nil, errors.New("no available drivers found")
+		return nil, domain.ErrNoAvailableDrivers
 	}
 
-	// Select best driver (closest with highest rating)
-	bestDriver := s.selectBestDriver(drivers)
+	drivers = s.driversUnderCap(drivers)
+	if len(drivers) == 0 {
+		return nil, fmt.Errorf("%w under their active delivery cap", domain.ErrNoAvailableDrivers)
+	}
+
+	// Select best driver (closest with highest rating) whose vehicle can handle the delivery
+	bestDriver, found := s.selectBestDriver(drivers, delivery.RequiredVehicle)
+	if !found {
+		return nil, fmt.Errorf("%w with a %s or larger vehicle", domain.ErrNoAvailableDrivers, delivery.RequiredVehicle)
+	}
 
 	// Create assignment
 	assignment := &domain.DeliveryAssignment{
@@ -233,8 +364,8 @@ func (s *deliveryService) AutoAssignDriver(req domain.AutoAssignmentRequest) (*d
 		return nil, err
 	}
 
-	// Send notification to driver
-	go s.notificationService.SendDeliveryAssignment(bestDriver.DriverID, delivery)
+	// Notify the driver
+	s.publishAssigned(delivery, bestDriver.DriverID)
 
 	return s.buildDeliveryResponse(delivery)
 }
@@ -252,7 +383,20 @@ func (s *deliveryService) ManualAssignDriver(req domain.AssignDriverRequest, adm
 	}
 
 	if !available {
-		return nil, errors.New("driver is not available")
+		return nil, domain.ErrDriverUnavailable
+	}
+
+	driverInfo, err := s.driverService.GetDriver(req.DriverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !domain.VehicleType(driverInfo.Vehicle.Type).CanHandle(delivery.RequiredVehicle) {
+		return nil, fmt.Errorf("%w, %s or larger required", domain.ErrVehicleTooSmall, delivery.RequiredVehicle)
+	}
+
+	if err := s.checkDriverCapacity(req.DriverID, delivery); err != nil {
+		return nil, err
 	}
 
 	// Create assignment
@@ -282,8 +426,8 @@ func (s *deliveryService) ManualAssignDriver(req domain.AssignDriverRequest, adm
 		return nil, err
 	}
 
-	// Send notification to driver
-	go s.notificationService.SendDeliveryAssignment(req.DriverID, delivery)
+	// Notify the driver
+	s.publishAssigned(delivery, req.DriverID)
 
 	return s.buildDeliveryResponse(delivery)
 }
@@ -309,14 +453,14 @@ func (s *deliveryService) RespondToAssignment(driverID string, req domain.Driver
 	}
 
 	if assignment == nil {
-		return errors.New("no pending assignment found for this driver")
+		return domain.ErrAssignmentNotFound
 	}
 
 	// Check if assignment has expired
 	if time.Now().After(assignment.ExpiresAt) {
 		assignment.Status = domain.AssignmentExpired
 		s.assignmentRepo.Update(assignment)
-		return errors.New("assignment has expired")
+		return domain.ErrAssignmentExpired
 	}
 
 	// Update assignment
@@ -336,7 +480,7 @@ func (s *deliveryService) RespondToAssignment(driverID string, req domain.Driver
 		delivery.Status = domain.StatusAccepted
 
 		// Update driver status
-		go s.driverService.UpdateDriverStatus(driverID, "busy")
+		s.updateDriverStatus(driverID, "busy")
 
 		// Create delivery route
 		pickupLocation := domain.Location{
@@ -349,7 +493,10 @@ func (s *deliveryService) RespondToAssignment(driverID string, req domain.Driver
 			Longitude: delivery.DeliveryAddress.Longitude,
 			Timestamp: time.Now(),
 		}
-		go s.locationService.CreateDeliveryRoute(delivery.ID, driverID, pickupLocation, dropoffLocation)
+		deliveryID := delivery.ID
+		s.sideEffects.enqueue("create route for delivery "+deliveryID, func() error {
+			return s.locationService.CreateDeliveryRoute(deliveryID, driverID, pickupLocation, dropoffLocation)
+		})
 	} else {
 		assignment.Status = domain.AssignmentRejected
 		delivery.Status = domain.StatusPending
@@ -370,7 +517,7 @@ func (s *deliveryService) RespondToAssignment(driverID string, req domain.Driver
 	}
 
 	// Send notifications
-	go s.sendStatusNotification(delivery, delivery.Status)
+	s.sendStatusNotification(delivery, delivery.Status)
 
 	return nil
 }
@@ -391,11 +538,11 @@ func (s *deliveryService) PickupOrder(deliveryID string, driverID string) (*doma
 	}
 
 	if delivery.DriverID == nil || *delivery.DriverID != driverID {
-		return nil, errors.New("unauthorized")
+		return nil, domain.ErrNotAssignedDriver
 	}
 
 	if delivery.Status != domain.StatusAccepted {
-		return nil, errors.New("delivery must be accepted before pickup")
+		return nil, fmt.Errorf("%w: delivery must be accepted before pickup", domain.ErrInvalidStatusTransition)
 	}
 
 	delivery.Status = domain.StatusPickedUp
@@ -408,29 +555,34 @@ func (s *deliveryService) PickupOrder(deliveryID string, driverID string) (*doma
 	}
 
 	// Update order status
-	go s.updateOrderStatus(delivery.OrderID, domain.StatusPickedUp)
+	s.updateOrderStatus(delivery.OrderID, domain.StatusPickedUp)
 
 	// Send notifications
-	go s.sendStatusNotification(delivery, domain.StatusPickedUp)
+	s.sendStatusNotification(delivery, domain.StatusPickedUp)
 
 	return s.buildDeliveryResponse(delivery)
 }
 
-func (s *deliveryService) CompleteDelivery(deliveryID string, driverID string) (*domain.DeliveryResponse, error) {
+func (s *deliveryService) CompleteDelivery(deliveryID string, driverID string, req domain.CompleteDeliveryRequest) (*domain.DeliveryResponse, error) {
 	delivery, err := s.deliveryRepo.GetByID(deliveryID)
 	if err != nil {
 		return nil, err
 	}
 
 	if delivery.DriverID == nil || *delivery.DriverID != driverID {
-		return nil, errors.New("unauthorized")
+		return nil, domain.ErrNotAssignedDriver
 	}
 
 	if delivery.Status != domain.StatusPickedUp && delivery.Status != domain.StatusInTransit {
-		return nil, errors.New("delivery must be picked up before completion")
+		return nil, fmt.Errorf("%w: delivery must be picked up before completion", domain.ErrInvalidStatusTransition)
+	}
+
+	if delivery.Instructions.Contactless && req.ProofOfDelivery == "" {
+		return nil, domain.ErrProofRequired
 	}
 
 	delivery.Status = domain.StatusDelivered
+	delivery.ProofOfDelivery = req.ProofOfDelivery
 	now := time.Now()
 	delivery.DeliveredAt = &now
 
@@ -446,16 +598,16 @@ func (s *deliveryService) CompleteDelivery(deliveryID string, driverID string) (
 	}
 
 	// Update driver status back to online
-	go s.driverService.UpdateDriverStatus(driverID, "online")
+	s.updateDriverStatus(driverID, "online")
 
-	// Process payment
-	go s.paymentService.ProcessDeliveryPayment(deliveryID)
+	// Payment settles the driver's earnings from the completion event
+	s.publishCompleted(delivery)
 
 	// Update order status
-	go s.updateOrderStatus(delivery.OrderID, domain.StatusDelivered)
+	s.updateOrderStatus(delivery.OrderID, domain.StatusDelivered)
 
 	// Send notifications
-	go s.sendStatusNotification(delivery, domain.StatusDelivered)
+	s.sendStatusNotification(delivery, domain.StatusDelivered)
 
 	// Update driver performance
 	go s.UpdateDriverPerformance(driverID)
@@ -470,11 +622,14 @@ func (s *deliveryService) ReportIssue(deliveryID string, driverID string, issue
 	}
 
 	if delivery.DriverID == nil || *delivery.DriverID != driverID {
-		return errors.New("unauthorized")
+		return domain.ErrNotAssignedDriver
 	}
 
 	// For now, just send notification to support
-	go s.notificationService.SendDriverNotification("admin", fmt.Sprintf("Issue reported for delivery %s: %s", deliveryID, issue))
+	message := fmt.Sprintf("Issue reported for delivery %s: %s", deliveryID, issue)
+	s.sideEffects.enqueue("report issue for delivery "+deliveryID, func() error {
+		return s.notificationService.SendDriverNotification("admin", message)
+	})
 
 	return nil
 }
@@ -512,6 +667,8 @@ func (s *deliveryService) UpdateDriverPerformance(driverID string) error {
 	}
 
 	var totalDeliveryTime float64
+	var totalLateness float64
+	var timedCount int
 	var onTimeCount int
 	var completedCount int
 
@@ -528,6 +685,11 @@ func (s *deliveryService) UpdateDriverPerformance(driverID string) error {
 				if *delivery.ActualTime <= int(float64(delivery.EstimatedTime)*1.1) {
 					onTimeCount++
 				}
+
+				lateness := *delivery.ActualTime - delivery.EstimatedTime
+				totalLateness += float64(lateness)
+				timedCount++
+				performance.LatenessDistribution.Add(lateness)
 			}
 		} else if delivery.Status == domain.StatusCancelled {
 			performance.CancelledDeliveries++
@@ -540,6 +702,9 @@ func (s *deliveryService) UpdateDriverPerformance(driverID string) error {
 		performance.AverageDeliveryTime = totalDeliveryTime / float64(completedCount)
 		performance.OnTimeDeliveryRate = float64(onTimeCount) / float64(completedCount) * 100
 	}
+	if timedCount > 0 {
+		performance.AverageLateness = totalLateness / float64(timedCount)
+	}
 
 	// Get existing performance to preserve ID
 	existing, err := s.performanceRepo.GetByDriverID(driverID)
@@ -556,9 +721,27 @@ func (s *deliveryService) GetDriverRankings() ([]domain.DriverPerformance, error
 	return s.performanceRepo.GetDriverRankings()
 }
 
+func (s *deliveryService) GetPunctualityRankings(limit int) ([]domain.DriverPerformance, error) {
+	return s.performanceRepo.GetPunctualityRankings(pagination.NormalizeLimit(limit))
+}
+
 // Admin operations
-func (s *deliveryService) SearchDeliveries(req domain.DeliverySearchRequest) ([]domain.Delivery, error) {
-	return s.deliveryRepo.Search(req)
+func (s *deliveryService) SearchDeliveries(req domain.DeliverySearchRequest) (*domain.DeliveryPage, error) {
+	cursor, err := pagination.Decode(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	req.Limit = pagination.NormalizeLimit(req.Limit)
+
+	deliveries, err := s.deliveryRepo.Search(req, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, next := pagination.Trim(deliveries, req.Limit, func(d domain.Delivery) (time.Time, string) {
+		return d.CreatedAt, d.ID
+	})
+	return &domain.DeliveryPage{Deliveries: deliveries, NextCursor: next}, nil
 }
 
 func (s *deliveryService) ReassignDelivery(deliveryID string, newDriverID string, adminID string) error {
@@ -574,12 +757,16 @@ func (s *deliveryService) ReassignDelivery(deliveryID string, newDriverID string
 	}
 
 	if !available {
-		return errors.New("new driver is not available")
+		return fmt.Errorf("new %w", domain.ErrDriverUnavailable)
+	}
+
+	if err := s.checkDriverCapacity(newDriverID, delivery); err != nil {
+		return err
 	}
 
 	// Update current driver status if assigned
 	if delivery.DriverID != nil {
-		go s.driverService.UpdateDriverStatus(*delivery.DriverID, "online")
+		s.updateDriverStatus(*delivery.DriverID, "online")
 	}
 
 	// Update delivery
@@ -608,8 +795,8 @@ func (s *deliveryService) ReassignDelivery(deliveryID string, newDriverID string
 		return err
 	}
 
-	// Send notification to new driver
-	go s.notificationService.SendDeliveryAssignment(newDriverID, delivery)
+	// Notify the new driver
+	s.publishAssigned(delivery, newDriverID)
 
 	return nil
 }
@@ -639,8 +826,15 @@ func (s *deliveryService) buildDeliveryResponse(delivery *domain.Delivery) (*dom
 	// Get tracking info
 	if tracking, err := s.locationService.GetDeliveryTracking(delivery.ID); err == nil {
 		response.TrackingInfo = tracking
+	} else {
+		response.TrackingInfo = &domain.TrackingInfo{}
 	}
 
+	// The customer's estimate covers preparation as well as travel
+	eta := delivery.CustomerETA(time.Now())
+	response.TrackingInfo.ETA = &eta
+	response.TrackingInfo.EstimatedArrival = &eta.EstimatedArrival
+
 	return response, nil
 }
 
@@ -667,16 +861,58 @@ func (s *deliveryService) isValidStatusTransition(from, to domain.DeliveryStatus
 	return false
 }
 
-func (s *deliveryService) selectBestDriver(drivers []domain.DriverAvailability) domain.DriverAvailability {
-	if len(drivers) == 0 {
-		return domain.DriverAvailability{}
+// checkDriverCapacity refuses to give the driver another delivery once they
+// hold maxActivePerDriver active ones; delivery itself doesn't count
+func (s *deliveryService) checkDriverCapacity(driverID string, delivery *domain.Delivery) error {
+	active, err := s.deliveryRepo.CountActiveByDriverID(driverID)
+	if err != nil {
+		return fmt.Errorf("failed to count active deliveries: %w", err)
+	}
+	if delivery.DriverID != nil && *delivery.DriverID == driverID && isActiveStatus(delivery.Status) {
+		active--
+	}
+	if active >= int64(s.maxActivePerDriver) {
+		return fmt.Errorf("%w (%d)", domain.ErrDriverAtCapacity, s.maxActivePerDriver)
 	}
+	return nil
+}
 
+// driversUnderCap drops drivers who can't take another delivery
+func (s *deliveryService) driversUnderCap(drivers []domain.DriverAvailability) []domain.DriverAvailability {
+	eligible := make([]domain.DriverAvailability, 0, len(drivers))
+	for _, driver := range drivers {
+		active, err := s.deliveryRepo.CountActiveByDriverID(driver.DriverID)
+		if err != nil {
+			log.Printf("Skipping driver %s, failed to count active deliveries: %v", driver.DriverID, err)
+			continue
+		}
+		if active < int64(s.maxActivePerDriver) {
+			eligible = append(eligible, driver)
+		}
+	}
+	return eligible
+}
+
+func isActiveStatus(status domain.DeliveryStatus) bool {
+	for _, active := range domain.ActiveStatuses {
+		if status == active {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *deliveryService) selectBestDriver(drivers []domain.DriverAvailability, requiredVehicle domain.VehicleType) (domain.DriverAvailability, bool) {
 	// Score drivers based on distance and rating
 	bestScore := -1.0
-	bestIndex := 0
+	bestIndex := -1
 
 	for i, driver := range drivers {
+		// Skip drivers outside an active shift or whose vehicle can't carry the order
+		if !driver.OnShift || !driver.VehicleType.CanHandle(requiredVehicle) {
+			continue
+		}
+
 		// Normalize distance (closer is better)
 		distanceScore := 1.0 / (1.0 + driver.Distance)
 
@@ -692,7 +928,11 @@ func (s *deliveryService) selectBestDriver(drivers []domain.DriverAvailability)
 		}
 	}
 
-	return drivers[bestIndex]
+	if bestIndex < 0 {
+		return domain.DriverAvailability{}, false
+	}
+
+	return drivers[bestIndex], true
 }
 
 func (s *deliveryService) tryAutoAssignment(delivery *domain.Delivery) {
@@ -710,23 +950,112 @@ func (s *deliveryService) tryAutoAssignment(delivery *domain.Delivery) {
 	}
 }
 
+// orderStatuses maps every delivery status to the order status it implies.
+// The order service has no failed state, so a failed delivery cancels the
+// order; a rejected assignment puts the order back to awaiting a driver.
+var orderStatuses = map[domain.DeliveryStatus]string{
+	domain.StatusPending:   "ready",
+	domain.StatusAssigned:  "assigned",
+	domain.StatusAccepted:  "assigned",
+	domain.StatusRejected:  "ready",
+	domain.StatusPickedUp:  "picked_up",
+	domain.StatusInTransit: "in_transit",
+	domain.StatusDelivered: "delivered",
+	domain.StatusCancelled: "cancelled",
+	domain.StatusFailed:    "cancelled",
+}
+
 func (s *deliveryService) updateOrderStatus(orderID string, status domain.DeliveryStatus) {
-	// Map delivery status to order status
-	orderStatus := "in_progress"
-	switch status {
-	case domain.StatusPickedUp:
-		orderStatus = "picked_up"
-	case domain.StatusInTransit:
-		orderStatus = "in_transit"
-	case domain.StatusDelivered:
-		orderStatus = "delivered"
-	case domain.StatusCancelled:
-		orderStatus = "cancelled"
+	orderStatus, ok := orderStatuses[status]
+	if !ok {
+		log.Printf("No order status mapping for delivery status %q, order %s left unchanged", status, orderID)
+		return
 	}
 
-	s.orderService.UpdateOrderStatus(orderID, orderStatus)
+	s.sideEffects.enqueue(fmt.Sprintf("update order %s to %s", orderID, orderStatus), func() error {
+		return s.orderService.UpdateOrderStatus(orderID, orderStatus)
+	})
+}
+
+func (s *deliveryService) updateDriverStatus(driverID, status string) {
+	s.sideEffects.enqueue(fmt.Sprintf("set driver %s %s", driverID, status), func() error {
+		return s.driverService.UpdateDriverStatus(driverID, status)
+	})
+}
+
+// publishAssigned lets the notification service alert the driver; the phone
+// number is included so it can fall back to SMS, and the drop-off
+// instructions so the driver knows them before setting off
+func (s *deliveryService) publishAssigned(delivery *domain.Delivery, driverID string) {
+	payload := events.DeliveryAssignedPayload{
+		DeliveryID:      delivery.ID,
+		OrderID:         delivery.OrderID,
+		DriverID:        driverID,
+		PickupAddress:   delivery.PickupAddress.String(),
+		DeliveryAddress: delivery.DeliveryAddress.String(),
+		DeliveryFee:     delivery.DeliveryFee,
+		Distance:        delivery.Distance,
+		EstimatedTime:   delivery.EstimatedTime,
+		Contactless:     delivery.Instructions.Contactless,
+		DropLocation:    delivery.Instructions.DropLocation,
+		CallOnArrival:   delivery.Instructions.CallOnArrival,
+		AssignedAt:      time.Now(),
+	}
+	if delivery.AssignedAt != nil {
+		payload.AssignedAt = *delivery.AssignedAt
+	}
+	if driver, err := s.driverService.GetDriver(driverID); err == nil {
+		payload.DriverPhone = driver.Phone
+	}
+	if err := s.eventBus.Publish(context.Background(), events.DeliveryAssigned, payload); err != nil {
+		log.Printf("Failed to publish %s for delivery %s: %v", events.DeliveryAssigned, delivery.ID, err)
+	}
+}
+
+func (s *deliveryService) publishCompleted(delivery *domain.Delivery) {
+	payload := events.DeliveryCompletedPayload{
+		DeliveryID:      delivery.ID,
+		OrderID:         delivery.OrderID,
+		CustomerID:      delivery.CustomerID,
+		MerchantID:      delivery.MerchantID,
+		DeliveryFee:     delivery.DeliveryFee,
+		Distance:        delivery.Distance,
+		SurgeMultiplier: delivery.SurgeMultiplier,
+		Tip:             delivery.Tip,
+		DeliveredAt:     *delivery.DeliveredAt,
+	}
+	if delivery.DriverID != nil {
+		payload.DriverID = *delivery.DriverID
+	}
+	if err := s.eventBus.Publish(context.Background(), events.DeliveryCompleted, payload); err != nil {
+		log.Printf("Failed to publish %s for delivery %s: %v", events.DeliveryCompleted, delivery.ID, err)
+	}
+}
+
+func (s *deliveryService) publishCancelled(delivery *domain.Delivery) {
+	payload := events.DeliveryCancelledPayload{
+		DeliveryID:         delivery.ID,
+		OrderID:            delivery.OrderID,
+		CustomerID:         delivery.CustomerID,
+		MerchantID:         delivery.MerchantID,
+		CancellationFee:    delivery.CancellationFee,
+		DriverCompensation: delivery.DriverCompensation,
+		CancelledAt:        *delivery.CancelledAt,
+	}
+	if delivery.DriverID != nil {
+		payload.DriverID = *delivery.DriverID
+	}
+	if delivery.CancellationReason != nil {
+		payload.Reason = *delivery.CancellationReason
+	}
+	if err := s.eventBus.Publish(context.Background(), events.DeliveryCancelled, payload); err != nil {
+		log.Printf("Failed to publish %s for delivery %s: %v", events.DeliveryCancelled, delivery.ID, err)
+	}
 }
 
 func (s *deliveryService) sendStatusNotification(delivery *domain.Delivery, status domain.DeliveryStatus) {
-	s.notificationService.SendDeliveryUpdate(delivery.OrderID, status)
+	orderID := delivery.OrderID
+	s.sideEffects.enqueue(fmt.Sprintf("notify order %s %s", orderID, status), func() error {
+		return s.notificationService.SendDeliveryUpdate(orderID, status)
+	})
 }
@@ -0,0 +1,291 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+	"glovo-backend/shared/auth"
+	"glovo-backend/shared/events"
+)
+
+type fakeDeliveryRepo struct {
+	domain.DeliveryRepository
+	deliveries map[string]*domain.Delivery
+}
+
+func (r *fakeDeliveryRepo) Create(delivery *domain.Delivery) error {
+	stored := *delivery
+	r.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (r *fakeDeliveryRepo) GetByID(id string) (*domain.Delivery, error) {
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return nil, errors.New("delivery not found")
+	}
+	read := *delivery
+	return &read, nil
+}
+
+func (r *fakeDeliveryRepo) GetByOrderID(orderID string) (*domain.Delivery, error) {
+	for _, delivery := range r.deliveries {
+		if delivery.OrderID == orderID {
+			read := *delivery
+			return &read, nil
+		}
+	}
+	return nil, errors.New("delivery not found")
+}
+
+func (r *fakeDeliveryRepo) Update(delivery *domain.Delivery) error {
+	stored := *delivery
+	r.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (r *fakeDeliveryRepo) CountActiveByDriverID(driverID string) (int64, error) {
+	var active int64
+	for _, delivery := range r.deliveries {
+		if delivery.DriverID != nil && *delivery.DriverID == driverID && isActiveStatus(delivery.Status) {
+			active++
+		}
+	}
+	return active, nil
+}
+
+type fakeAssignmentRepo struct {
+	domain.DeliveryAssignmentRepository
+	assignments []domain.DeliveryAssignment
+}
+
+func (r *fakeAssignmentRepo) Create(assignment *domain.DeliveryAssignment) error {
+	r.assignments = append(r.assignments, *assignment)
+	return nil
+}
+
+type fakeDriverService struct {
+	domain.DriverService
+	drivers   map[string]*domain.DriverInfo
+	available []domain.DriverAvailability
+}
+
+func (s *fakeDriverService) GetDriver(driverID string) (*domain.DriverInfo, error) {
+	driver, ok := s.drivers[driverID]
+	if !ok {
+		return nil, errors.New("driver not found")
+	}
+	return driver, nil
+}
+
+func (s *fakeDriverService) GetAvailableDrivers(latitude, longitude, radius float64) ([]domain.DriverAvailability, error) {
+	return s.available, nil
+}
+
+func (s *fakeDriverService) IsDriverAvailable(driverID string) (bool, error) {
+	_, ok := s.drivers[driverID]
+	return ok, nil
+}
+
+type fakeOrderService struct {
+	domain.OrderService
+	orders  map[string]*domain.OrderInfo
+	updates []string
+}
+
+func (s *fakeOrderService) GetOrder(orderID string) (*domain.OrderInfo, error) {
+	order, ok := s.orders[orderID]
+	if !ok {
+		return nil, errors.New("order service unavailable")
+	}
+	return order, nil
+}
+
+type fakeLocationService struct {
+	domain.LocationService
+	history []domain.Location
+}
+
+func (s *fakeLocationService) GetDeliveryTracking(deliveryID string) (*domain.TrackingInfo, error) {
+	return nil, errors.New("no tracking")
+}
+
+func newTestDeliveryService(deliveries ...domain.Delivery) (*deliveryService, *fakeDeliveryRepo, *fakeDriverService) {
+	repo := &fakeDeliveryRepo{deliveries: make(map[string]*domain.Delivery)}
+	for _, delivery := range deliveries {
+		repo.Create(&delivery)
+	}
+	drivers := &fakeDriverService{drivers: make(map[string]*domain.DriverInfo)}
+	svc := &deliveryService{
+		deliveryRepo:       repo,
+		assignmentRepo:     &fakeAssignmentRepo{},
+		orderService:       &fakeOrderService{orders: make(map[string]*domain.OrderInfo)},
+		driverService:      drivers,
+		locationService:    &fakeLocationService{},
+		pricingEngine:      NewPricingEngine(nil),
+		eventBus:           events.NewInMemoryBus(),
+		maxActivePerDriver: domain.DefaultMaxActiveDeliveries,
+	}
+	return svc, repo, drivers
+}
+
+func TestAutoAssignDriverVehicle(t *testing.T) {
+	bike := domain.DriverAvailability{DriverID: "bike-1", Distance: 0.2, Rating: 5, VehicleType: domain.VehicleBicycle, OnShift: true}
+	car := domain.DriverAvailability{DriverID: "car-1", Distance: 4, Rating: 3, VehicleType: domain.VehicleCar, OnShift: true}
+	unknown := domain.DriverAvailability{DriverID: "unknown-1", Distance: 0.1, Rating: 5, OnShift: true}
+
+	tests := []struct {
+		name       string
+		required   domain.VehicleType
+		available  []domain.DriverAvailability
+		wantDriver string
+	}{
+		{name: "closer bike driver passed over", required: domain.VehicleCar, available: []domain.DriverAvailability{bike, car}, wantDriver: "car-1"},
+		{name: "only bike drivers", required: domain.VehicleCar, available: []domain.DriverAvailability{bike}},
+		{name: "driver without a vehicle type", required: domain.VehicleBicycle, available: []domain.DriverAvailability{unknown, bike}, wantDriver: "bike-1"},
+		{name: "bike delivery", required: domain.VehicleBicycle, available: []domain.DriverAvailability{bike, car}, wantDriver: "bike-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, drivers := newTestDeliveryService(domain.Delivery{
+				ID: "delivery-1", OrderID: "order-1", Status: domain.StatusPending, RequiredVehicle: tt.required,
+			})
+			drivers.available = tt.available
+
+			_, err := svc.AutoAssignDriver(domain.AutoAssignmentRequest{DeliveryID: "delivery-1", Radius: 10})
+			if tt.wantDriver == "" {
+				if !errors.Is(err, domain.ErrNoAvailableDrivers) {
+					t.Fatalf("expected %v, got %v", domain.ErrNoAvailableDrivers, err)
+				}
+				if stored := repo.deliveries["delivery-1"]; stored.DriverID != nil {
+					t.Fatalf("delivery assigned to %s", *stored.DriverID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AutoAssignDriver: %v", err)
+			}
+			if stored := repo.deliveries["delivery-1"]; stored.DriverID == nil || *stored.DriverID != tt.wantDriver {
+				t.Fatalf("assigned driver = %v, want %s", stored.DriverID, tt.wantDriver)
+			}
+		})
+	}
+}
+
+func TestManualAssignDriverVehicle(t *testing.T) {
+	tests := []struct {
+		name    string
+		vehicle string
+		wantErr error
+	}{
+		{name: "bike driver", vehicle: string(domain.VehicleBicycle), wantErr: domain.ErrVehicleTooSmall},
+		{name: "driver without a vehicle type", wantErr: domain.ErrVehicleTooSmall},
+		{name: "car driver", vehicle: string(domain.VehicleCar)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, drivers := newTestDeliveryService(domain.Delivery{
+				ID: "delivery-1", OrderID: "order-1", Status: domain.StatusPending, RequiredVehicle: domain.VehicleCar,
+			})
+			drivers.drivers["driver-1"] = &domain.DriverInfo{ID: "driver-1", Vehicle: domain.Vehicle{Type: tt.vehicle}}
+
+			_, err := svc.ManualAssignDriver(domain.AssignDriverRequest{DeliveryID: "delivery-1", DriverID: "driver-1"}, "admin-1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				if stored := repo.deliveries["delivery-1"]; stored.DriverID != nil {
+					t.Fatalf("delivery assigned to %s", *stored.DriverID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ManualAssignDriver: %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateDeliveryFailsWithoutOrder(t *testing.T) {
+	svc, repo, _ := newTestDeliveryService()
+
+	_, err := svc.CreateDelivery(domain.CreateDeliveryRequest{OrderID: "order-1", Distance: 3, EstimatedTime: 15})
+	if err == nil {
+		t.Fatalf("expected the order lookup failure to be returned")
+	}
+	if len(repo.deliveries) != 0 {
+		t.Fatalf("created %d deliveries, want 0", len(repo.deliveries))
+	}
+}
+
+func TestGetDeliveryETAIncludesPrepTime(t *testing.T) {
+	svc, _, _ := newTestDeliveryService(domain.Delivery{
+		ID: "delivery-1", OrderID: "order-1", Status: domain.StatusPending,
+		PrepTime: 20, EstimatedTime: 15, CreatedAt: time.Now(),
+	})
+
+	response, err := svc.GetDelivery("delivery-1")
+	if err != nil {
+		t.Fatalf("GetDelivery: %v", err)
+	}
+
+	eta := response.TrackingInfo.ETA
+	if eta == nil {
+		t.Fatalf("response has no ETA")
+	}
+	// Allow a minute for the prep time that passed while the test ran
+	if eta.TravelTime != 15 || eta.PrepTime < 19 || eta.TotalTime != eta.PrepTime+eta.TravelTime {
+		t.Fatalf("eta = %+v, want 20 minutes of prep plus 15 of travel", eta)
+	}
+	if !response.TrackingInfo.EstimatedArrival.Equal(eta.EstimatedArrival) {
+		t.Fatalf("estimated arrival %s doesn't match the ETA %s", response.TrackingInfo.EstimatedArrival, eta.EstimatedArrival)
+	}
+}
+
+func TestCancelDeliveryPublishesEvent(t *testing.T) {
+	driverID := "driver-1"
+	svc, repo, _ := newTestDeliveryService(domain.Delivery{
+		ID: "delivery-1", OrderID: "order-1", CustomerID: "customer-1", MerchantID: "merchant-1",
+		DriverID: &driverID, Status: domain.StatusPickedUp, DeliveryFee: 5,
+	})
+	svc.pricingEngine = NewPricingEngine(&fakeConfigService{})
+	// No workers, so the order and notification calls are only queued
+	svc.sideEffects = newSideEffectQueue(0, 10, 1, 0)
+
+	var published []events.DeliveryCancelledPayload
+	svc.eventBus.Subscribe(events.DeliveryCancelled, func(ctx context.Context, event events.Event) error {
+		var payload events.DeliveryCancelledPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		published = append(published, payload)
+		return nil
+	})
+
+	if err := svc.CancelDelivery("delivery-1", "customer changed their mind", "customer-1", auth.RoleCustomer); err != nil {
+		t.Fatalf("CancelDelivery: %v", err)
+	}
+	svc.eventBus.Close()
+
+	stored := repo.deliveries["delivery-1"]
+	if len(published) != 1 {
+		t.Fatalf("published %d events, want 1", len(published))
+	}
+	got := published[0]
+	if got.DeliveryID != "delivery-1" || got.OrderID != "order-1" || got.CustomerID != "customer-1" ||
+		got.DriverID != driverID || got.Reason != "customer changed their mind" {
+		t.Fatalf("payload = %+v", got)
+	}
+	if stored.CancellationFee == 0 || got.CancellationFee != stored.CancellationFee || got.DriverCompensation != stored.DriverCompensation {
+		t.Fatalf("payload charges = %v/%v, want the stored %v/%v",
+			got.CancellationFee, got.DriverCompensation, stored.CancellationFee, stored.DriverCompensation)
+	}
+	if !got.CancelledAt.Equal(*stored.CancelledAt) {
+		t.Fatalf("cancelled at = %v, want %v", got.CancelledAt, *stored.CancelledAt)
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+)
+
+// withActiveDeliveries gives driverID count active deliveries plus one
+// already delivered, which doesn't count toward the cap
+func withActiveDeliveries(repo *fakeDeliveryRepo, driverID string, count int) {
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("%s-active-%d", driverID, i)
+		repo.Create(&domain.Delivery{ID: id, OrderID: "order-" + id, DriverID: &driverID, Status: domain.StatusPickedUp})
+	}
+	id := driverID + "-delivered"
+	repo.Create(&domain.Delivery{ID: id, OrderID: "order-" + id, DriverID: &driverID, Status: domain.StatusDelivered})
+}
+
+func TestManualAssignDriverCapacity(t *testing.T) {
+	svc, repo, drivers := newTestDeliveryService(domain.Delivery{ID: "delivery-1", OrderID: "order-1", Status: domain.StatusPending})
+	svc.maxActivePerDriver = 2
+	drivers.drivers["driver-1"] = &domain.DriverInfo{ID: "driver-1"}
+	withActiveDeliveries(repo, "driver-1", 2)
+
+	assign := func() error {
+		_, err := svc.ManualAssignDriver(domain.AssignDriverRequest{DeliveryID: "delivery-1", DriverID: "driver-1"}, "admin-1")
+		return err
+	}
+
+	if err := assign(); !errors.Is(err, domain.ErrDriverAtCapacity) {
+		t.Fatalf("error = %v, want %v", err, domain.ErrDriverAtCapacity)
+	}
+	if stored := repo.deliveries["delivery-1"]; stored.DriverID != nil {
+		t.Fatalf("delivery assigned to %s", *stored.DriverID)
+	}
+
+	// Completing one frees a slot
+	repo.deliveries["driver-1-active-0"].Status = domain.StatusDelivered
+	if err := assign(); err != nil {
+		t.Fatalf("ManualAssignDriver after a completion: %v", err)
+	}
+	if stored := repo.deliveries["delivery-1"]; stored.DriverID == nil || *stored.DriverID != "driver-1" {
+		t.Fatalf("delivery not assigned to driver-1")
+	}
+}
+
+func TestAutoAssignDriverCapacity(t *testing.T) {
+	busy := domain.DriverAvailability{DriverID: "busy", Distance: 0.1, Rating: 5, OnShift: true}
+	free := domain.DriverAvailability{DriverID: "free", Distance: 3, Rating: 3, OnShift: true}
+
+	tests := []struct {
+		name       string
+		freeActive int
+		wantDriver string
+	}{
+		{name: "closest driver at the cap is skipped", freeActive: 1, wantDriver: "free"},
+		{name: "every driver at the cap", freeActive: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, drivers := newTestDeliveryService(domain.Delivery{ID: "delivery-1", OrderID: "order-1", Status: domain.StatusPending})
+			svc.maxActivePerDriver = 2
+			drivers.available = []domain.DriverAvailability{busy, free}
+			withActiveDeliveries(repo, "busy", 2)
+			withActiveDeliveries(repo, "free", tt.freeActive)
+
+			_, err := svc.AutoAssignDriver(domain.AutoAssignmentRequest{DeliveryID: "delivery-1", Radius: 10})
+			stored := repo.deliveries["delivery-1"]
+			if tt.wantDriver == "" {
+				if !errors.Is(err, domain.ErrNoAvailableDrivers) {
+					t.Fatalf("error = %v, want %v", err, domain.ErrNoAvailableDrivers)
+				}
+				if stored.DriverID != nil {
+					t.Fatalf("delivery assigned to %s", *stored.DriverID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AutoAssignDriver: %v", err)
+			}
+			if stored.DriverID == nil || *stored.DriverID != tt.wantDriver {
+				t.Fatalf("delivery assigned to %v, want %s", stored.DriverID, tt.wantDriver)
+			}
+		})
+	}
+}
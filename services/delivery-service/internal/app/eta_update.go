@@ -0,0 +1,35 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+)
+
+// UpdateETA records the location service's recalculated arrival for the
+// order's delivery and tells the customer. Only deliveries on their way to the
+// customer are updated; the estimate is ignored before pickup and once the
+// delivery has finished.
+func (s *deliveryService) UpdateETA(orderID string, arrival time.Time) error {
+	delivery, err := s.deliveryRepo.GetByOrderID(orderID)
+	if err != nil {
+		return err
+	}
+
+	if delivery.Status != domain.StatusPickedUp && delivery.Status != domain.StatusInTransit {
+		return nil
+	}
+
+	delivery.EstimatedArrival = &arrival
+	delivery.UpdatedAt = time.Now()
+	if err := s.deliveryRepo.Update(delivery); err != nil {
+		return fmt.Errorf("failed to update delivery ETA: %w", err)
+	}
+
+	customerID := delivery.CustomerID
+	s.sideEffects.enqueue(fmt.Sprintf("notify order %s ETA %s", orderID, arrival.Format(time.RFC3339)), func() error {
+		return s.notificationService.SendETAUpdate(orderID, customerID, arrival)
+	})
+	return nil
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+)
+
+type etaNotice struct {
+	orderID    string
+	customerID string
+	arrival    time.Time
+}
+
+type fakeNotificationService struct {
+	domain.NotificationService
+	etaUpdates []etaNotice
+}
+
+func (n *fakeNotificationService) SendETAUpdate(orderID, customerID string, arrival time.Time) error {
+	n.etaUpdates = append(n.etaUpdates, etaNotice{orderID, customerID, arrival})
+	return nil
+}
+
+func TestUpdateETA(t *testing.T) {
+	arrival := time.Date(2026, 10, 15, 12, 45, 0, 0, time.UTC)
+
+	tests := []struct {
+		status       domain.DeliveryStatus
+		wantNotified bool
+	}{
+		{domain.StatusAssigned, false},
+		{domain.StatusPickedUp, true},
+		{domain.StatusInTransit, true},
+		{domain.StatusDelivered, false},
+		{domain.StatusCancelled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			svc, repo, _ := newTestDeliveryService(domain.Delivery{
+				ID:         "delivery-1",
+				OrderID:    "order-1",
+				CustomerID: "customer-1",
+				Status:     tt.status,
+			})
+			notifications := &fakeNotificationService{}
+			svc.notificationService = notifications
+			svc.sideEffects = newSideEffectQueue(0, 10, 1, 0)
+
+			if err := svc.UpdateETA("order-1", arrival); err != nil {
+				t.Fatalf("UpdateETA: %v", err)
+			}
+			drain(svc.sideEffects)
+
+			saved := repo.deliveries["delivery-1"].EstimatedArrival
+			if !tt.wantNotified {
+				if saved != nil {
+					t.Fatalf("estimated arrival = %v, want it left unset", saved)
+				}
+				if len(notifications.etaUpdates) != 0 {
+					t.Fatalf("sent %d ETA updates, want none", len(notifications.etaUpdates))
+				}
+				return
+			}
+
+			if saved == nil || !saved.Equal(arrival) {
+				t.Fatalf("estimated arrival = %v, want %v", saved, arrival)
+			}
+			want := etaNotice{"order-1", "customer-1", arrival}
+			if len(notifications.etaUpdates) != 1 || notifications.etaUpdates[0] != want {
+				t.Fatalf("ETA updates = %+v, want [%+v]", notifications.etaUpdates, want)
+			}
+		})
+	}
+}
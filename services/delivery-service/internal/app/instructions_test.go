@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+func TestPublishAssignedCarriesInstructions(t *testing.T) {
+	svc, _, _ := newTestDeliveryService()
+	delivery := &domain.Delivery{
+		ID:      "delivery-1",
+		OrderID: "order-1",
+		Instructions: domain.DeliveryInstructions{
+			Contactless:   true,
+			DropLocation:  "leave at the door",
+			CallOnArrival: true,
+		},
+	}
+
+	var published []events.DeliveryAssignedPayload
+	svc.eventBus.Subscribe(events.DeliveryAssigned, func(ctx context.Context, event events.Event) error {
+		var payload events.DeliveryAssignedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		published = append(published, payload)
+		return nil
+	})
+
+	svc.publishAssigned(delivery, "driver-1")
+	svc.eventBus.Close()
+
+	if len(published) != 1 {
+		t.Fatalf("published %d events, want 1", len(published))
+	}
+	got := published[0]
+	if !got.Contactless || got.DropLocation != "leave at the door" || !got.CallOnArrival || got.DriverID != "driver-1" {
+		t.Fatalf("payload = %+v", got)
+	}
+}
+
+func TestCompleteContactlessDeliveryRequiresProof(t *testing.T) {
+	driverID := "driver-1"
+	svc, repo, _ := newTestDeliveryService(domain.Delivery{
+		ID: "delivery-1", OrderID: "order-1", DriverID: &driverID, Status: domain.StatusPickedUp,
+		Instructions: domain.DeliveryInstructions{Contactless: true},
+	})
+
+	_, err := svc.CompleteDelivery("delivery-1", driverID, domain.CompleteDeliveryRequest{})
+	if !errors.Is(err, domain.ErrProofRequired) {
+		t.Fatalf("error = %v, want %v", err, domain.ErrProofRequired)
+	}
+	if stored := repo.deliveries["delivery-1"]; stored.Status != domain.StatusPickedUp || stored.DeliveredAt != nil {
+		t.Fatalf("delivery = %s delivered at %v, want it still picked up", stored.Status, stored.DeliveredAt)
+	}
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"testing"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+)
+
+// UpdateOrderStatus records calls on the fake order service
+func (s *fakeOrderService) UpdateOrderStatus(orderID, status string) error {
+	s.updates = append(s.updates, orderID+":"+status)
+	return nil
+}
+
+// drain runs the side effects queued so far
+func drain(q *sideEffectQueue) {
+	for {
+		select {
+		case job := <-q.jobs:
+			job.call()
+		default:
+			return
+		}
+	}
+}
+
+func TestUpdateOrderStatusMapping(t *testing.T) {
+	tests := []struct {
+		status domain.DeliveryStatus
+		want   string // "" leaves the order unchanged
+	}{
+		{status: domain.StatusPending, want: "ready"},
+		{status: domain.StatusAssigned, want: "assigned"},
+		{status: domain.StatusAccepted, want: "assigned"},
+		{status: domain.StatusRejected, want: "ready"},
+		{status: domain.StatusPickedUp, want: "picked_up"},
+		{status: domain.StatusInTransit, want: "in_transit"},
+		{status: domain.StatusDelivered, want: "delivered"},
+		{status: domain.StatusCancelled, want: "cancelled"},
+		{status: domain.StatusFailed, want: "cancelled"},
+		{status: domain.DeliveryStatus("lost_in_space")},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			svc, _, _ := newTestDeliveryService()
+			orders := &fakeOrderService{}
+			svc.orderService = orders
+			svc.sideEffects = newSideEffectQueue(0, 10, 1, 0)
+
+			svc.updateOrderStatus("order-1", tt.status)
+			drain(svc.sideEffects)
+
+			if tt.want == "" {
+				if len(orders.updates) != 0 {
+					t.Fatalf("updates = %v, want none", orders.updates)
+				}
+				return
+			}
+			if len(orders.updates) != 1 || orders.updates[0] != "order-1:"+tt.want {
+				t.Fatalf("updates = %v, want order-1:%s", orders.updates, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+)
+
+func (r *fakeDeliveryRepo) GetByDriverID(driverID string, limit, offset int) ([]domain.Delivery, error) {
+	var deliveries []domain.Delivery
+	for _, delivery := range r.deliveries {
+		if delivery.DriverID != nil && *delivery.DriverID == driverID {
+			deliveries = append(deliveries, *delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+type fakePerformanceRepo struct {
+	domain.DriverPerformanceRepository
+	performances map[string]domain.DriverPerformance
+	rankingLimit int
+}
+
+func (r *fakePerformanceRepo) GetByDriverID(driverID string) (*domain.DriverPerformance, error) {
+	performance, ok := r.performances[driverID]
+	if !ok {
+		return nil, errors.New("performance not found")
+	}
+	return &performance, nil
+}
+
+func (r *fakePerformanceRepo) Create(performance *domain.DriverPerformance) error {
+	r.performances[performance.DriverID] = *performance
+	return nil
+}
+
+func (r *fakePerformanceRepo) Update(performance *domain.DriverPerformance) error {
+	r.performances[performance.DriverID] = *performance
+	return nil
+}
+
+func (r *fakePerformanceRepo) GetPunctualityRankings(limit int) ([]domain.DriverPerformance, error) {
+	r.rankingLimit = limit
+	return nil, nil
+}
+
+func TestUpdateDriverPerformanceLateness(t *testing.T) {
+	driverID := "driver-1"
+	minutes := func(m int) *int { return &m }
+	delivered := func(id string, estimated int, actual *int) domain.Delivery {
+		return domain.Delivery{ID: id, OrderID: "order-" + id, DriverID: &driverID, Status: domain.StatusDelivered, EstimatedTime: estimated, ActualTime: actual}
+	}
+
+	svc, _, _ := newTestDeliveryService(
+		delivered("early", 30, minutes(20)),     // -10
+		delivered("on-time", 20, minutes(22)),   // +2
+		delivered("late", 15, minutes(35)),      // +20
+		delivered("very-late", 10, minutes(50)), // +40
+		delivered("untimed", 10, nil),
+		domain.Delivery{ID: "cancelled", OrderID: "order-cancelled", DriverID: &driverID, Status: domain.StatusCancelled},
+	)
+	performances := &fakePerformanceRepo{performances: map[string]domain.DriverPerformance{
+		driverID: {ID: "performance-1", DriverID: driverID},
+	}}
+	svc.performanceRepo = performances
+
+	if err := svc.UpdateDriverPerformance(driverID); err != nil {
+		t.Fatalf("UpdateDriverPerformance: %v", err)
+	}
+
+	got := performances.performances[driverID]
+	if got.ID != "performance-1" {
+		t.Fatalf("ID = %s, want the existing performance-1", got.ID)
+	}
+	// Only deliveries with an actual time count toward lateness
+	if got.AverageLateness != 13 {
+		t.Fatalf("average lateness = %v, want 13", got.AverageLateness)
+	}
+	want := domain.LatenessDistribution{Early: 1, OnTime: 1, Late15To30: 1, LateOver30: 1}
+	if got.LatenessDistribution != want {
+		t.Fatalf("distribution = %+v, want %+v", got.LatenessDistribution, want)
+	}
+	if got.CompletedDeliveries != 5 || got.CancelledDeliveries != 1 || got.TotalDeliveries != 6 {
+		t.Fatalf("counts = %d completed, %d cancelled, %d total", got.CompletedDeliveries, got.CancelledDeliveries, got.TotalDeliveries)
+	}
+}
+
+func TestGetPunctualityRankingsLimit(t *testing.T) {
+	svc, _, _ := newTestDeliveryService()
+	performances := &fakePerformanceRepo{}
+	svc.performanceRepo = performances
+
+	for limit, want := range map[int]int{0: 20, 5: 5, 1000: 100} {
+		if _, err := svc.GetPunctualityRankings(limit); err != nil {
+			t.Fatalf("GetPunctualityRankings: %v", err)
+		}
+		if performances.rankingLimit != want {
+			t.Fatalf("limit %d queried %d, want %d", limit, performances.rankingLimit, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+)
+
+type pricingEngine struct {
+	configService domain.ConfigService
+}
+
+// NewPricingEngine prices deliveries from the coefficients in system config,
+// read on every quote so admin changes apply to the next delivery
+func NewPricingEngine(configService domain.ConfigService) domain.PricingEngine {
+	return &pricingEngine{configService: configService}
+}
+
+// Quote computes (base + per_km*distance + per_minute*minutes) * surge,
+// raised to the minimum fee and rounded to cents
+func (e *pricingEngine) Quote(distance float64, minutes int, surge float64) (*domain.DeliveryQuote, error) {
+	if distance < 0 || minutes < 0 {
+		return nil, fmt.Errorf("%w: distance and time cannot be negative", domain.ErrInvalidPricing)
+	}
+	if surge < 1 {
+		surge = 1
+	}
+
+	config := e.loadConfig()
+	quote := &domain.DeliveryQuote{
+		BaseFee:         roundCents(config.BaseFee),
+		DistanceFee:     roundCents(config.PerKm * distance),
+		TimeFee:         roundCents(config.PerMinute * float64(minutes)),
+		SurgeMultiplier: surge,
+	}
+
+	total := (quote.BaseFee + quote.DistanceFee + quote.TimeFee) * surge
+	quote.Total = roundCents(math.Max(total, config.MinimumFee))
+	return quote, nil
+}
+
+// loadConfig reads each coefficient from system config, keeping the default
+// for keys that are unset, unreadable or negative
+func (e *pricingEngine) loadConfig() domain.PricingConfig {
+	config := domain.DefaultPricingConfig
+	for key, field := range map[string]*float64{
+		domain.ConfigDeliveryBaseFee:    &config.BaseFee,
+		domain.ConfigDeliveryPerKm:      &config.PerKm,
+		domain.ConfigDeliveryPerMinute:  &config.PerMinute,
+		domain.ConfigDeliveryMinimumFee: &config.MinimumFee,
+	} {
+		value, ok, err := e.configService.GetFloat(key)
+		if err != nil {
+			log.Printf("Failed to read pricing config %s, using default: %v", key, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if value < 0 {
+			log.Printf("Ignoring negative pricing config %s=%v", key, value)
+			continue
+		}
+		*field = value
+	}
+	return config
+}
+
+// CancellationCharges charges nothing before a driver accepts, then the
+// accepted or picked-up share of the delivery fee
+func (e *pricingEngine) CancellationCharges(status domain.DeliveryStatus, deliveryFee float64) domain.CancellationCharges {
+	policy := e.loadCancellationPolicy()
+
+	var rates domain.CancellationRates
+	switch status {
+	case domain.StatusAccepted:
+		rates = policy.Accepted
+	case domain.StatusPickedUp, domain.StatusInTransit:
+		rates = policy.PickedUp
+	default:
+		return domain.CancellationCharges{}
+	}
+
+	return domain.CancellationCharges{
+		CustomerFee:        roundCents(deliveryFee * rates.CustomerFee),
+		DriverCompensation: roundCents(deliveryFee * rates.DriverCompensation),
+	}
+}
+
+// loadCancellationPolicy reads each rate from system config, keeping the
+// default for keys that are unset, unreadable or outside 0-1
+func (e *pricingEngine) loadCancellationPolicy() domain.CancellationPolicy {
+	policy := domain.DefaultCancellationPolicy
+	for key, field := range map[string]*float64{
+		domain.ConfigCancelFeeAccepted:          &policy.Accepted.CustomerFee,
+		domain.ConfigCancelFeePickedUp:          &policy.PickedUp.CustomerFee,
+		domain.ConfigCancelCompensationAccepted: &policy.Accepted.DriverCompensation,
+		domain.ConfigCancelCompensationPickedUp: &policy.PickedUp.DriverCompensation,
+	} {
+		value, ok, err := e.configService.GetFloat(key)
+		if err != nil {
+			log.Printf("Failed to read cancellation config %s, using default: %v", key, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if value < 0 || value > 1 {
+			log.Printf("Ignoring out of range cancellation config %s=%v", key, value)
+			continue
+		}
+		*field = value
+	}
+	return policy
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
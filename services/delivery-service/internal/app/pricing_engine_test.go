@@ -0,0 +1,104 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+)
+
+// fakeConfigService serves system config values; unset keys use the defaults
+type fakeConfigService struct {
+	values map[string]float64
+	err    error
+}
+
+func (s *fakeConfigService) GetFloat(key string) (float64, bool, error) {
+	if s.err != nil {
+		return 0, false, s.err
+	}
+	value, ok := s.values[key]
+	return value, ok, nil
+}
+
+func TestPricingEngineQuote(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]float64
+		distance float64
+		minutes  int
+		surge    float64
+		want     domain.DeliveryQuote
+	}{
+		{
+			name:     "defaults",
+			distance: 4, minutes: 15, surge: 1,
+			want: domain.DeliveryQuote{BaseFee: 1.5, DistanceFee: 2, TimeFee: 1.5, SurgeMultiplier: 1, Total: 5},
+		},
+		{
+			name:     "short trip raised to the minimum",
+			distance: 0.5, minutes: 2, surge: 1,
+			want: domain.DeliveryQuote{BaseFee: 1.5, DistanceFee: 0.25, TimeFee: 0.2, SurgeMultiplier: 1, Total: 2.5},
+		},
+		{
+			name:     "surge",
+			distance: 10, minutes: 30, surge: 1.5,
+			want: domain.DeliveryQuote{BaseFee: 1.5, DistanceFee: 5, TimeFee: 3, SurgeMultiplier: 1.5, Total: 14.25},
+		},
+		{
+			name:     "surge below 1 is ignored",
+			distance: 4, minutes: 15, surge: 0.5,
+			want: domain.DeliveryQuote{BaseFee: 1.5, DistanceFee: 2, TimeFee: 1.5, SurgeMultiplier: 1, Total: 5},
+		},
+		{
+			name:     "rounded to cents",
+			distance: 3.333, minutes: 7, surge: 1.15,
+			want: domain.DeliveryQuote{BaseFee: 1.5, DistanceFee: 1.67, TimeFee: 0.7, SurgeMultiplier: 1.15, Total: 4.45},
+		},
+		{
+			name:     "configured coefficients",
+			config:   map[string]float64{domain.ConfigDeliveryBaseFee: 2, domain.ConfigDeliveryPerKm: 1, domain.ConfigDeliveryMinimumFee: 10},
+			distance: 4, minutes: 10, surge: 1,
+			want: domain.DeliveryQuote{BaseFee: 2, DistanceFee: 4, TimeFee: 1, SurgeMultiplier: 1, Total: 10},
+		},
+		{
+			name:     "negative config ignored",
+			config:   map[string]float64{domain.ConfigDeliveryPerKm: -1},
+			distance: 4, minutes: 15, surge: 1,
+			want: domain.DeliveryQuote{BaseFee: 1.5, DistanceFee: 2, TimeFee: 1.5, SurgeMultiplier: 1, Total: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewPricingEngine(&fakeConfigService{values: tt.config})
+
+			quote, err := engine.Quote(tt.distance, tt.minutes, tt.surge)
+			if err != nil {
+				t.Fatalf("Quote: %v", err)
+			}
+			if *quote != tt.want {
+				t.Fatalf("quote = %+v, want %+v", *quote, tt.want)
+			}
+		})
+	}
+}
+
+func TestPricingEngineQuoteErrors(t *testing.T) {
+	engine := NewPricingEngine(&fakeConfigService{})
+	for _, input := range []struct {
+		distance float64
+		minutes  int
+	}{{-1, 10}, {1, -10}} {
+		if _, err := engine.Quote(input.distance, input.minutes, 1); !errors.Is(err, domain.ErrInvalidPricing) {
+			t.Fatalf("Quote(%v, %d) error = %v, want %v", input.distance, input.minutes, err, domain.ErrInvalidPricing)
+		}
+	}
+
+	// An unreadable config falls back to the defaults rather than failing
+	engine = NewPricingEngine(&fakeConfigService{err: errors.New("admin service down")})
+	quote, err := engine.Quote(4, 15, 1)
+	if err != nil || quote.Total != 5 {
+		t.Fatalf("Quote = %+v, %v, want the default 5", quote, err)
+	}
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"log"
+	"time"
+)
+
+// Cross-service calls made after a delivery changes (order status, driver
+// status, notifications) run in the background through a sideEffectQueue so a
+// transient failure in another service is retried instead of silently dropped.
+const (
+	sideEffectWorkers     = 4
+	sideEffectQueueSize   = 256
+	sideEffectMaxAttempts = 5
+	sideEffectBackoff     = 500 * time.Millisecond
+)
+
+type sideEffect struct {
+	name     string
+	call     func() error
+	attempts int
+}
+
+type sideEffectQueue struct {
+	jobs        chan sideEffect
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func newSideEffectQueue(workers, size, maxAttempts int, backoff time.Duration) *sideEffectQueue {
+	q := &sideEffectQueue{
+		jobs:        make(chan sideEffect, size),
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// enqueue schedules call without blocking the caller. When the queue is full
+// the call runs on its own goroutine rather than being dropped.
+func (q *sideEffectQueue) enqueue(name string, call func() error) {
+	job := sideEffect{name: name, call: call}
+	select {
+	case q.jobs <- job:
+	default:
+		log.Printf("Side effect queue full, running %q directly", name)
+		go q.run(job)
+	}
+}
+
+func (q *sideEffectQueue) work() {
+	for job := range q.jobs {
+		q.run(job)
+	}
+}
+
+// run calls job until it succeeds or maxAttempts is reached, doubling the
+// delay between attempts. A job that never succeeds is written to the
+// dead-letter log so the desync can be repaired by hand.
+func (q *sideEffectQueue) run(job sideEffect) {
+	delay := q.backoff
+	for {
+		job.attempts++
+		err := job.call()
+		if err == nil {
+			return
+		}
+		if job.attempts >= q.maxAttempts {
+			log.Printf("DEAD LETTER: %q failed after %d attempts: %v", job.name, job.attempts, err)
+			return
+		}
+		log.Printf("Side effect %q failed (attempt %d/%d), retrying in %s: %v", job.name, job.attempts, q.maxAttempts, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+)
+
+// flakyOrderService fails the first failures updates, then applies them
+type flakyOrderService struct {
+	domain.OrderService
+	mu       sync.Mutex
+	failures int
+	calls    int
+	applied  chan string
+}
+
+func (s *flakyOrderService) UpdateOrderStatus(orderID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.calls <= s.failures {
+		return errors.New("order service unavailable")
+	}
+	s.applied <- orderID + ":" + status
+	return nil
+}
+
+func TestSideEffectRetriesUntilApplied(t *testing.T) {
+	orders := &flakyOrderService{failures: 2, applied: make(chan string, 1)}
+	svc, _, _ := newTestDeliveryService()
+	svc.orderService = orders
+	svc.sideEffects = newSideEffectQueue(1, 10, 5, time.Millisecond)
+
+	svc.updateOrderStatus("order-1", domain.StatusDelivered)
+
+	select {
+	case got := <-orders.applied:
+		if got != "order-1:delivered" {
+			t.Fatalf("applied %s, want order-1:delivered", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("order status was never updated")
+	}
+	orders.mu.Lock()
+	defer orders.mu.Unlock()
+	if orders.calls != 3 {
+		t.Fatalf("order service called %d times, want 3", orders.calls)
+	}
+}
+
+func TestSideEffectDeadLetter(t *testing.T) {
+	var logs bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(previous) })
+
+	q := newSideEffectQueue(0, 1, 3, time.Millisecond)
+	attempts := 0
+	q.run(sideEffect{name: "update order order-1 to delivered", call: func() error {
+		attempts++
+		return errors.New("order service unavailable")
+	}})
+
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+	if !strings.Contains(logs.String(), `DEAD LETTER: "update order order-1 to delivered" failed after 3 attempts`) {
+		t.Fatalf("dead-letter log missing:\n%s", logs.String())
+	}
+}
+
+func TestSideEffectQueueFull(t *testing.T) {
+	// Without workers or room in the queue, the call still runs
+	q := newSideEffectQueue(0, 0, 1, 0)
+	done := make(chan struct{})
+	q.enqueue("notify", func() error {
+		close(done)
+		return nil
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("side effect dropped when the queue was full")
+	}
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"glovo-backend/services/delivery-service/internal/domain"
+)
+
+// GetLocationHistory returns every recorded point, like a location service
+// that rounds the window outward
+func (s *fakeLocationService) GetLocationHistory(driverID string, startTime, endTime time.Time) ([]domain.Location, error) {
+	return s.history, nil
+}
+
+func TestGetDeliveryTrail(t *testing.T) {
+	pickedUp := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	delivered := pickedUp.Add(20 * time.Minute)
+	at := func(minutes int) domain.Location {
+		return domain.Location{Latitude: 41.38, Longitude: 2.17 + float64(minutes)/1000, Timestamp: pickedUp.Add(time.Duration(minutes) * time.Minute)}
+	}
+	driverID := "driver-1"
+
+	svc, _, _ := newTestDeliveryService(domain.Delivery{
+		ID: "delivery-1", OrderID: "order-1", DriverID: &driverID, Status: domain.StatusDelivered,
+		PickedUpAt: &pickedUp, DeliveredAt: &delivered,
+	})
+	// Out of order, with points before pickup and after drop-off
+	svc.locationService = &fakeLocationService{history: []domain.Location{at(15), at(-5), at(0), at(20), at(7), at(25)}}
+
+	trail, err := svc.GetDeliveryTrail("delivery-1")
+	if err != nil {
+		t.Fatalf("GetDeliveryTrail: %v", err)
+	}
+	if !trail.StartTime.Equal(pickedUp) || !trail.EndTime.Equal(delivered) {
+		t.Fatalf("window = %v to %v, want %v to %v", trail.StartTime, trail.EndTime, pickedUp, delivered)
+	}
+
+	want := []time.Time{at(0).Timestamp, at(7).Timestamp, at(15).Timestamp, at(20).Timestamp}
+	if len(trail.Points) != len(want) {
+		t.Fatalf("got %d points, want %d: %+v", len(trail.Points), len(want), trail.Points)
+	}
+	for i, point := range trail.Points {
+		if !point.Timestamp.Equal(want[i]) {
+			t.Fatalf("point %d at %v, want %v", i, point.Timestamp, want[i])
+		}
+	}
+
+	feature := trail.GeoJSON()
+	first := feature.Geometry.Coordinates[0]
+	if feature.Geometry.Type != "LineString" || len(feature.Geometry.Coordinates) != 4 || first[0] != 2.17 || first[1] != 41.38 {
+		t.Fatalf("GeoJSON geometry = %+v, want a 4 point LineString of [longitude, latitude]", feature.Geometry)
+	}
+}
+
+func TestGetDeliveryTrailBeforePickup(t *testing.T) {
+	driverID := "driver-1"
+	svc, _, _ := newTestDeliveryService(domain.Delivery{ID: "delivery-1", OrderID: "order-1", DriverID: &driverID, Status: domain.StatusAccepted})
+
+	if _, err := svc.GetDeliveryTrail("delivery-1"); !errors.Is(err, domain.ErrNoTrail) {
+		t.Fatalf("error = %v, want %v", err, domain.ErrNoTrail)
+	}
+}
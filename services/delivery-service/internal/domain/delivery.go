@@ -1,33 +1,48 @@
 package domain
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"glovo-backend/shared/auth"
+	"glovo-backend/shared/pagination"
+	"glovo-backend/shared/vehicle"
 )
 
 // Delivery represents a delivery assignment
 type Delivery struct {
-	ID                 string           `json:"id" gorm:"primaryKey"`
-	OrderID            string           `json:"order_id" gorm:"uniqueIndex"`
-	DriverID           *string          `json:"driver_id,omitempty" gorm:"index"`
-	Status             DeliveryStatus   `json:"status"`
-	AssignmentType     AssignmentType   `json:"assignment_type"`
-	PickupAddress      Address          `json:"pickup_address" gorm:"embedded;embeddedPrefix:pickup_"`
-	DeliveryAddress    Address          `json:"delivery_address" gorm:"embedded;embeddedPrefix:delivery_"`
-	EstimatedTime      int              `json:"estimated_time"`        // in minutes
-	ActualTime         *int             `json:"actual_time,omitempty"` // in minutes
-	Distance           float64          `json:"distance"`              // in kilometers
-	DeliveryFee        float64          `json:"delivery_fee"`
-	Priority           DeliveryPriority `json:"priority"`
-	Notes              string           `json:"notes,omitempty"`
-	AssignedAt         *time.Time       `json:"assigned_at,omitempty"`
-	PickedUpAt         *time.Time       `json:"picked_up_at,omitempty"`
-	DeliveredAt        *time.Time       `json:"delivered_at,omitempty"`
-	CancelledAt        *time.Time       `json:"cancelled_at,omitempty"`
-	CancellationReason *string          `json:"cancellation_reason,omitempty"`
-	CreatedAt          time.Time        `json:"created_at"`
-	UpdatedAt          time.Time        `json:"updated_at"`
+	ID                 string               `json:"id" gorm:"primaryKey"`
+	OrderID            string               `json:"order_id" gorm:"uniqueIndex"`
+	CustomerID         string               `json:"customer_id" gorm:"index"`
+	MerchantID         string               `json:"merchant_id" gorm:"index"`
+	DriverID           *string              `json:"driver_id,omitempty" gorm:"index"`
+	Status             DeliveryStatus       `json:"status"`
+	AssignmentType     AssignmentType       `json:"assignment_type"`
+	PickupAddress      Address              `json:"pickup_address" gorm:"embedded;embeddedPrefix:pickup_"`
+	DeliveryAddress    Address              `json:"delivery_address" gorm:"embedded;embeddedPrefix:delivery_"`
+	EstimatedTime      int                  `json:"estimated_time"`        // travel time in minutes
+	PrepTime           int                  `json:"prep_time"`             // minutes the merchant needs before pickup
+	ActualTime         *int                 `json:"actual_time,omitempty"` // in minutes
+	Distance           float64              `json:"distance"`              // in kilometers
+	DeliveryFee        float64              `json:"delivery_fee"`
+	SurgeMultiplier    float64              `json:"surge_multiplier"` // demand pricing at creation, 1 for none
+	Tip                float64              `json:"tip"`              // customer tip, passed to the driver in full
+	Priority           DeliveryPriority     `json:"priority"`
+	RequiredVehicle    VehicleType          `json:"required_vehicle,omitempty"`
+	Notes              string               `json:"notes,omitempty"`
+	Instructions       DeliveryInstructions `json:"instructions" gorm:"embedded;embeddedPrefix:instructions_"`
+	ProofOfDelivery    string               `json:"proof_of_delivery,omitempty"` // photo URL, required for contactless drop-off
+	AssignedAt         *time.Time           `json:"assigned_at,omitempty"`
+	PickedUpAt         *time.Time           `json:"picked_up_at,omitempty"`
+	DeliveredAt        *time.Time           `json:"delivered_at,omitempty"`
+	CancelledAt        *time.Time           `json:"cancelled_at,omitempty"`
+	CancellationReason *string              `json:"cancellation_reason,omitempty"`
+	CancellationFee    float64              `json:"cancellation_fee,omitempty"`    // charged to the customer
+	DriverCompensation float64              `json:"driver_compensation,omitempty"` // paid to the driver
+	EstimatedArrival   *time.Time           `json:"estimated_arrival,omitempty"`   // recalculated from the driver's location after pickup
+	CreatedAt          time.Time            `json:"created_at"`
+	UpdatedAt          time.Time            `json:"updated_at"`
 }
 
 type DeliveryStatus string
@@ -44,6 +59,12 @@ const (
 	StatusFailed    DeliveryStatus = "failed"
 )
 
+// ActiveStatuses are the non-terminal statuses of a delivery with a driver
+var ActiveStatuses = []DeliveryStatus{StatusAssigned, StatusAccepted, StatusPickedUp, StatusInTransit}
+
+// DefaultMaxActiveDeliveries caps how many active deliveries a driver can hold
+const DefaultMaxActiveDeliveries = 3
+
 type AssignmentType string
 
 const (
@@ -60,6 +81,25 @@ const (
 	PriorityUrgent DeliveryPriority = "urgent"
 )
 
+// VehicleType ranks vehicles by capacity; see the shared vehicle package
+type VehicleType = vehicle.Type
+
+const (
+	VehicleBicycle    = vehicle.Bicycle
+	VehicleMotorcycle = vehicle.Motorcycle
+	VehicleCar        = vehicle.Car
+	VehicleVan        = vehicle.Van
+)
+
+// RequiredVehicleForOrder derives the minimum vehicle type an order needs.
+// Large or temperature-controlled items need a car; anything else fits a bicycle.
+func RequiredVehicleForOrder(order *OrderInfo) VehicleType {
+	if order != nil && (order.HasLargeItems || order.HasColdItems) {
+		return VehicleCar
+	}
+	return VehicleBicycle
+}
+
 type Address struct {
 	Street    string  `json:"street"`
 	City      string  `json:"city"`
@@ -71,6 +111,24 @@ type Address struct {
 	Notes     string  `json:"notes,omitempty"`
 }
 
+// String formats the address on one line, skipping empty parts
+func (a Address) String() string {
+	var parts []string
+	for _, part := range []string{a.Street, a.City, a.ZipCode} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DeliveryInstructions are the customer's drop-off preferences
+type DeliveryInstructions struct {
+	Contactless   bool   `json:"contactless"`
+	DropLocation  string `json:"drop_location,omitempty"` // e.g. "leave at the door"
+	CallOnArrival bool   `json:"call_on_arrival"`
+}
+
 // DeliveryAssignment tracks assignment attempts
 type DeliveryAssignment struct {
 	ID         string              `json:"id" gorm:"primaryKey"`
@@ -99,29 +157,66 @@ type AssignmentResponse struct {
 
 // DriverPerformance tracks driver metrics
 type DriverPerformance struct {
-	ID                  string    `json:"id" gorm:"primaryKey"`
-	DriverID            string    `json:"driver_id" gorm:"uniqueIndex"`
-	TotalDeliveries     int       `json:"total_deliveries"`
-	CompletedDeliveries int       `json:"completed_deliveries"`
-	CancelledDeliveries int       `json:"cancelled_deliveries"`
-	AverageRating       float64   `json:"average_rating"`
-	TotalRatings        int       `json:"total_ratings"`
-	AverageDeliveryTime float64   `json:"average_delivery_time"` // in minutes
-	OnTimeDeliveryRate  float64   `json:"on_time_delivery_rate"` // percentage
-	AcceptanceRate      float64   `json:"acceptance_rate"`       // percentage
-	LastUpdated         time.Time `json:"last_updated"`
+	ID                   string               `json:"id" gorm:"primaryKey"`
+	DriverID             string               `json:"driver_id" gorm:"uniqueIndex"`
+	TotalDeliveries      int                  `json:"total_deliveries"`
+	CompletedDeliveries  int                  `json:"completed_deliveries"`
+	CancelledDeliveries  int                  `json:"cancelled_deliveries"`
+	AverageRating        float64              `json:"average_rating"`
+	TotalRatings         int                  `json:"total_ratings"`
+	AverageDeliveryTime  float64              `json:"average_delivery_time"` // in minutes
+	OnTimeDeliveryRate   float64              `json:"on_time_delivery_rate"` // percentage
+	AcceptanceRate       float64              `json:"acceptance_rate"`       // percentage
+	AverageLateness      float64              `json:"average_lateness"`      // actual minus estimated minutes; negative is early
+	LatenessDistribution LatenessDistribution `json:"lateness_distribution" gorm:"embedded;embeddedPrefix:lateness_"`
+	LastUpdated          time.Time            `json:"last_updated"`
+}
+
+// LatenessDistribution counts completed deliveries by how late they were
+type LatenessDistribution struct {
+	Early      int `json:"early"`         // more than 5 minutes early
+	OnTime     int `json:"on_time"`       // within 5 minutes either way
+	Late5To15  int `json:"late_5_to_15"`  // 5 to 15 minutes late
+	Late15To30 int `json:"late_15_to_30"` // 15 to 30 minutes late
+	LateOver30 int `json:"late_over_30"`  // more than 30 minutes late
+}
+
+// Add counts one delivery's lateness in minutes
+func (d *LatenessDistribution) Add(lateness int) {
+	switch {
+	case lateness < -5:
+		d.Early++
+	case lateness <= 5:
+		d.OnTime++
+	case lateness <= 15:
+		d.Late5To15++
+	case lateness <= 30:
+		d.Late15To30++
+	default:
+		d.LateOver30++
+	}
 }
 
 // Request/Response DTOs
 type CreateDeliveryRequest struct {
-	OrderID         string           `json:"order_id" binding:"required"`
-	PickupAddress   Address          `json:"pickup_address" binding:"required"`
-	DeliveryAddress Address          `json:"delivery_address" binding:"required"`
-	EstimatedTime   int              `json:"estimated_time" binding:"required"`
-	Distance        float64          `json:"distance" binding:"required"`
-	DeliveryFee     float64          `json:"delivery_fee" binding:"required"`
-	Priority        DeliveryPriority `json:"priority"`
-	Notes           string           `json:"notes,omitempty"`
+	OrderID         string               `json:"order_id" binding:"required"`
+	CustomerID      string               `json:"customer_id,omitempty"` // taken from the order when empty
+	MerchantID      string               `json:"merchant_id,omitempty"` // taken from the order when empty
+	PickupAddress   Address              `json:"pickup_address" binding:"required"`
+	DeliveryAddress Address              `json:"delivery_address" binding:"required"`
+	EstimatedTime   int                  `json:"estimated_time" binding:"required"`   // travel time in minutes
+	PrepTime        int                  `json:"prep_time,omitempty" binding:"min=0"` // taken from the order when empty
+	Distance        float64              `json:"distance" binding:"required"`
+	SurgeMultiplier float64              `json:"surge_multiplier,omitempty" binding:"omitempty,min=1"` // 1 when empty
+	Tip             float64              `json:"tip,omitempty" binding:"min=0"`
+	Priority        DeliveryPriority     `json:"priority"`
+	RequiredVehicle VehicleType          `json:"required_vehicle,omitempty"` // derived from the order when empty
+	Notes           string               `json:"notes,omitempty"`
+	Instructions    DeliveryInstructions `json:"instructions"`
+}
+
+type CompleteDeliveryRequest struct {
+	ProofOfDelivery string `json:"proof_of_delivery,omitempty"` // photo URL
 }
 
 type AssignDriverRequest struct {
@@ -142,13 +237,20 @@ type DriverResponseRequest struct {
 }
 
 type DeliverySearchRequest struct {
-	Status   DeliveryStatus   `json:"status,omitempty"`
-	DriverID string           `json:"driver_id,omitempty"`
-	Priority DeliveryPriority `json:"priority,omitempty"`
-	DateFrom *time.Time       `json:"date_from,omitempty"`
-	DateTo   *time.Time       `json:"date_to,omitempty"`
-	Limit    int              `json:"limit,omitempty"`
-	Offset   int              `json:"offset,omitempty"`
+	Status     DeliveryStatus   `json:"status,omitempty"`
+	DriverID   string           `json:"driver_id,omitempty"`
+	CustomerID string           `json:"customer_id,omitempty"`
+	Priority   DeliveryPriority `json:"priority,omitempty"`
+	DateFrom   *time.Time       `json:"date_from,omitempty"`
+	DateTo     *time.Time       `json:"date_to,omitempty"`
+	Limit      int              `json:"limit,omitempty"`
+	Cursor     string           `json:"cursor,omitempty"` // next_cursor of the previous page
+}
+
+// DeliveryPage is one page of search results, newest first
+type DeliveryPage struct {
+	Deliveries []Delivery `json:"deliveries"`
+	NextCursor string     `json:"next_cursor,omitempty"`
 }
 
 type DeliveryResponse struct {
@@ -174,16 +276,71 @@ type Vehicle struct {
 }
 
 type OrderInfo struct {
-	ID           string  `json:"id"`
-	CustomerName string  `json:"customer_name"`
-	Items        int     `json:"items"`
-	TotalAmount  float64 `json:"total_amount"`
+	ID            string  `json:"id"`
+	CustomerID    string  `json:"customer_id"`
+	MerchantID    string  `json:"merchant_id"`
+	CustomerName  string  `json:"customer_name"`
+	Items         int     `json:"items"`
+	TotalAmount   float64 `json:"total_amount"`
+	HasLargeItems bool    `json:"has_large_items"`
+	HasColdItems  bool    `json:"has_cold_items"`
+	PrepTime      int     `json:"prep_time"` // in minutes
 }
 
 type TrackingInfo struct {
-	CurrentLocation  *Location  `json:"current_location,omitempty"`
-	EstimatedArrival *time.Time `json:"estimated_arrival,omitempty"`
-	Route            []Location `json:"route,omitempty"`
+	CurrentLocation  *Location    `json:"current_location,omitempty"`
+	EstimatedArrival *time.Time   `json:"estimated_arrival,omitempty"`
+	ETA              *CustomerETA `json:"eta,omitempty"`
+	Route            []Location   `json:"route,omitempty"`
+}
+
+// CustomerETA is the estimate shown to the customer: what's left of the
+// merchant's preparation plus the travel time
+type CustomerETA struct {
+	PrepTime         int       `json:"prep_time"`   // minutes of preparation left
+	TravelTime       int       `json:"travel_time"` // minutes from pickup to drop-off
+	TotalTime        int       `json:"total_time"`  // minutes until arrival
+	EstimatedArrival time.Time `json:"estimated_arrival"`
+}
+
+// CustomerETA estimates when the delivery arrives. Before pickup the order is
+// ready PrepTime minutes after the delivery was created, or now if that has
+// passed, and travel starts then. After pickup only travel is left, ending at
+// the location service's latest estimate when there is one. A late delivery is
+// estimated to arrive now.
+func (d *Delivery) CustomerETA(now time.Time) CustomerETA {
+	travel := time.Duration(d.EstimatedTime) * time.Minute
+	if d.DeliveredAt != nil {
+		return CustomerETA{TravelTime: d.EstimatedTime, EstimatedArrival: *d.DeliveredAt}
+	}
+
+	var prepLeft time.Duration
+	var arrival time.Time
+	if d.PickedUpAt != nil && d.EstimatedArrival != nil {
+		arrival = *d.EstimatedArrival
+	} else if d.PickedUpAt != nil {
+		arrival = d.PickedUpAt.Add(travel)
+	} else {
+		readyAt := d.CreatedAt.Add(time.Duration(d.PrepTime) * time.Minute)
+		if readyAt.After(now) {
+			prepLeft = readyAt.Sub(now)
+		}
+		arrival = now.Add(prepLeft + travel)
+	}
+	if arrival.Before(now) {
+		arrival = now
+	}
+
+	return CustomerETA{
+		PrepTime:         ceilMinutes(prepLeft),
+		TravelTime:       d.EstimatedTime,
+		TotalTime:        ceilMinutes(arrival.Sub(now)),
+		EstimatedArrival: arrival,
+	}
+}
+
+func ceilMinutes(d time.Duration) int {
+	return int((d + time.Minute - 1) / time.Minute)
 }
 
 type Location struct {
@@ -192,6 +349,51 @@ type Location struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// DeliveryTrail is the driver's GPS trail from pickup to drop-off, oldest first
+type DeliveryTrail struct {
+	DeliveryID string     `json:"delivery_id"`
+	OrderID    string     `json:"order_id"`
+	DriverID   string     `json:"driver_id"`
+	StartTime  time.Time  `json:"start_time"` // picked up
+	EndTime    time.Time  `json:"end_time"`   // delivered or cancelled, or now while in progress
+	Points     []Location `json:"points"`
+}
+
+// GeoJSONFeature is a GeoJSON Feature with a LineString geometry
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"` // always "Feature"
+	Geometry   GeoJSONLineString      `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type GeoJSONLineString struct {
+	Type        string      `json:"type"`        // always "LineString"
+	Coordinates [][]float64 `json:"coordinates"` // [longitude, latitude]
+}
+
+// GeoJSON renders the trail as a LineString feature; point timestamps are
+// listed in the "timestamps" property, in the same order as the coordinates
+func (t *DeliveryTrail) GeoJSON() GeoJSONFeature {
+	coordinates := make([][]float64, len(t.Points))
+	timestamps := make([]time.Time, len(t.Points))
+	for i, point := range t.Points {
+		coordinates[i] = []float64{point.Longitude, point.Latitude}
+		timestamps[i] = point.Timestamp
+	}
+	return GeoJSONFeature{
+		Type:     "Feature",
+		Geometry: GeoJSONLineString{Type: "LineString", Coordinates: coordinates},
+		Properties: map[string]interface{}{
+			"delivery_id": t.DeliveryID,
+			"order_id":    t.OrderID,
+			"driver_id":   t.DriverID,
+			"start_time":  t.StartTime,
+			"end_time":    t.EndTime,
+			"timestamps":  timestamps,
+		},
+	}
+}
+
 type AutoAssignmentRequest struct {
 	DeliveryID string  `json:"delivery_id" binding:"required"`
 	Latitude   float64 `json:"latitude" binding:"required"`
@@ -200,13 +402,15 @@ type AutoAssignmentRequest struct {
 }
 
 type DriverAvailability struct {
-	DriverID    string  `json:"driver_id"`
-	Name        string  `json:"name"`
-	Distance    float64 `json:"distance"`
-	Rating      float64 `json:"rating"`
-	ETA         int     `json:"eta"` // in minutes
-	IsOnline    bool    `json:"is_online"`
-	IsAvailable bool    `json:"is_available"`
+	DriverID    string      `json:"driver_id"`
+	Name        string      `json:"name"`
+	Distance    float64     `json:"distance"`
+	Rating      float64     `json:"rating"`
+	ETA         int         `json:"eta"` // in minutes
+	VehicleType VehicleType `json:"vehicle_type"`
+	OnShift     bool        `json:"on_shift"`
+	IsOnline    bool        `json:"is_online"`
+	IsAvailable bool        `json:"is_available"`
 }
 
 type DeliveryMetrics struct {
@@ -220,6 +424,83 @@ type DeliveryMetrics struct {
 	SuccessRate         float64 `json:"success_rate"`
 }
 
+// Delivery pricing config keys, as stored in the admin system config
+const (
+	ConfigDeliveryBaseFee    = "delivery.base_fee"
+	ConfigDeliveryPerKm      = "delivery.per_km"
+	ConfigDeliveryPerMinute  = "delivery.per_minute"
+	ConfigDeliveryMinimumFee = "delivery.minimum_fee"
+)
+
+// PricingConfig holds the delivery fee coefficients
+type PricingConfig struct {
+	BaseFee    float64 `json:"base_fee"`
+	PerKm      float64 `json:"per_km"`
+	PerMinute  float64 `json:"per_minute"`
+	MinimumFee float64 `json:"minimum_fee"`
+}
+
+// DefaultPricingConfig is used for any coefficient missing from system config
+var DefaultPricingConfig = PricingConfig{
+	BaseFee:    1.50,
+	PerKm:      0.50,
+	PerMinute:  0.10,
+	MinimumFee: 2.50,
+}
+
+// Cancellation policy config keys: the share of the delivery fee charged to
+// the customer and paid to the driver, by how far the delivery got
+const (
+	ConfigCancelFeeAccepted          = "delivery.cancel_fee_accepted"
+	ConfigCancelFeePickedUp          = "delivery.cancel_fee_picked_up"
+	ConfigCancelCompensationAccepted = "delivery.cancel_compensation_accepted"
+	ConfigCancelCompensationPickedUp = "delivery.cancel_compensation_picked_up"
+)
+
+// CancellationRates are shares of the delivery fee, from 0 to 1
+type CancellationRates struct {
+	CustomerFee        float64 `json:"customer_fee"`
+	DriverCompensation float64 `json:"driver_compensation"`
+}
+
+// CancellationPolicy holds the rates once a driver has accepted and once the
+// order is picked up (or in transit). Cancelling before that is free.
+type CancellationPolicy struct {
+	Accepted CancellationRates `json:"accepted"`
+	PickedUp CancellationRates `json:"picked_up"`
+}
+
+// DefaultCancellationPolicy is used for any rate missing from system config
+var DefaultCancellationPolicy = CancellationPolicy{
+	Accepted: CancellationRates{CustomerFee: 0.25, DriverCompensation: 0.25},
+	PickedUp: CancellationRates{CustomerFee: 0.50, DriverCompensation: 0.75},
+}
+
+// CancellationCharges is what cancelling a delivery costs the customer and
+// pays the driver
+type CancellationCharges struct {
+	CustomerFee        float64 `json:"customer_fee"`
+	DriverCompensation float64 `json:"driver_compensation"`
+}
+
+// DeliveryQuote is a computed delivery fee and how it was reached
+type DeliveryQuote struct {
+	BaseFee         float64 `json:"base_fee"`
+	DistanceFee     float64 `json:"distance_fee"`
+	TimeFee         float64 `json:"time_fee"`
+	SurgeMultiplier float64 `json:"surge_multiplier"`
+	Total           float64 `json:"total"`
+}
+
+// PricingEngine is the single source of delivery fees
+type PricingEngine interface {
+	// Quote prices a delivery of distance km taking minutes, scaled by surge
+	Quote(distance float64, minutes int, surge float64) (*DeliveryQuote, error)
+	// CancellationCharges applies the cancellation policy to a delivery
+	// cancelled in status
+	CancellationCharges(status DeliveryStatus, deliveryFee float64) CancellationCharges
+}
+
 // Repository interfaces (ports)
 type DeliveryRepository interface {
 	Create(delivery *Delivery) error
@@ -227,11 +508,12 @@ type DeliveryRepository interface {
 	GetByOrderID(orderID string) (*Delivery, error)
 	GetByDriverID(driverID string, limit, offset int) ([]Delivery, error)
 	GetByStatus(status DeliveryStatus, limit, offset int) ([]Delivery, error)
-	Search(req DeliverySearchRequest) ([]Delivery, error)
+	Search(req DeliverySearchRequest, cursor *pagination.Cursor) ([]Delivery, error)
 	Update(delivery *Delivery) error
 	Delete(id string) error
 	GetPendingDeliveries() ([]Delivery, error)
 	GetActiveDeliveries() ([]Delivery, error)
+	CountActiveByDriverID(driverID string) (int64, error)
 }
 
 type DeliveryAssignmentRepository interface {
@@ -250,6 +532,7 @@ type DriverPerformanceRepository interface {
 	Update(performance *DriverPerformance) error
 	GetTopDrivers(limit int) ([]DriverPerformance, error)
 	GetDriverRankings() ([]DriverPerformance, error)
+	GetPunctualityRankings(limit int) ([]DriverPerformance, error)
 }
 
 // Service interfaces (ports)
@@ -257,9 +540,11 @@ type DeliveryService interface {
 	// Delivery management
 	CreateDelivery(req CreateDeliveryRequest) (*DeliveryResponse, error)
 	GetDelivery(deliveryID string) (*DeliveryResponse, error)
+	GetDeliveryTrail(deliveryID string) (*DeliveryTrail, error)
 	GetDeliveryByOrder(orderID string) (*DeliveryResponse, error)
 	UpdateDeliveryStatus(deliveryID string, req UpdateDeliveryStatusRequest, userID string, role auth.UserRole) (*DeliveryResponse, error)
 	CancelDelivery(deliveryID string, reason string, userID string, role auth.UserRole) error
+	UpdateETA(orderID string, arrival time.Time) error
 
 	// Driver assignment
 	AutoAssignDriver(req AutoAssignmentRequest) (*DeliveryResponse, error)
@@ -273,7 +558,7 @@ type DeliveryService interface {
 
 	// Driver operations
 	PickupOrder(deliveryID string, driverID string) (*DeliveryResponse, error)
-	CompleteDelivery(deliveryID string, driverID string) (*DeliveryResponse, error)
+	CompleteDelivery(deliveryID string, driverID string, req CompleteDeliveryRequest) (*DeliveryResponse, error)
 	ReportIssue(deliveryID string, driverID string, issue string) error
 
 	// Analytics and metrics
@@ -281,9 +566,10 @@ type DeliveryService interface {
 	GetDriverPerformance(driverID string) (*DriverPerformance, error)
 	UpdateDriverPerformance(driverID string) error
 	GetDriverRankings() ([]DriverPerformance, error)
+	GetPunctualityRankings(limit int) ([]DriverPerformance, error)
 
 	// Admin operations
-	SearchDeliveries(req DeliverySearchRequest) ([]Delivery, error)
+	SearchDeliveries(req DeliverySearchRequest) (*DeliveryPage, error)
 	ReassignDelivery(deliveryID string, newDriverID string, adminID string) error
 	GetSystemStats() (*DeliveryMetrics, error)
 }
@@ -305,16 +591,50 @@ type LocationService interface {
 	GetDriverLocation(driverID string) (*Location, error)
 	CreateDeliveryRoute(deliveryID, driverID string, pickup, dropoff Location) error
 	GetDeliveryTracking(deliveryID string) (*TrackingInfo, error)
+	GetLocationHistory(driverID string, startTime, endTime time.Time) ([]Location, error)
 	CalculateETA(from, to Location) (int, error)
 }
 
 type NotificationService interface {
-	SendDeliveryAssignment(driverID string, delivery *Delivery) error
 	SendDeliveryUpdate(orderID string, status DeliveryStatus) error
 	SendDriverNotification(driverID string, message string) error
+	SendETAUpdate(orderID, customerID string, arrival time.Time) error
 }
 
 type PaymentService interface {
 	ProcessDeliveryPayment(deliveryID string) error
 	CalculateDriverPayout(deliveryID string) (float64, error)
 }
+
+// ConfigService reads numeric system config values; ok is false when the key
+// is not set
+type ConfigService interface {
+	GetFloat(key string) (value float64, ok bool, err error)
+}
+
+var (
+	// ErrDeliveryExists is returned when an order already has a delivery
+	ErrDeliveryExists = errors.New("delivery already exists for this order")
+	// ErrNotAssignedDriver is returned when a driver acts on a delivery assigned to someone else
+	ErrNotAssignedDriver = errors.New("unauthorized: driver can only update their own deliveries")
+	// ErrInvalidStatusTransition is returned when a delivery cannot move to the requested status
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	// ErrNoAvailableDrivers is returned when auto-assignment finds no suitable driver
+	ErrNoAvailableDrivers = errors.New("no available drivers found")
+	// ErrDriverUnavailable is returned when manually assigning a driver who is not available
+	ErrDriverUnavailable = errors.New("driver is not available")
+	// ErrVehicleTooSmall is returned when a driver's vehicle cannot carry the delivery
+	ErrVehicleTooSmall = errors.New("driver's vehicle cannot handle this delivery")
+	// ErrDriverAtCapacity is returned when a driver already holds the maximum number of active deliveries
+	ErrDriverAtCapacity = errors.New("driver has reached the maximum number of active deliveries")
+	// ErrAssignmentNotFound is returned when a driver has no pending assignment for a delivery
+	ErrAssignmentNotFound = errors.New("no pending assignment found for this driver")
+	// ErrAssignmentExpired is returned when a driver responds after the assignment expired
+	ErrAssignmentExpired = errors.New("assignment has expired")
+	// ErrProofRequired is returned when completing a contactless delivery without proof
+	ErrProofRequired = errors.New("proof of delivery is required for contactless deliveries")
+	// ErrNoTrail is returned when asking for the trail of a delivery that was never picked up
+	ErrNoTrail = errors.New("delivery has no trail until it is picked up")
+	// ErrInvalidPricing is returned when a delivery cannot be priced from its inputs
+	ErrInvalidPricing = errors.New("invalid delivery pricing input")
+)
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCustomerETA(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		t := now.Add(time.Duration(minutes) * time.Minute)
+		return &t
+	}
+
+	tests := []struct {
+		name        string
+		delivery    Delivery
+		wantPrep    int
+		wantTotal   int
+		wantArrival time.Time
+	}{
+		{
+			name:        "just placed: prep plus travel",
+			delivery:    Delivery{CreatedAt: now, PrepTime: 20, EstimatedTime: 15},
+			wantPrep:    20,
+			wantTotal:   35,
+			wantArrival: now.Add(35 * time.Minute),
+		},
+		{
+			name:        "part of the prep done",
+			delivery:    Delivery{CreatedAt: *at(-12), PrepTime: 20, EstimatedTime: 15},
+			wantPrep:    8,
+			wantTotal:   23,
+			wantArrival: now.Add(23 * time.Minute),
+		},
+		{
+			name:        "prep overdue: travel from now",
+			delivery:    Delivery{CreatedAt: *at(-30), PrepTime: 20, EstimatedTime: 15},
+			wantTotal:   15,
+			wantArrival: now.Add(15 * time.Minute),
+		},
+		{
+			name:        "picked up: only travel left",
+			delivery:    Delivery{CreatedAt: *at(-30), PrepTime: 20, EstimatedTime: 15, PickedUpAt: at(-5)},
+			wantTotal:   10,
+			wantArrival: now.Add(10 * time.Minute),
+		},
+		{
+			name:        "running late",
+			delivery:    Delivery{CreatedAt: *at(-60), PrepTime: 20, EstimatedTime: 15, PickedUpAt: at(-30)},
+			wantArrival: now,
+		},
+		{
+			name:        "delivered",
+			delivery:    Delivery{CreatedAt: *at(-60), PrepTime: 20, EstimatedTime: 15, PickedUpAt: at(-30), DeliveredAt: at(-10)},
+			wantArrival: now.Add(-10 * time.Minute),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eta := tt.delivery.CustomerETA(now)
+			if eta.PrepTime != tt.wantPrep || eta.TotalTime != tt.wantTotal || !eta.EstimatedArrival.Equal(tt.wantArrival) {
+				t.Fatalf("eta = %+v, want prep %d, total %d, arriving %s", eta, tt.wantPrep, tt.wantTotal, tt.wantArrival)
+			}
+			if eta.TravelTime != tt.delivery.EstimatedTime {
+				t.Fatalf("travel time = %d, want %d", eta.TravelTime, tt.delivery.EstimatedTime)
+			}
+		})
+	}
+}
+
+func TestLatenessDistributionAdd(t *testing.T) {
+	var d LatenessDistribution
+	for _, lateness := range []int{-6, -5, 0, 5, 6, 15, 16, 30, 31} {
+		d.Add(lateness)
+	}
+
+	want := LatenessDistribution{Early: 1, OnTime: 3, Late5To15: 2, Late15To30: 2, LateOver30: 1}
+	if d != want {
+		t.Fatalf("distribution = %+v, want %+v", d, want)
+	}
+}
@@ -3,14 +3,19 @@ package main
 import (
 	"log"
 	"net/http"
-	"os"
+	"time"
 
 	"glovo-backend/services/driver-service/internal/adapters/client"
 	"glovo-backend/services/driver-service/internal/adapters/db"
 	httpHandler "glovo-backend/services/driver-service/internal/adapters/http"
 	"glovo-backend/services/driver-service/internal/app"
 	"glovo-backend/services/driver-service/internal/domain"
+	"glovo-backend/shared/config"
 	"glovo-backend/shared/database"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/health"
+	"glovo-backend/shared/logging"
+	"glovo-backend/shared/metrics"
 	"glovo-backend/shared/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -40,11 +45,16 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	logging.Setup("driver-service")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
 	}
 
+	// Fail fast on missing or malformed configuration
+	cfg := config.MustLoad("driver-service", config.Postgres(), config.Auth(), config.EventBus(), config.HTTPPort("PORT", "8005"))
+
 	// Initialize database connection
 	postgresDB := database.ConnectPostgres()
 
@@ -52,6 +62,8 @@ func main() {
 	if err := postgresDB.AutoMigrate(
 		&domain.Driver{},
 		&domain.DriverDocument{},
+		&domain.DriverShift{},
+		&domain.DocumentAuditLog{},
 	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
@@ -59,37 +71,62 @@ func main() {
 	// Initialize repositories
 	driverRepo := db.NewDriverRepository(postgresDB)
 	documentRepo := db.NewDriverDocumentRepository(postgresDB)
+	shiftRepo := db.NewDriverShiftRepository(postgresDB)
+	auditRepo := db.NewDocumentAuditLogRepository(postgresDB)
 
 	// Initialize external service clients (mock for now)
 	userService := client.NewMockUserService()
 	locationService := client.NewMockLocationService()
+	deliveryService := client.NewMockDeliveryService()
 	paymentService := client.NewMockPaymentService()
+	notificationService := client.NewMockNotificationService()
 
 	// Initialize use case
 	driverService := app.NewDriverService(
 		driverRepo,
 		documentRepo,
+		shiftRepo,
+		auditRepo,
 		userService,
 		locationService,
+		deliveryService,
 		paymentService,
+		notificationService,
 	)
 
-	// Setup Gin router
-	router := gin.Default()
+	// Daily job: expire lapsed documents and warn drivers about upcoming expiries
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for ; true; <-ticker.C {
+			sent, err := driverService.NotifyExpiringDocuments(30 * 24 * time.Hour)
+			if err != nil {
+				log.Printf("Document expiry job failed: %v", err)
+				continue
+			}
+			log.Printf("Document expiry job sent %d notifications", sent)
+		}
+	}()
 
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type,Authorization")
+	// Report requests made with impersonation tokens to the admin audit log
+	eventBus := events.FromEnv()
+	defer eventBus.Close()
+	middleware.AuditImpersonation(eventBus, "driver-service")
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// Setup Gin router
+	router := gin.New()
+	// Client IPs come from X-Forwarded-For only behind TRUSTED_PROXIES
+	if err := router.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	router.Use(gin.Recovery(), middleware.RequestID(), middleware.RequestLogger())
+	router.Use(metrics.Middleware("driver-service"))
 
-		c.Next()
-	})
+	// CORS, restricted to the origins in CORS_ALLOWED_ORIGINS
+	router.Use(middleware.CORS(middleware.CORSConfigFromEnv()))
+
+	// Prometheus metrics
+	router.GET("/metrics", metrics.Handler())
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -99,6 +136,9 @@ func main() {
 		})
 	})
 
+	// Readiness check, verifies database connectivity
+	router.GET("/health/ready", health.Ready("driver-service", map[string]health.Check{"postgres": health.Postgres(postgresDB)}))
+
 	// Initialize HTTP handler
 	handler := httpHandler.NewDriverHandler(driverService)
 
@@ -112,25 +152,6 @@ func main() {
 	// TODO: Remove the inline route handlers below (kept for reference)
 	// v1_old := router.Group("/api/v1/old")
 	{
-		// Public driver registration (after user authentication)
-		v1.POST("/drivers/register", middleware.AuthMiddleware(), func(c *gin.Context) {
-			userID := c.GetString("user_id")
-
-			var req domain.RegisterDriverRequest
-			if err := c.ShouldBindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-				return
-			}
-
-			driver, err := driverService.RegisterDriver(userID, req)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-
-			c.JSON(http.StatusCreated, driver)
-		})
-
 		// Driver profile routes (authenticated drivers)
 		drivers := v1.Group("/drivers")
 		drivers.Use(middleware.AuthMiddleware())
@@ -187,7 +208,7 @@ func main() {
 					return
 				}
 
-				updatedDriver, err := driverService.UpdateStatus(driver.ID, userID, req.Status)
+				updatedDriver, err := driverService.UpdateStatus(driver.ID, userID, req.Status, false)
 				if err != nil {
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 					return
@@ -283,17 +304,10 @@ func main() {
 	}
 
 	// Start server
-	port := getEnv("PORT", "8005")
+	port := cfg.String("PORT")
 	log.Printf("Driver Service starting on port %s", port)
 
 	if err := router.Run(":" + port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
-
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultValue
-}
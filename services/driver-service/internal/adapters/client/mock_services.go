@@ -1,6 +1,7 @@
 package client
 
 import (
+	"log"
 	"time"
 
 	"glovo-backend/services/driver-service/internal/domain"
@@ -45,6 +46,17 @@ func (m *mockLocationService) GetDriverLocation(driverID string) (*domain.Curren
 	}, nil
 }
 
+// Mock Delivery Service
+type mockDeliveryService struct{}
+
+func NewMockDeliveryService() domain.DeliveryService {
+	return &mockDeliveryService{}
+}
+
+func (m *mockDeliveryService) GetActiveDeliveries(driverID string) ([]domain.ActiveDelivery, error) {
+	return []domain.ActiveDelivery{}, nil
+}
+
 // Mock Payment Service
 type mockPaymentService struct{}
 
@@ -58,10 +70,26 @@ func (m *mockPaymentService) ProcessDriverPayout(driverID string, amount float64
 
 func (m *mockPaymentService) GetDriverEarnings(driverID string, startDate, endDate time.Time) (*domain.EarningsReport, error) {
 	return &domain.EarningsReport{
-		Period:      "Weekly",
-		Deliveries:  25,
-		Earnings:    750.50,
-		Commission:  112.58,
-		NetEarnings: 637.92,
+		Period:        "Weekly",
+		Deliveries:    25,
+		BaseFare:      500.00,
+		DistanceBonus: 120.50,
+		Surge:         45.00,
+		Tips:          85.00,
+		Earnings:      750.50,
+		Commission:    112.58,
+		NetEarnings:   637.92,
 	}, nil
 }
+
+// Mock Notification Service
+type mockNotificationService struct{}
+
+func NewMockNotificationService() domain.NotificationService {
+	return &mockNotificationService{}
+}
+
+func (m *mockNotificationService) SendDriverNotification(driverID string, message string) error {
+	log.Printf("Mock notification to driver %s: %s", driverID, message)
+	return nil
+}
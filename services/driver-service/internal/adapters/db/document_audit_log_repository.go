@@ -0,0 +1,27 @@
+package db
+
+import (
+	"glovo-backend/services/driver-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type documentAuditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewDocumentAuditLogRepository(db *gorm.DB) domain.DocumentAuditLogRepository {
+	return &documentAuditLogRepository{db: db}
+}
+
+func (r *documentAuditLogRepository) Create(log *domain.DocumentAuditLog) error {
+	return r.db.Create(log).Error
+}
+
+func (r *documentAuditLogRepository) GetByDocumentID(documentID string) ([]domain.DocumentAuditLog, error) {
+	var logs []domain.DocumentAuditLog
+	err := r.db.Where("document_id = ?", documentID).
+		Order("created_at DESC").
+		Find(&logs).Error
+	return logs, err
+}
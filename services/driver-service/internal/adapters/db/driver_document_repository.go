@@ -1,6 +1,8 @@
 package db
 
 import (
+	"time"
+
 	"glovo-backend/services/driver-service/internal/domain"
 
 	"gorm.io/gorm"
@@ -50,3 +52,42 @@ func (r *driverDocumentRepository) GetByStatusAndType(status domain.DocumentStat
 		Find(&documents).Error
 	return documents, err
 }
+
+func (r *driverDocumentRepository) GetExpiringBetween(from, to time.Time) ([]domain.DriverDocument, error) {
+	var documents []domain.DriverDocument
+	err := r.db.Where("status = ? AND expiry_date > ? AND expiry_date <= ? AND expiry_notified_at IS NULL", domain.DocStatusApproved, from, to).
+		Order("expiry_date ASC").
+		Find(&documents).Error
+	return documents, err
+}
+
+func (r *driverDocumentRepository) MarkExpiryNotified(id string, at time.Time) (bool, error) {
+	result := r.db.Model(&domain.DriverDocument{}).
+		Where("id = ? AND expiry_notified_at IS NULL", id).
+		Update("expiry_notified_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *driverDocumentRepository) MarkExpired(before time.Time) error {
+	return r.db.Model(&domain.DriverDocument{}).
+		Where("status <> ? AND expiry_date <= ?", domain.DocStatusExpired, before).
+		Update("status", domain.DocStatusExpired).Error
+}
+
+func (r *driverDocumentRepository) Review(review *domain.DocumentAuditLog) (bool, error) {
+	reviewed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.DriverDocument{}).
+			Where("id = ? AND status = ?", review.DocumentID, domain.DocStatusPending).
+			Update("status", review.Action)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+		reviewed = true
+		return nil
+	})
+	return reviewed, err
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"glovo-backend/services/driver-service/internal/domain"
+	"glovo-backend/shared/vehicle"
 
 	"gorm.io/gorm"
 )
@@ -52,7 +53,11 @@ func (r *driverRepository) Search(req domain.DriverSearchRequest) ([]domain.Driv
 	}
 
 	if req.VehicleType != "" {
-		query = query.Where("vehicle_type = ?", req.VehicleType)
+		query = query.Where("type = ?", req.VehicleType)
+	}
+
+	if req.MinVehicle != "" {
+		query = query.Where("type IN ?", vehicle.AtLeast(req.MinVehicle))
 	}
 
 	if req.MinRating > 0 {
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"time"
+
+	"glovo-backend/services/driver-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type driverShiftRepository struct {
+	db *gorm.DB
+}
+
+func NewDriverShiftRepository(db *gorm.DB) domain.DriverShiftRepository {
+	return &driverShiftRepository{db: db}
+}
+
+func (r *driverShiftRepository) Create(shift *domain.DriverShift) error {
+	return r.db.Create(shift).Error
+}
+
+func (r *driverShiftRepository) GetByDriverID(driverID string, from, to time.Time) ([]domain.DriverShift, error) {
+	var shifts []domain.DriverShift
+	err := r.db.Where("driver_id = ? AND end_time > ? AND start_time < ?", driverID, from, to).
+		Order("start_time ASC").
+		Find(&shifts).Error
+	return shifts, err
+}
+
+func (r *driverShiftRepository) GetOverlapping(driverID string, start, end time.Time) ([]domain.DriverShift, error) {
+	var shifts []domain.DriverShift
+	err := r.db.Where("driver_id = ? AND status = ? AND start_time < ? AND end_time > ?",
+		driverID, domain.ShiftScheduled, end, start).
+		Find(&shifts).Error
+	return shifts, err
+}
+
+func (r *driverShiftRepository) GetActiveDriverIDs(at time.Time) ([]string, error) {
+	var driverIDs []string
+	err := r.db.Model(&domain.DriverShift{}).
+		Where("status = ? AND start_time <= ? AND end_time > ?", domain.ShiftScheduled, at, at).
+		Distinct().
+		Pluck("driver_id", &driverIDs).Error
+	return driverIDs, err
+}
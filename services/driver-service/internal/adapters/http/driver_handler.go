@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -23,10 +24,10 @@ func NewDriverHandler(driverService domain.DriverService) *DriverHandler {
 }
 
 func (h *DriverHandler) SetupRoutes(router *gin.RouterGroup) {
-	// Public driver registration routes
+	// Driver registration, for users who already signed in
 	public := router.Group("/drivers")
 	{
-		public.POST("/register", h.registerDriver)
+		public.POST("/register", middleware.AuthMiddleware(), h.registerDriver)
 	}
 
 	// Driver profile management (authenticated drivers)
@@ -37,6 +38,7 @@ func (h *DriverHandler) SetupRoutes(router *gin.RouterGroup) {
 		profile.GET("/", h.getProfile)
 		profile.PUT("/", h.updateProfile)
 		profile.PUT("/status", h.updateStatus)
+		profile.GET("/onboarding", h.getOnboardingStatus)
 		profile.PUT("/location", h.updateLocation)
 
 		// Document management
@@ -45,6 +47,10 @@ func (h *DriverHandler) SetupRoutes(router *gin.RouterGroup) {
 
 		// Earnings
 		profile.GET("/earnings", h.getEarningsReport)
+
+		// Shift scheduling
+		profile.POST("/shifts", h.createShift)
+		profile.GET("/shifts", h.getShifts)
 	}
 
 	// Admin driver management
@@ -57,10 +63,40 @@ func (h *DriverHandler) SetupRoutes(router *gin.RouterGroup) {
 		admin.GET("/available", h.getAvailableDrivers)
 		admin.PUT("/documents/:id/approve", h.approveDocument)
 		admin.PUT("/documents/:id/reject", h.rejectDocument)
+		admin.GET("/documents/:id/audit", h.getDocumentAuditLogs)
 		admin.PUT("/:id/performance", h.updatePerformance)
 	}
 }
 
+// resolveDriver returns the driver the request acts on. Drivers always act on
+// their own profile, resolved from the token; a client-supplied driver_id is
+// only honoured for admins.
+func (h *DriverHandler) resolveDriver(c *gin.Context) (*domain.Driver, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return nil, false
+	}
+
+	role, _ := c.Get("role")
+	if driverID := c.Query("driver_id"); driverID != "" && role == string(auth.RoleAdmin) {
+		driver, err := h.driverService.GetDriver(driverID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Driver not found"})
+			return nil, false
+		}
+		return driver, true
+	}
+
+	driver, err := h.driverService.GetDriverByUser(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Driver profile not found"})
+		return nil, false
+	}
+
+	return driver, true
+}
+
 // @Summary Register as driver
 // @Description Register a new driver profile
 // @Tags drivers
@@ -138,10 +174,12 @@ func (h *DriverHandler) updateProfile(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	driverID := c.Query("driver_id")
+	current, ok := h.resolveDriver(c)
+	if !ok {
+		return
+	}
 
-	driver, err := h.driverService.UpdateProfile(driverID, userID.(string), req)
+	driver, err := h.driverService.UpdateProfile(current.ID, current.UserID, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -151,14 +189,16 @@ func (h *DriverHandler) updateProfile(c *gin.Context) {
 }
 
 // @Summary Update driver status
-// @Description Update driver availability status
+// @Description Update driver availability status. Going offline is rejected while the driver has active deliveries unless an admin sets force.
 // @Tags drivers
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body domain.DriverStatus true "Status update"
+// @Param force query bool false "Go offline despite active deliveries (admin only)"
 // @Success 200 {object} domain.Driver
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/driver/profile/status [put]
 func (h *DriverHandler) updateStatus(c *gin.Context) {
@@ -170,11 +210,20 @@ func (h *DriverHandler) updateStatus(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	driverID := c.Query("driver_id")
+	current, ok := h.resolveDriver(c)
+	if !ok {
+		return
+	}
 
-	driver, err := h.driverService.UpdateStatus(driverID, userID.(string), req.Status)
+	role, _ := c.Get("role")
+	force := c.Query("force") == "true" && role == string(auth.RoleAdmin)
+
+	driver, err := h.driverService.UpdateStatus(current.ID, current.UserID, req.Status, force)
 	if err != nil {
+		if errors.Is(err, domain.ErrOnboardingIncomplete) || errors.Is(err, domain.ErrActiveDeliveries) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -182,6 +231,30 @@ func (h *DriverHandler) updateStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, driver)
 }
 
+// @Summary Get onboarding status
+// @Description Get the checklist of steps the driver must complete before going online
+// @Tags drivers
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.OnboardingStatus
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/driver/profile/onboarding [get]
+func (h *DriverHandler) getOnboardingStatus(c *gin.Context) {
+	driver, ok := h.resolveDriver(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.driverService.GetOnboardingStatus(driver.ID, driver.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
 // @Summary Update driver location
 // @Description Update driver's current location
 // @Tags drivers
@@ -200,10 +273,12 @@ func (h *DriverHandler) updateLocation(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	driverID := c.Query("driver_id")
+	current, ok := h.resolveDriver(c)
+	if !ok {
+		return
+	}
 
-	driver, err := h.driverService.UpdateLocation(driverID, userID.(string), req)
+	driver, err := h.driverService.UpdateLocation(current.ID, current.UserID, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -230,10 +305,12 @@ func (h *DriverHandler) uploadDocument(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	driverID := c.Query("driver_id")
+	current, ok := h.resolveDriver(c)
+	if !ok {
+		return
+	}
 
-	document, err := h.driverService.UploadDocument(driverID, userID.(string), req)
+	document, err := h.driverService.UploadDocument(current.ID, current.UserID, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -251,10 +328,12 @@ func (h *DriverHandler) uploadDocument(c *gin.Context) {
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/driver/profile/documents [get]
 func (h *DriverHandler) getDocuments(c *gin.Context) {
-	userID, _ := c.Get("user_id")
-	driverID := c.Query("driver_id")
+	current, ok := h.resolveDriver(c)
+	if !ok {
+		return
+	}
 
-	documents, err := h.driverService.GetDocuments(driverID, userID.(string))
+	documents, err := h.driverService.GetDocuments(current.ID, current.UserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -295,15 +374,18 @@ func (h *DriverHandler) getEarningsReport(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	driverID := c.Query("driver_id")
+	current, ok := h.resolveDriver(c)
+	if !ok {
+		return
+	}
 
 	req := domain.EarningsReportRequest{
+		DriverID:  current.ID,
 		StartDate: startDate,
 		EndDate:   endDate,
 	}
 
-	report, err := h.driverService.GetEarningsReport(driverID, userID.(string), req)
+	report, err := h.driverService.GetEarningsReport(current.ID, current.UserID, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -312,6 +394,83 @@ func (h *DriverHandler) getEarningsReport(c *gin.Context) {
 	c.JSON(http.StatusOK, report)
 }
 
+// @Summary Schedule a shift
+// @Description Schedule a working window for the authenticated driver
+// @Tags drivers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.CreateShiftRequest true "Shift window"
+// @Success 201 {object} domain.DriverShift
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/driver/profile/shifts [post]
+func (h *DriverHandler) createShift(c *gin.Context) {
+	var req domain.CreateShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, ok := h.resolveDriver(c)
+	if !ok {
+		return
+	}
+
+	shift, err := h.driverService.CreateShift(current.ID, current.UserID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, shift)
+}
+
+// @Summary List shifts
+// @Description List the authenticated driver's shifts within a time range
+// @Tags drivers
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Range start (RFC3339), defaults to now"
+// @Param to query string false "Range end (RFC3339), defaults to 7 days from start"
+// @Success 200 {array} domain.DriverShift
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/driver/profile/shifts [get]
+func (h *DriverHandler) getShifts(c *gin.Context) {
+	from := time.Now()
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from format"})
+			return
+		}
+		from = parsed
+	}
+
+	to := from.AddDate(0, 0, 7)
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to format"})
+			return
+		}
+		to = parsed
+	}
+
+	current, ok := h.resolveDriver(c)
+	if !ok {
+		return
+	}
+
+	shifts, err := h.driverService.GetShifts(current.ID, current.UserID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, shifts)
+}
+
 // Admin endpoints
 
 // @Summary Search drivers
@@ -320,6 +479,8 @@ func (h *DriverHandler) getEarningsReport(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param status query string false "Driver status filter"
+// @Param vehicle_type query string false "Exact vehicle type filter"
+// @Param min_vehicle query string false "Minimum vehicle type the driver must have"
 // @Param limit query int false "Limit results"
 // @Param offset query int false "Offset for pagination"
 // @Success 200 {array} domain.Driver
@@ -334,9 +495,11 @@ func (h *DriverHandler) searchDrivers(c *gin.Context) {
 	offset, _ := strconv.Atoi(offsetStr)
 
 	req := domain.DriverSearchRequest{
-		Status: domain.DriverStatus(status),
-		Limit:  limit,
-		Offset: offset,
+		Status:      domain.DriverStatus(status),
+		VehicleType: domain.VehicleType(c.Query("vehicle_type")),
+		MinVehicle:  domain.VehicleType(c.Query("min_vehicle")),
+		Limit:       limit,
+		Offset:      offset,
 	}
 
 	drivers, err := h.driverService.SearchDrivers(req)
@@ -426,6 +589,7 @@ func (h *DriverHandler) getAvailableDrivers(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "Document ID"
 // @Success 200 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/admin/drivers/documents/{id}/approve [put]
 func (h *DriverHandler) approveDocument(c *gin.Context) {
@@ -434,6 +598,10 @@ func (h *DriverHandler) approveDocument(c *gin.Context) {
 
 	err := h.driverService.ApproveDocument(documentID, adminID.(string))
 	if err != nil {
+		if errors.Is(err, domain.ErrDocumentNotPending) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -451,6 +619,7 @@ func (h *DriverHandler) approveDocument(c *gin.Context) {
 // @Param request body map[string]string true "Rejection reason"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/admin/drivers/documents/{id}/reject [put]
 func (h *DriverHandler) rejectDocument(c *gin.Context) {
@@ -467,6 +636,10 @@ func (h *DriverHandler) rejectDocument(c *gin.Context) {
 
 	err := h.driverService.RejectDocument(documentID, adminID.(string), req.Reason)
 	if err != nil {
+		if errors.Is(err, domain.ErrDocumentNotPending) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -474,6 +647,27 @@ func (h *DriverHandler) rejectDocument(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Document rejected successfully"})
 }
 
+// @Summary Get document review history
+// @Description Get the approve/reject history of a driver document (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Document ID"
+// @Success 200 {array} domain.DocumentAuditLog
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/admin/drivers/documents/{id}/audit [get]
+func (h *DriverHandler) getDocumentAuditLogs(c *gin.Context) {
+	documentID := c.Param("id")
+
+	logs, err := h.driverService.GetDocumentAuditLogs(documentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
+
 // @Summary Update driver performance
 // @Description Update driver performance metrics (admin only)
 // @Tags admin
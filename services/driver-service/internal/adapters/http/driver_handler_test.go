@@ -0,0 +1,201 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"glovo-backend/services/driver-service/internal/domain"
+	"glovo-backend/shared/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeDriverService struct {
+	domain.DriverService
+	drivers map[string]*domain.Driver // by driver ID
+	active  map[string]int            // active deliveries by driver ID
+}
+
+func (s *fakeDriverService) GetDriver(driverID string) (*domain.Driver, error) {
+	driver, ok := s.drivers[driverID]
+	if !ok {
+		return nil, errors.New("driver not found")
+	}
+	return driver, nil
+}
+
+func (s *fakeDriverService) GetDriverByUser(userID string) (*domain.Driver, error) {
+	for _, driver := range s.drivers {
+		if driver.UserID == userID {
+			return driver, nil
+		}
+	}
+	return nil, errors.New("driver not found")
+}
+
+func (s *fakeDriverService) RegisterDriver(userID string, req domain.RegisterDriverRequest) (*domain.Driver, error) {
+	driver := &domain.Driver{ID: "driver-new", UserID: userID}
+	s.drivers[driver.ID] = driver
+	return driver, nil
+}
+
+func TestRegisterDriverRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+	token, err := auth.GenerateToken("user-1", auth.RoleCustomer)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+	}{
+		{name: "signed-in user", header: "Bearer " + token, wantStatus: http.StatusCreated},
+		{name: "no token", wantStatus: http.StatusUnauthorized},
+		{name: "invalid token", header: "Bearer not-a-token", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeDriverService{drivers: make(map[string]*domain.Driver)}
+			router := gin.New()
+			NewDriverHandler(service).SetupRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/drivers/register", strings.NewReader(`{"profile":{},"vehicle":{},"bank_info":{}}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if registered := service.drivers["driver-new"]; tt.wantStatus == http.StatusCreated && (registered == nil || registered.UserID != "user-1") {
+				t.Fatalf("driver not registered for the token's user: %+v", registered)
+			}
+		})
+	}
+}
+
+func TestResolveDriver(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		userID     string
+		role       auth.UserRole
+		query      string
+		wantDriver string
+		wantStatus int
+	}{
+		{name: "driver gets their own profile", userID: "user-1", role: auth.RoleDriver, wantDriver: "driver-1"},
+		{name: "driver_id is ignored for drivers", userID: "user-1", role: auth.RoleDriver, query: "?driver_id=driver-2", wantDriver: "driver-1"},
+		{name: "admin acts on driver_id", userID: "admin-1", role: auth.RoleAdmin, query: "?driver_id=driver-2", wantDriver: "driver-2"},
+		{name: "admin with unknown driver_id", userID: "admin-1", role: auth.RoleAdmin, query: "?driver_id=missing", wantStatus: http.StatusNotFound},
+		{name: "user without a driver profile", userID: "user-9", role: auth.RoleDriver, wantStatus: http.StatusNotFound},
+		{name: "no user in context", role: auth.RoleDriver, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewDriverHandler(&fakeDriverService{drivers: map[string]*domain.Driver{
+				"driver-1": {ID: "driver-1", UserID: "user-1"},
+				"driver-2": {ID: "driver-2", UserID: "user-2"},
+			}})
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/driver/profile"+tt.query, nil)
+			if tt.userID != "" {
+				c.Set(auth.ContextUserID, tt.userID)
+			}
+			c.Set(auth.ContextRole, string(tt.role))
+
+			driver, ok := handler.resolveDriver(c)
+			if tt.wantStatus != 0 {
+				if ok || w.Code != tt.wantStatus {
+					t.Fatalf("got ok=%v status %d, want status %d", ok, w.Code, tt.wantStatus)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("unexpected rejection with status %d", w.Code)
+			}
+			if driver.ID != tt.wantDriver {
+				t.Fatalf("resolved driver %s, want %s", driver.ID, tt.wantDriver)
+			}
+		})
+	}
+}
+
+func (s *fakeDriverService) UpdateStatus(driverID string, userID string, status domain.DriverStatus, force bool) (*domain.Driver, error) {
+	driver := s.drivers[driverID]
+	if status == domain.StatusOffline && !force && s.active[driverID] > 0 {
+		return nil, fmt.Errorf("%w: %d in progress", domain.ErrActiveDeliveries, s.active[driverID])
+	}
+	driver.Status = status
+	return driver, nil
+}
+
+func TestUpdateStatusOfflineGuard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+	driverToken, err := auth.GenerateToken("user-1", auth.RoleDriver)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	adminToken, err := auth.GenerateToken("admin-1", auth.RoleAdmin)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		query      string
+		active     int
+		wantStatus int
+	}{
+		{name: "idle driver goes offline", token: driverToken, wantStatus: http.StatusOK},
+		{name: "driver with an active delivery", token: driverToken, active: 1, wantStatus: http.StatusConflict},
+		{name: "driver can't force", token: driverToken, query: "?force=true", active: 1, wantStatus: http.StatusConflict},
+		{name: "admin without force", token: adminToken, query: "?driver_id=driver-1", active: 1, wantStatus: http.StatusConflict},
+		{name: "admin forces", token: adminToken, query: "?driver_id=driver-1&force=true", active: 1, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeDriverService{
+				drivers: map[string]*domain.Driver{"driver-1": {ID: "driver-1", UserID: "user-1", Status: domain.StatusOnline}},
+				active:  map[string]int{"driver-1": tt.active},
+			}
+			router := gin.New()
+			NewDriverHandler(service).SetupRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/driver/profile/status"+tt.query, strings.NewReader(`{"status":"offline"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			want := domain.StatusOnline
+			if tt.wantStatus == http.StatusOK {
+				want = domain.StatusOffline
+			}
+			if got := service.drivers["driver-1"].Status; got != want {
+				t.Fatalf("driver status = %s, want %s", got, want)
+			}
+		})
+	}
+}
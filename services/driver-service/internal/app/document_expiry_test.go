@@ -0,0 +1,103 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"glovo-backend/services/driver-service/internal/domain"
+)
+
+type fakeDocumentRepo struct {
+	domain.DriverDocumentRepository
+	documents []domain.DriverDocument
+	reviews   []domain.DocumentAuditLog
+	// stale, when set, is what GetByID returns, like a read that raced
+	// another admin's review
+	stale *domain.DriverDocument
+}
+
+func (r *fakeDocumentRepo) MarkExpired(before time.Time) error {
+	for i := range r.documents {
+		if r.documents[i].IsExpired(before) {
+			r.documents[i].Status = domain.DocStatusExpired
+		}
+	}
+	return nil
+}
+
+func (r *fakeDocumentRepo) GetExpiringBetween(from, to time.Time) ([]domain.DriverDocument, error) {
+	var expiring []domain.DriverDocument
+	for _, document := range r.documents {
+		if document.Status == domain.DocStatusApproved && document.ExpiryNotifiedAt == nil &&
+			document.ExpiryDate.After(from) && !document.ExpiryDate.After(to) {
+			expiring = append(expiring, document)
+		}
+	}
+	return expiring, nil
+}
+
+func (r *fakeDocumentRepo) MarkExpiryNotified(id string, at time.Time) (bool, error) {
+	for i := range r.documents {
+		if r.documents[i].ID == id && r.documents[i].ExpiryNotifiedAt == nil {
+			r.documents[i].ExpiryNotifiedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeDriverNotifier struct {
+	domain.NotificationService
+	sent    map[string]int // by driver ID
+	failFor string
+}
+
+func (n *fakeDriverNotifier) SendDriverNotification(driverID string, message string) error {
+	if driverID == n.failFor {
+		return errors.New("push failed")
+	}
+	n.sent[driverID]++
+	return nil
+}
+
+func TestNotifyExpiringDocumentsOnce(t *testing.T) {
+	at := func(d time.Duration) *time.Time {
+		tm := time.Now().Add(d)
+		return &tm
+	}
+	day := 24 * time.Hour
+	repo := &fakeDocumentRepo{documents: []domain.DriverDocument{
+		{ID: "soon", DriverID: "driver-1", Status: domain.DocStatusApproved, ExpiryDate: at(10 * day)},
+		{ID: "later", DriverID: "driver-2", Status: domain.DocStatusApproved, ExpiryDate: at(60 * day)},
+		{ID: "reminded", DriverID: "driver-3", Status: domain.DocStatusApproved, ExpiryDate: at(5 * day), ExpiryNotifiedAt: at(-day)},
+		{ID: "pending", DriverID: "driver-4", Status: domain.DocStatusPending, ExpiryDate: at(5 * day)},
+		{ID: "lapsed", DriverID: "driver-5", Status: domain.DocStatusApproved, ExpiryDate: at(-day)},
+	}}
+	notifier := &fakeDriverNotifier{sent: make(map[string]int)}
+	svc := NewDriverService(nil, repo, nil, nil, nil, nil, nil, nil, notifier)
+
+	for run := 1; run <= 3; run++ {
+		sent, err := svc.NotifyExpiringDocuments(30 * day)
+		if err != nil {
+			t.Fatalf("run %d: unexpected error: %v", run, err)
+		}
+		want := 0
+		if run == 1 {
+			want = 1
+		}
+		if sent != want {
+			t.Fatalf("run %d sent %d reminders, want %d", run, sent, want)
+		}
+	}
+
+	if len(notifier.sent) != 1 || notifier.sent["driver-1"] != 1 {
+		t.Fatalf("unexpected reminders: %v", notifier.sent)
+	}
+	if repo.documents[0].ExpiryNotifiedAt == nil {
+		t.Fatalf("reminder was not recorded")
+	}
+	if repo.documents[4].Status != domain.DocStatusExpired {
+		t.Fatalf("lapsed document status = %s, want expired", repo.documents[4].Status)
+	}
+}
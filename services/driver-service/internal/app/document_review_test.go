@@ -0,0 +1,166 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"glovo-backend/services/driver-service/internal/domain"
+)
+
+func (r *fakeDocumentRepo) GetByID(id string) (*domain.DriverDocument, error) {
+	for _, document := range r.documents {
+		if document.ID == id {
+			if r.stale != nil {
+				return r.stale, nil
+			}
+			return &document, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+// Review applies the decision and records it together, only to a pending
+// document, like the repository's transaction does
+func (r *fakeDocumentRepo) Review(review *domain.DocumentAuditLog) (bool, error) {
+	for i := range r.documents {
+		if r.documents[i].ID != review.DocumentID {
+			continue
+		}
+		if r.documents[i].Status != domain.DocStatusPending {
+			return false, nil
+		}
+		r.documents[i].Status = review.Action
+		r.reviews = append(r.reviews, *review)
+		return true, nil
+	}
+	return false, nil
+}
+
+type recordingNotifier struct {
+	domain.NotificationService
+	messages []string
+	err      error
+}
+
+func (n *recordingNotifier) SendDriverNotification(driverID string, message string) error {
+	n.messages = append(n.messages, driverID+": "+message)
+	return n.err
+}
+
+func TestReviewDocument(t *testing.T) {
+	tests := []struct {
+		name        string
+		review      func(svc domain.DriverService) error
+		notifyErr   error
+		wantStatus  domain.DocumentStatus
+		wantReason  string
+		wantMessage string
+	}{
+		{
+			name: "approve",
+			review: func(svc domain.DriverService) error {
+				return svc.ApproveDocument("document-1", "admin-1")
+			},
+			wantStatus:  domain.DocStatusApproved,
+			wantMessage: "driver-1: Your license has been approved.",
+		},
+		{
+			name: "reject",
+			review: func(svc domain.DriverService) error {
+				return svc.RejectDocument("document-1", "admin-1", "photo is blurry")
+			},
+			wantStatus:  domain.DocStatusRejected,
+			wantReason:  "photo is blurry",
+			wantMessage: "driver-1: Your license has been rejected: photo is blurry",
+		},
+		{
+			name: "notification fails",
+			review: func(svc domain.DriverService) error {
+				return svc.ApproveDocument("document-1", "admin-1")
+			},
+			notifyErr:   errors.New("push failed"),
+			wantStatus:  domain.DocStatusApproved,
+			wantMessage: "driver-1: Your license has been approved.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documents := &fakeDocumentRepo{documents: []domain.DriverDocument{
+				{ID: "document-1", DriverID: "driver-1", Type: "license", Status: domain.DocStatusPending},
+			}}
+			notifier := &recordingNotifier{err: tt.notifyErr}
+			svc := NewDriverService(nil, documents, nil, nil, nil, nil, nil, nil, notifier)
+
+			before := time.Now()
+			if err := tt.review(svc); err != nil {
+				t.Fatalf("review: %v", err)
+			}
+
+			if got := documents.documents[0].Status; got != tt.wantStatus {
+				t.Fatalf("document status = %s, want %s", got, tt.wantStatus)
+			}
+
+			if len(documents.reviews) != 1 {
+				t.Fatalf("%d audit entries, want 1", len(documents.reviews))
+			}
+			entry := documents.reviews[0]
+			if entry.AdminID != "admin-1" || entry.DocumentID != "document-1" || entry.DriverID != "driver-1" ||
+				entry.Action != tt.wantStatus || entry.Reason != tt.wantReason || entry.CreatedAt.Before(before) {
+				t.Fatalf("audit entry = %+v", entry)
+			}
+
+			if len(notifier.messages) != 1 || notifier.messages[0] != tt.wantMessage {
+				t.Fatalf("notifications = %q, want %q", notifier.messages, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestReviewDocumentOnce(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored domain.DocumentStatus
+		read   domain.DocumentStatus
+	}{
+		{name: "already reviewed", stored: domain.DocStatusApproved, read: domain.DocStatusApproved},
+		// Another admin reviewed the document after it was read
+		{name: "reviewed concurrently", stored: domain.DocStatusApproved, read: domain.DocStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := domain.DriverDocument{ID: "document-1", DriverID: "driver-1", Type: "license", Status: tt.stored}
+			read := document
+			read.Status = tt.read
+			documents := &fakeDocumentRepo{documents: []domain.DriverDocument{document}, stale: &read}
+			notifier := &recordingNotifier{}
+			svc := NewDriverService(nil, documents, nil, nil, nil, nil, nil, nil, notifier)
+
+			if err := svc.RejectDocument("document-1", "admin-2", "photo is blurry"); !errors.Is(err, domain.ErrDocumentNotPending) {
+				t.Fatalf("expected %v, got %v", domain.ErrDocumentNotPending, err)
+			}
+			if documents.documents[0].Status != tt.stored || len(documents.reviews) != 0 || len(notifier.messages) != 0 {
+				t.Fatalf("status %s, %d audit entries and %d notifications after a repeated review",
+					documents.documents[0].Status, len(documents.reviews), len(notifier.messages))
+			}
+		})
+	}
+}
+
+func TestReviewMissingDocument(t *testing.T) {
+	documents := &fakeDocumentRepo{}
+	notifier := &recordingNotifier{}
+	svc := NewDriverService(nil, documents, nil, nil, nil, nil, nil, nil, notifier)
+
+	if err := svc.ApproveDocument("missing", "admin-1"); err == nil {
+		t.Fatalf("expected an error for a missing document")
+	}
+	if len(documents.reviews) != 0 {
+		t.Fatalf("audited a review of a missing document")
+	}
+	if len(notifier.messages) != 0 {
+		t.Fatalf("notified %q for a missing document", notifier.messages)
+	}
+}
@@ -3,6 +3,8 @@ package app
 import (
 	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"glovo-backend/services/driver-service/internal/domain"
@@ -11,26 +13,38 @@ import (
 )
 
 type driverService struct {
-	driverRepo      domain.DriverRepository
-	documentRepo    domain.DriverDocumentRepository
-	userService     domain.UserService
-	locationService domain.LocationService
-	paymentService  domain.PaymentService
+	driverRepo          domain.DriverRepository
+	documentRepo        domain.DriverDocumentRepository
+	shiftRepo           domain.DriverShiftRepository
+	auditRepo           domain.DocumentAuditLogRepository
+	userService         domain.UserService
+	locationService     domain.LocationService
+	deliveryService     domain.DeliveryService
+	paymentService      domain.PaymentService
+	notificationService domain.NotificationService
 }
 
 func NewDriverService(
 	driverRepo domain.DriverRepository,
 	documentRepo domain.DriverDocumentRepository,
+	shiftRepo domain.DriverShiftRepository,
+	auditRepo domain.DocumentAuditLogRepository,
 	userService domain.UserService,
 	locationService domain.LocationService,
+	deliveryService domain.DeliveryService,
 	paymentService domain.PaymentService,
+	notificationService domain.NotificationService,
 ) domain.DriverService {
 	return &driverService{
-		driverRepo:      driverRepo,
-		documentRepo:    documentRepo,
-		userService:     userService,
-		locationService: locationService,
-		paymentService:  paymentService,
+		driverRepo:          driverRepo,
+		documentRepo:        documentRepo,
+		shiftRepo:           shiftRepo,
+		auditRepo:           auditRepo,
+		userService:         userService,
+		locationService:     locationService,
+		deliveryService:     deliveryService,
+		paymentService:      paymentService,
+		notificationService: notificationService,
 	}
 }
 
@@ -121,7 +135,7 @@ func (s *driverService) UpdateProfile(driverID string, userID string, req domain
 	return driver, nil
 }
 
-func (s *driverService) UpdateStatus(driverID string, userID string, status domain.DriverStatus) (*domain.Driver, error) {
+func (s *driverService) UpdateStatus(driverID string, userID string, status domain.DriverStatus, force bool) (*domain.Driver, error) {
 	driver, err := s.driverRepo.GetByID(driverID)
 	if err != nil {
 		return nil, err
@@ -132,6 +146,21 @@ func (s *driverService) UpdateStatus(driverID string, userID string, status doma
 		return nil, errors.New("unauthorized")
 	}
 
+	if status == domain.StatusOnline {
+		if expired := expiredRequiredDocuments(driver.Documents, time.Now()); len(expired) > 0 {
+			return nil, fmt.Errorf("cannot go online: required documents expired: %v", expired)
+		}
+		if err := requireOnboarded(driver, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	if status == domain.StatusOffline && !force {
+		if err := s.requireNoActiveDeliveries(driverID); err != nil {
+			return nil, err
+		}
+	}
+
 	driver.Status = status
 	driver.UpdatedAt = time.Now()
 
@@ -142,6 +171,25 @@ func (s *driverService) UpdateStatus(driverID string, userID string, status doma
 	return driver, nil
 }
 
+// requireNoActiveDeliveries rejects going offline while an order still
+// depends on the driver. If the delivery service can't be reached the change
+// is rejected too, rather than risk orphaning an order.
+func (s *driverService) requireNoActiveDeliveries(driverID string) error {
+	active, err := s.deliveryService.GetActiveDeliveries(driverID)
+	if err != nil {
+		return fmt.Errorf("failed to check active deliveries: %w", err)
+	}
+	if len(active) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, 0, len(active))
+	for _, delivery := range active {
+		descriptions = append(descriptions, fmt.Sprintf("%s (order %s, %s)", delivery.ID, delivery.OrderID, delivery.Status))
+	}
+	return fmt.Errorf("%w: %s", domain.ErrActiveDeliveries, strings.Join(descriptions, ", "))
+}
+
 func (s *driverService) UpdateLocation(driverID string, userID string, req domain.UpdateLocationRequest) (*domain.Driver, error) {
 	driver, err := s.driverRepo.GetByID(driverID)
 	if err != nil {
@@ -228,7 +276,30 @@ func (s *driverService) GetAvailableDrivers(latitude, longitude, radius float64)
 		Limit:     50,
 	}
 
-	return s.driverRepo.Search(req)
+	drivers, err := s.driverRepo.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only drivers inside an active shift can take deliveries
+	activeIDs, err := s.shiftRepo.GetActiveDriverIDs(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	onShift := make(map[string]bool, len(activeIDs))
+	for _, id := range activeIDs {
+		onShift[id] = true
+	}
+
+	available := make([]domain.Driver, 0, len(drivers))
+	for _, driver := range drivers {
+		if onShift[driver.ID] {
+			available = append(available, driver)
+		}
+	}
+
+	return available, nil
 }
 
 func (s *driverService) GetEarningsReport(driverID string, userID string, req domain.EarningsReportRequest) ([]domain.EarningsReport, error) {
@@ -252,25 +323,58 @@ func (s *driverService) GetEarningsReport(driverID string, userID string, req do
 }
 
 func (s *driverService) ApproveDocument(documentID string, adminID string) error {
-	document, err := s.documentRepo.GetByID(documentID)
-	if err != nil {
-		return err
-	}
+	return s.reviewDocument(documentID, adminID, domain.DocStatusApproved, "")
+}
 
-	document.Status = domain.DocStatusApproved
+func (s *driverService) RejectDocument(documentID string, adminID string, reason string) error {
+	return s.reviewDocument(documentID, adminID, domain.DocStatusRejected, reason)
+}
 
-	return s.documentRepo.Update(document)
+func (s *driverService) GetDocumentAuditLogs(documentID string) ([]domain.DocumentAuditLog, error) {
+	return s.auditRepo.GetByDocumentID(documentID)
 }
 
-func (s *driverService) RejectDocument(documentID string, adminID string, reason string) error {
+// reviewDocument applies an admin decision to a pending document, records who
+// made it and tells the driver about the outcome.
+func (s *driverService) reviewDocument(documentID string, adminID string, status domain.DocumentStatus, reason string) error {
 	document, err := s.documentRepo.GetByID(documentID)
 	if err != nil {
 		return err
 	}
+	if document.Status != domain.DocStatusPending {
+		return domain.ErrDocumentNotPending
+	}
+
+	auditLog := &domain.DocumentAuditLog{
+		ID:         uuid.New().String(),
+		DocumentID: document.ID,
+		DriverID:   document.DriverID,
+		AdminID:    adminID,
+		Action:     status,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	}
 
-	document.Status = domain.DocStatusRejected
+	// The status only changes if no other admin reviewed the document since it was read
+	reviewed, err := s.documentRepo.Review(auditLog)
+	if err != nil {
+		return fmt.Errorf("failed to record document review: %w", err)
+	}
+	if !reviewed {
+		return domain.ErrDocumentNotPending
+	}
 
-	return s.documentRepo.Update(document)
+	message := fmt.Sprintf("Your %s has been approved.", document.Type)
+	if status == domain.DocStatusRejected {
+		message = fmt.Sprintf("Your %s has been rejected: %s", document.Type, reason)
+	}
+
+	// The review is already recorded, so a failed notification is only logged
+	if err := s.notificationService.SendDriverNotification(document.DriverID, message); err != nil {
+		log.Printf("Failed to notify driver %s of the review of document %s: %v", document.DriverID, document.ID, err)
+	}
+
+	return nil
 }
 
 func (s *driverService) UpdatePerformance(driverID string, stats domain.PerformanceStats) error {
@@ -284,3 +388,119 @@ func (s *driverService) UpdatePerformance(driverID string, stats domain.Performa
 
 	return s.driverRepo.Update(driver)
 }
+
+func (s *driverService) CreateShift(driverID string, userID string, req domain.CreateShiftRequest) (*domain.DriverShift, error) {
+	driver, err := s.driverRepo.GetByID(driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if driver.UserID != userID {
+		return nil, errors.New("unauthorized")
+	}
+
+	if !req.EndTime.After(req.StartTime) {
+		return nil, errors.New("shift end time must be after start time")
+	}
+
+	if req.EndTime.Before(time.Now()) {
+		return nil, errors.New("cannot schedule a shift in the past")
+	}
+
+	overlapping, err := s.shiftRepo.GetOverlapping(driverID, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(overlapping) > 0 {
+		return nil, fmt.Errorf("shift overlaps with existing shift %s", overlapping[0].ID)
+	}
+
+	shift := &domain.DriverShift{
+		ID:        uuid.New().String(),
+		DriverID:  driverID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Status:    domain.ShiftScheduled,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := s.shiftRepo.Create(shift); err != nil {
+		return nil, fmt.Errorf("failed to create shift: %w", err)
+	}
+
+	return shift, nil
+}
+
+func (s *driverService) GetShifts(driverID string, userID string, from, to time.Time) ([]domain.DriverShift, error) {
+	driver, err := s.driverRepo.GetByID(driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if driver.UserID != userID {
+		return nil, errors.New("unauthorized")
+	}
+
+	return s.shiftRepo.GetByDriverID(driverID, from, to)
+}
+
+func (s *driverService) NotifyExpiringDocuments(within time.Duration) (int, error) {
+	now := time.Now()
+
+	if err := s.documentRepo.MarkExpired(now); err != nil {
+		return 0, fmt.Errorf("failed to mark expired documents: %w", err)
+	}
+
+	documents, err := s.documentRepo.GetExpiringBetween(now, now.Add(within))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, document := range documents {
+		// Claim the reminder first so overlapping runs don't both send it
+		claimed, err := s.documentRepo.MarkExpiryNotified(document.ID, now)
+		if err != nil {
+			log.Printf("Failed to record expiry reminder for document %s: %v", document.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		days := int(document.ExpiryDate.Sub(now).Hours() / 24)
+		message := fmt.Sprintf("Your %s expires in %d days. Please upload a renewed document to keep driving.", document.Type, days)
+		if err := s.notificationService.SendDriverNotification(document.DriverID, message); err != nil {
+			log.Printf("Failed to send expiry reminder for document %s: %v", document.ID, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// expiredRequiredDocuments returns the required document types that are expired.
+// A required type is only considered expired if the driver has no valid copy of it.
+func expiredRequiredDocuments(documents []domain.DriverDocument, now time.Time) []domain.DocumentType {
+	var expired []domain.DocumentType
+	for _, required := range domain.RequiredDocumentTypes {
+		hasExpired, hasValid := false, false
+		for _, document := range documents {
+			if document.Type != required || document.Status == domain.DocStatusRejected {
+				continue
+			}
+			if document.IsExpired(now) || document.Status == domain.DocStatusExpired {
+				hasExpired = true
+			} else {
+				hasValid = true
+			}
+		}
+		if hasExpired && !hasValid {
+			expired = append(expired, required)
+		}
+	}
+	return expired
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"glovo-backend/services/driver-service/internal/domain"
+)
+
+func (r *fakeDriverRepo) Update(driver *domain.Driver) error {
+	r.drivers[driver.ID] = driver
+	return nil
+}
+
+type fakeDeliveryService struct {
+	active map[string][]domain.ActiveDelivery // by driver ID
+	err    error
+}
+
+func (s *fakeDeliveryService) GetActiveDeliveries(driverID string) ([]domain.ActiveDelivery, error) {
+	return s.active[driverID], s.err
+}
+
+func TestUpdateStatusOffline(t *testing.T) {
+	active := []domain.ActiveDelivery{
+		{ID: "delivery-1", OrderID: "order-1", Status: "picked_up"},
+		{ID: "delivery-2", OrderID: "order-2", Status: "assigned"},
+	}
+	unavailable := errors.New("delivery service unavailable")
+
+	tests := []struct {
+		name       string
+		active     []domain.ActiveDelivery
+		lookupErr  error
+		force      bool
+		wantErr    error
+		wantListed []string
+	}{
+		{name: "idle driver"},
+		{
+			name:       "active deliveries",
+			active:     active,
+			wantErr:    domain.ErrActiveDeliveries,
+			wantListed: []string{"delivery-1 (order order-1, picked_up)", "delivery-2 (order order-2, assigned)"},
+		},
+		{name: "forced by an admin", active: active, force: true},
+		{name: "delivery service down", lookupErr: unavailable, wantErr: unavailable},
+		{name: "forced while delivery service down", lookupErr: unavailable, force: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drivers := &fakeDriverRepo{drivers: map[string]*domain.Driver{
+				"driver-1": {ID: "driver-1", UserID: "user-1", Status: domain.StatusOnline},
+			}}
+			deliveries := &fakeDeliveryService{
+				active: map[string][]domain.ActiveDelivery{"driver-1": tt.active},
+				err:    tt.lookupErr,
+			}
+			svc := &driverService{driverRepo: drivers, deliveryService: deliveries}
+
+			driver, err := svc.UpdateStatus("driver-1", "user-1", domain.StatusOffline, tt.force)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				for _, listed := range tt.wantListed {
+					if !strings.Contains(err.Error(), listed) {
+						t.Fatalf("error %q doesn't list %q", err, listed)
+					}
+				}
+				if status := drivers.drivers["driver-1"].Status; status != domain.StatusOnline {
+					t.Fatalf("status = %s after a rejected change, want %s", status, domain.StatusOnline)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("UpdateStatus: %v", err)
+			}
+			if driver.Status != domain.StatusOffline {
+				t.Fatalf("status = %s, want %s", driver.Status, domain.StatusOffline)
+			}
+		})
+	}
+}
+
+func TestUpdateStatusBusySkipsDeliveryCheck(t *testing.T) {
+	drivers := &fakeDriverRepo{drivers: map[string]*domain.Driver{
+		"driver-1": {ID: "driver-1", UserID: "user-1", Status: domain.StatusOnline},
+	}}
+	deliveries := &fakeDeliveryService{err: errors.New("delivery service unavailable")}
+	svc := &driverService{driverRepo: drivers, deliveryService: deliveries}
+
+	if _, err := svc.UpdateStatus("driver-1", "user-1", domain.StatusBusy, false); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+}
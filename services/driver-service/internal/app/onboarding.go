@@ -0,0 +1,122 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"glovo-backend/services/driver-service/internal/domain"
+)
+
+func (s *driverService) GetOnboardingStatus(driverID string, userID string) (*domain.OnboardingStatus, error) {
+	driver, err := s.driverRepo.GetByID(driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if driver.UserID != userID {
+		return nil, errors.New("unauthorized")
+	}
+
+	return onboardingStatus(driver, time.Now()), nil
+}
+
+// onboardingStatus derives the driver's checklist from their profile, vehicle,
+// documents and bank details
+func onboardingStatus(driver *domain.Driver, now time.Time) *domain.OnboardingStatus {
+	status := &domain.OnboardingStatus{
+		DriverID: driver.ID,
+		Steps: []domain.OnboardingItem{
+			onboardingItem(domain.StepProfile, missingProfileFields(driver)),
+			onboardingItem(domain.StepDocuments, missingDocuments(driver.Documents, now)),
+			onboardingItem(domain.StepPayoutAccount, missingBankFields(driver.BankInfo)),
+		},
+	}
+
+	status.Ready = true
+	for _, item := range status.Steps {
+		if !item.Complete {
+			status.Ready = false
+		}
+	}
+	return status
+}
+
+func onboardingItem(step domain.OnboardingStep, missing []string) domain.OnboardingItem {
+	return domain.OnboardingItem{Step: step, Complete: len(missing) == 0, Missing: missing}
+}
+
+func missingProfileFields(driver *domain.Driver) []string {
+	type field struct{ name, value string }
+	fields := []field{
+		{"first_name", driver.Profile.FirstName},
+		{"last_name", driver.Profile.LastName},
+		{"phone", driver.Profile.Phone},
+		{"email", driver.Profile.Email},
+		{"date_of_birth", driver.Profile.DateOfBirth},
+		{"address", driver.Profile.Address},
+		{"vehicle.type", string(driver.Vehicle.Type)},
+	}
+	// Bicycles carry no plate
+	if driver.Vehicle.Type != domain.VehicleBicycle {
+		fields = append(fields, field{"vehicle.license_plate", driver.Vehicle.LicensePlate})
+	}
+
+	var missing []string
+	for _, f := range fields {
+		if strings.TrimSpace(f.value) == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	return missing
+}
+
+// missingDocuments lists the required document types without an approved,
+// unexpired copy
+func missingDocuments(documents []domain.DriverDocument, now time.Time) []string {
+	var missing []string
+	for _, required := range domain.RequiredDocumentTypes {
+		approved := false
+		for _, document := range documents {
+			if document.Type == required && document.Status == domain.DocStatusApproved && !document.IsExpired(now) {
+				approved = true
+				break
+			}
+		}
+		if !approved {
+			missing = append(missing, string(required))
+		}
+	}
+	return missing
+}
+
+func missingBankFields(bank domain.BankInfo) []string {
+	var missing []string
+	if strings.TrimSpace(bank.AccountHolder) == "" {
+		missing = append(missing, "bank_info.account_holder")
+	}
+	if strings.TrimSpace(bank.AccountNumber) == "" {
+		missing = append(missing, "bank_info.account_number")
+	}
+	if strings.TrimSpace(bank.RoutingNumber) == "" {
+		missing = append(missing, "bank_info.routing_number")
+	}
+	return missing
+}
+
+// requireOnboarded rejects going online until every onboarding step is complete
+func requireOnboarded(driver *domain.Driver, now time.Time) error {
+	status := onboardingStatus(driver, now)
+	if status.Ready {
+		return nil
+	}
+
+	var incomplete []string
+	for _, item := range status.Steps {
+		if !item.Complete {
+			incomplete = append(incomplete, fmt.Sprintf("%s (%s)", item.Step, strings.Join(item.Missing, ", ")))
+		}
+	}
+	return fmt.Errorf("%w: %s", domain.ErrOnboardingIncomplete, strings.Join(incomplete, "; "))
+}
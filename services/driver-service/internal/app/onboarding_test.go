@@ -0,0 +1,152 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"glovo-backend/services/driver-service/internal/domain"
+)
+
+// onboardedDriver has every onboarding step complete as of now
+func onboardedDriver(now time.Time) *domain.Driver {
+	expiry := now.AddDate(1, 0, 0)
+	return &domain.Driver{
+		ID:     "driver-1",
+		UserID: "user-1",
+		Status: domain.StatusOffline,
+		Profile: domain.DriverProfile{
+			FirstName:   "Ana",
+			LastName:    "Puig",
+			Phone:       "+34600000000",
+			Email:       "ana@example.com",
+			DateOfBirth: "1990-04-02",
+			Address:     "Carrer de Mallorca 1",
+		},
+		Vehicle: domain.VehicleInfo{Type: domain.VehicleMotorcycle, LicensePlate: "1234ABC"},
+		Documents: []domain.DriverDocument{
+			{Type: domain.DocDriverLicense, Status: domain.DocStatusApproved, ExpiryDate: &expiry},
+			{Type: domain.DocInsurance, Status: domain.DocStatusApproved, ExpiryDate: &expiry},
+		},
+		BankInfo: domain.BankInfo{AccountHolder: "Ana Puig", AccountNumber: "ES0000000000", RoutingNumber: "0000"},
+	}
+}
+
+func TestOnboardingStatus(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+
+	tests := []struct {
+		name        string
+		change      func(d *domain.Driver)
+		wantMissing map[domain.OnboardingStep][]string
+	}{
+		{name: "complete", change: func(d *domain.Driver) {}},
+		{
+			name:   "bicycle needs no plate",
+			change: func(d *domain.Driver) { d.Vehicle = domain.VehicleInfo{Type: domain.VehicleBicycle} },
+		},
+		{
+			name: "incomplete profile",
+			change: func(d *domain.Driver) {
+				d.Profile.Email = "  "
+				d.Vehicle.LicensePlate = ""
+			},
+			wantMissing: map[domain.OnboardingStep][]string{domain.StepProfile: {"email", "vehicle.license_plate"}},
+		},
+		{
+			name:        "document awaiting review",
+			change:      func(d *domain.Driver) { d.Documents[0].Status = domain.DocStatusPending },
+			wantMissing: map[domain.OnboardingStep][]string{domain.StepDocuments: {"driver_license"}},
+		},
+		{
+			name:        "document expired",
+			change:      func(d *domain.Driver) { d.Documents[1].ExpiryDate = &yesterday },
+			wantMissing: map[domain.OnboardingStep][]string{domain.StepDocuments: {"insurance"}},
+		},
+		{
+			name:        "no payout account",
+			change:      func(d *domain.Driver) { d.BankInfo = domain.BankInfo{BankName: "Some Bank"} },
+			wantMissing: map[domain.OnboardingStep][]string{domain.StepPayoutAccount: {"bank_info.account_holder", "bank_info.account_number", "bank_info.routing_number"}},
+		},
+		{
+			name:   "just registered",
+			change: func(d *domain.Driver) { *d = domain.Driver{ID: "driver-1"} },
+			wantMissing: map[domain.OnboardingStep][]string{
+				domain.StepProfile:       {"first_name", "last_name", "phone", "email", "date_of_birth", "address", "vehicle.type", "vehicle.license_plate"},
+				domain.StepDocuments:     {"driver_license", "insurance"},
+				domain.StepPayoutAccount: {"bank_info.account_holder", "bank_info.account_number", "bank_info.routing_number"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := onboardedDriver(now)
+			tt.change(driver)
+
+			status := onboardingStatus(driver, now)
+			if status.Ready != (len(tt.wantMissing) == 0) {
+				t.Fatalf("ready = %v with missing %v", status.Ready, tt.wantMissing)
+			}
+
+			steps := []domain.OnboardingStep{domain.StepProfile, domain.StepDocuments, domain.StepPayoutAccount}
+			if len(status.Steps) != len(steps) {
+				t.Fatalf("got %d steps, want %d", len(status.Steps), len(steps))
+			}
+			for i, item := range status.Steps {
+				if item.Step != steps[i] {
+					t.Fatalf("step %d = %s, want %s", i, item.Step, steps[i])
+				}
+				want := tt.wantMissing[item.Step]
+				if item.Complete != (len(want) == 0) || !reflect.DeepEqual(item.Missing, want) {
+					t.Fatalf("%s = complete %v missing %v, want missing %v", item.Step, item.Complete, item.Missing, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGoOnlineRequiresOnboarding(t *testing.T) {
+	now := time.Now()
+
+	incomplete := onboardedDriver(now)
+	incomplete.Documents = incomplete.Documents[:1]
+	drivers := &fakeDriverRepo{drivers: map[string]*domain.Driver{"driver-1": incomplete}}
+	svc := &driverService{driverRepo: drivers}
+
+	_, err := svc.UpdateStatus("driver-1", "user-1", domain.StatusOnline, false)
+	if !errors.Is(err, domain.ErrOnboardingIncomplete) {
+		t.Fatalf("error = %v, want %v", err, domain.ErrOnboardingIncomplete)
+	}
+	if status := drivers.drivers["driver-1"].Status; status != domain.StatusOffline {
+		t.Fatalf("status = %s, want %s", status, domain.StatusOffline)
+	}
+
+	drivers.drivers["driver-1"] = onboardedDriver(now)
+	driver, err := svc.UpdateStatus("driver-1", "user-1", domain.StatusOnline, false)
+	if err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+	if driver.Status != domain.StatusOnline {
+		t.Fatalf("status = %s, want %s", driver.Status, domain.StatusOnline)
+	}
+}
+
+func TestGetOnboardingStatusOwnership(t *testing.T) {
+	drivers := &fakeDriverRepo{drivers: map[string]*domain.Driver{"driver-1": onboardedDriver(time.Now())}}
+	svc := &driverService{driverRepo: drivers}
+
+	status, err := svc.GetOnboardingStatus("driver-1", "user-1")
+	if err != nil {
+		t.Fatalf("GetOnboardingStatus: %v", err)
+	}
+	if !status.Ready || status.DriverID != "driver-1" {
+		t.Fatalf("status = %+v, want driver-1 ready", status)
+	}
+
+	if _, err := svc.GetOnboardingStatus("driver-1", "user-2"); err == nil {
+		t.Fatalf("another user read the driver's onboarding status")
+	}
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"glovo-backend/services/driver-service/internal/domain"
+)
+
+type fakeDriverRepo struct {
+	domain.DriverRepository
+	drivers map[string]*domain.Driver
+}
+
+func (r *fakeDriverRepo) GetByID(id string) (*domain.Driver, error) {
+	driver, ok := r.drivers[id]
+	if !ok {
+		return nil, errors.New("driver not found")
+	}
+	return driver, nil
+}
+
+type fakeShiftRepo struct {
+	domain.DriverShiftRepository
+	shifts []domain.DriverShift
+}
+
+func (r *fakeShiftRepo) Create(shift *domain.DriverShift) error {
+	r.shifts = append(r.shifts, *shift)
+	return nil
+}
+
+func (r *fakeShiftRepo) GetOverlapping(driverID string, start, end time.Time) ([]domain.DriverShift, error) {
+	var overlapping []domain.DriverShift
+	for _, shift := range r.shifts {
+		if shift.DriverID == driverID && shift.Overlaps(start, end) {
+			overlapping = append(overlapping, shift)
+		}
+	}
+	return overlapping, nil
+}
+
+func TestCreateShiftOverlap(t *testing.T) {
+	base := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	existing := []domain.DriverShift{
+		{ID: "morning", DriverID: "driver-1", StartTime: base, EndTime: base.Add(4 * time.Hour), Status: domain.ShiftScheduled},
+		{ID: "cancelled", DriverID: "driver-1", StartTime: base.Add(8 * time.Hour), EndTime: base.Add(10 * time.Hour), Status: domain.ShiftCancelled},
+		{ID: "other", DriverID: "driver-2", StartTime: base.Add(12 * time.Hour), EndTime: base.Add(14 * time.Hour), Status: domain.ShiftScheduled},
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		start   time.Time
+		end     time.Time
+		wantErr string
+	}{
+		{name: "identical window", userID: "user-1", start: base, end: base.Add(4 * time.Hour), wantErr: "overlaps with existing shift morning"},
+		{name: "contained", userID: "user-1", start: base.Add(time.Hour), end: base.Add(2 * time.Hour), wantErr: "overlaps"},
+		{name: "spanning", userID: "user-1", start: base.Add(-time.Hour), end: base.Add(5 * time.Hour), wantErr: "overlaps"},
+		{name: "overlaps start", userID: "user-1", start: base.Add(-time.Hour), end: base.Add(time.Hour), wantErr: "overlaps"},
+		{name: "overlaps end", userID: "user-1", start: base.Add(3 * time.Hour), end: base.Add(5 * time.Hour), wantErr: "overlaps"},
+		{name: "back to back after", userID: "user-1", start: base.Add(4 * time.Hour), end: base.Add(6 * time.Hour)},
+		{name: "back to back before", userID: "user-1", start: base.Add(-2 * time.Hour), end: base},
+		{name: "over cancelled shift", userID: "user-1", start: base.Add(8 * time.Hour), end: base.Add(10 * time.Hour)},
+		{name: "over another driver's shift", userID: "user-1", start: base.Add(12 * time.Hour), end: base.Add(14 * time.Hour)},
+		{name: "end before start", userID: "user-1", start: base.Add(6 * time.Hour), end: base.Add(5 * time.Hour), wantErr: "end time must be after start time"},
+		{name: "zero length", userID: "user-1", start: base.Add(6 * time.Hour), end: base.Add(6 * time.Hour), wantErr: "end time must be after start time"},
+		{name: "in the past", userID: "user-1", start: time.Now().Add(-3 * time.Hour), end: time.Now().Add(-time.Hour), wantErr: "in the past"},
+		{name: "not the owner", userID: "user-2", start: base.Add(6 * time.Hour), end: base.Add(7 * time.Hour), wantErr: "unauthorized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shiftRepo := &fakeShiftRepo{shifts: append([]domain.DriverShift(nil), existing...)}
+			driverRepo := &fakeDriverRepo{drivers: map[string]*domain.Driver{
+				"driver-1": {ID: "driver-1", UserID: "user-1"},
+			}}
+			svc := NewDriverService(driverRepo, nil, shiftRepo, nil, nil, nil, nil, nil, nil)
+
+			shift, err := svc.CreateShift("driver-1", tt.userID, domain.CreateShiftRequest{StartTime: tt.start, EndTime: tt.end})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if len(shiftRepo.shifts) != len(existing) {
+					t.Fatalf("rejected shift was persisted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if shift.Status != domain.ShiftScheduled || shift.DriverID != "driver-1" {
+				t.Fatalf("unexpected shift: %+v", shift)
+			}
+			if len(shiftRepo.shifts) != len(existing)+1 {
+				t.Fatalf("shift was not persisted")
+			}
+		})
+	}
+}
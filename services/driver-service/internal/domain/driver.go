@@ -1,7 +1,10 @@
 package domain
 
 import (
+	"errors"
 	"time"
+
+	"glovo-backend/shared/vehicle"
 )
 
 // Driver represents the domain entity
@@ -48,13 +51,14 @@ type VehicleInfo struct {
 	LicensePlate string      `json:"license_plate"`
 }
 
-type VehicleType string
+// VehicleType ranks vehicles by capacity; see the shared vehicle package
+type VehicleType = vehicle.Type
 
 const (
-	VehicleBicycle    VehicleType = "bicycle"
-	VehicleMotorcycle VehicleType = "motorcycle"
-	VehicleCar        VehicleType = "car"
-	VehicleVan        VehicleType = "van"
+	VehicleBicycle    = vehicle.Bicycle
+	VehicleMotorcycle = vehicle.Motorcycle
+	VehicleCar        = vehicle.Car
+	VehicleVan        = vehicle.Van
 )
 
 type DriverDocument struct {
@@ -65,6 +69,9 @@ type DriverDocument struct {
 	Status     DocumentStatus `json:"status"`
 	ExpiryDate *time.Time     `json:"expiry_date,omitempty"`
 	UploadedAt time.Time      `json:"uploaded_at"`
+	// ExpiryNotifiedAt is when the driver was reminded the document expires
+	// soon; each document is reminded about once
+	ExpiryNotifiedAt *time.Time `json:"expiry_notified_at,omitempty"`
 }
 
 type DocumentType string
@@ -86,6 +93,61 @@ const (
 	DocStatusExpired  DocumentStatus = "expired"
 )
 
+// DocumentAuditLog records an admin review decision on a driver document
+type DocumentAuditLog struct {
+	ID         string         `json:"id" gorm:"primaryKey"`
+	DocumentID string         `json:"document_id" gorm:"index"`
+	DriverID   string         `json:"driver_id" gorm:"index"`
+	AdminID    string         `json:"admin_id" gorm:"index"`
+	Action     DocumentStatus `json:"action"`
+	Reason     string         `json:"reason,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// RequiredDocumentTypes must be valid (not expired) for a driver to go online
+var RequiredDocumentTypes = []DocumentType{
+	DocDriverLicense,
+	DocInsurance,
+}
+
+// IsExpired reports whether the document's expiry date has passed
+func (d DriverDocument) IsExpired(now time.Time) bool {
+	return d.ExpiryDate != nil && !d.ExpiryDate.After(now)
+}
+
+// OnboardingStep is one requirement a driver must meet before going online
+type OnboardingStep string
+
+const (
+	StepProfile       OnboardingStep = "profile_complete"
+	StepDocuments     OnboardingStep = "documents_approved"
+	StepPayoutAccount OnboardingStep = "payment_method_set"
+)
+
+// OnboardingItem reports one step and, when incomplete, what is still missing
+type OnboardingItem struct {
+	Step     OnboardingStep `json:"step"`
+	Complete bool           `json:"complete"`
+	Missing  []string       `json:"missing,omitempty"`
+}
+
+// OnboardingStatus is the driver's checklist; Ready is true once every step is complete
+type OnboardingStatus struct {
+	DriverID string           `json:"driver_id"`
+	Ready    bool             `json:"ready"`
+	Steps    []OnboardingItem `json:"steps"`
+}
+
+// ErrOnboardingIncomplete is returned when a driver who hasn't finished onboarding tries to go online
+var ErrOnboardingIncomplete = errors.New("driver onboarding is incomplete")
+
+// ErrActiveDeliveries is returned when a driver tries to go offline with deliveries still in progress
+var ErrActiveDeliveries = errors.New("driver has active deliveries")
+
+// ErrDocumentNotPending is returned when an admin reviews a document that has
+// already been reviewed
+var ErrDocumentNotPending = errors.New("document is not pending review")
+
 type PerformanceStats struct {
 	Rating              float64 `json:"rating"`
 	TotalDeliveries     int     `json:"total_deliveries"`
@@ -118,6 +180,29 @@ type WorkingHour struct {
 	EndTime   string `json:"end_time"`
 }
 
+// DriverShift is a planned working window for a driver
+type DriverShift struct {
+	ID        string      `json:"id" gorm:"primaryKey"`
+	DriverID  string      `json:"driver_id" gorm:"index"`
+	StartTime time.Time   `json:"start_time" gorm:"index"`
+	EndTime   time.Time   `json:"end_time" gorm:"index"`
+	Status    ShiftStatus `json:"status"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// Overlaps reports whether a scheduled shift intersects [start, end); back-to-back shifts do not overlap
+func (s DriverShift) Overlaps(start, end time.Time) bool {
+	return s.Status == ShiftScheduled && s.StartTime.Before(end) && s.EndTime.After(start)
+}
+
+type ShiftStatus string
+
+const (
+	ShiftScheduled ShiftStatus = "scheduled"
+	ShiftCancelled ShiftStatus = "cancelled"
+)
+
 type BankInfo struct {
 	AccountHolder string `json:"account_holder"`
 	BankName      string `json:"bank_name"`
@@ -154,9 +239,15 @@ type UploadDocumentRequest struct {
 	ExpiryDate *time.Time   `json:"expiry_date,omitempty"`
 }
 
+type CreateShiftRequest struct {
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+}
+
 type DriverSearchRequest struct {
 	Status      DriverStatus `json:"status,omitempty"`
 	VehicleType VehicleType  `json:"vehicle_type,omitempty"`
+	MinVehicle  VehicleType  `json:"min_vehicle,omitempty"`
 	Latitude    float64      `json:"latitude,omitempty"`
 	Longitude   float64      `json:"longitude,omitempty"`
 	Radius      float64      `json:"radius,omitempty"` // in kilometers
@@ -173,12 +264,18 @@ type EarningsReportRequest struct {
 	GroupBy   string    `json:"group_by"` // day, week, month
 }
 
+// EarningsReport totals a driver's deliveries in a period. Earnings is the
+// sum of the base fares, distance bonuses, surge and tips.
 type EarningsReport struct {
-	Period      string  `json:"period"`
-	Deliveries  int     `json:"deliveries"`
-	Earnings    float64 `json:"earnings"`
-	Commission  float64 `json:"commission"`
-	NetEarnings float64 `json:"net_earnings"`
+	Period        string  `json:"period"`
+	Deliveries    int     `json:"deliveries"`
+	BaseFare      float64 `json:"base_fare"`
+	DistanceBonus float64 `json:"distance_bonus"`
+	Surge         float64 `json:"surge"`
+	Tips          float64 `json:"tips"`
+	Earnings      float64 `json:"earnings"`
+	Commission    float64 `json:"commission"`
+	NetEarnings   float64 `json:"net_earnings"`
 }
 
 // Repository interfaces (ports)
@@ -200,6 +297,29 @@ type DriverDocumentRepository interface {
 	Update(document *DriverDocument) error
 	Delete(id string) error
 	GetByStatusAndType(status DocumentStatus, docType DocumentType) ([]DriverDocument, error)
+	// GetExpiringBetween returns approved documents expiring in (from, to]
+	// whose driver hasn't been reminded yet
+	GetExpiringBetween(from, to time.Time) ([]DriverDocument, error)
+	// MarkExpiryNotified records the reminder, reporting false if another
+	// run already sent it
+	MarkExpiryNotified(id string, at time.Time) (bool, error)
+	MarkExpired(before time.Time) error
+	// Review sets a pending document's status to the decision in review and
+	// records review in the same transaction; it reports false, changing
+	// nothing, when the document is no longer pending
+	Review(review *DocumentAuditLog) (bool, error)
+}
+
+type DocumentAuditLogRepository interface {
+	Create(log *DocumentAuditLog) error
+	GetByDocumentID(documentID string) ([]DocumentAuditLog, error)
+}
+
+type DriverShiftRepository interface {
+	Create(shift *DriverShift) error
+	GetByDriverID(driverID string, from, to time.Time) ([]DriverShift, error)
+	GetOverlapping(driverID string, start, end time.Time) ([]DriverShift, error)
+	GetActiveDriverIDs(at time.Time) ([]string, error)
 }
 
 // Service interfaces (ports)
@@ -208,7 +328,9 @@ type DriverService interface {
 	GetDriver(driverID string) (*Driver, error)
 	GetDriverByUser(userID string) (*Driver, error)
 	UpdateProfile(driverID string, userID string, req UpdateDriverProfileRequest) (*Driver, error)
-	UpdateStatus(driverID string, userID string, status DriverStatus) (*Driver, error)
+	// UpdateStatus changes the driver's status; force skips the active
+	// delivery check when going offline and is meant for admins
+	UpdateStatus(driverID string, userID string, status DriverStatus, force bool) (*Driver, error)
 	UpdateLocation(driverID string, userID string, req UpdateLocationRequest) (*Driver, error)
 	UploadDocument(driverID string, userID string, req UploadDocumentRequest) (*DriverDocument, error)
 	GetDocuments(driverID string, userID string) ([]DriverDocument, error)
@@ -217,7 +339,12 @@ type DriverService interface {
 	GetEarningsReport(driverID string, userID string, req EarningsReportRequest) ([]EarningsReport, error)
 	ApproveDocument(documentID string, adminID string) error
 	RejectDocument(documentID string, adminID string, reason string) error
+	GetDocumentAuditLogs(documentID string) ([]DocumentAuditLog, error)
 	UpdatePerformance(driverID string, stats PerformanceStats) error
+	CreateShift(driverID string, userID string, req CreateShiftRequest) (*DriverShift, error)
+	GetShifts(driverID string, userID string, from, to time.Time) ([]DriverShift, error)
+	NotifyExpiringDocuments(within time.Duration) (int, error)
+	GetOnboardingStatus(driverID string, userID string) (*OnboardingStatus, error)
 }
 
 // External service interfaces
@@ -231,11 +358,21 @@ type LocationService interface {
 	GetDriverLocation(driverID string) (*CurrentLocation, error)
 }
 
+type DeliveryService interface {
+	// GetActiveDeliveries returns the driver's deliveries that are assigned,
+	// accepted, picked up or in transit
+	GetActiveDeliveries(driverID string) ([]ActiveDelivery, error)
+}
+
 type PaymentService interface {
 	ProcessDriverPayout(driverID string, amount float64) error
 	GetDriverEarnings(driverID string, startDate, endDate time.Time) (*EarningsReport, error)
 }
 
+type NotificationService interface {
+	SendDriverNotification(driverID string, message string) error
+}
+
 // External DTOs
 type User struct {
 	ID    string `json:"id"`
@@ -244,6 +381,12 @@ type User struct {
 	Role  string `json:"role"`
 }
 
+type ActiveDelivery struct {
+	ID      string `json:"id"`
+	OrderID string `json:"order_id"`
+	Status  string `json:"status"`
+}
+
 type DeliveryStats struct {
 	TotalDeliveries int     `json:"total_deliveries"`
 	TotalEarnings   float64 `json:"total_earnings"`
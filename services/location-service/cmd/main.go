@@ -11,7 +11,13 @@ import (
 	httpHandler "glovo-backend/services/location-service/internal/adapters/http"
 	"glovo-backend/services/location-service/internal/app"
 	"glovo-backend/services/location-service/internal/domain"
+	"glovo-backend/shared/config"
 	"glovo-backend/shared/database"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/health"
+	"glovo-backend/shared/logging"
+	"glovo-backend/shared/metrics"
+	"glovo-backend/shared/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -29,11 +35,17 @@ import (
 // @in header
 // @name Authorization
 func main() {
+	logging.Setup("location-service")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
 
+	// Fail fast on missing or malformed configuration
+	cfg := config.MustLoad("location-service", config.Mongo(), config.Auth(), config.EventBus(), config.HTTPPort("LOCATION_SERVICE_PORT", "8008"),
+		[]config.Var{{Key: "ETA_CHANGE_THRESHOLD", Kind: config.Duration, Default: "5m"}})
+
 	// Database connections
 	mongoClient := database.ConnectMongoDB()
 
@@ -47,6 +59,11 @@ func main() {
 	mapsService := &mockMapsService{}
 	notificationService := &mockNotificationService{}
 
+	// Publishes ETA changes for the delivery service
+	eventBus := events.FromEnv()
+	defer eventBus.Close()
+	middleware.AuditImpersonation(eventBus, "location-service")
+
 	// Initialize location service
 	locationService := app.NewLocationService(
 		locationRepo,
@@ -55,6 +72,8 @@ func main() {
 		geofenceRepo,
 		mapsService,
 		notificationService,
+		eventBus,
+		cfg.Duration("ETA_CHANGE_THRESHOLD"),
 	)
 
 	// Initialize HTTP handler
@@ -62,19 +81,19 @@ func main() {
 
 	// Setup Gin router
 	gin.SetMode(gin.ReleaseMode)
-	router := gin.Default()
-
-	// Simple CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "*")
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-		c.Next()
-	})
+	router := gin.New()
+	// Client IPs come from X-Forwarded-For only behind TRUSTED_PROXIES
+	if err := router.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	router.Use(gin.Recovery(), middleware.RequestID(), middleware.RequestLogger())
+	router.Use(metrics.Middleware("location-service"))
+
+	// CORS, restricted to the origins in CORS_ALLOWED_ORIGINS
+	router.Use(middleware.CORS(middleware.CORSConfigFromEnv()))
+
+	// Prometheus metrics
+	router.GET("/metrics", metrics.Handler())
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
@@ -84,6 +103,9 @@ func main() {
 		})
 	})
 
+	// Readiness check, verifies database connectivity
+	router.GET("/health/ready", health.Ready("location-service", map[string]health.Check{"mongodb": health.Mongo(mongoClient)}))
+
 	// API routes
 	v1 := router.Group("/api/v1")
 	{
@@ -95,7 +117,7 @@ func main() {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Start server
-	port := getEnv("LOCATION_SERVICE_PORT", "8008")
+	port := cfg.String("LOCATION_SERVICE_PORT")
 	log.Printf("Location Service starting on port %s", port)
 
 	if err := router.Run(":" + port); err != nil {
@@ -103,13 +125,6 @@ func main() {
 	}
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -1,11 +1,15 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"math"
+	"log"
 	"time"
 
 	"glovo-backend/services/location-service/internal/domain"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/geo"
 )
 
 type locationService struct {
@@ -15,6 +19,8 @@ type locationService struct {
 	geofenceRepo        domain.GeofenceRepository
 	mapsService         domain.MapsService
 	notificationService domain.NotificationService
+	eventBus            events.Bus
+	etaThreshold        time.Duration
 }
 
 func NewLocationService(
@@ -24,7 +30,13 @@ func NewLocationService(
 	geofenceRepo domain.GeofenceRepository,
 	mapsService domain.MapsService,
 	notificationService domain.NotificationService,
+	eventBus events.Bus,
+	etaThreshold time.Duration,
 ) domain.LocationService {
+	if etaThreshold <= 0 {
+		etaThreshold = domain.DefaultETAChangeThreshold
+	}
+
 	return &locationService{
 		driverLocationRepo:  driverLocationRepo,
 		locationHistoryRepo: locationHistoryRepo,
@@ -32,6 +44,8 @@ func NewLocationService(
 		geofenceRepo:        geofenceRepo,
 		mapsService:         mapsService,
 		notificationService: notificationService,
+		eventBus:            eventBus,
+		etaThreshold:        etaThreshold,
 	}
 }
 
@@ -131,6 +145,8 @@ func (s *locationService) CreateDeliveryRoute(req domain.RouteRequest) (*domain.
 		}
 	}
 
+	now := time.Now()
+	arrival := now.Add(time.Duration(routeInfo.Duration) * time.Minute)
 	route := &domain.DeliveryRoute{
 		OrderID:           req.OrderID,
 		DriverID:          req.DriverID,
@@ -141,9 +157,10 @@ func (s *locationService) CreateDeliveryRoute(req domain.RouteRequest) (*domain.
 		EstimatedDistance: routeInfo.Distance,
 		EstimatedDuration: routeInfo.Duration,
 		ActualDistance:    0,
-		StartedAt:         time.Now(),
-		CreatedAt:         time.Now(),
-		UpdatedAt:         time.Now(),
+		EstimatedArrival:  &arrival,
+		StartedAt:         now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
 	}
 
 	if err := s.deliveryRouteRepo.Create(route); err != nil {
@@ -174,12 +191,19 @@ func (s *locationService) UpdateRouteProgress(orderID string, currentLocation do
 		route.ActualDistance = s.calculateDistance(route.PickupLocation, currentLocation)
 	}
 
+	etaChange := s.recalculateETA(route, currentLocation)
 	route.UpdatedAt = time.Now()
 
 	if err := s.deliveryRouteRepo.Update(route); err != nil {
 		return nil, err
 	}
 
+	if etaChange != nil {
+		if err := s.eventBus.Publish(context.Background(), events.DeliveryETAChanged, *etaChange); err != nil {
+			log.Printf("Failed to publish %s for order %s: %v", events.DeliveryETAChanged, route.OrderID, err)
+		}
+	}
+
 	return route, nil
 }
 
@@ -400,26 +424,42 @@ func (s *locationService) updateActiveRouteProgress(driverID string, location do
 	}
 }
 
-func (s *locationService) calculateDistance(point1, point2 domain.GeoPoint) float64 {
-	// Simple haversine distance calculation
-	lat1 := point1.Coordinates[1]
-	lon1 := point1.Coordinates[0]
-	lat2 := point2.Coordinates[1]
-	lon2 := point2.Coordinates[0]
-
-	const R = 6371 // Earth's radius in kilometers
+// recalculateETA estimates the arrival from the driver's current location. It
+// returns the change to publish, and moves the route's EstimatedArrival, only
+// when the estimate differs from the last published one by at least the
+// threshold, so GPS jitter doesn't spam the customer.
+func (s *locationService) recalculateETA(route *domain.DeliveryRoute, current domain.GeoPoint) *events.DeliveryETAChangedPayload {
+	eta, err := s.mapsService.GetETA(current, route.DropoffLocation)
+	if err != nil {
+		return nil
+	}
 
-	lat1Rad := lat1 * math.Pi / 180
-	lat2Rad := lat2 * math.Pi / 180
-	deltaLat := (lat2 - lat1) * math.Pi / 180
-	deltaLon := (lon2 - lon1) * math.Pi / 180
+	if previous := route.EstimatedArrival; previous != nil {
+		shift := eta.ArrivalTime.Sub(*previous)
+		if shift < 0 {
+			shift = -shift
+		}
+		if shift < s.etaThreshold {
+			return nil
+		}
+	}
 
-	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
-		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
-			math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	change := &events.DeliveryETAChangedPayload{
+		OrderID:           route.OrderID,
+		DriverID:          route.DriverID,
+		EstimatedArrival:  eta.ArrivalTime,
+		PreviousArrival:   route.EstimatedArrival,
+		RemainingTime:     eta.Duration,
+		RemainingDistance: eta.Distance,
+		CalculatedAt:      time.Now(),
+	}
+	arrival := eta.ArrivalTime
+	route.EstimatedArrival = &arrival
+	return change
+}
 
-	return R * c
+func (s *locationService) calculateDistance(point1, point2 domain.GeoPoint) float64 {
+	return geo.DistanceKm(toGeoPoint(point1.Coordinates), toGeoPoint(point2.Coordinates))
 }
 
 func (s *locationService) isPointInGeofence(point domain.GeoPoint, geofence *domain.Geofence) bool {
@@ -443,7 +483,42 @@ func (s *locationService) isPointInGeofence(point domain.GeoPoint, geofence *dom
 		return distance <= (*geofence.Geometry.Radius / 1000) // Convert meters to km
 	}
 
-	// For polygon geofences, implement ray casting algorithm
-	// This is a simplified version - in production, use a proper geospatial library
+	if geofence.Geometry.Type == "Polygon" {
+		// GeoJSON polygon: rings of [longitude, latitude]; the first ring is the
+		// outline and any further rings are holes
+		var rings [][][]float64
+		data, err := json.Marshal(geofence.Geometry.Coordinates)
+		if err != nil || json.Unmarshal(data, &rings) != nil || len(rings) == 0 {
+			return false
+		}
+
+		p := toGeoPoint(point.Coordinates)
+		if !geo.InPolygon(p, toGeoRing(rings[0])) {
+			return false
+		}
+		for _, hole := range rings[1:] {
+			if geo.InPolygon(p, toGeoRing(hole)) {
+				return false
+			}
+		}
+		return true
+	}
+
 	return false
 }
+
+// toGeoPoint converts GeoJSON [longitude, latitude] coordinates
+func toGeoPoint(coordinates []float64) geo.Point {
+	if len(coordinates) < 2 {
+		return geo.Point{}
+	}
+	return geo.Point{Latitude: coordinates[1], Longitude: coordinates[0]}
+}
+
+func toGeoRing(ring [][]float64) []geo.Point {
+	points := make([]geo.Point, 0, len(ring))
+	for _, coordinates := range ring {
+		points = append(points, toGeoPoint(coordinates))
+	}
+	return points
+}
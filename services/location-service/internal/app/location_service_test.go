@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"glovo-backend/services/location-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+type fakeRouteRepo struct {
+	domain.DeliveryRouteRepository
+	route *domain.DeliveryRoute
+}
+
+func (r *fakeRouteRepo) GetByOrderID(orderID string) (*domain.DeliveryRoute, error) {
+	route := *r.route
+	return &route, nil
+}
+
+func (r *fakeRouteRepo) Update(route *domain.DeliveryRoute) error {
+	saved := *route
+	r.route = &saved
+	return nil
+}
+
+type fakeMapsService struct {
+	domain.MapsService
+	eta domain.ETAInfo
+}
+
+func (m *fakeMapsService) GetETA(origin, destination domain.GeoPoint) (*domain.ETAInfo, error) {
+	eta := m.eta
+	return &eta, nil
+}
+
+func TestUpdateRouteProgressETAThreshold(t *testing.T) {
+	published := time.Date(2026, 10, 15, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		previous     *time.Time
+		arrival      time.Time
+		wantNotified bool
+	}{
+		{"first estimate", nil, published, true},
+		{"jitter later", &published, published.Add(2 * time.Minute), false},
+		{"jitter earlier", &published, published.Add(-4 * time.Minute), false},
+		{"at the threshold", &published, published.Add(domain.DefaultETAChangeThreshold), true},
+		{"large delay", &published, published.Add(12 * time.Minute), true},
+		{"large gain", &published, published.Add(-9 * time.Minute), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes := &fakeRouteRepo{route: &domain.DeliveryRoute{
+				OrderID:          "order-1",
+				DriverID:         "driver-1",
+				EstimatedArrival: tt.previous,
+			}}
+			maps := &fakeMapsService{eta: domain.ETAInfo{Duration: 14, Distance: 3.2, ArrivalTime: tt.arrival}}
+			bus := events.NewInMemoryBus()
+
+			var mu sync.Mutex
+			var changes []events.DeliveryETAChangedPayload
+			bus.Subscribe(events.DeliveryETAChanged, func(ctx context.Context, event events.Event) error {
+				var payload events.DeliveryETAChangedPayload
+				if err := event.Decode(&payload); err != nil {
+					return err
+				}
+				mu.Lock()
+				changes = append(changes, payload)
+				mu.Unlock()
+				return nil
+			})
+
+			svc := NewLocationService(nil, nil, routes, nil, maps, nil, bus, 0)
+			current := domain.GeoPoint{Type: "Point", Coordinates: []float64{2.17, 41.38}}
+			if _, err := svc.UpdateRouteProgress("order-1", current); err != nil {
+				t.Fatalf("UpdateRouteProgress: %v", err)
+			}
+			bus.Close()
+
+			if !tt.wantNotified {
+				if len(changes) != 0 {
+					t.Fatalf("published %d ETA changes for jitter, want none", len(changes))
+				}
+				// The last published estimate stays the baseline so small
+				// shifts can't creep past the threshold unnoticed
+				if got := routes.route.EstimatedArrival; got == nil || !got.Equal(published) {
+					t.Fatalf("route arrival = %v, want %v", got, published)
+				}
+				return
+			}
+
+			if len(changes) != 1 {
+				t.Fatalf("published %d ETA changes, want 1", len(changes))
+			}
+			change := changes[0]
+			if change.OrderID != "order-1" || change.DriverID != "driver-1" {
+				t.Fatalf("change is for order %q driver %q", change.OrderID, change.DriverID)
+			}
+			if !change.EstimatedArrival.Equal(tt.arrival) {
+				t.Fatalf("estimated arrival = %v, want %v", change.EstimatedArrival, tt.arrival)
+			}
+			if (change.PreviousArrival == nil) != (tt.previous == nil) ||
+				(tt.previous != nil && !change.PreviousArrival.Equal(*tt.previous)) {
+				t.Fatalf("previous arrival = %v, want %v", change.PreviousArrival, tt.previous)
+			}
+			if change.RemainingTime != 14 || change.RemainingDistance != 3.2 {
+				t.Fatalf("remaining = %d min %.1f km, want 14 min 3.2 km", change.RemainingTime, change.RemainingDistance)
+			}
+			if got := routes.route.EstimatedArrival; got == nil || !got.Equal(tt.arrival) {
+				t.Fatalf("route arrival = %v, want %v", got, tt.arrival)
+			}
+		})
+	}
+}
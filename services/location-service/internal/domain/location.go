@@ -65,10 +65,15 @@ type DeliveryRoute struct {
 	ActualDuration    *int               `json:"actual_duration,omitempty" bson:"actual_duration,omitempty"` // minutes
 	StartedAt         time.Time          `json:"started_at" bson:"started_at"`
 	CompletedAt       *time.Time         `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	EstimatedArrival  *time.Time         `json:"estimated_arrival,omitempty" bson:"estimated_arrival,omitempty"` // last ETA published for the order
 	CreatedAt         time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt         time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
+// DefaultETAChangeThreshold is how far a recalculated arrival must move
+// before the delivery is updated and the customer told
+const DefaultETAChangeThreshold = 5 * time.Minute
+
 type RouteStatus string
 
 const (
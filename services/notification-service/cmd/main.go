@@ -1,18 +1,27 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
+	"time"
 
 	"glovo-backend/services/notification-service/internal/adapters/client"
 	"glovo-backend/services/notification-service/internal/adapters/db"
 	httpHandler "glovo-backend/services/notification-service/internal/adapters/http"
+	"glovo-backend/services/notification-service/internal/adapters/subscriber"
 	"glovo-backend/services/notification-service/internal/app"
 	"glovo-backend/services/notification-service/internal/domain"
 	"glovo-backend/shared/auth"
+	"glovo-backend/shared/config"
 	"glovo-backend/shared/database"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/health"
+	"glovo-backend/shared/idempotency"
+	"glovo-backend/shared/logging"
+	"glovo-backend/shared/metrics"
 	"glovo-backend/shared/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -41,12 +50,22 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
+// @securityDefinitions.apikey ServiceToken
+// @in header
+// @name X-Service-Token
+// @description Signed service token for internal service-to-service calls.
+
 func main() {
+	logging.Setup("notification-service")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
 	}
 
+	// Fail fast on missing or malformed configuration
+	cfg := config.MustLoad("notification-service", config.Postgres(), config.Auth(), config.EventBus(), config.HTTPPort("NOTIFICATION_SERVICE_PORT", "8009"))
+
 	// Initialize database connection
 	postgresDB := database.ConnectPostgres()
 
@@ -56,6 +75,11 @@ func main() {
 		&domain.NotificationTemplate{},
 		&domain.UserPreference{},
 		&domain.NotificationDevice{},
+		&domain.NotificationSettings{},
+		&domain.BulkJob{},
+		&domain.MerchantWebhook{},
+		&domain.WebhookDelivery{},
+		&idempotency.Record{},
 	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
@@ -65,9 +89,15 @@ func main() {
 	templateRepo := db.NewTemplateRepository(postgresDB)
 	preferenceRepo := db.NewPreferenceRepository(postgresDB)
 	deviceRepo := db.NewDeviceRepository(postgresDB)
-
-	// Initialize external service clients (mock for now)
-	pushService := client.NewMockPushNotificationService()
+	bulkJobRepo := db.NewBulkJobRepository(postgresDB)
+	webhookRepo := db.NewMerchantWebhookRepository(postgresDB)
+	webhookDeliveryRepo := db.NewWebhookDeliveryRepository(postgresDB)
+
+	// Initialize external service clients (mock unless credentials are configured)
+	var pushService domain.PushNotificationService = client.NewMockPushNotificationService()
+	if serverKey := getEnv("FCM_SERVER_KEY", ""); serverKey != "" {
+		pushService = client.NewFirebasePushService(serverKey, getEnv("FCM_ENDPOINT", ""), deviceRepo)
+	}
 	smsService := client.NewMockSMSService()
 	emailService := client.NewMockEmailService()
 
@@ -77,27 +107,75 @@ func main() {
 		templateRepo,
 		preferenceRepo,
 		deviceRepo,
+		bulkJobRepo,
 		pushService,
 		smsService,
 		emailService,
+		domain.RateLimitConfig{
+			Default: domain.RateLimit{
+				PerMinute: getEnvInt("NOTIFICATION_RATE_LIMIT_PER_MINUTE", 10),
+				Burst:     getEnvInt("NOTIFICATION_RATE_LIMIT_BURST", 20),
+			},
+			Overrides: map[domain.NotificationType]domain.RateLimit{
+				domain.TypeOTP:       {PerMinute: 1, Burst: 5},
+				domain.TypePromotion: {PerMinute: 1, Burst: 2},
+			},
+		},
+		getEnv("NOTIFICATION_DEFAULT_LOCALE", "en"),
 	)
 
-	// Setup Gin router
-	router := gin.Default()
+	webhookService := app.NewWebhookService(webhookRepo, webhookDeliveryRepo, client.NewHTTPWebhookSender())
 
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type,Authorization")
+	// Subscribe to delivery, store review and payout events
+	eventBus := events.FromEnv()
+	defer eventBus.Close()
+	middleware.AuditImpersonation(eventBus, "notification-service")
+	if err := subscriber.NewDeliverySubscriber(notificationService).Register(eventBus); err != nil {
+		log.Fatal("Failed to subscribe to delivery events:", err)
+	}
+	if err := subscriber.NewStoreSubscriber(notificationService).Register(eventBus); err != nil {
+		log.Fatal("Failed to subscribe to store events:", err)
+	}
+	if err := subscriber.NewWebhookSubscriber(webhookService).Register(eventBus); err != nil {
+		log.Fatal("Failed to subscribe to merchant webhook events:", err)
+	}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
+	// Dispatch scheduled notifications once they are due
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := notificationService.ProcessScheduledNotifications(); err != nil {
+				log.Printf("Scheduled notification dispatch failed: %v", err)
+			}
+		}
+	}()
+
+	// Retry failed merchant webhook deliveries once their backoff has passed
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := webhookService.RetryDueDeliveries(); err != nil {
+				log.Printf("Webhook retry sweep failed: %v", err)
+			}
 		}
+	}()
 
-		c.Next()
-	})
+	// Setup Gin router
+	router := gin.New()
+	// Client IPs come from X-Forwarded-For only behind TRUSTED_PROXIES
+	if err := router.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	router.Use(gin.Recovery(), middleware.RequestID(), middleware.RequestLogger())
+	router.Use(metrics.Middleware("notification-service"))
+
+	// CORS, restricted to the origins in CORS_ALLOWED_ORIGINS
+	router.Use(middleware.CORS(middleware.CORSConfigFromEnv()))
+
+	// Prometheus metrics
+	router.GET("/metrics", metrics.Handler())
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -107,12 +185,16 @@ func main() {
 		})
 	})
 
+	// Readiness check, verifies database connectivity
+	router.GET("/health/ready", health.Ready("notification-service", map[string]health.Check{"postgres": health.Postgres(postgresDB)}))
+
 	// Initialize HTTP handler
-	handler := httpHandler.NewNotificationHandler(notificationService)
+	handler := httpHandler.NewNotificationHandler(notificationService, idempotency.NewGormStore(postgresDB, "notification-service"))
 
 	// Setup routes
 	v1 := router.Group("/api/v1")
 	handler.SetupRoutes(v1)
+	httpHandler.NewWebhookHandler(webhookService).SetupRoutes(v1)
 
 	// Swagger endpoint
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -120,8 +202,8 @@ func main() {
 	// TODO: Remove the inline route handlers below (kept for reference)
 	// v1_old := router.Group("/api/v1/old")
 	{
-		// Public OTP endpoint (for User Service)
-		v1.POST("/notifications/otp", func(c *gin.Context) {
+		// Internal OTP endpoint (for User Service)
+		v1.POST("/notifications/otp", middleware.ServiceAuth(), func(c *gin.Context) {
 			var req struct {
 				PhoneNumber string `json:"phone_number" binding:"required"`
 				OTPCode     string `json:"otp_code" binding:"required"`
@@ -142,7 +224,7 @@ func main() {
 		})
 
 		// Order notification endpoint (for Order Service)
-		v1.POST("/notifications/order", func(c *gin.Context) {
+		v1.POST("/notifications/order", middleware.ServiceAuth(), func(c *gin.Context) {
 			var req struct {
 				OrderID string `json:"order_id" binding:"required"`
 				UserID  string `json:"user_id" binding:"required"`
@@ -167,23 +249,6 @@ func main() {
 		notifications := v1.Group("/notifications")
 		notifications.Use(middleware.AuthMiddleware())
 		{
-			// Send single notification
-			notifications.POST("/send", func(c *gin.Context) {
-				var req domain.SendNotificationRequest
-				if err := c.ShouldBindJSON(&req); err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-					return
-				}
-
-				notification, err := notificationService.SendNotification(req)
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-					return
-				}
-
-				c.JSON(http.StatusCreated, notification)
-			})
-
 			// Send bulk notifications (admin only)
 			notifications.POST("/bulk", middleware.RequireRole(auth.RoleAdmin), func(c *gin.Context) {
 				var req domain.BulkNotificationRequest
@@ -192,13 +257,13 @@ func main() {
 					return
 				}
 
-				notifications, err := notificationService.SendBulkNotification(req)
+				job, err := notificationService.SendBulkNotification(req)
 				if err != nil {
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 					return
 				}
 
-				c.JSON(http.StatusCreated, notifications)
+				c.JSON(http.StatusAccepted, job)
 			})
 
 			// Send template notification
@@ -257,6 +322,10 @@ func main() {
 
 				err := notificationService.MarkNotificationAsRead(notificationID, userID)
 				if err != nil {
+					if errors.Is(err, domain.ErrNotificationForbidden) {
+						c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+						return
+					}
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 					return
 				}
@@ -319,7 +388,7 @@ func main() {
 				limit, _ := strconv.Atoi(limitStr)
 				offset, _ := strconv.Atoi(offsetStr)
 
-				templates, err := notificationService.ListTemplates(limit, offset)
+				templates, err := notificationService.ListTemplates(domain.NotificationType(c.Query("type")), limit, offset)
 				if err != nil {
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 					return
@@ -343,7 +412,7 @@ func main() {
 			templates.PUT("/:id", func(c *gin.Context) {
 				templateID := c.Param("id")
 
-				var updates map[string]interface{}
+				var updates domain.UpdateTemplateRequest
 				if err := c.ShouldBindJSON(&updates); err != nil {
 					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 					return
@@ -456,7 +525,7 @@ func main() {
 	}
 
 	// Start server
-	port := getEnv("NOTIFICATION_SERVICE_PORT", "8009")
+	port := cfg.String("NOTIFICATION_SERVICE_PORT")
 	log.Printf("Notification Service starting on port %s", port)
 
 	if err := router.Run(":" + port); err != nil {
@@ -470,3 +539,12 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"glovo-backend/services/notification-service/internal/domain"
+)
+
+const (
+	defaultFCMEndpoint = "https://fcm.googleapis.com/fcm/send"
+	// FCM accepts at most 1000 registration tokens per multicast request
+	fcmMaxBatchSize = 1000
+)
+
+// Firebase Cloud Messaging push service using the HTTP API with a server key
+type firebasePushService struct {
+	serverKey  string
+	endpoint   string
+	client     *http.Client
+	deviceRepo domain.DeviceRepository
+}
+
+func NewFirebasePushService(serverKey, endpoint string, deviceRepo domain.DeviceRepository) *firebasePushService {
+	if endpoint == "" {
+		endpoint = defaultFCMEndpoint
+	}
+	return &firebasePushService{
+		serverKey:  serverKey,
+		endpoint:   endpoint,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		deviceRepo: deviceRepo,
+	}
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmMessage struct {
+	RegistrationIDs []string          `json:"registration_ids,omitempty"`
+	To              string            `json:"to,omitempty"`
+	Notification    fcmNotification   `json:"notification"`
+	Data            map[string]string `json:"data,omitempty"`
+	Priority        string            `json:"priority"`
+}
+
+type fcmResponse struct {
+	Success int `json:"success"`
+	Failure int `json:"failure"`
+	Results []struct {
+		MessageID string `json:"message_id"`
+		Error     string `json:"error"`
+	} `json:"results"`
+}
+
+func (f *firebasePushService) SendPushNotification(deviceTokens []string, title, message string, data map[string]string) error {
+	var failed int
+	for start := 0; start < len(deviceTokens); start += fcmMaxBatchSize {
+		end := start + fcmMaxBatchSize
+		if end > len(deviceTokens) {
+			end = len(deviceTokens)
+		}
+		batch := deviceTokens[start:end]
+
+		resp, err := f.send(fcmMessage{
+			RegistrationIDs: batch,
+			Notification:    fcmNotification{Title: title, Body: message},
+			Data:            data,
+			Priority:        "high",
+		})
+		if err != nil {
+			return err
+		}
+
+		// Results are returned in the same order as the registration IDs
+		for i, result := range resp.Results {
+			if result.Error == "" || i >= len(batch) {
+				continue
+			}
+			failed++
+			if isInvalidTokenError(result.Error) {
+				if err := f.deviceRepo.DeactivateDevice(batch[i]); err != nil {
+					log.Printf("Failed to deactivate device token: %v", err)
+				}
+			}
+		}
+	}
+
+	if failed == len(deviceTokens) && failed > 0 {
+		return fmt.Errorf("push notification failed for all %d devices", failed)
+	}
+	return nil
+}
+
+func (f *firebasePushService) SendPushToTopic(topic, title, message string, data map[string]string) error {
+	_, err := f.send(fcmMessage{
+		To:           "/topics/" + topic,
+		Notification: fcmNotification{Title: title, Body: message},
+		Data:         data,
+		Priority:     "high",
+	})
+	return err
+}
+
+func (f *firebasePushService) send(msg fcmMessage) (*fcmResponse, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal FCM message: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, f.endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create FCM request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "key="+f.serverKey)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send FCM request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("FCM returned status %d", resp.StatusCode)
+	}
+
+	var result fcmResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode FCM response: %w", err)
+	}
+	return &result, nil
+}
+
+// isInvalidTokenError reports whether FCM rejected the token itself, meaning
+// the device should no longer receive pushes
+func isInvalidTokenError(code string) bool {
+	switch code {
+	case "NotRegistered", "InvalidRegistration", "MismatchSenderId":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"glovo-backend/services/notification-service/internal/domain"
+)
+
+type fakeDeviceRepo struct {
+	domain.DeviceRepository
+	mu          sync.Mutex
+	deactivated []string
+}
+
+func (r *fakeDeviceRepo) DeactivateDevice(deviceToken string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deactivated = append(r.deactivated, deviceToken)
+	return nil
+}
+
+// fcmStub records the messages it receives and answers each token with the
+// error listed for it, or success
+type fcmStub struct {
+	mu       sync.Mutex
+	auth     []string
+	messages []fcmMessage
+	errors   map[string]string
+}
+
+func (s *fcmStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var msg fcmMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+	s.messages = append(s.messages, msg)
+	s.mu.Unlock()
+
+	var results []map[string]string
+	success, failure := 0, 0
+	for i, token := range msg.RegistrationIDs {
+		if code, ok := s.errors[token]; ok {
+			results = append(results, map[string]string{"error": code})
+			failure++
+			continue
+		}
+		results = append(results, map[string]string{"message_id": fmt.Sprintf("message-%d", i)})
+		success++
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": success, "failure": failure, "results": results})
+}
+
+func TestFirebasePushPayload(t *testing.T) {
+	stub := &fcmStub{}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	push := NewFirebasePushService("server-key", server.URL, &fakeDeviceRepo{})
+	err := push.SendPushNotification([]string{"token-1", "token-2"}, "Order update", "Your order is on its way", map[string]string{"order_id": "order-1"})
+	if err != nil {
+		t.Fatalf("SendPushNotification: %v", err)
+	}
+
+	if len(stub.messages) != 1 {
+		t.Fatalf("FCM received %d requests, want 1", len(stub.messages))
+	}
+	if stub.auth[0] != "key=server-key" {
+		t.Fatalf("Authorization = %q, want %q", stub.auth[0], "key=server-key")
+	}
+	msg := stub.messages[0]
+	if len(msg.RegistrationIDs) != 2 || msg.RegistrationIDs[0] != "token-1" || msg.RegistrationIDs[1] != "token-2" {
+		t.Fatalf("registration_ids = %v", msg.RegistrationIDs)
+	}
+	if msg.Notification.Title != "Order update" || msg.Notification.Body != "Your order is on its way" {
+		t.Fatalf("notification = %+v", msg.Notification)
+	}
+	if msg.Data["order_id"] != "order-1" || msg.Priority != "high" {
+		t.Fatalf("data = %v, priority = %q", msg.Data, msg.Priority)
+	}
+}
+
+func TestFirebasePushDeactivatesInvalidTokens(t *testing.T) {
+	stub := &fcmStub{errors: map[string]string{
+		"stale-token":   "NotRegistered",
+		"garbled-token": "InvalidRegistration",
+		"busy-token":    "Unavailable",
+	}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	devices := &fakeDeviceRepo{}
+	push := NewFirebasePushService("server-key", server.URL, devices)
+	err := push.SendPushNotification([]string{"good-token", "stale-token", "garbled-token", "busy-token"}, "Hi", "Hello", nil)
+	if err != nil {
+		t.Fatalf("SendPushNotification with one delivered token: %v", err)
+	}
+
+	// Transient errors keep the device; rejected tokens are deactivated
+	if len(devices.deactivated) != 2 || devices.deactivated[0] != "stale-token" || devices.deactivated[1] != "garbled-token" {
+		t.Fatalf("deactivated %v, want [stale-token garbled-token]", devices.deactivated)
+	}
+
+	if err := push.SendPushNotification([]string{"stale-token"}, "Hi", "Hello", nil); err == nil {
+		t.Fatalf("expected an error when every token fails")
+	}
+}
+
+func TestFirebasePushBatches(t *testing.T) {
+	stub := &fcmStub{}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	tokens := make([]string, fcmMaxBatchSize+5)
+	for i := range tokens {
+		tokens[i] = fmt.Sprintf("token-%d", i)
+	}
+
+	push := NewFirebasePushService("server-key", server.URL, &fakeDeviceRepo{})
+	if err := push.SendPushNotification(tokens, "Hi", "Hello", nil); err != nil {
+		t.Fatalf("SendPushNotification: %v", err)
+	}
+
+	if len(stub.messages) != 2 {
+		t.Fatalf("FCM received %d requests, want 2", len(stub.messages))
+	}
+	if got := len(stub.messages[0].RegistrationIDs); got != fcmMaxBatchSize {
+		t.Fatalf("first batch has %d tokens, want %d", got, fcmMaxBatchSize)
+	}
+	if got := len(stub.messages[1].RegistrationIDs); got != 5 {
+		t.Fatalf("second batch has %d tokens, want 5", got)
+	}
+}
+
+func TestFirebasePushServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	push := NewFirebasePushService("bad-key", server.URL, &fakeDeviceRepo{})
+	if err := push.SendPushNotification([]string{"token-1"}, "Hi", "Hello", nil); err == nil {
+		t.Fatalf("expected an error for a rejected FCM request")
+	}
+}
@@ -90,26 +90,3 @@ func (t *twilioSMSService) SendBulkSMS(phoneNumbers []string, message string) er
 	}
 	return nil
 }
-
-// Firebase Push Notification Service (Real implementation placeholder)
-type firebasePushService struct {
-	serverKey string
-}
-
-func NewFirebasePushService(serverKey string) *firebasePushService {
-	return &firebasePushService{
-		serverKey: serverKey,
-	}
-}
-
-func (f *firebasePushService) SendPushNotification(deviceTokens []string, title, message string, data map[string]string) error {
-	// In production, this would integrate with Firebase Cloud Messaging
-	log.Printf("Firebase Push to %d devices - Title: %s, Message: %s", len(deviceTokens), title, message)
-	return nil
-}
-
-func (f *firebasePushService) SendPushToTopic(topic, title, message string, data map[string]string) error {
-	// In production, this would send to a Firebase topic
-	log.Printf("Firebase Push to topic %s - Title: %s, Message: %s", topic, title, message)
-	return nil
-}
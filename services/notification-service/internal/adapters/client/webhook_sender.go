@@ -0,0 +1,80 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"glovo-backend/services/notification-service/internal/domain"
+)
+
+type httpWebhookSender struct {
+	client *http.Client
+}
+
+// NewHTTPWebhookSender returns a sender that only connects to public
+// addresses. The check runs on the resolved address at dial time, so a
+// hostname can't point back into the network, and every redirect is held to
+// the same rules as the registered URL.
+func NewHTTPWebhookSender() domain.WebhookSender {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: refuseInternalAddress,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &httpWebhookSender{client: &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("stopped after 5 redirects")
+			}
+			return domain.ValidateWebhookURL(req.URL.String())
+		},
+	}}
+}
+
+func refuseInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !domain.IsPublicWebhookIP(ip) {
+		return fmt.Errorf("%w: %s is not a public address", domain.ErrUnsafeWebhookURL, host)
+	}
+	return nil
+}
+
+func (s *httpWebhookSender) Send(url string, headers map[string]string, body []byte) (int, error) {
+	if err := domain.ValidateWebhookURL(url); err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "glovo-webhooks/1.0")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"glovo-backend/services/notification-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type bulkJobRepository struct {
+	db *gorm.DB
+}
+
+func NewBulkJobRepository(db *gorm.DB) domain.BulkJobRepository {
+	return &bulkJobRepository{db: db}
+}
+
+func (r *bulkJobRepository) Create(job *domain.BulkJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r *bulkJobRepository) GetByID(id string) (*domain.BulkJob, error) {
+	var job domain.BulkJob
+	err := r.db.Where("id = ?", id).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *bulkJobRepository) Update(job *domain.BulkJob) error {
+	return r.db.Save(job).Error
+}
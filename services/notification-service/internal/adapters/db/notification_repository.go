@@ -1,6 +1,8 @@
 package db
 
 import (
+	"time"
+
 	"glovo-backend/services/notification-service/internal/domain"
 
 	"gorm.io/gorm"
@@ -27,19 +29,44 @@ func (r *notificationRepository) GetByID(id string) (*domain.Notification, error
 	return &notification, nil
 }
 
-func (r *notificationRepository) GetByUserID(userID string, limit, offset int) ([]domain.Notification, error) {
+func (r *notificationRepository) GetByUserID(userID string, limit, offset int) ([]domain.Notification, int64, error) {
+	query := r.db.Model(&domain.Notification{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
 	var notifications []domain.Notification
-	err := r.db.Where("user_id = ?", userID).
-		Order("created_at DESC").
+	err := query.Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
 		Find(&notifications).Error
-	return notifications, err
+	return notifications, total, err
+}
+
+func (r *notificationRepository) GetHistory(req domain.NotificationHistoryRequest) ([]domain.Notification, int64, error) {
+	query := r.db.Model(&domain.Notification{}).Where("user_id = ?", req.UserID)
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []domain.Notification
+	err := query.Order("created_at DESC").
+		Limit(req.Limit).
+		Offset(req.Offset).
+		Find(&notifications).Error
+	return notifications, total, err
 }
 
 func (r *notificationRepository) GetUnreadByUserID(userID string) ([]domain.Notification, error) {
 	var notifications []domain.Notification
-	err := r.db.Where("user_id = ? AND status != ?", userID, domain.StatusRead).
+	err := r.db.Where("user_id = ? AND status IN ?", userID, domain.UnreadStatuses).
 		Order("created_at DESC").
 		Find(&notifications).Error
 	return notifications, err
@@ -63,22 +90,70 @@ func (r *notificationRepository) Delete(id string) error {
 	return r.db.Where("id = ?", id).Delete(&domain.Notification{}).Error
 }
 
-func (r *notificationRepository) MarkAsRead(id string) error {
-	return r.db.Model(&domain.Notification{}).
-		Where("id = ?", id).
-		Update("status", domain.StatusRead).Error
+func (r *notificationRepository) MarkAsRead(id string) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&domain.Notification{}).
+		Where("id = ? AND status IN ?", id, domain.UnreadStatuses).
+		Updates(map[string]interface{}{
+			"status":     domain.StatusRead,
+			"read_at":    now,
+			"updated_at": now,
+		})
+	return result.RowsAffected == 1, result.Error
 }
 
+// MarkAllAsRead reads the user's sent and delivered notifications; pending
+// ones, such as scheduled or deferred sends, are left to go out
 func (r *notificationRepository) MarkAllAsRead(userID string) error {
 	return r.db.Model(&domain.Notification{}).
-		Where("user_id = ? AND status != ?", userID, domain.StatusRead).
-		Update("status", domain.StatusRead).Error
+		Where("user_id = ? AND status IN ?", userID, domain.UnreadStatuses).
+		Updates(map[string]interface{}{
+			"status":     domain.StatusRead,
+			"read_at":    time.Now(),
+			"updated_at": time.Now(),
+		}).Error
 }
 
 func (r *notificationRepository) GetUnreadCount(userID string) (int, error) {
 	var count int64
 	err := r.db.Model(&domain.Notification{}).
-		Where("user_id = ? AND status != ?", userID, domain.StatusRead).
+		Where("user_id = ? AND status IN ?", userID, domain.UnreadStatuses).
 		Count(&count).Error
 	return int(count), err
 }
+
+func (r *notificationRepository) GetDueScheduled(before time.Time, limit int) ([]domain.Notification, error) {
+	var notifications []domain.Notification
+	err := r.db.Where("status = ? AND scheduled_for IS NOT NULL AND scheduled_for <= ?", domain.StatusPending, before).
+		Order("scheduled_for ASC").
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepository) ClaimPending(id string) (bool, error) {
+	result := r.db.Model(&domain.Notification{}).
+		Where("id = ? AND status = ?", id, domain.StatusPending).
+		Updates(map[string]interface{}{
+			"status":     domain.StatusProcessing,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *notificationRepository) CountByChannelAndStatus(start, end time.Time) ([]domain.ChannelStatusCount, error) {
+	var counts []domain.ChannelStatusCount
+	query := r.db.Model(&domain.Notification{}).
+		Select("channel, status, COUNT(*) AS count")
+	if !start.IsZero() {
+		query = query.Where("created_at >= ?", start)
+	}
+	if !end.IsZero() {
+		query = query.Where("created_at < ?", end)
+	}
+	err := query.Group("channel, status").Scan(&counts).Error
+	return counts, err
+}
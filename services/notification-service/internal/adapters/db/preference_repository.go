@@ -35,6 +35,15 @@ func (r *preferenceRepository) GetByUserAndType(userID string, notificationType
 	return &preference, nil
 }
 
+func (r *preferenceRepository) GetByUserTypeAndChannel(userID string, notificationType domain.NotificationType, channel domain.NotificationChannel) (*domain.UserPreference, error) {
+	var preference domain.UserPreference
+	err := r.db.Where("user_id = ? AND type = ? AND channel = ?", userID, notificationType, channel).First(&preference).Error
+	if err != nil {
+		return nil, err
+	}
+	return &preference, nil
+}
+
 func (r *preferenceRepository) Update(preference *domain.UserPreference) error {
 	return r.db.Save(preference).Error
 }
@@ -55,3 +64,16 @@ func (r *preferenceRepository) UpsertPreference(userID string, notificationType
 		Assign(preference).
 		FirstOrCreate(preference).Error
 }
+
+func (r *preferenceRepository) GetSettings(userID string) (*domain.NotificationSettings, error) {
+	var settings domain.NotificationSettings
+	err := r.db.Where("user_id = ?", userID).First(&settings).Error
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *preferenceRepository) SaveSettings(settings *domain.NotificationSettings) error {
+	return r.db.Save(settings).Error
+}
@@ -52,9 +52,13 @@ func (r *templateRepository) Delete(id string) error {
 	return r.db.Where("id = ?", id).Delete(&domain.NotificationTemplate{}).Error
 }
 
-func (r *templateRepository) List(limit, offset int) ([]domain.NotificationTemplate, error) {
+func (r *templateRepository) List(notificationType domain.NotificationType, limit, offset int) ([]domain.NotificationTemplate, error) {
 	var templates []domain.NotificationTemplate
-	err := r.db.Order("created_at DESC").
+	query := r.db.Model(&domain.NotificationTemplate{})
+	if notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+	err := query.Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
 		Find(&templates).Error
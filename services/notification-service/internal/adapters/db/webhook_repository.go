@@ -0,0 +1,102 @@
+package db
+
+import (
+	"time"
+
+	"glovo-backend/services/notification-service/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type merchantWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewMerchantWebhookRepository(db *gorm.DB) domain.MerchantWebhookRepository {
+	return &merchantWebhookRepository{db: db}
+}
+
+func (r *merchantWebhookRepository) Create(webhook *domain.MerchantWebhook) error {
+	return r.db.Create(webhook).Error
+}
+
+func (r *merchantWebhookRepository) GetByID(id string) (*domain.MerchantWebhook, error) {
+	var webhook domain.MerchantWebhook
+	err := r.db.Where("id = ?", id).First(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *merchantWebhookRepository) GetByMerchantID(merchantID string) ([]domain.MerchantWebhook, error) {
+	var webhooks []domain.MerchantWebhook
+	err := r.db.Where("merchant_id = ?", merchantID).
+		Order("created_at ASC").
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *merchantWebhookRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&domain.MerchantWebhook{}).Error
+}
+
+type webhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) domain.WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{db: db}
+}
+
+func (r *webhookDeliveryRepository) Create(delivery *domain.WebhookDelivery) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(delivery)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *webhookDeliveryRepository) Update(delivery *domain.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
+
+func (r *webhookDeliveryRepository) GetByWebhookID(webhookID string, limit, offset int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	err := r.db.Where("webhook_id = ?", webhookID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookDeliveryRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Rows another sweep is claiming are skipped rather than waited on
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", domain.WebhookDeliveryPending, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		leased := now.Add(lease)
+		ids := make([]string, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+			deliveries[i].NextAttemptAt = &leased
+		}
+		return tx.Model(&domain.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", leased).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
@@ -1,10 +1,15 @@
 package http
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"glovo-backend/services/notification-service/internal/domain"
 	"glovo-backend/shared/auth"
+	"glovo-backend/shared/idempotency"
 	"glovo-backend/shared/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -12,20 +17,28 @@ import (
 
 type NotificationHandler struct {
 	notificationService domain.NotificationService
+	idempotencyStore    idempotency.Store
 }
 
-func NewNotificationHandler(notificationService domain.NotificationService) *NotificationHandler {
+func NewNotificationHandler(notificationService domain.NotificationService, idempotencyStore idempotency.Store) *NotificationHandler {
 	return &NotificationHandler{
 		notificationService: notificationService,
+		idempotencyStore:    idempotencyStore,
 	}
 }
 
 func (h *NotificationHandler) SetupRoutes(router *gin.RouterGroup) {
-	// Public notification endpoints (for internal service calls)
-	public := router.Group("/notifications")
+	// Callers may retry these with the same Idempotency-Key
+	idempotent := idempotency.Middleware(h.idempotencyStore, idempotency.DefaultTTL)
+
+	// Internal notification endpoints (for internal service calls)
+	internal := router.Group("/notifications")
 	{
-		public.POST("/send", h.sendNotification)
-		public.POST("/send-bulk", h.sendBulkNotification)
+		internal.POST("/send", middleware.ServiceAuth(), idempotent, h.sendNotification)
+		internal.POST("/send-bulk", middleware.ServiceAuth(), idempotent, h.sendBulkNotification)
+		internal.GET("/bulk-jobs/:id", middleware.ServiceAuth(), h.getBulkJob)
+		internal.DELETE("/scheduled/:id", middleware.ServiceAuth(), h.cancelScheduledNotification)
+		internal.GET("/stream", middleware.AuthMiddleware(), h.streamUnreadCount)
 	}
 
 	// User notification preferences and history
@@ -33,19 +46,21 @@ func (h *NotificationHandler) SetupRoutes(router *gin.RouterGroup) {
 	user.Use(middleware.AuthMiddleware())
 	{
 		// Notification history
-		// user.GET("/", h.getNotificationHistory) // TODO: Add this later
-		// user.PUT("/:id/read", h.markAsRead) // TODO: Add this later
-		// user.PUT("/read-all", h.markAllAsRead)  // TODO: Add this later
+		user.GET("/", h.getNotificationHistory)
+		user.PUT("/:id/read", h.markAsRead)
+		user.PUT("/read-all", h.markAllAsRead)
 
 		// Preferences
-		// user.GET("/preferences", h.getPreferences)  // TODO: Add this later
-		// user.PUT("/preferences", h.updatePreferences)  // TODO: Add this later
+		user.GET("/preferences", h.getPreferences)
+		user.PUT("/preferences", h.updatePreferences)
+		user.GET("/settings", h.getSettings)
+		user.PUT("/settings", h.updateSettings)
 
 		// Device management
 		user.POST("/devices", h.registerDevice)
 		user.GET("/devices", h.getDevices)
-		// user.PUT("/devices/:id", h.updateDevice)
-		// user.DELETE("/devices/:id", h.deleteDevice)  // TODO: Add this later
+		user.PUT("/devices/:id", h.updateDevice)
+		user.DELETE("/devices/:id", h.deleteDevice)
 	}
 
 	// Admin notification management
@@ -54,18 +69,18 @@ func (h *NotificationHandler) SetupRoutes(router *gin.RouterGroup) {
 	admin.Use(middleware.RequireRole(auth.RoleAdmin))
 	{
 		// Templates
-		// admin.POST("/templates", h.createTemplate)  // TODO: Add this later
-		// admin.GET("/templates", h.getTemplates)  // TODO: Add this later
+		admin.POST("/templates", h.createTemplate)
+		admin.GET("/templates", h.getTemplates)
 		admin.GET("/templates/:id", h.getTemplate)
-		// admin.PUT("/templates/:id", h.updateTemplate)  // TODO: Add this later
+		admin.PUT("/templates/:id", h.updateTemplate)
 		admin.DELETE("/templates/:id", h.deleteTemplate)
 
 		// Broadcast notifications
-		// admin.POST("/broadcast", h.broadcastNotification)
+		admin.POST("/broadcast", h.broadcastNotification)
 
 		// Analytics
-		// admin.GET("/stats", h.getNotificationStats)  // TODO: Add this later
-		// admin.GET("/delivery-stats", h.getDeliveryStats)  // TODO: Add this later
+		admin.GET("/stats", h.getNotificationStats)
+		admin.GET("/delivery-stats", h.getDeliveryStats)
 
 		// All notifications
 		// admin.GET("/", h.getAllNotifications)  // TODO: Add this later
@@ -78,9 +93,11 @@ func (h *NotificationHandler) SetupRoutes(router *gin.RouterGroup) {
 // @Tags notifications
 // @Accept json
 // @Produce json
+// @Security ServiceToken
 // @Param request body domain.SendNotificationRequest true "Notification data"
 // @Success 200 {object} domain.Notification
 // @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/notifications/send [post]
 func (h *NotificationHandler) sendNotification(c *gin.Context) {
@@ -92,6 +109,10 @@ func (h *NotificationHandler) sendNotification(c *gin.Context) {
 
 	notification, err := h.notificationService.SendNotification(req)
 	if err != nil {
+		if errors.Is(err, domain.ErrRateLimited) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -100,12 +121,13 @@ func (h *NotificationHandler) sendNotification(c *gin.Context) {
 }
 
 // @Summary Send bulk notification
-// @Description Send notifications to multiple users
+// @Description Start sending notifications to multiple users in batches; poll the returned job for progress
 // @Tags notifications
 // @Accept json
 // @Produce json
+// @Security ServiceToken
 // @Param request body domain.BulkNotificationRequest true "Bulk notification data"
-// @Success 200 {object} map[string]interface{}
+// @Success 202 {object} domain.BulkJob
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/notifications/send-bulk [post]
@@ -116,13 +138,96 @@ func (h *NotificationHandler) sendBulkNotification(c *gin.Context) {
 		return
 	}
 
-	result, err := h.notificationService.SendBulkNotification(req)
+	job, err := h.notificationService.SendBulkNotification(req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// @Summary Get bulk notification job
+// @Description Get the progress of a bulk send: total, sent and failed recipients
+// @Tags notifications
+// @Produce json
+// @Security ServiceToken
+// @Param id path string true "Bulk job ID"
+// @Success 200 {object} domain.BulkJob
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/notifications/bulk-jobs/{id} [get]
+func (h *NotificationHandler) getBulkJob(c *gin.Context) {
+	job, err := h.notificationService.GetBulkJob(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, domain.ErrBulkJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// @Summary Cancel scheduled notification
+// @Description Cancel a scheduled notification that has not been sent yet
+// @Tags notifications
+// @Produce json
+// @Security ServiceToken
+// @Param id path string true "Notification ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/notifications/scheduled/{id} [delete]
+func (h *NotificationHandler) cancelScheduledNotification(c *gin.Context) {
+	notificationID := c.Param("id")
+
+	if err := h.notificationService.CancelScheduledNotification(notificationID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Scheduled notification cancelled"})
+}
+
+// @Summary Stream unread count
+// @Description Server-Sent Events stream pushing the user's unread notification count whenever it changes
+// @Tags notifications
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Success 200 {object} map[string]int
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/notifications/stream [get]
+func (h *NotificationHandler) streamUnreadCount(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	// Subscribe before reading the initial count so no update is missed
+	updates, unsubscribe := h.notificationService.SubscribeUnreadCount(userID.(string))
+	defer unsubscribe()
+
+	count, err := h.notificationService.GetUnreadCount(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.SSEvent("unread_count", gin.H{"unread_count": count})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case count, ok := <-updates:
+			if !ok {
+				return false
+			}
+			c.SSEvent("unread_count", gin.H{"unread_count": count})
+			return true
+		}
+	})
 }
 
 // User endpoints
@@ -135,33 +240,33 @@ func (h *NotificationHandler) sendBulkNotification(c *gin.Context) {
 // @Param limit query int false "Limit results"
 // @Param offset query int false "Offset for pagination"
 // @Param type query string false "Notification type filter"
-// @Success 200 {array} domain.Notification
+// @Success 200 {object} domain.NotificationListResponse
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/user/notifications [get]
-// func (h *NotificationHandler) getNotificationHistory(c *gin.Context) {
-// 	userID, _ := c.Get("user_id")
-// 	limitStr := c.DefaultQuery("limit", "50")
-// 	offsetStr := c.DefaultQuery("offset", "0")
-// 	notificationType := c.Query("type")
-
-// 	limit, _ := strconv.Atoi(limitStr)
-// 	offset, _ := strconv.Atoi(offsetStr)
-
-// 	req := domain.NotificationHistoryRequest{
-// 		UserID: userID.(string),
-// 		Type:   notificationType,
-// 		Limit:  limit,
-// 		Offset: offset,
-// 	}
+func (h *NotificationHandler) getNotificationHistory(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	limitStr := c.DefaultQuery("limit", "50")
+	offsetStr := c.DefaultQuery("offset", "0")
+	notificationType := c.Query("type")
+
+	limit, _ := strconv.Atoi(limitStr)
+	offset, _ := strconv.Atoi(offsetStr)
+
+	req := domain.NotificationHistoryRequest{
+		UserID: userID.(string),
+		Type:   domain.NotificationType(notificationType),
+		Limit:  limit,
+		Offset: offset,
+	}
 
-// 	notifications, err := h.notificationService.GetNotificationHistory(req)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	notifications, err := h.notificationService.GetNotificationHistory(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-// 	c.JSON(http.StatusOK, notifications)
-// }
+	c.JSON(http.StatusOK, notifications)
+}
 
 // @Summary Mark notification as read
 // @Description Mark a specific notification as read
@@ -170,21 +275,30 @@ func (h *NotificationHandler) sendBulkNotification(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "Notification ID"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /api/v1/user/notifications/{id}/read [put]
-// func (h *NotificationHandler) markAsRead(c *gin.Context) {
-// 	notificationID := c.Param("id")
-// 	userID, _ := c.Get("user_id")
+func (h *NotificationHandler) markAsRead(c *gin.Context) {
+	notificationID := c.Param("id")
+	userID, _ := c.Get("user_id")
 
-// 	err := h.notificationService.MarkAsRead(notificationID, userID.(string))
-// 	if err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	err := h.notificationService.MarkNotificationAsRead(notificationID, userID.(string))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotificationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrNotificationNotDelivered) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
 
-// 	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
-// }
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
 
 // @Summary Mark all notifications as read
 // @Description Mark all notifications as read for the user
@@ -194,67 +308,118 @@ func (h *NotificationHandler) sendBulkNotification(c *gin.Context) {
 // @Success 200 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/user/notifications/read-all [put]
-// func (h *NotificationHandler) markAllAsRead(c *gin.Context) {
-// 	userID, _ := c.Get("user_id")
+func (h *NotificationHandler) markAllAsRead(c *gin.Context) {
+	userID, _ := c.Get("user_id")
 
-// 	err := h.notificationService.MarkAllAsRead(userID.(string))
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	err := h.notificationService.MarkAllNotificationsAsRead(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-// 	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read"})
-// }
+	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read"})
+}
 
 // @Summary Get notification preferences
-// @Description Get user's notification preferences
+// @Description Get the user's opt-in state for every notification type and channel; unset preferences default to enabled
 // @Tags user
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} domain.NotificationPreference
+// @Success 200 {array} domain.UserPreference
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/user/notifications/preferences [get]
-// func (h *NotificationHandler) getPreferences(c *gin.Context) {
-// 	userID, _ := c.Get("user_id")
+func (h *NotificationHandler) getPreferences(c *gin.Context) {
+	userID, _ := c.Get("user_id")
 
-// 	preferences, err := h.notificationService.GetPreferences(userID.(string))
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	preferences, err := h.notificationService.GetUserPreferences(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-// 	c.JSON(http.StatusOK, preferences)
-// }
+	c.JSON(http.StatusOK, preferences)
+}
 
-// @Summary Update notification preferences
-// @Description Update user's notification preferences
+// @Summary Update notification preference
+// @Description Enable or disable one notification type on one channel
 // @Tags user
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body domain.UpdatePreferencesRequest true "Preferences data"
-// @Success 200 {object} domain.NotificationPreference
+// @Param request body domain.UpdatePreferenceRequest true "Preference data"
+// @Success 200 {object} domain.UserPreference
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/user/notifications/preferences [put]
-// func (h *NotificationHandler) updatePreferences(c *gin.Context) {
-// 	var req domain.UpdatePreferencesRequest
-// 	if err := c.ShouldBindJSON(&req); err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-// 		return
-// 	}
+func (h *NotificationHandler) updatePreferences(c *gin.Context) {
+	var req domain.UpdatePreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-// 	userID, _ := c.Get("user_id")
-// 	req.UserID = userID.(string)
+	userID, _ := c.Get("user_id")
 
-// 	preferences, err := h.notificationService.UpdatePreferences(req)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	preference, err := h.notificationService.UpdateUserPreference(userID.(string), req)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPreference) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-// 	c.JSON(http.StatusOK, preferences)
-// }
+	c.JSON(http.StatusOK, preference)
+}
+
+// @Summary Get notification settings
+// @Description Get the user's timezone and quiet-hours settings
+// @Tags user
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.NotificationSettings
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/user/notifications/settings [get]
+func (h *NotificationHandler) getSettings(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	settings, err := h.notificationService.GetNotificationSettings(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// @Summary Update notification settings
+// @Description Update the user's timezone and quiet-hours settings
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.UpdateNotificationSettingsRequest true "Settings data"
+// @Success 200 {object} domain.NotificationSettings
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/user/notifications/settings [put]
+func (h *NotificationHandler) updateSettings(c *gin.Context) {
+	var req domain.UpdateNotificationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	settings, err := h.notificationService.UpdateNotificationSettings(userID.(string), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
 
 // @Summary Register device
 // @Description Register a device for push notifications
@@ -307,35 +472,38 @@ func (h *NotificationHandler) getDevices(c *gin.Context) {
 }
 
 // @Summary Update device
-// @Description Update device information
+// @Description Refresh a device's push token, platform or app version
 // @Tags user
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Device ID"
 // @Param request body domain.UpdateDeviceRequest true "Device update data"
-// @Success 200 {object} domain.Device
+// @Success 200 {object} domain.NotificationDevice
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/user/notifications/devices/{id} [put]
-// func (h *NotificationHandler) updateDevice(c *gin.Context) {
-// 	deviceID := c.Param("id")
-// 	userID, _ := c.Get("user_id")
+func (h *NotificationHandler) updateDevice(c *gin.Context) {
+	deviceID := c.Param("id")
+	userID, _ := c.Get("user_id")
 
-// 	var req domain.UpdateDeviceRequest
-// 	if err := c.ShouldBindJSON(&req); err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	var req domain.UpdateDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-// 	device, err := h.notificationService.UpdateDevice(deviceID, userID.(string), req)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	device, err := h.notificationService.UpdateDevice(userID.(string), deviceID, req)
+	if err != nil {
+		deviceError(c, err)
+		return
+	}
 
-// 	c.JSON(http.StatusOK, device)
-// }
+	c.JSON(http.StatusOK, device)
+}
 
 // @Summary Delete device
 // @Description Delete a registered device
@@ -344,20 +512,37 @@ func (h *NotificationHandler) getDevices(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "Device ID"
 // @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/user/notifications/devices/{id} [delete]
-// func (h *NotificationHandler) deleteDevice(c *gin.Context) {
-// 	deviceID := c.Param("id")
-// 	userID, _ := c.Get("user_id")
+func (h *NotificationHandler) deleteDevice(c *gin.Context) {
+	deviceID := c.Param("id")
+	userID, _ := c.Get("user_id")
 
-// 	err := h.notificationService.DeleteDevice(deviceID, userID.(string))
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	if err := h.notificationService.DeleteDevice(userID.(string), deviceID); err != nil {
+		deviceError(c, err)
+		return
+	}
 
-// 	c.JSON(http.StatusOK, gin.H{"message": "Device deleted successfully"})
-// }
+	c.JSON(http.StatusOK, gin.H{"message": "Device deleted successfully"})
+}
+
+// deviceError maps device ownership and validation failures to a status
+func deviceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidDevice):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrDeviceForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrDeviceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrDeviceTokenInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
 
 // Admin endpoints
 
@@ -370,47 +555,60 @@ func (h *NotificationHandler) getDevices(c *gin.Context) {
 // @Param request body domain.CreateTemplateRequest true "Template data"
 // @Success 201 {object} domain.NotificationTemplate
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/admin/notifications/templates [post]
-// func (h *NotificationHandler) createTemplate(c *gin.Context) {
-// 	var req domain.CreateTemplateRequest
-// 	if err := c.ShouldBindJSON(&req); err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-// 		return
-// 	}
+func (h *NotificationHandler) createTemplate(c *gin.Context) {
+	var req domain.CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-// 	adminID, _ := c.Get("user_id")
-// 	req.CreatedBy = adminID.(string)
+	template := &domain.NotificationTemplate{
+		Name:         req.Name,
+		Type:         req.Type,
+		Channel:      req.Channel,
+		Title:        req.Title,
+		Message:      req.Message,
+		Variables:    req.Variables,
+		Translations: req.Translations,
+		IsActive:     req.IsActive == nil || *req.IsActive,
+	}
 
-// 	template, err := h.notificationService.CreateTemplate(req)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	created, err := h.notificationService.CreateTemplate(template)
+	if err != nil {
+		templateError(c, err)
+		return
+	}
 
-// 	c.JSON(http.StatusCreated, template)
-// }
+	c.JSON(http.StatusCreated, created)
+}
 
 // @Summary Get notification templates
-// @Description Get all notification templates (admin only)
+// @Description List notification templates, optionally of one type (admin only)
 // @Tags admin
 // @Produce json
 // @Security BearerAuth
 // @Param type query string false "Template type filter"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
 // @Success 200 {array} domain.NotificationTemplate
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/admin/notifications/templates [get]
-// func (h *NotificationHandler) getTemplates(c *gin.Context) {
-// 	templateType := c.Query("type")
+func (h *NotificationHandler) getTemplates(c *gin.Context) {
+	templateType := domain.NotificationType(c.Query("type"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-// 	templates, err := h.notificationService.GetTemplates(templateType)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	templates, err := h.notificationService.ListTemplates(templateType, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-// 	c.JSON(http.StatusOK, templates)
-// }
+	c.JSON(http.StatusOK, templates)
+}
 
 // @Summary Get notification template
 // @Description Get a specific notification template (admin only)
@@ -435,7 +633,7 @@ func (h *NotificationHandler) getTemplate(c *gin.Context) {
 }
 
 // @Summary Update notification template
-// @Description Update a notification template (admin only)
+// @Description Update a notification template; omitted fields are left unchanged (admin only)
 // @Tags admin
 // @Accept json
 // @Produce json
@@ -444,28 +642,27 @@ func (h *NotificationHandler) getTemplate(c *gin.Context) {
 // @Param request body domain.UpdateTemplateRequest true "Template update data"
 // @Success 200 {object} domain.NotificationTemplate
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/admin/notifications/templates/{id} [put]
-// func (h *NotificationHandler) updateTemplate(c *gin.Context) {
-// 	templateID := c.Param("id")
-
-// 	var req domain.UpdateTemplateRequest
-// 	if err := c.ShouldBindJSON(&req); err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-// 		return
-// 	}
+func (h *NotificationHandler) updateTemplate(c *gin.Context) {
+	templateID := c.Param("id")
 
-// 	adminID, _ := c.Get("user_id")
-// 	req.UpdatedBy = adminID.(string)
+	var req domain.UpdateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-// 	template, err := h.notificationService.UpdateTemplate(templateID, req)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	template, err := h.notificationService.UpdateTemplate(templateID, req)
+	if err != nil {
+		templateError(c, err)
+		return
+	}
 
-// 	c.JSON(http.StatusOK, template)
-// }
+	c.JSON(http.StatusOK, template)
+}
 
 // @Summary Delete notification template
 // @Description Delete a notification template (admin only)
@@ -489,80 +686,104 @@ func (h *NotificationHandler) deleteTemplate(c *gin.Context) {
 }
 
 // @Summary Broadcast notification
-// @Description Send a broadcast notification to multiple users (admin only)
+// @Description Send a template or inline notification to multiple users (admin only)
 // @Tags admin
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body domain.BroadcastRequest true "Broadcast data"
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} domain.BroadcastResult
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/admin/notifications/broadcast [post]
-// func (h *NotificationHandler) broadcastNotification(c *gin.Context) {
-// 	var req domain.BroadcastRequest
-// 	if err := c.ShouldBindJSON(&req); err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-// 		return
-// 	}
+func (h *NotificationHandler) broadcastNotification(c *gin.Context) {
+	var req domain.BroadcastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-// 	adminID, _ := c.Get("user_id")
-// 	req.SentBy = adminID.(string)
+	result, err := h.notificationService.BroadcastNotification(c.GetString("user_id"), req)
+	if err != nil {
+		templateError(c, err)
+		return
+	}
 
-// 	result, err := h.notificationService.BroadcastNotification(req)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	c.JSON(http.StatusOK, result)
+}
 
-// 	c.JSON(http.StatusOK, result)
-// }
+// templateError maps template validation and lookup failures to a status
+func templateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidTemplate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrTemplateNameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
 
 // @Summary Get notification statistics
-// @Description Get notification delivery statistics (admin only)
+// @Description Get overall notification counts by channel and status (admin only)
 // @Tags admin
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} domain.NotificationStats
+// @Success 200 {object} domain.DeliveryStats
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/admin/notifications/stats [get]
-// func (h *NotificationHandler) getNotificationStats(c *gin.Context) {
-// 	stats, err := h.notificationService.GetNotificationStats()
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+func (h *NotificationHandler) getNotificationStats(c *gin.Context) {
+	stats, err := h.notificationService.GetDeliveryStats(time.Time{}, time.Time{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-// 	c.JSON(http.StatusOK, stats)
-// }
+	c.JSON(http.StatusOK, stats)
+}
 
 // @Summary Get delivery statistics
-// @Description Get notification delivery success/failure statistics (admin only)
+// @Description Get notification counts by channel and status within a date range (admin only)
 // @Tags admin
 // @Produce json
 // @Security BearerAuth
 // @Param start_date query string false "Start date filter (YYYY-MM-DD)"
-// @Param end_date query string false "End date filter (YYYY-MM-DD)"
+// @Param end_date query string false "End date filter, inclusive (YYYY-MM-DD)"
 // @Success 200 {object} domain.DeliveryStats
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/admin/notifications/delivery-stats [get]
-// func (h *NotificationHandler) getDeliveryStats(c *gin.Context) {
-// 	startDate := c.Query("start_date")
-// 	endDate := c.Query("end_date")
-
-// 	req := domain.DeliveryStatsRequest{
-// 		StartDate: startDate,
-// 		EndDate:   endDate,
-// 	}
+func (h *NotificationHandler) getDeliveryStats(c *gin.Context) {
+	var start, end time.Time
+	var err error
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		start, err = time.Parse("2006-01-02", startDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format, use YYYY-MM-DD"})
+			return
+		}
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		end, err = time.Parse("2006-01-02", endDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format, use YYYY-MM-DD"})
+			return
+		}
+		end = end.AddDate(0, 0, 1)
+	}
 
-// 	stats, err := h.notificationService.GetDeliveryStats(req)
-// 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-// 		return
-// 	}
+	stats, err := h.notificationService.GetDeliveryStats(start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-// 	c.JSON(http.StatusOK, stats)
-// }
+	c.JSON(http.StatusOK, stats)
+}
 
 // @Summary Get all notifications
 // @Description Get all notifications in the system (admin only)
@@ -0,0 +1,657 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"glovo-backend/services/notification-service/internal/domain"
+	"glovo-backend/shared/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeNotificationService struct {
+	domain.NotificationService
+	sendErr error
+	sent    []domain.SendNotificationRequest
+
+	history []domain.NotificationHistoryRequest
+	readErr error
+	read    []string // notificationID:userID
+	readAll []string
+
+	statsRanges [][2]time.Time
+
+	unread chan int
+
+	templateErr error
+	created     []domain.NotificationTemplate
+	listedTypes []domain.NotificationType
+	updated     map[string]domain.UpdateTemplateRequest
+	broadcastBy []string
+	broadcasts  []domain.BroadcastRequest
+
+	preferenceErr error
+	preferences   map[string][]domain.UpdatePreferenceRequest
+
+	deviceErr      error
+	deviceUpdates  []domain.UpdateDeviceRequest
+	deletedDevices []string // userID:deviceID
+}
+
+func (s *fakeNotificationService) SendNotification(req domain.SendNotificationRequest) (*domain.Notification, error) {
+	if s.sendErr != nil {
+		return nil, s.sendErr
+	}
+	s.sent = append(s.sent, req)
+	return &domain.Notification{ID: "notification-1", UserID: req.UserID}, nil
+}
+
+func TestSendNotificationRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "user-secret")
+	t.Setenv("SERVICE_TOKEN_SECRET", "service-secret")
+	serviceToken, err := auth.GenerateServiceToken("order-service")
+	if err != nil {
+		t.Fatalf("GenerateServiceToken: %v", err)
+	}
+	userToken, err := auth.GenerateToken("user-1", auth.RoleCustomer)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		serviceToken string
+		userToken    string
+		sendErr      error
+		wantStatus   int
+	}{
+		{name: "service call", serviceToken: serviceToken, wantStatus: http.StatusOK},
+		{name: "rate limited", serviceToken: serviceToken, sendErr: domain.ErrRateLimited, wantStatus: http.StatusTooManyRequests},
+		{name: "no service token", wantStatus: http.StatusUnauthorized},
+		{name: "user token only", userToken: userToken, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeNotificationService{sendErr: tt.sendErr}
+			router := gin.New()
+			NewNotificationHandler(service, nil).SetupRoutes(router.Group("/api/v1"))
+
+			body := `{"user_id":"user-1","type":"order_update","channel":"push","title":"On its way","message":"Your order is on its way"}`
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/notifications/send", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.serviceToken != "" {
+				req.Header.Set(auth.HeaderServiceToken, tt.serviceToken)
+			}
+			if tt.userToken != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.userToken)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			wantSent := 0
+			if tt.wantStatus == http.StatusOK {
+				wantSent = 1
+			}
+			if len(service.sent) != wantSent {
+				t.Fatalf("sent %d notifications, want %d", len(service.sent), wantSent)
+			}
+		})
+	}
+}
+
+// userRequest serves a request authenticated as userID with the given role
+func userRequest(t *testing.T, router *gin.Engine, userID string, role auth.UserRole, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	token, err := auth.GenerateToken(userID, role)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func newNotificationRouter(t *testing.T, service *fakeNotificationService) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "user-secret")
+	router := gin.New()
+	NewNotificationHandler(service, nil).SetupRoutes(router.Group("/api/v1"))
+	return router
+}
+
+func (s *fakeNotificationService) GetNotificationHistory(req domain.NotificationHistoryRequest) (*domain.NotificationListResponse, error) {
+	s.history = append(s.history, req)
+	return &domain.NotificationListResponse{
+		Notifications: []domain.Notification{{ID: "notification-1", UserID: req.UserID, Type: req.Type}},
+		UnreadCount:   3,
+		TotalCount:    12,
+	}, nil
+}
+
+func (s *fakeNotificationService) MarkNotificationAsRead(notificationID, userID string) error {
+	if s.readErr != nil {
+		return s.readErr
+	}
+	s.read = append(s.read, notificationID+":"+userID)
+	return nil
+}
+
+func (s *fakeNotificationService) MarkAllNotificationsAsRead(userID string) error {
+	s.readAll = append(s.readAll, userID)
+	return nil
+}
+
+func TestNotificationHistoryRoute(t *testing.T) {
+	service := &fakeNotificationService{}
+	router := newNotificationRouter(t, service)
+
+	w := userRequest(t, router, "user-1", auth.RoleCustomer, http.MethodGet, "/api/v1/user/notifications/?type=promotion&limit=10&offset=20", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	want := domain.NotificationHistoryRequest{UserID: "user-1", Type: domain.TypePromotion, Limit: 10, Offset: 20}
+	if len(service.history) != 1 || service.history[0] != want {
+		t.Fatalf("history requests = %+v, want %+v", service.history, want)
+	}
+
+	var resp domain.NotificationListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Notifications) != 1 || resp.UnreadCount != 3 || resp.TotalCount != 12 {
+		t.Fatalf("response = %+v", resp)
+	}
+
+	// Without a token the history is not served
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/user/notifications/", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status without a token = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestMarkAsReadRoute(t *testing.T) {
+	tests := []struct {
+		name       string
+		readErr    error
+		wantStatus int
+	}{
+		{name: "own notification", wantStatus: http.StatusOK},
+		{name: "another user's notification", readErr: domain.ErrNotificationForbidden, wantStatus: http.StatusForbidden},
+		{name: "missing notification", readErr: errors.New("record not found"), wantStatus: http.StatusNotFound},
+		{name: "not delivered yet", readErr: domain.ErrNotificationNotDelivered, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeNotificationService{readErr: tt.readErr}
+			router := newNotificationRouter(t, service)
+
+			w := userRequest(t, router, "user-1", auth.RoleCustomer, http.MethodPut, "/api/v1/user/notifications/notification-1/read", "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.readErr == nil && (len(service.read) != 1 || service.read[0] != "notification-1:user-1") {
+				t.Fatalf("marked read = %v, want [notification-1:user-1]", service.read)
+			}
+		})
+	}
+}
+
+func TestMarkAllAsReadRoute(t *testing.T) {
+	service := &fakeNotificationService{}
+	router := newNotificationRouter(t, service)
+
+	w := userRequest(t, router, "user-1", auth.RoleCustomer, http.MethodPut, "/api/v1/user/notifications/read-all", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if len(service.readAll) != 1 || service.readAll[0] != "user-1" {
+		t.Fatalf("marked all read for %v, want [user-1]", service.readAll)
+	}
+}
+
+func (s *fakeNotificationService) GetDeliveryStats(start, end time.Time) (*domain.DeliveryStats, error) {
+	s.statsRanges = append(s.statsRanges, [2]time.Time{start, end})
+	return &domain.DeliveryStats{Total: 7}, nil
+}
+
+func TestDeliveryStatsRoute(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		role       auth.UserRole
+		wantStatus int
+		wantStart  time.Time
+		wantEnd    time.Time
+	}{
+		{name: "all time", role: auth.RoleAdmin, wantStatus: http.StatusOK},
+		{
+			name:       "date range includes the end day",
+			query:      "?start_date=2026-03-01&end_date=2026-03-10",
+			role:       auth.RoleAdmin,
+			wantStatus: http.StatusOK,
+			wantStart:  time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:    time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{name: "invalid date", query: "?start_date=03/01/2026", role: auth.RoleAdmin, wantStatus: http.StatusBadRequest},
+		{name: "not an admin", role: auth.RoleCustomer, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeNotificationService{}
+			router := newNotificationRouter(t, service)
+
+			w := userRequest(t, router, "admin-1", tt.role, http.MethodGet, "/api/v1/admin/notifications/delivery-stats"+tt.query, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if len(service.statsRanges) != 0 {
+					t.Fatalf("stats were queried for a rejected request")
+				}
+				return
+			}
+			if len(service.statsRanges) != 1 {
+				t.Fatalf("stats queried %d times, want 1", len(service.statsRanges))
+			}
+			if got := service.statsRanges[0]; !got[0].Equal(tt.wantStart) || !got[1].Equal(tt.wantEnd) {
+				t.Fatalf("range = %v - %v, want %v - %v", got[0], got[1], tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func (s *fakeNotificationService) GetUnreadCount(userID string) (int, error) {
+	return 4, nil
+}
+
+func (s *fakeNotificationService) SubscribeUnreadCount(userID string) (<-chan int, func()) {
+	return s.unread, func() {}
+}
+
+func TestStreamUnreadCount(t *testing.T) {
+	service := &fakeNotificationService{unread: make(chan int)}
+	router := newNotificationRouter(t, service)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	token, err := auth.GenerateToken("user-1", auth.RoleCustomer)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/notifications/stream", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	events := bufio.NewScanner(resp.Body)
+	nextData := func() string {
+		t.Helper()
+		for events.Scan() {
+			if data, ok := strings.CutPrefix(events.Text(), "data:"); ok {
+				return data
+			}
+		}
+		t.Fatalf("stream ended: %v", events.Err())
+		return ""
+	}
+
+	// The current count is sent on connect, then every update
+	if data := nextData(); data != `{"unread_count":4}` {
+		t.Fatalf("first event data = %s", data)
+	}
+	service.unread <- 5
+	if data := nextData(); data != `{"unread_count":5}` {
+		t.Fatalf("update event data = %s", data)
+	}
+}
+
+func (s *fakeNotificationService) CreateTemplate(template *domain.NotificationTemplate) (*domain.NotificationTemplate, error) {
+	if s.templateErr != nil {
+		return nil, s.templateErr
+	}
+	s.created = append(s.created, *template)
+	template.ID = "template-1"
+	return template, nil
+}
+
+func (s *fakeNotificationService) ListTemplates(notificationType domain.NotificationType, limit, offset int) ([]domain.NotificationTemplate, error) {
+	s.listedTypes = append(s.listedTypes, notificationType)
+	return []domain.NotificationTemplate{{ID: "template-1", Type: notificationType}}, nil
+}
+
+func (s *fakeNotificationService) UpdateTemplate(templateID string, req domain.UpdateTemplateRequest) (*domain.NotificationTemplate, error) {
+	if s.templateErr != nil {
+		return nil, s.templateErr
+	}
+	if s.updated == nil {
+		s.updated = make(map[string]domain.UpdateTemplateRequest)
+	}
+	s.updated[templateID] = req
+	return &domain.NotificationTemplate{ID: templateID, Title: *req.Title}, nil
+}
+
+func (s *fakeNotificationService) BroadcastNotification(adminID string, req domain.BroadcastRequest) (*domain.BroadcastResult, error) {
+	if s.templateErr != nil {
+		return nil, s.templateErr
+	}
+	s.broadcastBy = append(s.broadcastBy, adminID)
+	s.broadcasts = append(s.broadcasts, req)
+	return &domain.BroadcastResult{Requested: len(req.UserIDs), Sent: len(req.UserIDs) - 1, Failed: 1, FailedUserIDs: req.UserIDs[:1]}, nil
+}
+
+func TestCreateTemplateRoute(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		templateErr error
+		wantStatus  int
+	}{
+		{
+			name:       "created",
+			body:       `{"name":"order_ready","type":"order_update","channel":"push","title":"Order {{.order_id}}","message":"Ready","variables":["order_id"]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:        "name taken",
+			body:        `{"name":"order_ready","type":"order_update","channel":"push","title":"Order","message":"Ready"}`,
+			templateErr: domain.ErrTemplateNameTaken,
+			wantStatus:  http.StatusConflict,
+		},
+		{
+			name:        "unknown channel",
+			body:        `{"name":"order_ready","type":"order_update","channel":"pigeon","title":"Order","message":"Ready"}`,
+			templateErr: domain.ErrInvalidTemplate,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{name: "missing channel", body: `{"name":"order_ready","type":"order_update","title":"Order","message":"Ready"}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeNotificationService{templateErr: tt.templateErr}
+			router := newNotificationRouter(t, service)
+
+			w := userRequest(t, router, "admin-1", auth.RoleAdmin, http.MethodPost, "/api/v1/admin/notifications/templates", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			if len(service.created) != 1 {
+				t.Fatalf("created %d templates, want 1", len(service.created))
+			}
+			created := service.created[0]
+			if created.Name != "order_ready" || created.Channel != domain.ChannelPush || len(created.Variables) != 1 || !created.IsActive {
+				t.Fatalf("created template = %+v", created)
+			}
+		})
+	}
+
+	// Only admins manage templates
+	service := &fakeNotificationService{}
+	router := newNotificationRouter(t, service)
+	w := userRequest(t, router, "user-1", auth.RoleCustomer, http.MethodPost, "/api/v1/admin/notifications/templates", tests[0].body)
+	if w.Code != http.StatusForbidden || len(service.created) != 0 {
+		t.Fatalf("customer create: status = %d, created %d", w.Code, len(service.created))
+	}
+}
+
+func TestListTemplatesRoute(t *testing.T) {
+	service := &fakeNotificationService{}
+	router := newNotificationRouter(t, service)
+
+	w := userRequest(t, router, "admin-1", auth.RoleAdmin, http.MethodGet, "/api/v1/admin/notifications/templates?type=promotion", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if len(service.listedTypes) != 1 || service.listedTypes[0] != domain.TypePromotion {
+		t.Fatalf("listed types = %v, want [promotion]", service.listedTypes)
+	}
+}
+
+func TestUpdateTemplateRoute(t *testing.T) {
+	tests := []struct {
+		name        string
+		templateErr error
+		wantStatus  int
+	}{
+		{name: "updated", wantStatus: http.StatusOK},
+		{name: "missing template", templateErr: domain.ErrTemplateNotFound, wantStatus: http.StatusNotFound},
+		{name: "name taken", templateErr: domain.ErrTemplateNameTaken, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeNotificationService{templateErr: tt.templateErr}
+			router := newNotificationRouter(t, service)
+
+			w := userRequest(t, router, "admin-1", auth.RoleAdmin, http.MethodPut, "/api/v1/admin/notifications/templates/template-1", `{"title":"Order {{.order_id}} is ready"}`)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.templateErr != nil {
+				return
+			}
+			req, ok := service.updated["template-1"]
+			if !ok || req.Title == nil || *req.Title != "Order {{.order_id}} is ready" || req.Message != nil {
+				t.Fatalf("update request = %+v", req)
+			}
+		})
+	}
+}
+
+func TestBroadcastRoute(t *testing.T) {
+	service := &fakeNotificationService{}
+	router := newNotificationRouter(t, service)
+
+	body := `{"user_ids":["user-1","user-2","user-3"],"template_id":"template-1","variables":{"code":"SAVE10"}}`
+	w := userRequest(t, router, "admin-1", auth.RoleAdmin, http.MethodPost, "/api/v1/admin/notifications/broadcast", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if len(service.broadcasts) != 1 || service.broadcastBy[0] != "admin-1" {
+		t.Fatalf("broadcasts = %v by %v", service.broadcasts, service.broadcastBy)
+	}
+	if req := service.broadcasts[0]; len(req.UserIDs) != 3 || req.TemplateID != "template-1" || req.Variables["code"] != "SAVE10" {
+		t.Fatalf("broadcast request = %+v", req)
+	}
+
+	var result domain.BroadcastResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.Requested != 3 || result.Sent != 2 || result.Failed != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	// A broadcast needs at least one recipient
+	w = userRequest(t, router, "admin-1", auth.RoleAdmin, http.MethodPost, "/api/v1/admin/notifications/broadcast", `{"user_ids":[]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("empty segment status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func (s *fakeNotificationService) GetUserPreferences(userID string) ([]domain.UserPreference, error) {
+	return []domain.UserPreference{{UserID: userID, Type: domain.TypePromotion, Channel: domain.ChannelPush, Enabled: true, IsDefault: true}}, nil
+}
+
+func (s *fakeNotificationService) UpdateUserPreference(userID string, req domain.UpdatePreferenceRequest) (*domain.UserPreference, error) {
+	if s.preferenceErr != nil {
+		return nil, s.preferenceErr
+	}
+	if s.preferences == nil {
+		s.preferences = make(map[string][]domain.UpdatePreferenceRequest)
+	}
+	s.preferences[userID] = append(s.preferences[userID], req)
+	return &domain.UserPreference{UserID: userID, Type: req.Type, Channel: req.Channel, Enabled: req.Enabled}, nil
+}
+
+func TestPreferencesRoutes(t *testing.T) {
+	service := &fakeNotificationService{}
+	router := newNotificationRouter(t, service)
+
+	w := userRequest(t, router, "user-1", auth.RoleCustomer, http.MethodGet, "/api/v1/user/notifications/preferences", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("get status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var preferences []domain.UserPreference
+	if err := json.Unmarshal(w.Body.Bytes(), &preferences); err != nil {
+		t.Fatalf("decode preferences: %v", err)
+	}
+	if len(preferences) != 1 || preferences[0].UserID != "user-1" || !preferences[0].IsDefault {
+		t.Fatalf("preferences = %+v", preferences)
+	}
+
+	w = userRequest(t, router, "user-1", auth.RoleCustomer, http.MethodPut, "/api/v1/user/notifications/preferences", `{"type":"promotion","channel":"push","enabled":false}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	want := domain.UpdatePreferenceRequest{Type: domain.TypePromotion, Channel: domain.ChannelPush}
+	if got := service.preferences["user-1"]; len(got) != 1 || got[0] != want {
+		t.Fatalf("updates for user-1 = %+v, want [%+v]", got, want)
+	}
+
+	service.preferenceErr = domain.ErrInvalidPreference
+	w = userRequest(t, router, "user-1", auth.RoleCustomer, http.MethodPut, "/api/v1/user/notifications/preferences", `{"type":"promotion","channel":"fax","enabled":false}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid channel status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func (s *fakeNotificationService) UpdateDevice(userID, deviceID string, req domain.UpdateDeviceRequest) (*domain.NotificationDevice, error) {
+	if s.deviceErr != nil {
+		return nil, s.deviceErr
+	}
+	s.deviceUpdates = append(s.deviceUpdates, req)
+	return &domain.NotificationDevice{ID: deviceID, UserID: userID, DeviceToken: *req.DeviceToken}, nil
+}
+
+func (s *fakeNotificationService) DeleteDevice(userID, deviceID string) error {
+	if s.deviceErr != nil {
+		return s.deviceErr
+	}
+	s.deletedDevices = append(s.deletedDevices, userID+":"+deviceID)
+	return nil
+}
+
+func TestDeviceRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		deviceErr  error
+		wantStatus int
+	}{
+		{name: "own device", wantStatus: http.StatusOK},
+		{name: "another user's device", deviceErr: domain.ErrDeviceForbidden, wantStatus: http.StatusForbidden},
+		{name: "missing device", deviceErr: domain.ErrDeviceNotFound, wantStatus: http.StatusNotFound},
+		{name: "token in use", deviceErr: domain.ErrDeviceTokenInUse, wantStatus: http.StatusConflict},
+		{name: "invalid platform", deviceErr: domain.ErrInvalidDevice, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeNotificationService{deviceErr: tt.deviceErr}
+			router := newNotificationRouter(t, service)
+
+			w := userRequest(t, router, "user-1", auth.RoleCustomer, http.MethodPut, "/api/v1/user/notifications/devices/device-1", `{"device_token":"new-token","platform":"android"}`)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("update status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.deviceErr == nil {
+				if len(service.deviceUpdates) != 1 || *service.deviceUpdates[0].Platform != domain.PlatformAndroid {
+					t.Fatalf("device updates = %+v", service.deviceUpdates)
+				}
+			}
+
+			if tt.deviceErr == domain.ErrDeviceTokenInUse || tt.deviceErr == domain.ErrInvalidDevice {
+				return
+			}
+			w = userRequest(t, router, "user-1", auth.RoleCustomer, http.MethodDelete, "/api/v1/user/notifications/devices/device-1", "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("delete status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.deviceErr == nil && (len(service.deletedDevices) != 1 || service.deletedDevices[0] != "user-1:device-1") {
+				t.Fatalf("deleted = %v, want [user-1:device-1]", service.deletedDevices)
+			}
+		})
+	}
+}
+
+func (s *fakeNotificationService) GetBulkJob(jobID string) (*domain.BulkJob, error) {
+	if jobID != "job-1" {
+		return nil, domain.ErrBulkJobNotFound
+	}
+	return &domain.BulkJob{ID: jobID, Status: domain.BulkJobProcessing, Total: 250, Sent: 180, Failed: 20}, nil
+}
+
+func TestGetBulkJobRoute(t *testing.T) {
+	router := newNotificationRouter(t, &fakeNotificationService{})
+	t.Setenv("SERVICE_TOKEN_SECRET", "service-secret")
+	serviceToken, err := auth.GenerateServiceToken("order-service")
+	if err != nil {
+		t.Fatalf("GenerateServiceToken: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		jobID      string
+		wantStatus int
+	}{
+		{name: "in progress", jobID: "job-1", wantStatus: http.StatusOK},
+		{name: "missing", jobID: "job-9", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/bulk-jobs/"+tt.jobID, nil)
+			req.Header.Set(auth.HeaderServiceToken, serviceToken)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var job domain.BulkJob
+			if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+				t.Fatalf("decode job: %v", err)
+			}
+			if job.Total != 250 || job.Sent != 180 || job.Failed != 20 {
+				t.Fatalf("job = %+v", job)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"glovo-backend/services/notification-service/internal/domain"
+	"glovo-backend/shared/auth"
+	"glovo-backend/shared/middleware"
+	"glovo-backend/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService domain.WebhookService
+}
+
+func init() {
+	response.Register(domain.ErrNotWebhookOwner, response.CodeForbidden)
+	response.Register(domain.ErrUnsupportedWebhookEvent, response.CodeInvalidRequest)
+	response.Register(domain.ErrUnsafeWebhookURL, response.CodeInvalidRequest)
+}
+
+func NewWebhookHandler(webhookService domain.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+func (h *WebhookHandler) SetupRoutes(router *gin.RouterGroup) {
+	// Merchant webhook registrations and delivery log
+	merchant := router.Group("/merchant/webhooks")
+	merchant.Use(middleware.AuthMiddleware())
+	merchant.Use(middleware.RequireRole(auth.RoleMerchant))
+	{
+		merchant.POST("", h.registerWebhook)
+		merchant.GET("", h.getWebhooks)
+		merchant.DELETE("/:id", h.deleteWebhook)
+		merchant.GET("/:id/deliveries", h.getDeliveries)
+	}
+}
+
+// @Summary Register webhook
+// @Description Register a public https URL to receive signed order and payout events. Each request carries an X-Webhook-Signature header, "sha256=" followed by the hex HMAC-SHA256 of the body keyed with the secret. The secret is generated when omitted and only returned here.
+// @Tags merchant
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.RegisterWebhookRequest true "Webhook data"
+// @Success 201 {object} domain.RegisteredWebhook
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
+// @Router /api/v1/merchant/webhooks [post]
+func (h *WebhookHandler) registerWebhook(c *gin.Context) {
+	var req domain.RegisterWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err)
+		return
+	}
+
+	merchantID, _ := c.Get("user_id")
+
+	webhook, err := h.webhookService.RegisterWebhook(merchantID.(string), req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// @Summary Get webhooks
+// @Description Get the merchant's registered webhooks
+// @Tags merchant
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.MerchantWebhook
+// @Failure 500 {object} response.ErrorEnvelope
+// @Router /api/v1/merchant/webhooks [get]
+func (h *WebhookHandler) getWebhooks(c *gin.Context) {
+	merchantID, _ := c.Get("user_id")
+
+	webhooks, err := h.webhookService.GetWebhooks(merchantID.(string))
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// @Summary Delete webhook
+// @Description Stop sending events to a webhook; pending retries are dropped
+// @Tags merchant
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} response.ErrorEnvelope
+// @Failure 404 {object} response.ErrorEnvelope
+// @Router /api/v1/merchant/webhooks/{id} [delete]
+func (h *WebhookHandler) deleteWebhook(c *gin.Context) {
+	merchantID, _ := c.Get("user_id")
+
+	if err := h.webhookService.DeleteWebhook(merchantID.(string), c.Param("id")); err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// @Summary Get webhook deliveries
+// @Description Get the delivery log of a webhook, newest first, with each attempt's outcome
+// @Tags merchant
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param limit query int false "Limit results" default(20)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {array} domain.WebhookDelivery
+// @Failure 403 {object} response.ErrorEnvelope
+// @Failure 404 {object} response.ErrorEnvelope
+// @Router /api/v1/merchant/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) getDeliveries(c *gin.Context) {
+	merchantID, _ := c.Get("user_id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	deliveries, err := h.webhookService.GetDeliveries(merchantID.(string), c.Param("id"), limit, offset)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
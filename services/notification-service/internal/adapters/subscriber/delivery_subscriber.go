@@ -0,0 +1,86 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+
+	"glovo-backend/services/notification-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// DeliverySubscriber notifies drivers about new deliveries and their outcome.
+// Customers are already notified by the delivery service on every status
+// change.
+type DeliverySubscriber struct {
+	notificationService domain.NotificationService
+}
+
+func NewDeliverySubscriber(notificationService domain.NotificationService) *DeliverySubscriber {
+	return &DeliverySubscriber{notificationService: notificationService}
+}
+
+// Register subscribes to the delivery events notification reacts to
+func (s *DeliverySubscriber) Register(bus events.Bus) error {
+	if err := bus.Subscribe(events.DeliveryAssigned, s.handleDeliveryAssigned); err != nil {
+		return err
+	}
+	if err := bus.Subscribe(events.DeliveryCompleted, s.handleDeliveryCompleted); err != nil {
+		return err
+	}
+	return bus.Subscribe(events.DeliveryCancelled, s.handleDeliveryCancelled)
+}
+
+func (s *DeliverySubscriber) handleDeliveryAssigned(ctx context.Context, event events.Event) error {
+	var payload events.DeliveryAssignedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	if payload.DriverID == "" {
+		return nil
+	}
+
+	_, err := s.notificationService.SendDeliveryAssignment(domain.DeliveryAssignment{
+		DeliveryID:      payload.DeliveryID,
+		OrderID:         payload.OrderID,
+		DriverID:        payload.DriverID,
+		DriverPhone:     payload.DriverPhone,
+		PickupAddress:   payload.PickupAddress,
+		DeliveryAddress: payload.DeliveryAddress,
+		DeliveryFee:     payload.DeliveryFee,
+		Distance:        payload.Distance,
+		EstimatedTime:   payload.EstimatedTime,
+		Contactless:     payload.Contactless,
+		DropLocation:    payload.DropLocation,
+		CallOnArrival:   payload.CallOnArrival,
+	})
+	return err
+}
+
+func (s *DeliverySubscriber) handleDeliveryCompleted(ctx context.Context, event events.Event) error {
+	var payload events.DeliveryCompletedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	if payload.DriverID == "" {
+		return nil
+	}
+
+	message := fmt.Sprintf("Delivery completed. %.2f has been added to your earnings.", payload.DeliveryFee)
+	return s.notificationService.SendOrderNotification(payload.OrderID, payload.DriverID, message)
+}
+
+func (s *DeliverySubscriber) handleDeliveryCancelled(ctx context.Context, event events.Event) error {
+	var payload events.DeliveryCancelledPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	if payload.DriverID == "" {
+		return nil
+	}
+
+	message := "Your delivery has been cancelled."
+	if payload.Reason != "" {
+		message = fmt.Sprintf("Your delivery has been cancelled: %s", payload.Reason)
+	}
+	return s.notificationService.SendOrderNotification(payload.OrderID, payload.DriverID, message)
+}
@@ -0,0 +1,54 @@
+package subscriber
+
+import (
+	"context"
+	"testing"
+
+	"glovo-backend/services/notification-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+func TestDeliveryAssignedNotifiesDriver(t *testing.T) {
+	service := &fakeNotificationService{}
+	bus := events.NewInMemoryBus()
+	if err := NewDeliverySubscriber(service).Register(bus); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	payload := events.DeliveryAssignedPayload{
+		DeliveryID:      "delivery-1",
+		OrderID:         "order-1",
+		DriverID:        "driver-1",
+		DriverPhone:     "+34600000000",
+		PickupAddress:   "Calle Mayor 1",
+		DeliveryAddress: "Gran Via 20",
+		DeliveryFee:     4.5,
+		Distance:        3.2,
+		EstimatedTime:   18,
+		Contactless:     true,
+		DropLocation:    "leave at the door",
+		CallOnArrival:   true,
+	}
+	if err := bus.Publish(context.Background(), events.DeliveryAssigned, payload); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	bus.Close()
+
+	want := domain.DeliveryAssignment{
+		DeliveryID:      "delivery-1",
+		OrderID:         "order-1",
+		DriverID:        "driver-1",
+		DriverPhone:     "+34600000000",
+		PickupAddress:   "Calle Mayor 1",
+		DeliveryAddress: "Gran Via 20",
+		DeliveryFee:     4.5,
+		Distance:        3.2,
+		EstimatedTime:   18,
+		Contactless:     true,
+		DropLocation:    "leave at the door",
+		CallOnArrival:   true,
+	}
+	if len(service.assignments) != 1 || service.assignments[0] != want {
+		t.Fatalf("assignments = %+v, want %+v", service.assignments, want)
+	}
+}
@@ -0,0 +1,48 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+
+	"glovo-backend/services/notification-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// StoreSubscriber tells merchants whether their new store was approved
+type StoreSubscriber struct {
+	notificationService domain.NotificationService
+}
+
+func NewStoreSubscriber(notificationService domain.NotificationService) *StoreSubscriber {
+	return &StoreSubscriber{notificationService: notificationService}
+}
+
+// Register subscribes to the catalog events notification reacts to
+func (s *StoreSubscriber) Register(bus events.Bus) error {
+	return bus.Subscribe(events.StoreReviewed, s.handleStoreReviewed)
+}
+
+func (s *StoreSubscriber) handleStoreReviewed(ctx context.Context, event events.Event) error {
+	var payload events.StoreReviewedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	title := "Store approved"
+	message := fmt.Sprintf("%s has been approved and is now visible to customers.", payload.StoreName)
+	if !payload.Approved {
+		title = "Store not approved"
+		message = fmt.Sprintf("%s was not approved: %s. Update your store to resubmit it.", payload.StoreName, payload.Reason)
+	}
+
+	_, err := s.notificationService.SendNotification(domain.SendNotificationRequest{
+		UserID:   payload.MerchantID,
+		Type:     domain.TypeSystemAlert,
+		Channel:  domain.ChannelPush,
+		Title:    title,
+		Message:  message,
+		Data:     map[string]string{"store_id": payload.StoreID},
+		Priority: domain.PriorityHigh,
+	})
+	return err
+}
@@ -0,0 +1,71 @@
+package subscriber
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"glovo-backend/services/notification-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+type fakeNotificationService struct {
+	domain.NotificationService
+	sent        []domain.SendNotificationRequest
+	assignments []domain.DeliveryAssignment
+}
+
+func (s *fakeNotificationService) SendNotification(req domain.SendNotificationRequest) (*domain.Notification, error) {
+	s.sent = append(s.sent, req)
+	return &domain.Notification{UserID: req.UserID}, nil
+}
+
+func (s *fakeNotificationService) SendDeliveryAssignment(assignment domain.DeliveryAssignment) (*domain.Notification, error) {
+	s.assignments = append(s.assignments, assignment)
+	return &domain.Notification{UserID: assignment.DriverID}, nil
+}
+
+func TestStoreReviewedNotifiesMerchant(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     events.StoreReviewedPayload
+		wantTitle   string
+		wantMessage string
+	}{
+		{
+			name:        "approved",
+			payload:     events.StoreReviewedPayload{StoreID: "store-1", MerchantID: "merchant-1", StoreName: "Fresh Bakery", Approved: true},
+			wantTitle:   "Store approved",
+			wantMessage: "Fresh Bakery has been approved",
+		},
+		{
+			name:        "rejected",
+			payload:     events.StoreReviewedPayload{StoreID: "store-1", MerchantID: "merchant-1", StoreName: "Fresh Bakery", Reason: "Missing food licence"},
+			wantTitle:   "Store not approved",
+			wantMessage: "was not approved: Missing food licence",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeNotificationService{}
+			bus := events.NewInMemoryBus()
+			if err := NewStoreSubscriber(service).Register(bus); err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+
+			if err := bus.Publish(context.Background(), events.StoreReviewed, tt.payload); err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			bus.Close()
+
+			if len(service.sent) != 1 {
+				t.Fatalf("sent %d notifications, want 1", len(service.sent))
+			}
+			sent := service.sent[0]
+			if sent.UserID != "merchant-1" || sent.Title != tt.wantTitle || !strings.Contains(sent.Message, tt.wantMessage) || sent.Data["store_id"] != "store-1" {
+				t.Fatalf("sent %+v", sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package subscriber
+
+import (
+	"context"
+
+	"glovo-backend/services/notification-service/internal/domain"
+	"glovo-backend/shared/auth"
+	"glovo-backend/shared/events"
+)
+
+// WebhookSubscriber forwards order and payout events to merchant webhooks
+type WebhookSubscriber struct {
+	webhookService domain.WebhookService
+}
+
+func NewWebhookSubscriber(webhookService domain.WebhookService) *WebhookSubscriber {
+	return &WebhookSubscriber{webhookService: webhookService}
+}
+
+// Register subscribes to the events merchants receive webhooks for
+func (s *WebhookSubscriber) Register(bus events.Bus) error {
+	if err := bus.Subscribe(events.DeliveryCompleted, s.handleDeliveryCompleted); err != nil {
+		return err
+	}
+	if err := bus.Subscribe(events.DeliveryCancelled, s.handleDeliveryCancelled); err != nil {
+		return err
+	}
+	return bus.Subscribe(events.PayoutCompleted, s.handlePayoutCompleted)
+}
+
+func (s *WebhookSubscriber) handleDeliveryCompleted(ctx context.Context, event events.Event) error {
+	var payload events.DeliveryCompletedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	if payload.MerchantID == "" {
+		return nil
+	}
+
+	return s.webhookService.Dispatch(event.ID, payload.MerchantID, domain.WebhookOrderDelivered, event.OccurredAt, map[string]interface{}{
+		"order_id":     payload.OrderID,
+		"delivery_id":  payload.DeliveryID,
+		"delivered_at": payload.DeliveredAt,
+	})
+}
+
+func (s *WebhookSubscriber) handleDeliveryCancelled(ctx context.Context, event events.Event) error {
+	var payload events.DeliveryCancelledPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	if payload.MerchantID == "" {
+		return nil
+	}
+
+	return s.webhookService.Dispatch(event.ID, payload.MerchantID, domain.WebhookOrderCancelled, event.OccurredAt, map[string]interface{}{
+		"order_id":     payload.OrderID,
+		"delivery_id":  payload.DeliveryID,
+		"reason":       payload.Reason,
+		"cancelled_at": payload.CancelledAt,
+	})
+}
+
+func (s *WebhookSubscriber) handlePayoutCompleted(ctx context.Context, event events.Event) error {
+	var payload events.PayoutCompletedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	if payload.UserRole != string(auth.RoleMerchant) {
+		return nil
+	}
+
+	return s.webhookService.Dispatch(event.ID, payload.UserID, domain.WebhookPayoutCompleted, event.OccurredAt, map[string]interface{}{
+		"transaction_id": payload.TransactionID,
+		"amount":         payload.Amount,
+		"currency":       payload.Currency,
+		"completed_at":   payload.CompletedAt,
+	})
+}
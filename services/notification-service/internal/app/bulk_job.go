@@ -0,0 +1,95 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"glovo-backend/services/notification-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// SendBulkNotification creates a job for the send and processes it in the
+// background. Poll GetBulkJob for its progress.
+func (s *notificationService) SendBulkNotification(req domain.BulkNotificationRequest) (*domain.BulkJob, error) {
+	now := time.Now()
+	job := &domain.BulkJob{
+		ID:        uuid.New().String(),
+		Type:      req.Type,
+		Channel:   req.Channel,
+		Status:    domain.BulkJobPending,
+		Total:     len(req.UserIDs),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.bulkJobRepo.Create(job); err != nil {
+		return nil, fmt.Errorf("failed to create bulk job: %w", err)
+	}
+
+	// The worker owns job from here; the caller gets a copy
+	created := *job
+	go s.processBulkJob(job, req)
+	return &created, nil
+}
+
+func (s *notificationService) GetBulkJob(jobID string) (*domain.BulkJob, error) {
+	job, err := s.bulkJobRepo.GetByID(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrBulkJobNotFound, err)
+	}
+	return job, nil
+}
+
+// processBulkJob sends to the job's recipients BulkBatchSize at a time, saving
+// the counters after every batch. A recipient that can't be sent to is counted
+// as failed and the job carries on.
+func (s *notificationService) processBulkJob(job *domain.BulkJob, req domain.BulkNotificationRequest) {
+	startedAt := time.Now()
+	job.Status = domain.BulkJobProcessing
+	job.StartedAt = &startedAt
+
+	for start := 0; start < len(req.UserIDs); start += domain.BulkBatchSize {
+		end := start + domain.BulkBatchSize
+		if end > len(req.UserIDs) {
+			end = len(req.UserIDs)
+		}
+		s.sendBulkBatch(job, req, req.UserIDs[start:end])
+		s.saveBulkJob(job)
+	}
+
+	completedAt := time.Now()
+	job.Status = domain.BulkJobCompleted
+	job.CompletedAt = &completedAt
+	s.saveBulkJob(job)
+	log.Printf("Bulk job %s completed: %d sent, %d failed", job.ID, job.Sent, job.Failed)
+}
+
+func (s *notificationService) sendBulkBatch(job *domain.BulkJob, req domain.BulkNotificationRequest, userIDs []string) {
+	for _, userID := range userIDs {
+		_, err := s.SendNotification(domain.SendNotificationRequest{
+			UserID:       userID,
+			Type:         req.Type,
+			Channel:      req.Channel,
+			Title:        req.Title,
+			Message:      req.Message,
+			Data:         req.Data,
+			Priority:     req.Priority,
+			ScheduledFor: req.ScheduledFor,
+		})
+		if err != nil {
+			log.Printf("Bulk job %s: failed to send notification to user %s: %v", job.ID, userID, err)
+			job.Failed++
+			job.FailedUserIDs = append(job.FailedUserIDs, userID)
+			continue
+		}
+		job.Sent++
+	}
+}
+
+func (s *notificationService) saveBulkJob(job *domain.BulkJob) {
+	job.UpdatedAt = time.Now()
+	if err := s.bulkJobRepo.Update(job); err != nil {
+		log.Printf("Failed to save progress of bulk job %s: %v", job.ID, err)
+	}
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"glovo-backend/services/notification-service/internal/domain"
+)
+
+func TestBulkJobCounters(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{Default: domain.RateLimit{PerMinute: 1, Burst: 1}})
+
+	// Spans three batches; every tenth recipient has used up their rate limit
+	userIDs := make([]string, 2*domain.BulkBatchSize+50)
+	wantFailed := 0
+	for i := range userIDs {
+		userIDs[i] = fmt.Sprintf("user-%d", i)
+		if i%10 == 0 {
+			if _, err := svc.SendNotification(domain.SendNotificationRequest{
+				UserID: userIDs[i], Type: domain.TypePromotion, Channel: domain.ChannelInApp, Title: "Earlier", Message: "Earlier",
+			}); err != nil {
+				t.Fatalf("SendNotification: %v", err)
+			}
+			wantFailed++
+		}
+	}
+
+	job, err := svc.SendBulkNotification(domain.BulkNotificationRequest{
+		UserIDs: userIDs,
+		Type:    domain.TypePromotion,
+		Channel: domain.ChannelInApp,
+		Title:   "Weekend deal",
+		Message: "Free delivery all weekend",
+	})
+	if err != nil {
+		t.Fatalf("SendBulkNotification: %v", err)
+	}
+	if job.Status != domain.BulkJobPending || job.Total != len(userIDs) || job.Sent != 0 || job.Failed != 0 {
+		t.Fatalf("new job = %+v", job)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		polled, err := svc.GetBulkJob(job.ID)
+		if err != nil {
+			t.Fatalf("GetBulkJob: %v", err)
+		}
+		if polled.Sent+polled.Failed > polled.Total {
+			t.Fatalf("counters overshoot: %d sent + %d failed > %d", polled.Sent, polled.Failed, polled.Total)
+		}
+		if polled.Status == domain.BulkJobCompleted {
+			if polled.Sent != len(userIDs)-wantFailed || polled.Failed != wantFailed {
+				t.Fatalf("sent %d, failed %d; want %d and %d", polled.Sent, polled.Failed, len(userIDs)-wantFailed, wantFailed)
+			}
+			if len(polled.FailedUserIDs) != wantFailed || polled.FailedUserIDs[0] != "user-0" {
+				t.Fatalf("failed users = %v", polled.FailedUserIDs)
+			}
+			if polled.StartedAt == nil || polled.CompletedAt == nil {
+				t.Fatalf("started at %v, completed at %v", polled.StartedAt, polled.CompletedAt)
+			}
+			// Progress is saved after each of the three batches and on completion
+			fakes.bulkJobs.mu.Lock()
+			updates := fakes.bulkJobs.updates
+			fakes.bulkJobs.mu.Unlock()
+			if updates != 4 {
+				t.Fatalf("job saved %d times, want 4", updates)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job still %s after %d sent, %d failed", polled.Status, polled.Sent, polled.Failed)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestGetBulkJobNotFound(t *testing.T) {
+	svc, _ := newTestNotificationService(domain.RateLimitConfig{})
+	if _, err := svc.GetBulkJob("missing"); !errors.Is(err, domain.ErrBulkJobNotFound) {
+		t.Fatalf("expected %v, got %v", domain.ErrBulkJobNotFound, err)
+	}
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"glovo-backend/services/notification-service/internal/domain"
+)
+
+// defaultDeliveryAssignedTemplate is used until admins create an active
+// template named domain.DeliveryAssignedTemplate
+var defaultDeliveryAssignedTemplate = domain.NotificationTemplate{
+	Name:      domain.DeliveryAssignedTemplate,
+	Type:      domain.TypeDeliveryAssigned,
+	Channel:   domain.ChannelPush,
+	Title:     "New delivery assigned",
+	Message:   "Pick up order {{.order_id}} at {{.pickup_address}} and deliver to {{.delivery_address}} ({{.distance}} km, about {{.estimated_time}} min). Fee: {{.delivery_fee}}.",
+	Variables: []string{"order_id", "pickup_address", "delivery_address"},
+	IsActive:  true,
+}
+
+// SendDeliveryAssignment tells a driver about a new delivery. It goes out by
+// push when the driver has an active device and hasn't opted out of push, by
+// SMS otherwise, and is kept in-app only when neither channel is usable. The
+// customer's drop-off instructions are appended to the message whatever the
+// template says, and also sent as data for the driver app.
+func (s *notificationService) SendDeliveryAssignment(assignment domain.DeliveryAssignment) (*domain.Notification, error) {
+	template := &defaultDeliveryAssignedTemplate
+	if stored, err := s.templateRepo.GetByName(domain.DeliveryAssignedTemplate); err == nil && stored.IsActive {
+		template = stored
+	}
+
+	variables := map[string]string{
+		"delivery_id":      assignment.DeliveryID,
+		"order_id":         assignment.OrderID,
+		"pickup_address":   assignment.PickupAddress,
+		"delivery_address": assignment.DeliveryAddress,
+		"delivery_fee":     strconv.FormatFloat(assignment.DeliveryFee, 'f', 2, 64),
+		"distance":         strconv.FormatFloat(assignment.Distance, 'f', 1, 64),
+		"estimated_time":   strconv.Itoa(assignment.EstimatedTime),
+	}
+	content := localizedContent(template, s.userLocale(assignment.DriverID), s.defaultLocale)
+	title, message, err := renderTemplate(template, content, variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render delivery assignment: %w", err)
+	}
+	if note := instructionsNote(assignment); note != "" {
+		message += " " + note
+	}
+
+	data := map[string]string{
+		"delivery_id": assignment.DeliveryID,
+		"order_id":    assignment.OrderID,
+	}
+	if assignment.Contactless {
+		data["contactless"] = "true"
+	}
+	if assignment.DropLocation != "" {
+		data["drop_location"] = assignment.DropLocation
+	}
+	if assignment.CallOnArrival {
+		data["call_on_arrival"] = "true"
+	}
+	channel := s.assignmentChannel(assignment.DriverID, assignment.DriverPhone)
+	if channel == domain.ChannelSMS {
+		data["phone"] = assignment.DriverPhone
+	}
+
+	return s.SendNotification(domain.SendNotificationRequest{
+		UserID:   assignment.DriverID,
+		Type:     domain.TypeDeliveryAssigned,
+		Channel:  channel,
+		Title:    title,
+		Message:  message,
+		Data:     data,
+		Priority: domain.PriorityHigh,
+	})
+}
+
+// assignmentChannel picks push, then SMS, then in-app for a driver
+func (s *notificationService) assignmentChannel(driverID, phone string) domain.NotificationChannel {
+	if s.channelEnabled(driverID, domain.TypeDeliveryAssigned, domain.ChannelPush) && s.hasActiveDevice(driverID) {
+		return domain.ChannelPush
+	}
+	if phone != "" && s.channelEnabled(driverID, domain.TypeDeliveryAssigned, domain.ChannelSMS) {
+		return domain.ChannelSMS
+	}
+	return domain.ChannelInApp
+}
+
+// channelEnabled reports whether the user hasn't opted out of the type on the channel
+func (s *notificationService) channelEnabled(userID string, notificationType domain.NotificationType, channel domain.NotificationChannel) bool {
+	preference, err := s.preferenceRepo.GetByUserTypeAndChannel(userID, notificationType, channel)
+	return err != nil || preference.Enabled
+}
+
+func (s *notificationService) hasActiveDevice(userID string) bool {
+	devices, err := s.deviceRepo.GetByUserID(userID)
+	if err != nil {
+		return false
+	}
+	for _, device := range devices {
+		if device.IsActive {
+			return true
+		}
+	}
+	return false
+}
+
+// instructionsNote describes the drop-off instructions, or "" when there are none
+func instructionsNote(assignment domain.DeliveryAssignment) string {
+	var parts []string
+	if assignment.Contactless {
+		parts = append(parts, "Contactless drop-off, take a photo as proof.")
+	}
+	if assignment.DropLocation != "" {
+		parts = append(parts, fmt.Sprintf("Drop location: %s.", assignment.DropLocation))
+	}
+	if assignment.CallOnArrival {
+		parts = append(parts, "Call the customer on arrival.")
+	}
+	return strings.Join(parts, " ")
+}
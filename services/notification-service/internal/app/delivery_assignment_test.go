@@ -0,0 +1,159 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"glovo-backend/services/notification-service/internal/domain"
+)
+
+func TestSendDeliveryAssignment(t *testing.T) {
+	assignment := domain.DeliveryAssignment{
+		DeliveryID:      "delivery-1",
+		OrderID:         "order-1",
+		DriverID:        "driver-1",
+		DriverPhone:     "+34600000000",
+		PickupAddress:   "Calle Mayor 1",
+		DeliveryAddress: "Gran Via 20",
+		DeliveryFee:     4.5,
+		Distance:        3.2,
+		EstimatedTime:   18,
+	}
+
+	tests := []struct {
+		name        string
+		device      *domain.NotificationDevice
+		pushOptOut  bool
+		noPhone     bool
+		wantChannel domain.NotificationChannel
+		wantStatus  domain.NotificationStatus
+		wantPush    int
+		wantSMS     int
+	}{
+		{
+			name:        "active device",
+			device:      &domain.NotificationDevice{ID: "device-1", UserID: "driver-1", DeviceToken: "token-1", IsActive: true},
+			wantChannel: domain.ChannelPush,
+			wantStatus:  domain.StatusSent,
+			wantPush:    1,
+		},
+		{
+			name:        "no device",
+			wantChannel: domain.ChannelSMS,
+			wantStatus:  domain.StatusSent,
+			wantSMS:     1,
+		},
+		{
+			name:        "inactive device",
+			device:      &domain.NotificationDevice{ID: "device-1", UserID: "driver-1", DeviceToken: "token-1"},
+			wantChannel: domain.ChannelSMS,
+			wantStatus:  domain.StatusSent,
+			wantSMS:     1,
+		},
+		{
+			name:        "push opted out",
+			device:      &domain.NotificationDevice{ID: "device-1", UserID: "driver-1", DeviceToken: "token-1", IsActive: true},
+			pushOptOut:  true,
+			wantChannel: domain.ChannelSMS,
+			wantStatus:  domain.StatusSent,
+			wantSMS:     1,
+		},
+		{
+			name:        "no device or phone",
+			noPhone:     true,
+			wantChannel: domain.ChannelInApp,
+			wantStatus:  domain.StatusSent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+			if tt.device != nil {
+				fakes.devices.Create(tt.device)
+			}
+			if tt.pushOptOut {
+				fakes.preferences.Create(&domain.UserPreference{UserID: "driver-1", Type: domain.TypeDeliveryAssigned, Channel: domain.ChannelPush})
+			}
+			req := assignment
+			if tt.noPhone {
+				req.DriverPhone = ""
+			}
+
+			notification, err := svc.SendDeliveryAssignment(req)
+			if err != nil {
+				t.Fatalf("SendDeliveryAssignment: %v", err)
+			}
+			if notification.Channel != tt.wantChannel {
+				t.Fatalf("channel = %s, want %s", notification.Channel, tt.wantChannel)
+			}
+			waitForStatus(t, fakes.notifications, notification.ID, tt.wantStatus)
+
+			if fakes.push.count() != tt.wantPush || fakes.sms.count() != tt.wantSMS {
+				t.Fatalf("sent %d pushes and %d SMS, want %d and %d", fakes.push.count(), fakes.sms.count(), tt.wantPush, tt.wantSMS)
+			}
+			for _, want := range []string{"order-1", "Calle Mayor 1", "Gran Via 20", "3.2 km", "18 min", "4.50"} {
+				if !strings.Contains(notification.Message, want) {
+					t.Fatalf("message %q is missing %q", notification.Message, want)
+				}
+			}
+			if tt.wantSMS > 0 && fakes.sms.sent[0].phone != assignment.DriverPhone {
+				t.Fatalf("SMS sent to %s, want %s", fakes.sms.sent[0].phone, assignment.DriverPhone)
+			}
+			if tt.wantPush > 0 && fakes.push.sent[0].tokens[0] != "token-1" {
+				t.Fatalf("push sent to %v, want [token-1]", fakes.push.sent[0].tokens)
+			}
+		})
+	}
+}
+
+func TestSendDeliveryAssignmentStoredTemplate(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+	fakes.templates.Create(&domain.NotificationTemplate{
+		ID:        "template-1",
+		Name:      domain.DeliveryAssignedTemplate,
+		Type:      domain.TypeDeliveryAssigned,
+		Channel:   domain.ChannelPush,
+		Title:     "Pickup {{.order_id}}",
+		Message:   "Head to {{.pickup_address}}",
+		Variables: []string{"order_id", "pickup_address"},
+		IsActive:  true,
+	})
+
+	notification, err := svc.SendDeliveryAssignment(domain.DeliveryAssignment{
+		DeliveryID: "delivery-1", OrderID: "order-1", DriverID: "driver-1", PickupAddress: "Calle Mayor 1",
+	})
+	if err != nil {
+		t.Fatalf("SendDeliveryAssignment: %v", err)
+	}
+	if notification.Title != "Pickup order-1" || notification.Message != "Head to Calle Mayor 1" {
+		t.Fatalf("rendered %q / %q from the stored template", notification.Title, notification.Message)
+	}
+}
+
+func TestSendDeliveryAssignmentInstructions(t *testing.T) {
+	svc, _ := newTestNotificationService(domain.RateLimitConfig{})
+
+	notification, err := svc.SendDeliveryAssignment(domain.DeliveryAssignment{
+		DeliveryID:    "delivery-1",
+		OrderID:       "order-1",
+		DriverID:      "driver-1",
+		PickupAddress: "Calle Mayor 1",
+		Contactless:   true,
+		DropLocation:  "leave at the door",
+		CallOnArrival: true,
+	})
+	if err != nil {
+		t.Fatalf("SendDeliveryAssignment: %v", err)
+	}
+
+	for _, want := range []string{"Contactless drop-off", "Drop location: leave at the door.", "Call the customer on arrival."} {
+		if !strings.Contains(notification.Message, want) {
+			t.Fatalf("message %q is missing %q", notification.Message, want)
+		}
+	}
+	data := notification.Data
+	if data["contactless"] != "true" || data["drop_location"] != "leave at the door" || data["call_on_arrival"] != "true" {
+		t.Fatalf("data = %v", data)
+	}
+}
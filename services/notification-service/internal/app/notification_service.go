@@ -3,6 +3,8 @@ package app
 import (
 	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"glovo-backend/services/notification-service/internal/domain"
@@ -15,9 +17,13 @@ type notificationService struct {
 	templateRepo     domain.TemplateRepository
 	preferenceRepo   domain.PreferenceRepository
 	deviceRepo       domain.DeviceRepository
+	bulkJobRepo      domain.BulkJobRepository
 	pushService      domain.PushNotificationService
 	smsService       domain.SMSService
 	emailService     domain.EmailService
+	rateLimiter      *rateLimiter
+	defaultLocale    string
+	unread           *unreadBroadcaster
 }
 
 func NewNotificationService(
@@ -25,36 +31,32 @@ func NewNotificationService(
 	templateRepo domain.TemplateRepository,
 	preferenceRepo domain.PreferenceRepository,
 	deviceRepo domain.DeviceRepository,
+	bulkJobRepo domain.BulkJobRepository,
 	pushService domain.PushNotificationService,
 	smsService domain.SMSService,
 	emailService domain.EmailService,
+	rateLimits domain.RateLimitConfig,
+	defaultLocale string,
 ) domain.NotificationService {
 	return &notificationService{
 		notificationRepo: notificationRepo,
 		templateRepo:     templateRepo,
 		preferenceRepo:   preferenceRepo,
 		deviceRepo:       deviceRepo,
+		bulkJobRepo:      bulkJobRepo,
 		pushService:      pushService,
 		smsService:       smsService,
 		emailService:     emailService,
+		rateLimiter:      newRateLimiter(rateLimits),
+		defaultLocale:    defaultLocale,
+		unread:           newUnreadBroadcaster(),
 	}
 }
 
 // Sending notifications
 func (s *notificationService) SendNotification(req domain.SendNotificationRequest) (*domain.Notification, error) {
-	// Check user preferences for this type/channel
-	preference, err := s.preferenceRepo.GetByUserAndType(req.UserID, req.Type)
-	if err != nil {
-		// Create default preference if none exist
-		preference = &domain.UserPreference{
-			ID:        uuid.New().String(),
-			UserID:    req.UserID,
-			Type:      req.Type,
-			Channel:   req.Channel,
-			Enabled:   true, // Default to enabled
-			UpdatedAt: time.Now(),
-		}
-		s.preferenceRepo.Create(preference)
+	if !s.rateLimiter.Allow(req.UserID, req.Channel, req.Type, time.Now()) {
+		return nil, fmt.Errorf("%w for user %s on %s", domain.ErrRateLimited, req.UserID, req.Channel)
 	}
 
 	// Create notification record
@@ -74,43 +76,43 @@ func (s *notificationService) SendNotification(req domain.SendNotificationReques
 		UpdatedAt:    time.Now(),
 	}
 
+	// Apply user preferences unless the notification is scheduled for later,
+	// in which case the dispatcher applies them at send time
+	scheduled := notification.ScheduledFor != nil && notification.ScheduledFor.After(time.Now())
+	if !scheduled {
+		s.applyPreferences(notification, time.Now())
+	}
+
 	if err := s.notificationRepo.Create(notification); err != nil {
 		return nil, fmt.Errorf("failed to create notification: %w", err)
 	}
 
-	// Send notification based on channel and preferences
-	if preference.Enabled {
+	if s.isDueForSending(notification, time.Now()) {
 		go s.processNotification(notification)
 	}
 
 	return notification, nil
 }
 
-func (s *notificationService) SendBulkNotification(req domain.BulkNotificationRequest) ([]domain.Notification, error) {
-	var notifications []domain.Notification
+// Scheduled notifications
+func (s *notificationService) CancelScheduledNotification(notificationID string) error {
+	notification, err := s.notificationRepo.GetByID(notificationID)
+	if err != nil {
+		return err
+	}
 
-	for _, userID := range req.UserIDs {
-		notifReq := domain.SendNotificationRequest{
-			UserID:       userID,
-			Type:         req.Type,
-			Channel:      req.Channel,
-			Title:        req.Title,
-			Message:      req.Message,
-			Data:         req.Data,
-			Priority:     req.Priority,
-			ScheduledFor: req.ScheduledFor,
-		}
+	if notification.ScheduledFor == nil {
+		return errors.New("notification is not scheduled")
+	}
 
-		notification, err := s.SendNotification(notifReq)
-		if err != nil {
-			// Log error but continue with bulk operation
-			fmt.Printf("Failed to send notification to user %s: %v\n", userID, err)
-			continue
-		}
-		notifications = append(notifications, *notification)
+	if notification.Status != domain.StatusPending {
+		return fmt.Errorf("cannot cancel notification with status %s", notification.Status)
 	}
 
-	return notifications, nil
+	notification.Status = domain.StatusCancelled
+	notification.UpdatedAt = time.Now()
+
+	return s.notificationRepo.Update(notification)
 }
 
 func (s *notificationService) SendTemplateNotification(req domain.SendTemplateNotificationRequest) (*domain.Notification, error) {
@@ -120,9 +122,12 @@ func (s *notificationService) SendTemplateNotification(req domain.SendTemplateNo
 		return nil, fmt.Errorf("template not found: %w", err)
 	}
 
-	// Replace variables in template
-	title := s.replaceVariables(template.Title, req.Variables)
-	message := s.replaceVariables(template.Message, req.Variables)
+	// Render the recipient's locale variant with the provided variables
+	content := localizedContent(template, s.userLocale(req.UserID), s.defaultLocale)
+	title, message, err := renderTemplate(template, content, req.Variables)
+	if err != nil {
+		return nil, err
+	}
 
 	// Send notification
 	notifReq := domain.SendNotificationRequest{
@@ -161,7 +166,7 @@ func (s *notificationService) SendOrderNotification(orderID, userID, message str
 
 // Managing notifications
 func (s *notificationService) GetNotifications(userID string, limit, offset int) (*domain.NotificationListResponse, error) {
-	notifications, err := s.notificationRepo.GetByUserID(userID, limit, offset)
+	notifications, total, err := s.notificationRepo.GetByUserID(userID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -174,7 +179,25 @@ func (s *notificationService) GetNotifications(userID string, limit, offset int)
 	return &domain.NotificationListResponse{
 		Notifications: notifications,
 		UnreadCount:   unreadCount,
-		TotalCount:    len(notifications),
+		TotalCount:    total,
+	}, nil
+}
+
+func (s *notificationService) GetNotificationHistory(req domain.NotificationHistoryRequest) (*domain.NotificationListResponse, error) {
+	notifications, total, err := s.notificationRepo.GetHistory(req)
+	if err != nil {
+		return nil, err
+	}
+
+	unreadCount, err := s.notificationRepo.GetUnreadCount(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.NotificationListResponse{
+		Notifications: notifications,
+		UnreadCount:   unreadCount,
+		TotalCount:    total,
 	}, nil
 }
 
@@ -189,27 +212,78 @@ func (s *notificationService) MarkNotificationAsRead(notificationID, userID stri
 	}
 
 	if notification.UserID != userID {
-		return errors.New("unauthorized")
+		return domain.ErrNotificationForbidden
+	}
+	if notification.Status == domain.StatusRead {
+		return nil
 	}
 
-	notification.Status = domain.StatusRead
-	notification.ReadAt = &time.Time{}
-	*notification.ReadAt = time.Now()
-	notification.UpdatedAt = time.Now()
+	// Only a sent or delivered notification is marked, checked again in the
+	// update in case a pending one went out or expired since the read above
+	read, err := s.notificationRepo.MarkAsRead(notificationID)
+	if err != nil {
+		return err
+	}
+	if !read {
+		return domain.ErrNotificationNotDelivered
+	}
 
-	return s.notificationRepo.Update(notification)
+	s.publishUnreadCount(userID)
+	return nil
 }
 
 func (s *notificationService) MarkAllNotificationsAsRead(userID string) error {
-	return s.notificationRepo.MarkAllAsRead(userID)
+	if err := s.notificationRepo.MarkAllAsRead(userID); err != nil {
+		return err
+	}
+
+	s.publishUnreadCount(userID)
+	return nil
 }
 
 func (s *notificationService) GetUnreadCount(userID string) (int, error) {
 	return s.notificationRepo.GetUnreadCount(userID)
 }
 
+func (s *notificationService) SubscribeUnreadCount(userID string) (<-chan int, func()) {
+	return s.unread.subscribe(userID)
+}
+
+func (s *notificationService) GetDeliveryStats(start, end time.Time) (*domain.DeliveryStats, error) {
+	counts, err := s.notificationRepo.CountByChannelAndStatus(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &domain.DeliveryStats{
+		ByStatus:  make(map[domain.NotificationStatus]int),
+		ByChannel: make(map[domain.NotificationChannel]map[domain.NotificationStatus]int),
+	}
+	if !start.IsZero() {
+		stats.StartDate = &start
+	}
+	if !end.IsZero() {
+		stats.EndDate = &end
+	}
+
+	for _, count := range counts {
+		if stats.ByChannel[count.Channel] == nil {
+			stats.ByChannel[count.Channel] = make(map[domain.NotificationStatus]int)
+		}
+		stats.ByChannel[count.Channel][count.Status] += count.Count
+		stats.ByStatus[count.Status] += count.Count
+		stats.Total += count.Count
+	}
+
+	return stats, nil
+}
+
 // Templates
 func (s *notificationService) CreateTemplate(template *domain.NotificationTemplate) (*domain.NotificationTemplate, error) {
+	if err := s.checkTemplate(template); err != nil {
+		return nil, err
+	}
+
 	template.ID = uuid.New().String()
 	template.CreatedAt = time.Now()
 	template.UpdatedAt = time.Now()
@@ -225,24 +299,39 @@ func (s *notificationService) GetTemplate(templateID string) (*domain.Notificati
 	return s.templateRepo.GetByID(templateID)
 }
 
-func (s *notificationService) UpdateTemplate(templateID string, updates map[string]interface{}) (*domain.NotificationTemplate, error) {
+func (s *notificationService) UpdateTemplate(templateID string, req domain.UpdateTemplateRequest) (*domain.NotificationTemplate, error) {
 	template, err := s.templateRepo.GetByID(templateID)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", domain.ErrTemplateNotFound, err)
 	}
 
-	// Update fields based on updates map
-	if name, ok := updates["name"].(string); ok {
-		template.Name = name
+	if req.Name != nil {
+		template.Name = *req.Name
+	}
+	if req.Type != nil {
+		template.Type = *req.Type
+	}
+	if req.Channel != nil {
+		template.Channel = *req.Channel
+	}
+	if req.Title != nil {
+		template.Title = *req.Title
 	}
-	if title, ok := updates["title"].(string); ok {
-		template.Title = title
+	if req.Message != nil {
+		template.Message = *req.Message
 	}
-	if message, ok := updates["message"].(string); ok {
-		template.Message = message
+	if req.Variables != nil {
+		template.Variables = req.Variables
 	}
-	if isActive, ok := updates["is_active"].(bool); ok {
-		template.IsActive = isActive
+	if req.Translations != nil {
+		template.Translations = req.Translations
+	}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+
+	if err := s.checkTemplate(template); err != nil {
+		return nil, err
 	}
 
 	template.UpdatedAt = time.Now()
@@ -254,22 +343,141 @@ func (s *notificationService) UpdateTemplate(templateID string, updates map[stri
 	return template, nil
 }
 
+// checkTemplate validates the template's fields and content and that no other
+// template already uses its name
+func (s *notificationService) checkTemplate(template *domain.NotificationTemplate) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" {
+		return fmt.Errorf("%w: name is required", domain.ErrInvalidTemplate)
+	}
+	if template.Type == "" {
+		return fmt.Errorf("%w: type is required", domain.ErrInvalidTemplate)
+	}
+	if !domain.IsValidChannel(template.Channel) {
+		return fmt.Errorf("%w: unknown channel %q", domain.ErrInvalidTemplate, template.Channel)
+	}
+	if err := validateTemplate(template); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidTemplate, err)
+	}
+
+	if existing, err := s.templateRepo.GetByName(template.Name); err == nil && existing.ID != template.ID {
+		return fmt.Errorf("%w: %s", domain.ErrTemplateNameTaken, template.Name)
+	}
+	return nil
+}
+
 func (s *notificationService) DeleteTemplate(templateID string) error {
 	return s.templateRepo.Delete(templateID)
 }
 
-func (s *notificationService) ListTemplates(limit, offset int) ([]domain.NotificationTemplate, error) {
-	return s.templateRepo.List(limit, offset)
+func (s *notificationService) ListTemplates(notificationType domain.NotificationType, limit, offset int) ([]domain.NotificationTemplate, error) {
+	return s.templateRepo.List(notificationType, limit, offset)
+}
+
+// BroadcastNotification sends a template, rendered per recipient in their
+// locale, or inline content to every listed user. Individual failures are
+// counted rather than aborting the broadcast.
+func (s *notificationService) BroadcastNotification(adminID string, req domain.BroadcastRequest) (*domain.BroadcastResult, error) {
+	if req.TemplateID != "" {
+		template, err := s.templateRepo.GetByID(req.TemplateID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrTemplateNotFound, err)
+		}
+		if !template.IsActive {
+			return nil, fmt.Errorf("%w: template %s is inactive", domain.ErrInvalidTemplate, template.Name)
+		}
+	} else {
+		if req.Type == "" || req.Title == "" || req.Message == "" {
+			return nil, fmt.Errorf("%w: type, title and message are required without a template", domain.ErrInvalidTemplate)
+		}
+		if !domain.IsValidChannel(req.Channel) {
+			return nil, fmt.Errorf("%w: unknown channel %q", domain.ErrInvalidTemplate, req.Channel)
+		}
+	}
+
+	result := &domain.BroadcastResult{Requested: len(req.UserIDs)}
+	for _, userID := range req.UserIDs {
+		var err error
+		if req.TemplateID != "" {
+			_, err = s.SendTemplateNotification(domain.SendTemplateNotificationRequest{
+				UserID:       userID,
+				TemplateID:   req.TemplateID,
+				Variables:    req.Variables,
+				ScheduledFor: req.ScheduledFor,
+			})
+		} else {
+			_, err = s.SendNotification(domain.SendNotificationRequest{
+				UserID:       userID,
+				Type:         req.Type,
+				Channel:      req.Channel,
+				Title:        req.Title,
+				Message:      req.Message,
+				Data:         req.Data,
+				Priority:     req.Priority,
+				ScheduledFor: req.ScheduledFor,
+			})
+		}
+		if err != nil {
+			log.Printf("Broadcast to user %s failed: %v", userID, err)
+			result.Failed++
+			result.FailedUserIDs = append(result.FailedUserIDs, userID)
+			continue
+		}
+		result.Sent++
+	}
+
+	log.Printf("Broadcast by admin %s: %d sent, %d failed", adminID, result.Sent, result.Failed)
+	return result, nil
 }
 
 // User preferences
+
+// GetUserPreferences returns a preference for every type and channel, filling
+// the ones the user never set with the enabled default
 func (s *notificationService) GetUserPreferences(userID string) ([]domain.UserPreference, error) {
-	return s.preferenceRepo.GetByUserID(userID)
+	stored, err := s.preferenceRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		notificationType domain.NotificationType
+		channel          domain.NotificationChannel
+	}
+	byKey := make(map[key]domain.UserPreference, len(stored))
+	for _, preference := range stored {
+		byKey[key{preference.Type, preference.Channel}] = preference
+	}
+
+	preferences := make([]domain.UserPreference, 0, len(domain.NotificationTypes)*len(domain.NotificationChannels))
+	for _, notificationType := range domain.NotificationTypes {
+		for _, channel := range domain.NotificationChannels {
+			if preference, ok := byKey[key{notificationType, channel}]; ok {
+				preferences = append(preferences, preference)
+				continue
+			}
+			preferences = append(preferences, domain.UserPreference{
+				UserID:    userID,
+				Type:      notificationType,
+				Channel:   channel,
+				Enabled:   true,
+				IsDefault: true,
+			})
+		}
+	}
+	return preferences, nil
 }
 
 func (s *notificationService) UpdateUserPreference(userID string, req domain.UpdatePreferenceRequest) (*domain.UserPreference, error) {
+	if !domain.IsValidType(req.Type) {
+		return nil, fmt.Errorf("%w: unknown type %q", domain.ErrInvalidPreference, req.Type)
+	}
+	if !domain.IsValidChannel(req.Channel) {
+		return nil, fmt.Errorf("%w: unknown channel %q", domain.ErrInvalidPreference, req.Channel)
+	}
+
 	// Check if preference exists
-	preference, err := s.preferenceRepo.GetByUserAndType(userID, req.Type)
+	preference, err := s.preferenceRepo.GetByUserTypeAndChannel(userID, req.Type, req.Channel)
 	if err != nil {
 		// Create new preference
 		preference = &domain.UserPreference{
@@ -297,6 +505,49 @@ func (s *notificationService) UpdateUserPreference(userID string, req domain.Upd
 	return preference, nil
 }
 
+func (s *notificationService) GetNotificationSettings(userID string) (*domain.NotificationSettings, error) {
+	settings, err := s.preferenceRepo.GetSettings(userID)
+	if err != nil {
+		// No settings stored yet: UTC with quiet hours off
+		return &domain.NotificationSettings{UserID: userID, Timezone: "UTC"}, nil
+	}
+	return settings, nil
+}
+
+func (s *notificationService) UpdateNotificationSettings(userID string, req domain.UpdateNotificationSettingsRequest) (*domain.NotificationSettings, error) {
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return nil, fmt.Errorf("invalid timezone: %s", req.Timezone)
+	}
+
+	if req.QuietHoursEnabled {
+		if _, err := time.Parse("15:04", req.QuietHoursStart); err != nil {
+			return nil, errors.New("quiet_hours_start must be in HH:MM format")
+		}
+		if _, err := time.Parse("15:04", req.QuietHoursEnd); err != nil {
+			return nil, errors.New("quiet_hours_end must be in HH:MM format")
+		}
+		if req.QuietHoursStart == req.QuietHoursEnd {
+			return nil, errors.New("quiet_hours_start and quiet_hours_end must differ")
+		}
+	}
+
+	settings := &domain.NotificationSettings{
+		UserID:            userID,
+		Timezone:          req.Timezone,
+		Locale:            req.Locale,
+		QuietHoursEnabled: req.QuietHoursEnabled,
+		QuietHoursStart:   req.QuietHoursStart,
+		QuietHoursEnd:     req.QuietHoursEnd,
+		UpdatedAt:         time.Now(),
+	}
+
+	if err := s.preferenceRepo.SaveSettings(settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
 // Device management
 func (s *notificationService) RegisterDevice(userID string, req domain.RegisterDeviceRequest) (*domain.NotificationDevice, error) {
 	// Check if device already exists
@@ -340,6 +591,64 @@ func (s *notificationService) GetUserDevices(userID string) ([]domain.Notificati
 	return s.deviceRepo.GetByUserID(userID)
 }
 
+// UpdateDevice refreshes the push token, platform or app version of one of the
+// user's devices
+func (s *notificationService) UpdateDevice(userID, deviceID string, req domain.UpdateDeviceRequest) (*domain.NotificationDevice, error) {
+	device, err := s.userDevice(userID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DeviceToken != nil && *req.DeviceToken != device.DeviceToken {
+		if *req.DeviceToken == "" {
+			return nil, fmt.Errorf("%w: device token cannot be empty", domain.ErrInvalidDevice)
+		}
+		if existing, err := s.deviceRepo.GetByToken(*req.DeviceToken); err == nil && existing.ID != device.ID {
+			return nil, domain.ErrDeviceTokenInUse
+		}
+		device.DeviceToken = *req.DeviceToken
+	}
+	if req.Platform != nil {
+		if !domain.IsValidPlatform(*req.Platform) {
+			return nil, fmt.Errorf("%w: unknown platform %q", domain.ErrInvalidDevice, *req.Platform)
+		}
+		device.Platform = *req.Platform
+	}
+	if req.AppVersion != nil {
+		device.AppVersion = *req.AppVersion
+	}
+
+	device.IsActive = true
+	device.LastActiveAt = time.Now()
+	device.UpdatedAt = time.Now()
+
+	if err := s.deviceRepo.Update(device); err != nil {
+		return nil, fmt.Errorf("failed to update device: %w", err)
+	}
+	return device, nil
+}
+
+// DeleteDevice removes one of the user's devices so its token stops receiving pushes
+func (s *notificationService) DeleteDevice(userID, deviceID string) error {
+	device, err := s.userDevice(userID, deviceID)
+	if err != nil {
+		return err
+	}
+	return s.deviceRepo.Delete(device.ID)
+}
+
+// userDevice loads a device, checking that it belongs to userID
+func (s *notificationService) userDevice(userID, deviceID string) (*domain.NotificationDevice, error) {
+	device, err := s.deviceRepo.GetByID(deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrDeviceNotFound, err)
+	}
+	if device.UserID != userID {
+		return nil, domain.ErrDeviceForbidden
+	}
+	return device, nil
+}
+
 func (s *notificationService) DeactivateDevice(userID, deviceToken string) error {
 	device, err := s.deviceRepo.GetByToken(deviceToken)
 	if err != nil {
@@ -347,7 +656,7 @@ func (s *notificationService) DeactivateDevice(userID, deviceToken string) error
 	}
 
 	if device.UserID != userID {
-		return errors.New("unauthorized")
+		return domain.ErrDeviceForbidden
 	}
 
 	device.IsActive = false
@@ -357,10 +666,52 @@ func (s *notificationService) DeactivateDevice(userID, deviceToken string) error
 }
 
 // System operations
+
+// ProcessScheduledNotifications sends the scheduled notifications that are
+// due. Each one is claimed before it is handled, so dispatchers running on
+// several replicas never send the same notification twice. Saving it
+// afterwards records the outcome, or returns it to pending when deferred.
 func (s *notificationService) ProcessScheduledNotifications() error {
-	// Get notifications scheduled for now
-	// This would typically involve a more complex query
-	// For now, this is a placeholder implementation
+	now := time.Now()
+
+	due, err := s.notificationRepo.GetDueScheduled(now, 100)
+	if err != nil {
+		return fmt.Errorf("failed to load scheduled notifications: %w", err)
+	}
+
+	for i := range due {
+		notification := &due[i]
+
+		claimed, err := s.notificationRepo.ClaimPending(notification.ID)
+		if err != nil {
+			return fmt.Errorf("failed to claim notification %s: %w", notification.ID, err)
+		}
+		if !claimed {
+			// Sent by another dispatcher, or cancelled, since it was loaded
+			continue
+		}
+
+		if notification.ExpiresAt != nil && notification.ExpiresAt.Before(now) {
+			notification.Status = domain.StatusExpired
+			notification.UpdatedAt = now
+			if err := s.notificationRepo.Update(notification); err != nil {
+				log.Printf("Failed to record %s status of notification %s: %v", notification.Status, notification.ID, err)
+			}
+			continue
+		}
+
+		s.applyPreferences(notification, now)
+		if !s.isDueForSending(notification, now) {
+			notification.UpdatedAt = now
+			if err := s.notificationRepo.Update(notification); err != nil {
+				log.Printf("Failed to record %s status of notification %s: %v", notification.Status, notification.ID, err)
+			}
+			continue
+		}
+
+		s.processNotification(notification)
+	}
+
 	return nil
 }
 
@@ -372,6 +723,120 @@ func (s *notificationService) CleanupExpiredNotifications() error {
 }
 
 // Helper methods
+
+// applyPreferences checks the recipient's channel opt-outs and quiet hours.
+// Opted-out or marketing notifications during quiet hours are suppressed;
+// other notifications during quiet hours are deferred until the window ends.
+// Critical notifications (OTP, critical priority) bypass quiet hours.
+func (s *notificationService) applyPreferences(notification *domain.Notification, now time.Time) {
+	preference, err := s.preferenceRepo.GetByUserTypeAndChannel(notification.UserID, notification.Type, notification.Channel)
+	if err == nil && !preference.Enabled {
+		notification.Status = domain.StatusSuppressed
+		notification.SuppressionReason = domain.SuppressedChannelOptOut
+		return
+	}
+
+	if notification.Type == domain.TypeOTP || notification.Priority == domain.PriorityCritical {
+		return
+	}
+
+	settings, err := s.preferenceRepo.GetSettings(notification.UserID)
+	if err != nil {
+		return
+	}
+
+	quietEnd, inQuietHours := quietHoursEnd(settings, now)
+	if !inQuietHours {
+		return
+	}
+
+	if notification.Type == domain.TypePromotion {
+		notification.Status = domain.StatusSuppressed
+		notification.SuppressionReason = domain.SuppressedQuietHours
+		return
+	}
+
+	notification.ScheduledFor = &quietEnd
+}
+
+// publishUnreadCount pushes the user's current unread count to any open streams
+func (s *notificationService) publishUnreadCount(userID string) {
+	if !s.unread.hasSubscribers(userID) {
+		return
+	}
+
+	count, err := s.notificationRepo.GetUnreadCount(userID)
+	if err != nil {
+		return
+	}
+	s.unread.publish(userID, count)
+}
+
+// userLocale returns the recipient's preferred locale, or the service default
+func (s *notificationService) userLocale(userID string) string {
+	settings, err := s.preferenceRepo.GetSettings(userID)
+	if err != nil || settings.Locale == "" {
+		return s.defaultLocale
+	}
+	return settings.Locale
+}
+
+// isDueForSending reports whether a notification should be sent right away
+func (s *notificationService) isDueForSending(notification *domain.Notification, now time.Time) bool {
+	if notification.Status != domain.StatusPending {
+		return false
+	}
+	return notification.ScheduledFor == nil || !notification.ScheduledFor.After(now)
+}
+
+// quietHoursEnd returns when the user's current quiet-hours window ends, and
+// whether now falls inside such a window. Windows may wrap past midnight;
+// a window whose start equals its end is treated as disabled.
+func quietHoursEnd(settings *domain.NotificationSettings, now time.Time) (time.Time, bool) {
+	if !settings.QuietHoursEnabled {
+		return time.Time{}, false
+	}
+
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	start, err := time.Parse("15:04", settings.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := time.Parse("15:04", settings.QuietHoursEnd)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	if start.Equal(end) {
+		return time.Time{}, false
+	}
+
+	local := now.In(loc)
+	startAt := time.Date(local.Year(), local.Month(), local.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+	endAt := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+
+	if !endAt.After(startAt) {
+		// Window wraps midnight, e.g. 22:00-07:00
+		if local.Before(endAt) {
+			return endAt, true
+		}
+		if !local.Before(startAt) {
+			return endAt.AddDate(0, 0, 1), true
+		}
+		return time.Time{}, false
+	}
+
+	if !local.Before(startAt) && local.Before(endAt) {
+		return endAt, true
+	}
+
+	return time.Time{}, false
+}
+
 func (s *notificationService) processNotification(notification *domain.Notification) {
 	var err error
 
@@ -397,7 +862,14 @@ func (s *notificationService) processNotification(notification *domain.Notificat
 	}
 
 	notification.UpdatedAt = time.Now()
-	s.notificationRepo.Update(notification)
+	if err := s.notificationRepo.Update(notification); err != nil {
+		log.Printf("Failed to record %s status of notification %s: %v", notification.Status, notification.ID, err)
+		return
+	}
+	// It counts as unread from the moment it is sent
+	if notification.Status == domain.StatusSent {
+		s.publishUnreadCount(notification.UserID)
+	}
 }
 
 func (s *notificationService) sendSMS(notification *domain.Notification) error {
@@ -445,12 +917,3 @@ func (s *notificationService) sendPush(notification *domain.Notification) error
 
 	return s.pushService.SendPushNotification(deviceTokens, notification.Title, notification.Message, notification.Data)
 }
-
-func (s *notificationService) replaceVariables(template string, variables map[string]string) string {
-	result := template
-	for key, value := range variables {
-		placeholder := fmt.Sprintf("{{%s}}", key)
-		result = fmt.Sprintf(result, placeholder, value)
-	}
-	return result
-}
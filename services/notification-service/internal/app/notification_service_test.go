@@ -0,0 +1,966 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"glovo-backend/services/notification-service/internal/domain"
+)
+
+// fakeNotificationRepo is shared with the send goroutines, so it hands out copies
+type fakeNotificationRepo struct {
+	domain.NotificationRepository
+	mu            sync.Mutex
+	notifications map[string]domain.Notification
+}
+
+func (r *fakeNotificationRepo) Create(notification *domain.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications[notification.ID] = *notification
+	return nil
+}
+
+func (r *fakeNotificationRepo) GetByID(id string) (*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notification, ok := r.notifications[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &notification, nil
+}
+
+func (r *fakeNotificationRepo) Update(notification *domain.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications[notification.ID] = *notification
+	return nil
+}
+
+func (r *fakeNotificationRepo) GetUnreadCount(userID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, notification := range r.notifications {
+		if notification.UserID == userID && unread(notification.Status) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeNotificationRepo) MarkAsRead(id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notification, ok := r.notifications[id]
+	if !ok || !unread(notification.Status) {
+		return false, nil
+	}
+	now := time.Now()
+	notification.Status = domain.StatusRead
+	notification.ReadAt = &now
+	r.notifications[id] = notification
+	return true, nil
+}
+
+func (r *fakeNotificationRepo) MarkAllAsRead(userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, notification := range r.notifications {
+		if notification.UserID == userID && unread(notification.Status) {
+			notification.Status = domain.StatusRead
+			r.notifications[id] = notification
+		}
+	}
+	return nil
+}
+
+func (r *fakeNotificationRepo) GetDueScheduled(before time.Time, limit int) ([]domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []domain.Notification
+	for _, notification := range r.notifications {
+		if notification.Status == domain.StatusPending && notification.ScheduledFor != nil && !notification.ScheduledFor.After(before) {
+			due = append(due, notification)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeNotificationRepo) ClaimPending(id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notification, ok := r.notifications[id]
+	if !ok || notification.Status != domain.StatusPending {
+		return false, nil
+	}
+	notification.Status = domain.StatusProcessing
+	r.notifications[id] = notification
+	return true, nil
+}
+
+func (r *fakeNotificationRepo) CountByChannelAndStatus(start, end time.Time) ([]domain.ChannelStatusCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	type key struct {
+		channel domain.NotificationChannel
+		status  domain.NotificationStatus
+	}
+	counts := make(map[key]int)
+	for _, notification := range r.notifications {
+		if !start.IsZero() && notification.CreatedAt.Before(start) {
+			continue
+		}
+		if !end.IsZero() && !notification.CreatedAt.Before(end) {
+			continue
+		}
+		counts[key{notification.Channel, notification.Status}]++
+	}
+	var result []domain.ChannelStatusCount
+	for k, count := range counts {
+		result = append(result, domain.ChannelStatusCount{Channel: k.channel, Status: k.status, Count: count})
+	}
+	return result, nil
+}
+
+// unread mirrors the status IN domain.UnreadStatuses filter
+func unread(status domain.NotificationStatus) bool {
+	for _, s := range domain.UnreadStatuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeNotificationRepo) get(id string) domain.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.notifications[id]
+}
+
+type fakeTemplateRepo struct {
+	domain.TemplateRepository
+	templates map[string]domain.NotificationTemplate
+}
+
+func (r *fakeTemplateRepo) Create(template *domain.NotificationTemplate) error {
+	r.templates[template.ID] = *template
+	return nil
+}
+
+func (r *fakeTemplateRepo) GetByID(id string) (*domain.NotificationTemplate, error) {
+	template, ok := r.templates[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &template, nil
+}
+
+func (r *fakeTemplateRepo) GetByName(name string) (*domain.NotificationTemplate, error) {
+	for _, template := range r.templates {
+		if template.Name == name {
+			return &template, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *fakeTemplateRepo) Update(template *domain.NotificationTemplate) error {
+	r.templates[template.ID] = *template
+	return nil
+}
+
+type preferenceKey struct {
+	userID           string
+	notificationType domain.NotificationType
+	channel          domain.NotificationChannel
+}
+
+type fakePreferenceRepo struct {
+	domain.PreferenceRepository
+	preferences map[preferenceKey]domain.UserPreference
+	settings    map[string]domain.NotificationSettings
+}
+
+func (r *fakePreferenceRepo) Create(preference *domain.UserPreference) error {
+	r.preferences[preferenceKey{preference.UserID, preference.Type, preference.Channel}] = *preference
+	return nil
+}
+
+func (r *fakePreferenceRepo) Update(preference *domain.UserPreference) error {
+	return r.Create(preference)
+}
+
+func (r *fakePreferenceRepo) GetByUserID(userID string) ([]domain.UserPreference, error) {
+	var preferences []domain.UserPreference
+	for key, preference := range r.preferences {
+		if key.userID == userID {
+			preferences = append(preferences, preference)
+		}
+	}
+	return preferences, nil
+}
+
+func (r *fakePreferenceRepo) GetByUserTypeAndChannel(userID string, notificationType domain.NotificationType, channel domain.NotificationChannel) (*domain.UserPreference, error) {
+	preference, ok := r.preferences[preferenceKey{userID, notificationType, channel}]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &preference, nil
+}
+
+func (r *fakePreferenceRepo) GetSettings(userID string) (*domain.NotificationSettings, error) {
+	settings, ok := r.settings[userID]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &settings, nil
+}
+
+func (r *fakePreferenceRepo) SaveSettings(settings *domain.NotificationSettings) error {
+	r.settings[settings.UserID] = *settings
+	return nil
+}
+
+type fakeDeviceRepo struct {
+	domain.DeviceRepository
+	mu      sync.Mutex
+	devices map[string]domain.NotificationDevice
+}
+
+func (r *fakeDeviceRepo) Create(device *domain.NotificationDevice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.devices[device.ID] = *device
+	return nil
+}
+
+func (r *fakeDeviceRepo) GetByID(id string) (*domain.NotificationDevice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	device, ok := r.devices[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &device, nil
+}
+
+func (r *fakeDeviceRepo) GetByUserID(userID string) ([]domain.NotificationDevice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var devices []domain.NotificationDevice
+	for _, device := range r.devices {
+		if device.UserID == userID {
+			devices = append(devices, device)
+		}
+	}
+	return devices, nil
+}
+
+func (r *fakeDeviceRepo) GetByToken(deviceToken string) (*domain.NotificationDevice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, device := range r.devices {
+		if device.DeviceToken == deviceToken {
+			return &device, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *fakeDeviceRepo) Update(device *domain.NotificationDevice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.devices[device.ID] = *device
+	return nil
+}
+
+func (r *fakeDeviceRepo) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.devices, id)
+	return nil
+}
+
+// fakeBulkJobRepo is shared with the bulk worker goroutine, so it hands out copies
+type fakeBulkJobRepo struct {
+	domain.BulkJobRepository
+	mu      sync.Mutex
+	jobs    map[string]domain.BulkJob
+	updates int
+}
+
+func (r *fakeBulkJobRepo) Create(job *domain.BulkJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *fakeBulkJobRepo) GetByID(id string) (*domain.BulkJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return &job, nil
+}
+
+func (r *fakeBulkJobRepo) Update(job *domain.BulkJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.FailedUserIDs = append([]string(nil), job.FailedUserIDs...)
+	r.jobs[job.ID] = *job
+	r.updates++
+	return nil
+}
+
+type sentPush struct {
+	tokens         []string
+	title, message string
+}
+
+type fakePushService struct {
+	domain.PushNotificationService
+	mu   sync.Mutex
+	sent []sentPush
+}
+
+func (s *fakePushService) SendPushNotification(deviceTokens []string, title, message string, data map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, sentPush{tokens: deviceTokens, title: title, message: message})
+	return nil
+}
+
+func (s *fakePushService) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+type sentSMS struct {
+	phone, message string
+}
+
+type fakeSMSService struct {
+	domain.SMSService
+	mu   sync.Mutex
+	sent []sentSMS
+}
+
+func (s *fakeSMSService) SendSMS(phoneNumber, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, sentSMS{phone: phoneNumber, message: message})
+	return nil
+}
+
+func (s *fakeSMSService) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+type fakeEmailService struct {
+	domain.EmailService
+}
+
+// notificationFakes are the repositories and senders behind a test service
+type notificationFakes struct {
+	notifications *fakeNotificationRepo
+	templates     *fakeTemplateRepo
+	preferences   *fakePreferenceRepo
+	devices       *fakeDeviceRepo
+	bulkJobs      *fakeBulkJobRepo
+	push          *fakePushService
+	sms           *fakeSMSService
+}
+
+func newTestNotificationService(rateLimits domain.RateLimitConfig) (*notificationService, *notificationFakes) {
+	fakes := &notificationFakes{
+		notifications: &fakeNotificationRepo{notifications: make(map[string]domain.Notification)},
+		templates:     &fakeTemplateRepo{templates: make(map[string]domain.NotificationTemplate)},
+		preferences: &fakePreferenceRepo{
+			preferences: make(map[preferenceKey]domain.UserPreference),
+			settings:    make(map[string]domain.NotificationSettings),
+		},
+		devices:  &fakeDeviceRepo{devices: make(map[string]domain.NotificationDevice)},
+		bulkJobs: &fakeBulkJobRepo{jobs: make(map[string]domain.BulkJob)},
+		push:     &fakePushService{},
+		sms:      &fakeSMSService{},
+	}
+	svc := NewNotificationService(
+		fakes.notifications, fakes.templates, fakes.preferences, fakes.devices, fakes.bulkJobs,
+		fakes.push, fakes.sms, &fakeEmailService{}, rateLimits, "en",
+	).(*notificationService)
+	return svc, fakes
+}
+
+// waitForStatus polls until the notification, sent in the background, reaches want
+func waitForStatus(t *testing.T, repo *fakeNotificationRepo, id string, want domain.NotificationStatus) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		got := repo.get(id).Status
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("notification status = %s, want %s", got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func smsRequest(userID string, notificationType domain.NotificationType) domain.SendNotificationRequest {
+	return domain.SendNotificationRequest{
+		UserID:  userID,
+		Type:    notificationType,
+		Channel: domain.ChannelSMS,
+		Title:   "Hello",
+		Message: "Your order is on its way",
+		Data:    map[string]string{"phone": "+34600000000"},
+	}
+}
+
+func TestScheduledNotificationDispatch(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+
+	sendAt := time.Now().Add(50 * time.Millisecond)
+	req := smsRequest("user-1", domain.TypeReminder)
+	req.ScheduledFor = &sendAt
+
+	notification, err := svc.SendNotification(req)
+	if err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+	if notification.Status != domain.StatusPending {
+		t.Fatalf("status = %s, want %s", notification.Status, domain.StatusPending)
+	}
+
+	if err := svc.ProcessScheduledNotifications(); err != nil {
+		t.Fatalf("ProcessScheduledNotifications: %v", err)
+	}
+	if fakes.sms.count() != 0 {
+		t.Fatalf("sent %d SMS before the scheduled time", fakes.sms.count())
+	}
+	if got := fakes.notifications.get(notification.ID).Status; got != domain.StatusPending {
+		t.Fatalf("status before the scheduled time = %s, want %s", got, domain.StatusPending)
+	}
+
+	time.Sleep(time.Until(sendAt) + 10*time.Millisecond)
+	if err := svc.ProcessScheduledNotifications(); err != nil {
+		t.Fatalf("ProcessScheduledNotifications: %v", err)
+	}
+	if fakes.sms.count() != 1 {
+		t.Fatalf("sent %d SMS after the scheduled time, want 1", fakes.sms.count())
+	}
+	sent := fakes.notifications.get(notification.ID)
+	if sent.Status != domain.StatusSent || sent.SentAt == nil {
+		t.Fatalf("status = %s, sent at %v; want sent", sent.Status, sent.SentAt)
+	}
+
+	// A second dispatcher run must not resend
+	if err := svc.ProcessScheduledNotifications(); err != nil {
+		t.Fatalf("ProcessScheduledNotifications: %v", err)
+	}
+	if fakes.sms.count() != 1 {
+		t.Fatalf("sent %d SMS after a second run, want 1", fakes.sms.count())
+	}
+}
+
+// staleDueRepo returns the due notifications it was given, like a replica
+// that loaded them just before another one sent them
+type staleDueRepo struct {
+	*fakeNotificationRepo
+	due []domain.Notification
+}
+
+func (r *staleDueRepo) GetDueScheduled(before time.Time, limit int) ([]domain.Notification, error) {
+	return r.due, nil
+}
+
+func TestScheduledNotificationClaimedOnce(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+
+	sendAt := time.Now().Add(-time.Minute)
+	req := smsRequest("user-1", domain.TypeReminder)
+	req.ScheduledFor = &sendAt
+	notification, err := svc.SendNotification(req)
+	if err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+
+	due, err := fakes.notifications.GetDueScheduled(time.Now(), 100)
+	if err != nil || len(due) != 1 {
+		t.Fatalf("due = %v, %v; want the scheduled notification", due, err)
+	}
+	replica := NewNotificationService(
+		&staleDueRepo{fakeNotificationRepo: fakes.notifications, due: due}, fakes.templates, fakes.preferences, fakes.devices, fakes.bulkJobs,
+		fakes.push, fakes.sms, &fakeEmailService{}, domain.RateLimitConfig{}, "en",
+	)
+
+	if err := svc.ProcessScheduledNotifications(); err != nil {
+		t.Fatalf("ProcessScheduledNotifications: %v", err)
+	}
+	if err := replica.ProcessScheduledNotifications(); err != nil {
+		t.Fatalf("replica ProcessScheduledNotifications: %v", err)
+	}
+	if fakes.sms.count() != 1 {
+		t.Fatalf("sent %d SMS from two dispatchers, want 1", fakes.sms.count())
+	}
+	if got := fakes.notifications.get(notification.ID).Status; got != domain.StatusSent {
+		t.Fatalf("status = %s, want %s", got, domain.StatusSent)
+	}
+}
+
+func TestCancelScheduledNotification(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+
+	sendAt := time.Now().Add(20 * time.Millisecond)
+	req := smsRequest("user-1", domain.TypeReminder)
+	req.ScheduledFor = &sendAt
+	scheduled, err := svc.SendNotification(req)
+	if err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+
+	if err := svc.CancelScheduledNotification(scheduled.ID); err != nil {
+		t.Fatalf("CancelScheduledNotification: %v", err)
+	}
+	if got := fakes.notifications.get(scheduled.ID).Status; got != domain.StatusCancelled {
+		t.Fatalf("status = %s, want %s", got, domain.StatusCancelled)
+	}
+
+	time.Sleep(time.Until(sendAt) + 10*time.Millisecond)
+	if err := svc.ProcessScheduledNotifications(); err != nil {
+		t.Fatalf("ProcessScheduledNotifications: %v", err)
+	}
+	if fakes.sms.count() != 0 {
+		t.Fatalf("sent %d SMS for a cancelled notification", fakes.sms.count())
+	}
+
+	// Notifications that were sent immediately or already cancelled can't be cancelled
+	immediate, err := svc.SendNotification(smsRequest("user-1", domain.TypeReminder))
+	if err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+	waitForStatus(t, fakes.notifications, immediate.ID, domain.StatusSent)
+	for _, id := range []string{immediate.ID, scheduled.ID} {
+		if err := svc.CancelScheduledNotification(id); err == nil {
+			t.Fatalf("cancelled notification %s, want an error", id)
+		}
+	}
+}
+
+// quietNow returns settings whose quiet hours cover the current time in UTC
+func quietNow(userID string) domain.NotificationSettings {
+	now := time.Now().UTC()
+	return domain.NotificationSettings{
+		UserID:            userID,
+		Timezone:          "UTC",
+		QuietHoursEnabled: true,
+		QuietHoursStart:   now.Add(-time.Hour).Format("15:04"),
+		QuietHoursEnd:     now.Add(time.Hour).Format("15:04"),
+	}
+}
+
+func TestSendNotificationPreferences(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          domain.SendNotificationRequest
+		optOut       bool
+		quietHours   bool
+		wantStatus   domain.NotificationStatus
+		wantReason   string
+		wantDeferred bool
+		wantSMS      int
+	}{
+		{
+			name:       "marketing during quiet hours",
+			req:        smsRequest("user-1", domain.TypePromotion),
+			quietHours: true,
+			wantStatus: domain.StatusSuppressed,
+			wantReason: domain.SuppressedQuietHours,
+		},
+		{
+			name:       "otp during quiet hours",
+			req:        smsRequest("user-1", domain.TypeOTP),
+			quietHours: true,
+			wantStatus: domain.StatusSent,
+			wantSMS:    1,
+		},
+		{
+			name:         "order update during quiet hours",
+			req:          smsRequest("user-1", domain.TypeOrderUpdate),
+			quietHours:   true,
+			wantStatus:   domain.StatusPending,
+			wantDeferred: true,
+		},
+		{
+			name:       "channel opted out",
+			req:        smsRequest("user-1", domain.TypeOrderUpdate),
+			optOut:     true,
+			wantStatus: domain.StatusSuppressed,
+			wantReason: domain.SuppressedChannelOptOut,
+		},
+		{
+			name:       "outside quiet hours",
+			req:        smsRequest("user-1", domain.TypePromotion),
+			wantStatus: domain.StatusSent,
+			wantSMS:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+			if tt.quietHours {
+				settings := quietNow(tt.req.UserID)
+				fakes.preferences.SaveSettings(&settings)
+			}
+			if tt.optOut {
+				fakes.preferences.Create(&domain.UserPreference{UserID: tt.req.UserID, Type: tt.req.Type, Channel: tt.req.Channel})
+			}
+
+			notification, err := svc.SendNotification(tt.req)
+			if err != nil {
+				t.Fatalf("SendNotification: %v", err)
+			}
+			waitForStatus(t, fakes.notifications, notification.ID, tt.wantStatus)
+
+			stored := fakes.notifications.get(notification.ID)
+			if stored.SuppressionReason != tt.wantReason {
+				t.Fatalf("suppression reason = %q, want %q", stored.SuppressionReason, tt.wantReason)
+			}
+			if deferred := stored.ScheduledFor != nil && stored.ScheduledFor.After(time.Now()); deferred != tt.wantDeferred {
+				t.Fatalf("deferred = %v (scheduled for %v), want %v", deferred, stored.ScheduledFor, tt.wantDeferred)
+			}
+			if fakes.sms.count() != tt.wantSMS {
+				t.Fatalf("sent %d SMS, want %d", fakes.sms.count(), tt.wantSMS)
+			}
+		})
+	}
+}
+
+func TestQuietHoursEnd(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	overnight := &domain.NotificationSettings{Timezone: "Europe/Madrid", QuietHoursEnabled: true, QuietHoursStart: "22:00", QuietHoursEnd: "07:00"}
+	daytime := &domain.NotificationSettings{Timezone: "Europe/Madrid", QuietHoursEnabled: true, QuietHoursStart: "13:00", QuietHoursEnd: "15:00"}
+
+	tests := []struct {
+		name      string
+		settings  *domain.NotificationSettings
+		now       time.Time
+		wantEnd   time.Time
+		wantQuiet bool
+	}{
+		{name: "before midnight", settings: overnight, now: time.Date(2026, 3, 10, 23, 0, 0, 0, madrid), wantEnd: time.Date(2026, 3, 11, 7, 0, 0, 0, madrid), wantQuiet: true},
+		{name: "after midnight", settings: overnight, now: time.Date(2026, 3, 11, 6, 59, 0, 0, madrid), wantEnd: time.Date(2026, 3, 11, 7, 0, 0, 0, madrid), wantQuiet: true},
+		{name: "in the user's timezone", settings: overnight, now: time.Date(2026, 3, 10, 21, 30, 0, 0, time.UTC), wantEnd: time.Date(2026, 3, 11, 7, 0, 0, 0, madrid), wantQuiet: true},
+		{name: "daytime", settings: overnight, now: time.Date(2026, 3, 10, 12, 0, 0, 0, madrid)},
+		{name: "window end", settings: overnight, now: time.Date(2026, 3, 11, 7, 0, 0, 0, madrid)},
+		{name: "same-day window", settings: daytime, now: time.Date(2026, 3, 10, 14, 0, 0, 0, madrid), wantEnd: time.Date(2026, 3, 10, 15, 0, 0, 0, madrid), wantQuiet: true},
+		{name: "disabled", settings: &domain.NotificationSettings{Timezone: "UTC", QuietHoursStart: "00:00", QuietHoursEnd: "23:59"}, now: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)},
+		{name: "equal start and end", settings: &domain.NotificationSettings{Timezone: "UTC", QuietHoursEnabled: true, QuietHoursStart: "22:00", QuietHoursEnd: "22:00"}, now: time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, quiet := quietHoursEnd(tt.settings, tt.now)
+			if quiet != tt.wantQuiet {
+				t.Fatalf("quiet = %v, want %v", quiet, tt.wantQuiet)
+			}
+			if quiet && !end.Equal(tt.wantEnd) {
+				t.Fatalf("end = %v, want %v", end, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestMarkNotificationAsRead(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+	for _, notification := range []domain.Notification{
+		{ID: "mine-1", UserID: "user-1", Status: domain.StatusSent},
+		{ID: "mine-2", UserID: "user-1", Status: domain.StatusSent},
+		{ID: "theirs", UserID: "user-2", Status: domain.StatusSent},
+	} {
+		fakes.notifications.Create(&notification)
+	}
+
+	if err := svc.MarkNotificationAsRead("theirs", "user-1"); !errors.Is(err, domain.ErrNotificationForbidden) {
+		t.Fatalf("expected %v, got %v", domain.ErrNotificationForbidden, err)
+	}
+	if got := fakes.notifications.get("theirs").Status; got != domain.StatusSent {
+		t.Fatalf("another user's notification status = %s, want %s", got, domain.StatusSent)
+	}
+
+	if err := svc.MarkNotificationAsRead("mine-1", "user-1"); err != nil {
+		t.Fatalf("MarkNotificationAsRead: %v", err)
+	}
+	if read := fakes.notifications.get("mine-1"); read.Status != domain.StatusRead || read.ReadAt == nil {
+		t.Fatalf("status = %s, read at %v; want read", read.Status, read.ReadAt)
+	}
+	if count, _ := svc.GetUnreadCount("user-1"); count != 1 {
+		t.Fatalf("unread count = %d, want 1", count)
+	}
+
+	if err := svc.MarkAllNotificationsAsRead("user-1"); err != nil {
+		t.Fatalf("MarkAllNotificationsAsRead: %v", err)
+	}
+	if count, _ := svc.GetUnreadCount("user-1"); count != 0 {
+		t.Fatalf("unread count after read-all = %d, want 0", count)
+	}
+	if count, _ := svc.GetUnreadCount("user-2"); count != 1 {
+		t.Fatalf("another user's unread count = %d, want 1", count)
+	}
+}
+
+func TestReadSkipsUndeliveredNotifications(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+	later := time.Now().Add(time.Hour)
+	for _, notification := range []domain.Notification{
+		{ID: "sent", UserID: "user-1", Status: domain.StatusSent},
+		{ID: "delivered", UserID: "user-1", Status: domain.StatusDelivered},
+		{ID: "scheduled", UserID: "user-1", Status: domain.StatusPending, ScheduledFor: &later},
+		{ID: "suppressed", UserID: "user-1", Status: domain.StatusSuppressed},
+		{ID: "cancelled", UserID: "user-1", Status: domain.StatusCancelled},
+		{ID: "expired", UserID: "user-1", Status: domain.StatusExpired},
+		{ID: "failed", UserID: "user-1", Status: domain.StatusFailed},
+	} {
+		fakes.notifications.Create(&notification)
+	}
+
+	// Only what reached the user counts towards the badge
+	if count, _ := svc.GetUnreadCount("user-1"); count != 2 {
+		t.Fatalf("unread count = %d, want 2", count)
+	}
+
+	for _, id := range []string{"scheduled", "suppressed", "cancelled", "expired", "failed"} {
+		before := fakes.notifications.get(id).Status
+		if err := svc.MarkNotificationAsRead(id, "user-1"); !errors.Is(err, domain.ErrNotificationNotDelivered) {
+			t.Fatalf("%s: error = %v, want %v", id, err, domain.ErrNotificationNotDelivered)
+		}
+		if got := fakes.notifications.get(id).Status; got != before {
+			t.Fatalf("%s: status = %s, want %s", id, got, before)
+		}
+	}
+
+	if err := svc.MarkAllNotificationsAsRead("user-1"); err != nil {
+		t.Fatalf("MarkAllNotificationsAsRead: %v", err)
+	}
+	for id, want := range map[string]domain.NotificationStatus{
+		"sent":       domain.StatusRead,
+		"delivered":  domain.StatusRead,
+		"scheduled":  domain.StatusPending,
+		"suppressed": domain.StatusSuppressed,
+	} {
+		if got := fakes.notifications.get(id).Status; got != want {
+			t.Fatalf("%s: status after read-all = %s, want %s", id, got, want)
+		}
+	}
+	if count, _ := svc.GetUnreadCount("user-1"); count != 0 {
+		t.Fatalf("unread count after read-all = %d, want 0", count)
+	}
+
+	// The scheduled notification is still sent when due, and can then be read
+	if due, _ := fakes.notifications.GetDueScheduled(later, 10); len(due) != 1 || due[0].ID != "scheduled" {
+		t.Fatalf("due scheduled = %v, want the scheduled notification", due)
+	}
+
+	// Reading twice is harmless
+	if err := svc.MarkNotificationAsRead("sent", "user-1"); err != nil {
+		t.Fatalf("MarkNotificationAsRead on a read notification: %v", err)
+	}
+}
+
+func TestGetDeliveryStats(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+	day := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	seed := []struct {
+		channel   domain.NotificationChannel
+		status    domain.NotificationStatus
+		createdAt time.Time
+	}{
+		{domain.ChannelPush, domain.StatusSent, day},
+		{domain.ChannelPush, domain.StatusSent, day},
+		{domain.ChannelPush, domain.StatusFailed, day},
+		{domain.ChannelPush, domain.StatusRead, day},
+		{domain.ChannelSMS, domain.StatusSent, day},
+		{domain.ChannelSMS, domain.StatusFailed, day},
+		{domain.ChannelEmail, domain.StatusRead, day},
+		{domain.ChannelPush, domain.StatusSent, day.AddDate(0, 0, -5)},
+	}
+	for i, n := range seed {
+		fakes.notifications.Create(&domain.Notification{
+			ID: fmt.Sprintf("notification-%d", i), UserID: "user-1", Channel: n.channel, Status: n.status, CreatedAt: n.createdAt,
+		})
+	}
+
+	stats, err := svc.GetDeliveryStats(day.AddDate(0, 0, -1), day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetDeliveryStats: %v", err)
+	}
+	if stats.Total != 7 {
+		t.Fatalf("total = %d, want 7", stats.Total)
+	}
+	wantByStatus := map[domain.NotificationStatus]int{domain.StatusSent: 3, domain.StatusFailed: 2, domain.StatusRead: 2}
+	for status, want := range wantByStatus {
+		if got := stats.ByStatus[status]; got != want {
+			t.Fatalf("%s = %d, want %d", status, got, want)
+		}
+	}
+	wantByChannel := map[domain.NotificationChannel]map[domain.NotificationStatus]int{
+		domain.ChannelPush:  {domain.StatusSent: 2, domain.StatusFailed: 1, domain.StatusRead: 1},
+		domain.ChannelSMS:   {domain.StatusSent: 1, domain.StatusFailed: 1},
+		domain.ChannelEmail: {domain.StatusRead: 1},
+	}
+	for channel, statuses := range wantByChannel {
+		for status, want := range statuses {
+			if got := stats.ByChannel[channel][status]; got != want {
+				t.Fatalf("%s %s = %d, want %d", channel, status, got, want)
+			}
+		}
+	}
+	if stats.StartDate == nil || stats.EndDate == nil {
+		t.Fatalf("date range not reported: %v - %v", stats.StartDate, stats.EndDate)
+	}
+
+	all, err := svc.GetDeliveryStats(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("GetDeliveryStats: %v", err)
+	}
+	if all.Total != 8 || all.StartDate != nil || all.EndDate != nil {
+		t.Fatalf("unfiltered total = %d, range %v - %v; want 8 and no range", all.Total, all.StartDate, all.EndDate)
+	}
+}
+
+func TestUserPreferences(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+
+	find := func(preferences []domain.UserPreference, notificationType domain.NotificationType, channel domain.NotificationChannel) domain.UserPreference {
+		t.Helper()
+		for _, preference := range preferences {
+			if preference.Type == notificationType && preference.Channel == channel {
+				return preference
+			}
+		}
+		t.Fatalf("no %s preference on %s", notificationType, channel)
+		return domain.UserPreference{}
+	}
+
+	defaults, err := svc.GetUserPreferences("user-1")
+	if err != nil {
+		t.Fatalf("GetUserPreferences: %v", err)
+	}
+	if want := len(domain.NotificationTypes) * len(domain.NotificationChannels); len(defaults) != want {
+		t.Fatalf("%d default preferences, want %d", len(defaults), want)
+	}
+	for _, preference := range defaults {
+		if !preference.Enabled || !preference.IsDefault {
+			t.Fatalf("default preference = %+v, want enabled default", preference)
+		}
+	}
+
+	if _, err := svc.UpdateUserPreference("user-1", domain.UpdatePreferenceRequest{Type: domain.TypePromotion, Channel: domain.ChannelPush}); err != nil {
+		t.Fatalf("UpdateUserPreference: %v", err)
+	}
+	preferences, err := svc.GetUserPreferences("user-1")
+	if err != nil {
+		t.Fatalf("GetUserPreferences: %v", err)
+	}
+	if promo := find(preferences, domain.TypePromotion, domain.ChannelPush); promo.Enabled || promo.IsDefault {
+		t.Fatalf("updated preference = %+v, want stored and disabled", promo)
+	}
+	if email := find(preferences, domain.TypePromotion, domain.ChannelEmail); !email.Enabled || !email.IsDefault {
+		t.Fatalf("untouched preference = %+v, want enabled default", email)
+	}
+
+	// Updating again changes the stored row rather than adding one
+	if _, err := svc.UpdateUserPreference("user-1", domain.UpdatePreferenceRequest{Type: domain.TypePromotion, Channel: domain.ChannelPush, Enabled: true}); err != nil {
+		t.Fatalf("UpdateUserPreference: %v", err)
+	}
+	if stored, _ := fakes.preferences.GetByUserID("user-1"); len(stored) != 1 || !stored[0].Enabled {
+		t.Fatalf("stored preferences = %+v, want one enabled", stored)
+	}
+
+	for _, req := range []domain.UpdatePreferenceRequest{
+		{Type: domain.TypePromotion, Channel: "fax"},
+		{Type: "gossip", Channel: domain.ChannelPush},
+	} {
+		if _, err := svc.UpdateUserPreference("user-1", req); !errors.Is(err, domain.ErrInvalidPreference) {
+			t.Fatalf("%+v: expected %v, got %v", req, domain.ErrInvalidPreference, err)
+		}
+	}
+}
+
+func TestUpdateAndDeleteDevice(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+	for _, device := range []domain.NotificationDevice{
+		{ID: "device-1", UserID: "user-1", DeviceToken: "old-token", Platform: domain.PlatformIOS, IsActive: true},
+		{ID: "device-2", UserID: "user-2", DeviceToken: "their-token", Platform: domain.PlatformAndroid, IsActive: true},
+	} {
+		fakes.devices.Create(&device)
+	}
+
+	token, platform := "new-token", domain.PlatformAndroid
+	updated, err := svc.UpdateDevice("user-1", "device-1", domain.UpdateDeviceRequest{DeviceToken: &token, Platform: &platform})
+	if err != nil {
+		t.Fatalf("UpdateDevice: %v", err)
+	}
+	if updated.DeviceToken != "new-token" || updated.Platform != domain.PlatformAndroid {
+		t.Fatalf("updated device = %+v", updated)
+	}
+	if stored, _ := fakes.devices.GetByToken("new-token"); stored == nil || stored.ID != "device-1" {
+		t.Fatalf("refreshed token not stored: %+v", stored)
+	}
+
+	taken, unknown, empty := "their-token", domain.DevicePlatform("blackberry"), ""
+	tests := []struct {
+		name     string
+		userID   string
+		deviceID string
+		req      domain.UpdateDeviceRequest
+		wantErr  error
+	}{
+		{name: "another user's device", userID: "user-1", deviceID: "device-2", req: domain.UpdateDeviceRequest{DeviceToken: &token}, wantErr: domain.ErrDeviceForbidden},
+		{name: "missing device", userID: "user-1", deviceID: "device-9", req: domain.UpdateDeviceRequest{DeviceToken: &token}, wantErr: domain.ErrDeviceNotFound},
+		{name: "token registered elsewhere", userID: "user-1", deviceID: "device-1", req: domain.UpdateDeviceRequest{DeviceToken: &taken}, wantErr: domain.ErrDeviceTokenInUse},
+		{name: "unknown platform", userID: "user-1", deviceID: "device-1", req: domain.UpdateDeviceRequest{Platform: &unknown}, wantErr: domain.ErrInvalidDevice},
+		{name: "empty token", userID: "user-1", deviceID: "device-1", req: domain.UpdateDeviceRequest{DeviceToken: &empty}, wantErr: domain.ErrInvalidDevice},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.UpdateDevice(tt.userID, tt.deviceID, tt.req); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+	if theirs, _ := fakes.devices.GetByID("device-2"); theirs.DeviceToken != "their-token" {
+		t.Fatalf("another user's device token changed to %q", theirs.DeviceToken)
+	}
+
+	if err := svc.DeleteDevice("user-1", "device-2"); !errors.Is(err, domain.ErrDeviceForbidden) {
+		t.Fatalf("delete another user's device: expected %v, got %v", domain.ErrDeviceForbidden, err)
+	}
+	if _, err := fakes.devices.GetByID("device-2"); err != nil {
+		t.Fatalf("another user's device was deleted")
+	}
+	if err := svc.DeleteDevice("user-1", "device-1"); err != nil {
+		t.Fatalf("DeleteDevice: %v", err)
+	}
+	if _, err := fakes.devices.GetByID("device-1"); err == nil {
+		t.Fatalf("device still stored after delete")
+	}
+}
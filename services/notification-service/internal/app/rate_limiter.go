@@ -0,0 +1,49 @@
+package app
+
+import (
+	"time"
+
+	"glovo-backend/services/notification-service/internal/domain"
+	"glovo-backend/shared/ratelimit"
+)
+
+// rateLimiter throttles sends per user and channel on the shared token bucket.
+// Notification types with an override get their own limiter; a nil limiter
+// means the limit is disabled.
+type rateLimiter struct {
+	defaultLimit *ratelimit.Limiter
+	overrides    map[domain.NotificationType]*ratelimit.Limiter
+}
+
+func newRateLimiter(config domain.RateLimitConfig) *rateLimiter {
+	r := &rateLimiter{
+		defaultLimit: newLimiter(config.Default),
+		overrides:    make(map[domain.NotificationType]*ratelimit.Limiter, len(config.Overrides)),
+	}
+	for notificationType, limit := range config.Overrides {
+		r.overrides[notificationType] = newLimiter(limit)
+	}
+	return r
+}
+
+func newLimiter(limit domain.RateLimit) *ratelimit.Limiter {
+	// A zero limit disables rate limiting
+	if limit.PerMinute <= 0 || limit.Burst <= 0 {
+		return nil
+	}
+	return ratelimit.New(float64(limit.PerMinute)/60, limit.Burst)
+}
+
+// Allow consumes a token for the user/channel/type and reports whether the send may proceed
+func (r *rateLimiter) Allow(userID string, channel domain.NotificationChannel, notificationType domain.NotificationType, now time.Time) bool {
+	limiter, ok := r.overrides[notificationType]
+	if !ok {
+		limiter = r.defaultLimit
+	}
+	if limiter == nil {
+		return true
+	}
+
+	allowed, _ := limiter.Take(userID+":"+string(channel), now)
+	return allowed
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"glovo-backend/services/notification-service/internal/domain"
+)
+
+func TestRateLimiterBurst(t *testing.T) {
+	limiter := newRateLimiter(domain.RateLimitConfig{
+		Default:   domain.RateLimit{PerMinute: 6, Burst: 3},
+		Overrides: map[domain.NotificationType]domain.RateLimit{domain.TypeOTP: {PerMinute: 60, Burst: 10}},
+	})
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if limiter.Allow("user-1", domain.ChannelPush, domain.TypeOrderUpdate, now) {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Fatalf("allowed %d of a burst of 10, want 3", allowed)
+	}
+
+	// Buckets are per user and channel
+	if !limiter.Allow("user-2", domain.ChannelPush, domain.TypeOrderUpdate, now) {
+		t.Fatalf("another user was throttled")
+	}
+	if !limiter.Allow("user-1", domain.ChannelSMS, domain.TypeOrderUpdate, now) {
+		t.Fatalf("another channel was throttled")
+	}
+
+	// Six per minute refills a token every ten seconds
+	if limiter.Allow("user-1", domain.ChannelPush, domain.TypeOrderUpdate, now.Add(5*time.Second)) {
+		t.Fatalf("allowed before a token refilled")
+	}
+	if !limiter.Allow("user-1", domain.ChannelPush, domain.TypeOrderUpdate, now.Add(10*time.Second)) {
+		t.Fatalf("throttled after a token refilled")
+	}
+
+	// Overridden types get their own, larger bucket
+	allowed = 0
+	for i := 0; i < 15; i++ {
+		if limiter.Allow("user-1", domain.ChannelPush, domain.TypeOTP, now) {
+			allowed++
+		}
+	}
+	if allowed != 10 {
+		t.Fatalf("allowed %d OTPs of a burst of 15, want 10", allowed)
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := newRateLimiter(domain.RateLimitConfig{})
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		if !limiter.Allow("user-1", domain.ChannelPush, domain.TypePromotion, now) {
+			t.Fatalf("send %d throttled with rate limiting disabled", i+1)
+		}
+	}
+}
+
+func TestSendNotificationRateLimited(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{
+		Default: domain.RateLimit{PerMinute: 1, Burst: 2},
+	})
+
+	req := domain.SendNotificationRequest{
+		UserID:  "user-1",
+		Type:    domain.TypeOrderUpdate,
+		Channel: domain.ChannelInApp,
+		Title:   "Order update",
+		Message: "Your order is on its way",
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := svc.SendNotification(req); err != nil {
+			t.Fatalf("send %d: %v", i+1, err)
+		}
+	}
+
+	_, err := svc.SendNotification(req)
+	if !errors.Is(err, domain.ErrRateLimited) {
+		t.Fatalf("expected %v, got %v", domain.ErrRateLimited, err)
+	}
+	fakes.notifications.mu.Lock()
+	stored := len(fakes.notifications.notifications)
+	fakes.notifications.mu.Unlock()
+	if stored != 2 {
+		t.Fatalf("stored %d notifications, want 2", stored)
+	}
+}
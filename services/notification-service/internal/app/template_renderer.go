@@ -0,0 +1,125 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	"strings"
+	"text/template"
+
+	"glovo-backend/services/notification-service/internal/domain"
+)
+
+// Templates use Go template syntax with variables referenced as {{.name}}.
+// Every name listed in NotificationTemplate.Variables is required; extra
+// variables in the payload are ignored.
+
+// validateTemplate checks that the template's title and message parse, in every locale
+func validateTemplate(tmpl *domain.NotificationTemplate) error {
+	if err := validateContent(tmpl.Channel, domain.TemplateContent{Title: tmpl.Title, Message: tmpl.Message}); err != nil {
+		return err
+	}
+
+	for locale, content := range tmpl.Translations {
+		if err := validateContent(tmpl.Channel, content); err != nil {
+			return fmt.Errorf("locale %s: %w", locale, err)
+		}
+	}
+	return nil
+}
+
+func validateContent(channel domain.NotificationChannel, content domain.TemplateContent) error {
+	if _, err := template.New("title").Parse(content.Title); err != nil {
+		return fmt.Errorf("invalid template title: %w", err)
+	}
+
+	if channel == domain.ChannelEmail {
+		if _, err := htmltemplate.New("message").Parse(content.Message); err != nil {
+			return fmt.Errorf("invalid template message: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := template.New("message").Parse(content.Message); err != nil {
+		return fmt.Errorf("invalid template message: %w", err)
+	}
+	return nil
+}
+
+// localizedContent picks the template content for locale, falling back to its
+// base language (es-ES -> es), then the fallback locale, then the template's
+// default title and message
+func localizedContent(tmpl *domain.NotificationTemplate, locale, fallback string) domain.TemplateContent {
+	candidates := []string{locale}
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	candidates = append(candidates, fallback)
+
+	for _, candidate := range candidates {
+		if content, ok := tmpl.Translations[candidate]; ok && candidate != "" {
+			return content
+		}
+	}
+
+	return domain.TemplateContent{Title: tmpl.Title, Message: tmpl.Message}
+}
+
+// renderTemplate substitutes variables into the given title and message.
+// Email messages are rendered as HTML so variable values are escaped.
+func renderTemplate(tmpl *domain.NotificationTemplate, content domain.TemplateContent, variables map[string]string) (string, string, error) {
+	var missing []string
+	for _, name := range tmpl.Variables {
+		if _, ok := variables[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", "", fmt.Errorf("missing required template variables: %s", strings.Join(missing, ", "))
+	}
+
+	title, err := renderText("title", content.Title, variables)
+	if err != nil {
+		return "", "", err
+	}
+
+	var message string
+	if tmpl.Channel == domain.ChannelEmail {
+		message, err = renderHTML("message", content.Message, variables)
+	} else {
+		message, err = renderText("message", content.Message, variables)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return title, message, nil
+}
+
+func renderText(name, text string, variables map[string]string) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, variables); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+func renderHTML(name, text string, variables map[string]string) (string, error) {
+	t, err := htmltemplate.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, variables); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
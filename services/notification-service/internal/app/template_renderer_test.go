@@ -0,0 +1,284 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"glovo-backend/services/notification-service/internal/domain"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	orderReady := &domain.NotificationTemplate{
+		Channel:   domain.ChannelPush,
+		Title:     "Order {{.order_id}}",
+		Message:   "Hi {{.name}}, your order from {{.store}} is ready",
+		Variables: []string{"order_id", "name", "store"},
+	}
+	receipt := &domain.NotificationTemplate{
+		Channel:   domain.ChannelEmail,
+		Title:     "Receipt for {{.name}}",
+		Message:   "<p>Thanks, {{.name}}</p>",
+		Variables: []string{"name"},
+	}
+
+	tests := []struct {
+		name        string
+		tmpl        *domain.NotificationTemplate
+		variables   map[string]string
+		wantTitle   string
+		wantMessage string
+		wantErr     string
+	}{
+		{
+			name:        "substitutes variables",
+			tmpl:        orderReady,
+			variables:   map[string]string{"order_id": "42", "name": "Ana", "store": "Burger Place"},
+			wantTitle:   "Order 42",
+			wantMessage: "Hi Ana, your order from Burger Place is ready",
+		},
+		{
+			name:        "ignores unknown variables",
+			tmpl:        orderReady,
+			variables:   map[string]string{"order_id": "42", "name": "Ana", "store": "Burger Place", "coupon": "SAVE10"},
+			wantTitle:   "Order 42",
+			wantMessage: "Hi Ana, your order from Burger Place is ready",
+		},
+		{
+			name:      "lists every missing variable",
+			tmpl:      orderReady,
+			variables: map[string]string{"name": "Ana"},
+			wantErr:   "missing required template variables: order_id, store",
+		},
+		{
+			name:        "escapes email html",
+			tmpl:        receipt,
+			variables:   map[string]string{"name": "<script>alert(1)</script>"},
+			wantTitle:   "Receipt for <script>alert(1)</script>",
+			wantMessage: "<p>Thanks, &lt;script&gt;alert(1)&lt;/script&gt;</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := domain.TemplateContent{Title: tt.tmpl.Title, Message: tt.tmpl.Message}
+			title, message, err := renderTemplate(tt.tmpl, content, tt.variables)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderTemplate: %v", err)
+			}
+			if title != tt.wantTitle {
+				t.Fatalf("title = %q, want %q", title, tt.wantTitle)
+			}
+			if message != tt.wantMessage {
+				t.Fatalf("message = %q, want %q", message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    domain.NotificationTemplate
+		wantErr bool
+	}{
+		{name: "valid", tmpl: domain.NotificationTemplate{Channel: domain.ChannelSMS, Title: "Hi {{.name}}", Message: "Code {{.code}}"}},
+		{name: "unclosed action in title", tmpl: domain.NotificationTemplate{Channel: domain.ChannelSMS, Title: "Hi {{.name", Message: "Code"}, wantErr: true},
+		{name: "unclosed action in message", tmpl: domain.NotificationTemplate{Channel: domain.ChannelEmail, Title: "Hi", Message: "<p>{{.name</p>"}, wantErr: true},
+		{
+			name: "invalid translation",
+			tmpl: domain.NotificationTemplate{
+				Channel:      domain.ChannelSMS,
+				Title:        "Hi",
+				Message:      "Code",
+				Translations: map[string]domain.TemplateContent{"es": {Title: "Hola {{", Message: "Código"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTemplate(&tt.tmpl); (err != nil) != tt.wantErr {
+				t.Fatalf("validateTemplate error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSendTemplateNotificationMissingVariables(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+	fakes.templates.Create(&domain.NotificationTemplate{
+		ID:        "template-1",
+		Name:      "order_ready",
+		Type:      domain.TypeOrderUpdate,
+		Channel:   domain.ChannelInApp,
+		Title:     "Order {{.order_id}}",
+		Message:   "Your order from {{.store}} is ready",
+		Variables: []string{"order_id", "store"},
+		IsActive:  true,
+	})
+
+	_, err := svc.SendTemplateNotification(domain.SendTemplateNotificationRequest{
+		UserID:     "user-1",
+		TemplateID: "template-1",
+		Variables:  map[string]string{"order_id": "42"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "store") {
+		t.Fatalf("expected a missing store variable error, got %v", err)
+	}
+	if len(fakes.notifications.notifications) != 0 {
+		t.Fatalf("stored %d notifications for a rejected template", len(fakes.notifications.notifications))
+	}
+}
+
+func TestSendTemplateNotificationLocale(t *testing.T) {
+	tests := []struct {
+		name        string
+		locale      string
+		wantTitle   string
+		wantMessage string
+	}{
+		{name: "spanish", locale: "es", wantTitle: "Pedido 42", wantMessage: "Tu pedido de Burger Place está listo"},
+		{name: "portuguese", locale: "pt-BR", wantTitle: "Pedido 42", wantMessage: "Seu pedido de Burger Place está pronto"},
+		{name: "regional variant falls back to the base language", locale: "es-MX", wantTitle: "Pedido 42", wantMessage: "Tu pedido de Burger Place está listo"},
+		{name: "missing locale falls back to the default", locale: "fr", wantTitle: "Order 42", wantMessage: "Your order from Burger Place is ready"},
+		{name: "no locale set", wantTitle: "Order 42", wantMessage: "Your order from Burger Place is ready"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, fakes := newTestNotificationService(domain.RateLimitConfig{})
+			fakes.templates.Create(&domain.NotificationTemplate{
+				ID:        "template-1",
+				Name:      "order_ready",
+				Type:      domain.TypeOrderUpdate,
+				Channel:   domain.ChannelInApp,
+				Title:     "Order {{.order_id}}",
+				Message:   "Your order from {{.store}} is ready",
+				Variables: []string{"order_id", "store"},
+				Translations: map[string]domain.TemplateContent{
+					"es":    {Title: "Pedido {{.order_id}}", Message: "Tu pedido de {{.store}} está listo"},
+					"pt-BR": {Title: "Pedido {{.order_id}}", Message: "Seu pedido de {{.store}} está pronto"},
+				},
+				IsActive: true,
+			})
+			if tt.locale != "" {
+				fakes.preferences.SaveSettings(&domain.NotificationSettings{UserID: "user-1", Timezone: "UTC", Locale: tt.locale})
+			}
+
+			notification, err := svc.SendTemplateNotification(domain.SendTemplateNotificationRequest{
+				UserID:     "user-1",
+				TemplateID: "template-1",
+				Variables:  map[string]string{"order_id": "42", "store": "Burger Place"},
+			})
+			if err != nil {
+				t.Fatalf("SendTemplateNotification: %v", err)
+			}
+			if notification.Title != tt.wantTitle || notification.Message != tt.wantMessage {
+				t.Fatalf("rendered %q / %q, want %q / %q", notification.Title, notification.Message, tt.wantTitle, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestLocalizedContentFallbackLocale(t *testing.T) {
+	tmpl := &domain.NotificationTemplate{
+		Title:        "Hello",
+		Message:      "Default",
+		Translations: map[string]domain.TemplateContent{"es": {Title: "Hola", Message: "Predeterminado"}},
+	}
+
+	// The service's fallback locale is used before the template's own content
+	if got := localizedContent(tmpl, "fr", "es"); got.Title != "Hola" {
+		t.Fatalf("title = %q, want the fallback locale's %q", got.Title, "Hola")
+	}
+	if got := localizedContent(tmpl, "fr", "de"); got.Title != "Hello" {
+		t.Fatalf("title = %q, want the template default %q", got.Title, "Hello")
+	}
+}
+
+func TestCreateTemplateValidation(t *testing.T) {
+	svc, _ := newTestNotificationService(domain.RateLimitConfig{})
+	valid := func() *domain.NotificationTemplate {
+		return &domain.NotificationTemplate{Name: "order_ready", Type: domain.TypeOrderUpdate, Channel: domain.ChannelPush, Title: "Order", Message: "Ready", IsActive: true}
+	}
+
+	created, err := svc.CreateTemplate(valid())
+	if err != nil {
+		t.Fatalf("CreateTemplate: %v", err)
+	}
+
+	duplicate := valid()
+	duplicate.Name = " order_ready "
+	if _, err := svc.CreateTemplate(duplicate); !errors.Is(err, domain.ErrTemplateNameTaken) {
+		t.Fatalf("duplicate name: expected %v, got %v", domain.ErrTemplateNameTaken, err)
+	}
+
+	noChannel := valid()
+	noChannel.Name = "no_channel"
+	noChannel.Channel = "pigeon"
+	if _, err := svc.CreateTemplate(noChannel); !errors.Is(err, domain.ErrInvalidTemplate) {
+		t.Fatalf("unknown channel: expected %v, got %v", domain.ErrInvalidTemplate, err)
+	}
+
+	other := valid()
+	other.Name = "order_late"
+	other, err = svc.CreateTemplate(other)
+	if err != nil {
+		t.Fatalf("CreateTemplate: %v", err)
+	}
+
+	// Renaming onto another template's name is refused; keeping its own name is fine
+	taken := "order_ready"
+	if _, err := svc.UpdateTemplate(other.ID, domain.UpdateTemplateRequest{Name: &taken}); !errors.Is(err, domain.ErrTemplateNameTaken) {
+		t.Fatalf("rename onto a taken name: expected %v, got %v", domain.ErrTemplateNameTaken, err)
+	}
+	title := "Order {{.order_id}}"
+	updated, err := svc.UpdateTemplate(created.ID, domain.UpdateTemplateRequest{Name: &taken, Title: &title})
+	if err != nil {
+		t.Fatalf("UpdateTemplate: %v", err)
+	}
+	if updated.Title != title || updated.Message != "Ready" {
+		t.Fatalf("updated template = %+v", updated)
+	}
+}
+
+func TestBroadcastNotification(t *testing.T) {
+	svc, fakes := newTestNotificationService(domain.RateLimitConfig{Default: domain.RateLimit{PerMinute: 1, Burst: 1}})
+	fakes.templates.Create(&domain.NotificationTemplate{ID: "inactive", Name: "old_promo", Type: domain.TypePromotion, Channel: domain.ChannelInApp, Title: "Promo", Message: "Old"})
+
+	// user-2 has used up their rate limit, so their send fails
+	if _, err := svc.SendNotification(domain.SendNotificationRequest{
+		UserID: "user-2", Type: domain.TypePromotion, Channel: domain.ChannelInApp, Title: "Earlier", Message: "Earlier",
+	}); err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+
+	result, err := svc.BroadcastNotification("admin-1", domain.BroadcastRequest{
+		UserIDs: []string{"user-1", "user-2", "user-3"},
+		Type:    domain.TypePromotion,
+		Channel: domain.ChannelInApp,
+		Title:   "Weekend deal",
+		Message: "Free delivery all weekend",
+	})
+	if err != nil {
+		t.Fatalf("BroadcastNotification: %v", err)
+	}
+	if result.Requested != 3 || result.Sent != 2 || result.Failed != 1 || len(result.FailedUserIDs) != 1 || result.FailedUserIDs[0] != "user-2" {
+		t.Fatalf("result = %+v", result)
+	}
+
+	if _, err := svc.BroadcastNotification("admin-1", domain.BroadcastRequest{UserIDs: []string{"user-1"}, TemplateID: "inactive"}); !errors.Is(err, domain.ErrInvalidTemplate) {
+		t.Fatalf("inactive template: expected %v, got %v", domain.ErrInvalidTemplate, err)
+	}
+	if _, err := svc.BroadcastNotification("admin-1", domain.BroadcastRequest{UserIDs: []string{"user-1"}, TemplateID: "missing"}); !errors.Is(err, domain.ErrTemplateNotFound) {
+		t.Fatalf("missing template: expected %v, got %v", domain.ErrTemplateNotFound, err)
+	}
+}
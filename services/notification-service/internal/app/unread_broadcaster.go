@@ -0,0 +1,62 @@
+package app
+
+import "sync"
+
+// unreadBroadcaster fans out unread-count updates to per-user subscribers
+type unreadBroadcaster struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan int]struct{}
+}
+
+func newUnreadBroadcaster() *unreadBroadcaster {
+	return &unreadBroadcaster{
+		subscribers: make(map[string]map[chan int]struct{}),
+	}
+}
+
+func (b *unreadBroadcaster) subscribe(userID string) (<-chan int, func()) {
+	ch := make(chan int, 1)
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan int]struct{})
+	}
+	b.subscribers[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[userID][ch]; !ok {
+			return
+		}
+		delete(b.subscribers[userID], ch)
+		if len(b.subscribers[userID]) == 0 {
+			delete(b.subscribers, userID)
+		}
+		close(ch)
+	}
+
+	return ch, unsubscribe
+}
+
+func (b *unreadBroadcaster) hasSubscribers(userID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers[userID]) > 0
+}
+
+// publish sends the latest count to every subscriber of the user, replacing
+// any count a slow subscriber has not consumed yet
+func (b *unreadBroadcaster) publish(userID string, count int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[userID] {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- count
+	}
+}
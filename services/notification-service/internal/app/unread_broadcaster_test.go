@@ -0,0 +1,83 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"glovo-backend/services/notification-service/internal/domain"
+)
+
+func receiveCount(t *testing.T, updates <-chan int, want int) {
+	t.Helper()
+	select {
+	case got := <-updates:
+		if got != want {
+			t.Fatalf("pushed unread count = %d, want %d", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no unread count pushed, want %d", want)
+	}
+}
+
+func TestSubscribeUnreadCount(t *testing.T) {
+	svc, _ := newTestNotificationService(domain.RateLimitConfig{})
+
+	updates, unsubscribe := svc.SubscribeUnreadCount("user-1")
+
+	req := domain.SendNotificationRequest{
+		UserID:  "user-1",
+		Type:    domain.TypeOrderUpdate,
+		Channel: domain.ChannelInApp,
+		Title:   "Order update",
+		Message: "Your order is on its way",
+	}
+	first, err := svc.SendNotification(req)
+	if err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+	receiveCount(t, updates, 1)
+
+	if _, err := svc.SendNotification(req); err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+	receiveCount(t, updates, 2)
+
+	if err := svc.MarkNotificationAsRead(first.ID, "user-1"); err != nil {
+		t.Fatalf("MarkNotificationAsRead: %v", err)
+	}
+	receiveCount(t, updates, 1)
+
+	// Another user's notifications are not pushed to this stream
+	other := req
+	other.UserID = "user-2"
+	if _, err := svc.SendNotification(other); err != nil {
+		t.Fatalf("SendNotification: %v", err)
+	}
+	select {
+	case count := <-updates:
+		t.Fatalf("pushed %d for another user's notification", count)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	unsubscribe()
+	if _, ok := <-updates; ok {
+		t.Fatalf("stream still open after unsubscribing")
+	}
+	unsubscribe()
+}
+
+func TestUnreadBroadcasterKeepsLatestCount(t *testing.T) {
+	b := newUnreadBroadcaster()
+	updates, unsubscribe := b.subscribe("user-1")
+	defer unsubscribe()
+
+	// A slow subscriber only sees the latest count
+	b.publish("user-1", 1)
+	b.publish("user-1", 2)
+	b.publish("user-1", 3)
+	receiveCount(t, updates, 3)
+
+	if !b.hasSubscribers("user-1") || b.hasSubscribers("user-2") {
+		t.Fatalf("hasSubscribers reported the wrong users")
+	}
+}
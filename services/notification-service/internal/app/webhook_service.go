@@ -0,0 +1,295 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"glovo-backend/services/notification-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// webhookRetryDelays is the backoff after each failed attempt; its length is
+// MaxWebhookAttempts-1
+var webhookRetryDelays = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+}
+
+// webhookRetryBatch caps how many due deliveries one sweep re-attempts
+const webhookRetryBatch = 100
+
+// webhookAttemptLease keeps a delivery out of retry sweeps while an attempt
+// is in flight; it outlasts the sender's timeout
+const webhookAttemptLease = 5 * time.Minute
+
+// webhookDeliveryNamespace derives delivery IDs from event and webhook IDs
+var webhookDeliveryNamespace = uuid.MustParse("6f1c2d7e-8b4a-4f3e-9a51-2c0d5e7b9f14")
+
+type webhookService struct {
+	webhookRepo  domain.MerchantWebhookRepository
+	deliveryRepo domain.WebhookDeliveryRepository
+	sender       domain.WebhookSender
+}
+
+func NewWebhookService(
+	webhookRepo domain.MerchantWebhookRepository,
+	deliveryRepo domain.WebhookDeliveryRepository,
+	sender domain.WebhookSender,
+) domain.WebhookService {
+	return &webhookService{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		sender:       sender,
+	}
+}
+
+func (s *webhookService) RegisterWebhook(merchantID string, req domain.RegisterWebhookRequest) (*domain.RegisteredWebhook, error) {
+	if err := domain.ValidateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	for _, event := range req.Events {
+		if !isWebhookEvent(event) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrUnsupportedWebhookEvent, event)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	now := time.Now()
+	webhook := &domain.MerchantWebhook{
+		ID:         uuid.New().String(),
+		MerchantID: merchantID,
+		URL:        req.URL,
+		Secret:     secret,
+		Events:     req.Events,
+		IsActive:   true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.webhookRepo.Create(webhook); err != nil {
+		return nil, fmt.Errorf("failed to register webhook: %w", err)
+	}
+
+	return &domain.RegisteredWebhook{MerchantWebhook: *webhook, Secret: secret}, nil
+}
+
+func (s *webhookService) GetWebhooks(merchantID string) ([]domain.MerchantWebhook, error) {
+	return s.webhookRepo.GetByMerchantID(merchantID)
+}
+
+func (s *webhookService) DeleteWebhook(merchantID, webhookID string) error {
+	if _, err := s.getOwnWebhook(merchantID, webhookID); err != nil {
+		return err
+	}
+	return s.webhookRepo.Delete(webhookID)
+}
+
+func (s *webhookService) GetDeliveries(merchantID, webhookID string, limit, offset int) ([]domain.WebhookDelivery, error) {
+	if _, err := s.getOwnWebhook(merchantID, webhookID); err != nil {
+		return nil, err
+	}
+	return s.deliveryRepo.GetByWebhookID(webhookID, limit, offset)
+}
+
+// Dispatch records a delivery for every subscribed webhook and makes the first
+// attempt right away. Failed attempts are left for RetryDueDeliveries. The
+// delivery ID is derived from the event ID, so a redelivered event finds its
+// deliveries already recorded and sends nothing.
+func (s *webhookService) Dispatch(eventID, merchantID string, event domain.WebhookEvent, occurredAt time.Time, data interface{}) error {
+	webhooks, err := s.webhookRepo.GetByMerchantID(merchantID)
+	if err != nil {
+		return err
+	}
+
+	for i := range webhooks {
+		webhook := &webhooks[i]
+		if !webhook.IsActive || !webhook.Subscribes(event) {
+			continue
+		}
+
+		// The first attempt holds the lease, so a sweep can't send it as well
+		now := time.Now()
+		leased := now.Add(webhookAttemptLease)
+		delivery := &domain.WebhookDelivery{
+			ID:            webhookDeliveryID(eventID, webhook.ID),
+			EventID:       eventID,
+			WebhookID:     webhook.ID,
+			MerchantID:    merchantID,
+			Event:         event,
+			Status:        domain.WebhookDeliveryPending,
+			NextAttemptAt: &leased,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		body, err := json.Marshal(domain.WebhookPayload{
+			ID:         delivery.ID,
+			Event:      event,
+			OccurredAt: occurredAt,
+			Data:       data,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode %s webhook: %w", event, err)
+		}
+		delivery.Payload = string(body)
+
+		created, err := s.deliveryRepo.Create(delivery)
+		if err != nil {
+			return fmt.Errorf("failed to record webhook delivery: %w", err)
+		}
+		if !created {
+			continue
+		}
+		if err := s.attempt(webhook, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RetryDueDeliveries claims due deliveries before attempting them, so sweeps
+// running on several replicas never send the same delivery twice
+func (s *webhookService) RetryDueDeliveries() (int, error) {
+	deliveries, err := s.deliveryRepo.ClaimDue(time.Now(), webhookAttemptLease, webhookRetryBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	retried := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		webhook, err := s.webhookRepo.GetByID(delivery.WebhookID)
+		if err != nil || !webhook.IsActive {
+			// The merchant removed the webhook since the event was recorded
+			delivery.Status = domain.WebhookDeliveryFailed
+			delivery.LastError = "webhook no longer registered"
+			delivery.NextAttemptAt = nil
+			delivery.UpdatedAt = time.Now()
+			if err := s.deliveryRepo.Update(delivery); err != nil {
+				return retried, err
+			}
+			continue
+		}
+
+		if err := s.attempt(webhook, delivery); err != nil {
+			return retried, err
+		}
+		retried++
+	}
+	return retried, nil
+}
+
+// attempt posts the delivery's payload once and records the outcome,
+// scheduling the next attempt on failure
+func (s *webhookService) attempt(webhook *domain.MerchantWebhook, delivery *domain.WebhookDelivery) error {
+	// Webhooks registered before URLs were checked are failed without retrying
+	if err := domain.ValidateWebhookURL(webhook.URL); err != nil {
+		delivery.Attempts++
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = nil
+		delivery.UpdatedAt = time.Now()
+		log.Printf("Webhook delivery %s to %s refused: %v", delivery.ID, webhook.URL, err)
+		return s.deliveryRepo.Update(delivery)
+	}
+
+	body := []byte(delivery.Payload)
+	headers := map[string]string{
+		domain.HeaderWebhookEvent:     string(delivery.Event),
+		domain.HeaderWebhookDelivery:  delivery.ID,
+		domain.HeaderWebhookSignature: SignWebhookPayload(webhook.Secret, body),
+	}
+
+	status, err := s.sender.Send(webhook.URL, headers, body)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.UpdatedAt = now
+
+	if err == nil && status >= 200 && status < 300 {
+		delivery.Status = domain.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+		return s.deliveryRepo.Update(delivery)
+	}
+
+	if err != nil {
+		delivery.LastError = err.Error()
+	} else {
+		delivery.LastError = fmt.Sprintf("webhook responded with status %d", status)
+	}
+	if delivery.Attempts >= domain.MaxWebhookAttempts {
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		log.Printf("Webhook delivery %s to %s failed after %d attempts: %s", delivery.ID, webhook.URL, delivery.Attempts, delivery.LastError)
+	} else {
+		next := now.Add(webhookRetryDelays[delivery.Attempts-1])
+		delivery.NextAttemptAt = &next
+	}
+
+	return s.deliveryRepo.Update(delivery)
+}
+
+func (s *webhookService) getOwnWebhook(merchantID, webhookID string) (*domain.MerchantWebhook, error) {
+	webhook, err := s.webhookRepo.GetByID(webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.MerchantID != merchantID {
+		return nil, domain.ErrNotWebhookOwner
+	}
+	return webhook, nil
+}
+
+// webhookDeliveryID is stable for one event sent to one webhook. Events
+// without an ID get a random one.
+func webhookDeliveryID(eventID, webhookID string) string {
+	if eventID == "" {
+		return uuid.New().String()
+	}
+	return uuid.NewSHA1(webhookDeliveryNamespace, []byte(eventID+"/"+webhookID)).String()
+}
+
+// SignWebhookPayload returns the signature header value for body:
+// "sha256=" followed by the hex HMAC-SHA256 of body keyed with secret
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func isWebhookEvent(event domain.WebhookEvent) bool {
+	for _, e := range domain.WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"glovo-backend/services/notification-service/internal/domain"
+)
+
+type fakeWebhookRepo struct {
+	domain.MerchantWebhookRepository
+	webhooks []domain.MerchantWebhook
+}
+
+func (r *fakeWebhookRepo) GetByMerchantID(merchantID string) ([]domain.MerchantWebhook, error) {
+	return r.webhooks, nil
+}
+
+func (r *fakeWebhookRepo) GetByID(id string) (*domain.MerchantWebhook, error) {
+	for i := range r.webhooks {
+		if r.webhooks[i].ID == id {
+			return &r.webhooks[i], nil
+		}
+	}
+	return nil, domain.ErrNotWebhookOwner
+}
+
+// fakeDeliveryRepo keeps deliveries by ID and claims them the way ClaimDue does
+type fakeDeliveryRepo struct {
+	domain.WebhookDeliveryRepository
+	deliveries map[string]domain.WebhookDelivery
+}
+
+func (r *fakeDeliveryRepo) Create(delivery *domain.WebhookDelivery) (bool, error) {
+	if _, ok := r.deliveries[delivery.ID]; ok {
+		return false, nil
+	}
+	r.deliveries[delivery.ID] = *delivery
+	return true, nil
+}
+
+func (r *fakeDeliveryRepo) Update(delivery *domain.WebhookDelivery) error {
+	r.deliveries[delivery.ID] = *delivery
+	return nil
+}
+
+func (r *fakeDeliveryRepo) ClaimDue(now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	var claimed []domain.WebhookDelivery
+	for id, delivery := range r.deliveries {
+		if delivery.Status != domain.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) || len(claimed) == limit {
+			continue
+		}
+		leased := now.Add(lease)
+		delivery.NextAttemptAt = &leased
+		r.deliveries[id] = delivery
+		claimed = append(claimed, delivery)
+	}
+	return claimed, nil
+}
+
+type fakeWebhookSender struct {
+	status int
+	sent   []string // delivery IDs
+	during func()   // runs while a request is in flight
+}
+
+func (s *fakeWebhookSender) Send(url string, headers map[string]string, body []byte) (int, error) {
+	s.sent = append(s.sent, headers[domain.HeaderWebhookDelivery])
+	if s.during != nil {
+		s.during()
+	}
+	return s.status, nil
+}
+
+func TestDispatchWebhookOnce(t *testing.T) {
+	webhooks := &fakeWebhookRepo{webhooks: []domain.MerchantWebhook{
+		{ID: "webhook-1", MerchantID: "merchant-1", URL: "https://hooks.example.com/a", IsActive: true},
+		{ID: "webhook-2", MerchantID: "merchant-1", URL: "https://hooks.example.com/b", IsActive: true},
+	}}
+	deliveries := &fakeDeliveryRepo{deliveries: make(map[string]domain.WebhookDelivery)}
+	sender := &fakeWebhookSender{status: http.StatusServiceUnavailable}
+	svc := NewWebhookService(webhooks, deliveries, sender)
+
+	// A sweep on another replica while the first attempts are in flight
+	sender.during = func() {
+		if retried, err := svc.RetryDueDeliveries(); err != nil || retried != 0 {
+			t.Fatalf("sweep during the first attempt retried %d deliveries: %v", retried, err)
+		}
+	}
+
+	dispatch := func(eventID string) {
+		t.Helper()
+		if err := svc.Dispatch(eventID, "merchant-1", domain.WebhookOrderDelivered, time.Now(), map[string]string{"order_id": "order-1"}); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+	}
+
+	// A redelivered event neither records nor sends anything again
+	dispatch("event-1")
+	dispatch("event-1")
+	if len(deliveries.deliveries) != 2 || len(sender.sent) != 2 {
+		t.Fatalf("%d deliveries and %d sends, want 2 of each", len(deliveries.deliveries), len(sender.sent))
+	}
+	dispatch("event-2")
+	if len(deliveries.deliveries) != 4 || len(sender.sent) != 4 {
+		t.Fatalf("%d deliveries and %d sends after a second event, want 4 of each", len(deliveries.deliveries), len(sender.sent))
+	}
+	for id, delivery := range deliveries.deliveries {
+		if delivery.Attempts != 1 || delivery.Status != domain.WebhookDeliveryPending {
+			t.Fatalf("delivery %s = %+v, want one failed attempt awaiting a retry", id, delivery)
+		}
+	}
+}
+
+func TestRetryDueDeliveriesClaims(t *testing.T) {
+	webhooks := &fakeWebhookRepo{webhooks: []domain.MerchantWebhook{
+		{ID: "webhook-1", MerchantID: "merchant-1", URL: "https://hooks.example.com/a", IsActive: true},
+	}}
+	due := time.Now().Add(-time.Minute)
+	deliveries := &fakeDeliveryRepo{deliveries: map[string]domain.WebhookDelivery{
+		"delivery-1": {ID: "delivery-1", WebhookID: "webhook-1", Status: domain.WebhookDeliveryPending, Attempts: 1, NextAttemptAt: &due},
+	}}
+	// The receiver stays down, but the failed attempt schedules the next one
+	// before the lease runs out
+	sender := &fakeWebhookSender{status: http.StatusBadGateway}
+	svc := NewWebhookService(webhooks, deliveries, sender)
+
+	for _, want := range []int{1, 0} {
+		retried, err := svc.RetryDueDeliveries()
+		if err != nil {
+			t.Fatalf("RetryDueDeliveries: %v", err)
+		}
+		if retried != want {
+			t.Fatalf("retried %d deliveries, want %d", retried, want)
+		}
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d times, want once", len(sender.sent))
+	}
+	delivery := deliveries.deliveries["delivery-1"]
+	if delivery.Attempts != 2 || !delivery.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("delivery = %+v, want a second attempt with a later retry", delivery)
+	}
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// BulkJobStatus is the processing state of a bulk send
+type BulkJobStatus string
+
+const (
+	BulkJobPending    BulkJobStatus = "pending"
+	BulkJobProcessing BulkJobStatus = "processing"
+	BulkJobCompleted  BulkJobStatus = "completed"
+)
+
+// BulkBatchSize is how many recipients a bulk job sends to before saving its
+// progress
+const BulkBatchSize = 100
+
+// BulkJob tracks a bulk send. Sent and Failed grow as each batch is
+// processed until they add up to Total.
+type BulkJob struct {
+	ID            string              `json:"id" gorm:"primaryKey"`
+	Type          NotificationType    `json:"type"`
+	Channel       NotificationChannel `json:"channel"`
+	Status        BulkJobStatus       `json:"status" gorm:"index"`
+	Total         int                 `json:"total"`
+	Sent          int                 `json:"sent"`
+	Failed        int                 `json:"failed"`
+	FailedUserIDs []string            `json:"failed_user_ids,omitempty" gorm:"serializer:json"`
+	StartedAt     *time.Time          `json:"started_at,omitempty"`
+	CompletedAt   *time.Time          `json:"completed_at,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+// ErrBulkJobNotFound is returned when a bulk job ID does not exist
+var ErrBulkJobNotFound = errors.New("bulk job not found")
+
+type BulkJobRepository interface {
+	Create(job *BulkJob) error
+	GetByID(id string) (*BulkJob, error)
+	Update(job *BulkJob) error
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"time"
 )
 
@@ -16,11 +17,13 @@ type Notification struct {
 	Status       NotificationStatus   `json:"status"`
 	Priority     NotificationPriority `json:"priority"`
 	ScheduledFor *time.Time           `json:"scheduled_for,omitempty"`
-	SentAt       *time.Time           `json:"sent_at,omitempty"`
-	ReadAt       *time.Time           `json:"read_at,omitempty"`
-	ExpiresAt    *time.Time           `json:"expires_at,omitempty"`
-	CreatedAt    time.Time            `json:"created_at"`
-	UpdatedAt    time.Time            `json:"updated_at"`
+	// SuppressionReason explains why a suppressed notification was not sent
+	SuppressionReason string     `json:"suppression_reason,omitempty"`
+	SentAt            *time.Time `json:"sent_at,omitempty"`
+	ReadAt            *time.Time `json:"read_at,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
 }
 
 type NotificationType string
@@ -41,6 +44,13 @@ const (
 	TypeReminder         NotificationType = "reminder"
 )
 
+// NotificationTypes lists every notification type users can set preferences for
+var NotificationTypes = []NotificationType{
+	TypeOrderUpdate, TypeOrderConfirmed, TypeOrderDelivered, TypeOrderCancelled,
+	TypeDeliveryAssigned, TypeDeliveryUpdate, TypePaymentSuccess, TypePaymentFailed,
+	TypePromotion, TypeSystemAlert, TypeOTP, TypeWelcome, TypeReminder,
+}
+
 type NotificationChannel string
 
 const (
@@ -50,15 +60,32 @@ const (
 	ChannelInApp NotificationChannel = "in_app"
 )
 
+// NotificationChannels lists every channel notifications can be sent on
+var NotificationChannels = []NotificationChannel{ChannelPush, ChannelSMS, ChannelEmail, ChannelInApp}
+
 type NotificationStatus string
 
 const (
-	StatusPending   NotificationStatus = "pending"
-	StatusSent      NotificationStatus = "sent"
-	StatusDelivered NotificationStatus = "delivered"
-	StatusFailed    NotificationStatus = "failed"
-	StatusRead      NotificationStatus = "read"
-	StatusExpired   NotificationStatus = "expired"
+	StatusPending    NotificationStatus = "pending"
+	StatusProcessing NotificationStatus = "processing" // claimed by a scheduler run that is sending it
+	StatusSent       NotificationStatus = "sent"
+	StatusDelivered  NotificationStatus = "delivered"
+	StatusFailed     NotificationStatus = "failed"
+	StatusRead       NotificationStatus = "read"
+	StatusExpired    NotificationStatus = "expired"
+	StatusCancelled  NotificationStatus = "cancelled"
+	StatusSuppressed NotificationStatus = "suppressed"
+)
+
+// UnreadStatuses are the statuses of notifications that reached the user and
+// haven't been read. Pending, suppressed, cancelled, expired and failed ones
+// never reached the user, so they can't be read and don't count as unread.
+var UnreadStatuses = []NotificationStatus{StatusSent, StatusDelivered}
+
+// Suppression reasons recorded on notifications that were not sent
+const (
+	SuppressedChannelOptOut = "channel_opted_out"
+	SuppressedQuietHours    = "quiet_hours"
 )
 
 type NotificationPriority string
@@ -79,9 +106,72 @@ type NotificationTemplate struct {
 	Title     string              `json:"title"`
 	Message   string              `json:"message"`
 	Variables []string            `json:"variables" gorm:"serializer:json"`
-	IsActive  bool                `json:"is_active"`
-	CreatedAt time.Time           `json:"created_at"`
-	UpdatedAt time.Time           `json:"updated_at"`
+	// Translations holds localized content keyed by locale (e.g. "es", "pt-BR");
+	// Title and Message are used when no translation matches
+	Translations map[string]TemplateContent `json:"translations,omitempty" gorm:"serializer:json"`
+	IsActive     bool                       `json:"is_active"`
+	CreatedAt    time.Time                  `json:"created_at"`
+	UpdatedAt    time.Time                  `json:"updated_at"`
+}
+
+// ErrNotificationForbidden is returned when a user acts on another user's notification
+var ErrNotificationForbidden = errors.New("notification does not belong to user")
+
+// ErrNotificationNotDelivered is returned when marking a notification the user
+// never received as read
+var ErrNotificationNotDelivered = errors.New("notification has not been delivered")
+
+// ErrRateLimited is returned when a send exceeds the recipient's rate limit
+var ErrRateLimited = errors.New("notification rate limit exceeded")
+
+// ErrTemplateNameTaken is returned when another template already uses the name
+var ErrTemplateNameTaken = errors.New("template name already in use")
+
+// ErrTemplateNotFound is returned when a template ID does not exist
+var ErrTemplateNotFound = errors.New("template not found")
+
+// ErrInvalidTemplate is returned when a template fails validation
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// ErrInvalidPreference is returned for a preference on an unknown type or channel
+var ErrInvalidPreference = errors.New("invalid notification preference")
+
+// IsValidChannel reports whether channel is one notifications can be sent on
+func IsValidChannel(channel NotificationChannel) bool {
+	for _, c := range NotificationChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidType reports whether notificationType is a known notification type
+func IsValidType(notificationType NotificationType) bool {
+	for _, t := range NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimit is a token bucket allowing Burst sends at once, refilled at PerMinute
+type RateLimit struct {
+	PerMinute int `json:"per_minute"`
+	Burst     int `json:"burst"`
+}
+
+// RateLimitConfig holds the default per-user, per-channel limit and per-type overrides
+type RateLimitConfig struct {
+	Default   RateLimit                      `json:"default"`
+	Overrides map[NotificationType]RateLimit `json:"overrides"`
+}
+
+// TemplateContent is the localized title and message of a template
+type TemplateContent struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
 }
 
 // UserPreference represents user notification preferences
@@ -92,6 +182,19 @@ type UserPreference struct {
 	Channel   NotificationChannel `json:"channel"`
 	Enabled   bool                `json:"enabled"`
 	UpdatedAt time.Time           `json:"updated_at"`
+	// IsDefault marks a preference the user never set; unset preferences are enabled
+	IsDefault bool `json:"is_default" gorm:"-"`
+}
+
+// NotificationSettings holds per-user delivery settings that apply to all notification types
+type NotificationSettings struct {
+	UserID            string    `json:"user_id" gorm:"primaryKey"`
+	Timezone          string    `json:"timezone"` // IANA name, e.g. Europe/Madrid
+	Locale            string    `json:"locale"`   // e.g. es-ES; empty uses the service default
+	QuietHoursEnabled bool      `json:"quiet_hours_enabled"`
+	QuietHoursStart   string    `json:"quiet_hours_start"` // HH:MM in the user's timezone
+	QuietHoursEnd     string    `json:"quiet_hours_end"`   // HH:MM in the user's timezone
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 // NotificationDevice represents user devices for push notifications
@@ -115,6 +218,27 @@ const (
 	PlatformWeb     DevicePlatform = "web"
 )
 
+// IsValidPlatform reports whether platform is a supported device platform
+func IsValidPlatform(platform DevicePlatform) bool {
+	switch platform {
+	case PlatformIOS, PlatformAndroid, PlatformWeb:
+		return true
+	}
+	return false
+}
+
+// ErrDeviceNotFound is returned when a device ID does not exist
+var ErrDeviceNotFound = errors.New("device not found")
+
+// ErrDeviceForbidden is returned when a user acts on another user's device
+var ErrDeviceForbidden = errors.New("device does not belong to user")
+
+// ErrDeviceTokenInUse is returned when a refreshed token is already registered on another device
+var ErrDeviceTokenInUse = errors.New("device token already registered")
+
+// ErrInvalidDevice is returned for a device update with an unknown platform or empty token
+var ErrInvalidDevice = errors.New("invalid device")
+
 // Request/Response DTOs
 type SendNotificationRequest struct {
 	UserID       string               `json:"user_id" binding:"required"`
@@ -139,6 +263,73 @@ type BulkNotificationRequest struct {
 	ScheduledFor *time.Time           `json:"scheduled_for,omitempty"`
 }
 
+type CreateTemplateRequest struct {
+	Name         string                     `json:"name" binding:"required"`
+	Type         NotificationType           `json:"type" binding:"required"`
+	Channel      NotificationChannel        `json:"channel" binding:"required"`
+	Title        string                     `json:"title" binding:"required"`
+	Message      string                     `json:"message" binding:"required"`
+	Variables    []string                   `json:"variables,omitempty"`
+	Translations map[string]TemplateContent `json:"translations,omitempty"`
+	IsActive     *bool                      `json:"is_active,omitempty"`
+}
+
+// UpdateTemplateRequest changes only the fields that are set
+type UpdateTemplateRequest struct {
+	Name         *string                    `json:"name,omitempty"`
+	Type         *NotificationType          `json:"type,omitempty"`
+	Channel      *NotificationChannel       `json:"channel,omitempty"`
+	Title        *string                    `json:"title,omitempty"`
+	Message      *string                    `json:"message,omitempty"`
+	Variables    []string                   `json:"variables,omitempty"`
+	Translations map[string]TemplateContent `json:"translations,omitempty"`
+	IsActive     *bool                      `json:"is_active,omitempty"`
+}
+
+// BroadcastRequest sends either a template or inline content to every user in
+// UserIDs. Inline content requires Type, Channel, Title and Message.
+type BroadcastRequest struct {
+	UserIDs      []string             `json:"user_ids" binding:"required,min=1"`
+	TemplateID   string               `json:"template_id,omitempty"`
+	Variables    map[string]string    `json:"variables,omitempty"`
+	Type         NotificationType     `json:"type,omitempty"`
+	Channel      NotificationChannel  `json:"channel,omitempty"`
+	Title        string               `json:"title,omitempty"`
+	Message      string               `json:"message,omitempty"`
+	Data         map[string]string    `json:"data,omitempty"`
+	Priority     NotificationPriority `json:"priority,omitempty"`
+	ScheduledFor *time.Time           `json:"scheduled_for,omitempty"`
+}
+
+// BroadcastResult summarizes a broadcast; recipients whose send failed (rate
+// limited, template render error) are listed in FailedUserIDs
+type BroadcastResult struct {
+	Requested     int      `json:"requested"`
+	Sent          int      `json:"sent"`
+	Failed        int      `json:"failed"`
+	FailedUserIDs []string `json:"failed_user_ids,omitempty"`
+}
+
+// DeliveryAssignment is a delivery newly assigned to a driver
+type DeliveryAssignment struct {
+	DeliveryID      string
+	OrderID         string
+	DriverID        string
+	DriverPhone     string
+	PickupAddress   string
+	DeliveryAddress string
+	DeliveryFee     float64
+	Distance        float64 // in kilometers
+	EstimatedTime   int     // in minutes
+	Contactless     bool
+	DropLocation    string // e.g. "leave at the door"
+	CallOnArrival   bool
+}
+
+// DeliveryAssignedTemplate is the name of the template used for driver
+// assignment notifications; a built-in default is used when it is missing or inactive
+const DeliveryAssignedTemplate = "delivery_assigned"
+
 type SendTemplateNotificationRequest struct {
 	UserID       string            `json:"user_id" binding:"required"`
 	TemplateID   string            `json:"template_id" binding:"required"`
@@ -152,6 +343,14 @@ type UpdatePreferenceRequest struct {
 	Enabled bool                `json:"enabled"`
 }
 
+type UpdateNotificationSettingsRequest struct {
+	Timezone          string `json:"timezone" binding:"required"`
+	Locale            string `json:"locale"`
+	QuietHoursEnabled bool   `json:"quiet_hours_enabled"`
+	QuietHoursStart   string `json:"quiet_hours_start"`
+	QuietHoursEnd     string `json:"quiet_hours_end"`
+}
+
 type RegisterDeviceRequest struct {
 	UserID      string         `json:"user_id" binding:"required"`
 	DeviceToken string         `json:"device_token" binding:"required"`
@@ -159,24 +358,61 @@ type RegisterDeviceRequest struct {
 	AppVersion  string         `json:"app_version"`
 }
 
+// UpdateDeviceRequest changes only the fields that are set
+type UpdateDeviceRequest struct {
+	DeviceToken *string         `json:"device_token,omitempty"`
+	Platform    *DevicePlatform `json:"platform,omitempty"`
+	AppVersion  *string         `json:"app_version,omitempty"`
+}
+
+type NotificationHistoryRequest struct {
+	UserID string           `json:"user_id"`
+	Type   NotificationType `json:"type,omitempty"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset"`
+}
+
 type NotificationListResponse struct {
 	Notifications []Notification `json:"notifications"`
 	UnreadCount   int            `json:"unread_count"`
-	TotalCount    int            `json:"total_count"`
+	TotalCount    int64          `json:"total_count"` // all matching notifications, not just this page
+}
+
+// ChannelStatusCount is the number of notifications with a given channel and status
+type ChannelStatusCount struct {
+	Channel NotificationChannel `json:"channel"`
+	Status  NotificationStatus  `json:"status"`
+	Count   int                 `json:"count"`
+}
+
+type DeliveryStats struct {
+	StartDate *time.Time                                         `json:"start_date,omitempty"`
+	EndDate   *time.Time                                         `json:"end_date,omitempty"`
+	Total     int                                                `json:"total"`
+	ByStatus  map[NotificationStatus]int                         `json:"by_status"`
+	ByChannel map[NotificationChannel]map[NotificationStatus]int `json:"by_channel"`
 }
 
 // Repository interfaces (ports)
 type NotificationRepository interface {
 	Create(notification *Notification) error
 	GetByID(id string) (*Notification, error)
-	GetByUserID(userID string, limit, offset int) ([]Notification, error)
+	GetByUserID(userID string, limit, offset int) ([]Notification, int64, error)
+	GetHistory(req NotificationHistoryRequest) ([]Notification, int64, error)
 	GetUnreadByUserID(userID string) ([]Notification, error)
 	GetByStatus(status NotificationStatus, limit, offset int) ([]Notification, error)
 	Update(notification *Notification) error
 	Delete(id string) error
-	MarkAsRead(id string) error
+	// MarkAsRead reads a sent or delivered notification, reporting whether it
+	// was in one of those statuses
+	MarkAsRead(id string) (bool, error)
 	MarkAllAsRead(userID string) error
 	GetUnreadCount(userID string) (int, error)
+	GetDueScheduled(before time.Time, limit int) ([]Notification, error)
+	// ClaimPending moves a pending notification to processing, reporting
+	// false, changing nothing, when it is no longer pending
+	ClaimPending(id string) (bool, error)
+	CountByChannelAndStatus(start, end time.Time) ([]ChannelStatusCount, error)
 }
 
 type TemplateRepository interface {
@@ -186,16 +422,20 @@ type TemplateRepository interface {
 	GetByType(notificationType NotificationType) ([]NotificationTemplate, error)
 	Update(template *NotificationTemplate) error
 	Delete(id string) error
-	List(limit, offset int) ([]NotificationTemplate, error)
+	// List returns templates of notificationType, or of every type when empty
+	List(notificationType NotificationType, limit, offset int) ([]NotificationTemplate, error)
 }
 
 type PreferenceRepository interface {
 	Create(preference *UserPreference) error
 	GetByUserID(userID string) ([]UserPreference, error)
 	GetByUserAndType(userID string, notificationType NotificationType) (*UserPreference, error)
+	GetByUserTypeAndChannel(userID string, notificationType NotificationType, channel NotificationChannel) (*UserPreference, error)
 	Update(preference *UserPreference) error
 	Delete(id string) error
 	UpsertPreference(userID string, notificationType NotificationType, channel NotificationChannel, enabled bool) error
+	GetSettings(userID string) (*NotificationSettings, error)
+	SaveSettings(settings *NotificationSettings) error
 }
 
 type DeviceRepository interface {
@@ -213,7 +453,8 @@ type DeviceRepository interface {
 type NotificationService interface {
 	// Sending notifications
 	SendNotification(req SendNotificationRequest) (*Notification, error)
-	SendBulkNotification(req BulkNotificationRequest) ([]Notification, error)
+	SendBulkNotification(req BulkNotificationRequest) (*BulkJob, error)
+	GetBulkJob(jobID string) (*BulkJob, error)
 	SendTemplateNotification(req SendTemplateNotificationRequest) (*Notification, error)
 
 	// OTP notifications (for User Service integration)
@@ -221,28 +462,42 @@ type NotificationService interface {
 
 	// Order notifications (for Order Service integration)
 	SendOrderNotification(orderID, userID, message string) error
+	SendDeliveryAssignment(assignment DeliveryAssignment) (*Notification, error)
+
+	// Scheduled notifications
+	CancelScheduledNotification(notificationID string) error
 
 	// Managing notifications
 	GetNotifications(userID string, limit, offset int) (*NotificationListResponse, error)
+	GetNotificationHistory(req NotificationHistoryRequest) (*NotificationListResponse, error)
 	GetNotification(notificationID string) (*Notification, error)
 	MarkNotificationAsRead(notificationID, userID string) error
 	MarkAllNotificationsAsRead(userID string) error
 	GetUnreadCount(userID string) (int, error)
+	// SubscribeUnreadCount streams the user's unread count whenever it changes;
+	// call the returned function to unsubscribe
+	SubscribeUnreadCount(userID string) (<-chan int, func())
+	GetDeliveryStats(start, end time.Time) (*DeliveryStats, error)
 
 	// Templates
 	CreateTemplate(template *NotificationTemplate) (*NotificationTemplate, error)
 	GetTemplate(templateID string) (*NotificationTemplate, error)
-	UpdateTemplate(templateID string, updates map[string]interface{}) (*NotificationTemplate, error)
+	UpdateTemplate(templateID string, req UpdateTemplateRequest) (*NotificationTemplate, error)
 	DeleteTemplate(templateID string) error
-	ListTemplates(limit, offset int) ([]NotificationTemplate, error)
+	ListTemplates(notificationType NotificationType, limit, offset int) ([]NotificationTemplate, error)
+	BroadcastNotification(adminID string, req BroadcastRequest) (*BroadcastResult, error)
 
 	// User preferences
 	GetUserPreferences(userID string) ([]UserPreference, error)
 	UpdateUserPreference(userID string, req UpdatePreferenceRequest) (*UserPreference, error)
+	GetNotificationSettings(userID string) (*NotificationSettings, error)
+	UpdateNotificationSettings(userID string, req UpdateNotificationSettingsRequest) (*NotificationSettings, error)
 
 	// Device management
 	RegisterDevice(userID string, req RegisterDeviceRequest) (*NotificationDevice, error)
 	GetUserDevices(userID string) ([]NotificationDevice, error)
+	UpdateDevice(userID, deviceID string, req UpdateDeviceRequest) (*NotificationDevice, error)
+	DeleteDevice(userID, deviceID string) error
 	DeactivateDevice(userID, deviceToken string) error
 
 	// System operations
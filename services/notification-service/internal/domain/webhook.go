@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WebhookEvent is an event merchants can receive callbacks for
+type WebhookEvent string
+
+const (
+	WebhookOrderDelivered  WebhookEvent = "order.delivered"
+	WebhookOrderCancelled  WebhookEvent = "order.cancelled"
+	WebhookPayoutCompleted WebhookEvent = "payout.completed"
+)
+
+// WebhookEvents lists every event a webhook can subscribe to
+var WebhookEvents = []WebhookEvent{WebhookOrderDelivered, WebhookOrderCancelled, WebhookPayoutCompleted}
+
+// Webhook request headers. The signature is the hex HMAC-SHA256 of the raw
+// request body keyed with the webhook's secret, prefixed with "sha256=".
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// MaxWebhookAttempts is how many times a delivery is tried before it fails
+const MaxWebhookAttempts = 6
+
+// ValidateWebhookURL checks that rawURL is an https URL that doesn't name an
+// internal host. Hostnames still need checking once resolved, see
+// IsPublicWebhookIP.
+func ValidateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsafeWebhookURL, err)
+	}
+	if parsed.Scheme != "https" {
+		return fmt.Errorf("%w: must use https", ErrUnsafeWebhookURL)
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrUnsafeWebhookURL)
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s is a local host", ErrUnsafeWebhookURL, host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !IsPublicWebhookIP(ip) {
+		return fmt.Errorf("%w: %s is not a public address", ErrUnsafeWebhookURL, host)
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, 100.64.0.0/10
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicWebhookIP reports whether webhooks may be sent to ip. Loopback,
+// private, link-local (including the 169.254.169.254 metadata endpoint) and
+// unspecified addresses are all refused.
+func IsPublicWebhookIP(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// MerchantWebhook is a merchant's callback URL for push events
+type MerchantWebhook struct {
+	ID         string         `json:"id" gorm:"primaryKey"`
+	MerchantID string         `json:"merchant_id" gorm:"index"`
+	URL        string         `json:"url"`
+	Secret     string         `json:"-"`
+	Events     []WebhookEvent `json:"events" gorm:"serializer:json"` // empty subscribes to every event
+	IsActive   bool           `json:"is_active"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// Subscribes reports whether the webhook wants event
+func (w *MerchantWebhook) Subscribes(event WebhookEvent) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event sent to one webhook, with its attempts
+type WebhookDelivery struct {
+	ID             string                `json:"id" gorm:"primaryKey"`
+	EventID        string                `json:"event_id" gorm:"index"` // the bus event it was dispatched for
+	WebhookID      string                `json:"webhook_id" gorm:"index"`
+	MerchantID     string                `json:"merchant_id" gorm:"index"`
+	Event          WebhookEvent          `json:"event"`
+	Payload        string                `json:"payload" gorm:"type:text"` // the exact signed body
+	Status         WebhookDeliveryStatus `json:"status" gorm:"index"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus int                   `json:"response_status,omitempty"` // of the last attempt
+	LastError      string                `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty" gorm:"index"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending" // awaiting its next attempt
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed" // gave up after MaxWebhookAttempts
+)
+
+// WebhookPayload is the JSON body posted to webhooks
+type WebhookPayload struct {
+	ID         string       `json:"id"` // the delivery ID, stable across retries
+	Event      WebhookEvent `json:"event"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	Data       interface{}  `json:"data"`
+}
+
+type RegisterWebhookRequest struct {
+	URL    string         `json:"url" binding:"required,url"`
+	Secret string         `json:"secret,omitempty" binding:"omitempty,min=16"` // generated when empty
+	Events []WebhookEvent `json:"events,omitempty"`
+}
+
+// RegisteredWebhook is returned once on registration, with the signing secret
+type RegisteredWebhook struct {
+	MerchantWebhook
+	Secret string `json:"secret"`
+}
+
+type MerchantWebhookRepository interface {
+	Create(webhook *MerchantWebhook) error
+	GetByID(id string) (*MerchantWebhook, error)
+	GetByMerchantID(merchantID string) ([]MerchantWebhook, error)
+	Delete(id string) error
+}
+
+type WebhookDeliveryRepository interface {
+	// Create records a new delivery; it reports false, changing nothing, when
+	// a delivery with the same ID was already recorded
+	Create(delivery *WebhookDelivery) (bool, error)
+	Update(delivery *WebhookDelivery) error
+	GetByWebhookID(webhookID string, limit, offset int) ([]WebhookDelivery, error)
+	// ClaimDue returns up to limit pending deliveries due by now and moves
+	// their next attempt lease past now, so concurrent sweeps never claim the
+	// same delivery while an attempt is in flight
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]WebhookDelivery, error)
+}
+
+type WebhookService interface {
+	RegisterWebhook(merchantID string, req RegisterWebhookRequest) (*RegisteredWebhook, error)
+	GetWebhooks(merchantID string) ([]MerchantWebhook, error)
+	DeleteWebhook(merchantID, webhookID string) error
+	GetDeliveries(merchantID, webhookID string, limit, offset int) ([]WebhookDelivery, error)
+
+	// Dispatch sends event to each of the merchant's webhooks that subscribe
+	// to it, once per bus event ID
+	Dispatch(eventID, merchantID string, event WebhookEvent, occurredAt time.Time, data interface{}) error
+	// RetryDueDeliveries re-attempts failed deliveries whose backoff has passed
+	RetryDueDeliveries() (int, error)
+}
+
+// WebhookSender posts a signed body; a transport failure is an error, any
+// HTTP response is reported by status code
+type WebhookSender interface {
+	Send(url string, headers map[string]string, body []byte) (int, error)
+}
+
+var (
+	// ErrNotWebhookOwner is returned when a merchant acts on another merchant's webhook
+	ErrNotWebhookOwner = errors.New("webhook belongs to another merchant")
+	// ErrUnsupportedWebhookEvent is returned when subscribing to an unknown event
+	ErrUnsupportedWebhookEvent = errors.New("unsupported webhook event")
+	// ErrUnsafeWebhookURL is returned when a webhook URL isn't a public https endpoint
+	ErrUnsafeWebhookURL = errors.New("webhook URL must be a public https endpoint")
+)
@@ -3,14 +3,19 @@ package main
 import (
 	"log"
 	"net/http"
-	"os"
 
 	"glovo-backend/services/order-service/internal/adapters/client"
 	"glovo-backend/services/order-service/internal/adapters/db"
 	httpAdapter "glovo-backend/services/order-service/internal/adapters/http"
 	"glovo-backend/services/order-service/internal/app"
 	"glovo-backend/services/order-service/internal/domain"
+	"glovo-backend/shared/config"
 	"glovo-backend/shared/database"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/health"
+	"glovo-backend/shared/logging"
+	"glovo-backend/shared/metrics"
+	"glovo-backend/shared/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -39,11 +44,16 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	logging.Setup("order-service")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
 	}
 
+	// Fail fast on missing or malformed configuration
+	cfg := config.MustLoad("order-service", config.Postgres(), config.Auth(), config.EventBus(), config.HTTPPort("PORT", "8002"))
+
 	// Initialize database connection
 	postgresDB := database.ConnectPostgres()
 
@@ -59,29 +69,33 @@ func main() {
 	catalogService := client.NewMockCatalogClient()           // Use mock for development
 	paymentService := client.NewMockPaymentClient()           // Use mock for development
 	notificationService := client.NewMockNotificationClient() // Use mock for development
+	userService := client.NewMockUserClient()                 // Use mock for development
+
+	// Order snapshots are published for analytics
+	eventBus := events.FromEnv()
+	defer eventBus.Close()
+	middleware.AuditImpersonation(eventBus, "order-service")
 
 	// Initialize use case
-	orderService := app.NewOrderService(orderRepo, catalogService, paymentService, notificationService)
+	orderService := app.NewOrderService(orderRepo, catalogService, paymentService, notificationService, userService, eventBus)
 
 	// Initialize HTTP handler
 	orderHandler := httpAdapter.NewOrderHandler(orderService)
 
 	// Setup Gin router
-	router := gin.Default()
-
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type,Authorization")
+	router := gin.New()
+	// Client IPs come from X-Forwarded-For only behind TRUSTED_PROXIES
+	if err := router.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	router.Use(gin.Recovery(), middleware.RequestID(), middleware.RequestLogger())
+	router.Use(metrics.Middleware("order-service"))
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// CORS, restricted to the origins in CORS_ALLOWED_ORIGINS
+	router.Use(middleware.CORS(middleware.CORSConfigFromEnv()))
 
-		c.Next()
-	})
+	// Prometheus metrics
+	router.GET("/metrics", metrics.Handler())
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -91,6 +105,9 @@ func main() {
 		})
 	})
 
+	// Readiness check, verifies database connectivity
+	router.GET("/health/ready", health.Ready("order-service", map[string]health.Check{"postgres": health.Postgres(postgresDB)}))
+
 	// Setup routes
 	orderHandler.SetupRoutes(router)
 
@@ -98,7 +115,7 @@ func main() {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Start server
-	port := getEnv("PORT", "8002")
+	port := cfg.String("PORT")
 	log.Printf("Order Service starting on port %s", port)
 	log.Printf("Swagger documentation available at http://localhost:%s/swagger/index.html", port)
 
@@ -106,10 +123,3 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 }
-
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultValue
-}
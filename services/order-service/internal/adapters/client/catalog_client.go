@@ -8,18 +8,19 @@ import (
 	"os"
 
 	"glovo-backend/services/order-service/internal/domain"
+	"glovo-backend/shared/httpclient"
 )
 
 type catalogClient struct {
 	baseURL string
-	client  *http.Client
+	client  *httpclient.Client
 }
 
 func NewCatalogClient() domain.CatalogService {
 	baseURL := getEnv("CATALOG_SERVICE_URL", "http://localhost:8003")
 	return &catalogClient{
 		baseURL: baseURL,
-		client:  &http.Client{},
+		client:  httpclient.New(serviceConfig()),
 	}
 }
 
@@ -44,12 +45,19 @@ func (c *catalogClient) GetProduct(productID string) (*domain.Product, error) {
 	return &product, nil
 }
 
-func (c *catalogClient) ValidateOrder(merchantID string, items []domain.OrderItemReq) (*domain.OrderValidation, error) {
-	url := fmt.Sprintf("%s/api/v1/stores/%s/validate-order", c.baseURL, merchantID)
+func (c *catalogClient) ValidateOrder(merchantID string, items []domain.OrderItemReq, deliveryInfo domain.DeliveryInfo) (*domain.OrderValidation, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/stores/%s/validate-order", c.baseURL, merchantID)
 
 	reqBody := map[string]interface{}{
 		"items": items,
 	}
+	// The catalog only checks the store's delivery area when it has a location
+	if deliveryInfo.Latitude != 0 || deliveryInfo.Longitude != 0 {
+		reqBody["delivery_location"] = map[string]float64{
+			"latitude":  deliveryInfo.Latitude,
+			"longitude": deliveryInfo.Longitude,
+		}
+	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -74,6 +82,55 @@ func (c *catalogClient) ValidateOrder(merchantID string, items []domain.OrderIte
 	return &validation, nil
 }
 
+// stockItem is the catalog's view of an order line for stock changes
+type stockItem struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// stockRequest is the catalog's request to reserve or release an order's stock
+type stockRequest struct {
+	OrderID string      `json:"order_id"`
+	Items   []stockItem `json:"items"`
+}
+
+func (c *catalogClient) DecrementStock(merchantID, orderID string, items []domain.OrderItem) error {
+	return c.changeStock(merchantID, orderID, "decrement", items)
+}
+
+func (c *catalogClient) RestoreStock(merchantID, orderID string, items []domain.OrderItem) error {
+	return c.changeStock(merchantID, orderID, "restore", items)
+}
+
+func (c *catalogClient) changeStock(merchantID, orderID, action string, items []domain.OrderItem) error {
+	url := fmt.Sprintf("%s/api/v1/internal/stores/%s/stock/%s", c.baseURL, merchantID, action)
+
+	reqBody := stockRequest{OrderID: orderID, Items: make([]stockItem, len(items))}
+	for i, item := range items {
+		reqBody.Items[i] = stockItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to %s stock: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		return domain.ErrInsufficientStock
+	default:
+		return fmt.Errorf("catalog service returned status %d", resp.StatusCode)
+	}
+}
+
 // Mock implementation for development
 type mockCatalogClient struct{}
 
@@ -92,18 +149,21 @@ func (m *mockCatalogClient) GetProduct(productID string) (*domain.Product, error
 	}, nil
 }
 
-func (m *mockCatalogClient) ValidateOrder(merchantID string, items []domain.OrderItemReq) (*domain.OrderValidation, error) {
+func (m *mockCatalogClient) ValidateOrder(merchantID string, items []domain.OrderItemReq, deliveryInfo domain.DeliveryInfo) (*domain.OrderValidation, error) {
 	var validatedItems []domain.ValidatedItem
 	var totalAmount float64
 
 	for _, item := range items {
 		validatedItem := domain.ValidatedItem{
-			ProductID: item.ProductID,
-			Name:      fmt.Sprintf("Product %s", item.ProductID),
-			Price:     12.99,
-			Quantity:  item.Quantity,
-			Available: true,
-			Subtotal:  12.99 * float64(item.Quantity),
+			ProductID:     item.ProductID,
+			Name:          fmt.Sprintf("Product %s", item.ProductID),
+			Exists:        true,
+			Available:     true,
+			OptionsValid:  true,
+			Price:         12.99,
+			ExpectedPrice: item.UnitPrice,
+			Quantity:      item.Quantity,
+			Subtotal:      12.99 * float64(item.Quantity),
 		}
 		validatedItems = append(validatedItems, validatedItem)
 		totalAmount += validatedItem.Subtotal
@@ -113,13 +173,30 @@ func (m *mockCatalogClient) ValidateOrder(merchantID string, items []domain.Orde
 		Valid:       true,
 		Items:       validatedItems,
 		TotalAmount: totalAmount,
+		PrepTime:    15,
 		Errors:      []string{},
 	}, nil
 }
 
+func (m *mockCatalogClient) DecrementStock(merchantID, orderID string, items []domain.OrderItem) error {
+	return nil
+}
+
+func (m *mockCatalogClient) RestoreStock(merchantID, orderID string, items []domain.OrderItem) error {
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// serviceConfig identifies order-service to internal endpoints of the
+// services it calls
+func serviceConfig() httpclient.Config {
+	config := httpclient.DefaultConfig()
+	config.ServiceName = "order-service"
+	return config
+}
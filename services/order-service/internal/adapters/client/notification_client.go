@@ -8,18 +8,19 @@ import (
 	"net/http"
 
 	"glovo-backend/services/order-service/internal/domain"
+	"glovo-backend/shared/httpclient"
 )
 
 type notificationClient struct {
 	baseURL string
-	client  *http.Client
+	client  *httpclient.Client
 }
 
 func NewNotificationClient() domain.NotificationService {
 	baseURL := getEnv("NOTIFICATION_SERVICE_URL", "http://localhost:8008")
 	return &notificationClient{
 		baseURL: baseURL,
-		client:  &http.Client{},
+		client:  httpclient.New(serviceConfig()),
 	}
 }
 
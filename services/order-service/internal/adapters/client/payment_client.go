@@ -5,23 +5,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
 
 	"glovo-backend/services/order-service/internal/domain"
+	"glovo-backend/shared/httpclient"
 
 	"github.com/google/uuid"
 )
 
 type paymentClient struct {
 	baseURL string
-	client  *http.Client
+	client  *httpclient.Client
 }
 
 func NewPaymentClient() domain.PaymentService {
 	baseURL := getEnv("PAYMENT_SERVICE_URL", "http://localhost:8007")
 	return &paymentClient{
 		baseURL: baseURL,
-		client:  &http.Client{},
+		client:  httpclient.New(serviceConfig()),
 	}
 }
 
@@ -33,6 +33,7 @@ func (p *paymentClient) ProcessPayment(orderID string, amount float64, paymentIn
 		"amount":    amount,
 		"method":    paymentInfo.Method,
 		"reference": paymentInfo.Reference,
+		"tip":       paymentInfo.Tip,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"glovo-backend/services/order-service/internal/domain"
+	"glovo-backend/shared/httpclient"
+)
+
+type userClient struct {
+	baseURL string
+	client  *httpclient.Client
+}
+
+func NewUserClient() domain.UserService {
+	baseURL := getEnv("USER_SERVICE_URL", "http://localhost:8001")
+	return &userClient{
+		baseURL: baseURL,
+		client:  httpclient.New(serviceConfig()),
+	}
+}
+
+func (u *userClient) GetSavedAddress(userID, addressID string) (*domain.SavedAddress, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/users/%s/addresses/%s", u.baseURL, userID, addressID)
+
+	resp, err := u.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved address: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user service returned status %d", resp.StatusCode)
+	}
+
+	var address domain.SavedAddress
+	if err := json.NewDecoder(resp.Body).Decode(&address); err != nil {
+		return nil, fmt.Errorf("failed to decode address response: %w", err)
+	}
+
+	return &address, nil
+}
+
+// Mock implementation for development
+type mockUserClient struct{}
+
+func NewMockUserClient() domain.UserService {
+	return &mockUserClient{}
+}
+
+func (m *mockUserClient) GetSavedAddress(userID, addressID string) (*domain.SavedAddress, error) {
+	return &domain.SavedAddress{
+		ID:        addressID,
+		UserID:    userID,
+		Label:     "home",
+		Address:   "123 Main St, New York, NY",
+		Latitude:  40.7128,
+		Longitude: -74.0060,
+	}, nil
+}
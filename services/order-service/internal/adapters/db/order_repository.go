@@ -75,6 +75,14 @@ func (r *orderRepository) Update(order *domain.Order) error {
 	return r.db.Save(order).Error
 }
 
+func (r *orderRepository) UpdateStatus(order *domain.Order, from domain.OrderStatus) (bool, error) {
+	result := r.db.Model(order).
+		Where("status = ?", from).
+		Select("status", "estimated_time", "completed_at", "cancelled_at", "cancellation_reason", "updated_at").
+		Updates(order)
+	return result.RowsAffected > 0, result.Error
+}
+
 func (r *orderRepository) Delete(id string) error {
 	return r.db.Where("id = ?", id).Delete(&domain.Order{}).Error
 }
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -68,7 +69,7 @@ func (h *OrderHandler) SetupRoutes(router *gin.Engine) {
 
 // CreateOrder godoc
 // @Summary Create a new order
-// @Description Create a new order for the authenticated customer
+// @Description Create a new order for the authenticated customer. Pass address_id to deliver to one of their saved addresses.
 // @Tags Orders
 // @Accept json
 // @Produce json
@@ -169,6 +170,7 @@ func (h *OrderHandler) GetOrderHistory(c *gin.Context) {
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /api/v1/orders/{id}/status [put]
 func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	orderID := c.Param("id")
@@ -183,6 +185,10 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 
 	response, err := h.orderService.UpdateOrderStatus(orderID, req, userID, role)
 	if err != nil {
+		if errors.Is(err, domain.ErrInsufficientStock) || errors.Is(err, domain.ErrOrderStatusChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -204,6 +210,7 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /api/v1/orders/{id}/cancel [put]
 func (h *OrderHandler) CancelOrder(c *gin.Context) {
 	orderID := c.Param("id")
@@ -223,6 +230,10 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 
 	response, err := h.orderService.CancelOrder(orderID, userID, role, reason)
 	if err != nil {
+		if errors.Is(err, domain.ErrOrderStatusChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -1,12 +1,15 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"glovo-backend/services/order-service/internal/domain"
 	"glovo-backend/shared/auth"
+	"glovo-backend/shared/events"
 
 	"github.com/google/uuid"
 )
@@ -16,6 +19,8 @@ type orderService struct {
 	catalogService      domain.CatalogService
 	paymentService      domain.PaymentService
 	notificationService domain.NotificationService
+	userService         domain.UserService
+	eventBus            events.Bus
 }
 
 func NewOrderService(
@@ -23,18 +28,36 @@ func NewOrderService(
 	catalogService domain.CatalogService,
 	paymentService domain.PaymentService,
 	notificationService domain.NotificationService,
+	userService domain.UserService,
+	eventBus events.Bus,
 ) domain.OrderService {
 	return &orderService{
 		orderRepo:           orderRepo,
 		catalogService:      catalogService,
 		paymentService:      paymentService,
 		notificationService: notificationService,
+		userService:         userService,
+		eventBus:            eventBus,
 	}
 }
 
 func (s *orderService) CreateOrder(customerID string, req domain.CreateOrderRequest) (*domain.OrderResponse, error) {
+	// Deliver to a saved address when one is given
+	if req.AddressID != "" {
+		saved, err := s.userService.GetSavedAddress(customerID, req.AddressID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load saved address: %w", err)
+		}
+		req.DeliveryInfo.Address = saved.Address
+		req.DeliveryInfo.Latitude = saved.Latitude
+		req.DeliveryInfo.Longitude = saved.Longitude
+		if req.DeliveryInfo.Notes == "" {
+			req.DeliveryInfo.Notes = saved.Notes
+		}
+	}
+
 	// Validate order with catalog service
-	validation, err := s.catalogService.ValidateOrder(req.MerchantID, req.Items)
+	validation, err := s.catalogService.ValidateOrder(req.MerchantID, req.Items, req.DeliveryInfo)
 	if err != nil {
 		return nil, fmt.Errorf("failed to validate order: %w", err)
 	}
@@ -45,20 +68,23 @@ func (s *orderService) CreateOrder(customerID string, req domain.CreateOrderRequ
 
 	// Create order entity
 	order := &domain.Order{
-		ID:           uuid.New().String(),
-		CustomerID:   customerID,
-		MerchantID:   req.MerchantID,
-		Status:       domain.StatusPending,
-		DeliveryInfo: req.DeliveryInfo,
-		PaymentInfo:  req.PaymentInfo,
-		TotalAmount:  validation.TotalAmount,
-		DeliveryFee:  calculateDeliveryFee(req.DeliveryInfo),
-		ServiceFee:   calculateServiceFee(validation.TotalAmount),
-		TaxAmount:    calculateTax(validation.TotalAmount),
-		PlacedAt:     time.Now(),
-		ScheduledFor: req.ScheduledFor,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:            uuid.New().String(),
+		CustomerID:    customerID,
+		MerchantID:    req.MerchantID,
+		Status:        domain.StatusPending,
+		DeliveryInfo:  req.DeliveryInfo,
+		PaymentInfo:   req.PaymentInfo,
+		TotalAmount:   validation.TotalAmount,
+		DeliveryFee:   calculateDeliveryFee(req.DeliveryInfo),
+		ServiceFee:    calculateServiceFee(validation.TotalAmount),
+		TaxAmount:     calculateTax(validation.TotalAmount),
+		PlacedAt:      time.Now(),
+		ScheduledFor:  req.ScheduledFor,
+		PrepTime:      validation.PrepTime,
+		HasLargeItems: validation.HasLargeItems,
+		HasColdItems:  validation.HasColdItems,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}
 
 	// Calculate final amount
@@ -75,10 +101,11 @@ func (s *orderService) CreateOrder(customerID string, req domain.CreateOrderRequ
 			Quantity:  validatedItem.Quantity,
 		}
 
-		// Find corresponding notes from request
+		// Find corresponding notes and options from request
 		for _, reqItem := range req.Items {
 			if reqItem.ProductID == validatedItem.ProductID {
 				item.Notes = reqItem.Notes
+				item.Options = reqItem.Options
 				break
 			}
 		}
@@ -109,8 +136,7 @@ func (s *orderService) CreateOrder(customerID string, req domain.CreateOrderRequ
 	message := fmt.Sprintf("Order #%s has been placed successfully", order.ID[:8])
 	s.notificationService.SendOrderNotification(order.ID, customerID, message)
 
-	// Emit OrderCreated event (placeholder)
-	s.emitOrderCreatedEvent(order)
+	s.publishStatusChanged(order)
 
 	return &domain.OrderResponse{
 		Order: *order,
@@ -186,6 +212,11 @@ func (s *orderService) UpdateOrderStatus(orderID string, req domain.UpdateOrderS
 		return nil, fmt.Errorf("invalid status transition from %s to %s", order.Status, req.Status)
 	}
 
+	// Stock is reserved when the merchant confirms the order, so only orders
+	// cancelled after that have stock to give back
+	from := order.Status
+	stockReserved := from != domain.StatusPending
+
 	// Update order
 	order.Status = req.Status
 	order.UpdatedAt = time.Now()
@@ -207,9 +238,28 @@ func (s *orderService) UpdateOrderStatus(orderID string, req domain.UpdateOrderS
 		}
 	}
 
-	if err := s.orderRepo.Update(order); err != nil {
+	// Only the request that moves the order out of the status it read goes
+	// on to reserve or release stock
+	updated, err := s.orderRepo.UpdateStatus(order, from)
+	if err != nil {
 		return nil, fmt.Errorf("failed to update order: %w", err)
 	}
+	if !updated {
+		return nil, domain.ErrOrderStatusChanged
+	}
+
+	if req.Status == domain.StatusConfirmed {
+		if err := s.catalogService.DecrementStock(order.MerchantID, order.ID, order.Items); err != nil {
+			s.revertStatus(order, from)
+			return nil, fmt.Errorf("failed to reserve stock: %w", err)
+		}
+	}
+
+	if req.Status == domain.StatusCancelled && stockReserved {
+		s.restoreStock(order)
+	}
+
+	s.publishStatusChanged(order)
 
 	// Send notification
 	message := fmt.Sprintf("Order #%s status updated to %s", order.ID[:8], req.Status)
@@ -345,11 +395,60 @@ func (s *orderService) buildTrackingInfo(order *domain.Order) *domain.OrderTrack
 	}
 }
 
-func (s *orderService) emitOrderCreatedEvent(order *domain.Order) {
-	// Placeholder for event emission
-	// In real implementation, this would publish to message queue
-	fmt.Printf("Event: OrderCreated - OrderID: %s, CustomerID: %s, MerchantID: %s\n",
-		order.ID, order.CustomerID, order.MerchantID)
+// restoreStock gives back the stock reserved for an order. The order has
+// already moved on, so a failure is logged rather than returned.
+func (s *orderService) restoreStock(order *domain.Order) {
+	if err := s.catalogService.RestoreStock(order.MerchantID, order.ID, order.Items); err != nil {
+		log.Printf("Failed to restore stock for order %s: %v", order.ID, err)
+	}
+}
+
+// revertStatus moves an order whose stock couldn't be reserved back to the
+// status it was confirmed from, unless another request has moved it since
+func (s *orderService) revertStatus(order *domain.Order, to domain.OrderStatus) {
+	from := order.Status
+	order.Status = to
+	order.UpdatedAt = time.Now()
+	reverted, err := s.orderRepo.UpdateStatus(order, from)
+	if err != nil || !reverted {
+		log.Printf("Failed to revert order %s to %s after a failed confirmation: %v", order.ID, to, err)
+	}
+}
+
+// publishStatusChanged publishes a snapshot of the order; consumers such as
+// analytics keep their own copy of orders from these events
+func (s *orderService) publishStatusChanged(order *domain.Order) {
+	payload := events.OrderStatusChangedPayload{
+		OrderID:       order.ID,
+		CustomerID:    order.CustomerID,
+		MerchantID:    order.MerchantID,
+		Status:        string(order.Status),
+		TotalAmount:   order.TotalAmount,
+		DeliveryFee:   order.DeliveryFee,
+		ServiceFee:    order.ServiceFee,
+		TaxAmount:     order.TaxAmount,
+		FinalAmount:   order.FinalAmount,
+		EstimatedTime: order.EstimatedTime,
+		PlacedAt:      order.PlacedAt,
+		CompletedAt:   order.CompletedAt,
+		CancelledAt:   order.CancelledAt,
+		ChangedAt:     order.UpdatedAt,
+	}
+	if order.DriverID != nil {
+		payload.DriverID = *order.DriverID
+	}
+	for _, item := range order.Items {
+		payload.Items = append(payload.Items, events.OrderItemSnapshot{
+			ProductID: item.ProductID,
+			Name:      item.Name,
+			Price:     item.Price,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	if err := s.eventBus.Publish(context.Background(), events.OrderStatusChanged, payload); err != nil {
+		log.Printf("Failed to publish %s for order %s: %v", events.OrderStatusChanged, order.ID, err)
+	}
 }
 
 func calculateDeliveryFee(deliveryInfo domain.DeliveryInfo) float64 {
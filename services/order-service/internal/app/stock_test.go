@@ -0,0 +1,169 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/order-service/internal/domain"
+	"glovo-backend/shared/auth"
+	"glovo-backend/shared/events"
+)
+
+type fakeOrderRepo struct {
+	domain.OrderRepository
+	order     domain.Order
+	updateErr error
+	// stale, when set, is returned by GetByID instead of the stored order,
+	// like a read that raced another request's update
+	stale *domain.Order
+}
+
+func (r *fakeOrderRepo) GetByID(id string) (*domain.Order, error) {
+	order := r.order
+	if r.stale != nil {
+		order = *r.stale
+	}
+	return &order, nil
+}
+
+func (r *fakeOrderRepo) UpdateStatus(order *domain.Order, from domain.OrderStatus) (bool, error) {
+	if r.updateErr != nil {
+		return false, r.updateErr
+	}
+	if r.order.Status != from {
+		return false, nil
+	}
+	r.order = *order
+	return true, nil
+}
+
+type fakeCatalog struct {
+	domain.CatalogService
+	decrementErr error
+	decremented  int
+	restored     int
+}
+
+func (c *fakeCatalog) DecrementStock(merchantID, orderID string, items []domain.OrderItem) error {
+	if c.decrementErr != nil {
+		return c.decrementErr
+	}
+	c.decremented++
+	return nil
+}
+
+func (c *fakeCatalog) RestoreStock(merchantID, orderID string, items []domain.OrderItem) error {
+	c.restored++
+	return nil
+}
+
+type fakeNotifier struct{}
+
+func (fakeNotifier) SendOrderNotification(orderID string, userID string, message string) error {
+	return nil
+}
+
+func TestUpdateOrderStatusStock(t *testing.T) {
+	tests := []struct {
+		name          string
+		from          domain.OrderStatus
+		to            domain.OrderStatus
+		decrementErr  error
+		updateErr     error
+		wantErr       error
+		wantStatus    domain.OrderStatus
+		wantDecrement int
+		wantRestore   int
+	}{
+		{name: "confirm reserves stock", from: domain.StatusPending, to: domain.StatusConfirmed, wantStatus: domain.StatusConfirmed, wantDecrement: 1},
+		{name: "confirm without stock is rejected", from: domain.StatusPending, to: domain.StatusConfirmed, decrementErr: domain.ErrInsufficientStock, wantErr: domain.ErrInsufficientStock, wantStatus: domain.StatusPending},
+		{name: "failed confirm reserves nothing", from: domain.StatusPending, to: domain.StatusConfirmed, updateErr: errors.New("db down"), wantStatus: domain.StatusPending},
+		{name: "cancel before confirm has nothing to restore", from: domain.StatusPending, to: domain.StatusCancelled, wantStatus: domain.StatusCancelled},
+		{name: "cancel after confirm restores stock", from: domain.StatusConfirmed, to: domain.StatusCancelled, wantStatus: domain.StatusCancelled, wantRestore: 1},
+		{name: "cancel while preparing restores stock", from: domain.StatusPreparing, to: domain.StatusCancelled, wantStatus: domain.StatusCancelled, wantRestore: 1},
+		{name: "failed cancel keeps stock reserved", from: domain.StatusConfirmed, to: domain.StatusCancelled, updateErr: errors.New("db down"), wantStatus: domain.StatusConfirmed},
+		{name: "other transitions leave stock alone", from: domain.StatusConfirmed, to: domain.StatusPreparing, wantStatus: domain.StatusPreparing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeOrderRepo{
+				order: domain.Order{
+					ID:         "order-12345678",
+					CustomerID: "customer-1",
+					MerchantID: "merchant-1",
+					Status:     tt.from,
+					Items:      []domain.OrderItem{{ProductID: "product-1", Quantity: 2}},
+				},
+				updateErr: tt.updateErr,
+			}
+			catalog := &fakeCatalog{decrementErr: tt.decrementErr}
+			svc := NewOrderService(repo, catalog, nil, fakeNotifier{}, nil, events.NewInMemoryBus())
+
+			_, err := svc.UpdateOrderStatus(repo.order.ID, domain.UpdateOrderStatusRequest{Status: tt.to}, "merchant-1", auth.RoleMerchant)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			case tt.updateErr != nil:
+				if err == nil {
+					t.Fatalf("expected the update error to be returned")
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if repo.order.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", repo.order.Status, tt.wantStatus)
+			}
+			if catalog.decremented != tt.wantDecrement || catalog.restored != tt.wantRestore {
+				t.Fatalf("decremented %d and restored %d times, want %d and %d", catalog.decremented, catalog.restored, tt.wantDecrement, tt.wantRestore)
+			}
+		})
+	}
+}
+
+func TestUpdateOrderStatusRace(t *testing.T) {
+	tests := []struct {
+		name          string
+		from          domain.OrderStatus
+		to            domain.OrderStatus
+		wantDecrement int
+		wantRestore   int
+	}{
+		{name: "concurrent confirms reserve once", from: domain.StatusPending, to: domain.StatusConfirmed, wantDecrement: 1},
+		{name: "concurrent cancels restore once", from: domain.StatusConfirmed, to: domain.StatusCancelled, wantRestore: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			read := domain.Order{
+				ID:         "order-12345678",
+				CustomerID: "customer-1",
+				MerchantID: "merchant-1",
+				Status:     tt.from,
+				Items:      []domain.OrderItem{{ProductID: "product-1", Quantity: 2}},
+			}
+			// Both requests read the order before either updated it
+			repo := &fakeOrderRepo{order: read, stale: &read}
+			catalog := &fakeCatalog{}
+			svc := NewOrderService(repo, catalog, nil, fakeNotifier{}, nil, events.NewInMemoryBus())
+
+			req := domain.UpdateOrderStatusRequest{Status: tt.to}
+			if _, err := svc.UpdateOrderStatus(read.ID, req, "merchant-1", auth.RoleMerchant); err != nil {
+				t.Fatalf("first update: %v", err)
+			}
+			if _, err := svc.UpdateOrderStatus(read.ID, req, "merchant-1", auth.RoleMerchant); !errors.Is(err, domain.ErrOrderStatusChanged) {
+				t.Fatalf("second update: expected %v, got %v", domain.ErrOrderStatusChanged, err)
+			}
+
+			if repo.order.Status != tt.to {
+				t.Fatalf("status = %s, want %s", repo.order.Status, tt.to)
+			}
+			if catalog.decremented != tt.wantDecrement || catalog.restored != tt.wantRestore {
+				t.Fatalf("decremented %d and restored %d times, want %d and %d", catalog.decremented, catalog.restored, tt.wantDecrement, tt.wantRestore)
+			}
+		})
+	}
+}
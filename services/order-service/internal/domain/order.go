@@ -1,11 +1,20 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"glovo-backend/shared/auth"
 )
 
+// ErrInsufficientStock is returned when the catalog can't reserve stock for
+// an order being confirmed
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrOrderStatusChanged is returned when another request changed an order's
+// status while it was being updated
+var ErrOrderStatusChanged = errors.New("order status changed")
+
 // Order represents the domain entity
 type Order struct {
 	ID                 string       `json:"id" gorm:"primaryKey"`
@@ -24,6 +33,9 @@ type Order struct {
 	PlacedAt           time.Time    `json:"placed_at"`
 	ScheduledFor       *time.Time   `json:"scheduled_for,omitempty"`
 	EstimatedTime      *int         `json:"estimated_time,omitempty"` // in minutes
+	PrepTime           int          `json:"prep_time"`                // minutes the merchant needs to prepare the order
+	HasLargeItems      bool         `json:"has_large_items"`          // some item is too large for a bicycle
+	HasColdItems       bool         `json:"has_cold_items"`           // some item must be kept chilled
 	CompletedAt        *time.Time   `json:"completed_at,omitempty"`
 	CancelledAt        *time.Time   `json:"cancelled_at,omitempty"`
 	CancellationReason *string      `json:"cancellation_reason,omitempty"`
@@ -32,13 +44,20 @@ type Order struct {
 }
 
 type OrderItem struct {
-	ID        string  `json:"id" gorm:"primaryKey"`
-	OrderID   string  `json:"order_id"`
-	ProductID string  `json:"product_id"`
-	Name      string  `json:"name"`
-	Price     float64 `json:"price"`
-	Quantity  int     `json:"quantity"`
-	Notes     string  `json:"notes,omitempty"`
+	ID        string           `json:"id" gorm:"primaryKey"`
+	OrderID   string           `json:"order_id"`
+	ProductID string           `json:"product_id"`
+	Name      string           `json:"name"`
+	Price     float64          `json:"price"` // unit price including option surcharges
+	Quantity  int              `json:"quantity"`
+	Options   []SelectedOption `json:"options,omitempty" gorm:"serializer:json"`
+	Notes     string           `json:"notes,omitempty"`
+}
+
+// SelectedOption is the customer's choice(s) for one of a product's options
+type SelectedOption struct {
+	OptionID  string   `json:"option_id"`
+	ChoiceIDs []string `json:"choice_ids"`
 }
 
 type DeliveryInfo struct {
@@ -50,9 +69,10 @@ type DeliveryInfo struct {
 }
 
 type PaymentInfo struct {
-	Method    string `json:"method"`
-	Status    string `json:"status"`
-	Reference string `json:"reference,omitempty"`
+	Method    string  `json:"method"`
+	Status    string  `json:"status"`
+	Reference string  `json:"reference,omitempty"`
+	Tip       float64 `json:"tip,omitempty" binding:"min=0"` // for the driver, charged separately on delivery
 }
 
 type OrderStatus string
@@ -74,15 +94,18 @@ type CreateOrderRequest struct {
 	MerchantID   string         `json:"merchant_id" binding:"required"`
 	Items        []OrderItemReq `json:"items" binding:"required,min=1"`
 	DeliveryInfo DeliveryInfo   `json:"delivery_info" binding:"required"`
+	AddressID    string         `json:"address_id,omitempty"` // saved address; fills the delivery_info address and coordinates
 	PaymentInfo  PaymentInfo    `json:"payment_info" binding:"required"`
 	ScheduledFor *time.Time     `json:"scheduled_for,omitempty"`
 	Notes        string         `json:"notes,omitempty"`
 }
 
 type OrderItemReq struct {
-	ProductID string `json:"product_id" binding:"required"`
-	Quantity  int    `json:"quantity" binding:"required,min=1"`
-	Notes     string `json:"notes,omitempty"`
+	ProductID string           `json:"product_id" binding:"required"`
+	Quantity  int              `json:"quantity" binding:"required,min=1"`
+	UnitPrice float64          `json:"unit_price,omitempty"` // price shown to the customer; the order fails if it changed
+	Options   []SelectedOption `json:"options,omitempty"`
+	Notes     string           `json:"notes,omitempty"`
 }
 
 type UpdateOrderStatusRequest struct {
@@ -138,6 +161,10 @@ type OrderRepository interface {
 	GetByDriverID(driverID string, limit, offset int) ([]Order, error)
 	GetByStatus(status OrderStatus, limit, offset int) ([]Order, error)
 	Update(order *Order) error
+	// UpdateStatus saves the order's status and the fields that change with it
+	// only if its status is still from; it reports false, changing nothing,
+	// when another request moved the order first
+	UpdateStatus(order *Order, from OrderStatus) (bool, error)
 	Delete(id string) error
 	List(limit, offset int) ([]Order, error)
 }
@@ -157,7 +184,15 @@ type OrderService interface {
 // External service interfaces
 type CatalogService interface {
 	GetProduct(productID string) (*Product, error)
-	ValidateOrder(merchantID string, items []OrderItemReq) (*OrderValidation, error)
+	ValidateOrder(merchantID string, items []OrderItemReq, deliveryInfo DeliveryInfo) (*OrderValidation, error)
+	// DecrementStock reserves the order's items when it is confirmed, failing
+	// with ErrInsufficientStock if any tracked product is short. The catalog
+	// reserves an order's stock at most once.
+	DecrementStock(merchantID, orderID string, items []OrderItem) error
+	// RestoreStock releases the items of a confirmed order that was cancelled.
+	// The catalog releases an order's stock at most once, and never reserves
+	// it after it was released.
+	RestoreStock(merchantID, orderID string, items []OrderItem) error
 }
 
 type PaymentService interface {
@@ -168,7 +203,21 @@ type NotificationService interface {
 	SendOrderNotification(orderID string, userID string, message string) error
 }
 
+type UserService interface {
+	GetSavedAddress(userID, addressID string) (*SavedAddress, error)
+}
+
 // External DTOs
+type SavedAddress struct {
+	ID        string  `json:"id"`
+	UserID    string  `json:"user_id"`
+	Label     string  `json:"label"`
+	Address   string  `json:"address"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Notes     string  `json:"notes,omitempty"`
+}
+
 type Product struct {
 	ID          string  `json:"id"`
 	Name        string  `json:"name"`
@@ -178,19 +227,28 @@ type Product struct {
 }
 
 type OrderValidation struct {
-	Valid       bool            `json:"valid"`
-	Items       []ValidatedItem `json:"items"`
-	TotalAmount float64         `json:"total_amount"`
-	Errors      []string        `json:"errors,omitempty"`
+	Valid         bool            `json:"valid"`
+	Items         []ValidatedItem `json:"items"`
+	TotalAmount   float64         `json:"total_amount"`
+	PriceChanged  bool            `json:"price_changed"`
+	PrepTime      int             `json:"prep_time"` // in minutes
+	HasLargeItems bool            `json:"has_large_items"`
+	HasColdItems  bool            `json:"has_cold_items"`
+	Errors        []string        `json:"errors,omitempty"`
 }
 
 type ValidatedItem struct {
-	ProductID string  `json:"product_id"`
-	Name      string  `json:"name"`
-	Price     float64 `json:"price"`
-	Quantity  int     `json:"quantity"`
-	Available bool    `json:"available"`
-	Subtotal  float64 `json:"subtotal"`
+	ProductID     string  `json:"product_id"`
+	Name          string  `json:"name"`
+	Exists        bool    `json:"exists"`
+	Available     bool    `json:"available"`
+	OptionsValid  bool    `json:"options_valid"`
+	Issue         string  `json:"issue,omitempty"`
+	Price         float64 `json:"price"`
+	ExpectedPrice float64 `json:"expected_price,omitempty"`
+	PriceChanged  bool    `json:"price_changed"`
+	Quantity      int     `json:"quantity"`
+	Subtotal      float64 `json:"subtotal"`
 }
 
 type PaymentResult struct {
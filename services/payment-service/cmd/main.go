@@ -1,20 +1,27 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
 	"glovo-backend/services/payment-service/internal/adapters/client"
 	"glovo-backend/services/payment-service/internal/adapters/db"
 	httpHandler "glovo-backend/services/payment-service/internal/adapters/http"
+	"glovo-backend/services/payment-service/internal/adapters/subscriber"
 	"glovo-backend/services/payment-service/internal/app"
 	"glovo-backend/services/payment-service/internal/domain"
 	"glovo-backend/shared/auth"
+	"glovo-backend/shared/config"
 	"glovo-backend/shared/database"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/health"
+	"glovo-backend/shared/logging"
+	"glovo-backend/shared/metrics"
 	"glovo-backend/shared/middleware"
+	"glovo-backend/shared/server"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -42,12 +49,23 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
+// @securityDefinitions.apikey ServiceToken
+// @in header
+// @name X-Service-Token
+// @description Signed service token for internal service-to-service calls.
+
 func main() {
+	logging.Setup("payment-service")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
 	}
 
+	// Fail fast on missing or malformed configuration
+	cfg := config.MustLoad("payment-service", config.Postgres(), config.Auth(), config.EventBus(), config.HTTPPort("PAYMENT_SERVICE_PORT", "8007"),
+		[]config.Var{{Key: "MERCHANT_PAYOUT_MINIMUM", Kind: config.Float, Default: "10"}})
+
 	// Initialize database connection
 	postgresDB := database.ConnectPostgres()
 
@@ -57,6 +75,9 @@ func main() {
 		&domain.Transaction{},
 		&domain.PaymentMethod{},
 		&domain.Commission{},
+		&domain.Dispute{},
+		&domain.Voucher{},
+		&domain.VoucherRedemption{},
 	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
@@ -66,37 +87,62 @@ func main() {
 	transactionRepo := db.NewTransactionRepository(postgresDB)
 	paymentMethodRepo := db.NewPaymentMethodRepository(postgresDB)
 	commissionRepo := db.NewCommissionRepository(postgresDB)
+	disputeRepo := db.NewDisputeRepository(postgresDB)
+	voucherRepo := db.NewVoucherRepository(postgresDB)
 
 	// Initialize external service clients (mock for now)
 	stripeService := client.NewMockStripeService()
 	bankService := client.NewMockBankService()
 
+	// Event bus for payout events and delivery subscriptions
+	eventBus := events.FromEnv()
+	defer eventBus.Close()
+	middleware.AuditImpersonation(eventBus, "payment-service")
+
 	// Initialize use case
 	paymentService := app.NewPaymentService(
 		walletRepo,
 		transactionRepo,
 		paymentMethodRepo,
 		commissionRepo,
+		disputeRepo,
+		voucherRepo,
 		stripeService,
 		bankService,
+		eventBus,
+		cfg.Float("MERCHANT_PAYOUT_MINIMUM"),
 	)
 
-	// Setup Gin router
-	router := gin.Default()
-
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type,Authorization")
+	// Credit driver earnings when their deliveries complete
+	if err := subscriber.NewDeliverySubscriber(paymentService).Register(eventBus); err != nil {
+		log.Fatal("Failed to subscribe to delivery events:", err)
+	}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
+	// Release authorization holds that were never captured
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := paymentService.ExpireAuthorizations(); err != nil {
+				log.Printf("Authorization expiry sweep failed: %v", err)
+			}
 		}
+	}()
 
-		c.Next()
-	})
+	// Setup Gin router
+	router := gin.New()
+	// Client IPs come from X-Forwarded-For only behind TRUSTED_PROXIES
+	if err := router.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	router.Use(gin.Recovery(), middleware.RequestID(), middleware.RequestLogger())
+	router.Use(metrics.Middleware("payment-service"))
+
+	// CORS, restricted to the origins in CORS_ALLOWED_ORIGINS
+	router.Use(middleware.CORS(middleware.CORSConfigFromEnv()))
+
+	// Prometheus metrics
+	router.GET("/metrics", metrics.Handler())
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -106,6 +152,9 @@ func main() {
 		})
 	})
 
+	// Readiness check, verifies database connectivity
+	router.GET("/health/ready", health.Ready("payment-service", map[string]health.Check{"postgres": health.Postgres(postgresDB)}))
+
 	// Initialize HTTP handler
 	handler := httpHandler.NewPaymentHandler(paymentService)
 
@@ -173,26 +222,11 @@ func main() {
 			})
 		}
 
-		// Payment processing routes (authenticated)
+		// Payment processing routes (internal). These move money between
+		// wallets named in the request body, so only other services may call them
 		payments := v1.Group("/payments")
-		payments.Use(middleware.AuthMiddleware())
+		payments.Use(middleware.ServiceAuth())
 		{
-			payments.POST("/process", func(c *gin.Context) {
-				var req domain.ProcessPaymentRequest
-				if err := c.ShouldBindJSON(&req); err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-					return
-				}
-
-				response, err := paymentService.ProcessPayment(req)
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-					return
-				}
-
-				c.JSON(http.StatusOK, response)
-			})
-
 			payments.POST("/refund", func(c *gin.Context) {
 				var req domain.RefundRequest
 				if err := c.ShouldBindJSON(&req); err != nil {
@@ -326,7 +360,13 @@ func main() {
 			transactions.GET("/:id", func(c *gin.Context) {
 				transactionID := c.Param("id")
 
-				transaction, err := paymentService.GetTransaction(transactionID)
+				var transaction *domain.Transaction
+				var err error
+				if c.GetString("role") == string(auth.RoleAdmin) {
+					transaction, err = paymentService.GetTransaction(transactionID)
+				} else {
+					transaction, err = paymentService.GetUserTransaction(c.GetString("user_id"), transactionID)
+				}
 				if err != nil {
 					c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 					return
@@ -338,13 +378,9 @@ func main() {
 			transactions.GET("/", func(c *gin.Context) {
 				userID := c.GetString("user_id")
 
-				limitStr := c.DefaultQuery("limit", "20")
-				offsetStr := c.DefaultQuery("offset", "0")
+				limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-				limit, _ := strconv.Atoi(limitStr)
-				offset, _ := strconv.Atoi(offsetStr)
-
-				transactions, err := paymentService.GetTransactionHistory(userID, limit, offset)
+				transactions, err := paymentService.GetTransactionHistory(userID, c.Query("cursor"), limit)
 				if err != nil {
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 					return
@@ -386,22 +422,18 @@ func main() {
 		commissions.Use(middleware.AuthMiddleware())
 		{
 			commissions.POST("/calculate", middleware.RequireRoles([]auth.UserRole{auth.RoleAdmin}), func(c *gin.Context) {
-				var req struct {
-					OrderID     string  `json:"order_id" binding:"required"`
-					OrderAmount float64 `json:"order_amount" binding:"required"`
-					DeliveryFee float64 `json:"delivery_fee" binding:"required"`
-					MerchantID  string  `json:"merchant_id" binding:"required"`
-					DriverID    string  `json:"driver_id" binding:"required"`
-				}
-
+				var req domain.CalculateCommissionRequest
 				if err := c.ShouldBindJSON(&req); err != nil {
 					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 					return
 				}
 
-				commission, err := paymentService.CalculateCommission(
-					req.OrderID, req.OrderAmount, req.DeliveryFee, req.MerchantID, req.DriverID)
+				commission, err := paymentService.CalculateCommission(req)
 				if err != nil {
+					if errors.Is(err, domain.ErrInvalidCommission) {
+						c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+						return
+					}
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 					return
 				}
@@ -518,17 +550,11 @@ func main() {
 	}
 
 	// Start server
-	port := getEnv("PAYMENT_SERVICE_PORT", "8007")
+	port := cfg.String("PAYMENT_SERVICE_PORT")
 	log.Printf("Payment Service starting on port %s", port)
 
-	if err := router.Run(":" + port); err != nil {
+	if err := server.RunGraceful(":"+port, router, server.DefaultShutdownTimeout); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
-}
-
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultValue
+	log.Println("Server stopped")
 }
@@ -1,9 +1,12 @@
 package db
 
 import (
+	"time"
+
 	"glovo-backend/services/payment-service/internal/domain"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type commissionRepository struct {
@@ -56,10 +59,75 @@ func (r *commissionRepository) GetByDriverID(driverID string, limit, offset int)
 	return commissions, err
 }
 
+func (r *commissionRepository) GetByDriverIDBetween(driverID string, start, end time.Time) ([]domain.Commission, error) {
+	var commissions []domain.Commission
+	err := r.db.Where("driver_id = ? AND created_at >= ? AND created_at < ?", driverID, start, end).
+		Order("created_at ASC").
+		Find(&commissions).Error
+	return commissions, err
+}
+
+func (r *commissionRepository) GetCreatedBetween(start, end time.Time) ([]domain.Commission, error) {
+	var commissions []domain.Commission
+	err := r.db.Where("created_at >= ? AND created_at < ?", start, end).
+		Order("created_at ASC").
+		Find(&commissions).Error
+	return commissions, err
+}
+
 func (r *commissionRepository) Update(commission *domain.Commission) error {
 	return r.db.Save(commission).Error
 }
 
+func (r *commissionRepository) SaveDeliveryEarnings(commission *domain.Commission, from domain.CommissionStatus, earning *domain.Transaction) (bool, error) {
+	saved := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var result *gorm.DB
+		if from == "" {
+			// The unique order_id makes a concurrent first payment a no-op
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(commission)
+		} else {
+			result = tx.Model(commission).
+				Where("status = ?", from).
+				Select("*").
+				Updates(commission)
+		}
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if earning != nil {
+			result := tx.Model(&domain.Wallet{}).
+				Where("id = ?", *earning.ToWalletID).
+				Updates(map[string]interface{}{
+					"balance":    gorm.Expr("balance + ?", earning.Amount),
+					"updated_at": earning.UpdatedAt,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+			if err := tx.Create(earning).Error; err != nil {
+				return err
+			}
+		}
+		saved = true
+		return nil
+	})
+	return saved, err
+}
+
+func (r *commissionRepository) AddTip(id string, tip float64) error {
+	return r.db.Model(&domain.Commission{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"driver_tip":    gorm.Expr("driver_tip + ?", tip),
+			"driver_total":  gorm.Expr("driver_total + ?", tip),
+			"net_to_driver": gorm.Expr("net_to_driver + ?", tip),
+		}).Error
+}
+
 func (r *commissionRepository) List(limit, offset int) ([]domain.Commission, error) {
 	var commissions []domain.Commission
 	err := r.db.Order("created_at DESC").
@@ -68,3 +136,38 @@ func (r *commissionRepository) List(limit, offset int) ([]domain.Commission, err
 		Find(&commissions).Error
 	return commissions, err
 }
+
+func (r *commissionRepository) SaveReversal(commission *domain.Commission, from domain.CommissionStatus, clawbacks []*domain.Transaction) (bool, error) {
+	reversed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Commission{}).
+			Where("id = ? AND status = ?", commission.ID, from).
+			Updates(map[string]interface{}{
+				"status":      commission.Status,
+				"reversed_at": commission.ReversedAt,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		for _, clawback := range clawbacks {
+			result := tx.Model(&domain.Wallet{}).
+				Where("id = ?", *clawback.FromWalletID).
+				Updates(map[string]interface{}{
+					"balance":    gorm.Expr("balance - ?", clawback.Amount),
+					"updated_at": clawback.UpdatedAt,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+			if err := tx.Create(clawback).Error; err != nil {
+				return err
+			}
+		}
+		reversed = true
+		return nil
+	})
+	return reversed, err
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"glovo-backend/services/payment-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type disputeRepository struct {
+	db *gorm.DB
+}
+
+func NewDisputeRepository(db *gorm.DB) domain.DisputeRepository {
+	return &disputeRepository{db: db}
+}
+
+func (r *disputeRepository) Create(dispute *domain.Dispute) error {
+	return r.db.Create(dispute).Error
+}
+
+func (r *disputeRepository) GetByID(id string) (*domain.Dispute, error) {
+	var dispute domain.Dispute
+	err := r.db.Where("id = ?", id).First(&dispute).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func (r *disputeRepository) GetByTransactionID(transactionID string) ([]domain.Dispute, error) {
+	var disputes []domain.Dispute
+	err := r.db.Where("transaction_id = ?", transactionID).
+		Order("created_at ASC").
+		Find(&disputes).Error
+	return disputes, err
+}
+
+func (r *disputeRepository) GetByCustomerID(customerID string, limit, offset int) ([]domain.Dispute, error) {
+	var disputes []domain.Dispute
+	err := r.db.Where("customer_id = ?", customerID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&disputes).Error
+	return disputes, err
+}
+
+func (r *disputeRepository) List(status domain.DisputeStatus, limit, offset int) ([]domain.Dispute, error) {
+	query := r.db.Model(&domain.Dispute{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var disputes []domain.Dispute
+	err := query.Order("created_at ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&disputes).Error
+	return disputes, err
+}
+
+func (r *disputeRepository) Update(dispute *domain.Dispute) error {
+	return r.db.Save(dispute).Error
+}
+
+func (r *disputeRepository) UpdateIfOpen(dispute *domain.Dispute) (bool, error) {
+	result := r.db.Model(dispute).
+		Where("status IN ?", []domain.DisputeStatus{domain.DisputeStatusOpened, domain.DisputeStatusUnderReview}).
+		Select("*").
+		Updates(dispute)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,23 @@
+package db
+
+import (
+	"testing"
+
+	"glovo-backend/services/payment-service/internal/domain"
+	"glovo-backend/shared/database/dbtest"
+
+	"gorm.io/gorm"
+)
+
+// testDB returns a dbtest transaction with the payment service's tables migrated
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return dbtest.Open(t, func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&domain.Wallet{},
+			&domain.Transaction{},
+			&domain.PaymentMethod{},
+			&domain.Commission{},
+		)
+	})
+}
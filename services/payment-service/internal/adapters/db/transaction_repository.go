@@ -1,13 +1,20 @@
 package db
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"glovo-backend/services/payment-service/internal/domain"
+	"glovo-backend/shared/pagination"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// errAlreadySettled rolls back a settlement that lost the race to another
+var errAlreadySettled = errors.New("transaction already settled")
+
 type transactionRepository struct {
 	db *gorm.DB
 }
@@ -39,18 +46,18 @@ func (r *transactionRepository) GetByWalletID(walletID string, limit, offset int
 	return transactions, err
 }
 
-func (r *transactionRepository) GetByUserID(userID string, limit, offset int) ([]domain.Transaction, error) {
+// GetByUserID returns up to limit+1 transactions after cursor, newest first;
+// the extra row tells the caller whether there is a next page
+func (r *transactionRepository) GetByUserID(userID string, cursor *pagination.Cursor, limit int) ([]domain.Transaction, error) {
 	var transactions []domain.Transaction
 
 	// Join with wallets to find transactions for a specific user
-	err := r.db.Table("transactions").
+	query := r.db.Table("transactions").
 		Select("transactions.*").
 		Joins("LEFT JOIN wallets w1 ON transactions.from_wallet_id = w1.id").
 		Joins("LEFT JOIN wallets w2 ON transactions.to_wallet_id = w2.id").
-		Where("w1.user_id = ? OR w2.user_id = ?", userID, userID).
-		Order("transactions.created_at DESC").
-		Limit(limit).
-		Offset(offset).
+		Where("(w1.user_id = ? OR w2.user_id = ?)", userID, userID)
+	err := pagination.Apply(query, cursor, limit, "transactions.created_at", "transactions.id").
 		Find(&transactions).Error
 
 	return transactions, err
@@ -64,10 +71,311 @@ func (r *transactionRepository) GetByOrderID(orderID string) ([]domain.Transacti
 	return transactions, err
 }
 
+func (r *transactionRepository) GetExpiredAuthorizations(now time.Time) ([]domain.Transaction, error) {
+	var transactions []domain.Transaction
+	err := r.db.Where("status = ? AND expires_at <= ?", domain.TxStatusAuthorized, now).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *transactionRepository) GetLedgerTotals(walletID string) (*domain.LedgerTotals, error) {
+	var totals domain.LedgerTotals
+	err := r.db.Table("transactions").
+		Select(`COALESCE(SUM(CASE WHEN transactions.to_wallet_id = ? AND transactions.status = ? THEN transactions.amount ELSE 0 END), 0) AS credits,
+			COALESCE(SUM(CASE WHEN transactions.from_wallet_id = ? AND transactions.status = ? THEN transactions.amount ELSE 0 END), 0) AS debits,
+			COALESCE(SUM(CASE WHEN transactions.from_wallet_id = ? AND transactions.status = ? THEN transactions.amount ELSE 0 END), 0) AS held`,
+			walletID, domain.TxStatusCompleted,
+			walletID, domain.TxStatusCompleted,
+			walletID, domain.TxStatusAuthorized).
+		Joins("LEFT JOIN payment_methods pm ON pm.id = transactions.payment_method_id").
+		Where("transactions.to_wallet_id = ? OR transactions.from_wallet_id = ?", walletID, walletID).
+		// Only wallet-funded payments and tips debit the balance
+		Where("transactions.type NOT IN ? OR pm.type = ? OR transactions.to_wallet_id = ?",
+			[]domain.TransactionType{domain.TxTypePayment, domain.TxTypeTip}, domain.PaymentTypeDigitalWallet, walletID).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
+
 func (r *transactionRepository) Update(transaction *domain.Transaction) error {
 	return r.db.Save(transaction).Error
 }
 
+func (r *transactionRepository) CreateAuthorization(transaction *domain.Transaction, walletID *string) (bool, error) {
+	held := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if walletID != nil {
+			result := tx.Model(&domain.Wallet{}).
+				Where("id = ? AND status = ? AND balance >= ?", *walletID, domain.WalletStatusActive, transaction.Amount).
+				Updates(map[string]interface{}{
+					"balance":         gorm.Expr("balance - ?", transaction.Amount),
+					"pending_balance": gorm.Expr("pending_balance + ?", transaction.Amount),
+					"updated_at":      transaction.UpdatedAt,
+				})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+		}
+		if err := tx.Create(transaction).Error; err != nil {
+			return err
+		}
+		held = true
+		return nil
+	})
+	return held, err
+}
+
+func (r *transactionRepository) SettleAuthorization(transaction *domain.Transaction, walletID *string, held, refund float64) (bool, error) {
+	settled := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(transaction).
+			Where("status = ?", domain.TxStatusAuthorized).
+			Select("*").
+			Updates(transaction)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if walletID != nil {
+			err := tx.Model(&domain.Wallet{}).
+				Where("id = ?", *walletID).
+				Updates(map[string]interface{}{
+					"balance":         gorm.Expr("balance + ?", refund),
+					"pending_balance": gorm.Expr("pending_balance - ?", held),
+					"updated_at":      transaction.UpdatedAt,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		settled = true
+		return nil
+	})
+	return settled, err
+}
+
+func (r *transactionRepository) SettlePending(transaction *domain.Transaction, walletID *string) (bool, error) {
+	settled := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(transaction).
+			Where("status = ?", domain.TxStatusPending).
+			Select("*").
+			Updates(transaction)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if walletID != nil {
+			if err := creditWallet(tx, *walletID, transaction.NetAmount, transaction.UpdatedAt); err != nil {
+				return err
+			}
+		}
+		settled = true
+		return nil
+	})
+	return settled, err
+}
+
+func (r *transactionRepository) SettlePendingDebit(transaction *domain.Transaction, walletID string) (bool, error) {
+	settled := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := debitWallet(tx, walletID, transaction.Amount, transaction.UpdatedAt); err != nil {
+			return err
+		}
+		result := tx.Model(transaction).
+			Where("status = ?", domain.TxStatusPending).
+			Select("*").
+			Updates(transaction)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Roll the debit back; the transaction was already settled
+			return errAlreadySettled
+		}
+		settled = true
+		return nil
+	})
+	if errors.Is(err, errAlreadySettled) {
+		return false, nil
+	}
+	return settled, err
+}
+
+func (r *transactionRepository) CreateTransfer(transaction *domain.Transaction) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := debitWallet(tx, *transaction.FromWalletID, transaction.Amount, transaction.UpdatedAt); err != nil {
+			return err
+		}
+		if err := creditWallet(tx, *transaction.ToWalletID, transaction.NetAmount, transaction.UpdatedAt); err != nil {
+			return err
+		}
+		return tx.Create(transaction).Error
+	})
+}
+
+// debitWallet takes amount from the wallet's balance in a single update,
+// only if the wallet is active and the balance covers it
+func debitWallet(tx *gorm.DB, walletID string, amount float64, at time.Time) error {
+	result := tx.Model(&domain.Wallet{}).
+		Where("id = ? AND status = ? AND balance >= ?", walletID, domain.WalletStatusActive, amount).
+		Updates(map[string]interface{}{
+			"balance":    gorm.Expr("balance - ?", amount),
+			"updated_at": at,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return balanceRefusal(tx, walletID)
+	}
+	return nil
+}
+
+// creditWallet adds amount to the wallet's balance in a single update, only
+// if the wallet is active
+func creditWallet(tx *gorm.DB, walletID string, amount float64, at time.Time) error {
+	result := tx.Model(&domain.Wallet{}).
+		Where("id = ? AND status = ?", walletID, domain.WalletStatusActive).
+		Updates(map[string]interface{}{
+			"balance":    gorm.Expr("balance + ?", amount),
+			"updated_at": at,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return balanceRefusal(tx, walletID)
+	}
+	return nil
+}
+
+// balanceRefusal explains why a conditional balance update matched no wallet
+func balanceRefusal(tx *gorm.DB, walletID string) error {
+	var wallet domain.Wallet
+	if err := tx.Select("status").Where("id = ?", walletID).First(&wallet).Error; err != nil {
+		return err
+	}
+	if wallet.Status != domain.WalletStatusActive {
+		return domain.ErrWalletInactive
+	}
+	return domain.ErrInsufficientBalance
+}
+
+func (r *transactionRepository) CreateWithBalanceChange(transaction *domain.Transaction, walletID string, delta float64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Wallet{}).
+			Where("id = ?", walletID).
+			Updates(map[string]interface{}{
+				"balance":    gorm.Expr("balance + ?", delta),
+				"updated_at": transaction.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(transaction).Error
+	})
+}
+
+func (r *transactionRepository) CreateOrderSettlement(transaction *domain.Transaction, walletID string, delta float64) (bool, error) {
+	settled := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Concurrent settlements of the order queue on the wallet's row lock,
+		// so only the first sees no transaction of its type
+		var wallet domain.Wallet
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", walletID).
+			First(&wallet).Error
+		if err != nil {
+			return err
+		}
+
+		var existing int64
+		err = tx.Model(&domain.Transaction{}).
+			Where("order_id = ? AND type = ?", transaction.OrderID, transaction.Type).
+			Count(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing > 0 {
+			return nil
+		}
+
+		// The balance may go negative, but only an active wallet is charged or paid
+		result := tx.Model(&domain.Wallet{}).
+			Where("id = ? AND status = ?", walletID, domain.WalletStatusActive).
+			Updates(map[string]interface{}{
+				"balance":    gorm.Expr("balance + ?", delta),
+				"updated_at": transaction.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrWalletInactive
+		}
+		if err := tx.Create(transaction).Error; err != nil {
+			return err
+		}
+		settled = true
+		return nil
+	})
+	return settled, err
+}
+
+func (r *transactionRepository) CreateRefund(refund *domain.Transaction, walletID *string, limit float64) (float64, error) {
+	var total float64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var original domain.Transaction
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", refund.Reference).
+			First(&original).Error
+		if err != nil {
+			return err
+		}
+		if original.Type != domain.TxTypePayment || original.Status != domain.TxStatusCompleted {
+			return domain.ErrNotRefundable
+		}
+
+		var refunded float64
+		err = tx.Model(&domain.Transaction{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("type = ? AND reference = ? AND status = ?", domain.TxTypeRefund, refund.Reference, domain.TxStatusCompleted).
+			Scan(&refunded).Error
+		if err != nil {
+			return err
+		}
+		if refunded+refund.Amount > limit {
+			return fmt.Errorf("%w: %.2f of %.2f already refunded", domain.ErrRefundExceedsAmount, refunded, limit)
+		}
+
+		if walletID != nil {
+			result := tx.Model(&domain.Wallet{}).
+				Where("id = ?", *walletID).
+				Updates(map[string]interface{}{
+					"balance":    gorm.Expr("balance + ?", refund.Amount),
+					"updated_at": refund.UpdatedAt,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return gorm.ErrRecordNotFound
+			}
+		}
+		if err := tx.Create(refund).Error; err != nil {
+			return err
+		}
+		total = refunded + refund.Amount
+		return nil
+	})
+	return total, err
+}
+
 func (r *transactionRepository) GetTransactionReport(userID string, startDate, endDate time.Time) (*domain.TransactionReport, error) {
 	var result struct {
 		TotalAmount      float64 `gorm:"column:total_amount"`
@@ -98,6 +406,19 @@ func (r *transactionRepository) GetTransactionReport(userID string, startDate, e
 	return report, nil
 }
 
+func (r *transactionRepository) GetMerchantRefunds(start, end time.Time) ([]domain.MerchantRefund, error) {
+	var refunds []domain.MerchantRefund
+	err := r.db.Table("transactions AS refunds").
+		Select("commissions.merchant_id, refunds.amount, payments.amount AS payment_amount, commissions.net_to_merchant").
+		Joins("JOIN transactions AS payments ON payments.id = refunds.reference").
+		Joins("JOIN commissions ON commissions.order_id = payments.order_id").
+		Where("refunds.type = ? AND refunds.status = ?", domain.TxTypeRefund, domain.TxStatusCompleted).
+		Where("refunds.created_at >= ? AND refunds.created_at < ?", start, end).
+		Where("commissions.merchant_id <> '' AND commissions.status <> ?", domain.CommissionStatusVoided).
+		Scan(&refunds).Error
+	return refunds, err
+}
+
 func (r *transactionRepository) List(limit, offset int) ([]domain.Transaction, error) {
 	var transactions []domain.Transaction
 	err := r.db.Order("created_at DESC").
@@ -106,3 +427,41 @@ func (r *transactionRepository) List(limit, offset int) ([]domain.Transaction, e
 		Find(&transactions).Error
 	return transactions, err
 }
+
+func (r *transactionRepository) Search(filter domain.TransactionFilter) ([]domain.Transaction, int64, error) {
+	query := r.db.Model(&domain.Transaction{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.UserID != "" {
+		userWallets := r.db.Model(&domain.Wallet{}).Select("id").Where("user_id = ?", filter.UserID)
+		query = query.Where("(from_wallet_id IN (?) OR to_wallet_id IN (?))", userWallets, userWallets)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("created_at >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("created_at < ?", *filter.EndDate)
+	}
+	if filter.MinAmount != nil {
+		query = query.Where("amount >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query = query.Where("amount <= ?", *filter.MaxAmount)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var transactions []domain.Transaction
+	err := query.Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&transactions).Error
+	return transactions, total, err
+}
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+)
+
+func seedTransactions(t *testing.T, repo domain.TransactionRepository, transactions ...domain.Transaction) {
+	t.Helper()
+	for i := range transactions {
+		if err := repo.Create(&transactions[i]); err != nil {
+			t.Fatalf("seed transaction %s: %v", transactions[i].ID, err)
+		}
+	}
+}
+
+func TestGetLedgerTotals(t *testing.T) {
+	db := testDB(t)
+	methods := NewPaymentMethodRepository(db)
+	for _, method := range []domain.PaymentMethod{
+		{ID: "method-wallet", UserID: "user-1", Type: domain.PaymentTypeDigitalWallet},
+		{ID: "method-card", UserID: "user-1", Type: domain.PaymentTypeCard},
+	} {
+		if err := methods.Create(&method); err != nil {
+			t.Fatalf("seed payment method %s: %v", method.ID, err)
+		}
+	}
+
+	wallet, other := "wallet-1", "wallet-2"
+	walletMethod, cardMethod := "method-wallet", "method-card"
+	now := time.Now()
+	repo := NewTransactionRepository(db)
+	seedTransactions(t, repo,
+		domain.Transaction{ID: "top-up", ToWalletID: &wallet, Type: domain.TxTypeTopUp, Amount: 50, Status: domain.TxStatusCompleted, CreatedAt: now},
+		domain.Transaction{ID: "failed-top-up", ToWalletID: &wallet, Type: domain.TxTypeTopUp, Amount: 100, Status: domain.TxStatusFailed, CreatedAt: now},
+		domain.Transaction{ID: "earning", ToWalletID: &wallet, Type: domain.TxTypeEarning, Amount: 12.5, Status: domain.TxStatusCompleted, CreatedAt: now},
+		domain.Transaction{ID: "wallet-payment", FromWalletID: &wallet, Type: domain.TxTypePayment, Amount: 20, Status: domain.TxStatusCompleted, PaymentMethodID: &walletMethod, CreatedAt: now},
+		// Charged to the card, so the wallet balance never moved
+		domain.Transaction{ID: "card-payment", FromWalletID: &wallet, Type: domain.TxTypePayment, Amount: 30, Status: domain.TxStatusCompleted, PaymentMethodID: &cardMethod, CreatedAt: now},
+		domain.Transaction{ID: "hold", FromWalletID: &wallet, Type: domain.TxTypePayment, Amount: 5, Status: domain.TxStatusAuthorized, PaymentMethodID: &walletMethod, CreatedAt: now},
+		domain.Transaction{ID: "transfer-out", FromWalletID: &wallet, ToWalletID: &other, Type: domain.TxTypeTransfer, Amount: 7.5, Status: domain.TxStatusCompleted, CreatedAt: now},
+		domain.Transaction{ID: "unrelated", ToWalletID: &other, Type: domain.TxTypeTopUp, Amount: 40, Status: domain.TxStatusCompleted, CreatedAt: now},
+	)
+
+	totals, err := repo.GetLedgerTotals(wallet)
+	if err != nil {
+		t.Fatalf("GetLedgerTotals: %v", err)
+	}
+	want := domain.LedgerTotals{Credits: 62.5, Debits: 27.5, Held: 5}
+	if *totals != want {
+		t.Fatalf("totals = %+v, want %+v", *totals, want)
+	}
+
+	totals, err = repo.GetLedgerTotals("wallet-without-transactions")
+	if err != nil {
+		t.Fatalf("GetLedgerTotals: %v", err)
+	}
+	if *totals != (domain.LedgerTotals{}) {
+		t.Fatalf("totals = %+v, want zeros", *totals)
+	}
+}
+
+func TestTransactionSearch(t *testing.T) {
+	db := testDB(t)
+	wallets := NewWalletRepository(db)
+	for _, wallet := range []domain.Wallet{
+		{ID: "wallet-1", UserID: "user-1"},
+		{ID: "wallet-2", UserID: "user-2"},
+	} {
+		if err := wallets.Create(&wallet); err != nil {
+			t.Fatalf("seed wallet %s: %v", wallet.ID, err)
+		}
+	}
+
+	mine, theirs := "wallet-1", "wallet-2"
+	day := time.Date(2026, 10, 10, 12, 0, 0, 0, time.UTC)
+	repo := NewTransactionRepository(db)
+	seedTransactions(t, repo,
+		domain.Transaction{ID: "match-sent", FromWalletID: &mine, Type: domain.TxTypePayment, Amount: 25, Status: domain.TxStatusCompleted, CreatedAt: day},
+		domain.Transaction{ID: "match-received", FromWalletID: &theirs, ToWalletID: &mine, Type: domain.TxTypePayment, Amount: 40, Status: domain.TxStatusCompleted, CreatedAt: day.Add(24 * time.Hour)},
+		domain.Transaction{ID: "too-large", FromWalletID: &mine, Type: domain.TxTypePayment, Amount: 150, Status: domain.TxStatusCompleted, CreatedAt: day},
+		domain.Transaction{ID: "too-small", FromWalletID: &mine, Type: domain.TxTypePayment, Amount: 5, Status: domain.TxStatusCompleted, CreatedAt: day},
+		domain.Transaction{ID: "failed", FromWalletID: &mine, Type: domain.TxTypePayment, Amount: 25, Status: domain.TxStatusFailed, CreatedAt: day},
+		domain.Transaction{ID: "refund", ToWalletID: &mine, Type: domain.TxTypeRefund, Amount: 25, Status: domain.TxStatusCompleted, CreatedAt: day},
+		domain.Transaction{ID: "other-user", FromWalletID: &theirs, Type: domain.TxTypePayment, Amount: 25, Status: domain.TxStatusCompleted, CreatedAt: day},
+		domain.Transaction{ID: "too-early", FromWalletID: &mine, Type: domain.TxTypePayment, Amount: 25, Status: domain.TxStatusCompleted, CreatedAt: day.AddDate(0, 0, -5)},
+		domain.Transaction{ID: "too-late", FromWalletID: &mine, Type: domain.TxTypePayment, Amount: 25, Status: domain.TxStatusCompleted, CreatedAt: day.AddDate(0, 0, 2)},
+	)
+
+	start, end := day.Add(-time.Hour), day.AddDate(0, 0, 2)
+	minAmount, maxAmount := 20.0, 100.0
+	filter := domain.TransactionFilter{
+		Status:    domain.TxStatusCompleted,
+		Type:      domain.TxTypePayment,
+		UserID:    "user-1",
+		StartDate: &start,
+		EndDate:   &end, // exclusive, so "too-late" is out
+		MinAmount: &minAmount,
+		MaxAmount: &maxAmount,
+		Limit:     20,
+	}
+
+	transactions, total, err := repo.Search(filter)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if total != 2 {
+		t.Fatalf("total = %d, want 2", total)
+	}
+	var ids []string
+	for _, transaction := range transactions {
+		ids = append(ids, transaction.ID)
+	}
+	if strings.Join(ids, ",") != "match-received,match-sent" {
+		t.Fatalf("transactions = %v, want [match-received match-sent]", ids)
+	}
+
+	// The total counts every match, not just the page
+	filter.Limit, filter.Offset = 1, 1
+	transactions, total, err = repo.Search(filter)
+	if err != nil {
+		t.Fatalf("Search page 2: %v", err)
+	}
+	if total != 2 || len(transactions) != 1 || transactions[0].ID != "match-sent" {
+		t.Fatalf("page 2 = %d rows of %d total, want match-sent of 2", len(transactions), total)
+	}
+}
+
+func TestCreateOrderSettlement(t *testing.T) {
+	db := testDB(t)
+	wallets := NewWalletRepository(db)
+	for _, wallet := range []domain.Wallet{
+		{ID: "wallet-customer", UserID: "customer-1", Balance: 1, Status: domain.WalletStatusActive},
+		{ID: "wallet-frozen", UserID: "customer-2", Balance: 10, Status: domain.WalletStatusFrozen},
+	} {
+		if err := wallets.Create(&wallet); err != nil {
+			t.Fatalf("seed wallet %s: %v", wallet.ID, err)
+		}
+	}
+	repo := NewTransactionRepository(db)
+	fee := func(id, orderID string) *domain.Transaction {
+		now := time.Now()
+		return &domain.Transaction{ID: id, Type: domain.TxTypeCancellationFee, Status: domain.TxStatusCompleted, Amount: 3, OrderID: &orderID, CreatedAt: now, UpdatedAt: now}
+	}
+	balance := func(id string) float64 {
+		t.Helper()
+		wallet, err := wallets.GetByID(id)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		return wallet.Balance
+	}
+
+	// The fee may take the balance below zero
+	settled, err := repo.CreateOrderSettlement(fee("fee-1", "order-1"), "wallet-customer", -3)
+	if err != nil || !settled {
+		t.Fatalf("CreateOrderSettlement = %v, %v; want settled", settled, err)
+	}
+	if got := balance("wallet-customer"); got != -2 {
+		t.Fatalf("balance = %v, want -2", got)
+	}
+
+	// A second delivery of the event changes nothing
+	settled, err = repo.CreateOrderSettlement(fee("fee-2", "order-1"), "wallet-customer", -3)
+	if err != nil || settled {
+		t.Fatalf("repeat CreateOrderSettlement = %v, %v; want not settled", settled, err)
+	}
+	if got := balance("wallet-customer"); got != -2 {
+		t.Fatalf("balance after the repeat = %v, want -2", got)
+	}
+	if _, err := repo.GetByID("fee-2"); err == nil {
+		t.Fatalf("repeat settlement was recorded")
+	}
+
+	// Frozen wallets are neither charged nor recorded
+	if _, err := repo.CreateOrderSettlement(fee("fee-3", "order-2"), "wallet-frozen", -3); !errors.Is(err, domain.ErrWalletInactive) {
+		t.Fatalf("error = %v, want %v", err, domain.ErrWalletInactive)
+	}
+	if got := balance("wallet-frozen"); got != 10 {
+		t.Fatalf("frozen balance = %v, want 10", got)
+	}
+	if _, err := repo.GetByID("fee-3"); err == nil {
+		t.Fatalf("refused settlement was recorded")
+	}
+}
+
+func TestGetMerchantRefunds(t *testing.T) {
+	db := testDB(t)
+	commissions := NewCommissionRepository(db)
+	for _, commission := range []domain.Commission{
+		{ID: "commission-1", OrderID: "order-1", MerchantID: "merchant-a", NetToMerchant: 30, Status: domain.CommissionStatusProcessed},
+		{ID: "commission-2", OrderID: "order-2", MerchantID: "merchant-b", NetToMerchant: 20, Status: domain.CommissionStatusVoided},
+	} {
+		if err := commissions.Create(&commission); err != nil {
+			t.Fatalf("seed commission %s: %v", commission.ID, err)
+		}
+	}
+
+	orderOne, orderTwo := "order-1", "order-2"
+	day := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	repo := NewTransactionRepository(db)
+	seedTransactions(t, repo,
+		domain.Transaction{ID: "payment-1", OrderID: &orderOne, Type: domain.TxTypePayment, Amount: 40, Status: domain.TxStatusCompleted, CreatedAt: day.AddDate(0, 0, -3)},
+		domain.Transaction{ID: "payment-2", OrderID: &orderTwo, Type: domain.TxTypePayment, Amount: 25, Status: domain.TxStatusCompleted, CreatedAt: day},
+		// A partial refund days after the order
+		domain.Transaction{ID: "refund-1", Reference: "payment-1", Type: domain.TxTypeRefund, Amount: 10, Status: domain.TxStatusCompleted, CreatedAt: day.Add(9 * time.Hour)},
+		domain.Transaction{ID: "refund-next-day", Reference: "payment-1", Type: domain.TxTypeRefund, Amount: 5, Status: domain.TxStatusCompleted, CreatedAt: day.AddDate(0, 0, 1)},
+		domain.Transaction{ID: "refund-failed", Reference: "payment-1", Type: domain.TxTypeRefund, Amount: 5, Status: domain.TxStatusFailed, CreatedAt: day.Add(10 * time.Hour)},
+		// The order's commission never paid out
+		domain.Transaction{ID: "refund-voided", Reference: "payment-2", Type: domain.TxTypeRefund, Amount: 25, Status: domain.TxStatusCompleted, CreatedAt: day.Add(11 * time.Hour)},
+	)
+
+	refunds, err := repo.GetMerchantRefunds(day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetMerchantRefunds: %v", err)
+	}
+	want := domain.MerchantRefund{MerchantID: "merchant-a", Amount: 10, PaymentAmount: 40, NetToMerchant: 30}
+	if len(refunds) != 1 || refunds[0] != want {
+		t.Fatalf("refunds = %+v, want [%+v]", refunds, want)
+	}
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"fmt"
+
+	"glovo-backend/services/payment-service/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type voucherRepository struct {
+	db *gorm.DB
+}
+
+func NewVoucherRepository(db *gorm.DB) domain.VoucherRepository {
+	return &voucherRepository{db: db}
+}
+
+func (r *voucherRepository) Create(voucher *domain.Voucher) error {
+	return r.db.Create(voucher).Error
+}
+
+func (r *voucherRepository) GetByCode(code string) (*domain.Voucher, error) {
+	var voucher domain.Voucher
+	err := r.db.Where("code = ?", code).First(&voucher).Error
+	if err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+func (r *voucherRepository) List(limit, offset int) ([]domain.Voucher, error) {
+	var vouchers []domain.Voucher
+	err := r.db.Order("created_at DESC").Limit(limit).Offset(offset).Find(&vouchers).Error
+	return vouchers, err
+}
+
+func (r *voucherRepository) ClaimRedemption(redemption *domain.VoucherRedemption, limit int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var voucher domain.Voucher
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", redemption.VoucherID).
+			First(&voucher).Error
+		if err != nil {
+			return err
+		}
+
+		var onOrder int64
+		err = tx.Model(&domain.VoucherRedemption{}).
+			Where("voucher_id = ? AND user_id = ? AND order_id = ?", redemption.VoucherID, redemption.UserID, redemption.OrderID).
+			Count(&onOrder).Error
+		if err != nil {
+			return err
+		}
+		if onOrder > 0 {
+			return fmt.Errorf("%w: already redeemed on order %s", domain.ErrVoucherUsageLimit, redemption.OrderID)
+		}
+
+		if limit > 0 {
+			var used int64
+			err := tx.Model(&domain.VoucherRedemption{}).
+				Where("voucher_id = ? AND user_id = ?", redemption.VoucherID, redemption.UserID).
+				Count(&used).Error
+			if err != nil {
+				return err
+			}
+			if used >= int64(limit) {
+				return domain.ErrVoucherUsageLimit
+			}
+		}
+
+		return tx.Create(redemption).Error
+	})
+}
+
+func (r *voucherRepository) DeleteRedemption(id string) error {
+	return r.db.Where("id = ?", id).Delete(&domain.VoucherRedemption{}).Error
+}
@@ -1,7 +1,10 @@
 package db
 
 import (
+	"time"
+
 	"glovo-backend/services/payment-service/internal/domain"
+	"glovo-backend/shared/pagination"
 
 	"gorm.io/gorm"
 )
@@ -36,14 +39,54 @@ func (r *walletRepository) GetByUserID(userID string) (*domain.Wallet, error) {
 	return &wallet, nil
 }
 
-func (r *walletRepository) Update(wallet *domain.Wallet) error {
-	return r.db.Save(wallet).Error
+func (r *walletRepository) Debit(id string, amount float64) (bool, error) {
+	result := r.db.Model(&domain.Wallet{}).
+		Where("id = ? AND status = ? AND balance >= ?", id, domain.WalletStatusActive, amount).
+		Updates(map[string]interface{}{
+			"balance":    gorm.Expr("balance - ?", amount),
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *walletRepository) Credit(id string, amount float64) error {
+	return r.db.Model(&domain.Wallet{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"balance":    gorm.Expr("balance + ?", amount),
+			"updated_at": time.Now(),
+		}).Error
+}
+
+func (r *walletRepository) UpdateStatus(id string, status domain.WalletStatus, reason string) error {
+	result := r.db.Model(&domain.Wallet{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":        status,
+			"status_reason": reason,
+			"updated_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
 func (r *walletRepository) Delete(id string) error {
 	return r.db.Where("id = ?", id).Delete(&domain.Wallet{}).Error
 }
 
+// ListPage returns up to limit+1 wallets after cursor, newest first
+func (r *walletRepository) ListPage(cursor *pagination.Cursor, limit int) ([]domain.Wallet, error) {
+	var wallets []domain.Wallet
+	err := pagination.Apply(r.db.Model(&domain.Wallet{}), cursor, limit, "created_at", "id").
+		Find(&wallets).Error
+	return wallets, err
+}
+
 func (r *walletRepository) List(limit, offset int) ([]domain.Wallet, error) {
 	var wallets []domain.Wallet
 	err := r.db.Order("created_at DESC").
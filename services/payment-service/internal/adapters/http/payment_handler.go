@@ -1,6 +1,11 @@
 package http
 
 import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -8,6 +13,8 @@ import (
 	"glovo-backend/services/payment-service/internal/domain"
 	"glovo-backend/shared/auth"
 	"glovo-backend/shared/middleware"
+	"glovo-backend/shared/pagination"
+	"glovo-backend/shared/response"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,6 +23,38 @@ type PaymentHandler struct {
 	paymentService domain.PaymentService
 }
 
+func init() {
+	response.Register(domain.ErrInsufficientBalance, response.CodeUnprocessable)
+	response.Register(domain.ErrWalletInactive, response.CodeForbidden)
+	response.Register(domain.ErrUnsupportedPaymentMethod, response.CodeInvalidRequest)
+	response.Register(domain.ErrInvalidPaymentMethod, response.CodeInvalidRequest)
+	response.Register(domain.ErrNotPaymentMethodOwner, response.CodeForbidden)
+	response.Register(domain.ErrNotAuthorized, response.CodeConflict)
+	response.Register(domain.ErrAuthorizationExpired, response.CodeConflict)
+	response.Register(domain.ErrCaptureExceedsAuthorization, response.CodeUnprocessable)
+	response.Register(domain.ErrNotTransactionOwner, response.CodeForbidden)
+	response.Register(domain.ErrNotDisputable, response.CodeUnprocessable)
+	response.Register(domain.ErrDisputeExceedsAmount, response.CodeUnprocessable)
+	response.Register(domain.ErrDisputeExists, response.CodeConflict)
+	response.Register(domain.ErrDisputeClosed, response.CodeConflict)
+	response.Register(domain.ErrDisputeWon, response.CodeConflict)
+	response.Register(domain.ErrRefundExceedsAmount, response.CodeUnprocessable)
+	response.Register(domain.ErrNotRefundable, response.CodeUnprocessable)
+	response.Register(domain.ErrNotOrderCustomer, response.CodeForbidden)
+	response.Register(domain.ErrOrderNotDelivered, response.CodeConflict)
+	response.Register(domain.ErrTipWindowClosed, response.CodeUnprocessable)
+	response.Register(domain.ErrInvalidTransactionFilter, response.CodeInvalidRequest)
+	response.Register(domain.ErrPayoutBelowMinimum, response.CodeUnprocessable)
+	response.Register(domain.ErrNoPayoutAccount, response.CodeUnprocessable)
+	response.Register(domain.ErrVoucherNotFound, response.CodeNotFound)
+	response.Register(domain.ErrVoucherExists, response.CodeConflict)
+	response.Register(domain.ErrInvalidVoucher, response.CodeInvalidRequest)
+	response.Register(domain.ErrVoucherExpired, response.CodeUnprocessable)
+	response.Register(domain.ErrVoucherBelowMinimum, response.CodeUnprocessable)
+	response.Register(domain.ErrVoucherUsageLimit, response.CodeUnprocessable)
+	response.Register(pagination.ErrInvalidCursor, response.CodeInvalidRequest)
+}
+
 func NewPaymentHandler(paymentService domain.PaymentService) *PaymentHandler {
 	return &PaymentHandler{
 		paymentService: paymentService,
@@ -23,11 +62,15 @@ func NewPaymentHandler(paymentService domain.PaymentService) *PaymentHandler {
 }
 
 func (h *PaymentHandler) SetupRoutes(router *gin.RouterGroup) {
-	// Public payment processing (for order service internal calls)
-	public := router.Group("/payments")
+	// Internal payment processing (for order service calls)
+	internal := router.Group("/payments")
+	internal.Use(middleware.ServiceAuth())
 	{
-		public.POST("/process", h.processPayment)
-		public.POST("/validate", h.validatePaymentMethod)
+		internal.POST("/process", h.processPayment)
+		internal.POST("/validate", h.validatePaymentMethod)
+		internal.POST("/authorize", h.authorizePayment)
+		internal.POST("/:id/capture", h.capturePayment)
+		internal.POST("/:id/void", h.voidAuthorization)
 	}
 
 	// Customer wallet and payment methods
@@ -38,6 +81,11 @@ func (h *PaymentHandler) SetupRoutes(router *gin.RouterGroup) {
 		customer.GET("/balance", h.getWalletBalance)
 		customer.POST("/add-funds", h.addFunds)
 		customer.GET("/transactions", h.getTransactionHistory)
+		customer.POST("/tips", h.tipDriver)
+
+		// Disputes
+		customer.POST("/disputes", h.openDispute)
+		customer.GET("/disputes", h.getCustomerDisputes)
 
 		// Payment methods
 		customer.POST("/payment-methods", h.addPaymentMethod)
@@ -74,8 +122,18 @@ func (h *PaymentHandler) SetupRoutes(router *gin.RouterGroup) {
 	admin.Use(middleware.AuthMiddleware())
 	admin.Use(middleware.RequireRole(auth.RoleAdmin))
 	{
-		// admin.GET("/transactions", h.getAllTransactions) // TODO: Fix domain interface mismatch
+		admin.GET("/transactions", h.searchTransactions)
 		admin.GET("/transactions/:id", h.getTransaction)
+		admin.GET("/reconcile", h.reconcileWallets)
+		admin.GET("/disputes", h.listDisputes)
+		admin.PUT("/disputes/:id/review", h.reviewDispute)
+		admin.PUT("/disputes/:id/resolve", h.resolveDispute)
+		admin.POST("/orders/:order_id/reverse-commission", h.reverseCommission)
+		admin.PUT("/wallets/:user_id/freeze", h.freezeWallet)
+		admin.PUT("/wallets/:user_id/unfreeze", h.unfreezeWallet)
+		admin.GET("/settlements/daily", h.getDailySettlement)
+		admin.POST("/vouchers", h.createVoucher)
+		admin.GET("/vouchers", h.listVouchers)
 		// admin.POST("/refund", h.processRefund) // TODO: Fix domain interface mismatch
 		// admin.GET("/commissions", h.getCommissions) // TODO: Fix domain interface mismatch
 		// admin.POST("/commissions", h.createCommission) // TODO: Fix domain interface mismatch
@@ -87,44 +145,123 @@ func (h *PaymentHandler) SetupRoutes(router *gin.RouterGroup) {
 }
 
 // @Summary Process payment
-// @Description Process a payment for an order
+// @Description Process a payment for an order. A voucher_code takes its discount off the amount charged. A tip is recorded as its own transaction and charged to the same payment method once the order is delivered.
 // @Tags payments
 // @Accept json
 // @Produce json
+// @Security ServiceToken
 // @Param request body domain.ProcessPaymentRequest true "Payment data"
 // @Success 200 {object} domain.Transaction
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/payments/process [post]
 func (h *PaymentHandler) processPayment(c *gin.Context) {
 	var req domain.ProcessPaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
 	transaction, err := h.paymentService.ProcessPayment(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, transaction)
 }
 
+// @Summary Authorize payment
+// @Description Hold funds for an order without charging them; the hold must be captured or voided before it expires
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security ServiceToken
+// @Param request body domain.ProcessPaymentRequest true "Payment data"
+// @Success 201 {object} domain.PaymentResponse
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 422 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
+// @Router /api/v1/payments/authorize [post]
+func (h *PaymentHandler) authorizePayment(c *gin.Context) {
+	var req domain.ProcessPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err)
+		return
+	}
+
+	authorization, err := h.paymentService.AuthorizePayment(req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, authorization)
+}
+
+// @Summary Capture payment
+// @Description Charge an authorized payment, in full or for a smaller amount
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security ServiceToken
+// @Param id path string true "Transaction ID"
+// @Param request body domain.CaptureRequest false "Amount to capture, defaults to the authorized amount"
+// @Success 200 {object} domain.PaymentResponse
+// @Failure 404 {object} response.ErrorEnvelope
+// @Failure 409 {object} response.ErrorEnvelope
+// @Failure 422 {object} response.ErrorEnvelope
+// @Router /api/v1/payments/{id}/capture [post]
+func (h *PaymentHandler) capturePayment(c *gin.Context) {
+	var req domain.CaptureRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err)
+		return
+	}
+
+	payment, err := h.paymentService.CapturePayment(c.Param("id"), req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// @Summary Void authorization
+// @Description Release an authorized payment without charging it
+// @Tags payments
+// @Produce json
+// @Security ServiceToken
+// @Param id path string true "Transaction ID"
+// @Success 200 {object} domain.PaymentResponse
+// @Failure 404 {object} response.ErrorEnvelope
+// @Failure 409 {object} response.ErrorEnvelope
+// @Router /api/v1/payments/{id}/void [post]
+func (h *PaymentHandler) voidAuthorization(c *gin.Context) {
+	payment, err := h.paymentService.VoidAuthorization(c.Param("id"))
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
 // @Summary Validate payment method
 // @Description Validate if a payment method can be used for a transaction
 // @Tags payments
 // @Accept json
 // @Produce json
+// @Security ServiceToken
 // @Param request body map[string]interface{} true "Validation data"
 // @Success 200 {object} map[string]bool
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
 // @Router /api/v1/payments/validate [post]
 func (h *PaymentHandler) validatePaymentMethod(c *gin.Context) {
 	var req map[string]interface{}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
@@ -141,14 +278,17 @@ func (h *PaymentHandler) validatePaymentMethod(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} domain.Wallet
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/wallet/balance [get]
 func (h *PaymentHandler) getWalletBalance(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	wallet, err := h.paymentService.GetWallet(userID.(string))
+	wallet, err := h.paymentService.GetWallet(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -163,22 +303,25 @@ func (h *PaymentHandler) getWalletBalance(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body domain.TopUpRequest true "Add funds data"
 // @Success 200 {object} domain.Transaction
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/wallet/add-funds [post]
 func (h *PaymentHandler) addFunds(c *gin.Context) {
 	var req domain.TopUpRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	req.UserID = userID.(string)
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+	req.UserID = userID
 
 	transaction, err := h.paymentService.ProcessTopUp(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -191,27 +334,123 @@ func (h *PaymentHandler) addFunds(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param limit query int false "Limit results"
-// @Param offset query int false "Offset for pagination"
-// @Success 200 {array} domain.Transaction
-// @Failure 500 {object} map[string]string
+// @Param cursor query string false "Cursor from the previous page's next_cursor"
+// @Success 200 {object} domain.TransactionPage
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/wallet/transactions [get]
 func (h *PaymentHandler) getTransactionHistory(c *gin.Context) {
-	userID, _ := c.Get("user_id")
-	limitStr := c.DefaultQuery("limit", "50")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 
-	transactions, err := h.paymentService.GetTransactionHistory(userID.(string), limit, offset)
+	transactions, err := h.paymentService.GetTransactionHistory(userID, c.Query("cursor"), limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, transactions)
 }
 
+// @Summary Tip driver
+// @Description Tip the driver of a delivered order, up to 24 hours after delivery. The whole tip goes to the driver's wallet.
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.TipRequest true "Tip data"
+// @Success 201 {object} domain.Transaction
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 403 {object} response.ErrorEnvelope
+// @Failure 404 {object} response.ErrorEnvelope
+// @Failure 409 {object} response.ErrorEnvelope
+// @Failure 422 {object} response.ErrorEnvelope
+// @Router /api/v1/wallet/tips [post]
+func (h *PaymentHandler) tipDriver(c *gin.Context) {
+	var req domain.TipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err)
+		return
+	}
+
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	tip, err := h.paymentService.TipDriver(userID, req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tip)
+}
+
+// @Summary Open dispute
+// @Description Dispute a payment, in full or in part; an admin reviews and resolves it
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.OpenDisputeRequest true "Dispute data"
+// @Success 201 {object} domain.Dispute
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 403 {object} response.ErrorEnvelope
+// @Failure 404 {object} response.ErrorEnvelope
+// @Failure 409 {object} response.ErrorEnvelope
+// @Failure 422 {object} response.ErrorEnvelope
+// @Router /api/v1/wallet/disputes [post]
+func (h *PaymentHandler) openDispute(c *gin.Context) {
+	var req domain.OpenDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err)
+		return
+	}
+
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	dispute, err := h.paymentService.OpenDispute(userID, req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dispute)
+}
+
+// @Summary Get disputes
+// @Description Get the customer's disputes, newest first
+// @Tags wallet
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit results" default(20)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {array} domain.Dispute
+// @Failure 500 {object} response.ErrorEnvelope
+// @Router /api/v1/wallet/disputes [get]
+func (h *PaymentHandler) getCustomerDisputes(c *gin.Context) {
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	disputes, err := h.paymentService.GetCustomerDisputes(userID, limit, offset)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, disputes)
+}
+
 // @Summary Add payment method
 // @Description Add a new payment method for the customer
 // @Tags payment-methods
@@ -220,21 +459,24 @@ func (h *PaymentHandler) getTransactionHistory(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body domain.AddPaymentMethodRequest true "Payment method data"
 // @Success 201 {object} domain.PaymentMethod
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/wallet/payment-methods [post]
 func (h *PaymentHandler) addPaymentMethod(c *gin.Context) {
 	var req domain.AddPaymentMethodRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
-	userID, _ := c.Get("user_id")
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	paymentMethod, err := h.paymentService.AddPaymentMethod(userID.(string), req)
+	paymentMethod, err := h.paymentService.AddPaymentMethod(userID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -247,14 +489,17 @@ func (h *PaymentHandler) addPaymentMethod(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {array} domain.PaymentMethod
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/wallet/payment-methods [get]
 func (h *PaymentHandler) getPaymentMethods(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	paymentMethods, err := h.paymentService.GetPaymentMethods(userID.(string))
+	paymentMethods, err := h.paymentService.GetPaymentMethods(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -268,15 +513,18 @@ func (h *PaymentHandler) getPaymentMethods(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "Payment Method ID"
 // @Success 200 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/wallet/payment-methods/{id} [put]
 func (h *PaymentHandler) updatePaymentMethod(c *gin.Context) {
 	paymentMethodID := c.Param("id")
-	userID, _ := c.Get("user_id")
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	err := h.paymentService.SetDefaultPaymentMethod(userID.(string), paymentMethodID)
+	err := h.paymentService.SetDefaultPaymentMethod(userID, paymentMethodID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -290,15 +538,18 @@ func (h *PaymentHandler) updatePaymentMethod(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "Payment Method ID"
 // @Success 200 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/wallet/payment-methods/{id} [delete]
 func (h *PaymentHandler) deletePaymentMethod(c *gin.Context) {
 	paymentMethodID := c.Param("id")
-	userID, _ := c.Get("user_id")
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	err := h.paymentService.RemovePaymentMethod(userID.(string), paymentMethodID)
+	err := h.paymentService.RemovePaymentMethod(userID, paymentMethodID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -312,15 +563,18 @@ func (h *PaymentHandler) deletePaymentMethod(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path string true "Payment Method ID"
 // @Success 200 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/wallet/payment-methods/{id}/default [put]
 func (h *PaymentHandler) setDefaultPaymentMethod(c *gin.Context) {
 	paymentMethodID := c.Param("id")
-	userID, _ := c.Get("user_id")
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	err := h.paymentService.SetDefaultPaymentMethod(paymentMethodID, userID.(string))
+	err := h.paymentService.SetDefaultPaymentMethod(paymentMethodID, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -337,34 +591,37 @@ func (h *PaymentHandler) setDefaultPaymentMethod(c *gin.Context) {
 // @Param start_date query string true "Start date (YYYY-MM-DD)"
 // @Param end_date query string true "End date (YYYY-MM-DD)"
 // @Success 200 {object} domain.EarningsReport
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/merchant/earnings [get]
 func (h *PaymentHandler) getMerchantEarnings(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 
 	if startDateStr == "" || endDateStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
+		response.Error(c, response.CodeInvalidRequest, "start_date and end_date are required", nil)
 		return
 	}
 
 	_, err := time.Parse("2006-01-02", startDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+		response.Error(c, response.CodeInvalidRequest, "Invalid start_date format", nil)
 		return
 	}
 
 	_, err = time.Parse("2006-01-02", endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+		response.Error(c, response.CodeInvalidRequest, "Invalid end_date format", nil)
 		return
 	}
 
 	// Placeholder earnings report - replace with actual implementation
 	earnings := map[string]interface{}{
-		"user_id":        userID.(string),
+		"user_id":        userID,
 		"total_earnings": 0.0,
 		"period":         map[string]string{"start": startDateStr, "end": endDateStr},
 		"transactions":   []interface{}{},
@@ -380,21 +637,20 @@ func (h *PaymentHandler) getMerchantEarnings(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param limit query int false "Limit results"
-// @Param offset query int false "Offset for pagination"
-// @Success 200 {array} domain.Transaction
-// @Failure 500 {object} map[string]string
+// @Param cursor query string false "Cursor from the previous page's next_cursor"
+// @Success 200 {object} domain.TransactionPage
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/merchant/transactions [get]
 func (h *PaymentHandler) getMerchantTransactions(c *gin.Context) {
-	userID, _ := c.Get("user_id")
-	limitStr := c.DefaultQuery("limit", "50")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 
-	transactions, err := h.paymentService.GetTransactionHistory(userID.(string), limit, offset)
+	transactions, err := h.paymentService.GetTransactionHistory(userID, c.Query("cursor"), limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -402,34 +658,39 @@ func (h *PaymentHandler) getMerchantTransactions(c *gin.Context) {
 }
 
 // @Summary Request payout
-// @Description Request a payout for merchant
+// @Description Pay out from the merchant's available wallet balance to their bank account
 // @Tags merchant
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body map[string]float64 true "Payout data"
-// @Success 201 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 201 {object} domain.PaymentResponse
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 403 {object} response.ErrorEnvelope
+// @Failure 422 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/merchant/withdraw [post]
 func (h *PaymentHandler) requestPayout(c *gin.Context) {
 	var req map[string]float64
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
 	amount, exists := req["amount"]
 	if !exists || amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid amount is required"})
+		response.Error(c, response.CodeInvalidRequest, "Valid amount is required", nil)
 		return
 	}
 
-	userID, _ := c.Get("user_id")
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	payout, err := h.paymentService.ProcessMerchantPayout(userID.(string), amount)
+	payout, err := h.paymentService.ProcessMerchantPayout(userID, amount)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -442,16 +703,19 @@ func (h *PaymentHandler) requestPayout(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {array} domain.Payout
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/merchant/payouts [get]
 func (h *PaymentHandler) getPayoutHistory(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
 	// Placeholder payout history - replace with actual implementation
 	payouts := []map[string]interface{}{
 		{
 			"message": "Payout history feature coming soon",
-			"user_id": userID.(string),
+			"user_id": userID,
 		},
 	}
 
@@ -461,61 +725,61 @@ func (h *PaymentHandler) getPayoutHistory(c *gin.Context) {
 // Driver endpoints (similar to merchant)
 
 // @Summary Get driver earnings
-// @Description Get driver earnings report
+// @Description Get driver earnings between two dates (inclusive), split into base fare, distance bonus, surge and tips, with each delivery's breakdown
 // @Tags driver
 // @Produce json
 // @Security BearerAuth
 // @Param start_date query string true "Start date (YYYY-MM-DD)"
 // @Param end_date query string true "End date (YYYY-MM-DD)"
-// @Success 200 {object} domain.EarningsReport
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} domain.DriverEarningsReport
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
 // @Router /api/v1/driver/earnings [get]
 func (h *PaymentHandler) getDriverEarnings(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 
 	if startDateStr == "" || endDateStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
+		response.Error(c, response.CodeInvalidRequest, "start_date and end_date are required", nil)
 		return
 	}
 
-	_, err := time.Parse("2006-01-02", startDateStr)
+	startDate, err := time.Parse("2006-01-02", startDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+		response.Error(c, response.CodeInvalidRequest, "Invalid start_date format", nil)
 		return
 	}
 
-	_, err = time.Parse("2006-01-02", endDateStr)
+	endDate, err := time.Parse("2006-01-02", endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+		response.Error(c, response.CodeInvalidRequest, "Invalid end_date format", nil)
 		return
 	}
 
-	// Placeholder driver earnings report - replace with actual implementation
-	earnings := map[string]interface{}{
-		"user_id":        userID.(string),
-		"total_earnings": 0.0,
-		"period":         map[string]string{"start": startDateStr, "end": endDateStr},
-		"deliveries":     []interface{}{},
-		"message":        "Driver earnings report feature coming soon",
+	// end_date is inclusive
+	report, err := h.paymentService.GetDriverEarnings(userID, startDate, endDate.AddDate(0, 0, 1))
+	if err != nil {
+		response.FromError(c, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, earnings)
+	c.JSON(http.StatusOK, report)
 }
 
 func (h *PaymentHandler) getDriverTransactions(c *gin.Context) {
-	userID, _ := c.Get("user_id")
-	limitStr := c.DefaultQuery("limit", "50")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 
-	transactions, err := h.paymentService.GetTransactionHistory(userID.(string), limit, offset)
+	transactions, err := h.paymentService.GetTransactionHistory(userID, c.Query("cursor"), limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -527,32 +791,409 @@ func (h *PaymentHandler) getTransaction(c *gin.Context) {
 
 	transaction, err := h.paymentService.GetTransaction(transactionID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, transaction)
 }
 
+// @Summary Reconcile wallets
+// @Description Recompute each wallet's balance from its transactions and flag wallets whose stored balance differs (admin only, read-only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit results" default(20)
+// @Param cursor query string false "Cursor from the previous page's next_cursor"
+// @Success 200 {object} domain.ReconciliationReport
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/payments/reconcile [get]
+func (h *PaymentHandler) reconcileWallets(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	report, err := h.paymentService.ReconcileWallets(c.Query("cursor"), limit)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// @Summary List disputes
+// @Description List disputes, oldest first, optionally filtered by status (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Dispute status" Enums(opened, under_review, won, lost)
+// @Param limit query int false "Limit results" default(20)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {array} domain.Dispute
+// @Failure 500 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/payments/disputes [get]
+func (h *PaymentHandler) listDisputes(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	disputes, err := h.paymentService.ListDisputes(domain.DisputeStatus(c.Query("status")), limit, offset)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, disputes)
+}
+
+// @Summary Review dispute
+// @Description Mark an opened dispute as under review (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} domain.Dispute
+// @Failure 404 {object} response.ErrorEnvelope
+// @Failure 409 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/payments/disputes/{id}/review [put]
+func (h *PaymentHandler) reviewDispute(c *gin.Context) {
+	dispute, err := h.paymentService.ReviewDispute(c.Param("id"))
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// @Summary Resolve dispute
+// @Description Resolve a dispute as won, which refunds the disputed amount or voids an uncaptured hold, or as lost, which leaves the charge in place (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Dispute ID"
+// @Param request body domain.ResolveDisputeRequest true "Resolution"
+// @Success 200 {object} domain.Dispute
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 404 {object} response.ErrorEnvelope
+// @Failure 409 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/payments/disputes/{id}/resolve [put]
+func (h *PaymentHandler) resolveDispute(c *gin.Context) {
+	var req domain.ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err)
+		return
+	}
+
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	dispute, err := h.paymentService.ResolveDispute(c.Param("id"), adminID, req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// @Summary Search transactions
+// @Description Search all transactions, newest first; filters combine with AND (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Transaction status"
+// @Param type query string false "Transaction type"
+// @Param user_id query string false "User on either side of the transaction"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date, inclusive (YYYY-MM-DD)"
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} domain.TransactionSearchPage
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/payments/transactions [get]
+func (h *PaymentHandler) searchTransactions(c *gin.Context) {
+	filter := domain.TransactionFilter{
+		Status: domain.TransactionStatus(c.Query("status")),
+		Type:   domain.TransactionType(c.Query("type")),
+		UserID: c.Query("user_id"),
+	}
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	filter.Offset, _ = strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		startDate, err := time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			response.Error(c, response.CodeInvalidRequest, "Invalid start_date format", nil)
+			return
+		}
+		filter.StartDate = &startDate
+	}
+
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		endDate, err := time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			response.Error(c, response.CodeInvalidRequest, "Invalid end_date format", nil)
+			return
+		}
+		// Include the whole end day
+		endDate = endDate.AddDate(0, 0, 1)
+		filter.EndDate = &endDate
+	}
+
+	if minStr := c.Query("min_amount"); minStr != "" {
+		minAmount, err := strconv.ParseFloat(minStr, 64)
+		if err != nil {
+			response.Error(c, response.CodeInvalidRequest, "Invalid min_amount", nil)
+			return
+		}
+		filter.MinAmount = &minAmount
+	}
+
+	if maxStr := c.Query("max_amount"); maxStr != "" {
+		maxAmount, err := strconv.ParseFloat(maxStr, 64)
+		if err != nil {
+			response.Error(c, response.CodeInvalidRequest, "Invalid max_amount", nil)
+			return
+		}
+		filter.MaxAmount = &maxAmount
+	}
+
+	page, err := h.paymentService.SearchTransactions(filter)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// @Summary Reverse order commission
+// @Description Void the order's pending commission, or reverse a processed one and claw back the delivery fares credited to the driver (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param order_id path string true "Order ID"
+// @Success 200 {object} domain.Commission
+// @Failure 404 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/payments/orders/{order_id}/reverse-commission [post]
+func (h *PaymentHandler) reverseCommission(c *gin.Context) {
+	commission, err := h.paymentService.ReverseCommission(c.Param("order_id"))
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, commission)
+}
+
+// @Summary Freeze wallet
+// @Description Freeze a user's wallet, blocking payments, transfers, top-ups and withdrawals until it is unfrozen (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "Wallet owner's user ID"
+// @Param request body domain.FreezeWalletRequest true "Reason for freezing"
+// @Success 200 {object} domain.Wallet
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 404 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/payments/wallets/{user_id}/freeze [put]
+func (h *PaymentHandler) freezeWallet(c *gin.Context) {
+	var req domain.FreezeWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err)
+		return
+	}
+
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	wallet, err := h.paymentService.FreezeWallet(c.Param("user_id"), adminID, req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, wallet)
+}
+
+// @Summary Daily merchant settlement
+// @Description Per-merchant gross sales, platform fees, refunds and net payable for one UTC day, as JSON or CSV (admin only)
+// @Tags admin
+// @Produce json,text/csv
+// @Security BearerAuth
+// @Param date query string false "Day (YYYY-MM-DD), yesterday when empty"
+// @Param format query string false "Response format" Enums(json, csv) default(json)
+// @Success 200 {object} domain.SettlementReport
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 500 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/payments/settlements/daily [get]
+func (h *PaymentHandler) getDailySettlement(c *gin.Context) {
+	date := time.Now().UTC().AddDate(0, 0, -1)
+	if dateStr := c.Query("date"); dateStr != "" {
+		parsed, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			response.Error(c, response.CodeInvalidRequest, "Invalid date format", nil)
+			return
+		}
+		date = parsed
+	}
+
+	report, err := h.paymentService.GetDailySettlement(date)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	data, err := settlementCSV(report)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"settlement-%s.csv\"", report.Date))
+	c.Data(http.StatusOK, "text/csv", data)
+}
+
+// settlementCSV writes one row per merchant followed by a totals row
+func settlementCSV(report *domain.SettlementReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	money := func(amount float64) string { return strconv.FormatFloat(amount, 'f', 2, 64) }
+	row := func(merchantID string, line domain.MerchantSettlement) []string {
+		return []string{report.Date, merchantID, strconv.Itoa(line.Orders), money(line.GrossSales),
+			money(line.PlatformFees), money(line.Refunds), money(line.NetPayable)}
+	}
+
+	if err := w.Write([]string{"date", "merchant_id", "orders", "gross_sales", "platform_fees", "refunds", "net_payable"}); err != nil {
+		return nil, err
+	}
+	for _, line := range report.Merchants {
+		if err := w.Write(row(line.MerchantID, line)); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Write(row("TOTAL", report.Totals)); err != nil {
+		return nil, err
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// @Summary Create voucher
+// @Description Create a percentage or fixed promo code with an optional minimum order, expiry and per-customer usage limit (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.CreateVoucherRequest true "Voucher"
+// @Success 201 {object} domain.Voucher
+// @Failure 400 {object} response.ErrorEnvelope
+// @Failure 409 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/payments/vouchers [post]
+func (h *PaymentHandler) createVoucher(c *gin.Context) {
+	var req domain.CreateVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err)
+		return
+	}
+
+	voucher, err := h.paymentService.CreateVoucher(req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, voucher)
+}
+
+// @Summary List vouchers
+// @Description List vouchers, newest first (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit results" default(20)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {array} domain.Voucher
+// @Failure 500 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/payments/vouchers [get]
+func (h *PaymentHandler) listVouchers(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	vouchers, err := h.paymentService.ListVouchers(limit, offset)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, vouchers)
+}
+
+// @Summary Unfreeze wallet
+// @Description Return a frozen or suspended wallet to active (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "Wallet owner's user ID"
+// @Success 200 {object} domain.Wallet
+// @Failure 404 {object} response.ErrorEnvelope
+// @Router /api/v1/admin/payments/wallets/{user_id}/unfreeze [put]
+func (h *PaymentHandler) unfreezeWallet(c *gin.Context) {
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+
+	wallet, err := h.paymentService.UnfreezeWallet(c.Param("user_id"), adminID)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, wallet)
+}
+
 func (h *PaymentHandler) requestDriverPayout(c *gin.Context) {
 	var req struct {
 		Amount float64 `json:"amount" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
 	if req.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than 0"})
+		response.Error(c, response.CodeInvalidRequest, "Amount must be greater than 0", nil)
 		return
 	}
 
-	userID, _ := c.Get("user_id")
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	payout, err := h.paymentService.ProcessDriverPayout(userID.(string), req.Amount)
+	payout, err := h.paymentService.ProcessDriverPayout(userID, req.Amount)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -562,11 +1203,14 @@ func (h *PaymentHandler) requestDriverPayout(c *gin.Context) {
 // TODO: Fix domain interface mismatch
 /*
 func (h *PaymentHandler) getDriverPayoutHistory(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+	userID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	payouts, err := h.paymentService.GetPayoutHistory(userID.(string))
+	payouts, err := h.paymentService.GetPayoutHistory(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -579,44 +1223,22 @@ func (h *PaymentHandler) getDriverPayoutHistory(c *gin.Context) {
 // They need to be fixed to match the actual domain.PaymentService interface
 
 /*
-func (h *PaymentHandler) getAllTransactions(c *gin.Context) {
-	limitStr := c.DefaultQuery("limit", "100")
-	offsetStr := c.DefaultQuery("offset", "0")
-	status := c.Query("status")
-
-	limit, _ := strconv.Atoi(limitStr)
-	offset, _ := strconv.Atoi(offsetStr)
-
-	req := domain.AdminTransactionRequest{
-		Limit:  limit,
-		Offset: offset,
-		Status: status,
-	}
-
-	transactions, err := h.paymentService.GetAllTransactions(req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, transactions)
-}
-
-
-
 func (h *PaymentHandler) processRefund(c *gin.Context) {
 	var req domain.RefundRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
-	adminID, _ := c.Get("user_id")
-	req.ProcessedBy = adminID.(string)
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+	req.ProcessedBy = adminID
 
 	refund, err := h.paymentService.ProcessRefund(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -632,7 +1254,7 @@ func (h *PaymentHandler) getCommissions(c *gin.Context) {
 
 	commissions, err := h.paymentService.GetCommissions(limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -642,16 +1264,19 @@ func (h *PaymentHandler) getCommissions(c *gin.Context) {
 func (h *PaymentHandler) createCommission(c *gin.Context) {
 	var req domain.CreateCommissionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
-	adminID, _ := c.Get("user_id")
-	req.CreatedBy = adminID.(string)
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+	req.CreatedBy = adminID
 
 	commission, err := h.paymentService.CreateCommission(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -663,16 +1288,19 @@ func (h *PaymentHandler) updateCommission(c *gin.Context) {
 
 	var req domain.UpdateCommissionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
-	adminID, _ := c.Get("user_id")
-	req.UpdatedBy = adminID.(string)
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
+	req.UpdatedBy = adminID
 
 	commission, err := h.paymentService.UpdateCommission(commissionID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -682,7 +1310,7 @@ func (h *PaymentHandler) updateCommission(c *gin.Context) {
 func (h *PaymentHandler) getPendingPayouts(c *gin.Context) {
 	payouts, err := h.paymentService.GetPendingPayouts()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -691,11 +1319,14 @@ func (h *PaymentHandler) getPendingPayouts(c *gin.Context) {
 
 func (h *PaymentHandler) approvePayout(c *gin.Context) {
 	payoutID := c.Param("id")
-	adminID, _ := c.Get("user_id")
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
-	err := h.paymentService.ApprovePayout(payoutID, adminID.(string))
+	err := h.paymentService.ApprovePayout(payoutID, adminID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
@@ -704,19 +1335,22 @@ func (h *PaymentHandler) approvePayout(c *gin.Context) {
 
 func (h *PaymentHandler) rejectPayout(c *gin.Context) {
 	payoutID := c.Param("id")
-	adminID, _ := c.Get("user_id")
+	adminID, ok := auth.RequireUserID(c)
+	if !ok {
+		return
+	}
 
 	var req struct {
 		Reason string `json:"reason" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BadRequest(c, err)
 		return
 	}
 
-	err := h.paymentService.RejectPayout(payoutID, adminID.(string), req.Reason)
+	err := h.paymentService.RejectPayout(payoutID, adminID, req.Reason)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response.FromError(c, err)
 		return
 	}
 
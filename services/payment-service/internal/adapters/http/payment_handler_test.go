@@ -0,0 +1,214 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+	"glovo-backend/shared/auth"
+	"glovo-backend/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakePaymentService struct {
+	domain.PaymentService
+	processErr error
+	processed  []domain.ProcessPaymentRequest
+	searched   []domain.TransactionFilter
+}
+
+func (s *fakePaymentService) ProcessPayment(req domain.ProcessPaymentRequest) (*domain.PaymentResponse, error) {
+	if s.processErr != nil {
+		return nil, s.processErr
+	}
+	s.processed = append(s.processed, req)
+	return &domain.PaymentResponse{TransactionID: "tx-1", Status: domain.TxStatusCompleted, Amount: req.Amount}, nil
+}
+
+func TestProcessPaymentRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "user-secret")
+	t.Setenv("SERVICE_TOKEN_SECRET", "service-secret")
+	serviceToken, err := auth.GenerateServiceToken("order-service")
+	if err != nil {
+		t.Fatalf("GenerateServiceToken: %v", err)
+	}
+	userToken, err := auth.GenerateToken("customer-1", auth.RoleCustomer)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		serviceToken string
+		userToken    string
+		processErr   error
+		wantStatus   int
+		wantCode     response.Code
+	}{
+		{name: "service call", serviceToken: serviceToken, wantStatus: http.StatusOK},
+		{name: "declined", serviceToken: serviceToken, processErr: domain.ErrInsufficientBalance, wantStatus: http.StatusUnprocessableEntity, wantCode: response.CodeUnprocessable},
+		{name: "no service token", wantStatus: http.StatusUnauthorized},
+		{name: "customer token only", userToken: userToken, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakePaymentService{processErr: tt.processErr}
+			router := gin.New()
+			NewPaymentHandler(service).SetupRoutes(router.Group("/api/v1"))
+
+			body := `{"order_id":"order-1","customer_id":"customer-1","amount":25,"payment_method_id":"card-1"}`
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/payments/process", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.serviceToken != "" {
+				req.Header.Set(auth.HeaderServiceToken, tt.serviceToken)
+			}
+			if tt.userToken != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.userToken)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				var envelope response.ErrorEnvelope
+				if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+					t.Fatalf("decode body: %v", err)
+				}
+				if envelope.Error.Code != tt.wantCode || envelope.Error.Message != tt.processErr.Error() {
+					t.Fatalf("envelope = %+v", envelope)
+				}
+			}
+			wantProcessed := 0
+			if tt.wantStatus == http.StatusOK {
+				wantProcessed = 1
+			}
+			if len(service.processed) != wantProcessed {
+				t.Fatalf("processed %d payments, want %d", len(service.processed), wantProcessed)
+			}
+		})
+	}
+}
+
+func TestWalletHandlerWithoutUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// The embedded nil service panics if the handler gets past the user check
+	h := NewPaymentHandler(&fakePaymentService{})
+
+	for name, values := range map[string]map[string]interface{}{
+		"no user":    {},
+		"wrong type": {auth.ContextUserID: 7},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/wallet/balance", nil)
+			for key, value := range values {
+				c.Set(key, value)
+			}
+
+			h.getWalletBalance(c)
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusUnauthorized, w.Body.String())
+			}
+		})
+	}
+}
+
+func (s *fakePaymentService) SearchTransactions(filter domain.TransactionFilter) (*domain.TransactionSearchPage, error) {
+	s.searched = append(s.searched, filter)
+	return &domain.TransactionSearchPage{Transactions: []domain.Transaction{}, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+func TestSearchTransactionsRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "user-secret")
+	adminToken, err := auth.GenerateToken("admin-1", auth.RoleAdmin)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	t.Run("filters", func(t *testing.T) {
+		service := &fakePaymentService{}
+		router := gin.New()
+		NewPaymentHandler(service).SetupRoutes(router.Group("/api/v1"))
+
+		query := "?status=completed&type=payment&user_id=user-1&start_date=2026-10-01&end_date=2026-10-07&min_amount=10&max_amount=50.5&limit=5&offset=10"
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/payments/transactions"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		got := service.searched[0]
+		if got.Status != domain.TxStatusCompleted || got.Type != domain.TxTypePayment || got.UserID != "user-1" {
+			t.Fatalf("filter = %+v", got)
+		}
+		if got.StartDate == nil || !got.StartDate.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("start date = %v, want 2026-10-01", got.StartDate)
+		}
+		// end_date is inclusive, so the exclusive bound is the next day
+		if got.EndDate == nil || !got.EndDate.Equal(time.Date(2026, 10, 8, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("end date = %v, want 2026-10-08", got.EndDate)
+		}
+		if got.MinAmount == nil || *got.MinAmount != 10 || got.MaxAmount == nil || *got.MaxAmount != 50.5 {
+			t.Fatalf("amounts = %v to %v, want 10 to 50.5", got.MinAmount, got.MaxAmount)
+		}
+		if got.Limit != 5 || got.Offset != 10 {
+			t.Fatalf("limit %d offset %d, want 5 and 10", got.Limit, got.Offset)
+		}
+	})
+
+	for _, query := range []string{"?start_date=10/01/2026", "?end_date=yesterday", "?min_amount=ten", "?max_amount=1e"} {
+		t.Run(query, func(t *testing.T) {
+			service := &fakePaymentService{}
+			router := gin.New()
+			NewPaymentHandler(service).SetupRoutes(router.Group("/api/v1"))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/payments/transactions"+query, nil)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+			}
+			if len(service.searched) != 0 {
+				t.Fatalf("searched with an unparsable filter")
+			}
+		})
+	}
+}
+
+func TestSettlementCSV(t *testing.T) {
+	report := &domain.SettlementReport{
+		Date: "2026-10-14",
+		Merchants: []domain.MerchantSettlement{
+			{MerchantID: "merchant-a", Orders: 2, GrossSales: 150, PlatformFees: 19.5, Refunds: 45, NetPayable: 85.5},
+			{MerchantID: "merchant-b", Orders: 1, GrossSales: 80.1, PlatformFees: 10.41, NetPayable: 69.69},
+		},
+		Totals: domain.MerchantSettlement{Orders: 3, GrossSales: 230.1, PlatformFees: 29.91, Refunds: 45, NetPayable: 155.19},
+	}
+
+	data, err := settlementCSV(report)
+	if err != nil {
+		t.Fatalf("settlementCSV: %v", err)
+	}
+	want := "date,merchant_id,orders,gross_sales,platform_fees,refunds,net_payable\n" +
+		"2026-10-14,merchant-a,2,150.00,19.50,45.00,85.50\n" +
+		"2026-10-14,merchant-b,1,80.10,10.41,0.00,69.69\n" +
+		"2026-10-14,TOTAL,3,230.10,29.91,45.00,155.19\n"
+	if string(data) != want {
+		t.Fatalf("csv =\n%s\nwant\n%s", data, want)
+	}
+}
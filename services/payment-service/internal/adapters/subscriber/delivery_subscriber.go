@@ -0,0 +1,74 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+
+	"glovo-backend/services/payment-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// DeliverySubscriber credits drivers when their deliveries complete and
+// settles the fees of cancelled ones
+type DeliverySubscriber struct {
+	paymentService domain.PaymentService
+}
+
+func NewDeliverySubscriber(paymentService domain.PaymentService) *DeliverySubscriber {
+	return &DeliverySubscriber{paymentService: paymentService}
+}
+
+// Register subscribes to the delivery events payment reacts to
+func (s *DeliverySubscriber) Register(bus events.Bus) error {
+	if err := bus.Subscribe(events.DeliveryCompleted, s.handleDeliveryCompleted); err != nil {
+		return err
+	}
+	return bus.Subscribe(events.DeliveryCancelled, s.handleDeliveryCancelled)
+}
+
+func (s *DeliverySubscriber) handleDeliveryCompleted(ctx context.Context, event events.Event) error {
+	var payload events.DeliveryCompletedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	if payload.DriverID == "" {
+		return nil
+	}
+
+	_, err := s.paymentService.PayDeliveryEarnings(domain.DeliveryEarningsRequest{
+		OrderID:         payload.OrderID,
+		MerchantID:      payload.MerchantID,
+		DriverID:        payload.DriverID,
+		DeliveryFee:     payload.DeliveryFee,
+		Distance:        payload.Distance,
+		SurgeMultiplier: payload.SurgeMultiplier,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pay out delivery %s: %w", payload.DeliveryID, err)
+	}
+	return nil
+}
+
+func (s *DeliverySubscriber) handleDeliveryCancelled(ctx context.Context, event events.Event) error {
+	var payload events.DeliveryCancelledPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	if payload.CancellationFee <= 0 && payload.DriverCompensation <= 0 {
+		return nil
+	}
+
+	_, err := s.paymentService.SettleCancellation(domain.CancellationSettlementRequest{
+		OrderID:            payload.OrderID,
+		CustomerID:         payload.CustomerID,
+		DriverID:           payload.DriverID,
+		CancellationFee:    payload.CancellationFee,
+		DriverCompensation: payload.DriverCompensation,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to settle cancelled delivery %s: %w", payload.DeliveryID, err)
+	}
+	return nil
+}
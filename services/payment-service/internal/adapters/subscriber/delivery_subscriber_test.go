@@ -0,0 +1,105 @@
+package subscriber
+
+import (
+	"context"
+	"testing"
+
+	"glovo-backend/services/payment-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+type fakePaymentService struct {
+	domain.PaymentService
+	earnings    []domain.DeliveryEarningsRequest
+	settlements []domain.CancellationSettlementRequest
+}
+
+func (s *fakePaymentService) PayDeliveryEarnings(req domain.DeliveryEarningsRequest) (*domain.Commission, error) {
+	s.earnings = append(s.earnings, req)
+	return &domain.Commission{}, nil
+}
+
+func (s *fakePaymentService) SettleCancellation(req domain.CancellationSettlementRequest) ([]domain.Transaction, error) {
+	s.settlements = append(s.settlements, req)
+	return nil, nil
+}
+
+func publish(t *testing.T, service *fakePaymentService, eventType string, payload interface{}) {
+	t.Helper()
+	bus := events.NewInMemoryBus()
+	if err := NewDeliverySubscriber(service).Register(bus); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := bus.Publish(context.Background(), eventType, payload); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	bus.Close()
+}
+
+func TestDeliveryCompletedPaysDriver(t *testing.T) {
+	service := &fakePaymentService{}
+	publish(t, service, events.DeliveryCompleted, events.DeliveryCompletedPayload{
+		DeliveryID:      "delivery-1",
+		OrderID:         "order-1",
+		MerchantID:      "merchant-1",
+		DriverID:        "driver-1",
+		DeliveryFee:     4.5,
+		Distance:        3.2,
+		SurgeMultiplier: 1.5,
+	})
+
+	want := domain.DeliveryEarningsRequest{
+		OrderID:         "order-1",
+		MerchantID:      "merchant-1",
+		DriverID:        "driver-1",
+		DeliveryFee:     4.5,
+		Distance:        3.2,
+		SurgeMultiplier: 1.5,
+	}
+	if len(service.earnings) != 1 || service.earnings[0] != want {
+		t.Fatalf("earnings = %+v, want %+v", service.earnings, want)
+	}
+
+	// Without a driver there is nobody to pay
+	service = &fakePaymentService{}
+	publish(t, service, events.DeliveryCompleted, events.DeliveryCompletedPayload{OrderID: "order-1", DeliveryFee: 4.5})
+	if len(service.earnings) != 0 {
+		t.Fatalf("earnings = %+v, want none", service.earnings)
+	}
+}
+
+func TestDeliveryCancelledSettlesFees(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload events.DeliveryCancelledPayload
+		want    bool
+	}{
+		{name: "fee and compensation", payload: events.DeliveryCancelledPayload{OrderID: "order-1", CustomerID: "customer-1", DriverID: "driver-1", CancellationFee: 3, DriverCompensation: 2}, want: true},
+		{name: "compensation only", payload: events.DeliveryCancelledPayload{OrderID: "order-1", DriverID: "driver-1", DriverCompensation: 2}, want: true},
+		{name: "free cancellation", payload: events.DeliveryCancelledPayload{OrderID: "order-1", CustomerID: "customer-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakePaymentService{}
+			publish(t, service, events.DeliveryCancelled, tt.payload)
+
+			if !tt.want {
+				if len(service.settlements) != 0 {
+					t.Fatalf("settlements = %+v, want none", service.settlements)
+				}
+				return
+			}
+			want := domain.CancellationSettlementRequest{
+				OrderID:            tt.payload.OrderID,
+				CustomerID:         tt.payload.CustomerID,
+				DriverID:           tt.payload.DriverID,
+				CancellationFee:    tt.payload.CancellationFee,
+				DriverCompensation: tt.payload.DriverCompensation,
+			}
+			if len(service.settlements) != 1 || service.settlements[0] != want {
+				t.Fatalf("settlements = %+v, want %+v", service.settlements, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// AuthorizePayment places a hold for an order instead of charging it. Card
+// holds sit with the processor; digital wallet holds move the amount from the
+// wallet's balance to its pending balance. The hold is captured on delivery,
+// voided on cancellation, or voided automatically once it expires.
+func (s *paymentService) AuthorizePayment(req domain.ProcessPaymentRequest) (*domain.PaymentResponse, error) {
+	payerWallet, err := s.walletRepo.GetByUserID(req.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("payer wallet not found: %w", err)
+	}
+	if err := requireActiveWallet(payerWallet); err != nil {
+		return nil, err
+	}
+
+	paymentMethod, err := s.paymentMethodRepo.GetByID(req.PaymentMethodID)
+	if err != nil {
+		return nil, fmt.Errorf("payment method not found: %w", err)
+	}
+
+	var holdWalletID *string
+	switch paymentMethod.Type {
+	case domain.PaymentTypeCard:
+		// In production, place the hold with the card processor
+	case domain.PaymentTypeDigitalWallet:
+		if payerWallet.Balance < req.Amount {
+			return nil, domain.ErrInsufficientBalance
+		}
+		holdWalletID = &payerWallet.ID
+	default:
+		return nil, fmt.Errorf("%w: only card and wallet payments can be authorized", domain.ErrUnsupportedPaymentMethod)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(domain.AuthorizationTTL)
+	transaction := &domain.Transaction{
+		ID:              uuid.New().String(),
+		FromWalletID:    &payerWallet.ID,
+		Type:            domain.TxTypePayment,
+		Status:          domain.TxStatusAuthorized,
+		Amount:          req.Amount,
+		Currency:        req.Currency,
+		Description:     req.Description,
+		OrderID:         &req.OrderID,
+		PaymentMethodID: &req.PaymentMethodID,
+		Metadata:        req.Metadata,
+		ExpiresAt:       &expiresAt,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	// The wallet read above may be stale; the hold is only placed if the
+	// wallet is still active and its balance still covers it when the
+	// authorization is saved
+	held, err := s.transactionRepo.CreateAuthorization(transaction, holdWalletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	if !held {
+		return nil, s.debitRefused(*holdWalletID)
+	}
+
+	s.recordCheckoutTip(req, payerWallet)
+
+	return &domain.PaymentResponse{
+		TransactionID: transaction.ID,
+		Status:        domain.TxStatusAuthorized,
+		Amount:        req.Amount,
+		ExpiresAt:     &expiresAt,
+	}, nil
+}
+
+// CapturePayment charges an open authorization, in full or in part. Any
+// uncaptured remainder of a wallet hold is returned to the balance.
+func (s *paymentService) CapturePayment(transactionID string, req domain.CaptureRequest) (*domain.PaymentResponse, error) {
+	transaction, err := s.getOpenAuthorization(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.ExpiresAt != nil && !time.Now().Before(*transaction.ExpiresAt) {
+		if err := s.voidAuthorization(transaction, "expired"); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrAuthorizationExpired
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		amount = transaction.Amount
+	}
+	if amount > transaction.Amount {
+		return nil, domain.ErrCaptureExceedsAuthorization
+	}
+
+	paymentMethod, err := s.paymentMethodRepo.GetByID(*transaction.PaymentMethodID)
+	if err != nil {
+		return nil, fmt.Errorf("payment method not found: %w", err)
+	}
+
+	// Wallet holds give back whatever isn't captured
+	fee := 0.0
+	var walletID *string
+	switch paymentMethod.Type {
+	case domain.PaymentTypeCard:
+		fee = amount * 0.029 // 2.9% typical card fee
+	case domain.PaymentTypeDigitalWallet:
+		walletID = transaction.FromWalletID
+	}
+	held := transaction.Amount
+
+	now := time.Now()
+	if transaction.Metadata == nil {
+		transaction.Metadata = make(map[string]string)
+	}
+	transaction.Metadata["authorized_amount"] = strconv.FormatFloat(held, 'f', 2, 64)
+	transaction.Status = domain.TxStatusCompleted
+	transaction.Amount = amount
+	transaction.Fee = fee
+	transaction.NetAmount = amount - fee
+	transaction.ExpiresAt = nil
+	transaction.ProcessedAt = &now
+	transaction.UpdatedAt = now
+
+	settled, err := s.transactionRepo.SettleAuthorization(transaction, walletID, held, held-amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture authorization: %w", err)
+	}
+	if !settled {
+		// Captured, voided or expired by a concurrent request
+		return nil, domain.ErrNotAuthorized
+	}
+
+	return &domain.PaymentResponse{
+		TransactionID: transaction.ID,
+		Status:        domain.TxStatusCompleted,
+		Amount:        amount,
+		Fee:           fee,
+		NetAmount:     transaction.NetAmount,
+		ProcessedAt:   &now,
+	}, nil
+}
+
+// VoidAuthorization releases an open authorization without charging it
+func (s *paymentService) VoidAuthorization(transactionID string) (*domain.PaymentResponse, error) {
+	transaction, err := s.getOpenAuthorization(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.voidAuthorization(transaction, "voided"); err != nil {
+		return nil, err
+	}
+	s.reverseOrderCommission(transaction.OrderID)
+
+	return &domain.PaymentResponse{
+		TransactionID: transaction.ID,
+		Status:        domain.TxStatusVoided,
+		Amount:        transaction.Amount,
+		ProcessedAt:   transaction.ProcessedAt,
+	}, nil
+}
+
+// ExpireAuthorizations voids every hold past its expiry and returns how many
+// were released. A hold that fails to void is logged and retried on the next
+// run rather than holding up the rest.
+func (s *paymentService) ExpireAuthorizations() (int, error) {
+	transactions, err := s.transactionRepo.GetExpiredAuthorizations(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range transactions {
+		err := s.voidAuthorization(&transactions[i], "expired")
+		if errors.Is(err, domain.ErrNotAuthorized) {
+			continue // settled since it was listed
+		}
+		if err != nil {
+			log.Printf("Failed to expire authorization %s: %v", transactions[i].ID, err)
+			continue
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+func (s *paymentService) getOpenAuthorization(transactionID string) (*domain.Transaction, error) {
+	transaction, err := s.transactionRepo.GetByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Status != domain.TxStatusAuthorized {
+		return nil, domain.ErrNotAuthorized
+	}
+	return transaction, nil
+}
+
+// voidAuthorization releases the hold behind transaction and marks it voided
+func (s *paymentService) voidAuthorization(transaction *domain.Transaction, reason string) error {
+	paymentMethod, err := s.paymentMethodRepo.GetByID(*transaction.PaymentMethodID)
+	if err != nil {
+		return fmt.Errorf("payment method not found: %w", err)
+	}
+
+	// Card holds are released with the processor in production
+	var walletID *string
+	if paymentMethod.Type == domain.PaymentTypeDigitalWallet {
+		walletID = transaction.FromWalletID
+	}
+
+	now := time.Now()
+	if transaction.Metadata == nil {
+		transaction.Metadata = make(map[string]string)
+	}
+	transaction.Metadata["void_reason"] = reason
+	transaction.Status = domain.TxStatusVoided
+	transaction.ExpiresAt = nil
+	transaction.ProcessedAt = &now
+	transaction.UpdatedAt = now
+
+	settled, err := s.transactionRepo.SettleAuthorization(transaction, walletID, transaction.Amount, transaction.Amount)
+	if err != nil {
+		return err
+	}
+	if !settled {
+		return domain.ErrNotAuthorized
+	}
+	return nil
+}
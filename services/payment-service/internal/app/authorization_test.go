@@ -0,0 +1,291 @@
+package app
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+)
+
+// fakeTransactionRepo settles holds the way the database does: only while
+// they are still authorized, moving the wallet amounts in the same step
+type fakeTransactionRepo struct {
+	domain.TransactionRepository
+	transactions map[string]domain.Transaction
+	wallets      map[string]*domain.Wallet
+	// settledElsewhere simulates a concurrent request settling the hold
+	// between the read and the update
+	settledElsewhere bool
+}
+
+func (r *fakeTransactionRepo) GetByID(id string) (*domain.Transaction, error) {
+	transaction, ok := r.transactions[id]
+	if !ok {
+		return nil, errors.New("transaction not found")
+	}
+	return &transaction, nil
+}
+
+func (r *fakeTransactionRepo) GetExpiredAuthorizations(now time.Time) ([]domain.Transaction, error) {
+	var expired []domain.Transaction
+	for _, transaction := range r.transactions {
+		if transaction.Status == domain.TxStatusAuthorized && transaction.ExpiresAt != nil && !transaction.ExpiresAt.After(now) {
+			expired = append(expired, transaction)
+		}
+	}
+	return expired, nil
+}
+
+func (r *fakeTransactionRepo) CreateAuthorization(transaction *domain.Transaction, walletID *string) (bool, error) {
+	if walletID != nil {
+		wallet := r.wallets[*walletID]
+		if wallet.Status != domain.WalletStatusActive || wallet.Balance < transaction.Amount {
+			return false, nil
+		}
+		wallet.Balance -= transaction.Amount
+		wallet.PendingBalance += transaction.Amount
+	}
+	r.transactions[transaction.ID] = *transaction
+	return true, nil
+}
+
+func (r *fakeTransactionRepo) SettleAuthorization(transaction *domain.Transaction, walletID *string, held, refund float64) (bool, error) {
+	if r.settledElsewhere || r.transactions[transaction.ID].Status != domain.TxStatusAuthorized {
+		return false, nil
+	}
+	r.transactions[transaction.ID] = *transaction
+	if walletID != nil {
+		wallet := r.wallets[*walletID]
+		wallet.PendingBalance -= held
+		wallet.Balance += refund
+	}
+	return true, nil
+}
+
+type fakePaymentMethodRepo struct {
+	domain.PaymentMethodRepository
+	methods map[string]domain.PaymentMethod
+}
+
+func (r *fakePaymentMethodRepo) GetByID(id string) (*domain.PaymentMethod, error) {
+	method, ok := r.methods[id]
+	if !ok {
+		return nil, errors.New("payment method not found")
+	}
+	return &method, nil
+}
+
+func newAuthorizationService(transactions ...domain.Transaction) (*paymentService, *fakeTransactionRepo) {
+	repo := &fakeTransactionRepo{
+		transactions: make(map[string]domain.Transaction),
+		wallets: map[string]*domain.Wallet{
+			"wallet-1": {ID: "wallet-1", Balance: 10, PendingBalance: 50},
+		},
+	}
+	for _, transaction := range transactions {
+		repo.transactions[transaction.ID] = transaction
+	}
+	svc := &paymentService{
+		transactionRepo: repo,
+		paymentMethodRepo: &fakePaymentMethodRepo{methods: map[string]domain.PaymentMethod{
+			"card":   {ID: "card", Type: domain.PaymentTypeCard},
+			"wallet": {ID: "wallet", Type: domain.PaymentTypeDigitalWallet},
+		}},
+	}
+	return svc, repo
+}
+
+func authorization(id, methodID string, expiresIn time.Duration) domain.Transaction {
+	walletID := "wallet-1"
+	expiresAt := time.Now().Add(expiresIn)
+	return domain.Transaction{
+		ID:              id,
+		FromWalletID:    &walletID,
+		Type:            domain.TxTypePayment,
+		Status:          domain.TxStatusAuthorized,
+		Amount:          50,
+		PaymentMethodID: &methodID,
+		ExpiresAt:       &expiresAt,
+	}
+}
+
+func TestAuthorizePayment(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		amount      float64
+		stale       float64
+		wantErr     error
+		wantBalance float64
+		wantPending float64
+	}{
+		{name: "card hold", method: "card", amount: 6, wantBalance: 10},
+		{name: "wallet hold", method: "wallet", amount: 6, wantBalance: 4, wantPending: 6},
+		{name: "wallet hold of the whole balance", method: "wallet", amount: 10, wantBalance: 0, wantPending: 10},
+		{name: "wallet hold over the balance", method: "wallet", amount: 11, wantErr: domain.ErrInsufficientBalance, wantBalance: 10},
+		{name: "balance spent by a concurrent hold", method: "wallet", amount: 30, stale: 50, wantErr: domain.ErrInsufficientBalance, wantBalance: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallet := &domain.Wallet{ID: "wallet-1", UserID: "customer-1", Balance: 10, Status: domain.WalletStatusActive}
+			svc, repo := newAuthorizationService()
+			repo.wallets = map[string]*domain.Wallet{wallet.ID: wallet}
+			svc.walletRepo = &fakeWalletRepo{wallets: map[string]*domain.Wallet{wallet.UserID: wallet}, stale: tt.stale}
+
+			result, err := svc.AuthorizePayment(domain.ProcessPaymentRequest{OrderID: "order-1", CustomerID: "customer-1", Amount: tt.amount, PaymentMethodID: tt.method})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				if len(repo.transactions) != 0 {
+					t.Fatalf("rejected hold recorded transactions: %+v", repo.transactions)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if result.Status != domain.TxStatusAuthorized || repo.transactions[result.TransactionID].Status != domain.TxStatusAuthorized {
+					t.Fatalf("hold not authorized: %+v", result)
+				}
+			}
+
+			if wallet.Balance != tt.wantBalance || wallet.PendingBalance != tt.wantPending {
+				t.Fatalf("balance = %v pending %v, want %v pending %v", wallet.Balance, wallet.PendingBalance, tt.wantBalance, tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestCapturePayment(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		status           domain.TransactionStatus
+		expiresIn        time.Duration
+		amount           float64
+		settledElsewhere bool
+		wantErr          error
+		wantStatus       domain.TransactionStatus
+		wantAmount       float64
+		wantFee          float64
+		wantBalance      float64
+		wantPending      float64
+	}{
+		{name: "full wallet capture", method: "wallet", expiresIn: time.Hour, wantStatus: domain.TxStatusCompleted, wantAmount: 50, wantBalance: 10, wantPending: 0},
+		{name: "partial wallet capture returns the rest", method: "wallet", expiresIn: time.Hour, amount: 30, wantStatus: domain.TxStatusCompleted, wantAmount: 30, wantBalance: 30, wantPending: 0},
+		{name: "card capture charges the fee", method: "card", expiresIn: time.Hour, amount: 40, wantStatus: domain.TxStatusCompleted, wantAmount: 40, wantFee: 1.16, wantBalance: 10, wantPending: 50},
+		{name: "more than authorized", method: "wallet", expiresIn: time.Hour, amount: 60, wantErr: domain.ErrCaptureExceedsAuthorization, wantStatus: domain.TxStatusAuthorized, wantAmount: 50, wantBalance: 10, wantPending: 50},
+		{name: "expired hold is voided", method: "wallet", expiresIn: -time.Minute, wantErr: domain.ErrAuthorizationExpired, wantStatus: domain.TxStatusVoided, wantAmount: 50, wantBalance: 60, wantPending: 0},
+		{name: "already captured", method: "wallet", status: domain.TxStatusCompleted, expiresIn: time.Hour, wantErr: domain.ErrNotAuthorized, wantStatus: domain.TxStatusCompleted, wantAmount: 50, wantBalance: 10, wantPending: 50},
+		{name: "settled by a concurrent request", method: "wallet", expiresIn: time.Hour, settledElsewhere: true, wantErr: domain.ErrNotAuthorized, wantStatus: domain.TxStatusAuthorized, wantAmount: 50, wantBalance: 10, wantPending: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hold := authorization("tx-1", tt.method, tt.expiresIn)
+			if tt.status != "" {
+				hold.Status = tt.status
+			}
+			svc, repo := newAuthorizationService(hold)
+			repo.settledElsewhere = tt.settledElsewhere
+
+			_, err := svc.CapturePayment("tx-1", domain.CaptureRequest{Amount: tt.amount})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			stored := repo.transactions["tx-1"]
+			if stored.Status != tt.wantStatus || stored.Amount != tt.wantAmount || math.Abs(stored.Fee-tt.wantFee) > 1e-9 {
+				t.Fatalf("unexpected transaction: %+v", stored)
+			}
+			wallet := repo.wallets["wallet-1"]
+			if wallet.Balance != tt.wantBalance || wallet.PendingBalance != tt.wantPending {
+				t.Fatalf("balance %v and pending %v, want %v and %v", wallet.Balance, wallet.PendingBalance, tt.wantBalance, tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestVoidAuthorization(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		status           domain.TransactionStatus
+		settledElsewhere bool
+		wantErr          error
+		wantStatus       domain.TransactionStatus
+		wantBalance      float64
+		wantPending      float64
+	}{
+		{name: "wallet hold is released", method: "wallet", wantStatus: domain.TxStatusVoided, wantBalance: 60, wantPending: 0},
+		{name: "card hold leaves the wallet alone", method: "card", wantStatus: domain.TxStatusVoided, wantBalance: 10, wantPending: 50},
+		{name: "already voided", method: "wallet", status: domain.TxStatusVoided, wantErr: domain.ErrNotAuthorized, wantStatus: domain.TxStatusVoided, wantBalance: 10, wantPending: 50},
+		{name: "captured by a concurrent request", method: "wallet", settledElsewhere: true, wantErr: domain.ErrNotAuthorized, wantStatus: domain.TxStatusAuthorized, wantBalance: 10, wantPending: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hold := authorization("tx-1", tt.method, time.Hour)
+			if tt.status != "" {
+				hold.Status = tt.status
+			}
+			svc, repo := newAuthorizationService(hold)
+			repo.settledElsewhere = tt.settledElsewhere
+
+			_, err := svc.VoidAuthorization("tx-1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if status := repo.transactions["tx-1"].Status; status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", status, tt.wantStatus)
+			}
+			wallet := repo.wallets["wallet-1"]
+			if wallet.Balance != tt.wantBalance || wallet.PendingBalance != tt.wantPending {
+				t.Fatalf("balance %v and pending %v, want %v and %v", wallet.Balance, wallet.PendingBalance, tt.wantBalance, tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestExpireAuthorizationsContinuesPastFailures(t *testing.T) {
+	broken := authorization("broken", "missing-method", -time.Minute)
+	card := authorization("card", "card", -time.Minute)
+	wallet := authorization("wallet", "wallet", -time.Minute)
+	open := authorization("open", "wallet", time.Hour)
+	svc, repo := newAuthorizationService(broken, card, wallet, open)
+
+	expired, err := svc.ExpireAuthorizations()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expired != 2 {
+		t.Fatalf("expired %d authorizations, want 2", expired)
+	}
+
+	want := map[string]domain.TransactionStatus{
+		"broken": domain.TxStatusAuthorized,
+		"card":   domain.TxStatusVoided,
+		"wallet": domain.TxStatusVoided,
+		"open":   domain.TxStatusAuthorized,
+	}
+	for id, status := range want {
+		if got := repo.transactions[id].Status; got != status {
+			t.Fatalf("%s status = %s, want %s", id, got, status)
+		}
+	}
+	if balance := repo.wallets["wallet-1"].Balance; balance != 60 {
+		t.Fatalf("balance = %v, want 60", balance)
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// SettleCancellation debits the cancellation fee from the customer's wallet,
+// even into a negative balance, and credits the compensation to the driver's.
+// Each side is settled once per order, so a redelivered event is a no-op even
+// when both deliveries run at once, and its balance change is saved together
+// with its transaction. Frozen and suspended wallets are left alone.
+func (s *paymentService) SettleCancellation(req domain.CancellationSettlementRequest) ([]domain.Transaction, error) {
+	var transactions []domain.Transaction
+	settle := func(userID string, txType domain.TransactionType, amount float64, description string) error {
+		amount = roundCents(amount)
+		if userID == "" || amount <= 0 {
+			return nil
+		}
+		wallet, err := s.walletRepo.GetByUserID(userID)
+		if err != nil {
+			return fmt.Errorf("wallet not found for %s: %w", userID, err)
+		}
+
+		now := time.Now()
+		transaction := domain.Transaction{
+			ID:          uuid.New().String(),
+			Type:        txType,
+			Status:      domain.TxStatusCompleted,
+			Amount:      amount,
+			NetAmount:   amount,
+			Currency:    wallet.Currency,
+			Description: description,
+			OrderID:     &req.OrderID,
+			ProcessedAt: &now,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		delta := amount
+		if txType == domain.TxTypeCancellationFee {
+			transaction.FromWalletID = &wallet.ID
+			delta = -amount
+		} else {
+			transaction.ToWalletID = &wallet.ID
+		}
+
+		settled, err := s.transactionRepo.CreateOrderSettlement(&transaction, wallet.ID, delta)
+		if err != nil {
+			return err
+		}
+		if settled {
+			transactions = append(transactions, transaction)
+		}
+		return nil
+	}
+
+	if err := settle(req.CustomerID, domain.TxTypeCancellationFee, req.CancellationFee,
+		fmt.Sprintf("Cancellation fee for order %s", req.OrderID)); err != nil {
+		return nil, fmt.Errorf("failed to charge cancellation fee: %w", err)
+	}
+	if err := settle(req.DriverID, domain.TxTypeCompensation, req.DriverCompensation,
+		fmt.Sprintf("Compensation for cancelled order %s", req.OrderID)); err != nil {
+		return transactions, fmt.Errorf("failed to pay driver compensation: %w", err)
+	}
+	return transactions, nil
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/payment-service/internal/domain"
+)
+
+// fakeSettlementRepo saves a transaction and its balance change together, or
+// neither when failType matches the transaction
+type fakeSettlementRepo struct {
+	domain.TransactionRepository
+	wallets      *fakeWalletRepo
+	transactions []domain.Transaction
+	failType     domain.TransactionType
+}
+
+func (r *fakeSettlementRepo) GetByOrderID(orderID string) ([]domain.Transaction, error) {
+	var transactions []domain.Transaction
+	for _, transaction := range r.transactions {
+		if transaction.OrderID != nil && *transaction.OrderID == orderID {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions, nil
+}
+
+// CreateOrderSettlement settles each order and type once and only charges or
+// pays active wallets, as the locked check in the SQL does
+func (r *fakeSettlementRepo) CreateOrderSettlement(transaction *domain.Transaction, walletID string, delta float64) (bool, error) {
+	if transaction.Type == r.failType {
+		return false, errors.New("db down")
+	}
+	for _, existing := range r.transactions {
+		if existing.OrderID != nil && *existing.OrderID == *transaction.OrderID && existing.Type == transaction.Type {
+			return false, nil
+		}
+	}
+	if wallet := r.wallets.byID(walletID); wallet == nil || wallet.Status != domain.WalletStatusActive {
+		return false, domain.ErrWalletInactive
+	}
+	if err := r.wallets.Credit(walletID, delta); err != nil {
+		return false, err
+	}
+	r.transactions = append(r.transactions, *transaction)
+	return true, nil
+}
+
+func TestSettleCancellation(t *testing.T) {
+	orderID := "order-1"
+
+	tests := []struct {
+		name            string
+		customerBalance float64
+		driverID        string
+		existing        []domain.TransactionType
+		failType        domain.TransactionType
+		customerStatus  domain.WalletStatus
+		wantErr         bool
+		wantCustomer    float64
+		wantDriver      float64
+		wantSettled     int
+	}{
+		{name: "fee and compensation", customerBalance: 10, driverID: "driver-1", wantCustomer: 7, wantDriver: 4, wantSettled: 2},
+		{name: "fee into a negative balance", customerBalance: 1, driverID: "driver-1", wantCustomer: -2, wantDriver: 4, wantSettled: 2},
+		{name: "no driver assigned", customerBalance: 10, wantCustomer: 7, wantSettled: 1},
+		{name: "redelivered event", customerBalance: 10, driverID: "driver-1", existing: []domain.TransactionType{domain.TxTypeCancellationFee, domain.TxTypeCompensation}, wantCustomer: 10, wantSettled: 0},
+		{name: "retry after the fee was charged", customerBalance: 10, driverID: "driver-1", existing: []domain.TransactionType{domain.TxTypeCancellationFee}, wantCustomer: 10, wantDriver: 4, wantSettled: 1},
+		{name: "fee not saved leaves both wallets alone", customerBalance: 10, driverID: "driver-1", failType: domain.TxTypeCancellationFee, wantErr: true, wantCustomer: 10, wantSettled: 0},
+		{name: "frozen customer wallet", customerBalance: 10, driverID: "driver-1", customerStatus: domain.WalletStatusFrozen, wantErr: true, wantCustomer: 10, wantSettled: 0},
+		{name: "compensation not saved leaves the driver alone", customerBalance: 10, driverID: "driver-1", failType: domain.TxTypeCompensation, wantErr: true, wantCustomer: 7, wantSettled: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customerStatus := domain.WalletStatusActive
+			if tt.customerStatus != "" {
+				customerStatus = tt.customerStatus
+			}
+			wallets := &fakeWalletRepo{wallets: map[string]*domain.Wallet{
+				"customer-1": {ID: "wallet-customer", UserID: "customer-1", Balance: tt.customerBalance, Status: customerStatus},
+				"driver-1":   {ID: "wallet-driver", UserID: "driver-1", Status: domain.WalletStatusActive},
+			}}
+			repo := &fakeSettlementRepo{wallets: wallets, failType: tt.failType}
+			for _, txType := range tt.existing {
+				repo.transactions = append(repo.transactions, domain.Transaction{ID: string(txType), Type: txType, OrderID: &orderID})
+			}
+			svc := &paymentService{walletRepo: wallets, transactionRepo: repo}
+
+			settled, err := svc.SettleCancellation(domain.CancellationSettlementRequest{
+				OrderID:            orderID,
+				CustomerID:         "customer-1",
+				DriverID:           tt.driverID,
+				CancellationFee:    3,
+				DriverCompensation: 4,
+			})
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("error = %v, want error: %v", err, tt.wantErr)
+			}
+
+			if len(settled) != tt.wantSettled {
+				t.Fatalf("settled %d transactions, want %d", len(settled), tt.wantSettled)
+			}
+			if len(repo.transactions) != len(tt.existing)+tt.wantSettled {
+				t.Fatalf("recorded %d transactions, want %d", len(repo.transactions), len(tt.existing)+tt.wantSettled)
+			}
+			if balance := wallets.wallets["customer-1"].Balance; balance != tt.wantCustomer {
+				t.Fatalf("customer balance = %v, want %v", balance, tt.wantCustomer)
+			}
+			if balance := wallets.wallets["driver-1"].Balance; balance != tt.wantDriver {
+				t.Fatalf("driver balance = %v, want %v", balance, tt.wantDriver)
+			}
+		})
+	}
+}
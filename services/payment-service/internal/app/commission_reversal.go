@@ -0,0 +1,103 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// ReverseCommission undoes the order's commission. A pending commission paid
+// nothing out and is just voided. A processed one is reversed: the delivery
+// fares credited to the driver are debited back from their wallet, even into
+// a negative balance. Tips stay with the driver, and the merchant's net is
+// only ever paid through settlement, which leaves reversed orders out.
+// Reversing twice is a no-op.
+func (s *paymentService) ReverseCommission(orderID string) (*domain.Commission, error) {
+	for {
+		commission, err := s.commissionRepo.GetByOrderID(orderID)
+		if err != nil {
+			return nil, err
+		}
+
+		from := commission.Status
+		switch from {
+		case domain.CommissionStatusVoided, domain.CommissionStatusReversed:
+			return commission, nil
+		case domain.CommissionStatusProcessed:
+			commission.Status = domain.CommissionStatusReversed
+		default:
+			commission.Status = domain.CommissionStatusVoided
+		}
+		now := time.Now()
+		commission.ReversedAt = &now
+
+		var clawbacks []*domain.Transaction
+		if from == domain.CommissionStatusProcessed {
+			clawbacks, err = s.fareClawbacks(commission, now)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		reversed, err := s.commissionRepo.SaveReversal(commission, from, clawbacks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reverse commission: %w", err)
+		}
+		if reversed {
+			return commission, nil
+		}
+		// The commission was processed or reversed concurrently; statuses only
+		// move forward, so this settles within a couple of rounds
+	}
+}
+
+// fareClawbacks builds a clawback for every delivery fare credited for the
+// commission's order
+func (s *paymentService) fareClawbacks(commission *domain.Commission, now time.Time) ([]*domain.Transaction, error) {
+	transactions, err := s.transactionRepo.GetByOrderID(commission.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order transactions: %w", err)
+	}
+
+	var clawbacks []*domain.Transaction
+	for _, earning := range transactions {
+		if earning.Type != domain.TxTypeEarning || earning.Status != domain.TxStatusCompleted || earning.ToWalletID == nil {
+			continue
+		}
+		clawbacks = append(clawbacks, &domain.Transaction{
+			ID:           uuid.New().String(),
+			FromWalletID: earning.ToWalletID,
+			Type:         domain.TxTypeClawback,
+			Status:       domain.TxStatusCompleted,
+			Amount:       earning.Amount,
+			NetAmount:    earning.Amount,
+			Currency:     earning.Currency,
+			Description:  fmt.Sprintf("Delivery fare reversed for order %s", commission.OrderID),
+			Reference:    commission.ID,
+			OrderID:      &commission.OrderID,
+			ProcessedAt:  &now,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+	}
+	return clawbacks, nil
+}
+
+// reverseOrderCommission reverses the commission of a cancelled or fully
+// refunded order. The refund or void has already happened, so a failure is
+// only logged.
+func (s *paymentService) reverseOrderCommission(orderID *string) {
+	if orderID == nil || *orderID == "" {
+		return
+	}
+	if existing, _ := s.commissionRepo.GetByOrderID(*orderID); existing == nil {
+		return
+	}
+	if _, err := s.ReverseCommission(*orderID); err != nil {
+		log.Printf("Failed to reverse commission for order %s: %v", *orderID, err)
+	}
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"glovo-backend/services/payment-service/internal/domain"
+)
+
+// fakeCommissionRepo flips commission status the way SaveReversal's
+// conditional update does
+type fakeCommissionRepo struct {
+	domain.CommissionRepository
+	commissions map[string]*domain.Commission // by order ID
+	wallets     *fakeWalletRepo
+	clawbacks   []domain.Transaction
+	earnings    []domain.Transaction
+	// staleStatus is returned by the first read, simulating a concurrent
+	// update landing between the read and the write; with staleMissing the
+	// first read finds no commission at all
+	staleStatus  domain.CommissionStatus
+	staleMissing bool
+}
+
+func (r *fakeCommissionRepo) GetByOrderID(orderID string) (*domain.Commission, error) {
+	stored, ok := r.commissions[orderID]
+	if !ok || r.staleMissing {
+		r.staleMissing = false
+		return nil, errors.New("record not found")
+	}
+	read := *stored
+	if r.staleStatus != "" {
+		read.Status = r.staleStatus
+		r.staleStatus = ""
+	}
+	return &read, nil
+}
+
+func (r *fakeCommissionRepo) SaveReversal(commission *domain.Commission, from domain.CommissionStatus, clawbacks []*domain.Transaction) (bool, error) {
+	stored := r.commissions[commission.OrderID]
+	if stored.Status != from {
+		return false, nil
+	}
+	for _, clawback := range clawbacks {
+		if err := r.wallets.Credit(*clawback.FromWalletID, -clawback.Amount); err != nil {
+			return false, err
+		}
+		r.clawbacks = append(r.clawbacks, *clawback)
+	}
+	*stored = *commission
+	return true, nil
+}
+
+func TestReverseCommission(t *testing.T) {
+	orderID := "order-1"
+	driverID := "driver-1"
+	driverWallet := "wallet-driver"
+
+	tests := []struct {
+		name         string
+		status       domain.CommissionStatus
+		staleStatus  domain.CommissionStatus
+		earnings     []float64
+		wantStatus   domain.CommissionStatus
+		wantDriver   float64
+		wantClawback int
+	}{
+		{name: "processed commission claws back the fare", status: domain.CommissionStatusProcessed, earnings: []float64{4.5}, wantStatus: domain.CommissionStatusReversed, wantDriver: 5.5, wantClawback: 1},
+		{name: "into a negative balance", status: domain.CommissionStatusProcessed, earnings: []float64{4.5, 8}, wantStatus: domain.CommissionStatusReversed, wantDriver: -2.5, wantClawback: 2},
+		{name: "no fare was credited", status: domain.CommissionStatusProcessed, wantStatus: domain.CommissionStatusReversed, wantDriver: 10},
+		{name: "pending commission is voided", status: domain.CommissionStatusPending, earnings: []float64{4.5}, wantStatus: domain.CommissionStatusVoided, wantDriver: 10},
+		{name: "already reversed", status: domain.CommissionStatusReversed, earnings: []float64{4.5}, wantStatus: domain.CommissionStatusReversed, wantDriver: 10},
+		{name: "reversed concurrently", status: domain.CommissionStatusReversed, staleStatus: domain.CommissionStatusProcessed, earnings: []float64{4.5}, wantStatus: domain.CommissionStatusReversed, wantDriver: 10},
+		{name: "processed while being voided", status: domain.CommissionStatusProcessed, staleStatus: domain.CommissionStatusPending, earnings: []float64{4.5}, wantStatus: domain.CommissionStatusReversed, wantDriver: 5.5, wantClawback: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallets := &fakeWalletRepo{wallets: map[string]*domain.Wallet{
+				driverID:     {ID: driverWallet, UserID: driverID, Balance: 10},
+				"merchant-1": {ID: "wallet-merchant", UserID: "merchant-1", Balance: 100},
+			}}
+			// Tips are credited for the order too, and stay with the driver
+			transactions := &fakeSettlementRepo{wallets: wallets, transactions: []domain.Transaction{
+				{ID: "tip-1", Type: domain.TxTypeTip, Status: domain.TxStatusCompleted, Amount: 2, ToWalletID: &driverWallet, OrderID: &orderID},
+			}}
+			for i, amount := range tt.earnings {
+				transactions.transactions = append(transactions.transactions, domain.Transaction{
+					ID: fmt.Sprintf("earning-%d", i), Type: domain.TxTypeEarning, Status: domain.TxStatusCompleted, Amount: amount, ToWalletID: &driverWallet, OrderID: &orderID,
+				})
+			}
+			commissions := &fakeCommissionRepo{
+				commissions: map[string]*domain.Commission{orderID: {
+					ID: "commission-1", OrderID: orderID, MerchantID: "merchant-1", DriverID: &driverID,
+					NetToMerchant: 30, Status: tt.status,
+				}},
+				wallets:     wallets,
+				staleStatus: tt.staleStatus,
+			}
+			svc := &paymentService{walletRepo: wallets, transactionRepo: transactions, commissionRepo: commissions}
+
+			commission, err := svc.ReverseCommission(orderID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if commission.Status != tt.wantStatus || commissions.commissions[orderID].Status != tt.wantStatus {
+				t.Fatalf("status = %s (stored %s), want %s", commission.Status, commissions.commissions[orderID].Status, tt.wantStatus)
+			}
+
+			// Reversing again changes nothing
+			if _, err := svc.ReverseCommission(orderID); err != nil {
+				t.Fatalf("second reversal: %v", err)
+			}
+
+			if balance := wallets.wallets[driverID].Balance; balance != tt.wantDriver {
+				t.Fatalf("driver balance = %v, want %v", balance, tt.wantDriver)
+			}
+			if balance := wallets.wallets["merchant-1"].Balance; balance != 100 {
+				t.Fatalf("merchant balance = %v, the merchant's net was never credited", balance)
+			}
+			if len(commissions.clawbacks) != tt.wantClawback {
+				t.Fatalf("recorded %d clawbacks, want %d", len(commissions.clawbacks), tt.wantClawback)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/payment-service/internal/domain"
+)
+
+func (r *fakeCommissionRepo) Create(commission *domain.Commission) error {
+	stored := *commission
+	r.commissions[commission.OrderID] = &stored
+	return nil
+}
+
+func TestCalculateCommission(t *testing.T) {
+	driver, noDriver := "driver-1", ""
+
+	tests := []struct {
+		name              string
+		req               domain.CalculateCommissionRequest
+		wantDriver        bool
+		wantPlatformFee   float64
+		wantMerchantFee   float64
+		wantNetToMerchant float64
+		wantDriverFee     float64
+		wantNetToDriver   float64
+	}{
+		{
+			name:              "merchant only",
+			req:               domain.CalculateCommissionRequest{OrderID: "order-1", OrderAmount: 40, DeliveryFee: 3.5, MerchantID: "merchant-1"},
+			wantPlatformFee:   1.2,
+			wantMerchantFee:   0.8,
+			wantNetToMerchant: 38,
+			wantDriverFee:     3.5, // the platform keeps the whole delivery fee
+		},
+		{
+			name:              "empty driver ID",
+			req:               domain.CalculateCommissionRequest{OrderID: "order-1", OrderAmount: 40, DeliveryFee: 3.5, MerchantID: "merchant-1", DriverID: &noDriver},
+			wantPlatformFee:   1.2,
+			wantMerchantFee:   0.8,
+			wantNetToMerchant: 38,
+			wantDriverFee:     3.5,
+		},
+		{
+			name:              "zero delivery fee",
+			req:               domain.CalculateCommissionRequest{OrderID: "order-1", OrderAmount: 40, MerchantID: "merchant-1", DriverID: &driver, Distance: 1},
+			wantDriver:        true,
+			wantPlatformFee:   1.2,
+			wantMerchantFee:   0.8,
+			wantNetToMerchant: 38,
+			wantDriverFee:     -2.5, // the platform tops up the base fare
+			wantNetToDriver:   2.5,
+		},
+		{
+			name:            "driver only",
+			req:             domain.CalculateCommissionRequest{OrderID: "order-1", DeliveryFee: 4, DriverID: &driver, Distance: 3},
+			wantDriver:      true,
+			wantDriverFee:   0.9,
+			wantNetToDriver: 3.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCommissionRepo{commissions: make(map[string]*domain.Commission)}
+			svc := &paymentService{commissionRepo: repo}
+
+			commission, err := svc.CalculateCommission(tt.req)
+			if err != nil {
+				t.Fatalf("CalculateCommission: %v", err)
+			}
+			if (commission.DriverID != nil) != tt.wantDriver {
+				t.Fatalf("driver ID = %v, want driver %v", commission.DriverID, tt.wantDriver)
+			}
+			if commission.PlatformFee != tt.wantPlatformFee || commission.MerchantFee != tt.wantMerchantFee || commission.NetToMerchant != tt.wantNetToMerchant {
+				t.Fatalf("merchant side = platform %v, fee %v, net %v, want %v, %v, %v",
+					commission.PlatformFee, commission.MerchantFee, commission.NetToMerchant,
+					tt.wantPlatformFee, tt.wantMerchantFee, tt.wantNetToMerchant)
+			}
+			if commission.DriverFee != tt.wantDriverFee || commission.NetToDriver != tt.wantNetToDriver {
+				t.Fatalf("driver side = fee %v, net %v, want %v and %v",
+					commission.DriverFee, commission.NetToDriver, tt.wantDriverFee, tt.wantNetToDriver)
+			}
+			if commission.NetToMerchant < 0 || commission.NetToDriver < 0 {
+				t.Fatalf("negative net: merchant %v, driver %v", commission.NetToMerchant, commission.NetToDriver)
+			}
+			if repo.commissions["order-1"] == nil {
+				t.Fatalf("commission not saved")
+			}
+		})
+	}
+}
+
+func TestCalculateCommissionRejects(t *testing.T) {
+	driver := "driver-1"
+
+	for name, req := range map[string]domain.CalculateCommissionRequest{
+		"negative order amount": {OrderID: "order-1", OrderAmount: -1, MerchantID: "merchant-1"},
+		"negative delivery fee": {OrderID: "order-1", OrderAmount: 40, DeliveryFee: -2, DriverID: &driver},
+		"no merchant or driver": {OrderID: "order-1", OrderAmount: 40, DeliveryFee: 3},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Saving would panic on the fake's nil map
+			svc := &paymentService{commissionRepo: &fakeCommissionRepo{}}
+			if _, err := svc.CalculateCommission(req); !errors.Is(err, domain.ErrInvalidCommission) {
+				t.Fatalf("err = %v, want %v", err, domain.ErrInvalidCommission)
+			}
+		})
+	}
+}
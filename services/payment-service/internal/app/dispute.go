@@ -0,0 +1,196 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// OpenDispute lets a customer challenge one of their payments, in full or in
+// part. A transaction can have only one open dispute at a time, and none
+// once a dispute on it was won.
+func (s *paymentService) OpenDispute(customerID string, req domain.OpenDisputeRequest) (*domain.Dispute, error) {
+	transaction, err := s.transactionRepo.GetByID(req.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Type != domain.TxTypePayment ||
+		(transaction.Status != domain.TxStatusCompleted && transaction.Status != domain.TxStatusAuthorized) {
+		return nil, domain.ErrNotDisputable
+	}
+
+	if transaction.FromWalletID == nil {
+		return nil, domain.ErrNotTransactionOwner
+	}
+	wallet, err := s.walletRepo.GetByID(*transaction.FromWalletID)
+	if err != nil {
+		return nil, fmt.Errorf("payer wallet not found: %w", err)
+	}
+	if wallet.UserID != customerID {
+		return nil, domain.ErrNotTransactionOwner
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		amount = transaction.Amount
+	}
+	if amount > transaction.Amount {
+		return nil, domain.ErrDisputeExceedsAmount
+	}
+
+	existing, err := s.disputeRepo.GetByTransactionID(transaction.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, dispute := range existing {
+		if dispute.IsOpen() {
+			return nil, domain.ErrDisputeExists
+		}
+		if dispute.Status == domain.DisputeStatusWon {
+			return nil, domain.ErrDisputeWon
+		}
+	}
+
+	now := time.Now()
+	dispute := &domain.Dispute{
+		ID:            uuid.New().String(),
+		TransactionID: transaction.ID,
+		CustomerID:    customerID,
+		Amount:        amount,
+		Reason:        req.Reason,
+		Status:        domain.DisputeStatusOpened,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.disputeRepo.Create(dispute); err != nil {
+		return nil, fmt.Errorf("failed to create dispute: %w", err)
+	}
+	return dispute, nil
+}
+
+func (s *paymentService) GetCustomerDisputes(customerID string, limit, offset int) ([]domain.Dispute, error) {
+	return s.disputeRepo.GetByCustomerID(customerID, limit, offset)
+}
+
+func (s *paymentService) ListDisputes(status domain.DisputeStatus, limit, offset int) ([]domain.Dispute, error) {
+	return s.disputeRepo.List(status, limit, offset)
+}
+
+// ReviewDispute marks an opened dispute as being looked into
+func (s *paymentService) ReviewDispute(disputeID string) (*domain.Dispute, error) {
+	dispute, err := s.getOpenDispute(disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	dispute.Status = domain.DisputeStatusUnderReview
+	dispute.UpdatedAt = time.Now()
+	updated, err := s.disputeRepo.UpdateIfOpen(dispute)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, domain.ErrDisputeClosed
+	}
+	return dispute, nil
+}
+
+// ResolveDispute closes a dispute. A won dispute gives the customer their
+// money back: a payment that is still only authorized has the disputed
+// amount released from its hold and the rest captured, a captured one is
+// refunded for the disputed amount. A lost dispute leaves the charge as it
+// is.
+//
+// The dispute is resolved before any money moves, so of two admins
+// resolving it at once only one gets to give the money back.
+func (s *paymentService) ResolveDispute(disputeID, adminID string, req domain.ResolveDisputeRequest) (*domain.Dispute, error) {
+	dispute, err := s.getOpenDispute(disputeID)
+	if err != nil {
+		return nil, err
+	}
+	open := *dispute
+
+	now := time.Now()
+	dispute.Status = req.Status
+	dispute.ResolutionNotes = req.Notes
+	dispute.ResolvedBy = &adminID
+	dispute.ResolvedAt = &now
+	dispute.UpdatedAt = now
+	resolved, err := s.disputeRepo.UpdateIfOpen(dispute)
+	if err != nil {
+		return nil, err
+	}
+	if !resolved {
+		return nil, domain.ErrDisputeClosed
+	}
+
+	if req.Status != domain.DisputeStatusWon {
+		return dispute, nil
+	}
+
+	refundID, err := s.giveDisputeBack(dispute)
+	if err != nil {
+		// Reopen the dispute so the resolution can be retried
+		open.UpdatedAt = time.Now()
+		if reopenErr := s.disputeRepo.Update(&open); reopenErr != nil {
+			log.Printf("Failed to reopen dispute %s after %v: %v", dispute.ID, err, reopenErr)
+		}
+		return nil, err
+	}
+	if refundID != nil {
+		dispute.RefundTransactionID = refundID
+		if err := s.disputeRepo.Update(dispute); err != nil {
+			log.Printf("Failed to record refund %s on dispute %s: %v", *refundID, dispute.ID, err)
+		}
+	}
+	return dispute, nil
+}
+
+// giveDisputeBack releases or refunds the disputed amount of the payment,
+// returning the refund's transaction ID when there is one
+func (s *paymentService) giveDisputeBack(dispute *domain.Dispute) (*string, error) {
+	transaction, err := s.transactionRepo.GetByID(dispute.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch transaction.Status {
+	case domain.TxStatusAuthorized:
+		if dispute.Amount >= transaction.Amount {
+			return nil, s.voidAuthorization(transaction, "dispute won")
+		}
+		// Capturing the undisputed part releases the rest of the hold
+		_, err := s.CapturePayment(transaction.ID, domain.CaptureRequest{Amount: transaction.Amount - dispute.Amount})
+		if errors.Is(err, domain.ErrAuthorizationExpired) {
+			return nil, nil // the whole hold was released instead
+		}
+		return nil, err
+	case domain.TxStatusCompleted:
+		refund, err := s.ProcessRefund(domain.RefundRequest{
+			TransactionID: transaction.ID,
+			Amount:        dispute.Amount,
+			Reason:        "dispute won: " + dispute.Reason,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &refund.TransactionID, nil
+	}
+	return nil, nil
+}
+
+func (s *paymentService) getOpenDispute(disputeID string) (*domain.Dispute, error) {
+	dispute, err := s.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if !dispute.IsOpen() {
+		return nil, domain.ErrDisputeClosed
+	}
+	return dispute, nil
+}
@@ -0,0 +1,335 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+)
+
+// fakeDisputeRepo resolves disputes with the same status check UpdateIfOpen
+// makes in its update
+type fakeDisputeRepo struct {
+	domain.DisputeRepository
+	disputes map[string]*domain.Dispute
+	// resolvedElsewhere resolves the dispute just before it is next saved,
+	// simulating another admin winning the race
+	resolvedElsewhere domain.DisputeStatus
+}
+
+func (r *fakeDisputeRepo) Create(dispute *domain.Dispute) error {
+	stored := *dispute
+	r.disputes[dispute.ID] = &stored
+	return nil
+}
+
+func (r *fakeDisputeRepo) GetByID(id string) (*domain.Dispute, error) {
+	stored, ok := r.disputes[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	read := *stored
+	return &read, nil
+}
+
+func (r *fakeDisputeRepo) GetByTransactionID(transactionID string) ([]domain.Dispute, error) {
+	var disputes []domain.Dispute
+	for _, dispute := range r.disputes {
+		if dispute.TransactionID == transactionID {
+			disputes = append(disputes, *dispute)
+		}
+	}
+	return disputes, nil
+}
+
+func (r *fakeDisputeRepo) Update(dispute *domain.Dispute) error {
+	stored := *dispute
+	r.disputes[dispute.ID] = &stored
+	return nil
+}
+
+func (r *fakeDisputeRepo) UpdateIfOpen(dispute *domain.Dispute) (bool, error) {
+	stored := r.disputes[dispute.ID]
+	if r.resolvedElsewhere != "" {
+		stored.Status = r.resolvedElsewhere
+		r.resolvedElsewhere = ""
+	}
+	if !stored.IsOpen() {
+		return false, nil
+	}
+	return true, r.Update(dispute)
+}
+
+// fakeRefundRepo checks and caps refunds the way CreateRefund does under its
+// row lock
+type fakeRefundRepo struct {
+	domain.TransactionRepository
+	wallets      *fakeWalletRepo
+	transactions []domain.Transaction
+}
+
+func (r *fakeRefundRepo) GetByID(id string) (*domain.Transaction, error) {
+	for _, transaction := range r.transactions {
+		if transaction.ID == id {
+			return &transaction, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *fakeRefundRepo) CreateRefund(refund *domain.Transaction, walletID *string, limit float64) (float64, error) {
+	original, err := r.GetByID(refund.Reference)
+	if err != nil {
+		return 0, err
+	}
+	if original.Type != domain.TxTypePayment || original.Status != domain.TxStatusCompleted {
+		return 0, domain.ErrNotRefundable
+	}
+
+	var refunded float64
+	for _, transaction := range r.transactions {
+		if transaction.Type == domain.TxTypeRefund && transaction.Reference == refund.Reference && transaction.Status == domain.TxStatusCompleted {
+			refunded += transaction.Amount
+		}
+	}
+	if refunded+refund.Amount > limit {
+		return 0, domain.ErrRefundExceedsAmount
+	}
+	if walletID != nil {
+		if err := r.wallets.Credit(*walletID, refund.Amount); err != nil {
+			return 0, err
+		}
+	}
+	r.transactions = append(r.transactions, *refund)
+	return refunded + refund.Amount, nil
+}
+
+func (r *fakeRefundRepo) refunds() []domain.Transaction {
+	var refunds []domain.Transaction
+	for _, transaction := range r.transactions {
+		if transaction.Type == domain.TxTypeRefund {
+			refunds = append(refunds, transaction)
+		}
+	}
+	return refunds
+}
+
+func newDisputeService(priorRefund float64, disputes ...domain.Dispute) (*paymentService, *fakeRefundRepo, *fakeDisputeRepo, *fakeWalletRepo) {
+	customerWallet := "wallet-customer"
+	wallets := &fakeWalletRepo{wallets: map[string]*domain.Wallet{
+		"customer-1": {ID: customerWallet, UserID: "customer-1", Balance: 10, Status: domain.WalletStatusActive},
+	}}
+	transactions := &fakeRefundRepo{wallets: wallets, transactions: []domain.Transaction{{
+		ID: "payment-1", Type: domain.TxTypePayment, Status: domain.TxStatusCompleted, Amount: 50, FromWalletID: &customerWallet,
+	}}}
+	if priorRefund > 0 {
+		transactions.transactions = append(transactions.transactions, domain.Transaction{
+			ID: "refund-0", Type: domain.TxTypeRefund, Status: domain.TxStatusCompleted, Amount: priorRefund, Reference: "payment-1",
+		})
+	}
+	disputeRepo := &fakeDisputeRepo{disputes: make(map[string]*domain.Dispute)}
+	for _, dispute := range disputes {
+		disputeRepo.Create(&dispute)
+	}
+	svc := &paymentService{walletRepo: wallets, transactionRepo: transactions, disputeRepo: disputeRepo}
+	return svc, transactions, disputeRepo, wallets
+}
+
+func TestOpenDispute(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing domain.DisputeStatus
+		wantErr  error
+	}{
+		{name: "first dispute"},
+		{name: "after a lost dispute", existing: domain.DisputeStatusLost},
+		{name: "while another is open", existing: domain.DisputeStatusUnderReview, wantErr: domain.ErrDisputeExists},
+		{name: "after a won dispute", existing: domain.DisputeStatusWon, wantErr: domain.ErrDisputeWon},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var disputes []domain.Dispute
+			if tt.existing != "" {
+				disputes = append(disputes, domain.Dispute{ID: "dispute-0", TransactionID: "payment-1", CustomerID: "customer-1", Amount: 50, Status: tt.existing})
+			}
+			svc, _, _, _ := newDisputeService(0, disputes...)
+
+			dispute, err := svc.OpenDispute("customer-1", domain.OpenDisputeRequest{TransactionID: "payment-1", Reason: "never arrived"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if dispute.Status != domain.DisputeStatusOpened || dispute.Amount != 50 {
+				t.Fatalf("unexpected dispute: %+v", dispute)
+			}
+		})
+	}
+}
+
+func TestResolveDispute(t *testing.T) {
+	tests := []struct {
+		name              string
+		status            domain.DisputeStatus
+		amount            float64
+		priorRefund       float64
+		resolvedElsewhere domain.DisputeStatus
+		wantErr           error
+		wantStored        domain.DisputeStatus
+		wantBalance       float64
+		wantRefunds       int
+	}{
+		{name: "won dispute is refunded", status: domain.DisputeStatusWon, amount: 50, wantStored: domain.DisputeStatusWon, wantBalance: 60, wantRefunds: 1},
+		{name: "partial dispute after a partial refund", status: domain.DisputeStatusWon, amount: 20, priorRefund: 30, wantStored: domain.DisputeStatusWon, wantBalance: 30, wantRefunds: 2},
+		{name: "lost dispute keeps the charge", status: domain.DisputeStatusLost, amount: 50, wantStored: domain.DisputeStatusLost, wantBalance: 10},
+		{name: "resolved concurrently", status: domain.DisputeStatusWon, amount: 50, resolvedElsewhere: domain.DisputeStatusWon, wantErr: domain.ErrDisputeClosed, wantStored: domain.DisputeStatusWon, wantBalance: 10},
+		{name: "more than is left to refund", status: domain.DisputeStatusWon, amount: 50, priorRefund: 30, wantErr: domain.ErrRefundExceedsAmount, wantStored: domain.DisputeStatusUnderReview, wantBalance: 10, wantRefunds: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, transactions, disputes, wallets := newDisputeService(tt.priorRefund, domain.Dispute{
+				ID: "dispute-1", TransactionID: "payment-1", CustomerID: "customer-1", Amount: tt.amount, Status: domain.DisputeStatusUnderReview,
+			})
+			disputes.resolvedElsewhere = tt.resolvedElsewhere
+
+			dispute, err := svc.ResolveDispute("dispute-1", "admin-1", domain.ResolveDisputeRequest{Status: tt.status})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Resolving again never gives the money back twice
+			if _, err := svc.ResolveDispute("dispute-1", "admin-2", domain.ResolveDisputeRequest{Status: tt.status}); tt.wantErr == nil && !errors.Is(err, domain.ErrDisputeClosed) {
+				t.Fatalf("second resolution: expected %v, got %v", domain.ErrDisputeClosed, err)
+			}
+
+			stored := disputes.disputes["dispute-1"]
+			if stored.Status != tt.wantStored {
+				t.Fatalf("stored status = %s, want %s", stored.Status, tt.wantStored)
+			}
+			if balance := wallets.wallets["customer-1"].Balance; balance != tt.wantBalance {
+				t.Fatalf("customer balance = %v, want %v", balance, tt.wantBalance)
+			}
+			refunds := transactions.refunds()
+			if len(refunds) != tt.wantRefunds {
+				t.Fatalf("%d refunds, want %d", len(refunds), tt.wantRefunds)
+			}
+			if tt.wantErr == nil && tt.status == domain.DisputeStatusWon {
+				if dispute.RefundTransactionID == nil || stored.RefundTransactionID == nil || *stored.RefundTransactionID != refunds[len(refunds)-1].ID {
+					t.Fatalf("refund not recorded on the dispute: %+v", stored)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessRefundCap(t *testing.T) {
+	svc, transactions, _, wallets := newDisputeService(0)
+	refund := func(amount float64) error {
+		_, err := svc.ProcessRefund(domain.RefundRequest{TransactionID: "payment-1", Amount: amount, Reason: "damaged item"})
+		return err
+	}
+
+	if err := refund(30); err != nil {
+		t.Fatalf("first refund: %v", err)
+	}
+	if err := refund(25); !errors.Is(err, domain.ErrRefundExceedsAmount) {
+		t.Fatalf("refund past the payment: expected %v, got %v", domain.ErrRefundExceedsAmount, err)
+	}
+	if err := refund(20); err != nil {
+		t.Fatalf("refunding the rest: %v", err)
+	}
+	if err := refund(0.01); !errors.Is(err, domain.ErrRefundExceedsAmount) {
+		t.Fatalf("refund after a full refund: expected %v, got %v", domain.ErrRefundExceedsAmount, err)
+	}
+
+	if balance := wallets.wallets["customer-1"].Balance; balance != 60 {
+		t.Fatalf("customer balance = %v, want 60", balance)
+	}
+	if refunds := transactions.refunds(); len(refunds) != 2 {
+		t.Fatalf("%d refunds, want 2", len(refunds))
+	}
+}
+
+func TestProcessRefundRequiresCompletedPayment(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      domain.TransactionStatus
+		kind        domain.TransactionType
+		wantErr     error
+		wantBalance float64
+	}{
+		{name: "completed payment", status: domain.TxStatusCompleted, kind: domain.TxTypePayment, wantBalance: 30},
+		{name: "voided authorization", status: domain.TxStatusVoided, kind: domain.TxTypePayment, wantErr: domain.ErrNotRefundable, wantBalance: 10},
+		{name: "failed payment", status: domain.TxStatusFailed, kind: domain.TxTypePayment, wantErr: domain.ErrNotRefundable, wantBalance: 10},
+		{name: "open authorization", status: domain.TxStatusAuthorized, kind: domain.TxTypePayment, wantErr: domain.ErrNotRefundable, wantBalance: 10},
+		{name: "top-up", status: domain.TxStatusCompleted, kind: domain.TxTypeTopUp, wantErr: domain.ErrNotRefundable, wantBalance: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, transactions, _, wallets := newDisputeService(0)
+			transactions.transactions[0].Status = tt.status
+			transactions.transactions[0].Type = tt.kind
+
+			_, err := svc.ProcessRefund(domain.RefundRequest{TransactionID: "payment-1", Amount: 20, Reason: "damaged item"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("ProcessRefund: %v", err)
+			}
+			if balance := wallets.wallets["customer-1"].Balance; balance != tt.wantBalance {
+				t.Fatalf("customer balance = %v, want %v", balance, tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestResolveDisputeOnAuthorization(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      float64
+		wantStatus  domain.TransactionStatus
+		wantCharged float64
+		wantBalance float64
+	}{
+		{name: "whole amount disputed", amount: 50, wantStatus: domain.TxStatusVoided, wantCharged: 50, wantBalance: 60},
+		{name: "part of the amount disputed", amount: 20, wantStatus: domain.TxStatusCompleted, wantCharged: 30, wantBalance: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := newAuthorizationService(authorization("auth-1", "wallet", time.Hour))
+			disputes := &fakeDisputeRepo{disputes: make(map[string]*domain.Dispute)}
+			disputes.Create(&domain.Dispute{
+				ID: "dispute-1", TransactionID: "auth-1", CustomerID: "customer-1", Amount: tt.amount, Status: domain.DisputeStatusUnderReview,
+			})
+			svc.disputeRepo = disputes
+
+			if _, err := svc.ResolveDispute("dispute-1", "admin-1", domain.ResolveDisputeRequest{Status: domain.DisputeStatusWon}); err != nil {
+				t.Fatalf("ResolveDispute: %v", err)
+			}
+
+			stored := repo.transactions["auth-1"]
+			if stored.Status != tt.wantStatus || stored.Amount != tt.wantCharged {
+				t.Fatalf("authorization = %s for %v, want %s for %v", stored.Status, stored.Amount, tt.wantStatus, tt.wantCharged)
+			}
+			wallet := repo.wallets["wallet-1"]
+			if wallet.Balance != tt.wantBalance || wallet.PendingBalance != 0 {
+				t.Fatalf("wallet balance = %v pending %v, want %v pending 0", wallet.Balance, wallet.PendingBalance, tt.wantBalance)
+			}
+		})
+	}
+}
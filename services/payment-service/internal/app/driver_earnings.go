@@ -0,0 +1,157 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Driver pay per delivery: a base fare covering the first driverBaseDistance
+// km, then driverDistanceRate per extra km. Surge multiplies both; tips are
+// passed through untouched.
+const (
+	driverBaseFare     = 2.50
+	driverBaseDistance = 2.0
+	driverDistanceRate = 0.60
+)
+
+// calculateDriverEarnings prices one delivery for the driver. Each component
+// is rounded to the cent first, so Total is exactly their sum.
+func calculateDriverEarnings(distance, surgeMultiplier, tip float64) domain.DriverEarnings {
+	earnings := domain.DriverEarnings{
+		BaseFare: driverBaseFare,
+		Tip:      roundCents(math.Max(tip, 0)),
+	}
+	if distance > driverBaseDistance {
+		earnings.DistanceBonus = roundCents((distance - driverBaseDistance) * driverDistanceRate)
+	}
+	if surgeMultiplier > 1 {
+		earnings.Surge = roundCents((earnings.BaseFare + earnings.DistanceBonus) * (surgeMultiplier - 1))
+	}
+	earnings.Total = roundCents(earnings.BaseFare + earnings.DistanceBonus + earnings.Surge + earnings.Tip)
+	return earnings
+}
+
+// applyDriverEarnings sets the commission's driver side; the platform keeps
+// whatever the customer's delivery fee doesn't pay the driver
+func applyDriverEarnings(commission *domain.Commission, earnings domain.DriverEarnings) {
+	commission.DriverEarnings = earnings
+	commission.NetToDriver = earnings.Total
+	commission.DriverFee = roundCents(commission.DeliveryFee - (earnings.Total - earnings.Tip))
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// PayDeliveryEarnings pays a delivery once, however often its event is
+// delivered: the commission only moves to processed, together with the fare
+// credit, from the status it was read in. A pending commission is paid; any
+// other status means there is nothing left to pay.
+func (s *paymentService) PayDeliveryEarnings(req domain.DeliveryEarningsRequest) (*domain.Commission, error) {
+	for {
+		commission, _ := s.commissionRepo.GetByOrderID(req.OrderID)
+		var from domain.CommissionStatus
+		if commission != nil {
+			from = commission.Status
+			if from != domain.CommissionStatusPending && from != domain.CommissionStatusFailed {
+				return commission, nil
+			}
+		}
+
+		tips, err := s.settleOrderTips(req.OrderID, req.DriverID)
+		if err != nil {
+			return nil, err
+		}
+
+		earnings := calculateDriverEarnings(req.Distance, req.SurgeMultiplier, tips)
+		if commission == nil {
+			// The merchant side is filled in if the order commission is calculated
+			commission = &domain.Commission{
+				ID:          uuid.New().String(),
+				OrderID:     req.OrderID,
+				MerchantID:  req.MerchantID,
+				DeliveryFee: req.DeliveryFee,
+				CreatedAt:   time.Now(),
+			}
+		}
+		commission.DriverID = &req.DriverID
+		commission.DeliveryFee = req.DeliveryFee
+		applyDriverEarnings(commission, earnings)
+
+		now := time.Now()
+		commission.Status = domain.CommissionStatusProcessed
+		commission.ProcessedAt = &now
+
+		// Tips were credited as their own transactions
+		var earning *domain.Transaction
+		if fare := roundCents(earnings.Total - earnings.Tip); fare > 0 {
+			if earning, err = s.driverFare(req.DriverID, req.OrderID, fare, now); err != nil {
+				return nil, err
+			}
+		}
+
+		saved, err := s.commissionRepo.SaveDeliveryEarnings(commission, from, earning)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record delivery earnings: %w", err)
+		}
+		if saved {
+			return commission, nil
+		}
+		// Another delivery event got there first; statuses only move forward
+	}
+}
+
+func (s *paymentService) GetDriverEarnings(driverID string, startDate, endDate time.Time) (*domain.DriverEarningsReport, error) {
+	commissions, err := s.commissionRepo.GetByDriverIDBetween(driverID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.DriverEarningsReport{
+		DriverID:   driverID,
+		StartDate:  startDate,
+		EndDate:    endDate,
+		Deliveries: len(commissions),
+		Items:      commissions,
+	}
+	for _, commission := range commissions {
+		report.Earnings.Add(commission.DriverEarnings)
+	}
+
+	// Summing floats drifts below a cent; the totals are quoted in cents
+	report.Earnings.BaseFare = roundCents(report.Earnings.BaseFare)
+	report.Earnings.DistanceBonus = roundCents(report.Earnings.DistanceBonus)
+	report.Earnings.Surge = roundCents(report.Earnings.Surge)
+	report.Earnings.Tip = roundCents(report.Earnings.Tip)
+	report.Earnings.Total = roundCents(report.Earnings.Total)
+	return report, nil
+}
+
+// driverFare builds the earning transaction crediting a delivery fare to the
+// driver's wallet
+func (s *paymentService) driverFare(driverID, orderID string, fare float64, now time.Time) (*domain.Transaction, error) {
+	wallet, err := s.walletRepo.GetByUserID(driverID)
+	if err != nil {
+		return nil, fmt.Errorf("driver wallet not found: %w", err)
+	}
+
+	return &domain.Transaction{
+		ID:          uuid.New().String(),
+		ToWalletID:  &wallet.ID,
+		Type:        domain.TxTypeEarning,
+		Status:      domain.TxStatusCompleted,
+		Amount:      fare,
+		NetAmount:   fare,
+		Currency:    wallet.Currency,
+		Description: fmt.Sprintf("Delivery fare for order %s", orderID),
+		OrderID:     &orderID,
+		ProcessedAt: &now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
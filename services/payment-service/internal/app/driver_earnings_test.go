@@ -0,0 +1,84 @@
+package app
+
+import (
+	"testing"
+
+	"glovo-backend/services/payment-service/internal/domain"
+)
+
+// SaveDeliveryEarnings only saves a commission still in the status it was
+// read in, or creates one when the order has none
+func (r *fakeCommissionRepo) SaveDeliveryEarnings(commission *domain.Commission, from domain.CommissionStatus, earning *domain.Transaction) (bool, error) {
+	stored, exists := r.commissions[commission.OrderID]
+	if from == "" && exists || from != "" && (!exists || stored.Status != from) {
+		return false, nil
+	}
+	if earning != nil {
+		if err := r.wallets.Credit(*earning.ToWalletID, earning.Amount); err != nil {
+			return false, err
+		}
+		r.earnings = append(r.earnings, *earning)
+	}
+	saved := *commission
+	r.commissions[commission.OrderID] = &saved
+	return true, nil
+}
+
+func TestPayDeliveryEarnings(t *testing.T) {
+	orderID := "order-1"
+	driverID := "driver-1"
+
+	tests := []struct {
+		name           string
+		existing       *domain.Commission
+		staleMissing   bool
+		wantStatus     domain.CommissionStatus
+		wantDriver     float64
+		wantEarnings   int
+		wantMerchantID string
+	}{
+		{name: "first delivery event", wantStatus: domain.CommissionStatusProcessed, wantDriver: 3.7, wantEarnings: 1, wantMerchantID: "merchant-1"},
+		{name: "order commission already calculated", existing: &domain.Commission{ID: "commission-1", OrderID: orderID, MerchantID: "merchant-2", NetToMerchant: 30, Status: domain.CommissionStatusPending}, wantStatus: domain.CommissionStatusProcessed, wantDriver: 3.7, wantEarnings: 1, wantMerchantID: "merchant-2"},
+		{name: "paid by a concurrent event", existing: &domain.Commission{ID: "commission-1", OrderID: orderID, Status: domain.CommissionStatusProcessed}, staleMissing: true, wantStatus: domain.CommissionStatusProcessed},
+		{name: "cancelled before delivery", existing: &domain.Commission{ID: "commission-1", OrderID: orderID, Status: domain.CommissionStatusVoided}, wantStatus: domain.CommissionStatusVoided},
+		{name: "reversed after delivery", existing: &domain.Commission{ID: "commission-1", OrderID: orderID, Status: domain.CommissionStatusReversed}, wantStatus: domain.CommissionStatusReversed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallets := &fakeWalletRepo{wallets: map[string]*domain.Wallet{
+				driverID: {ID: "wallet-driver", UserID: driverID, Status: domain.WalletStatusActive},
+			}}
+			commissions := &fakeCommissionRepo{commissions: make(map[string]*domain.Commission), wallets: wallets, staleMissing: tt.staleMissing}
+			if tt.existing != nil {
+				commissions.commissions[orderID] = tt.existing
+			}
+			svc := &paymentService{walletRepo: wallets, transactionRepo: &fakeTipRepo{wallets: wallets}, commissionRepo: commissions}
+
+			// The delivery completed event is delivered twice
+			req := domain.DeliveryEarningsRequest{OrderID: orderID, MerchantID: "merchant-1", DriverID: driverID, DeliveryFee: 4, Distance: 4}
+			for i := 0; i < 2; i++ {
+				commission, err := svc.PayDeliveryEarnings(req)
+				if err != nil {
+					t.Fatalf("delivery event %d: %v", i+1, err)
+				}
+				if commission.Status != tt.wantStatus {
+					t.Fatalf("delivery event %d: status = %s, want %s", i+1, commission.Status, tt.wantStatus)
+				}
+			}
+
+			if balance := wallets.wallets[driverID].Balance; balance != tt.wantDriver {
+				t.Fatalf("driver balance = %v, want %v", balance, tt.wantDriver)
+			}
+			if len(commissions.earnings) != tt.wantEarnings {
+				t.Fatalf("recorded %d earnings, want %d", len(commissions.earnings), tt.wantEarnings)
+			}
+			if tt.wantMerchantID != "" {
+				stored := commissions.commissions[orderID]
+				if stored.MerchantID != tt.wantMerchantID || stored.DriverEarnings.Total != tt.wantDriver {
+					t.Fatalf("unexpected stored commission: %+v", stored)
+				}
+			}
+		})
+	}
+}
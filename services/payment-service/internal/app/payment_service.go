@@ -1,12 +1,17 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"math"
 	"time"
 
 	"glovo-backend/services/payment-service/internal/domain"
 	"glovo-backend/shared/auth"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/pagination"
 
 	"github.com/google/uuid"
 )
@@ -16,8 +21,12 @@ type paymentService struct {
 	transactionRepo   domain.TransactionRepository
 	paymentMethodRepo domain.PaymentMethodRepository
 	commissionRepo    domain.CommissionRepository
+	disputeRepo       domain.DisputeRepository
+	voucherRepo       domain.VoucherRepository
 	stripeService     domain.StripeService
 	bankService       domain.BankService
+	eventBus          events.Bus
+	minMerchantPayout float64
 }
 
 func NewPaymentService(
@@ -25,16 +34,28 @@ func NewPaymentService(
 	transactionRepo domain.TransactionRepository,
 	paymentMethodRepo domain.PaymentMethodRepository,
 	commissionRepo domain.CommissionRepository,
+	disputeRepo domain.DisputeRepository,
+	voucherRepo domain.VoucherRepository,
 	stripeService domain.StripeService,
 	bankService domain.BankService,
+	eventBus events.Bus,
+	minMerchantPayout float64,
 ) domain.PaymentService {
+	if minMerchantPayout <= 0 {
+		minMerchantPayout = domain.DefaultMinMerchantPayout
+	}
+
 	return &paymentService{
 		walletRepo:        walletRepo,
 		transactionRepo:   transactionRepo,
 		paymentMethodRepo: paymentMethodRepo,
 		commissionRepo:    commissionRepo,
+		disputeRepo:       disputeRepo,
+		voucherRepo:       voucherRepo,
 		stripeService:     stripeService,
 		bankService:       bankService,
+		eventBus:          eventBus,
+		minMerchantPayout: minMerchantPayout,
 	}
 }
 
@@ -91,6 +112,9 @@ func (s *paymentService) ProcessPayment(req domain.ProcessPaymentRequest) (*doma
 	if err != nil {
 		return nil, fmt.Errorf("payer wallet not found: %w", err)
 	}
+	if err := requireActiveWallet(payerWallet); err != nil {
+		return nil, err
+	}
 
 	// Get payment method to determine type
 	paymentMethod, err := s.paymentMethodRepo.GetByID(req.PaymentMethodID)
@@ -98,12 +122,23 @@ func (s *paymentService) ProcessPayment(req domain.ProcessPaymentRequest) (*doma
 		return nil, fmt.Errorf("payment method not found: %w", err)
 	}
 
+	// Take the voucher's discount off before charging
+	var voucher *domain.Voucher
+	var discount float64
+	if req.VoucherCode != "" {
+		if voucher, discount, err = s.redeemVoucher(req.VoucherCode, req.Amount, time.Now()); err != nil {
+			return nil, err
+		}
+		req.Amount = roundCents(req.Amount - discount)
+	}
+
 	transaction := &domain.Transaction{
 		ID:              transactionID,
 		FromWalletID:    &payerWallet.ID,
 		Type:            domain.TxTypePayment,
 		Status:          domain.TxStatusPending,
 		Amount:          req.Amount,
+		Discount:        discount,
 		Currency:        req.Currency,
 		Description:     req.Description,
 		OrderID:         &req.OrderID,
@@ -112,11 +147,22 @@ func (s *paymentService) ProcessPayment(req domain.ProcessPaymentRequest) (*doma
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 	}
+	if voucher != nil {
+		transaction.VoucherCode = voucher.Code
+	}
 
 	if err := s.transactionRepo.Create(transaction); err != nil {
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
+	var redemption *domain.VoucherRedemption
+	if voucher != nil {
+		if redemption, err = s.claimRedemption(voucher, transaction, req.CustomerID); err != nil {
+			s.failTransaction(transaction)
+			return nil, err
+		}
+	}
+
 	// Process payment based on method type
 	var paymentResult *domain.PaymentResponse
 	switch paymentMethod.Type {
@@ -127,13 +173,14 @@ func (s *paymentService) ProcessPayment(req domain.ProcessPaymentRequest) (*doma
 	case domain.PaymentTypeBankAccount:
 		paymentResult, err = s.processBankPayment(req, transaction)
 	default:
-		return nil, errors.New("unsupported payment method")
+		err = domain.ErrUnsupportedPaymentMethod
 	}
 
 	if err != nil {
-		transaction.Status = domain.TxStatusFailed
-		transaction.UpdatedAt = time.Now()
-		s.transactionRepo.Update(transaction)
+		if redemption != nil {
+			s.releaseRedemption(redemption)
+		}
+		s.failTransaction(transaction)
 		return nil, err
 	}
 
@@ -149,7 +196,10 @@ func (s *paymentService) ProcessPayment(req domain.ProcessPaymentRequest) (*doma
 		return nil, fmt.Errorf("failed to update transaction: %w", err)
 	}
 
+	s.recordCheckoutTip(req, payerWallet)
+
 	paymentResult.TransactionID = transactionID
+	paymentResult.Discount = discount
 	return paymentResult, nil
 }
 
@@ -159,47 +209,37 @@ func (s *paymentService) ProcessRefund(req domain.RefundRequest) (*domain.Paymen
 	if err != nil {
 		return nil, fmt.Errorf("original transaction not found: %w", err)
 	}
+	if originalTx.Type != domain.TxTypePayment || originalTx.Status != domain.TxStatusCompleted {
+		return nil, domain.ErrNotRefundable
+	}
 
-	// Create refund transaction
+	// Refunds go back to the original payer, and together never come to
+	// more than was paid
+	now := time.Now()
 	refundTransaction := &domain.Transaction{
 		ID:          uuid.New().String(),
-		ToWalletID:  originalTx.FromWalletID, // Refund goes back to original payer
+		ToWalletID:  originalTx.FromWalletID,
 		Type:        domain.TxTypeRefund,
-		Status:      domain.TxStatusPending,
+		Status:      domain.TxStatusCompleted,
 		Amount:      req.Amount,
 		Currency:    originalTx.Currency,
 		Description: fmt.Sprintf("Refund: %s", req.Reason),
 		Reference:   req.TransactionID,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ProcessedAt: &now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
 	}
 
-	if err := s.transactionRepo.Create(refundTransaction); err != nil {
-		return nil, fmt.Errorf("failed to create refund transaction: %w", err)
+	refunded, err := s.transactionRepo.CreateRefund(refundTransaction, originalTx.FromWalletID, originalTx.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refund transaction: %w", err)
 	}
 
-	// Update wallet balance if refunding to wallet
-	if originalTx.FromWalletID != nil {
-		wallet, err := s.walletRepo.GetByID(*originalTx.FromWalletID)
-		if err != nil {
-			return nil, err
-		}
-
-		wallet.Balance += req.Amount
-		wallet.UpdatedAt = time.Now()
-
-		if err := s.walletRepo.Update(wallet); err != nil {
-			return nil, err
-		}
+	// A full refund undoes the order, so nobody keeps its earnings
+	if refunded >= originalTx.Amount {
+		s.reverseOrderCommission(originalTx.OrderID)
 	}
 
-	// Complete refund transaction
-	refundTransaction.Status = domain.TxStatusCompleted
-	now := time.Now()
-	refundTransaction.ProcessedAt = &now
-	refundTransaction.UpdatedAt = now
-	s.transactionRepo.Update(refundTransaction)
-
 	return &domain.PaymentResponse{
 		TransactionID: refundTransaction.ID,
 		Status:        domain.TxStatusCompleted,
@@ -215,10 +255,13 @@ func (s *paymentService) ProcessTransfer(req domain.TransferRequest) (*domain.Pa
 	if err != nil {
 		return nil, fmt.Errorf("sender wallet not found: %w", err)
 	}
+	if err := requireActiveWallet(senderWallet); err != nil {
+		return nil, err
+	}
 
 	// Check sufficient balance
 	if senderWallet.Balance < req.Amount {
-		return nil, errors.New("insufficient balance")
+		return nil, domain.ErrInsufficientBalance
 	}
 
 	// Get receiver wallet
@@ -226,18 +269,14 @@ func (s *paymentService) ProcessTransfer(req domain.TransferRequest) (*domain.Pa
 	if err != nil {
 		return nil, fmt.Errorf("receiver wallet not found: %w", err)
 	}
+	if err := requireActiveWallet(receiverWallet); err != nil {
+		return nil, err
+	}
 
 	transactionID := uuid.New().String()
 
-	// Debit sender
-	senderWallet.Balance -= req.Amount
-	senderWallet.UpdatedAt = time.Now()
-
-	// Credit receiver
-	receiverWallet.Balance += req.Amount
-	receiverWallet.UpdatedAt = time.Now()
-
 	// Create transfer transaction
+	now := time.Now()
 	transaction := &domain.Transaction{
 		ID:           transactionID,
 		FromWalletID: &senderWallet.ID,
@@ -245,25 +284,22 @@ func (s *paymentService) ProcessTransfer(req domain.TransferRequest) (*domain.Pa
 		Type:         domain.TxTypeTransfer,
 		Status:       domain.TxStatusCompleted,
 		Amount:       req.Amount,
+		NetAmount:    req.Amount,
 		Currency:     senderWallet.Currency,
 		Description:  req.Description,
 		Metadata:     req.Metadata,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ProcessedAt:  &now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	}
 
-	now := time.Now()
-	transaction.ProcessedAt = &now
-
-	// Save all changes
-	if err := s.walletRepo.Update(senderWallet); err != nil {
-		return nil, err
-	}
-	if err := s.walletRepo.Update(receiverWallet); err != nil {
-		return nil, err
-	}
-	if err := s.transactionRepo.Create(transaction); err != nil {
-		return nil, err
+	// The balance read above may be stale; the sender is only debited if
+	// the balance still covers the transfer when it is saved
+	if err := s.transactionRepo.CreateTransfer(transaction); err != nil {
+		if errors.Is(err, domain.ErrInsufficientBalance) || errors.Is(err, domain.ErrWalletInactive) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to transfer funds: %w", err)
 	}
 
 	return &domain.PaymentResponse{
@@ -281,6 +317,9 @@ func (s *paymentService) ProcessTopUp(req domain.TopUpRequest) (*domain.PaymentR
 	if err != nil {
 		return nil, fmt.Errorf("wallet not found: %w", err)
 	}
+	if err := requireActiveWallet(wallet); err != nil {
+		return nil, err
+	}
 
 	transactionID := uuid.New().String()
 
@@ -306,20 +345,19 @@ func (s *paymentService) ProcessTopUp(req domain.TopUpRequest) (*domain.PaymentR
 	// In production, use actual payment gateway
 	// For now, simulate successful payment
 
-	// Update wallet balance
-	wallet.Balance += req.Amount
-	wallet.UpdatedAt = time.Now()
-
-	if err := s.walletRepo.Update(wallet); err != nil {
-		return nil, err
-	}
-
-	// Complete transaction
+	// Complete the transaction and credit the wallet together
 	transaction.Status = domain.TxStatusCompleted
+	transaction.NetAmount = req.Amount
 	now := time.Now()
 	transaction.ProcessedAt = &now
 	transaction.UpdatedAt = now
-	s.transactionRepo.Update(transaction)
+	if _, err := s.transactionRepo.SettlePending(transaction, &wallet.ID); err != nil {
+		s.failTransaction(transaction)
+		if errors.Is(err, domain.ErrWalletInactive) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to credit top-up: %w", err)
+	}
 
 	return &domain.PaymentResponse{
 		TransactionID: transactionID,
@@ -336,10 +374,13 @@ func (s *paymentService) ProcessWithdrawal(req domain.WithdrawalRequest) (*domai
 	if err != nil {
 		return nil, fmt.Errorf("wallet not found: %w", err)
 	}
+	if err := requireActiveWallet(wallet); err != nil {
+		return nil, err
+	}
 
 	// Check sufficient balance
 	if wallet.Balance < req.Amount {
-		return nil, errors.New("insufficient balance")
+		return nil, domain.ErrInsufficientBalance
 	}
 
 	transactionID := uuid.New().String()
@@ -362,18 +403,22 @@ func (s *paymentService) ProcessWithdrawal(req domain.WithdrawalRequest) (*domai
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
+	// Reserve the funds with a conditional debit, so concurrent withdrawals
+	// and payouts can't overdraw the wallet
+	debited, err := s.walletRepo.Debit(wallet.ID, req.Amount)
+	if err != nil || !debited {
+		s.failTransaction(transaction)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve withdrawal: %w", err)
+		}
+		return nil, s.debitRefused(wallet.ID)
+	}
+
 	// Process withdrawal via bank transfer
-	// In production, use actual bank service
+	// In production, use actual bank service and credit the wallet back if
+	// the transfer fails, as processPayout does
 	// For now, simulate successful withdrawal
 
-	// Update wallet balance
-	wallet.Balance -= req.Amount
-	wallet.UpdatedAt = time.Now()
-
-	if err := s.walletRepo.Update(wallet); err != nil {
-		return nil, err
-	}
-
 	// Complete transaction
 	transaction.Status = domain.TxStatusCompleted
 	now := time.Now()
@@ -394,10 +439,10 @@ func (s *paymentService) ProcessWithdrawal(req domain.WithdrawalRequest) (*domai
 func (s *paymentService) AddPaymentMethod(userID string, req domain.AddPaymentMethodRequest) (*domain.PaymentMethod, error) {
 	// Validate payment method data
 	if req.Type == domain.PaymentTypeCard && req.CardToken == "" {
-		return nil, errors.New("card token required")
+		return nil, fmt.Errorf("%w: card token required", domain.ErrInvalidPaymentMethod)
 	}
 	if req.Type == domain.PaymentTypeBankAccount && req.BankInfo == nil {
-		return nil, errors.New("bank information required")
+		return nil, fmt.Errorf("%w: bank information required", domain.ErrInvalidPaymentMethod)
 	}
 
 	paymentMethod := &domain.PaymentMethod{
@@ -446,7 +491,7 @@ func (s *paymentService) RemovePaymentMethod(userID, methodID string) error {
 	}
 
 	if method.UserID != userID {
-		return errors.New("unauthorized")
+		return domain.ErrNotPaymentMethodOwner
 	}
 
 	return s.paymentMethodRepo.Delete(methodID)
@@ -457,34 +502,162 @@ func (s *paymentService) GetTransaction(transactionID string) (*domain.Transacti
 	return s.transactionRepo.GetByID(transactionID)
 }
 
-func (s *paymentService) GetTransactionHistory(userID string, limit, offset int) ([]domain.Transaction, error) {
-	return s.transactionRepo.GetByUserID(userID, limit, offset)
+// GetUserTransaction returns a transaction only if it moved money into or out
+// of the user's wallet
+func (s *paymentService) GetUserTransaction(userID, transactionID string) (*domain.Transaction, error) {
+	transaction, err := s.transactionRepo.GetByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.walletRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, domain.ErrNotTransactionOwner
+	}
+	if (transaction.FromWalletID == nil || *transaction.FromWalletID != wallet.ID) &&
+		(transaction.ToWalletID == nil || *transaction.ToWalletID != wallet.ID) {
+		return nil, domain.ErrNotTransactionOwner
+	}
+
+	return transaction, nil
+}
+
+func (s *paymentService) GetTransactionHistory(userID, cursor string, limit int) (*domain.TransactionPage, error) {
+	after, err := pagination.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = pagination.NormalizeLimit(limit)
+
+	transactions, err := s.transactionRepo.GetByUserID(userID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, next := pagination.Trim(transactions, limit, func(t domain.Transaction) (time.Time, string) {
+		return t.CreatedAt, t.ID
+	})
+	return &domain.TransactionPage{Transactions: transactions, NextCursor: next}, nil
 }
 
 func (s *paymentService) GetTransactionReport(userID string, startDate, endDate time.Time) (*domain.TransactionReport, error) {
 	return s.transactionRepo.GetTransactionReport(userID, startDate, endDate)
 }
 
+// reconciliationTolerance absorbs floating point rounding below a cent
+const reconciliationTolerance = 0.005
+
+// ReconcileWallets recomputes each wallet's balance from its ledger and flags
+// wallets whose stored balances drifted. It never modifies wallets.
+func (s *paymentService) ReconcileWallets(cursor string, limit int) (*domain.ReconciliationReport, error) {
+	after, err := pagination.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = pagination.NormalizeLimit(limit)
+
+	wallets, err := s.walletRepo.ListPage(after, limit)
+	if err != nil {
+		return nil, err
+	}
+	wallets, next := pagination.Trim(wallets, limit, func(w domain.Wallet) (time.Time, string) {
+		return w.CreatedAt, w.ID
+	})
+
+	report := &domain.ReconciliationReport{
+		Wallets:    make([]domain.WalletReconciliation, 0, len(wallets)),
+		NextCursor: next,
+	}
+	for _, wallet := range wallets {
+		totals, err := s.transactionRepo.GetLedgerTotals(wallet.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to total ledger for wallet %s: %w", wallet.ID, err)
+		}
+
+		expectedBalance := totals.Credits - totals.Debits - totals.Held
+		entry := domain.WalletReconciliation{
+			WalletID:               wallet.ID,
+			UserID:                 wallet.UserID,
+			Currency:               wallet.Currency,
+			Balance:                wallet.Balance,
+			ExpectedBalance:        expectedBalance,
+			PendingBalance:         wallet.PendingBalance,
+			ExpectedPendingBalance: totals.Held,
+			Difference:             wallet.Balance - expectedBalance,
+		}
+		entry.Discrepancy = math.Abs(entry.Difference) > reconciliationTolerance ||
+			math.Abs(wallet.PendingBalance-totals.Held) > reconciliationTolerance
+		if entry.Discrepancy {
+			report.Discrepancies++
+		}
+		report.Wallets = append(report.Wallets, entry)
+	}
+	return report, nil
+}
+
+func (s *paymentService) SearchTransactions(filter domain.TransactionFilter) (*domain.TransactionSearchPage, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	if filter.StartDate != nil && filter.EndDate != nil && !filter.EndDate.After(*filter.StartDate) {
+		return nil, fmt.Errorf("%w: end_date must be after start_date", domain.ErrInvalidTransactionFilter)
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MaxAmount < *filter.MinAmount {
+		return nil, fmt.Errorf("%w: max_amount must be at least min_amount", domain.ErrInvalidTransactionFilter)
+	}
+
+	transactions, total, err := s.transactionRepo.Search(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TransactionSearchPage{
+		Transactions: transactions,
+		Total:        total,
+		Limit:        filter.Limit,
+		Offset:       filter.Offset,
+	}, nil
+}
+
 // Commission management
-func (s *paymentService) CalculateCommission(orderID string, orderAmount, deliveryFee float64, merchantID, driverID string) (*domain.Commission, error) {
-	platformFee := orderAmount * 0.03 // 3% platform fee
-	merchantFee := orderAmount * 0.02 // 2% merchant fee
-	driverFee := deliveryFee * 0.10   // 10% driver fee
+
+// CalculateCommission splits an order between the platform, the merchant and
+// the driver. The platform fee is always taken; the merchant fee and net only
+// apply when there is a merchant, and driver earnings only when there is a
+// driver. Without a driver the platform keeps the whole delivery fee. Nets are
+// never negative.
+func (s *paymentService) CalculateCommission(req domain.CalculateCommissionRequest) (*domain.Commission, error) {
+	if req.OrderAmount < 0 || req.DeliveryFee < 0 {
+		return nil, fmt.Errorf("%w: order amount and delivery fee cannot be negative", domain.ErrInvalidCommission)
+	}
+	if req.MerchantID == "" && !hasDriver(req.DriverID) {
+		return nil, fmt.Errorf("%w: a merchant or a driver is required", domain.ErrInvalidCommission)
+	}
+
+	platformFee := roundCents(req.OrderAmount * 0.03) // 3% platform fee
 
 	commission := &domain.Commission{
-		ID:            uuid.New().String(),
-		OrderID:       orderID,
-		MerchantID:    merchantID,
-		DriverID:      &driverID,
-		OrderAmount:   orderAmount,
-		DeliveryFee:   deliveryFee,
-		PlatformFee:   platformFee,
-		MerchantFee:   merchantFee,
-		DriverFee:     driverFee,
-		NetToMerchant: orderAmount - platformFee - merchantFee,
-		NetToDriver:   deliveryFee - driverFee,
-		Status:        domain.CommissionStatusPending,
-		CreatedAt:     time.Now(),
+		ID:          uuid.New().String(),
+		OrderID:     req.OrderID,
+		MerchantID:  req.MerchantID,
+		OrderAmount: req.OrderAmount,
+		DeliveryFee: req.DeliveryFee,
+		PlatformFee: platformFee,
+		DriverFee:   req.DeliveryFee,
+		Status:      domain.CommissionStatusPending,
+		CreatedAt:   time.Now(),
+	}
+	if req.MerchantID != "" {
+		commission.MerchantFee = roundCents(req.OrderAmount * 0.02) // 2% merchant fee
+		commission.NetToMerchant = math.Max(roundCents(req.OrderAmount-platformFee-commission.MerchantFee), 0)
+	}
+	if hasDriver(req.DriverID) {
+		driverID := *req.DriverID
+		commission.DriverID = &driverID
+		applyDriverEarnings(commission, calculateDriverEarnings(req.Distance, req.SurgeMultiplier, req.Tip))
 	}
 
 	if err := s.commissionRepo.Create(commission); err != nil {
@@ -494,6 +667,10 @@ func (s *paymentService) CalculateCommission(orderID string, orderAmount, delive
 	return commission, nil
 }
 
+func hasDriver(driverID *string) bool {
+	return driverID != nil && *driverID != ""
+}
+
 func (s *paymentService) ProcessCommission(commissionID string) error {
 	commission, err := s.commissionRepo.GetByID(commissionID)
 	if err != nil {
@@ -516,89 +693,94 @@ func (s *paymentService) GetDriverCommissions(driverID string, limit, offset int
 }
 
 // Payouts
+
+// ProcessMerchantPayout moves amount out of the merchant's available balance
+// to their bank account, if it meets the configured minimum
 func (s *paymentService) ProcessMerchantPayout(merchantID string, amount float64) (*domain.PaymentResponse, error) {
-	// Get merchant wallet
-	wallet, err := s.walletRepo.GetByUserID(merchantID)
-	if err != nil {
-		return nil, fmt.Errorf("merchant wallet not found: %w", err)
+	if amount < s.minMerchantPayout {
+		return nil, fmt.Errorf("%w of %.2f", domain.ErrPayoutBelowMinimum, s.minMerchantPayout)
 	}
+	return s.processPayout(merchantID, auth.RoleMerchant, amount, "Merchant payout")
+}
 
-	transactionID := uuid.New().String()
+// ProcessDriverPayout moves amount out of the driver's available balance to
+// their bank account
+func (s *paymentService) ProcessDriverPayout(driverID string, amount float64) (*domain.PaymentResponse, error) {
+	return s.processPayout(driverID, auth.RoleDriver, amount, "Driver payout")
+}
 
-	// Create payout transaction
-	transaction := &domain.Transaction{
-		ID:          transactionID,
-		ToWalletID:  &wallet.ID,
-		Type:        domain.TxTypePayout,
-		Status:      domain.TxStatusCompleted,
-		Amount:      amount,
-		Currency:    wallet.Currency,
-		Description: "Merchant payout",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+// processPayout debits amount from the user's wallet and sends it to their
+// bank account. Pending balance is not available for payout. The amount is
+// reserved with a conditional debit before the bank transfer, so concurrent
+// payouts can't overdraw the wallet, and credited back if the transfer fails.
+func (s *paymentService) processPayout(userID string, role auth.UserRole, amount float64, description string) (*domain.PaymentResponse, error) {
+	wallet, err := s.walletRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s wallet not found: %w", role, err)
 	}
-
-	now := time.Now()
-	transaction.ProcessedAt = &now
-
-	// Update wallet balance
-	wallet.Balance += amount
-	wallet.UpdatedAt = time.Now()
-
-	if err := s.walletRepo.Update(wallet); err != nil {
+	if err := requireActiveWallet(wallet); err != nil {
 		return nil, err
 	}
-
-	if err := s.transactionRepo.Create(transaction); err != nil {
-		return nil, err
+	if wallet.Balance < amount {
+		return nil, fmt.Errorf("%w: %.2f available", domain.ErrInsufficientBalance, wallet.Balance)
 	}
 
-	return &domain.PaymentResponse{
-		TransactionID: transactionID,
-		Status:        domain.TxStatusCompleted,
-		Amount:        amount,
-		NetAmount:     amount,
-		ProcessedAt:   transaction.ProcessedAt,
-	}, nil
-}
-
-func (s *paymentService) ProcessDriverPayout(driverID string, amount float64) (*domain.PaymentResponse, error) {
-	// Get driver wallet
-	wallet, err := s.walletRepo.GetByUserID(driverID)
+	account, err := s.payoutAccount(userID)
 	if err != nil {
-		return nil, fmt.Errorf("driver wallet not found: %w", err)
+		return nil, err
 	}
 
 	transactionID := uuid.New().String()
 
-	// Create payout transaction
+	// Create outgoing payout transaction
 	transaction := &domain.Transaction{
-		ID:          transactionID,
-		ToWalletID:  &wallet.ID,
-		Type:        domain.TxTypePayout,
-		Status:      domain.TxStatusCompleted,
-		Amount:      amount,
-		Currency:    wallet.Currency,
-		Description: "Driver payout",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:              transactionID,
+		FromWalletID:    &wallet.ID,
+		Type:            domain.TxTypePayout,
+		Status:          domain.TxStatusPending,
+		Amount:          amount,
+		NetAmount:       amount,
+		Currency:        wallet.Currency,
+		Description:     description,
+		PaymentMethodID: &account.ID,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if err := s.transactionRepo.Create(transaction); err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
-	now := time.Now()
-	transaction.ProcessedAt = &now
-
-	// Update wallet balance
-	wallet.Balance += amount
-	wallet.UpdatedAt = time.Now()
+	// Reserve the funds; the balance read above may already be stale
+	debited, err := s.walletRepo.Debit(wallet.ID, amount)
+	if err != nil || !debited {
+		s.failTransaction(transaction)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve payout: %w", err)
+		}
+		return nil, s.debitRefused(wallet.ID)
+	}
 
-	if err := s.walletRepo.Update(wallet); err != nil {
-		return nil, err
+	transfer, err := s.bankService.ProcessACHTransfer(*account.BankInfo, amount)
+	if err != nil {
+		if creditErr := s.walletRepo.Credit(wallet.ID, amount); creditErr != nil {
+			log.Printf("Failed to return %.2f to wallet %s after failed payout %s: %v", amount, wallet.ID, transaction.ID, creditErr)
+		}
+		s.failTransaction(transaction)
+		return nil, fmt.Errorf("bank transfer failed: %w", err)
 	}
 
-	if err := s.transactionRepo.Create(transaction); err != nil {
-		return nil, err
+	now := time.Now()
+	transaction.Status = domain.TxStatusCompleted
+	transaction.Reference = transfer.TransferID
+	transaction.ProcessedAt = &now
+	transaction.UpdatedAt = now
+	if err := s.transactionRepo.Update(transaction); err != nil {
+		// The money has left; the wallet stays debited
+		log.Printf("Failed to complete payout transaction %s: %v", transaction.ID, err)
 	}
 
+	s.publishPayoutCompleted(transaction, userID, role)
+
 	return &domain.PaymentResponse{
 		TransactionID: transactionID,
 		Status:        domain.TxStatusCompleted,
@@ -608,6 +790,38 @@ func (s *paymentService) ProcessDriverPayout(driverID string, amount float64) (*
 	}, nil
 }
 
+// failTransaction marks transaction failed, logging if that can't be saved
+func (s *paymentService) failTransaction(transaction *domain.Transaction) {
+	transaction.Status = domain.TxStatusFailed
+	transaction.UpdatedAt = time.Now()
+	if err := s.transactionRepo.Update(transaction); err != nil {
+		log.Printf("Failed to mark transaction %s failed: %v", transaction.ID, err)
+	}
+}
+
+// payoutAccount returns the user's active bank account, preferring the default
+func (s *paymentService) payoutAccount(userID string) (*domain.PaymentMethod, error) {
+	methods, err := s.paymentMethodRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payment methods: %w", err)
+	}
+
+	var account *domain.PaymentMethod
+	for i := range methods {
+		method := &methods[i]
+		if method.Type != domain.PaymentTypeBankAccount || method.Status != domain.PaymentStatusActive || method.BankInfo == nil {
+			continue
+		}
+		if account == nil || method.IsDefault {
+			account = method
+		}
+	}
+	if account == nil {
+		return nil, domain.ErrNoPayoutAccount
+	}
+	return account, nil
+}
+
 // Helper methods for payment processing
 func (s *paymentService) processCardPayment(req domain.ProcessPaymentRequest, transaction *domain.Transaction) (*domain.PaymentResponse, error) {
 	// In production, integrate with Stripe or other payment processor
@@ -627,15 +841,25 @@ func (s *paymentService) processCardPayment(req domain.ProcessPaymentRequest, tr
 func (s *paymentService) processWalletPayment(req domain.ProcessPaymentRequest, transaction *domain.Transaction, wallet *domain.Wallet) (*domain.PaymentResponse, error) {
 	// Check sufficient balance
 	if wallet.Balance < req.Amount {
-		return nil, errors.New("insufficient wallet balance")
+		return nil, domain.ErrInsufficientBalance
 	}
 
-	// Deduct from wallet
-	wallet.Balance -= req.Amount
-	wallet.UpdatedAt = time.Now()
-
-	if err := s.walletRepo.Update(wallet); err != nil {
-		return nil, err
+	// The balance read above may be stale; the wallet is only debited if the
+	// balance still covers the payment when the transaction completes
+	now := time.Now()
+	transaction.Status = domain.TxStatusCompleted
+	transaction.NetAmount = req.Amount
+	transaction.ProcessedAt = &now
+	transaction.UpdatedAt = now
+	settled, err := s.transactionRepo.SettlePendingDebit(transaction, wallet.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrInsufficientBalance) || errors.Is(err, domain.ErrWalletInactive) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to charge wallet: %w", err)
+	}
+	if !settled {
+		return nil, fmt.Errorf("transaction %s was already settled", transaction.ID)
 	}
 
 	return &domain.PaymentResponse{
@@ -660,3 +884,17 @@ func (s *paymentService) processBankPayment(req domain.ProcessPaymentRequest, tr
 		NetAmount: netAmount,
 	}, nil
 }
+
+func (s *paymentService) publishPayoutCompleted(transaction *domain.Transaction, userID string, role auth.UserRole) {
+	payload := events.PayoutCompletedPayload{
+		TransactionID: transaction.ID,
+		UserID:        userID,
+		UserRole:      string(role),
+		Amount:        transaction.Amount,
+		Currency:      transaction.Currency,
+		CompletedAt:   *transaction.ProcessedAt,
+	}
+	if err := s.eventBus.Publish(context.Background(), events.PayoutCompleted, payload); err != nil {
+		log.Printf("Failed to publish %s for transaction %s: %v", events.PayoutCompleted, transaction.ID, err)
+	}
+}
@@ -0,0 +1,241 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/payment-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// fakeLedgerRepo moves wallet balances together with the transaction
+// record, with the balance check the database makes in the same update
+type fakeLedgerRepo struct {
+	fakePayoutTransactionRepo
+	wallets *fakeWalletRepo
+}
+
+func newLedgerRepo(wallets *fakeWalletRepo) *fakeLedgerRepo {
+	return &fakeLedgerRepo{
+		fakePayoutTransactionRepo: fakePayoutTransactionRepo{transactions: make(map[string]domain.Transaction)},
+		wallets:                   wallets,
+	}
+}
+
+// credit adds amount to an active wallet, as creditWallet does
+func (r *fakeLedgerRepo) credit(walletID string, amount float64) error {
+	if wallet := r.wallets.byID(walletID); wallet == nil || wallet.Status != domain.WalletStatusActive {
+		return domain.ErrWalletInactive
+	}
+	return r.wallets.Credit(walletID, amount)
+}
+
+// debit takes amount from an active wallet that covers it, as debitWallet does
+func (r *fakeLedgerRepo) debit(walletID string, amount float64) error {
+	debited, err := r.wallets.Debit(walletID, amount)
+	if err != nil || debited {
+		return err
+	}
+	if wallet := r.wallets.byID(walletID); wallet == nil || wallet.Status != domain.WalletStatusActive {
+		return domain.ErrWalletInactive
+	}
+	return domain.ErrInsufficientBalance
+}
+
+func (r *fakeLedgerRepo) SettlePending(transaction *domain.Transaction, walletID *string) (bool, error) {
+	if r.transactions[transaction.ID].Status != domain.TxStatusPending {
+		return false, nil
+	}
+	if walletID != nil {
+		if err := r.credit(*walletID, transaction.NetAmount); err != nil {
+			return false, err
+		}
+	}
+	r.transactions[transaction.ID] = *transaction
+	return true, nil
+}
+
+func (r *fakeLedgerRepo) SettlePendingDebit(transaction *domain.Transaction, walletID string) (bool, error) {
+	if r.transactions[transaction.ID].Status != domain.TxStatusPending {
+		return false, nil
+	}
+	if err := r.debit(walletID, transaction.Amount); err != nil {
+		return false, err
+	}
+	r.transactions[transaction.ID] = *transaction
+	return true, nil
+}
+
+func (r *fakeLedgerRepo) CreateTransfer(transaction *domain.Transaction) error {
+	if wallet := r.wallets.byID(*transaction.ToWalletID); wallet == nil || wallet.Status != domain.WalletStatusActive {
+		return domain.ErrWalletInactive
+	}
+	if err := r.debit(*transaction.FromWalletID, transaction.Amount); err != nil {
+		return err
+	}
+	if err := r.credit(*transaction.ToWalletID, transaction.NetAmount); err != nil {
+		return err
+	}
+	r.transactions[transaction.ID] = *transaction
+	return nil
+}
+
+func TestProcessTransfer(t *testing.T) {
+	tests := []struct {
+		name         string
+		amount       float64
+		wantErr      error
+		wantSender   float64
+		wantReceiver float64
+	}{
+		{name: "covered by the stored balance", amount: 30, wantSender: 10, wantReceiver: 30},
+		{name: "covered only by a stale read", amount: 50, wantErr: domain.ErrInsufficientBalance, wantSender: 40, wantReceiver: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Reads see 60 more than is stored, as if a payout debited the
+			// sender right after them
+			wallets := &fakeWalletRepo{
+				wallets: map[string]*domain.Wallet{
+					"customer-1": {ID: "wallet-1", UserID: "customer-1", Balance: 40, Status: domain.WalletStatusActive},
+					"customer-2": {ID: "wallet-2", UserID: "customer-2", Status: domain.WalletStatusActive},
+				},
+				stale: 60,
+			}
+			transactions := newLedgerRepo(wallets)
+			svc := NewPaymentService(wallets, transactions, nil, nil, nil, nil, nil, nil, events.NewInMemoryBus(), 0)
+
+			_, err := svc.ProcessTransfer(domain.TransferRequest{FromUserID: "customer-1", ToUserID: "customer-2", Amount: tt.amount})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("ProcessTransfer: %v", err)
+			}
+
+			if sender := wallets.wallets["customer-1"].Balance; sender != tt.wantSender {
+				t.Fatalf("sender balance = %v, want %v", sender, tt.wantSender)
+			}
+			if receiver := wallets.wallets["customer-2"].Balance; receiver != tt.wantReceiver {
+				t.Fatalf("receiver balance = %v, want %v", receiver, tt.wantReceiver)
+			}
+			wantTransactions := 1
+			if tt.wantErr != nil {
+				wantTransactions = 0
+			}
+			if len(transactions.transactions) != wantTransactions {
+				t.Fatalf("recorded %d transactions, want %d", len(transactions.transactions), wantTransactions)
+			}
+		})
+	}
+}
+
+func TestProcessTopUpCreditsStoredBalance(t *testing.T) {
+	// Reads see 30 less than is stored, as if a refund landed right after them
+	wallets := &fakeWalletRepo{
+		wallets: map[string]*domain.Wallet{"customer-1": {ID: "wallet-1", UserID: "customer-1", Balance: 40, Status: domain.WalletStatusActive}},
+		stale:   -30,
+	}
+	transactions := newLedgerRepo(wallets)
+	svc := NewPaymentService(wallets, transactions, nil, nil, nil, nil, nil, nil, events.NewInMemoryBus(), 0)
+
+	result, err := svc.ProcessTopUp(domain.TopUpRequest{UserID: "customer-1", Amount: 20, PaymentMethodID: "card-1"})
+	if err != nil {
+		t.Fatalf("ProcessTopUp: %v", err)
+	}
+	if balance := wallets.wallets["customer-1"].Balance; balance != 60 {
+		t.Fatalf("balance = %v after topping up, want 60", balance)
+	}
+	if stored := transactions.transactions[result.TransactionID]; stored.Status != domain.TxStatusCompleted || stored.NetAmount != 20 {
+		t.Fatalf("unexpected top-up transaction: %+v", stored)
+	}
+}
+
+func TestProcessWalletPayment(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      float64
+		wantErr     error
+		wantBalance float64
+		wantTx      domain.TransactionStatus
+	}{
+		{name: "covered by the stored balance", amount: 30, wantBalance: 10, wantTx: domain.TxStatusCompleted},
+		{name: "covered only by a stale read", amount: 50, wantErr: domain.ErrInsufficientBalance, wantBalance: 40, wantTx: domain.TxStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallets := &fakeWalletRepo{
+				wallets: map[string]*domain.Wallet{"customer-1": {ID: "wallet-1", UserID: "customer-1", Balance: 40, Status: domain.WalletStatusActive}},
+				stale:   60,
+			}
+			methods := &fakePaymentMethodRepo{methods: map[string]domain.PaymentMethod{
+				"wallet": {ID: "wallet", Type: domain.PaymentTypeDigitalWallet},
+			}}
+			transactions := newLedgerRepo(wallets)
+			svc := NewPaymentService(wallets, transactions, methods, nil, nil, nil, nil, nil, events.NewInMemoryBus(), 0)
+
+			_, err := svc.ProcessPayment(domain.ProcessPaymentRequest{OrderID: "order-1", CustomerID: "customer-1", Amount: tt.amount, PaymentMethodID: "wallet"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("ProcessPayment: %v", err)
+			}
+
+			if balance := wallets.wallets["customer-1"].Balance; balance != tt.wantBalance {
+				t.Fatalf("balance = %v, want %v", balance, tt.wantBalance)
+			}
+			for _, transaction := range transactions.transactions {
+				if transaction.Status != tt.wantTx {
+					t.Fatalf("transaction status = %s, want %s", transaction.Status, tt.wantTx)
+				}
+			}
+		})
+	}
+}
+
+func TestGetUserTransaction(t *testing.T) {
+	payer, payee, other := "wallet-1", "wallet-2", "wallet-3"
+	wallets := &fakeWalletRepo{wallets: map[string]*domain.Wallet{
+		"customer-1": {ID: payer, UserID: "customer-1"},
+		"merchant-1": {ID: payee, UserID: "merchant-1"},
+		"customer-2": {ID: other, UserID: "customer-2"},
+	}}
+	transactions := &fakeRefundRepo{wallets: wallets, transactions: []domain.Transaction{
+		{ID: "tx-1", FromWalletID: &payer, ToWalletID: &payee, Type: domain.TxTypePayment, Amount: 20},
+	}}
+	svc := NewPaymentService(wallets, transactions, nil, nil, nil, nil, nil, nil, events.NewInMemoryBus(), 0)
+
+	tests := []struct {
+		name    string
+		userID  string
+		wantErr error
+	}{
+		{name: "payer", userID: "customer-1"},
+		{name: "payee", userID: "merchant-1"},
+		{name: "another customer", userID: "customer-2", wantErr: domain.ErrNotTransactionOwner},
+		{name: "no wallet", userID: "customer-3", wantErr: domain.ErrNotTransactionOwner},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction, err := svc.GetUserTransaction(tt.userID, "tx-1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetUserTransaction: %v", err)
+			}
+			if transaction.ID != "tx-1" {
+				t.Fatalf("got transaction %q, want tx-1", transaction.ID)
+			}
+		})
+	}
+}
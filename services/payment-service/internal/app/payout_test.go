@@ -0,0 +1,260 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/payment-service/internal/domain"
+	"glovo-backend/shared/auth"
+	"glovo-backend/shared/events"
+)
+
+// fakeWalletRepo hands out copies, like reading a row, while Debit and
+// Credit apply to the stored wallet the way the conditional updates do
+type fakeWalletRepo struct {
+	domain.WalletRepository
+	wallets map[string]*domain.Wallet // by user ID
+	// stale is added to the balance callers read, simulating a concurrent
+	// debit landing between the read and the update
+	stale float64
+	// staleStatus, when set, is the status GetByUserID reports, simulating
+	// a freeze landing between the read and the update
+	staleStatus domain.WalletStatus
+}
+
+func (r *fakeWalletRepo) GetByUserID(userID string) (*domain.Wallet, error) {
+	wallet, ok := r.wallets[userID]
+	if !ok {
+		return nil, errors.New("wallet not found")
+	}
+	read := *wallet
+	read.Balance += r.stale
+	if r.staleStatus != "" {
+		read.Status = r.staleStatus
+	}
+	return &read, nil
+}
+
+func (r *fakeWalletRepo) GetByID(id string) (*domain.Wallet, error) {
+	wallet := r.byID(id)
+	if wallet == nil {
+		return nil, errors.New("wallet not found")
+	}
+	read := *wallet
+	return &read, nil
+}
+
+func (r *fakeWalletRepo) byID(id string) *domain.Wallet {
+	for _, wallet := range r.wallets {
+		if wallet.ID == id {
+			return wallet
+		}
+	}
+	return nil
+}
+
+func (r *fakeWalletRepo) Debit(id string, amount float64) (bool, error) {
+	wallet := r.byID(id)
+	if wallet == nil || wallet.Status != domain.WalletStatusActive || wallet.Balance < amount {
+		return false, nil
+	}
+	wallet.Balance -= amount
+	return true, nil
+}
+
+func (r *fakeWalletRepo) Credit(id string, amount float64) error {
+	wallet := r.byID(id)
+	if wallet == nil {
+		return errors.New("wallet not found")
+	}
+	wallet.Balance += amount
+	return nil
+}
+
+func (r *fakeWalletRepo) UpdateStatus(id string, status domain.WalletStatus, reason string) error {
+	wallet := r.byID(id)
+	if wallet == nil {
+		return errors.New("wallet not found")
+	}
+	wallet.Status = status
+	wallet.StatusReason = reason
+	return nil
+}
+
+type fakePayoutTransactionRepo struct {
+	domain.TransactionRepository
+	transactions map[string]domain.Transaction
+}
+
+func (r *fakePayoutTransactionRepo) Create(transaction *domain.Transaction) error {
+	r.transactions[transaction.ID] = *transaction
+	return nil
+}
+
+func (r *fakePayoutTransactionRepo) Update(transaction *domain.Transaction) error {
+	r.transactions[transaction.ID] = *transaction
+	return nil
+}
+
+type fakeBankAccounts struct {
+	domain.PaymentMethodRepository
+	methods []domain.PaymentMethod
+}
+
+func (r *fakeBankAccounts) GetByUserID(userID string) ([]domain.PaymentMethod, error) {
+	return r.methods, nil
+}
+
+type fakeBankService struct {
+	domain.BankService
+	transferErr error
+	transferred float64
+}
+
+func (b *fakeBankService) ProcessACHTransfer(accountInfo domain.BankAccountInfo, amount float64) (*domain.BankTransferResult, error) {
+	if b.transferErr != nil {
+		return nil, b.transferErr
+	}
+	b.transferred += amount
+	return &domain.BankTransferResult{TransferID: "transfer-1", Amount: amount}, nil
+}
+
+func TestProcessPayout(t *testing.T) {
+	bankDown := errors.New("bank unavailable")
+
+	tests := []struct {
+		name        string
+		role        auth.UserRole
+		amount      float64
+		status      domain.WalletStatus
+		noAccount   bool
+		stale       float64
+		transferErr error
+		wantErr     error
+		wantBalance float64
+		wantTx      domain.TransactionStatus // empty when no transaction is recorded
+	}{
+		{name: "merchant payout debits the wallet", role: auth.RoleMerchant, amount: 40, wantBalance: 60, wantTx: domain.TxStatusCompleted},
+		{name: "merchant payout of the whole balance", role: auth.RoleMerchant, amount: 100, wantBalance: 0, wantTx: domain.TxStatusCompleted},
+		{name: "merchant payout below the minimum", role: auth.RoleMerchant, amount: 5, wantErr: domain.ErrPayoutBelowMinimum, wantBalance: 100},
+		{name: "merchant payout over the balance", role: auth.RoleMerchant, amount: 150, wantErr: domain.ErrInsufficientBalance, wantBalance: 100},
+		{name: "balance spent by a concurrent payout", role: auth.RoleMerchant, amount: 120, stale: 50, wantErr: domain.ErrInsufficientBalance, wantBalance: 100, wantTx: domain.TxStatusFailed},
+		{name: "failed transfer returns the funds", role: auth.RoleMerchant, amount: 40, transferErr: bankDown, wantErr: bankDown, wantBalance: 100, wantTx: domain.TxStatusFailed},
+		{name: "frozen wallet", role: auth.RoleMerchant, amount: 40, status: domain.WalletStatusFrozen, wantErr: domain.ErrWalletInactive, wantBalance: 100},
+		{name: "no bank account", role: auth.RoleMerchant, amount: 40, noAccount: true, wantErr: domain.ErrNoPayoutAccount, wantBalance: 100},
+		{name: "driver payout debits the wallet", role: auth.RoleDriver, amount: 5, wantBalance: 95, wantTx: domain.TxStatusCompleted},
+		{name: "driver payout over the balance", role: auth.RoleDriver, amount: 101, wantErr: domain.ErrInsufficientBalance, wantBalance: 100},
+		{name: "driver balance spent by a concurrent payout", role: auth.RoleDriver, amount: 120, stale: 50, wantErr: domain.ErrInsufficientBalance, wantBalance: 100, wantTx: domain.TxStatusFailed},
+		{name: "failed driver transfer returns the funds", role: auth.RoleDriver, amount: 40, transferErr: bankDown, wantErr: bankDown, wantBalance: 100, wantTx: domain.TxStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			if status == "" {
+				status = domain.WalletStatusActive
+			}
+			wallets := &fakeWalletRepo{
+				wallets: map[string]*domain.Wallet{"user-1": {ID: "wallet-1", UserID: "user-1", Balance: 100, Status: status}},
+				stale:   tt.stale,
+			}
+			transactions := &fakePayoutTransactionRepo{transactions: make(map[string]domain.Transaction)}
+			accounts := &fakeBankAccounts{}
+			if !tt.noAccount {
+				accounts.methods = []domain.PaymentMethod{{ID: "bank-1", Type: domain.PaymentTypeBankAccount, Status: domain.PaymentStatusActive, BankInfo: &domain.BankAccountInfo{}}}
+			}
+			bank := &fakeBankService{transferErr: tt.transferErr}
+			svc := NewPaymentService(wallets, transactions, accounts, nil, nil, nil, nil, bank, events.NewInMemoryBus(), 10)
+
+			var err error
+			if tt.role == auth.RoleMerchant {
+				_, err = svc.ProcessMerchantPayout("user-1", tt.amount)
+			} else {
+				_, err = svc.ProcessDriverPayout("user-1", tt.amount)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if balance := wallets.wallets["user-1"].Balance; balance != tt.wantBalance {
+				t.Fatalf("balance = %v, want %v", balance, tt.wantBalance)
+			}
+			wantTransferred := 0.0
+			if tt.wantTx == domain.TxStatusCompleted {
+				wantTransferred = tt.amount
+			}
+			if bank.transferred != wantTransferred {
+				t.Fatalf("transferred %v, want %v", bank.transferred, wantTransferred)
+			}
+
+			if tt.wantTx == "" {
+				if len(transactions.transactions) != 0 {
+					t.Fatalf("rejected payout recorded transactions: %+v", transactions.transactions)
+				}
+				return
+			}
+			if len(transactions.transactions) != 1 {
+				t.Fatalf("recorded %d transactions, want 1", len(transactions.transactions))
+			}
+			for _, transaction := range transactions.transactions {
+				if transaction.Type != domain.TxTypePayout || transaction.Status != tt.wantTx || transaction.Amount != tt.amount {
+					t.Fatalf("unexpected transaction: %+v", transaction)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessWithdrawal(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      float64
+		stale       float64
+		wantErr     error
+		wantBalance float64
+		wantTx      domain.TransactionStatus // empty when no transaction is recorded
+	}{
+		{name: "withdrawal debits the wallet", amount: 30, wantBalance: 70, wantTx: domain.TxStatusCompleted},
+		{name: "withdrawal over the balance", amount: 130, wantErr: domain.ErrInsufficientBalance, wantBalance: 100},
+		{name: "balance spent by a concurrent withdrawal", amount: 130, stale: 50, wantErr: domain.ErrInsufficientBalance, wantBalance: 100, wantTx: domain.TxStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallets := &fakeWalletRepo{
+				wallets: map[string]*domain.Wallet{"user-1": {ID: "wallet-1", UserID: "user-1", Balance: 100, Status: domain.WalletStatusActive}},
+				stale:   tt.stale,
+			}
+			transactions := &fakePayoutTransactionRepo{transactions: make(map[string]domain.Transaction)}
+			svc := NewPaymentService(wallets, transactions, nil, nil, nil, nil, nil, nil, events.NewInMemoryBus(), 0)
+
+			_, err := svc.ProcessWithdrawal(domain.WithdrawalRequest{UserID: "user-1", Amount: tt.amount, PaymentMethodID: "bank-1"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if balance := wallets.wallets["user-1"].Balance; balance != tt.wantBalance {
+				t.Fatalf("balance = %v, want %v", balance, tt.wantBalance)
+			}
+			if tt.wantTx == "" {
+				if len(transactions.transactions) != 0 {
+					t.Fatalf("rejected withdrawal recorded transactions: %+v", transactions.transactions)
+				}
+				return
+			}
+			for _, transaction := range transactions.transactions {
+				if transaction.Type != domain.TxTypeWithdrawal || transaction.Status != tt.wantTx {
+					t.Fatalf("unexpected transaction: %+v", transaction)
+				}
+			}
+		})
+	}
+}
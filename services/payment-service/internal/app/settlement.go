@@ -0,0 +1,82 @@
+package app
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+)
+
+// GetDailySettlement totals the commissions of orders placed that day per
+// merchant, less the refunds made that day, whichever day their order was
+// placed. A refund costs the merchant the share of the order's net it
+// refunds of the payment, so a full refund takes back the whole net. Voided
+// commissions never paid out and are skipped.
+func (s *paymentService) GetDailySettlement(date time.Time) (*domain.SettlementReport, error) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+
+	commissions, err := s.commissionRepo.GetCreatedBetween(start, end)
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := s.transactionRepo.GetMerchantRefunds(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	merchants := make(map[string]*domain.MerchantSettlement)
+	settlement := func(merchantID string) *domain.MerchantSettlement {
+		line, ok := merchants[merchantID]
+		if !ok {
+			line = &domain.MerchantSettlement{MerchantID: merchantID}
+			merchants[merchantID] = line
+		}
+		return line
+	}
+
+	for _, commission := range commissions {
+		if commission.MerchantID == "" || commission.Status == domain.CommissionStatusVoided {
+			continue
+		}
+		line := settlement(commission.MerchantID)
+		line.Orders++
+		line.GrossSales += commission.OrderAmount
+		line.PlatformFees += commission.PlatformFee + commission.MerchantFee
+	}
+	for _, refund := range refunds {
+		if refund.PaymentAmount <= 0 {
+			continue
+		}
+		share := math.Min(refund.Amount/refund.PaymentAmount, 1)
+		settlement(refund.MerchantID).Refunds += share * refund.NetToMerchant
+	}
+
+	report := &domain.SettlementReport{
+		Date:      start.Format("2006-01-02"),
+		Merchants: make([]domain.MerchantSettlement, 0, len(merchants)),
+	}
+	for _, line := range merchants {
+		line.GrossSales = roundCents(line.GrossSales)
+		line.PlatformFees = roundCents(line.PlatformFees)
+		line.Refunds = roundCents(line.Refunds)
+		line.NetPayable = roundCents(line.GrossSales - line.PlatformFees - line.Refunds)
+		report.Merchants = append(report.Merchants, *line)
+
+		report.Totals.Orders += line.Orders
+		report.Totals.GrossSales += line.GrossSales
+		report.Totals.PlatformFees += line.PlatformFees
+		report.Totals.Refunds += line.Refunds
+		report.Totals.NetPayable += line.NetPayable
+	}
+	sort.Slice(report.Merchants, func(i, j int) bool {
+		return report.Merchants[i].MerchantID < report.Merchants[j].MerchantID
+	})
+
+	report.Totals.GrossSales = roundCents(report.Totals.GrossSales)
+	report.Totals.PlatformFees = roundCents(report.Totals.PlatformFees)
+	report.Totals.Refunds = roundCents(report.Totals.Refunds)
+	report.Totals.NetPayable = roundCents(report.Totals.NetPayable)
+	return report, nil
+}
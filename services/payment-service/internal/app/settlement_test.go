@@ -0,0 +1,83 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+)
+
+type fakeDailyCommissionRepo struct {
+	domain.CommissionRepository
+	commissions []domain.Commission
+	start, end  time.Time
+}
+
+func (r *fakeDailyCommissionRepo) GetCreatedBetween(start, end time.Time) ([]domain.Commission, error) {
+	r.start, r.end = start, end
+	return r.commissions, nil
+}
+
+type fakeMerchantRefundRepo struct {
+	domain.TransactionRepository
+	refunds    []domain.MerchantRefund
+	start, end time.Time
+}
+
+func (r *fakeMerchantRefundRepo) GetMerchantRefunds(start, end time.Time) ([]domain.MerchantRefund, error) {
+	r.start, r.end = start, end
+	return r.refunds, nil
+}
+
+func TestGetDailySettlement(t *testing.T) {
+	commissions := &fakeDailyCommissionRepo{commissions: []domain.Commission{
+		{ID: "commission-1", OrderID: "order-1", MerchantID: "merchant-a", OrderAmount: 100, PlatformFee: 3, MerchantFee: 10, Status: domain.CommissionStatusProcessed},
+		{ID: "commission-2", OrderID: "order-2", MerchantID: "merchant-a", OrderAmount: 50, PlatformFee: 1.5, MerchantFee: 5, NetToMerchant: 45, Status: domain.CommissionStatusReversed},
+		{ID: "commission-3", OrderID: "order-3", MerchantID: "merchant-b", OrderAmount: 80.1, PlatformFee: 2.4, MerchantFee: 8.01, Status: domain.CommissionStatusPending},
+		// Voided before anything was paid out
+		{ID: "commission-4", OrderID: "order-4", MerchantID: "merchant-b", OrderAmount: 60, PlatformFee: 1.8, MerchantFee: 6, Status: domain.CommissionStatusVoided},
+		// No merchant to settle with
+		{ID: "commission-5", OrderID: "order-5", OrderAmount: 20, PlatformFee: 0.6, Status: domain.CommissionStatusProcessed},
+	}}
+	refunds := &fakeMerchantRefundRepo{refunds: []domain.MerchantRefund{
+		// order-2 refunded in full the day it was placed
+		{MerchantID: "merchant-a", Amount: 57, PaymentAmount: 57, NetToMerchant: 45},
+		// A quarter of an earlier day's order refunded
+		{MerchantID: "merchant-b", Amount: 10, PaymentAmount: 40, NetToMerchant: 30},
+	}}
+	svc := &paymentService{commissionRepo: commissions, transactionRepo: refunds}
+
+	report, err := svc.GetDailySettlement(time.Date(2026, 10, 14, 15, 4, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetDailySettlement: %v", err)
+	}
+
+	dayStart := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	if !commissions.start.Equal(dayStart) || !commissions.end.Equal(dayStart.AddDate(0, 0, 1)) {
+		t.Fatalf("queried %v to %v, want the whole UTC day", commissions.start, commissions.end)
+	}
+	if !refunds.start.Equal(dayStart) || !refunds.end.Equal(dayStart.AddDate(0, 0, 1)) {
+		t.Fatalf("queried refunds from %v to %v, want the whole UTC day", refunds.start, refunds.end)
+	}
+	if report.Date != "2026-10-14" {
+		t.Fatalf("date = %s, want 2026-10-14", report.Date)
+	}
+
+	want := []domain.MerchantSettlement{
+		{MerchantID: "merchant-a", Orders: 2, GrossSales: 150, PlatformFees: 19.5, Refunds: 45, NetPayable: 85.5},
+		{MerchantID: "merchant-b", Orders: 1, GrossSales: 80.1, PlatformFees: 10.41, Refunds: 7.5, NetPayable: 62.19},
+	}
+	if len(report.Merchants) != len(want) {
+		t.Fatalf("merchants = %+v, want %+v", report.Merchants, want)
+	}
+	for i := range want {
+		if report.Merchants[i] != want[i] {
+			t.Fatalf("merchant %d = %+v, want %+v", i, report.Merchants[i], want[i])
+		}
+	}
+
+	wantTotals := domain.MerchantSettlement{Orders: 3, GrossSales: 230.1, PlatformFees: 29.91, Refunds: 52.5, NetPayable: 147.69}
+	if report.Totals != wantTotals {
+		t.Fatalf("totals = %+v, want %+v", report.Totals, wantTotals)
+	}
+}
@@ -0,0 +1,283 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// recordCheckoutTip keeps a tip given at checkout as a pending tip
+// transaction. No driver is assigned yet, so it is charged and credited when
+// the order is delivered.
+func (s *paymentService) recordCheckoutTip(req domain.ProcessPaymentRequest, payerWallet *domain.Wallet) {
+	if req.Tip <= 0 {
+		return
+	}
+
+	now := time.Now()
+	tip := &domain.Transaction{
+		ID:              uuid.New().String(),
+		FromWalletID:    &payerWallet.ID,
+		Type:            domain.TxTypeTip,
+		Status:          domain.TxStatusPending,
+		Amount:          roundCents(req.Tip),
+		Currency:        req.Currency,
+		Description:     fmt.Sprintf("Tip for order %s", req.OrderID),
+		OrderID:         &req.OrderID,
+		PaymentMethodID: &req.PaymentMethodID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	// The order is already paid for; a lost tip must not fail the checkout
+	if err := s.transactionRepo.Create(tip); err != nil {
+		log.Printf("Failed to record tip for order %s: %v", req.OrderID, err)
+	}
+}
+
+// settleOrderTips charges the order's pending checkout tips and credits them
+// to the driver, returning the order's total credited tips. Tips that can't
+// be charged are marked failed and left out. A frozen driver wallet fails the
+// whole delivery payment, so it is retried once the wallet is unfrozen.
+func (s *paymentService) settleOrderTips(orderID, driverID string) (float64, error) {
+	transactions, err := s.transactionRepo.GetByOrderID(orderID)
+	if err != nil {
+		return 0, err
+	}
+
+	var driverWallet *domain.Wallet
+	total := 0.0
+	for i := range transactions {
+		tip := &transactions[i]
+		if tip.Type != domain.TxTypeTip {
+			continue
+		}
+		if tip.Status == domain.TxStatusPending {
+			if driverWallet == nil {
+				if driverWallet, err = s.walletRepo.GetByUserID(driverID); err != nil {
+					return 0, fmt.Errorf("driver wallet not found: %w", err)
+				}
+				if err := requireActiveWallet(driverWallet); err != nil {
+					return 0, err
+				}
+			}
+			if err := s.settleTip(tip, driverWallet); err != nil {
+				log.Printf("Failed to settle tip %s for order %s: %v", tip.ID, orderID, err)
+				continue
+			}
+		}
+		// Includes tips settled by an earlier attempt at paying this delivery
+		if tip.Status == domain.TxStatusCompleted {
+			total += tip.Amount
+		}
+	}
+	return roundCents(total), nil
+}
+
+// settleTip charges a pending tip through its payment method and credits the
+// full amount to the driver; the platform absorbs any processing fee. Only
+// one settlement of a tip wins; a losing one gives its charge back.
+func (s *paymentService) settleTip(tip *domain.Transaction, driverWallet *domain.Wallet) error {
+	if err := s.chargeTip(tip); err != nil {
+		failed := *tip
+		failed.Status = domain.TxStatusFailed
+		failed.UpdatedAt = time.Now()
+		settled, saveErr := s.transactionRepo.SettlePending(&failed, nil)
+		switch {
+		case saveErr != nil:
+			log.Printf("Failed to mark tip %s failed: %v", tip.ID, saveErr)
+		case settled:
+			*tip = failed
+		default:
+			// Settled concurrently, which is likely what spent the balance
+			return s.reloadTip(tip)
+		}
+		return err
+	}
+
+	now := time.Now()
+	completed := *tip
+	completed.ToWalletID = &driverWallet.ID
+	completed.Status = domain.TxStatusCompleted
+	completed.NetAmount = tip.Amount
+	completed.ProcessedAt = &now
+	completed.UpdatedAt = now
+	settled, err := s.transactionRepo.SettlePending(&completed, &driverWallet.ID)
+	if err != nil {
+		s.refundTipCharge(tip)
+		return fmt.Errorf("failed to credit tip: %w", err)
+	}
+	if !settled {
+		// Settled concurrently; this charge is the extra one
+		s.refundTipCharge(tip)
+		return s.reloadTip(tip)
+	}
+	*tip = completed
+	return nil
+}
+
+// reloadTip replaces tip with its stored state after another settlement won
+func (s *paymentService) reloadTip(tip *domain.Transaction) error {
+	current, err := s.transactionRepo.GetByID(tip.ID)
+	if err != nil {
+		return fmt.Errorf("failed to reload tip: %w", err)
+	}
+	*tip = *current
+	return nil
+}
+
+// chargeTip takes the tip from the customer. Wallet tips are debited in a
+// single conditional update, so concurrent charges can't overdraw the wallet.
+func (s *paymentService) chargeTip(tip *domain.Transaction) error {
+	if tip.PaymentMethodID == nil || tip.FromWalletID == nil {
+		return domain.ErrInvalidPaymentMethod
+	}
+	method, err := s.paymentMethodRepo.GetByID(*tip.PaymentMethodID)
+	if err != nil {
+		return fmt.Errorf("payment method not found: %w", err)
+	}
+
+	switch method.Type {
+	case domain.PaymentTypeCard, domain.PaymentTypeBankAccount:
+		// In production, charge the card or account with the processor
+		return nil
+	case domain.PaymentTypeDigitalWallet:
+		wallet, err := s.walletRepo.GetByID(*tip.FromWalletID)
+		if err != nil {
+			return fmt.Errorf("payer wallet not found: %w", err)
+		}
+		if err := requireActiveWallet(wallet); err != nil {
+			return err
+		}
+		debited, err := s.walletRepo.Debit(wallet.ID, tip.Amount)
+		if err != nil {
+			return err
+		}
+		if !debited {
+			return s.debitRefused(wallet.ID)
+		}
+		return nil
+	default:
+		return domain.ErrUnsupportedPaymentMethod
+	}
+}
+
+// refundTipCharge gives back a charged tip that could not be credited
+func (s *paymentService) refundTipCharge(tip *domain.Transaction) {
+	method, err := s.paymentMethodRepo.GetByID(*tip.PaymentMethodID)
+	if err != nil {
+		log.Printf("Failed to refund tip %s: payment method not found: %v", tip.ID, err)
+		return
+	}
+	switch method.Type {
+	case domain.PaymentTypeDigitalWallet:
+		if err := s.walletRepo.Credit(*tip.FromWalletID, tip.Amount); err != nil {
+			log.Printf("Failed to refund tip %s to wallet %s: %v", tip.ID, *tip.FromWalletID, err)
+		}
+	default:
+		// In production, refund the charge with the processor
+	}
+}
+
+func (s *paymentService) TipDriver(customerID string, req domain.TipRequest) (*domain.Transaction, error) {
+	payerWallet, err := s.walletRepo.GetByUserID(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("payer wallet not found: %w", err)
+	}
+	if err := requireActiveWallet(payerWallet); err != nil {
+		return nil, err
+	}
+
+	method, err := s.paymentMethodRepo.GetByID(req.PaymentMethodID)
+	if err != nil {
+		return nil, fmt.Errorf("payment method not found: %w", err)
+	}
+	if method.UserID != customerID {
+		return nil, domain.ErrNotPaymentMethodOwner
+	}
+
+	paid, err := s.paidForOrder(req.OrderID, payerWallet.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !paid {
+		return nil, domain.ErrNotOrderCustomer
+	}
+
+	// The delivery's commission is processed once the driver has been paid
+	commission, _ := s.commissionRepo.GetByOrderID(req.OrderID)
+	if commission == nil || commission.DriverID == nil || commission.Status != domain.CommissionStatusProcessed || commission.ProcessedAt == nil {
+		return nil, domain.ErrOrderNotDelivered
+	}
+	if time.Since(*commission.ProcessedAt) > domain.TipWindow {
+		return nil, domain.ErrTipWindowClosed
+	}
+
+	driverWallet, err := s.walletRepo.GetByUserID(*commission.DriverID)
+	if err != nil {
+		return nil, fmt.Errorf("driver wallet not found: %w", err)
+	}
+	if err := requireActiveWallet(driverWallet); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tip := &domain.Transaction{
+		ID:              uuid.New().String(),
+		FromWalletID:    &payerWallet.ID,
+		Type:            domain.TxTypeTip,
+		Status:          domain.TxStatusPending,
+		Amount:          roundCents(req.Amount),
+		Currency:        payerWallet.Currency,
+		Description:     fmt.Sprintf("Tip for order %s", req.OrderID),
+		OrderID:         &req.OrderID,
+		PaymentMethodID: &req.PaymentMethodID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := s.chargeTip(tip); err != nil {
+		tip.Status = domain.TxStatusFailed
+		if createErr := s.transactionRepo.Create(tip); createErr != nil {
+			log.Printf("Failed to record failed tip for order %s: %v", req.OrderID, createErr)
+		}
+		return nil, err
+	}
+
+	// The tip is recorded and credited in one database transaction
+	tip.ToWalletID = &driverWallet.ID
+	tip.Status = domain.TxStatusCompleted
+	tip.NetAmount = tip.Amount
+	tip.ProcessedAt = &now
+	if err := s.transactionRepo.CreateWithBalanceChange(tip, driverWallet.ID, tip.Amount); err != nil {
+		s.refundTipCharge(tip)
+		return nil, fmt.Errorf("failed to credit tip: %w", err)
+	}
+
+	if err := s.commissionRepo.AddTip(commission.ID, tip.Amount); err != nil {
+		// The tip is credited; only the earnings record lags behind
+		log.Printf("Failed to add tip %s to commission %s: %v", tip.ID, commission.ID, err)
+	}
+
+	return tip, nil
+}
+
+// paidForOrder reports whether the wallet paid, or holds a payment, for the order
+func (s *paymentService) paidForOrder(orderID, walletID string) (bool, error) {
+	transactions, err := s.transactionRepo.GetByOrderID(orderID)
+	if err != nil {
+		return false, err
+	}
+	for _, transaction := range transactions {
+		if transaction.Type != domain.TxTypePayment || transaction.FromWalletID == nil || *transaction.FromWalletID != walletID {
+			continue
+		}
+		switch transaction.Status {
+		case domain.TxStatusCompleted, domain.TxStatusAuthorized:
+			return true, nil
+		}
+	}
+	return false, nil
+}
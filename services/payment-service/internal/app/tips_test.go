@@ -0,0 +1,261 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+)
+
+// fakeTipRepo settles pending tips with the same status check SettlePending
+// makes in its update
+type fakeTipRepo struct {
+	domain.TransactionRepository
+	wallets      *fakeWalletRepo
+	transactions []domain.Transaction
+	creditErr    error
+	// settledElsewhere completes pending tips just before they are settled,
+	// simulating a concurrent settlement winning
+	settledElsewhere bool
+}
+
+func (r *fakeTipRepo) find(id string) *domain.Transaction {
+	for i := range r.transactions {
+		if r.transactions[i].ID == id {
+			return &r.transactions[i]
+		}
+	}
+	return nil
+}
+
+func (r *fakeTipRepo) GetByOrderID(orderID string) ([]domain.Transaction, error) {
+	var transactions []domain.Transaction
+	for _, transaction := range r.transactions {
+		if transaction.OrderID != nil && *transaction.OrderID == orderID {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions, nil
+}
+
+func (r *fakeTipRepo) GetByID(id string) (*domain.Transaction, error) {
+	transaction := r.find(id)
+	if transaction == nil {
+		return nil, errors.New("transaction not found")
+	}
+	read := *transaction
+	return &read, nil
+}
+
+func (r *fakeTipRepo) Create(transaction *domain.Transaction) error {
+	r.transactions = append(r.transactions, *transaction)
+	return nil
+}
+
+func (r *fakeTipRepo) SettlePending(transaction *domain.Transaction, walletID *string) (bool, error) {
+	stored := r.find(transaction.ID)
+	if r.settledElsewhere && stored.Status == domain.TxStatusPending {
+		stored.Status = domain.TxStatusCompleted
+		if walletID != nil {
+			r.wallets.Credit(*walletID, stored.Amount)
+		}
+	}
+	if stored.Status != domain.TxStatusPending {
+		return false, nil
+	}
+	if walletID != nil {
+		if err := r.wallets.Credit(*walletID, transaction.NetAmount); err != nil {
+			return false, err
+		}
+	}
+	*stored = *transaction
+	return true, nil
+}
+
+func (r *fakeTipRepo) CreateWithBalanceChange(transaction *domain.Transaction, walletID string, delta float64) error {
+	if r.creditErr != nil {
+		return r.creditErr
+	}
+	if err := r.wallets.Credit(walletID, delta); err != nil {
+		return err
+	}
+	r.transactions = append(r.transactions, *transaction)
+	return nil
+}
+
+func (r *fakeCommissionRepo) AddTip(id string, tip float64) error {
+	for _, commission := range r.commissions {
+		if commission.ID == id {
+			commission.DriverEarnings.Tip += tip
+			commission.DriverEarnings.Total += tip
+			commission.NetToDriver += tip
+			return nil
+		}
+	}
+	return errors.New("commission not found")
+}
+
+func newTipWallets(driverStatus domain.WalletStatus) *fakeWalletRepo {
+	return &fakeWalletRepo{wallets: map[string]*domain.Wallet{
+		"customer-1": {ID: "wallet-customer", UserID: "customer-1", Balance: 10, Status: domain.WalletStatusActive},
+		"driver-1":   {ID: "wallet-driver", UserID: "driver-1", Status: driverStatus},
+	}}
+}
+
+var tipMethods = &fakePaymentMethodRepo{methods: map[string]domain.PaymentMethod{
+	"card":   {ID: "card", UserID: "customer-1", Type: domain.PaymentTypeCard},
+	"wallet": {ID: "wallet", UserID: "customer-1", Type: domain.PaymentTypeDigitalWallet},
+}}
+
+func TestSettleOrderTips(t *testing.T) {
+	orderID := "order-1"
+	customerWallet := "wallet-customer"
+
+	tests := []struct {
+		name             string
+		method           string
+		amount           float64
+		status           domain.TransactionStatus
+		driverStatus     domain.WalletStatus
+		settledElsewhere bool
+		wantErr          error
+		wantTotal        float64
+		wantCustomer     float64
+		wantDriver       float64
+		wantTip          domain.TransactionStatus
+	}{
+		{name: "card tip", method: "card", amount: 2, wantTotal: 2, wantCustomer: 10, wantDriver: 2, wantTip: domain.TxStatusCompleted},
+		{name: "wallet tip debits the customer", method: "wallet", amount: 3, wantTotal: 3, wantCustomer: 7, wantDriver: 3, wantTip: domain.TxStatusCompleted},
+		{name: "wallet tip over the balance", method: "wallet", amount: 12, wantTotal: 0, wantCustomer: 10, wantTip: domain.TxStatusFailed},
+		{name: "tip settled by an earlier attempt", method: "wallet", amount: 3, status: domain.TxStatusCompleted, wantTotal: 3, wantCustomer: 10, wantTip: domain.TxStatusCompleted},
+		{name: "frozen driver wallet", method: "wallet", amount: 3, driverStatus: domain.WalletStatusFrozen, wantErr: domain.ErrWalletInactive, wantCustomer: 10, wantTip: domain.TxStatusPending},
+		{name: "settled concurrently", method: "wallet", amount: 3, settledElsewhere: true, wantTotal: 3, wantCustomer: 10, wantDriver: 3, wantTip: domain.TxStatusCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driverStatus := tt.driverStatus
+			if driverStatus == "" {
+				driverStatus = domain.WalletStatusActive
+			}
+			status := tt.status
+			if status == "" {
+				status = domain.TxStatusPending
+			}
+			wallets := newTipWallets(driverStatus)
+			method := tt.method
+			transactions := &fakeTipRepo{wallets: wallets, settledElsewhere: tt.settledElsewhere, transactions: []domain.Transaction{{
+				ID: "tip-1", Type: domain.TxTypeTip, Status: status, Amount: tt.amount,
+				FromWalletID: &customerWallet, PaymentMethodID: &method, OrderID: &orderID,
+			}}}
+			svc := &paymentService{walletRepo: wallets, transactionRepo: transactions, paymentMethodRepo: tipMethods}
+
+			total, err := svc.settleOrderTips(orderID, "driver-1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if total != tt.wantTotal {
+				t.Fatalf("total = %v, want %v", total, tt.wantTotal)
+			}
+			// A settlement that loses the race gives its own charge back
+			if balance := wallets.wallets["customer-1"].Balance; balance != tt.wantCustomer {
+				t.Fatalf("customer balance = %v, want %v", balance, tt.wantCustomer)
+			}
+			if balance := wallets.wallets["driver-1"].Balance; balance != tt.wantDriver {
+				t.Fatalf("driver balance = %v, want %v", balance, tt.wantDriver)
+			}
+			if tip := transactions.find("tip-1"); tip.Status != tt.wantTip {
+				t.Fatalf("tip status = %s, want %s", tip.Status, tt.wantTip)
+			}
+		})
+	}
+}
+
+func TestTipDriver(t *testing.T) {
+	orderID := "order-1"
+	customerWallet := "wallet-customer"
+	driverID := "driver-1"
+	dbDown := errors.New("db down")
+
+	tests := []struct {
+		name         string
+		method       string
+		amount       float64
+		driverStatus domain.WalletStatus
+		creditErr    error
+		wantErr      error
+		wantCustomer float64
+		wantDriver   float64
+		wantTip      domain.TransactionStatus // empty when no tip is recorded
+	}{
+		{name: "wallet tip", method: "wallet", amount: 4, wantCustomer: 6, wantDriver: 4, wantTip: domain.TxStatusCompleted},
+		{name: "card tip", method: "card", amount: 4, wantCustomer: 10, wantDriver: 4, wantTip: domain.TxStatusCompleted},
+		{name: "over the balance", method: "wallet", amount: 11, wantErr: domain.ErrInsufficientBalance, wantCustomer: 10, wantTip: domain.TxStatusFailed},
+		{name: "frozen driver wallet", method: "wallet", amount: 4, driverStatus: domain.WalletStatusFrozen, wantErr: domain.ErrWalletInactive, wantCustomer: 10},
+		{name: "credit not saved gives the charge back", method: "wallet", amount: 4, creditErr: dbDown, wantErr: dbDown, wantCustomer: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driverStatus := tt.driverStatus
+			if driverStatus == "" {
+				driverStatus = domain.WalletStatusActive
+			}
+			wallets := newTipWallets(driverStatus)
+			transactions := &fakeTipRepo{wallets: wallets, creditErr: tt.creditErr, transactions: []domain.Transaction{{
+				ID: "payment-1", Type: domain.TxTypePayment, Status: domain.TxStatusCompleted, Amount: 20,
+				FromWalletID: &customerWallet, OrderID: &orderID,
+			}}}
+			processedAt := time.Now().Add(-time.Hour)
+			commissions := &fakeCommissionRepo{commissions: map[string]*domain.Commission{orderID: {
+				ID: "commission-1", OrderID: orderID, DriverID: &driverID, Status: domain.CommissionStatusProcessed, ProcessedAt: &processedAt,
+				DriverEarnings: domain.DriverEarnings{BaseFare: 2.5, Total: 2.5}, NetToDriver: 2.5,
+			}}}
+			svc := &paymentService{walletRepo: wallets, transactionRepo: transactions, paymentMethodRepo: tipMethods, commissionRepo: commissions}
+
+			_, err := svc.TipDriver("customer-1", domain.TipRequest{OrderID: orderID, Amount: tt.amount, PaymentMethodID: tt.method})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if balance := wallets.wallets["customer-1"].Balance; balance != tt.wantCustomer {
+				t.Fatalf("customer balance = %v, want %v", balance, tt.wantCustomer)
+			}
+			if balance := wallets.wallets["driver-1"].Balance; balance != tt.wantDriver {
+				t.Fatalf("driver balance = %v, want %v", balance, tt.wantDriver)
+			}
+
+			var tips []domain.Transaction
+			for _, transaction := range transactions.transactions {
+				if transaction.Type == domain.TxTypeTip {
+					tips = append(tips, transaction)
+				}
+			}
+			if tt.wantTip == "" {
+				if len(tips) != 0 {
+					t.Fatalf("recorded tips %+v, want none", tips)
+				}
+			} else if len(tips) != 1 || tips[0].Status != tt.wantTip {
+				t.Fatalf("recorded tips %+v, want one %s", tips, tt.wantTip)
+			}
+
+			wantEarnings := 2.5
+			if tt.wantTip == domain.TxStatusCompleted {
+				wantEarnings += tt.amount
+			}
+			if total := commissions.commissions[orderID].DriverEarnings.Total; total != wantEarnings {
+				t.Fatalf("driver earnings = %v, want %v", total, wantEarnings)
+			}
+		})
+	}
+}
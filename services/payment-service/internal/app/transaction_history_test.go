@@ -0,0 +1,90 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+	"glovo-backend/shared/pagination"
+)
+
+// GetByUserID pages the way pagination.Apply does: newest first by
+// (created_at, id), strictly after the cursor, one row past the limit
+func (r *fakeTransactionRepo) GetByUserID(userID string, cursor *pagination.Cursor, limit int) ([]domain.Transaction, error) {
+	var rows []domain.Transaction
+	for _, transaction := range r.transactions {
+		if cursor != nil && !before(transaction, cursor) {
+			continue
+		}
+		rows = append(rows, transaction)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return before(rows[j], &pagination.Cursor{CreatedAt: rows[i].CreatedAt, ID: rows[i].ID})
+	})
+	if len(rows) > limit+1 {
+		rows = rows[:limit+1]
+	}
+	return rows, nil
+}
+
+func before(transaction domain.Transaction, cursor *pagination.Cursor) bool {
+	if transaction.CreatedAt.Equal(cursor.CreatedAt) {
+		return transaction.ID < cursor.ID
+	}
+	return transaction.CreatedAt.Before(cursor.CreatedAt)
+}
+
+func TestGetTransactionHistoryPagesWithInserts(t *testing.T) {
+	svc, repo := newAuthorizationService()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		id := fmt.Sprintf("tx-%d", i)
+		// Pairs share a timestamp, so pages split ties on the ID
+		repo.transactions[id] = domain.Transaction{ID: id, CreatedAt: base.Add(time.Duration(i/2) * time.Minute)}
+	}
+
+	seen := make(map[string]bool)
+	var cursor string
+	for page := 0; ; page++ {
+		result, err := svc.GetTransactionHistory("user-1", cursor, 2)
+		if err != nil {
+			t.Fatalf("GetTransactionHistory: %v", err)
+		}
+		if len(result.Transactions) > 2 {
+			t.Fatalf("page %d has %d transactions, want at most 2", page, len(result.Transactions))
+		}
+		for _, transaction := range result.Transactions {
+			if seen[transaction.ID] {
+				t.Fatalf("page %d repeats %s", page, transaction.ID)
+			}
+			seen[transaction.ID] = true
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		cursor = result.NextCursor
+
+		// A new transaction lands between pages
+		id := fmt.Sprintf("new-%d", page)
+		repo.transactions[id] = domain.Transaction{ID: id, CreatedAt: base.Add(time.Hour)}
+	}
+
+	for i := 0; i < 7; i++ {
+		if id := fmt.Sprintf("tx-%d", i); !seen[id] {
+			t.Fatalf("paging skipped %s", id)
+		}
+	}
+	if len(seen) != 7 {
+		t.Fatalf("paging returned %d transactions, want 7", len(seen))
+	}
+}
+
+func TestGetTransactionHistoryRejectsBadCursor(t *testing.T) {
+	svc, _ := newAuthorizationService()
+	if _, err := svc.GetTransactionHistory("user-1", "garbage!", 10); !errors.Is(err, pagination.ErrInvalidCursor) {
+		t.Fatalf("error = %v, want %v", err, pagination.ErrInvalidCursor)
+	}
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+)
+
+type fakeSearchRepo struct {
+	domain.TransactionRepository
+	searched []domain.TransactionFilter
+}
+
+func (r *fakeSearchRepo) Search(filter domain.TransactionFilter) ([]domain.Transaction, int64, error) {
+	r.searched = append(r.searched, filter)
+	return []domain.Transaction{{ID: "tx-1"}}, 41, nil
+}
+
+func TestSearchTransactions(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	low, high := 10.0, 50.0
+
+	tests := []struct {
+		name       string
+		filter     domain.TransactionFilter
+		wantErr    error
+		wantLimit  int
+		wantOffset int
+	}{
+		{name: "defaults", wantLimit: 20},
+		{name: "within bounds", filter: domain.TransactionFilter{Limit: 50, Offset: 40}, wantLimit: 50, wantOffset: 40},
+		{name: "limit too large", filter: domain.TransactionFilter{Limit: 500}, wantLimit: 20},
+		{name: "negative offset", filter: domain.TransactionFilter{Offset: -5}, wantLimit: 20},
+		{name: "date range", filter: domain.TransactionFilter{StartDate: &start, EndDate: &end}, wantLimit: 20},
+		{name: "empty date range", filter: domain.TransactionFilter{StartDate: &start, EndDate: &start}, wantErr: domain.ErrInvalidTransactionFilter},
+		{name: "reversed date range", filter: domain.TransactionFilter{StartDate: &end, EndDate: &start}, wantErr: domain.ErrInvalidTransactionFilter},
+		{name: "amount range", filter: domain.TransactionFilter{MinAmount: &low, MaxAmount: &high}, wantLimit: 20},
+		{name: "single amount", filter: domain.TransactionFilter{MinAmount: &low, MaxAmount: &low}, wantLimit: 20},
+		{name: "reversed amount range", filter: domain.TransactionFilter{MinAmount: &high, MaxAmount: &low}, wantErr: domain.ErrInvalidTransactionFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSearchRepo{}
+			svc := &paymentService{transactionRepo: repo}
+
+			page, err := svc.SearchTransactions(tt.filter)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.searched) != 0 {
+					t.Fatalf("searched with an invalid filter")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SearchTransactions: %v", err)
+			}
+			if page.Limit != tt.wantLimit || page.Offset != tt.wantOffset {
+				t.Fatalf("page limit %d offset %d, want %d and %d", page.Limit, page.Offset, tt.wantLimit, tt.wantOffset)
+			}
+			if searched := repo.searched[0]; searched.Limit != tt.wantLimit || searched.Offset != tt.wantOffset {
+				t.Fatalf("searched with limit %d offset %d, want %d and %d", searched.Limit, searched.Offset, tt.wantLimit, tt.wantOffset)
+			}
+			if page.Total != 41 || len(page.Transactions) != 1 {
+				t.Fatalf("page = %+v, want the repository's rows and total", page)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func (s *paymentService) CreateVoucher(req domain.CreateVoucherRequest) (*domain.Voucher, error) {
+	if req.Type == domain.VoucherPercentage && req.Value > 100 {
+		return nil, fmt.Errorf("%w: percentage cannot exceed 100", domain.ErrInvalidVoucher)
+	}
+
+	code := normalizeVoucherCode(req.Code)
+	if existing, _ := s.voucherRepo.GetByCode(code); existing != nil {
+		return nil, domain.ErrVoucherExists
+	}
+
+	now := time.Now()
+	voucher := &domain.Voucher{
+		ID:             uuid.New().String(),
+		Code:           code,
+		Type:           req.Type,
+		Value:          req.Value,
+		MaxDiscount:    req.MaxDiscount,
+		MinOrderAmount: req.MinOrderAmount,
+		PerUserLimit:   req.PerUserLimit,
+		ExpiresAt:      req.ExpiresAt,
+		IsActive:       true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.voucherRepo.Create(voucher); err != nil {
+		return nil, fmt.Errorf("failed to create voucher: %w", err)
+	}
+	return voucher, nil
+}
+
+func (s *paymentService) ListVouchers(limit, offset int) ([]domain.Voucher, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.voucherRepo.List(limit, offset)
+}
+
+// redeemVoucher checks that the code can be used on an order of amount and
+// returns the voucher with the discount it gives, never more than the amount
+// itself. The per-customer limit is enforced when the use is claimed.
+func (s *paymentService) redeemVoucher(code string, amount float64, now time.Time) (*domain.Voucher, float64, error) {
+	voucher, err := s.voucherRepo.GetByCode(normalizeVoucherCode(code))
+	if err != nil || !voucher.IsActive {
+		return nil, 0, domain.ErrVoucherNotFound
+	}
+	if voucher.ExpiresAt != nil && !now.Before(*voucher.ExpiresAt) {
+		return nil, 0, domain.ErrVoucherExpired
+	}
+	if amount < voucher.MinOrderAmount {
+		return nil, 0, fmt.Errorf("%w of %.2f", domain.ErrVoucherBelowMinimum, voucher.MinOrderAmount)
+	}
+
+	discount := voucher.Value
+	if voucher.Type == domain.VoucherPercentage {
+		discount = amount * voucher.Value / 100
+		if voucher.MaxDiscount > 0 {
+			discount = math.Min(discount, voucher.MaxDiscount)
+		}
+	}
+	return voucher, roundCents(math.Min(discount, amount)), nil
+}
+
+// claimRedemption counts a voucher against the customer's limit before the
+// payment is charged, so a checkout fails rather than going through without
+// its redemption recorded
+func (s *paymentService) claimRedemption(voucher *domain.Voucher, transaction *domain.Transaction, customerID string) (*domain.VoucherRedemption, error) {
+	redemption := &domain.VoucherRedemption{
+		ID:            uuid.New().String(),
+		VoucherID:     voucher.ID,
+		UserID:        customerID,
+		OrderID:       *transaction.OrderID,
+		TransactionID: transaction.ID,
+		Discount:      transaction.Discount,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.voucherRepo.ClaimRedemption(redemption, voucher.PerUserLimit); err != nil {
+		if errors.Is(err, domain.ErrVoucherUsageLimit) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to redeem voucher: %w", err)
+	}
+	return redemption, nil
+}
+
+// releaseRedemption gives the customer back a use claimed for a payment that
+// failed
+func (s *paymentService) releaseRedemption(redemption *domain.VoucherRedemption) {
+	if err := s.voucherRepo.DeleteRedemption(redemption.ID); err != nil {
+		log.Printf("Failed to release redemption %s for order %s: %v", redemption.ID, redemption.OrderID, err)
+	}
+}
+
+func normalizeVoucherCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
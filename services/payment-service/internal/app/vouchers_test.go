@@ -0,0 +1,135 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/payment-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+// fakeVoucherRepo claims redemptions with the same checks ClaimRedemption
+// makes under the voucher's row lock
+type fakeVoucherRepo struct {
+	domain.VoucherRepository
+	voucher     domain.Voucher
+	redemptions []domain.VoucherRedemption
+	claimErr    error
+}
+
+func (r *fakeVoucherRepo) GetByCode(code string) (*domain.Voucher, error) {
+	if code != r.voucher.Code {
+		return nil, errors.New("voucher not found")
+	}
+	voucher := r.voucher
+	return &voucher, nil
+}
+
+func (r *fakeVoucherRepo) ClaimRedemption(redemption *domain.VoucherRedemption, limit int) error {
+	if r.claimErr != nil {
+		return r.claimErr
+	}
+	used := 0
+	for _, existing := range r.redemptions {
+		if existing.VoucherID != redemption.VoucherID || existing.UserID != redemption.UserID {
+			continue
+		}
+		if existing.OrderID == redemption.OrderID {
+			return domain.ErrVoucherUsageLimit
+		}
+		used++
+	}
+	if limit > 0 && used >= limit {
+		return domain.ErrVoucherUsageLimit
+	}
+	r.redemptions = append(r.redemptions, *redemption)
+	return nil
+}
+
+func (r *fakeVoucherRepo) DeleteRedemption(id string) error {
+	for i, redemption := range r.redemptions {
+		if redemption.ID == id {
+			r.redemptions = append(r.redemptions[:i], r.redemptions[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestProcessPaymentVoucherLimit(t *testing.T) {
+	tests := []struct {
+		name            string
+		limit           int
+		method          string
+		existing        []domain.VoucherRedemption
+		claimErr        error
+		wantErr         error
+		wantAnyErr      bool
+		wantRedemptions int
+		wantTx          domain.TransactionStatus
+	}{
+		{name: "first use", limit: 1, method: "card", wantRedemptions: 1, wantTx: domain.TxStatusCompleted},
+		{name: "limit reached", limit: 1, method: "card", existing: []domain.VoucherRedemption{{ID: "r1", VoucherID: "voucher-1", UserID: "customer-1", OrderID: "order-0"}}, wantErr: domain.ErrVoucherUsageLimit, wantRedemptions: 1, wantTx: domain.TxStatusFailed},
+		{name: "under the limit", limit: 2, method: "card", existing: []domain.VoucherRedemption{{ID: "r1", VoucherID: "voucher-1", UserID: "customer-1", OrderID: "order-0"}}, wantRedemptions: 2, wantTx: domain.TxStatusCompleted},
+		{name: "other customers' uses don't count", limit: 1, method: "card", existing: []domain.VoucherRedemption{{ID: "r1", VoucherID: "voucher-1", UserID: "customer-2", OrderID: "order-0"}}, wantRedemptions: 2, wantTx: domain.TxStatusCompleted},
+		{name: "unlimited voucher", method: "card", existing: []domain.VoucherRedemption{{ID: "r1", VoucherID: "voucher-1", UserID: "customer-1", OrderID: "order-0"}}, wantRedemptions: 2, wantTx: domain.TxStatusCompleted},
+		{name: "already redeemed on the order", method: "card", existing: []domain.VoucherRedemption{{ID: "r1", VoucherID: "voucher-1", UserID: "customer-1", OrderID: "order-1"}}, wantErr: domain.ErrVoucherUsageLimit, wantRedemptions: 1, wantTx: domain.TxStatusFailed},
+		{name: "redemption can't be recorded", limit: 1, method: "card", claimErr: errors.New("db down"), wantAnyErr: true, wantTx: domain.TxStatusFailed},
+		{name: "failed payment gives the use back", limit: 1, method: "wallet", wantErr: domain.ErrInsufficientBalance, wantTx: domain.TxStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vouchers := &fakeVoucherRepo{
+				voucher:     domain.Voucher{ID: "voucher-1", Code: "SAVE5", Type: domain.VoucherFixed, Value: 5, PerUserLimit: tt.limit, IsActive: true},
+				redemptions: append([]domain.VoucherRedemption(nil), tt.existing...),
+				claimErr:    tt.claimErr,
+			}
+			wallets := &fakeWalletRepo{wallets: map[string]*domain.Wallet{
+				"customer-1": {ID: "wallet-1", UserID: "customer-1", Balance: 1, Status: domain.WalletStatusActive},
+			}}
+			methods := &fakePaymentMethodRepo{methods: map[string]domain.PaymentMethod{
+				"card":   {ID: "card", Type: domain.PaymentTypeCard},
+				"wallet": {ID: "wallet", Type: domain.PaymentTypeDigitalWallet},
+			}}
+			transactions := &fakePayoutTransactionRepo{transactions: make(map[string]domain.Transaction)}
+			svc := NewPaymentService(wallets, transactions, methods, nil, nil, vouchers, nil, nil, events.NewInMemoryBus(), 0)
+
+			result, err := svc.ProcessPayment(domain.ProcessPaymentRequest{
+				OrderID:         "order-1",
+				CustomerID:      "customer-1",
+				Amount:          20,
+				PaymentMethodID: tt.method,
+				VoucherCode:     "save5",
+			})
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+			case tt.wantAnyErr:
+				if err == nil {
+					t.Fatalf("expected the checkout to fail")
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			default:
+				if result.Amount != 15 || result.Discount != 5 {
+					t.Fatalf("charged %v with discount %v, want 15 and 5", result.Amount, result.Discount)
+				}
+			}
+
+			if len(vouchers.redemptions) != tt.wantRedemptions {
+				t.Fatalf("%d redemptions recorded, want %d", len(vouchers.redemptions), tt.wantRedemptions)
+			}
+			if len(transactions.transactions) != 1 {
+				t.Fatalf("recorded %d transactions, want 1", len(transactions.transactions))
+			}
+			for _, transaction := range transactions.transactions {
+				if transaction.Status != tt.wantTx {
+					t.Fatalf("transaction status = %s, want %s", transaction.Status, tt.wantTx)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"glovo-backend/services/payment-service/internal/domain"
+)
+
+// requireActiveWallet rejects money movement on a frozen or suspended wallet
+func requireActiveWallet(wallet *domain.Wallet) error {
+	if wallet.Status == domain.WalletStatusActive {
+		return nil
+	}
+	if wallet.StatusReason != "" {
+		return fmt.Errorf("%w: wallet %s is %s: %s", domain.ErrWalletInactive, wallet.ID, wallet.Status, wallet.StatusReason)
+	}
+	return fmt.Errorf("%w: wallet %s is %s", domain.ErrWalletInactive, wallet.ID, wallet.Status)
+}
+
+// debitRefused explains why a conditional debit of the wallet changed
+// nothing: it was frozen since it was read, or its balance no longer covers
+// the amount
+func (s *paymentService) debitRefused(walletID string) error {
+	wallet, err := s.walletRepo.GetByID(walletID)
+	if err != nil {
+		return fmt.Errorf("wallet not found: %w", err)
+	}
+	if err := requireActiveWallet(wallet); err != nil {
+		return err
+	}
+	return domain.ErrInsufficientBalance
+}
+
+func (s *paymentService) FreezeWallet(userID, adminID string, req domain.FreezeWalletRequest) (*domain.Wallet, error) {
+	wallet, err := s.walletRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("wallet not found: %w", err)
+	}
+
+	if err := s.walletRepo.UpdateStatus(wallet.ID, domain.WalletStatusFrozen, req.Reason); err != nil {
+		return nil, fmt.Errorf("failed to freeze wallet: %w", err)
+	}
+
+	wallet.Status = domain.WalletStatusFrozen
+	wallet.StatusReason = req.Reason
+	wallet.UpdatedAt = time.Now()
+	log.Printf("Wallet %s of user %s frozen by admin %s: %s", wallet.ID, userID, adminID, req.Reason)
+	return wallet, nil
+}
+
+func (s *paymentService) UnfreezeWallet(userID, adminID string) (*domain.Wallet, error) {
+	wallet, err := s.walletRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("wallet not found: %w", err)
+	}
+	if wallet.Status == domain.WalletStatusActive {
+		return wallet, nil
+	}
+
+	if err := s.walletRepo.UpdateStatus(wallet.ID, domain.WalletStatusActive, ""); err != nil {
+		return nil, fmt.Errorf("failed to unfreeze wallet: %w", err)
+	}
+
+	wallet.Status = domain.WalletStatusActive
+	wallet.StatusReason = ""
+	wallet.UpdatedAt = time.Now()
+	log.Printf("Wallet %s of user %s unfrozen by admin %s", wallet.ID, userID, adminID)
+	return wallet, nil
+}
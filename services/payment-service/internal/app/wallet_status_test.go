@@ -0,0 +1,134 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"glovo-backend/services/payment-service/internal/domain"
+	"glovo-backend/shared/events"
+)
+
+func TestFreezeWallet(t *testing.T) {
+	// Reads see a stale balance, as if a top-up landed right after them;
+	// freezing must not write that balance back
+	wallets := &fakeWalletRepo{
+		wallets: map[string]*domain.Wallet{"user-1": {ID: "wallet-1", UserID: "user-1", Balance: 100, Status: domain.WalletStatusActive}},
+		stale:   -50,
+	}
+	transactions := &fakePayoutTransactionRepo{transactions: make(map[string]domain.Transaction)}
+	svc := NewPaymentService(wallets, transactions, nil, nil, nil, nil, nil, nil, events.NewInMemoryBus(), 0)
+	withdraw := func() error {
+		_, err := svc.ProcessWithdrawal(domain.WithdrawalRequest{UserID: "user-1", Amount: 30, PaymentMethodID: "bank-1"})
+		return err
+	}
+
+	frozen, err := svc.FreezeWallet("user-1", "admin-1", domain.FreezeWalletRequest{Reason: "chargeback review"})
+	if err != nil {
+		t.Fatalf("FreezeWallet: %v", err)
+	}
+	if frozen.Status != domain.WalletStatusFrozen || frozen.StatusReason != "chargeback review" {
+		t.Fatalf("unexpected frozen wallet: %+v", frozen)
+	}
+	if err := withdraw(); !errors.Is(err, domain.ErrWalletInactive) {
+		t.Fatalf("withdrawal from a frozen wallet: expected %v, got %v", domain.ErrWalletInactive, err)
+	}
+	if balance := wallets.wallets["user-1"].Balance; balance != 100 {
+		t.Fatalf("balance = %v after freezing, want 100", balance)
+	}
+
+	unfrozen, err := svc.UnfreezeWallet("user-1", "admin-1")
+	if err != nil {
+		t.Fatalf("UnfreezeWallet: %v", err)
+	}
+	if unfrozen.Status != domain.WalletStatusActive || unfrozen.StatusReason != "" {
+		t.Fatalf("unexpected unfrozen wallet: %+v", unfrozen)
+	}
+	if stored := wallets.wallets["user-1"]; stored.Status != domain.WalletStatusActive || stored.StatusReason != "" || stored.Balance != 100 {
+		t.Fatalf("unexpected stored wallet: %+v", stored)
+	}
+
+	wallets.stale = 0
+	if err := withdraw(); err != nil {
+		t.Fatalf("withdrawal after unfreezing: %v", err)
+	}
+	if balance := wallets.wallets["user-1"].Balance; balance != 70 {
+		t.Fatalf("balance = %v after withdrawing, want 70", balance)
+	}
+}
+
+func TestBalanceWritersRefuseWalletFrozenInFlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		holds bool // saves through the authorization repository
+		run   func(svc *paymentService) error
+	}{
+		{name: "wallet payment", run: func(svc *paymentService) error {
+			_, err := svc.ProcessPayment(domain.ProcessPaymentRequest{OrderID: "order-1", CustomerID: "user-1", Amount: 30, PaymentMethodID: "wallet"})
+			return err
+		}},
+		{name: "authorization", holds: true, run: func(svc *paymentService) error {
+			_, err := svc.AuthorizePayment(domain.ProcessPaymentRequest{OrderID: "order-1", CustomerID: "user-1", Amount: 30, PaymentMethodID: "wallet"})
+			return err
+		}},
+		{name: "transfer out", run: func(svc *paymentService) error {
+			_, err := svc.ProcessTransfer(domain.TransferRequest{FromUserID: "user-1", ToUserID: "user-2", Amount: 30})
+			return err
+		}},
+		{name: "transfer in", run: func(svc *paymentService) error {
+			_, err := svc.ProcessTransfer(domain.TransferRequest{FromUserID: "user-2", ToUserID: "user-1", Amount: 30})
+			return err
+		}},
+		{name: "top-up", run: func(svc *paymentService) error {
+			_, err := svc.ProcessTopUp(domain.TopUpRequest{UserID: "user-1", Amount: 30, PaymentMethodID: "card-1"})
+			return err
+		}},
+		{name: "withdrawal", run: func(svc *paymentService) error {
+			_, err := svc.ProcessWithdrawal(domain.WithdrawalRequest{UserID: "user-1", Amount: 30, PaymentMethodID: "bank-1"})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// user-1's wallet is frozen after the request reads it as active
+			wallets := &fakeWalletRepo{
+				wallets: map[string]*domain.Wallet{
+					"user-1": {ID: "wallet-1", UserID: "user-1", Balance: 100, Status: domain.WalletStatusFrozen, StatusReason: "chargeback review"},
+					"user-2": {ID: "wallet-2", UserID: "user-2", Balance: 100, Status: domain.WalletStatusActive},
+				},
+				staleStatus: domain.WalletStatusActive,
+			}
+			ledger := newLedgerRepo(wallets)
+			holds := &fakeTransactionRepo{
+				transactions: make(map[string]domain.Transaction),
+				wallets:      map[string]*domain.Wallet{"wallet-1": wallets.wallets["user-1"]},
+			}
+			methods := &fakePaymentMethodRepo{methods: map[string]domain.PaymentMethod{
+				"wallet": {ID: "wallet", Type: domain.PaymentTypeDigitalWallet},
+			}}
+			svc := &paymentService{walletRepo: wallets, transactionRepo: ledger, paymentMethodRepo: methods, eventBus: events.NewInMemoryBus()}
+			if tt.holds {
+				svc.transactionRepo = holds
+			}
+
+			err := tt.run(svc)
+			if !errors.Is(err, domain.ErrWalletInactive) {
+				t.Fatalf("expected %v, got %v", domain.ErrWalletInactive, err)
+			}
+
+			for userID, want := range map[string]float64{"user-1": 100, "user-2": 100} {
+				if balance := wallets.wallets[userID].Balance; balance != want {
+					t.Fatalf("%s balance = %v, want %v", userID, balance, want)
+				}
+			}
+			if stored := wallets.wallets["user-1"]; stored.Status != domain.WalletStatusFrozen || stored.PendingBalance != 0 {
+				t.Fatalf("frozen wallet changed: %+v", stored)
+			}
+			for _, transaction := range ledger.transactions {
+				if transaction.Status == domain.TxStatusCompleted {
+					t.Fatalf("transaction completed on a frozen wallet: %+v", transaction)
+				}
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"glovo-backend/shared/auth"
+	"glovo-backend/shared/pagination"
 )
 
 // Wallet represents user wallet/account
@@ -15,6 +17,7 @@ type Wallet struct {
 	PendingBalance float64       `json:"pending_balance"`
 	Currency       string        `json:"currency"`
 	Status         WalletStatus  `json:"status"`
+	StatusReason   string        `json:"status_reason,omitempty"` // why the wallet was frozen or suspended
 	CreatedAt      time.Time     `json:"created_at"`
 	UpdatedAt      time.Time     `json:"updated_at"`
 }
@@ -43,6 +46,9 @@ type Transaction struct {
 	OrderID         *string           `json:"order_id,omitempty"`
 	PaymentMethodID *string           `json:"payment_method_id,omitempty"`
 	Metadata        map[string]string `json:"metadata" gorm:"serializer:json"`
+	VoucherCode     string            `json:"voucher_code,omitempty"`
+	Discount        float64           `json:"discount,omitempty"`                // taken off the order amount by the voucher
+	ExpiresAt       *time.Time        `json:"expires_at,omitempty" gorm:"index"` // when an authorization auto-voids
 	ProcessedAt     *time.Time        `json:"processed_at,omitempty"`
 	CreatedAt       time.Time         `json:"created_at"`
 	UpdatedAt       time.Time         `json:"updated_at"`
@@ -51,15 +57,20 @@ type Transaction struct {
 type TransactionType string
 
 const (
-	TxTypePayment    TransactionType = "payment"
-	TxTypeRefund     TransactionType = "refund"
-	TxTypeTransfer   TransactionType = "transfer"
-	TxTypeTopUp      TransactionType = "top_up"
-	TxTypeWithdrawal TransactionType = "withdrawal"
-	TxTypeCommission TransactionType = "commission"
-	TxTypePayout     TransactionType = "payout"
-	TxTypeBonus      TransactionType = "bonus"
-	TxTypePenalty    TransactionType = "penalty"
+	TxTypePayment         TransactionType = "payment"
+	TxTypeRefund          TransactionType = "refund"
+	TxTypeTransfer        TransactionType = "transfer"
+	TxTypeTopUp           TransactionType = "top_up"
+	TxTypeWithdrawal      TransactionType = "withdrawal"
+	TxTypeCommission      TransactionType = "commission"
+	TxTypePayout          TransactionType = "payout"  // wallet to the user's bank account
+	TxTypeEarning         TransactionType = "earning" // delivery fare credited to the driver's wallet
+	TxTypeBonus           TransactionType = "bonus"
+	TxTypePenalty         TransactionType = "penalty"
+	TxTypeTip             TransactionType = "tip"              // customer to driver, pending until the order is delivered
+	TxTypeClawback        TransactionType = "clawback"         // takes back a reversed commission's payout
+	TxTypeCancellationFee TransactionType = "cancellation_fee" // charged to a customer cancelling a started delivery
+	TxTypeCompensation    TransactionType = "compensation"     // paid to the driver of a cancelled delivery
 )
 
 type TransactionStatus string
@@ -70,8 +81,51 @@ const (
 	TxStatusFailed    TransactionStatus = "failed"
 	TxStatusCancelled TransactionStatus = "cancelled"
 	TxStatusRefunded  TransactionStatus = "refunded"
+	// Authorized payments hold funds until they are captured or voided
+	TxStatusAuthorized TransactionStatus = "authorized"
+	TxStatusVoided     TransactionStatus = "voided"
 )
 
+// AuthorizationTTL is how long an uncaptured hold lasts before it auto-voids
+const AuthorizationTTL = 7 * 24 * time.Hour
+
+// TipWindow is how long after delivery a customer can still tip the driver
+const TipWindow = 24 * time.Hour
+
+// Voucher is a promo code customers redeem when paying for an order
+type Voucher struct {
+	ID             string      `json:"id" gorm:"primaryKey"`
+	Code           string      `json:"code" gorm:"uniqueIndex"` // stored upper-case
+	Type           VoucherType `json:"type"`
+	Value          float64     `json:"value"`        // percent off for percentage vouchers, amount off for fixed ones
+	MaxDiscount    float64     `json:"max_discount"` // caps a percentage discount; 0 for no cap
+	MinOrderAmount float64     `json:"min_order_amount"`
+	PerUserLimit   int         `json:"per_user_limit"` // redemptions per customer; 0 for unlimited
+	ExpiresAt      *time.Time  `json:"expires_at,omitempty"`
+	IsActive       bool        `json:"is_active"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+type VoucherType string
+
+const (
+	VoucherPercentage VoucherType = "percentage"
+	VoucherFixed      VoucherType = "fixed"
+)
+
+// VoucherRedemption records a voucher used on a payment. A voucher is
+// redeemed at most once per order.
+type VoucherRedemption struct {
+	ID            string    `json:"id" gorm:"primaryKey"`
+	VoucherID     string    `json:"voucher_id" gorm:"index:idx_voucher_user;uniqueIndex:idx_voucher_user_order"`
+	UserID        string    `json:"user_id" gorm:"index:idx_voucher_user;uniqueIndex:idx_voucher_user_order"`
+	OrderID       string    `json:"order_id" gorm:"uniqueIndex:idx_voucher_user_order"`
+	TransactionID string    `json:"transaction_id"`
+	Discount      float64   `json:"discount"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
 // PaymentMethod represents user payment methods
 type PaymentMethod struct {
 	ID            string              `json:"id" gorm:"primaryKey"`
@@ -129,20 +183,77 @@ type DigitalWalletInfo struct {
 
 // Commission represents commission calculations
 type Commission struct {
-	ID            string           `json:"id" gorm:"primaryKey"`
-	OrderID       string           `json:"order_id" gorm:"index"`
-	MerchantID    string           `json:"merchant_id"`
-	DriverID      *string          `json:"driver_id,omitempty"`
-	OrderAmount   float64          `json:"order_amount"`
-	PlatformFee   float64          `json:"platform_fee"`
-	DeliveryFee   float64          `json:"delivery_fee"`
-	DriverFee     float64          `json:"driver_fee"`
-	MerchantFee   float64          `json:"merchant_fee"`
-	NetToMerchant float64          `json:"net_to_merchant"`
-	NetToDriver   float64          `json:"net_to_driver"`
-	Status        CommissionStatus `json:"status"`
-	ProcessedAt   *time.Time       `json:"processed_at,omitempty"`
-	CreatedAt     time.Time        `json:"created_at"`
+	ID             string           `json:"id" gorm:"primaryKey"`
+	OrderID        string           `json:"order_id" gorm:"uniqueIndex"` // one commission per order
+	MerchantID     string           `json:"merchant_id"`
+	DriverID       *string          `json:"driver_id,omitempty"`
+	OrderAmount    float64          `json:"order_amount"`
+	PlatformFee    float64          `json:"platform_fee"`
+	DeliveryFee    float64          `json:"delivery_fee"`
+	DriverFee      float64          `json:"driver_fee"` // platform's share of the delivery fee, negative when it tops up the driver
+	MerchantFee    float64          `json:"merchant_fee"`
+	NetToMerchant  float64          `json:"net_to_merchant"`
+	NetToDriver    float64          `json:"net_to_driver"` // DriverEarnings.Total
+	DriverEarnings DriverEarnings   `json:"driver_earnings" gorm:"embedded;embeddedPrefix:driver_"`
+	Status         CommissionStatus `json:"status"`
+	ProcessedAt    *time.Time       `json:"processed_at,omitempty"`
+	ReversedAt     *time.Time       `json:"reversed_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+// DriverEarnings splits what a driver earns for one delivery; Total is the
+// sum of the components
+type DriverEarnings struct {
+	BaseFare      float64 `json:"base_fare"`
+	DistanceBonus float64 `json:"distance_bonus"` // per km beyond the distance the base fare covers
+	Surge         float64 `json:"surge"`          // base fare and distance bonus times the surge multiplier above 1
+	Tip           float64 `json:"tip"`            // paid to the driver in full
+	Total         float64 `json:"total"`
+}
+
+// Add accumulates other into e
+func (e *DriverEarnings) Add(other DriverEarnings) {
+	e.BaseFare += other.BaseFare
+	e.DistanceBonus += other.DistanceBonus
+	e.Surge += other.Surge
+	e.Tip += other.Tip
+	e.Total += other.Total
+}
+
+// DriverEarningsReport totals a driver's delivery earnings over a period
+type DriverEarningsReport struct {
+	DriverID   string         `json:"driver_id"`
+	StartDate  time.Time      `json:"start_date"`
+	EndDate    time.Time      `json:"end_date"` // exclusive
+	Deliveries int            `json:"deliveries"`
+	Earnings   DriverEarnings `json:"earnings"`
+	Items      []Commission   `json:"items"`
+}
+
+// MerchantSettlement is one merchant's line of a settlement report
+type MerchantSettlement struct {
+	MerchantID   string  `json:"merchant_id,omitempty"`
+	Orders       int     `json:"orders"`
+	GrossSales   float64 `json:"gross_sales"`
+	PlatformFees float64 `json:"platform_fees"` // platform and merchant fees
+	Refunds      float64 `json:"refunds"`       // merchant's share of the day's refunds
+	NetPayable   float64 `json:"net_payable"`   // gross sales less fees and refunds
+}
+
+// MerchantRefund is a refund of an order payment, with the merchant's net
+// from the order's commission
+type MerchantRefund struct {
+	MerchantID    string
+	Amount        float64 // refunded
+	PaymentAmount float64 // of the refunded payment
+	NetToMerchant float64
+}
+
+// SettlementReport is what each merchant is owed for one UTC day
+type SettlementReport struct {
+	Date      string               `json:"date"` // YYYY-MM-DD
+	Merchants []MerchantSettlement `json:"merchants"`
+	Totals    MerchantSettlement   `json:"totals"`
 }
 
 type CommissionStatus string
@@ -151,25 +262,132 @@ const (
 	CommissionStatusPending   CommissionStatus = "pending"
 	CommissionStatusProcessed CommissionStatus = "processed"
 	CommissionStatusFailed    CommissionStatus = "failed"
+	CommissionStatusVoided    CommissionStatus = "voided"   // cancelled before anything was paid out
+	CommissionStatusReversed  CommissionStatus = "reversed" // paid out, then clawed back
 )
 
+// Dispute is a customer's challenge of a charge, resolved by an admin
+type Dispute struct {
+	ID                  string        `json:"id" gorm:"primaryKey"`
+	TransactionID       string        `json:"transaction_id" gorm:"index"`
+	CustomerID          string        `json:"customer_id" gorm:"index"`
+	Amount              float64       `json:"amount"`
+	Reason              string        `json:"reason"`
+	Status              DisputeStatus `json:"status" gorm:"index"`
+	ResolutionNotes     string        `json:"resolution_notes,omitempty"`
+	ResolvedBy          *string       `json:"resolved_by,omitempty"`
+	RefundTransactionID *string       `json:"refund_transaction_id,omitempty"` // set when a won dispute was refunded
+	ResolvedAt          *time.Time    `json:"resolved_at,omitempty"`
+	CreatedAt           time.Time     `json:"created_at"`
+	UpdatedAt           time.Time     `json:"updated_at"`
+}
+
+type DisputeStatus string
+
+const (
+	DisputeStatusOpened      DisputeStatus = "opened"
+	DisputeStatusUnderReview DisputeStatus = "under_review"
+	DisputeStatusWon         DisputeStatus = "won"  // in the customer's favour
+	DisputeStatusLost        DisputeStatus = "lost" // the charge stands
+)
+
+// IsOpen reports whether the dispute still awaits a resolution
+func (d *Dispute) IsOpen() bool {
+	return d.Status == DisputeStatusOpened || d.Status == DisputeStatusUnderReview
+}
+
 // Request/Response DTOs
+// DeliveryEarningsRequest describes a completed delivery to pay its driver
+// for. Tips come from the order's tip transactions.
+type DeliveryEarningsRequest struct {
+	OrderID         string
+	MerchantID      string
+	DriverID        string
+	DeliveryFee     float64
+	Distance        float64
+	SurgeMultiplier float64
+}
+
+// CancellationSettlementRequest describes a cancelled delivery's fee and
+// driver compensation, as set by the delivery cancellation policy
+type CancellationSettlementRequest struct {
+	OrderID            string
+	CustomerID         string
+	DriverID           string
+	CancellationFee    float64
+	DriverCompensation float64
+}
+
+// CalculateCommissionRequest describes an order's parties and amounts. An
+// order without a driver (pickup or platform-internal) leaves DriverID unset
+// and has no driver earnings; one without a merchant has no merchant side.
+type CalculateCommissionRequest struct {
+	OrderID         string  `json:"order_id" binding:"required"`
+	OrderAmount     float64 `json:"order_amount" binding:"required"`
+	DeliveryFee     float64 `json:"delivery_fee" binding:"min=0"`
+	Distance        float64 `json:"distance" binding:"min=0"`                   // in kilometers
+	SurgeMultiplier float64 `json:"surge_multiplier" binding:"omitempty,min=1"` // 1 when empty
+	Tip             float64 `json:"tip" binding:"min=0"`
+	MerchantID      string  `json:"merchant_id,omitempty"`
+	DriverID        *string `json:"driver_id,omitempty"`
+}
+
 type ProcessPaymentRequest struct {
 	OrderID         string            `json:"order_id" binding:"required"`
 	CustomerID      string            `json:"customer_id" binding:"required"`
 	Amount          float64           `json:"amount" binding:"required,min=0"`
 	Currency        string            `json:"currency"`
 	PaymentMethodID string            `json:"payment_method_id" binding:"required"`
+	Tip             float64           `json:"tip,omitempty" binding:"min=0"` // charged on delivery and paid to the driver in full
+	VoucherCode     string            `json:"voucher_code,omitempty"`        // promo code taken off the amount
 	Description     string            `json:"description"`
 	Metadata        map[string]string `json:"metadata,omitempty"`
 }
 
+type CreateVoucherRequest struct {
+	Code           string      `json:"code" binding:"required,max=32"`
+	Type           VoucherType `json:"type" binding:"required,oneof=percentage fixed"`
+	Value          float64     `json:"value" binding:"required,gt=0"`
+	MaxDiscount    float64     `json:"max_discount" binding:"min=0"`
+	MinOrderAmount float64     `json:"min_order_amount" binding:"min=0"`
+	PerUserLimit   int         `json:"per_user_limit" binding:"min=0"`
+	ExpiresAt      *time.Time  `json:"expires_at,omitempty"`
+}
+
+// TipRequest tips the driver of a delivered order, within TipWindow of delivery
+type TipRequest struct {
+	OrderID         string  `json:"order_id" binding:"required"`
+	Amount          float64 `json:"amount" binding:"required,gt=0"`
+	PaymentMethodID string  `json:"payment_method_id" binding:"required"`
+}
+
+// CaptureRequest captures an authorization; a zero Amount captures it in full
+type CaptureRequest struct {
+	Amount float64 `json:"amount,omitempty" binding:"min=0"`
+}
+
 type RefundRequest struct {
 	TransactionID string  `json:"transaction_id" binding:"required"`
 	Amount        float64 `json:"amount" binding:"required,min=0"`
 	Reason        string  `json:"reason" binding:"required"`
 }
 
+// OpenDisputeRequest disputes a charge; a zero Amount disputes it in full
+type OpenDisputeRequest struct {
+	TransactionID string  `json:"transaction_id" binding:"required"`
+	Amount        float64 `json:"amount,omitempty" binding:"min=0"`
+	Reason        string  `json:"reason" binding:"required"`
+}
+
+type FreezeWalletRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type ResolveDisputeRequest struct {
+	Status DisputeStatus `json:"status" binding:"required,oneof=won lost"`
+	Notes  string        `json:"notes"`
+}
+
 type TransferRequest struct {
 	FromUserID  string            `json:"from_user_id" binding:"required"`
 	ToUserID    string            `json:"to_user_id" binding:"required"`
@@ -205,7 +423,9 @@ type PaymentResponse struct {
 	Amount        float64           `json:"amount"`
 	Fee           float64           `json:"fee"`
 	NetAmount     float64           `json:"net_amount"`
+	Discount      float64           `json:"discount,omitempty"`
 	Reference     string            `json:"reference,omitempty"`
+	ExpiresAt     *time.Time        `json:"expires_at,omitempty"`
 	ProcessedAt   *time.Time        `json:"processed_at,omitempty"`
 }
 
@@ -223,25 +443,148 @@ type TransactionReport struct {
 	NetAmount        float64 `json:"net_amount"`
 }
 
+// TransactionPage is one page of a transaction history, newest first
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
+
+// TransactionFilter selects transactions for the admin search; filters
+// combine with AND
+type TransactionFilter struct {
+	Status    TransactionStatus `json:"status,omitempty"`
+	Type      TransactionType   `json:"type,omitempty"`
+	UserID    string            `json:"user_id,omitempty"` // either side of the transaction
+	StartDate *time.Time        `json:"start_date,omitempty"`
+	EndDate   *time.Time        `json:"end_date,omitempty"` // exclusive
+	MinAmount *float64          `json:"min_amount,omitempty"`
+	MaxAmount *float64          `json:"max_amount,omitempty"`
+	Limit     int               `json:"limit"`
+	Offset    int               `json:"offset"`
+}
+
+type TransactionSearchPage struct {
+	Transactions []Transaction `json:"transactions"`
+	Total        int64         `json:"total"`
+	Limit        int           `json:"limit"`
+	Offset       int           `json:"offset"`
+}
+
+// LedgerTotals sums the completed and held transactions that move a wallet's
+// funds. Card and bank payments are charged outside the wallet and don't count.
+type LedgerTotals struct {
+	Credits float64 `json:"credits"`
+	Debits  float64 `json:"debits"`
+	Held    float64 `json:"held"` // open wallet authorizations
+}
+
+// WalletReconciliation compares a wallet's stored balances with the ones
+// implied by its transactions
+type WalletReconciliation struct {
+	WalletID               string  `json:"wallet_id"`
+	UserID                 string  `json:"user_id"`
+	Currency               string  `json:"currency"`
+	Balance                float64 `json:"balance"`
+	ExpectedBalance        float64 `json:"expected_balance"`
+	PendingBalance         float64 `json:"pending_balance"`
+	ExpectedPendingBalance float64 `json:"expected_pending_balance"`
+	Difference             float64 `json:"difference"` // balance minus expected balance
+	Discrepancy            bool    `json:"discrepancy"`
+}
+
+// ReconciliationReport is one page of wallets, newest first
+type ReconciliationReport struct {
+	Wallets       []WalletReconciliation `json:"wallets"`
+	Discrepancies int                    `json:"discrepancies"` // on this page
+	NextCursor    string                 `json:"next_cursor,omitempty"`
+}
+
 // Repository interfaces (ports)
 type WalletRepository interface {
 	Create(wallet *Wallet) error
 	GetByID(id string) (*Wallet, error)
 	GetByUserID(userID string) (*Wallet, error)
-	Update(wallet *Wallet) error
+	// Debit takes amount from the wallet's balance in a single update, only
+	// if the wallet is active and the balance covers it, and reports whether
+	// it did
+	Debit(id string, amount float64) (bool, error)
+	// Credit adds amount to the wallet's balance in a single update
+	Credit(id string, amount float64) error
+	// UpdateStatus sets only the wallet's status and status reason, leaving
+	// the balances to the updates above
+	UpdateStatus(id string, status WalletStatus, reason string) error
 	Delete(id string) error
 	List(limit, offset int) ([]Wallet, error)
+	ListPage(cursor *pagination.Cursor, limit int) ([]Wallet, error)
 }
 
 type TransactionRepository interface {
 	Create(transaction *Transaction) error
 	GetByID(id string) (*Transaction, error)
 	GetByWalletID(walletID string, limit, offset int) ([]Transaction, error)
-	GetByUserID(userID string, limit, offset int) ([]Transaction, error)
+	GetByUserID(userID string, cursor *pagination.Cursor, limit int) ([]Transaction, error)
 	GetByOrderID(orderID string) ([]Transaction, error)
+	GetExpiredAuthorizations(now time.Time) ([]Transaction, error)
+	GetLedgerTotals(walletID string) (*LedgerTotals, error)
 	Update(transaction *Transaction) error
+	// CreateAuthorization creates transaction, an authorized hold. For a
+	// wallet hold it also moves the amount from the wallet's balance to its
+	// pending balance, in the same database transaction and only if the
+	// wallet is active and the balance covers it. It reports false, creating
+	// nothing, when it isn't or doesn't.
+	CreateAuthorization(transaction *Transaction, walletID *string) (bool, error)
+	// SettleAuthorization saves transaction, a captured or voided hold, only
+	// if it is still authorized. For a wallet hold it also releases held from
+	// the wallet's pending balance and returns refund to its balance, in the
+	// same database transaction. It reports false, changing nothing, when
+	// the hold was already settled.
+	SettleAuthorization(transaction *Transaction, walletID *string, held, refund float64) (bool, error)
+	// SettlePending saves transaction, a completed or failed pending one, only
+	// if it is still pending. With a walletID it also credits the
+	// transaction's NetAmount to that wallet in the same database
+	// transaction, returning ErrWalletInactive, changing nothing, if the
+	// wallet isn't active. It reports false, changing nothing, when the
+	// transaction was already settled.
+	SettlePending(transaction *Transaction, walletID *string) (bool, error)
+	// SettlePendingDebit saves transaction, a completed pending one, only if
+	// it is still pending, and takes its Amount from walletID's balance in
+	// the same database transaction. It returns ErrWalletInactive or
+	// ErrInsufficientBalance, changing nothing, when the wallet isn't active
+	// or its balance doesn't cover the amount, and reports false, changing
+	// nothing, when the transaction was already settled.
+	SettlePendingDebit(transaction *Transaction, walletID string) (bool, error)
+	// CreateTransfer creates transaction, a completed transfer, taking its
+	// Amount from the FromWalletID wallet and crediting its NetAmount to the
+	// ToWalletID one in the same database transaction. It returns
+	// ErrWalletInactive, creating nothing, when either wallet isn't active,
+	// and ErrInsufficientBalance when the sender's balance doesn't cover the
+	// amount.
+	CreateTransfer(transaction *Transaction) error
+	// CreateWithBalanceChange creates transaction and adds delta to the
+	// wallet's balance in the same database transaction
+	CreateWithBalanceChange(transaction *Transaction, walletID string, delta float64) error
+	// CreateOrderSettlement creates transaction, a charge or payment for its
+	// OrderID, and adds delta to the wallet's balance in the same database
+	// transaction, even into a negative balance. It reports false, changing
+	// nothing, when the order already has a transaction of the same type, and
+	// returns ErrWalletInactive when the wallet isn't active.
+	CreateOrderSettlement(transaction *Transaction, walletID string, delta float64) (bool, error)
+	// CreateRefund creates refund, a completed refund of the transaction its
+	// Reference names, and credits the amount to walletID if one is given.
+	// It returns ErrNotRefundable unless that transaction is a completed
+	// payment.
+	// Earlier refunds are summed under the original transaction's row lock,
+	// so concurrent refunds can't together pass limit; it returns
+	// ErrRefundExceedsAmount, creating nothing, when this one would. The
+	// returned total includes refund.
+	CreateRefund(refund *Transaction, walletID *string, limit float64) (float64, error)
 	GetTransactionReport(userID string, startDate, endDate time.Time) (*TransactionReport, error)
+	// GetMerchantRefunds returns the completed refunds created between start
+	// and end of payments for orders with a merchant commission, skipping
+	// voided commissions
+	GetMerchantRefunds(start, end time.Time) ([]MerchantRefund, error)
 	List(limit, offset int) ([]Transaction, error)
+	Search(filter TransactionFilter) ([]Transaction, int64, error)
 }
 
 type PaymentMethodRepository interface {
@@ -259,8 +602,50 @@ type CommissionRepository interface {
 	GetByOrderID(orderID string) (*Commission, error)
 	GetByMerchantID(merchantID string, limit, offset int) ([]Commission, error)
 	GetByDriverID(driverID string, limit, offset int) ([]Commission, error)
+	GetByDriverIDBetween(driverID string, start, end time.Time) ([]Commission, error)
+	GetCreatedBetween(start, end time.Time) ([]Commission, error)
 	Update(commission *Commission) error
+	// SaveDeliveryEarnings saves the commission, processed with its driver
+	// earnings, and the earning transaction crediting the fare to its
+	// ToWalletID, in one database transaction. A commission in status from is
+	// updated; with an empty from it is created. It returns false, saving
+	// nothing, when the order's commission was created or moved out of from
+	// in the meantime.
+	SaveDeliveryEarnings(commission *Commission, from CommissionStatus, earning *Transaction) (bool, error)
+	// AddTip adds a post-delivery tip to the commission's driver earnings in
+	// a single update
+	AddTip(id string, tip float64) error
 	List(limit, offset int) ([]Commission, error)
+	// SaveReversal moves the commission out of status from and saves the
+	// clawbacks, debiting each one from its FromWalletID, in one database
+	// transaction. It returns false, saving nothing, when the commission is
+	// no longer in status from.
+	SaveReversal(commission *Commission, from CommissionStatus, clawbacks []*Transaction) (bool, error)
+}
+
+type DisputeRepository interface {
+	Create(dispute *Dispute) error
+	GetByID(id string) (*Dispute, error)
+	GetByTransactionID(transactionID string) ([]Dispute, error)
+	GetByCustomerID(customerID string, limit, offset int) ([]Dispute, error)
+	List(status DisputeStatus, limit, offset int) ([]Dispute, error)
+	Update(dispute *Dispute) error
+	// UpdateIfOpen saves dispute only if the stored one is still opened or
+	// under review. It reports false, saving nothing, when it was resolved.
+	UpdateIfOpen(dispute *Dispute) (bool, error)
+}
+
+type VoucherRepository interface {
+	Create(voucher *Voucher) error
+	GetByCode(code string) (*Voucher, error)
+	List(limit, offset int) ([]Voucher, error)
+	// ClaimRedemption records redemption if the customer has used the voucher
+	// fewer than limit times (0 for no limit) and not yet on this order. The
+	// count and insert run in one transaction holding the voucher's row
+	// lock, so concurrent checkouts can't both take the last use.
+	ClaimRedemption(redemption *VoucherRedemption, limit int) error
+	// DeleteRedemption gives back a claimed use when the payment fails
+	DeleteRedemption(id string) error
 }
 
 // Service interfaces (ports)
@@ -269,6 +654,10 @@ type PaymentService interface {
 	CreateWallet(userID string, userType auth.UserRole) (*Wallet, error)
 	GetWallet(userID string) (*Wallet, error)
 	GetBalance(userID string) (*WalletBalance, error)
+	// FreezeWallet blocks payments, transfers, top-ups and withdrawals on the
+	// user's wallet until it is unfrozen
+	FreezeWallet(userID, adminID string, req FreezeWalletRequest) (*Wallet, error)
+	UnfreezeWallet(userID, adminID string) (*Wallet, error)
 
 	// Payment processing
 	ProcessPayment(req ProcessPaymentRequest) (*PaymentResponse, error)
@@ -277,6 +666,19 @@ type PaymentService interface {
 	ProcessTopUp(req TopUpRequest) (*PaymentResponse, error)
 	ProcessWithdrawal(req WithdrawalRequest) (*PaymentResponse, error)
 
+	// Authorization holds
+	AuthorizePayment(req ProcessPaymentRequest) (*PaymentResponse, error)
+	CapturePayment(transactionID string, req CaptureRequest) (*PaymentResponse, error)
+	VoidAuthorization(transactionID string) (*PaymentResponse, error)
+	ExpireAuthorizations() (int, error)
+
+	// Disputes
+	OpenDispute(customerID string, req OpenDisputeRequest) (*Dispute, error)
+	GetCustomerDisputes(customerID string, limit, offset int) ([]Dispute, error)
+	ListDisputes(status DisputeStatus, limit, offset int) ([]Dispute, error)
+	ReviewDispute(disputeID string) (*Dispute, error)
+	ResolveDispute(disputeID, adminID string, req ResolveDisputeRequest) (*Dispute, error)
+
 	// Payment methods
 	AddPaymentMethod(userID string, req AddPaymentMethodRequest) (*PaymentMethod, error)
 	GetPaymentMethods(userID string) ([]PaymentMethod, error)
@@ -285,18 +687,42 @@ type PaymentService interface {
 
 	// Transactions
 	GetTransaction(transactionID string) (*Transaction, error)
-	GetTransactionHistory(userID string, limit, offset int) ([]Transaction, error)
+	GetUserTransaction(userID, transactionID string) (*Transaction, error)
+	GetTransactionHistory(userID, cursor string, limit int) (*TransactionPage, error)
 	GetTransactionReport(userID string, startDate, endDate time.Time) (*TransactionReport, error)
+	ReconcileWallets(cursor string, limit int) (*ReconciliationReport, error)
+	SearchTransactions(filter TransactionFilter) (*TransactionSearchPage, error)
 
 	// Commission management
-	CalculateCommission(orderID string, orderAmount, deliveryFee float64, merchantID, driverID string) (*Commission, error)
+	CalculateCommission(req CalculateCommissionRequest) (*Commission, error)
 	ProcessCommission(commissionID string) error
 	GetMerchantCommissions(merchantID string, limit, offset int) ([]Commission, error)
 	GetDriverCommissions(driverID string, limit, offset int) ([]Commission, error)
+	// ReverseCommission voids the order's pending commission, or reverses a
+	// processed one and claws back the delivery fares credited to the driver
+	ReverseCommission(orderID string) (*Commission, error)
+	// PayDeliveryEarnings records the driver's earnings for a completed
+	// delivery, credits them and settles the order's checkout tips; a delivery
+	// already paid, or an order whose commission was voided or reversed, is
+	// skipped
+	PayDeliveryEarnings(req DeliveryEarningsRequest) (*Commission, error)
+	// SettleCancellation charges the customer's cancellation fee and pays the
+	// driver's compensation for a cancelled delivery
+	SettleCancellation(req CancellationSettlementRequest) ([]Transaction, error)
+	// TipDriver charges a post-delivery tip and credits it to the order's driver
+	TipDriver(customerID string, req TipRequest) (*Transaction, error)
+	GetDriverEarnings(driverID string, startDate, endDate time.Time) (*DriverEarningsReport, error)
+	// GetDailySettlement reports each merchant's gross sales, fees, refunds
+	// and net payable for the UTC day containing date
+	GetDailySettlement(date time.Time) (*SettlementReport, error)
 
 	// Payouts
 	ProcessMerchantPayout(merchantID string, amount float64) (*PaymentResponse, error)
 	ProcessDriverPayout(driverID string, amount float64) (*PaymentResponse, error)
+
+	// Vouchers
+	CreateVoucher(req CreateVoucherRequest) (*Voucher, error)
+	ListVouchers(limit, offset int) ([]Voucher, error)
 }
 
 // External service interfaces
@@ -332,9 +758,73 @@ type StripeRefund struct {
 	ChargeID string `json:"charge_id"`
 }
 
+// DefaultMinMerchantPayout is the smallest merchant payout when none is configured
+const DefaultMinMerchantPayout = 10.0
+
 type BankTransferResult struct {
 	TransferID string  `json:"transfer_id"`
 	Status     string  `json:"status"`
 	Amount     float64 `json:"amount"`
 	Reference  string  `json:"reference"`
 }
+
+var (
+	// ErrInsufficientBalance is returned when a wallet cannot cover a debit
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	// ErrWalletInactive is returned when moving money in or out of a frozen or suspended wallet
+	ErrWalletInactive = errors.New("wallet is not active")
+	// ErrUnsupportedPaymentMethod is returned for payment methods of an unknown type
+	ErrUnsupportedPaymentMethod = errors.New("unsupported payment method")
+	// ErrInvalidPaymentMethod is returned when payment method details are incomplete
+	ErrInvalidPaymentMethod = errors.New("invalid payment method")
+	// ErrNotPaymentMethodOwner is returned when a user acts on another user's payment method
+	ErrNotPaymentMethodOwner = errors.New("payment method belongs to another user")
+	// ErrNotAuthorized is returned when capturing or voiding a transaction that isn't an open authorization
+	ErrNotAuthorized = errors.New("transaction is not an open authorization")
+	// ErrAuthorizationExpired is returned when capturing a hold after it expired
+	ErrAuthorizationExpired = errors.New("authorization has expired")
+	// ErrCaptureExceedsAuthorization is returned when capturing more than was authorized
+	ErrCaptureExceedsAuthorization = errors.New("capture amount exceeds the authorized amount")
+	// ErrNotTransactionOwner is returned when a customer reads or disputes another user's transaction
+	ErrNotTransactionOwner = errors.New("transaction belongs to another user")
+	// ErrNotDisputable is returned when disputing anything but a completed or authorized payment
+	ErrNotDisputable = errors.New("transaction cannot be disputed")
+	// ErrDisputeExceedsAmount is returned when disputing more than was charged
+	ErrDisputeExceedsAmount = errors.New("dispute amount exceeds the transaction amount")
+	// ErrDisputeExists is returned when a transaction already has an open dispute
+	ErrDisputeExists = errors.New("transaction already has an open dispute")
+	// ErrDisputeClosed is returned when reviewing or resolving a dispute that was already resolved
+	ErrDisputeClosed = errors.New("dispute is already resolved")
+	// ErrDisputeWon is returned when disputing a transaction that was already given back by a won dispute
+	ErrDisputeWon = errors.New("transaction already has a won dispute")
+	// ErrRefundExceedsAmount is returned when refunds would total more than the original transaction
+	ErrRefundExceedsAmount = errors.New("refund exceeds the transaction's remaining amount")
+	// ErrNotRefundable is returned when refunding anything but a completed payment
+	ErrNotRefundable = errors.New("only completed payments can be refunded")
+	// ErrNotOrderCustomer is returned when tipping for an order the customer didn't pay for
+	ErrNotOrderCustomer = errors.New("order belongs to another customer")
+	// ErrOrderNotDelivered is returned when tipping before the order's driver has been paid for the delivery
+	ErrOrderNotDelivered = errors.New("order has not been delivered")
+	// ErrTipWindowClosed is returned when tipping more than TipWindow after delivery
+	ErrTipWindowClosed = errors.New("tipping window for this order has closed")
+	// ErrInvalidTransactionFilter is returned for an empty date or amount range
+	ErrInvalidTransactionFilter = errors.New("invalid transaction filter")
+	// ErrPayoutBelowMinimum is returned when a merchant payout is under the configured minimum
+	ErrPayoutBelowMinimum = errors.New("payout amount is below the minimum")
+	// ErrNoPayoutAccount is returned when a merchant has no active bank account to pay out to
+	ErrNoPayoutAccount = errors.New("no bank account for payout")
+	// ErrVoucherNotFound is returned for unknown or deactivated voucher codes
+	ErrVoucherNotFound = errors.New("voucher not found")
+	// ErrVoucherExists is returned when creating a voucher with a code already in use
+	ErrVoucherExists = errors.New("voucher code already exists")
+	// ErrInvalidVoucher is returned for a percentage voucher over 100%
+	ErrInvalidVoucher = errors.New("invalid voucher")
+	// ErrVoucherExpired is returned when redeeming a voucher after its expiry
+	ErrVoucherExpired = errors.New("voucher has expired")
+	// ErrVoucherBelowMinimum is returned when the order is under the voucher's minimum amount
+	ErrVoucherBelowMinimum = errors.New("order amount is below the voucher minimum")
+	// ErrVoucherUsageLimit is returned when the customer has used the voucher as often as allowed
+	ErrVoucherUsageLimit = errors.New("voucher usage limit reached")
+	// ErrInvalidCommission is returned when a commission request has negative amounts or no merchant or driver
+	ErrInvalidCommission = errors.New("invalid commission request")
+)
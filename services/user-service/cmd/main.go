@@ -3,14 +3,19 @@ package main
 import (
 	"log"
 	"net/http"
-	"os"
 
 	"glovo-backend/services/user-service/internal/adapters/client"
 	"glovo-backend/services/user-service/internal/adapters/db"
 	httpAdapter "glovo-backend/services/user-service/internal/adapters/http"
 	"glovo-backend/services/user-service/internal/app"
 	"glovo-backend/services/user-service/internal/domain"
+	"glovo-backend/shared/config"
 	"glovo-backend/shared/database"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/health"
+	"glovo-backend/shared/logging"
+	"glovo-backend/shared/metrics"
+	"glovo-backend/shared/middleware"
 
 	// _ "glovo-backend/services/user-service/docs" // Import generated docs (will be generated by swaggo)
 
@@ -41,49 +46,59 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	logging.Setup("user-service")
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
 	}
 
+	// Fail fast on missing or malformed configuration
+	cfg := config.MustLoad("user-service", config.Postgres(), config.Redis(), config.Auth(), config.EventBus(), config.HTTPPort("PORT", "8001"))
+
 	// Initialize database connections
 	postgresDB := database.ConnectPostgres()
 	redisClient := database.ConnectRedis()
 
 	// Auto-migrate database schema
-	if err := postgresDB.AutoMigrate(&domain.User{}); err != nil {
+	if err := postgresDB.AutoMigrate(&domain.User{}, &domain.SavedAddress{}); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
 	// Initialize repositories
 	userRepo := db.NewUserRepository(postgresDB)
 	otpRepo := db.NewOTPRepository(redisClient)
+	refreshTokenRepo := db.NewRefreshTokenRepository(redisClient)
+	addressRepo := db.NewSavedAddressRepository(postgresDB)
 
 	// Initialize external services
 	smsService := client.NewSMSService()
 
+	// Signups are published for analytics
+	eventBus := events.FromEnv()
+	defer eventBus.Close()
+	middleware.AuditImpersonation(eventBus, "user-service")
+
 	// Initialize use cases
-	userService := app.NewUserService(userRepo, otpRepo, smsService)
+	userService := app.NewUserService(userRepo, otpRepo, refreshTokenRepo, addressRepo, smsService, eventBus)
 
 	// Initialize HTTP handler
 	userHandler := httpAdapter.NewUserHandler(userService)
 
 	// Setup Gin router
-	router := gin.Default()
-
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type,Authorization")
+	router := gin.New()
+	// Client IPs come from X-Forwarded-For only behind TRUSTED_PROXIES
+	if err := router.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	router.Use(gin.Recovery(), middleware.RequestID(), middleware.RequestLogger())
+	router.Use(metrics.Middleware("user-service"))
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// CORS, restricted to the origins in CORS_ALLOWED_ORIGINS
+	router.Use(middleware.CORS(middleware.CORSConfigFromEnv()))
 
-		c.Next()
-	})
+	// Prometheus metrics
+	router.GET("/metrics", metrics.Handler())
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -93,6 +108,12 @@ func main() {
 		})
 	})
 
+	// Readiness check, verifies database connectivity
+	router.GET("/health/ready", health.Ready("user-service", map[string]health.Check{
+		"postgres": health.Postgres(postgresDB),
+		"redis":    health.Redis(redisClient),
+	}))
+
 	// Setup routes
 	userHandler.SetupRoutes(router)
 
@@ -100,7 +121,7 @@ func main() {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Start server
-	port := getEnv("PORT", "8001")
+	port := cfg.String("PORT")
 	log.Printf("User Service starting on port %s", port)
 	log.Printf("Swagger documentation available at http://localhost:%s/swagger/index.html", port)
 
@@ -108,11 +129,3 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 }
-
-func getEnv(key, defaultValue string) string {
-	// This function should be moved to a shared utility package
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultValue
-}
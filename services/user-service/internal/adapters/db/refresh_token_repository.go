@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"glovo-backend/services/user-service/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type refreshTokenRepository struct {
+	redis *redis.Client
+}
+
+func NewRefreshTokenRepository(redisClient *redis.Client) domain.RefreshTokenRepository {
+	return &refreshTokenRepository{redis: redisClient}
+}
+
+func (r *refreshTokenRepository) Store(tokenID, userID string, expiresAt time.Time) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("refresh_token:%s", tokenID)
+
+	// Entries expire with the token, so the store never outgrows live tokens
+	return r.redis.Set(ctx, key, userID, time.Until(expiresAt)).Err()
+}
+
+// Consume deletes the token in a single command, so of two concurrent
+// refreshes with the same token only one sees it
+func (r *refreshTokenRepository) Consume(tokenID string) (bool, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("refresh_token:%s", tokenID)
+
+	count, err := r.redis.Del(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *refreshTokenRepository) Delete(tokenID string) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("refresh_token:%s", tokenID)
+	return r.redis.Del(ctx, key).Err()
+}
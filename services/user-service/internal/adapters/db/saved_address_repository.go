@@ -0,0 +1,59 @@
+package db
+
+import (
+	"glovo-backend/services/user-service/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type savedAddressRepository struct {
+	db *gorm.DB
+}
+
+func NewSavedAddressRepository(db *gorm.DB) domain.SavedAddressRepository {
+	return &savedAddressRepository{db: db}
+}
+
+func (r *savedAddressRepository) Create(address *domain.SavedAddress) error {
+	return r.db.Create(address).Error
+}
+
+func (r *savedAddressRepository) GetByID(id string) (*domain.SavedAddress, error) {
+	var address domain.SavedAddress
+	err := r.db.Where("id = ?", id).First(&address).Error
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (r *savedAddressRepository) GetByUserID(userID string) ([]domain.SavedAddress, error) {
+	var addresses []domain.SavedAddress
+	err := r.db.Where("user_id = ?", userID).
+		Order("is_default DESC, created_at DESC").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *savedAddressRepository) Update(address *domain.SavedAddress) error {
+	return r.db.Save(address).Error
+}
+
+func (r *savedAddressRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&domain.SavedAddress{}).Error
+}
+
+// SetDefault clears the user's other defaults and marks the address in one transaction
+func (r *savedAddressRepository) SetDefault(userID, addressID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.SavedAddress{}).
+			Where("user_id = ? AND id <> ?", userID, addressID).
+			Update("is_default", false).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&domain.SavedAddress{}).
+			Where("user_id = ? AND id = ?", userID, addressID).
+			Update("is_default", true).Error
+	})
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -22,19 +23,47 @@ func NewUserHandler(userService domain.UserService) *UserHandler {
 func (h *UserHandler) SetupRoutes(router *gin.Engine) {
 	v1 := router.Group("/api/v1")
 	{
-		// Public routes (no auth required)
-		v1.POST("/auth/send-otp", h.SendOTP)
-		v1.POST("/auth/verify-otp", h.VerifyOTP)
-		v1.POST("/auth/admin-login", h.AdminLogin)
+		// Public routes (no auth required), throttled per IP to slow down
+		// OTP spam and credential guessing
+		authRoutes := v1.Group("/auth")
+		authRoutes.Use(middleware.RateLimit(middleware.RateLimitConfig{Rate: 10.0 / 60, Burst: 10}))
+		{
+			authRoutes.POST("/send-otp", h.SendOTP)
+			authRoutes.POST("/verify-otp", h.VerifyOTP)
+			authRoutes.POST("/admin-login", h.AdminLogin)
+			authRoutes.POST("/refresh", h.RefreshToken)
+			authRoutes.POST("/logout", h.Logout)
+		}
 
 		// Protected routes (auth required)
 		protected := v1.Group("")
 		protected.Use(middleware.AuthMiddleware())
+		protected.Use(middleware.RateLimit(middleware.RateLimitConfig{Rate: 5, Burst: 20, KeyByUser: true}))
 		{
 			protected.GET("/profile", h.GetProfile)
 			protected.PUT("/profile", h.UpdateProfile)
 		}
 
+		// Customer address book
+		addresses := v1.Group("/customer/addresses")
+		addresses.Use(middleware.AuthMiddleware())
+		addresses.Use(middleware.RequireRole(auth.RoleCustomer))
+		{
+			addresses.GET("", h.ListAddresses)
+			addresses.POST("", h.AddAddress)
+			addresses.GET("/:id", h.GetAddress)
+			addresses.PUT("/:id", h.UpdateAddress)
+			addresses.DELETE("/:id", h.DeleteAddress)
+			addresses.PUT("/:id/default", h.SetDefaultAddress)
+		}
+
+		// Internal routes, for order-service to resolve saved addresses
+		internal := v1.Group("/internal")
+		internal.Use(middleware.ServiceAuth("order-service"))
+		{
+			internal.GET("/users/:id/addresses/:addressId", h.GetUserAddress)
+		}
+
 		// Admin only routes
 		admin := v1.Group("/admin")
 		admin.Use(middleware.AuthMiddleware())
@@ -129,6 +158,59 @@ func (h *UserHandler) AdminLogin(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchange a valid refresh token for a new access token and refresh token. The old refresh token stops working.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} domain.RefreshTokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/auth/refresh [post]
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req domain.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.userService.RefreshToken(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke a refresh token so it can no longer be used
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/auth/logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	var req domain.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.Logout(req.RefreshToken); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
 // GetProfile godoc
 // @Summary Get user profile
 // @Description Get the profile of the authenticated user
@@ -272,3 +354,173 @@ func (h *UserHandler) ReactivateUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "User reactivated successfully"})
 }
+
+// ListAddresses godoc
+// @Summary List saved addresses
+// @Description List the customer's saved addresses, default first
+// @Tags Addresses
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.SavedAddress
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/customer/addresses [get]
+func (h *UserHandler) ListAddresses(c *gin.Context) {
+	addresses, err := h.userService.ListAddresses(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, addresses)
+}
+
+// AddAddress godoc
+// @Summary Save an address
+// @Description Add a labeled address to the customer's address book. The first address becomes the default.
+// @Tags Addresses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.SavedAddressRequest true "Address"
+// @Success 201 {object} domain.SavedAddress
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/customer/addresses [post]
+func (h *UserHandler) AddAddress(c *gin.Context) {
+	var req domain.SavedAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	address, err := h.userService.AddAddress(c.GetString("user_id"), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, address)
+}
+
+// GetAddress godoc
+// @Summary Get a saved address
+// @Description Get one of the customer's saved addresses
+// @Tags Addresses
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Address ID"
+// @Success 200 {object} domain.SavedAddress
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/customer/addresses/{id} [get]
+func (h *UserHandler) GetAddress(c *gin.Context) {
+	address, err := h.userService.GetAddress(c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		addressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// UpdateAddress godoc
+// @Summary Update a saved address
+// @Description Replace one of the customer's saved addresses
+// @Tags Addresses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Address ID"
+// @Param request body domain.SavedAddressRequest true "Address"
+// @Success 200 {object} domain.SavedAddress
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/customer/addresses/{id} [put]
+func (h *UserHandler) UpdateAddress(c *gin.Context) {
+	var req domain.SavedAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	address, err := h.userService.UpdateAddress(c.GetString("user_id"), c.Param("id"), req)
+	if err != nil {
+		addressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// DeleteAddress godoc
+// @Summary Delete a saved address
+// @Description Remove an address from the customer's address book. Deleting the default makes the most recent remaining address the default.
+// @Tags Addresses
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Address ID"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/customer/addresses/{id} [delete]
+func (h *UserHandler) DeleteAddress(c *gin.Context) {
+	if err := h.userService.DeleteAddress(c.GetString("user_id"), c.Param("id")); err != nil {
+		addressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Address deleted successfully"})
+}
+
+// SetDefaultAddress godoc
+// @Summary Set the default address
+// @Description Make one of the customer's saved addresses the default
+// @Tags Addresses
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Address ID"
+// @Success 200 {object} domain.SavedAddress
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/customer/addresses/{id}/default [put]
+func (h *UserHandler) SetDefaultAddress(c *gin.Context) {
+	address, err := h.userService.SetDefaultAddress(c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		addressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// GetUserAddress godoc
+// @Summary Get a user's saved address (internal)
+// @Description Resolve a saved address for another service, e.g. when an order is placed with an address_id
+// @Tags Internal
+// @Produce json
+// @Param id path string true "User ID"
+// @Param addressId path string true "Address ID"
+// @Success 200 {object} domain.SavedAddress
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/internal/users/{id}/addresses/{addressId} [get]
+func (h *UserHandler) GetUserAddress(c *gin.Context) {
+	address, err := h.userService.GetAddress(c.Param("id"), c.Param("addressId"))
+	if err != nil {
+		addressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+func addressError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, domain.ErrAddressNotFound) {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"glovo-backend/services/user-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// AddAddress saves an address to the user's address book. The first address
+// saved becomes the default.
+func (s *userService) AddAddress(userID string, req domain.SavedAddressRequest) (*domain.SavedAddress, error) {
+	existing, err := s.addressRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	address := &domain.SavedAddress{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Label:     req.Label,
+		Address:   req.Address,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		Notes:     req.Notes,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.addressRepo.Create(address); err != nil {
+		return nil, fmt.Errorf("failed to save address: %w", err)
+	}
+
+	if req.IsDefault || len(existing) == 0 {
+		return s.SetDefaultAddress(userID, address.ID)
+	}
+	return address, nil
+}
+
+// ListAddresses returns the user's addresses, default first
+func (s *userService) ListAddresses(userID string) ([]domain.SavedAddress, error) {
+	return s.addressRepo.GetByUserID(userID)
+}
+
+// GetAddress returns one of the user's addresses. Another user's address is
+// reported as not found.
+func (s *userService) GetAddress(userID, addressID string) (*domain.SavedAddress, error) {
+	address, err := s.addressRepo.GetByID(addressID)
+	if err != nil || address.UserID != userID {
+		return nil, domain.ErrAddressNotFound
+	}
+	return address, nil
+}
+
+func (s *userService) UpdateAddress(userID, addressID string, req domain.SavedAddressRequest) (*domain.SavedAddress, error) {
+	address, err := s.GetAddress(userID, addressID)
+	if err != nil {
+		return nil, err
+	}
+
+	address.Label = req.Label
+	address.Address = req.Address
+	address.Latitude = req.Latitude
+	address.Longitude = req.Longitude
+	address.Notes = req.Notes
+	address.UpdatedAt = time.Now()
+	if err := s.addressRepo.Update(address); err != nil {
+		return nil, fmt.Errorf("failed to update address: %w", err)
+	}
+
+	if req.IsDefault && !address.IsDefault {
+		return s.SetDefaultAddress(userID, addressID)
+	}
+	return address, nil
+}
+
+// DeleteAddress removes the address. When it was the default, the most
+// recently saved remaining address takes over.
+func (s *userService) DeleteAddress(userID, addressID string) error {
+	address, err := s.GetAddress(userID, addressID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.addressRepo.Delete(addressID); err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+	if !address.IsDefault {
+		return nil
+	}
+
+	remaining, err := s.addressRepo.GetByUserID(userID)
+	if err != nil || len(remaining) == 0 {
+		return err
+	}
+	return s.addressRepo.SetDefault(userID, remaining[0].ID)
+}
+
+func (s *userService) SetDefaultAddress(userID, addressID string) (*domain.SavedAddress, error) {
+	if _, err := s.GetAddress(userID, addressID); err != nil {
+		return nil, err
+	}
+	if err := s.addressRepo.SetDefault(userID, addressID); err != nil {
+		return nil, fmt.Errorf("failed to set default address: %w", err)
+	}
+	return s.addressRepo.GetByID(addressID)
+}
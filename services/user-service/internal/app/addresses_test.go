@@ -0,0 +1,218 @@
+package app
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"testing"
+
+	"glovo-backend/services/user-service/internal/domain"
+)
+
+// fakeAddressRepo keeps addresses in insertion order and lists them the way
+// the SQL does: default first, then newest
+type fakeAddressRepo struct {
+	addresses []*domain.SavedAddress
+}
+
+func (r *fakeAddressRepo) Create(address *domain.SavedAddress) error {
+	stored := *address
+	r.addresses = append(r.addresses, &stored)
+	return nil
+}
+
+func (r *fakeAddressRepo) GetByID(id string) (*domain.SavedAddress, error) {
+	for _, address := range r.addresses {
+		if address.ID == id {
+			read := *address
+			return &read, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *fakeAddressRepo) GetByUserID(userID string) ([]domain.SavedAddress, error) {
+	var addresses []domain.SavedAddress
+	for i := len(r.addresses) - 1; i >= 0; i-- {
+		if r.addresses[i].UserID == userID {
+			addresses = append(addresses, *r.addresses[i])
+		}
+	}
+	sort.SliceStable(addresses, func(i, j int) bool {
+		return addresses[i].IsDefault && !addresses[j].IsDefault
+	})
+	return addresses, nil
+}
+
+func (r *fakeAddressRepo) Update(address *domain.SavedAddress) error {
+	for i, stored := range r.addresses {
+		if stored.ID == address.ID {
+			updated := *address
+			r.addresses[i] = &updated
+		}
+	}
+	return nil
+}
+
+func (r *fakeAddressRepo) Delete(id string) error {
+	for i, address := range r.addresses {
+		if address.ID == id {
+			r.addresses = append(r.addresses[:i], r.addresses[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *fakeAddressRepo) SetDefault(userID, addressID string) error {
+	for _, address := range r.addresses {
+		if address.UserID == userID {
+			address.IsDefault = address.ID == addressID
+		}
+	}
+	return nil
+}
+
+// addAddresses saves one address per label for the user, in order
+func addAddresses(t *testing.T, svc *userService, userID string, labels ...string) []*domain.SavedAddress {
+	t.Helper()
+	var added []*domain.SavedAddress
+	for _, label := range labels {
+		address, err := svc.AddAddress(userID, domain.SavedAddressRequest{Label: label, Address: label + " street", Latitude: 41.38, Longitude: 2.17})
+		if err != nil {
+			t.Fatalf("AddAddress %s: %v", label, err)
+		}
+		added = append(added, address)
+	}
+	return added
+}
+
+// listLabels lists the user's addresses as labels, marking the default with *
+func listLabels(t *testing.T, svc *userService, userID string) []string {
+	t.Helper()
+	addresses, err := svc.ListAddresses(userID)
+	if err != nil {
+		t.Fatalf("ListAddresses: %v", err)
+	}
+	labels := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		label := address.Label
+		if address.IsDefault {
+			label += "*"
+		}
+		labels = append(labels, label)
+	}
+	return labels
+}
+
+func TestAddAddress(t *testing.T) {
+	svc := &userService{addressRepo: &fakeAddressRepo{}}
+
+	added := addAddresses(t, svc, "customer-1", "home", "work")
+	if !added[0].IsDefault || added[1].IsDefault {
+		t.Fatalf("defaults = %v and %v, want only the first address", added[0].IsDefault, added[1].IsDefault)
+	}
+	if got, want := listLabels(t, svc, "customer-1"), []string{"home*", "work"}; !slices.Equal(got, want) {
+		t.Fatalf("addresses = %v, want %v", got, want)
+	}
+
+	gym, err := svc.AddAddress("customer-1", domain.SavedAddressRequest{Label: "gym", Address: "gym street", IsDefault: true})
+	if err != nil {
+		t.Fatalf("AddAddress: %v", err)
+	}
+	if !gym.IsDefault {
+		t.Fatalf("address saved as default isn't the default")
+	}
+	if got, want := listLabels(t, svc, "customer-1"), []string{"gym*", "work", "home"}; !slices.Equal(got, want) {
+		t.Fatalf("addresses = %v, want %v", got, want)
+	}
+
+	// Each customer has their own book and their own default
+	addAddresses(t, svc, "customer-2", "office")
+	if got, want := listLabels(t, svc, "customer-2"), []string{"office*"}; !slices.Equal(got, want) {
+		t.Fatalf("other customer's addresses = %v, want %v", got, want)
+	}
+	if got, want := listLabels(t, svc, "customer-1"), []string{"gym*", "work", "home"}; !slices.Equal(got, want) {
+		t.Fatalf("addresses = %v, want %v", got, want)
+	}
+}
+
+func TestSetDefaultAddress(t *testing.T) {
+	svc := &userService{addressRepo: &fakeAddressRepo{}}
+	mine := addAddresses(t, svc, "customer-1", "home", "work")
+	theirs := addAddresses(t, svc, "customer-2", "office")
+
+	work, err := svc.SetDefaultAddress("customer-1", mine[1].ID)
+	if err != nil {
+		t.Fatalf("SetDefaultAddress: %v", err)
+	}
+	if !work.IsDefault {
+		t.Fatalf("returned address isn't the default")
+	}
+	if got, want := listLabels(t, svc, "customer-1"), []string{"work*", "home"}; !slices.Equal(got, want) {
+		t.Fatalf("addresses = %v, want %v", got, want)
+	}
+
+	if _, err := svc.SetDefaultAddress("customer-1", theirs[0].ID); !errors.Is(err, domain.ErrAddressNotFound) {
+		t.Fatalf("error = %v, want %v", err, domain.ErrAddressNotFound)
+	}
+	if got, want := listLabels(t, svc, "customer-1"), []string{"work*", "home"}; !slices.Equal(got, want) {
+		t.Fatalf("addresses after a rejected change = %v, want %v", got, want)
+	}
+	if got, want := listLabels(t, svc, "customer-2"), []string{"office*"}; !slices.Equal(got, want) {
+		t.Fatalf("other customer's addresses = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteAddress(t *testing.T) {
+	svc := &userService{addressRepo: &fakeAddressRepo{}}
+	mine := addAddresses(t, svc, "customer-1", "home", "work", "gym")
+	theirs := addAddresses(t, svc, "customer-2", "office")
+
+	if err := svc.DeleteAddress("customer-1", theirs[0].ID); !errors.Is(err, domain.ErrAddressNotFound) {
+		t.Fatalf("error = %v, want %v", err, domain.ErrAddressNotFound)
+	}
+	if got, want := listLabels(t, svc, "customer-2"), []string{"office*"}; !slices.Equal(got, want) {
+		t.Fatalf("other customer's addresses = %v, want %v", got, want)
+	}
+
+	if err := svc.DeleteAddress("customer-1", mine[1].ID); err != nil {
+		t.Fatalf("DeleteAddress: %v", err)
+	}
+	if got, want := listLabels(t, svc, "customer-1"), []string{"home*", "gym"}; !slices.Equal(got, want) {
+		t.Fatalf("addresses = %v, want %v", got, want)
+	}
+
+	// The newest remaining address takes over as default
+	if err := svc.DeleteAddress("customer-1", mine[0].ID); err != nil {
+		t.Fatalf("DeleteAddress: %v", err)
+	}
+	if got, want := listLabels(t, svc, "customer-1"), []string{"gym*"}; !slices.Equal(got, want) {
+		t.Fatalf("addresses = %v, want %v", got, want)
+	}
+
+	if err := svc.DeleteAddress("customer-1", mine[2].ID); err != nil {
+		t.Fatalf("DeleteAddress: %v", err)
+	}
+	if got := listLabels(t, svc, "customer-1"); len(got) != 0 {
+		t.Fatalf("addresses = %v, want none", got)
+	}
+}
+
+func TestAddressOwnership(t *testing.T) {
+	svc := &userService{addressRepo: &fakeAddressRepo{}}
+	theirs := addAddresses(t, svc, "customer-2", "office")
+
+	if _, err := svc.GetAddress("customer-1", theirs[0].ID); !errors.Is(err, domain.ErrAddressNotFound) {
+		t.Fatalf("GetAddress error = %v, want %v", err, domain.ErrAddressNotFound)
+	}
+	if _, err := svc.UpdateAddress("customer-1", theirs[0].ID, domain.SavedAddressRequest{Label: "mine now"}); !errors.Is(err, domain.ErrAddressNotFound) {
+		t.Fatalf("UpdateAddress error = %v, want %v", err, domain.ErrAddressNotFound)
+	}
+	if _, err := svc.GetAddress("customer-1", "missing"); !errors.Is(err, domain.ErrAddressNotFound) {
+		t.Fatalf("missing address error = %v, want %v", err, domain.ErrAddressNotFound)
+	}
+	if got, want := listLabels(t, svc, "customer-2"), []string{"office*"}; !slices.Equal(got, want) {
+		t.Fatalf("other customer's addresses = %v, want %v", got, want)
+	}
+}
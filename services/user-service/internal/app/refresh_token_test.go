@@ -0,0 +1,158 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"glovo-backend/services/user-service/internal/domain"
+	"glovo-backend/shared/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type fakeUserRepo struct {
+	domain.UserRepository
+	users map[string]*domain.User
+}
+
+func (r *fakeUserRepo) GetByID(id string) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	read := *user
+	return &read, nil
+}
+
+type fakeRefreshTokenRepo struct {
+	active map[string]string // user ID by token ID
+}
+
+func (r *fakeRefreshTokenRepo) Store(tokenID, userID string, expiresAt time.Time) error {
+	r.active[tokenID] = userID
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) Consume(tokenID string) (bool, error) {
+	_, ok := r.active[tokenID]
+	delete(r.active, tokenID)
+	return ok, nil
+}
+
+func (r *fakeRefreshTokenRepo) Delete(tokenID string) error {
+	delete(r.active, tokenID)
+	return nil
+}
+
+func newRefreshTestService(t *testing.T) (*userService, *fakeUserRepo, *fakeRefreshTokenRepo) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	users := &fakeUserRepo{users: map[string]*domain.User{
+		"user-1": {ID: "user-1", Role: auth.RoleCustomer, Status: domain.StatusActive},
+	}}
+	tokens := &fakeRefreshTokenRepo{active: make(map[string]string)}
+	return &userService{userRepo: users, refreshTokenRepo: tokens}, users, tokens
+}
+
+func TestRefreshToken(t *testing.T) {
+	svc, users, tokens := newRefreshTestService(t)
+	login, err := svc.issueTokens(users.users["user-1"])
+	if err != nil {
+		t.Fatalf("issueTokens: %v", err)
+	}
+
+	refreshed, err := svc.RefreshToken(login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	claims, err := auth.ValidateToken(refreshed.Token)
+	if err != nil {
+		t.Fatalf("new access token is invalid: %v", err)
+	}
+	if claims.UserID != "user-1" || claims.Role != string(auth.RoleCustomer) {
+		t.Fatalf("access token claims = %+v, want user-1 customer", claims)
+	}
+	if refreshed.ExpiresIn != int(auth.DefaultAccessTokenTTL.Seconds()) {
+		t.Fatalf("expires in %d, want %d", refreshed.ExpiresIn, int(auth.DefaultAccessTokenTTL.Seconds()))
+	}
+	if refreshed.RefreshToken == "" || refreshed.RefreshToken == login.RefreshToken {
+		t.Fatalf("refresh token was not rotated")
+	}
+	if len(tokens.active) != 1 {
+		t.Fatalf("%d refresh tokens active, want only the new one", len(tokens.active))
+	}
+
+	// The exchanged token is spent; its replacement still works
+	if _, err := svc.RefreshToken(login.RefreshToken); !errors.Is(err, domain.ErrRefreshTokenRevoked) {
+		t.Fatalf("reused token error = %v, want %v", err, domain.ErrRefreshTokenRevoked)
+	}
+	if _, err := svc.RefreshToken(refreshed.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken with the rotated token: %v", err)
+	}
+}
+
+func TestRefreshTokenRevokedOnLogout(t *testing.T) {
+	svc, users, tokens := newRefreshTestService(t)
+	login, err := svc.issueTokens(users.users["user-1"])
+	if err != nil {
+		t.Fatalf("issueTokens: %v", err)
+	}
+
+	if err := svc.Logout(login.RefreshToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if len(tokens.active) != 0 {
+		t.Fatalf("%d refresh tokens still active after logout", len(tokens.active))
+	}
+	if _, err := svc.RefreshToken(login.RefreshToken); !errors.Is(err, domain.ErrRefreshTokenRevoked) {
+		t.Fatalf("error = %v, want %v", err, domain.ErrRefreshTokenRevoked)
+	}
+}
+
+func TestRefreshTokenExpired(t *testing.T) {
+	svc, _, tokens := newRefreshTestService(t)
+
+	past := time.Now().Add(-time.Hour)
+	claims := auth.Claims{
+		UserID:    "user-1",
+		Role:      string(auth.RoleCustomer),
+		TokenType: "refresh",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "expired-token",
+			IssuedAt:  jwt.NewNumericDate(past.Add(-auth.DefaultRefreshTokenTTL)),
+			ExpiresAt: jwt.NewNumericDate(past),
+		},
+	}
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	// Still in the store, so only the expiry can reject it
+	tokens.active["expired-token"] = "user-1"
+
+	if _, err := svc.RefreshToken(expired); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Fatalf("error = %v, want %v", err, jwt.ErrTokenExpired)
+	}
+	if _, ok := tokens.active["expired-token"]; !ok {
+		t.Fatalf("a rejected token was consumed")
+	}
+}
+
+func TestRefreshTokenRejects(t *testing.T) {
+	svc, users, _ := newRefreshTestService(t)
+	login, err := svc.issueTokens(users.users["user-1"])
+	if err != nil {
+		t.Fatalf("issueTokens: %v", err)
+	}
+
+	if _, err := svc.RefreshToken(login.Token); !errors.Is(err, auth.ErrNotARefreshToken) {
+		t.Fatalf("access token error = %v, want %v", err, auth.ErrNotARefreshToken)
+	}
+
+	// Suspension applies on the next refresh
+	users.users["user-1"].Status = domain.StatusSuspended
+	if _, err := svc.RefreshToken(login.RefreshToken); err == nil {
+		t.Fatalf("refreshed a suspended user's token")
+	}
+}
@@ -1,29 +1,38 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"math/rand"
 	"time"
 
 	"glovo-backend/services/user-service/internal/domain"
 	"glovo-backend/shared/auth"
+	"glovo-backend/shared/events"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type userService struct {
-	userRepo   domain.UserRepository
-	otpRepo    domain.OTPRepository
-	smsService domain.SMSService
+	userRepo         domain.UserRepository
+	otpRepo          domain.OTPRepository
+	refreshTokenRepo domain.RefreshTokenRepository
+	addressRepo      domain.SavedAddressRepository
+	smsService       domain.SMSService
+	eventBus         events.Bus
 }
 
-func NewUserService(userRepo domain.UserRepository, otpRepo domain.OTPRepository, smsService domain.SMSService) domain.UserService {
+func NewUserService(userRepo domain.UserRepository, otpRepo domain.OTPRepository, refreshTokenRepo domain.RefreshTokenRepository, addressRepo domain.SavedAddressRepository, smsService domain.SMSService, eventBus events.Bus) domain.UserService {
 	return &userService{
-		userRepo:   userRepo,
-		otpRepo:    otpRepo,
-		smsService: smsService,
+		userRepo:         userRepo,
+		otpRepo:          otpRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		addressRepo:      addressRepo,
+		smsService:       smsService,
+		eventBus:         eventBus,
 	}
 }
 
@@ -92,18 +101,21 @@ func (s *userService) VerifyOTP(phoneNumber, otpCode string) (*domain.LoginRespo
 		if err := s.userRepo.Create(user); err != nil {
 			return nil, fmt.Errorf("failed to create user: %w", err)
 		}
+		s.publishUserRegistered(user)
 	}
 
-	// Generate JWT token
-	token, err := auth.GenerateToken(user.ID, user.Role)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
-	}
+	return s.issueTokens(user)
+}
 
-	return &domain.LoginResponse{
-		Token: token,
-		User:  *user,
-	}, nil
+func (s *userService) publishUserRegistered(user *domain.User) {
+	payload := events.UserRegisteredPayload{
+		UserID:       user.ID,
+		Role:         string(user.Role),
+		RegisteredAt: user.CreatedAt,
+	}
+	if err := s.eventBus.Publish(context.Background(), events.UserRegistered, payload); err != nil {
+		log.Printf("Failed to publish %s for user %s: %v", events.UserRegistered, user.ID, err)
+	}
 }
 
 func (s *userService) AdminLogin(email, password string) (*domain.LoginResponse, error) {
@@ -125,18 +137,91 @@ func (s *userService) AdminLogin(email, password string) (*domain.LoginResponse,
 		return nil, errors.New("invalid credentials")
 	}
 
-	// Generate JWT token
+	return s.issueTokens(user)
+}
+
+// issueTokens signs an access and refresh token pair and records the refresh
+// token so it can be revoked on logout
+func (s *userService) issueTokens(user *domain.User) (*domain.LoginResponse, error) {
 	token, err := auth.GenerateToken(user.ID, user.Role)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
+	refreshToken, err := s.issueRefreshToken(user)
+	if err != nil {
+		return nil, err
+	}
+
 	return &domain.LoginResponse{
-		Token: token,
-		User:  *user,
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         *user,
 	}, nil
 }
 
+func (s *userService) issueRefreshToken(user *domain.User) (string, error) {
+	refreshToken, claims, err := auth.GenerateRefreshToken(user.ID, user.Role)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	if err := s.refreshTokenRepo.Store(claims.ID, user.ID, claims.ExpiresAt.Time); err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return refreshToken, nil
+}
+
+// RefreshToken exchanges a refresh token for a new access and refresh token
+// pair. Refresh tokens are single use: the one presented is revoked.
+func (s *userService) RefreshToken(refreshToken string) (*domain.RefreshTokenResponse, error) {
+	claims, err := auth.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.refreshTokenRepo.Consume(claims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check refresh token: %w", err)
+	}
+	if !active {
+		return nil, domain.ErrRefreshTokenRevoked
+	}
+
+	// Re-read the user so role changes and suspensions apply on refresh
+	user, err := s.userRepo.GetByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == domain.StatusSuspended {
+		return nil, errors.New("account is suspended")
+	}
+
+	token, err := auth.GenerateToken(user.ID, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	newRefreshToken, err := s.issueRefreshToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RefreshTokenResponse{
+		Token:        token,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int(auth.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// Logout revokes the refresh token; outstanding access tokens remain valid
+// until they expire
+func (s *userService) Logout(refreshToken string) error {
+	claims, err := auth.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.Delete(claims.ID)
+}
+
 func (s *userService) GetProfile(userID string) (*domain.User, error) {
 	return s.userRepo.GetByID(userID)
 }
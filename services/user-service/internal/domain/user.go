@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"glovo-backend/shared/auth"
@@ -24,6 +25,20 @@ type UserProfile struct {
 	Avatar    string `json:"avatar,omitempty"`
 }
 
+// SavedAddress is a labeled delivery address in a customer's address book
+type SavedAddress struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"index"`
+	Label     string    `json:"label"` // e.g. home, work
+	Address   string    `json:"address"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Notes     string    `json:"notes,omitempty"` // delivery notes, e.g. floor or door code
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type UserStatus string
 
 const (
@@ -60,10 +75,41 @@ type AdminLoginRequest struct {
 
 // LoginResponse represents the login response
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	User         User   `json:"user"`
+}
+
+// RefreshTokenRequest carries a refresh token for refresh and logout
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshTokenResponse carries the new access token and the refresh token
+// that replaces the one presented
+type RefreshTokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // seconds
+}
+
+// SavedAddressRequest creates or replaces a saved address
+type SavedAddressRequest struct {
+	Label     string  `json:"label" binding:"required,max=50"`
+	Address   string  `json:"address" binding:"required"`
+	Latitude  float64 `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude float64 `json:"longitude" binding:"required,min=-180,max=180"`
+	Notes     string  `json:"notes"`
+	IsDefault bool    `json:"is_default"`
 }
 
+// ErrRefreshTokenRevoked is returned for refresh tokens that were revoked on
+// logout or already exchanged on refresh
+var ErrRefreshTokenRevoked = errors.New("refresh token has been revoked")
+
+// ErrAddressNotFound is returned for addresses missing from the user's address book
+var ErrAddressNotFound = errors.New("address not found")
+
 // Repository interfaces (ports)
 type UserRepository interface {
 	Create(user *User) error
@@ -75,22 +121,51 @@ type UserRepository interface {
 	List(limit, offset int) ([]User, error)
 }
 
+type SavedAddressRepository interface {
+	Create(address *SavedAddress) error
+	GetByID(id string) (*SavedAddress, error)
+	GetByUserID(userID string) ([]SavedAddress, error)
+	Update(address *SavedAddress) error
+	Delete(id string) error
+	// SetDefault makes the address the user's only default in one transaction
+	SetDefault(userID, addressID string) error
+}
+
 type OTPRepository interface {
 	Store(otp *OTP) error
 	GetByPhoneNumber(phoneNumber string) (*OTP, error)
 	Delete(phoneNumber string) error
 }
 
+// RefreshTokenRepository tracks issued refresh tokens by token ID; a token
+// missing from the store has been revoked
+type RefreshTokenRepository interface {
+	Store(tokenID, userID string, expiresAt time.Time) error
+	// Consume revokes the token, reporting whether it was still active
+	Consume(tokenID string) (bool, error)
+	Delete(tokenID string) error
+}
+
 // Service interfaces (ports)
 type UserService interface {
 	SendOTP(phoneNumber string) error
 	VerifyOTP(phoneNumber, otpCode string) (*LoginResponse, error)
 	AdminLogin(email, password string) (*LoginResponse, error)
+	RefreshToken(refreshToken string) (*RefreshTokenResponse, error)
+	Logout(refreshToken string) error
 	GetProfile(userID string) (*User, error)
 	UpdateProfile(userID string, profile UserProfile) (*User, error)
 	ListUsers(limit, offset int) ([]User, error)
 	SuspendUser(userID string) error
 	ReactivateUser(userID string) error
+
+	// Address book
+	AddAddress(userID string, req SavedAddressRequest) (*SavedAddress, error)
+	ListAddresses(userID string) ([]SavedAddress, error)
+	GetAddress(userID, addressID string) (*SavedAddress, error)
+	UpdateAddress(userID, addressID string, req SavedAddressRequest) (*SavedAddress, error)
+	DeleteAddress(userID, addressID string) error
+	SetDefaultAddress(userID, addressID string) (*SavedAddress, error)
 }
 
 type SMSService interface {
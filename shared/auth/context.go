@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys AuthMiddleware stores the validated token under
+const (
+	ContextUserID = "user_id"
+	ContextRole   = "role"
+	ContextClaims = "claims"
+)
+
+// UserID returns the authenticated user's ID; ok is false when the request
+// carries none or it isn't a non-empty string
+func UserID(c *gin.Context) (string, bool) {
+	value, exists := c.Get(ContextUserID)
+	if !exists {
+		return "", false
+	}
+	userID, ok := value.(string)
+	return userID, ok && userID != ""
+}
+
+// Role returns the authenticated user's role; ok is false when the request
+// carries none or it isn't a non-empty string
+func Role(c *gin.Context) (UserRole, bool) {
+	value, exists := c.Get(ContextRole)
+	if !exists {
+		return "", false
+	}
+	switch role := value.(type) {
+	case UserRole:
+		return role, role != ""
+	case string:
+		return UserRole(role), role != ""
+	}
+	return "", false
+}
+
+// RequireUserID is UserID for handlers behind AuthMiddleware: when the user
+// ID is missing it aborts the request with 401 and returns ok false
+func RequireUserID(c *gin.Context) (string, bool) {
+	userID, ok := UserID(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+	}
+	return userID, ok
+}
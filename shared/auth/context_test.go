@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testContext(values map[string]interface{}) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	for key, value := range values {
+		c.Set(key, value)
+	}
+	return c, w
+}
+
+func TestUserID(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		set    bool
+		want   string
+		wantOK bool
+	}{
+		{name: "user ID", value: "user-1", set: true, want: "user-1", wantOK: true},
+		{name: "missing"},
+		{name: "empty", value: "", set: true},
+		{name: "wrong type", value: 42, set: true},
+		{name: "nil", value: nil, set: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]interface{}{}
+			if tt.set {
+				values[ContextUserID] = tt.value
+			}
+			c, w := testContext(values)
+
+			if got, ok := UserID(c); got != tt.want || ok != tt.wantOK {
+				t.Fatalf("UserID = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+
+			got, ok := RequireUserID(c)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("RequireUserID = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+			if c.IsAborted() == tt.wantOK {
+				t.Fatalf("aborted = %v, want %v", c.IsAborted(), !tt.wantOK)
+			}
+			if !tt.wantOK && w.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		set    bool
+		want   UserRole
+		wantOK bool
+	}{
+		{name: "typed role", value: RoleDriver, set: true, want: RoleDriver, wantOK: true},
+		{name: "string role", value: "admin", set: true, want: RoleAdmin, wantOK: true},
+		{name: "missing"},
+		{name: "empty", value: UserRole(""), set: true},
+		{name: "wrong type", value: 3, set: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]interface{}{}
+			if tt.set {
+				values[ContextRole] = tt.value
+			}
+			c, _ := testContext(values)
+
+			if got, ok := Role(c); got != tt.want || ok != tt.wantOK {
+				t.Fatalf("Role = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
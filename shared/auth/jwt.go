@@ -2,17 +2,33 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-var jwtSecret = []byte("your-secret-key") // In production, use environment variable
+// ErrSecretNotSet is returned when signing or validating a token without
+// its secret configured. Services require both secrets at startup through
+// config.Auth, so this only happens when that check is skipped.
+var ErrSecretNotSet = errors.New("token secret is not set")
+
+// jwtSecret signs user tokens; there is deliberately no default
+func jwtSecret() ([]byte, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, fmt.Errorf("%w: JWT_SECRET", ErrSecretNotSet)
+	}
+	return []byte(secret), nil
+}
 
 type Claims struct {
-	UserID string `json:"sub"`
-	Role   string `json:"role"`
+	UserID         string `json:"sub"`
+	Role           string `json:"role"`
+	ImpersonatedBy string `json:"impersonated_by,omitempty"` // admin acting as the user, for impersonation tokens
+	TokenType      string `json:"typ,omitempty"`             // "refresh" for refresh tokens, empty for access tokens
 	jwt.RegisteredClaims
 }
 
@@ -25,28 +41,121 @@ const (
 	RoleAdmin    UserRole = "admin"
 )
 
+// Token lifetimes and the clock skew tolerated when checking exp and nbf,
+// used when JWT_ACCESS_TTL, JWT_REFRESH_TTL and JWT_LEEWAY are unset
+const (
+	DefaultAccessTokenTTL  = 24 * time.Hour
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+	DefaultLeeway          = 30 * time.Second
+
+	tokenTypeRefresh = "refresh"
+)
+
+// AccessTokenTTL is how long access tokens are valid
+func AccessTokenTTL() time.Duration {
+	return envDuration("JWT_ACCESS_TTL", DefaultAccessTokenTTL)
+}
+
+// RefreshTokenTTL is how long refresh tokens are valid
+func RefreshTokenTTL() time.Duration {
+	return envDuration("JWT_REFRESH_TTL", DefaultRefreshTokenTTL)
+}
+
+// Leeway is how far past exp, or before nbf, a token is still accepted so
+// services with slightly different clocks agree
+func Leeway() time.Duration {
+	return envDuration("JWT_LEEWAY", DefaultLeeway)
+}
+
+// envDuration reads a duration like 15m; services validate it at startup
+func envDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value >= 0 {
+		return value
+	}
+	return defaultValue
+}
+
+var (
+	ErrInvalidToken      = errors.New("invalid token")
+	ErrNotARefreshToken  = errors.New("not a refresh token")
+	ErrRefreshTokenUsage = errors.New("refresh tokens cannot be used for API access")
+)
+
 func GenerateToken(userID string, role UserRole) (string, error) {
-	claims := Claims{
-		UserID: userID,
-		Role:   string(role),
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "glovo-backend",
-			Subject:   userID,
-			ID:        uuid.New().String(),
-		},
+	token, _, err := signToken(Claims{UserID: userID, Role: string(role)}, AccessTokenTTL())
+	return token, err
+}
+
+// GenerateRefreshToken issues a long-lived token that can only be exchanged
+// for new access tokens. The returned claims carry the token ID (jti) so the
+// caller can track and revoke it.
+func GenerateRefreshToken(userID string, role UserRole) (string, *Claims, error) {
+	return signToken(Claims{UserID: userID, Role: string(role), TokenType: tokenTypeRefresh}, RefreshTokenTTL())
+}
+
+// GenerateImpersonationToken issues a short-lived token that acts as the user
+// and records the admin behind it in the impersonated_by claim
+func GenerateImpersonationToken(userID string, role UserRole, adminID string, ttl time.Duration) (string, error) {
+	if adminID == "" {
+		return "", errors.New("impersonating admin is required")
+	}
+	token, _, err := signToken(Claims{UserID: userID, Role: string(role), ImpersonatedBy: adminID}, ttl)
+	return token, err
+}
+
+func signToken(claims Claims, ttl time.Duration) (string, *Claims, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Issuer:    "glovo-backend",
+		Subject:   claims.UserID,
+		ID:        uuid.New().String(),
+	}
+
+	secret, err := jwtSecret()
+	if err != nil {
+		return "", nil, err
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	signed, err := token.SignedString(secret)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, &claims, nil
 }
 
+// ValidateToken validates an access token; refresh tokens are rejected
 func ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType == tokenTypeRefresh {
+		return nil, ErrRefreshTokenUsage
+	}
+	return claims, nil
+}
+
+// ValidateRefreshToken validates a refresh token's signature, expiry and type.
+// Revocation is tracked by the issuing service.
+func ValidateRefreshToken(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != tokenTypeRefresh {
+		return nil, ErrNotARefreshToken
+	}
+	return claims, nil
+}
+
+func parseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+		return jwtSecret()
+	}, jwt.WithLeeway(Leeway()))
 
 	if err != nil {
 		return nil, err
@@ -56,7 +165,7 @@ func ValidateToken(tokenString string) (*Claims, error) {
 		return claims, nil
 	}
 
-	return nil, errors.New("invalid token")
+	return nil, ErrInvalidToken
 }
 
 func ValidateRole(claims *Claims, requiredRole UserRole) error {
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRefreshTokens(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	refresh, claims, err := GenerateRefreshToken("user-1", RoleCustomer)
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+	if claims.ID == "" {
+		t.Fatalf("refresh token has no ID to revoke it by")
+	}
+	if ttl := claims.ExpiresAt.Sub(claims.IssuedAt.Time); ttl != DefaultRefreshTokenTTL {
+		t.Fatalf("refresh token lives %v, want %v", ttl, DefaultRefreshTokenTTL)
+	}
+
+	validated, err := ValidateRefreshToken(refresh)
+	if err != nil {
+		t.Fatalf("ValidateRefreshToken: %v", err)
+	}
+	if validated.UserID != "user-1" || validated.Role != string(RoleCustomer) || validated.ID != claims.ID {
+		t.Fatalf("validated claims = %+v, want user-1 customer %s", validated, claims.ID)
+	}
+
+	// The two token types can't stand in for each other
+	if _, err := ValidateToken(refresh); !errors.Is(err, ErrRefreshTokenUsage) {
+		t.Fatalf("ValidateToken(refresh) error = %v, want %v", err, ErrRefreshTokenUsage)
+	}
+	access, err := GenerateToken("user-1", RoleCustomer)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if _, err := ValidateRefreshToken(access); !errors.Is(err, ErrNotARefreshToken) {
+		t.Fatalf("ValidateRefreshToken(access) error = %v, want %v", err, ErrNotARefreshToken)
+	}
+}
+
+func TestRefreshTokenExpiry(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_LEEWAY", "1m")
+
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		wantErr error
+	}{
+		{name: "valid", ttl: time.Hour},
+		{name: "expired within the leeway", ttl: -30 * time.Second},
+		{name: "expired", ttl: -2 * time.Minute, wantErr: jwt.ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _, err := signToken(Claims{UserID: "user-1", Role: string(RoleCustomer), TokenType: tokenTypeRefresh}, tt.ttl)
+			if err != nil {
+				t.Fatalf("signToken: %v", err)
+			}
+			_, err = ValidateRefreshToken(token)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ValidateRefreshToken: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTokenTTLFromEnv(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_REFRESH_TTL", "72h")
+
+	_, claims, err := GenerateRefreshToken("user-1", RoleDriver)
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+	if ttl := claims.ExpiresAt.Sub(claims.IssuedAt.Time); ttl != 72*time.Hour {
+		t.Fatalf("refresh token lives %v, want 72h", ttl)
+	}
+
+	t.Setenv("JWT_SECRET", "")
+	if _, _, err := GenerateRefreshToken("user-1", RoleDriver); !errors.Is(err, ErrSecretNotSet) {
+		t.Fatalf("error = %v, want %v", err, ErrSecretNotSet)
+	}
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// HeaderServiceToken carries service tokens, separate from the user's
+// Authorization header so one can never stand in for the other
+const HeaderServiceToken = "X-Service-Token"
+
+// ServiceTokenTTL is short because callers mint a token per request
+const ServiceTokenTTL = 5 * time.Minute
+
+const serviceAudience = "glovo-internal"
+
+var ErrInvalidServiceToken = errors.New("invalid service token")
+
+// ServiceClaims identify the internal service making a call
+type ServiceClaims struct {
+	Service string `json:"svc"`
+	jwt.RegisteredClaims
+}
+
+// serviceSecret is deliberately distinct from the user JWT secret, and
+// like it has no default
+func serviceSecret() ([]byte, error) {
+	secret := os.Getenv("SERVICE_TOKEN_SECRET")
+	if secret == "" {
+		return nil, fmt.Errorf("%w: SERVICE_TOKEN_SECRET", ErrSecretNotSet)
+	}
+	return []byte(secret), nil
+}
+
+// GenerateServiceToken signs a token identifying the calling service
+func GenerateServiceToken(service string) (string, error) {
+	if service == "" {
+		return "", errors.New("service name is required")
+	}
+
+	now := time.Now()
+	claims := ServiceClaims{
+		Service: service,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ServiceTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    service,
+			Audience:  jwt.ClaimStrings{serviceAudience},
+			ID:        uuid.New().String(),
+		},
+	}
+	secret, err := serviceSecret()
+	if err != nil {
+		return "", err
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+}
+
+// ValidateServiceToken checks a service token's signature, expiry (within the
+// clock-skew leeway) and audience
+func ValidateServiceToken(tokenString string) (*ServiceClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ServiceClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return serviceSecret()
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(serviceAudience), jwt.WithLeeway(Leeway()))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*ServiceClaims)
+	if !ok || !token.Valid || claims.Service == "" {
+		return nil, ErrInvalidServiceToken
+	}
+	return claims, nil
+}
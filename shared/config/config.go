@@ -0,0 +1,191 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type a value must parse as
+type Kind int
+
+const (
+	String Kind = iota
+	Int
+	Bool
+	Duration
+	Port
+	URL
+	Float
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Int:
+		return "an integer"
+	case Bool:
+		return "a boolean"
+	case Duration:
+		return "a duration like 30s or 5m"
+	case Port:
+		return "a port between 1 and 65535"
+	case URL:
+		return "an absolute URL"
+	case Float:
+		return "a number"
+	default:
+		return "a string"
+	}
+}
+
+// Var declares one environment variable. Variables with a Default are
+// optional; Required variables without one must be set.
+type Var struct {
+	Key       string
+	Kind      Kind
+	Required  bool
+	Default   string
+	OneOf     []string // allowed values, when set
+	MinLength int      // for secrets
+}
+
+// Config holds the validated values
+type Config struct {
+	values map[string]string
+}
+
+// ValidationError lists every problem found, so one boot reports them all
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load reads and validates the declared variables from the environment
+func Load(groups ...[]Var) (*Config, error) {
+	cfg := &Config{values: make(map[string]string)}
+	var problems []string
+
+	for _, group := range groups {
+		for _, v := range group {
+			value, set := os.LookupEnv(v.Key)
+			value = strings.TrimSpace(value)
+			if !set || value == "" {
+				if v.Default == "" {
+					if v.Required {
+						problems = append(problems, fmt.Sprintf("%s is required", v.Key))
+					}
+					continue
+				}
+				value = v.Default
+			}
+
+			if problem := v.check(value); problem != "" {
+				problems = append(problems, problem)
+				continue
+			}
+			cfg.values[v.Key] = value
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// MustLoad is Load for service startup: it exits with the aggregated error
+func MustLoad(service string, groups ...[]Var) *Config {
+	cfg, err := Load(groups...)
+	if err != nil {
+		log.Fatalf("%s failed to start: %v", service, err)
+	}
+	return cfg
+}
+
+func (v Var) check(value string) string {
+	invalid := func() string {
+		return fmt.Sprintf("%s must be %s, got %q", v.Key, v.Kind, value)
+	}
+
+	switch v.Kind {
+	case Int:
+		if _, err := strconv.Atoi(value); err != nil {
+			return invalid()
+		}
+	case Bool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return invalid()
+		}
+	case Duration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return invalid()
+		}
+	case Port:
+		if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+			return invalid()
+		}
+	case URL:
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			return invalid()
+		}
+	case Float:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return invalid()
+		}
+	}
+
+	if len(v.OneOf) > 0 {
+		allowed := false
+		for _, option := range v.OneOf {
+			if value == option {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Sprintf("%s must be one of %s, got %q", v.Key, strings.Join(v.OneOf, ", "), value)
+		}
+	}
+
+	if v.MinLength > 0 && len(value) < v.MinLength {
+		// Never echo secrets
+		return fmt.Sprintf("%s must be at least %d characters", v.Key, v.MinLength)
+	}
+	return ""
+}
+
+// String returns a validated value, or "" for unset optional variables
+func (c *Config) String(key string) string {
+	return c.values[key]
+}
+
+// Int returns a validated Int or Port value
+func (c *Config) Int(key string) int {
+	value, _ := strconv.Atoi(c.values[key])
+	return value
+}
+
+// Bool returns a validated Bool value
+func (c *Config) Bool(key string) bool {
+	value, _ := strconv.ParseBool(c.values[key])
+	return value
+}
+
+// Float returns a validated Float value
+func (c *Config) Float(key string) float64 {
+	value, _ := strconv.ParseFloat(c.values[key], 64)
+	return value
+}
+
+// Duration returns a validated Duration value
+func (c *Config) Duration(key string) time.Duration {
+	value, _ := time.ParseDuration(c.values[key])
+	return value
+}
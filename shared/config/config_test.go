@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadValidConfig(t *testing.T) {
+	t.Setenv("DB_HOST", "postgres")
+	t.Setenv("DB_PORT", "")
+	t.Setenv("DB_USER", "glovo")
+	t.Setenv("DB_PASSWORD", "secret")
+	t.Setenv("DB_NAME", "orders")
+	t.Setenv("ORDER_TIMEOUT", " 90s ")
+	t.Setenv("SURGE_CAP", "2.5")
+	t.Setenv("WEBHOOK_URL", "https://hooks.glovo.test/orders")
+	t.Setenv("FEATURE_ENABLED", "true")
+	t.Setenv("OPTIONAL_NOTE", "")
+
+	cfg, err := Load(Postgres(), []Var{
+		{Key: "ORDER_TIMEOUT", Kind: Duration, Required: true},
+		{Key: "SURGE_CAP", Kind: Float, Default: "3"},
+		{Key: "WEBHOOK_URL", Kind: URL},
+		{Key: "FEATURE_ENABLED", Kind: Bool},
+		{Key: "OPTIONAL_NOTE"},
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if got := cfg.String("DB_HOST"); got != "postgres" {
+		t.Fatalf("DB_HOST = %q, want postgres", got)
+	}
+	if got := cfg.Int("DB_PORT"); got != 5432 {
+		t.Fatalf("DB_PORT = %d, want the default 5432", got)
+	}
+	if got := cfg.Duration("ORDER_TIMEOUT"); got != 90*time.Second {
+		t.Fatalf("ORDER_TIMEOUT = %v, want 90s", got)
+	}
+	if got := cfg.Float("SURGE_CAP"); got != 2.5 {
+		t.Fatalf("SURGE_CAP = %v, want 2.5", got)
+	}
+	if !cfg.Bool("FEATURE_ENABLED") {
+		t.Fatalf("FEATURE_ENABLED = false, want true")
+	}
+	if got := cfg.String("OPTIONAL_NOTE"); got != "" {
+		t.Fatalf("OPTIONAL_NOTE = %q, want unset", got)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	t.Setenv("DB_HOST", "")
+	t.Setenv("DB_PORT", "70000")
+	t.Setenv("DB_USER", "glovo")
+	t.Setenv("DB_PASSWORD", "secret")
+	t.Setenv("DB_NAME", "")
+	t.Setenv("JWT_SECRET", "too-short-secret")
+	t.Setenv("SERVICE_TOKEN_SECRET", strings.Repeat("s", 32))
+	t.Setenv("JWT_ACCESS_TTL", "a day")
+	t.Setenv("JWT_REFRESH_TTL", "")
+	t.Setenv("JWT_LEEWAY", "")
+	t.Setenv("EVENT_BUS", "kafka")
+
+	_, err := Load(Postgres(), Auth(), EventBus())
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("error = %v, want a *ValidationError", err)
+	}
+
+	want := []string{
+		"DB_HOST is required",
+		`DB_PORT must be a port between 1 and 65535, got "70000"`,
+		"DB_NAME is required",
+		"JWT_SECRET must be at least 32 characters",
+		`JWT_ACCESS_TTL must be a duration like 30s or 5m, got "a day"`,
+		`EVENT_BUS must be one of memory, redis, got "kafka"`,
+	}
+	if !reflect.DeepEqual(validation.Problems, want) {
+		t.Fatalf("problems = %q, want %q", validation.Problems, want)
+	}
+	if strings.Contains(err.Error(), "too-short-secret") {
+		t.Fatalf("error leaks a secret: %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "invalid configuration:\n  - DB_HOST is required\n") {
+		t.Fatalf("error = %q", err.Error())
+	}
+}
+
+func TestVarCheck(t *testing.T) {
+	tests := []struct {
+		v     Var
+		value string
+		ok    bool
+	}{
+		{v: Var{Key: "N", Kind: Int}, value: "12", ok: true},
+		{v: Var{Key: "N", Kind: Int}, value: "1.5"},
+		{v: Var{Key: "B", Kind: Bool}, value: "yes"},
+		{v: Var{Key: "P", Kind: Port}, value: "0"},
+		{v: Var{Key: "P", Kind: Port}, value: "8080", ok: true},
+		{v: Var{Key: "U", Kind: URL}, value: "localhost:8080"},
+		{v: Var{Key: "U", Kind: URL}, value: "http://localhost:8080", ok: true},
+		{v: Var{Key: "F", Kind: Float}, value: "cheap"},
+	}
+
+	for _, tt := range tests {
+		if problem := tt.v.check(tt.value); (problem == "") != tt.ok {
+			t.Fatalf("%s %q: problem = %q, want ok = %v", tt.v.Key, tt.value, problem, tt.ok)
+		}
+	}
+}
+
+func TestEventBusRequiresRedis(t *testing.T) {
+	t.Setenv("EVENT_BUS", "redis")
+	t.Setenv("REDIS_HOST", "")
+	t.Setenv("REDIS_PORT", "")
+
+	_, err := Load(EventBus())
+	if err == nil || !strings.Contains(err.Error(), "REDIS_HOST is required") {
+		t.Fatalf("error = %v, want REDIS_HOST is required", err)
+	}
+}
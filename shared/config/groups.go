@@ -0,0 +1,61 @@
+package config
+
+import "os"
+
+// Postgres declares the variables database.ConnectPostgres reads
+func Postgres() []Var {
+	return []Var{
+		{Key: "DB_HOST", Required: true},
+		{Key: "DB_PORT", Kind: Port, Default: "5432"},
+		{Key: "DB_USER", Required: true},
+		{Key: "DB_PASSWORD", Required: true},
+		{Key: "DB_NAME", Required: true},
+	}
+}
+
+// Redis declares the variables database.ConnectRedis reads
+func Redis() []Var {
+	return []Var{
+		{Key: "REDIS_HOST", Required: true},
+		{Key: "REDIS_PORT", Kind: Port, Default: "6379"},
+		{Key: "REDIS_PASSWORD"},
+	}
+}
+
+// Mongo declares the variables database.ConnectMongoDB reads
+func Mongo() []Var {
+	return []Var{
+		{Key: "MONGO_HOST", Required: true},
+		{Key: "MONGO_PORT", Kind: Port, Default: "27017"},
+		{Key: "MONGO_USER"},
+		{Key: "MONGO_PASSWORD"},
+		{Key: "MONGO_DB", Required: true},
+	}
+}
+
+// Auth declares the signing secrets for user JWTs and service tokens, the
+// token lifetimes and the clock-skew leeway allowed when validating them
+func Auth() []Var {
+	return []Var{
+		{Key: "JWT_SECRET", Required: true, MinLength: 32},
+		{Key: "SERVICE_TOKEN_SECRET", Required: true, MinLength: 32},
+		{Key: "JWT_ACCESS_TTL", Kind: Duration, Default: "24h"},
+		{Key: "JWT_REFRESH_TTL", Kind: Duration, Default: "720h"},
+		{Key: "JWT_LEEWAY", Kind: Duration, Default: "30s"},
+	}
+}
+
+// EventBus declares the events.FromEnv settings; the Redis bus also needs
+// the Redis connection
+func EventBus() []Var {
+	vars := []Var{{Key: "EVENT_BUS", Default: "memory", OneOf: []string{"memory", "redis"}}}
+	if os.Getenv("EVENT_BUS") == "redis" {
+		vars = append(vars, Redis()...)
+	}
+	return vars
+}
+
+// HTTPPort declares the port a service listens on
+func HTTPPort(key, defaultPort string) []Var {
+	return []Var{{Key: key, Kind: Port, Default: defaultPort}}
+}
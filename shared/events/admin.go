@@ -0,0 +1,24 @@
+package events
+
+import "time"
+
+// Admin events, published by every service
+const (
+	ImpersonatedRequest = "admin.impersonated_request"
+)
+
+// ImpersonatedRequestPayload describes a request an admin made while
+// impersonating a user, for the admin service's audit log
+type ImpersonatedRequestPayload struct {
+	AdminID    string    `json:"admin_id"`
+	UserID     string    `json:"user_id"` // the impersonated user
+	Role       string    `json:"role"`
+	Service    string    `json:"service"` // the service that handled the request
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	TokenID    string    `json:"token_id"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
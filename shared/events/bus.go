@@ -0,0 +1,121 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"glovo-backend/shared/database"
+
+	"github.com/google/uuid"
+)
+
+// Event is the envelope every published event travels in
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Decode unmarshals the payload into v
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Handler processes one event. Returned errors are logged; they don't stop
+// delivery to other subscribers.
+type Handler func(ctx context.Context, event Event) error
+
+// Bus publishes events to the subscribers of their type
+type Bus interface {
+	Publish(ctx context.Context, eventType string, payload interface{}) error
+	Subscribe(eventType string, handler Handler) error
+	Close() error
+}
+
+func newEvent(eventType string, payload interface{}) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+	return Event{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		OccurredAt: time.Now(),
+		Payload:    data,
+	}, nil
+}
+
+// dispatch runs handler, recovering panics so one subscriber can't take the
+// others down
+func dispatch(ctx context.Context, handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event subscriber for %s panicked: %v", event.Type, r)
+		}
+	}()
+	if err := handler(ctx, event); err != nil {
+		log.Printf("Event subscriber for %s failed on event %s: %v", event.Type, event.ID, err)
+	}
+}
+
+type inMemoryBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	wg       sync.WaitGroup
+}
+
+// NewInMemoryBus delivers events to subscribers in the same process, each in
+// its own goroutine
+func NewInMemoryBus() Bus {
+	return &inMemoryBus{handlers: make(map[string][]Handler)}
+}
+
+func (b *inMemoryBus) Publish(ctx context.Context, eventType string, payload interface{}) error {
+	event, err := newEvent(eventType, payload)
+	if err != nil {
+		return err
+	}
+
+	b.mu.RLock()
+	handlers := append([]Handler(nil), b.handlers[eventType]...)
+	b.mu.RUnlock()
+
+	// Subscribers outlive the publishing request
+	ctx = context.WithoutCancel(ctx)
+	for _, handler := range handlers {
+		b.wg.Add(1)
+		go func(handler Handler) {
+			defer b.wg.Done()
+			dispatch(ctx, handler, event)
+		}(handler)
+	}
+	return nil
+}
+
+func (b *inMemoryBus) Subscribe(eventType string, handler Handler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+	return nil
+}
+
+// Close waits for in-flight handlers to finish
+func (b *inMemoryBus) Close() error {
+	b.wg.Wait()
+	return nil
+}
+
+// FromEnv returns the bus selected by EVENT_BUS: "redis" connects to Redis so
+// events cross service boundaries; anything else uses the in-memory bus
+func FromEnv() Bus {
+	if os.Getenv("EVENT_BUS") == "redis" {
+		return NewRedisBus(database.ConnectRedis())
+	}
+	return NewInMemoryBus()
+}
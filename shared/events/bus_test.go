@@ -0,0 +1,78 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type testPayload struct {
+	OrderID string `json:"order_id"`
+}
+
+func TestInMemoryBusDelivers(t *testing.T) {
+	bus := NewInMemoryBus()
+
+	var mu sync.Mutex
+	var received []string
+	record := func(name string) Handler {
+		return func(ctx context.Context, event Event) error {
+			if ctx.Err() != nil {
+				t.Errorf("%s got a cancelled context", name)
+			}
+			var payload testPayload
+			if err := event.Decode(&payload); err != nil {
+				t.Errorf("%s: Decode: %v", name, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, name+":"+payload.OrderID)
+			return nil
+		}
+	}
+
+	// Failing and panicking subscribers sit between the healthy ones
+	bus.Subscribe(DeliveryCompleted, record("first"))
+	bus.Subscribe(DeliveryCompleted, func(ctx context.Context, event Event) error {
+		return errors.New("subscriber failed")
+	})
+	bus.Subscribe(DeliveryCompleted, func(ctx context.Context, event Event) error {
+		panic("subscriber panicked")
+	})
+	bus.Subscribe(DeliveryCompleted, record("second"))
+	bus.Subscribe(DeliveryCancelled, record("other type"))
+
+	// Subscribers outlive the publishing request's context
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := bus.Publish(ctx, DeliveryCompleted, testPayload{OrderID: "order-1"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	cancel()
+	bus.Close()
+
+	if len(received) != 2 {
+		t.Fatalf("received %v, want first and second", received)
+	}
+	got := map[string]bool{received[0]: true, received[1]: true}
+	if !got["first:order-1"] || !got["second:order-1"] {
+		t.Fatalf("received %v, want first and second", received)
+	}
+}
+
+func TestInMemoryBusRejectsUnencodablePayload(t *testing.T) {
+	bus := NewInMemoryBus()
+	called := false
+	bus.Subscribe(DeliveryCompleted, func(ctx context.Context, event Event) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(context.Background(), DeliveryCompleted, make(chan int)); err == nil {
+		t.Fatalf("Publish of a channel succeeded")
+	}
+	bus.Close()
+	if called {
+		t.Fatalf("subscriber ran for an unpublished event")
+	}
+}
@@ -0,0 +1,30 @@
+package events
+
+import "time"
+
+// Catalog service events
+const (
+	StoreReviewed = "store.reviewed"
+	StoreRated    = "store.rated"
+)
+
+// StoreReviewedPayload is published when an admin approves or rejects a new store
+type StoreReviewedPayload struct {
+	StoreID    string    `json:"store_id"`
+	MerchantID string    `json:"merchant_id"`
+	StoreName  string    `json:"store_name"`
+	Approved   bool      `json:"approved"`
+	Reason     string    `json:"reason,omitempty"` // why the store was rejected
+	ReviewedAt time.Time `json:"reviewed_at"`
+}
+
+// StoreRatedPayload is published when a customer rates a store for one of
+// their delivered orders
+type StoreRatedPayload struct {
+	ReviewID   string    `json:"review_id"`
+	StoreID    string    `json:"store_id"`
+	MerchantID string    `json:"merchant_id"`
+	OrderID    string    `json:"order_id"`
+	Rating     int       `json:"rating"` // 1 to 5
+	RatedAt    time.Time `json:"rated_at"`
+}
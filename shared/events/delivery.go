@@ -0,0 +1,53 @@
+package events
+
+import "time"
+
+// Delivery service events
+const (
+	DeliveryAssigned  = "delivery.assigned"
+	DeliveryCompleted = "delivery.completed"
+	DeliveryCancelled = "delivery.cancelled"
+)
+
+// DeliveryAssignedPayload is published whenever a driver is assigned to a
+// delivery, including reassignments
+type DeliveryAssignedPayload struct {
+	DeliveryID      string    `json:"delivery_id"`
+	OrderID         string    `json:"order_id"`
+	DriverID        string    `json:"driver_id"`
+	DriverPhone     string    `json:"driver_phone,omitempty"`
+	PickupAddress   string    `json:"pickup_address"`
+	DeliveryAddress string    `json:"delivery_address"`
+	DeliveryFee     float64   `json:"delivery_fee"`
+	Distance        float64   `json:"distance"`       // in kilometers
+	EstimatedTime   int       `json:"estimated_time"` // in minutes
+	Contactless     bool      `json:"contactless,omitempty"`
+	DropLocation    string    `json:"drop_location,omitempty"`
+	CallOnArrival   bool      `json:"call_on_arrival,omitempty"`
+	AssignedAt      time.Time `json:"assigned_at"`
+}
+
+type DeliveryCompletedPayload struct {
+	DeliveryID      string    `json:"delivery_id"`
+	OrderID         string    `json:"order_id"`
+	CustomerID      string    `json:"customer_id,omitempty"`
+	MerchantID      string    `json:"merchant_id,omitempty"`
+	DriverID        string    `json:"driver_id"`
+	DeliveryFee     float64   `json:"delivery_fee"`
+	Distance        float64   `json:"distance"` // in kilometers
+	SurgeMultiplier float64   `json:"surge_multiplier,omitempty"`
+	Tip             float64   `json:"tip,omitempty"`
+	DeliveredAt     time.Time `json:"delivered_at"`
+}
+
+type DeliveryCancelledPayload struct {
+	DeliveryID         string    `json:"delivery_id"`
+	OrderID            string    `json:"order_id"`
+	CustomerID         string    `json:"customer_id,omitempty"`
+	MerchantID         string    `json:"merchant_id,omitempty"`
+	DriverID           string    `json:"driver_id,omitempty"`
+	Reason             string    `json:"reason,omitempty"`
+	CancellationFee    float64   `json:"cancellation_fee,omitempty"`    // to charge the customer
+	DriverCompensation float64   `json:"driver_compensation,omitempty"` // to pay the driver
+	CancelledAt        time.Time `json:"cancelled_at"`
+}
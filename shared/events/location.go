@@ -0,0 +1,20 @@
+package events
+
+import "time"
+
+// Location service events
+const (
+	DeliveryETAChanged = "delivery.eta_changed"
+)
+
+// DeliveryETAChangedPayload is published when the driver's progress moves a
+// delivery's estimated arrival by more than the location service's threshold
+type DeliveryETAChangedPayload struct {
+	OrderID           string     `json:"order_id"`
+	DriverID          string     `json:"driver_id"`
+	EstimatedArrival  time.Time  `json:"estimated_arrival"`
+	PreviousArrival   *time.Time `json:"previous_arrival,omitempty"`
+	RemainingTime     int        `json:"remaining_time"`     // in minutes
+	RemainingDistance float64    `json:"remaining_distance"` // in kilometers
+	CalculatedAt      time.Time  `json:"calculated_at"`
+}
@@ -0,0 +1,38 @@
+package events
+
+import "time"
+
+// Order service events
+const (
+	OrderStatusChanged = "order.status_changed"
+)
+
+// OrderItemSnapshot is one line of an order as it was placed
+type OrderItemSnapshot struct {
+	ProductID string  `json:"product_id"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"` // unit price including option surcharges
+	Quantity  int     `json:"quantity"`
+}
+
+// OrderStatusChangedPayload carries the whole order after it is placed and
+// after every status change, so consumers can keep their own copy by
+// upserting it. ChangedAt orders snapshots of the same order.
+type OrderStatusChangedPayload struct {
+	OrderID       string              `json:"order_id"`
+	CustomerID    string              `json:"customer_id"`
+	MerchantID    string              `json:"merchant_id"`
+	DriverID      string              `json:"driver_id,omitempty"`
+	Status        string              `json:"status"`
+	Items         []OrderItemSnapshot `json:"items"`
+	TotalAmount   float64             `json:"total_amount"` // item subtotal, paid to the merchant
+	DeliveryFee   float64             `json:"delivery_fee"`
+	ServiceFee    float64             `json:"service_fee"`
+	TaxAmount     float64             `json:"tax_amount"`
+	FinalAmount   float64             `json:"final_amount"`             // charged to the customer
+	EstimatedTime *int                `json:"estimated_time,omitempty"` // in minutes
+	PlacedAt      time.Time           `json:"placed_at"`
+	CompletedAt   *time.Time          `json:"completed_at,omitempty"`
+	CancelledAt   *time.Time          `json:"cancelled_at,omitempty"`
+	ChangedAt     time.Time           `json:"changed_at"`
+}
@@ -0,0 +1,17 @@
+package events
+
+import "time"
+
+// Payment service events
+const (
+	PayoutCompleted = "payout.completed"
+)
+
+type PayoutCompletedPayload struct {
+	TransactionID string    `json:"transaction_id"`
+	UserID        string    `json:"user_id"`
+	UserRole      string    `json:"user_role"` // merchant or driver
+	Amount        float64   `json:"amount"`
+	Currency      string    `json:"currency"`
+	CompletedAt   time.Time `json:"completed_at"`
+}
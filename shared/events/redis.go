@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const channelPrefix = "events:"
+
+type redisBus struct {
+	client *redis.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	subs   []*redis.PubSub
+}
+
+// NewRedisBus publishes events over Redis pub/sub. Delivery is at most once:
+// subscribers that are down when an event is published miss it.
+func NewRedisBus(client *redis.Client) Bus {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &redisBus{client: client, ctx: ctx, cancel: cancel}
+}
+
+func (b *redisBus) Publish(ctx context.Context, eventType string, payload interface{}) error {
+	event, err := newEvent(eventType, payload)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, channelPrefix+eventType, data).Err()
+}
+
+func (b *redisBus) Subscribe(eventType string, handler Handler) error {
+	sub := b.client.Subscribe(b.ctx, channelPrefix+eventType)
+	// Wait for the subscription to be confirmed so no events are missed after
+	// Subscribe returns
+	if _, err := sub.Receive(b.ctx); err != nil {
+		sub.Close()
+		return err
+	}
+
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+
+	go func() {
+		for msg := range sub.Channel() {
+			var event Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				log.Printf("Dropping malformed event on %s: %v", msg.Channel, err)
+				continue
+			}
+			dispatch(b.ctx, handler, event)
+		}
+	}()
+	return nil
+}
+
+func (b *redisBus) Close() error {
+	b.cancel()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subs {
+		sub.Close()
+	}
+	return nil
+}
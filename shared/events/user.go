@@ -0,0 +1,14 @@
+package events
+
+import "time"
+
+// User service events
+const (
+	UserRegistered = "user.registered"
+)
+
+type UserRegisteredPayload struct {
+	UserID       string    `json:"user_id"`
+	Role         string    `json:"role"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
@@ -0,0 +1,45 @@
+// Package geo holds the geometry services use to reason about delivery
+// areas: distances and point-in-polygon tests on WGS84 coordinates.
+package geo
+
+import "math"
+
+// Point is a WGS84 coordinate
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+const earthRadiusKm = 6371
+
+// DistanceKm returns the haversine great-circle distance between a and b
+func DistanceKm(a, b Point) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	deltaLat := (b.Latitude - a.Latitude) * math.Pi / 180
+	deltaLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(h), math.Sqrt(1-h))
+}
+
+// InPolygon reports whether p lies inside the polygon ring, using ray casting
+// on latitude and longitude, which is accurate enough for city-sized areas.
+// The ring may or may not repeat its first vertex at the end; rings with
+// fewer than three vertices contain nothing.
+func InPolygon(p Point, ring []Point) bool {
+	if len(ring) < 3 {
+		return false
+	}
+
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Latitude > p.Latitude) != (b.Latitude > p.Latitude) &&
+			p.Longitude < (b.Longitude-a.Longitude)*(p.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+	return inside
+}
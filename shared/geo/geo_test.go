@@ -0,0 +1,50 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	madrid := Point{Latitude: 40.4168, Longitude: -3.7038}
+	barcelona := Point{Latitude: 41.3874, Longitude: 2.1686}
+
+	if d := DistanceKm(madrid, madrid); d != 0 {
+		t.Fatalf("distance to itself = %v, want 0", d)
+	}
+	// About 505 km as the crow flies
+	if d := DistanceKm(madrid, barcelona); math.Abs(d-505) > 5 {
+		t.Fatalf("Madrid to Barcelona = %.1f km, want about 505", d)
+	}
+	if DistanceKm(madrid, barcelona) != DistanceKm(barcelona, madrid) {
+		t.Fatalf("distance is not symmetric")
+	}
+}
+
+func TestInPolygon(t *testing.T) {
+	square := []Point{{0, 0}, {0, 10}, {10, 10}, {10, 0}}
+	// An L shape: the square minus its top-right quarter
+	lShape := []Point{{0, 0}, {0, 10}, {5, 10}, {5, 5}, {10, 5}, {10, 0}}
+
+	tests := []struct {
+		name  string
+		point Point
+		ring  []Point
+		want  bool
+	}{
+		{name: "inside", point: Point{5, 5}, ring: square, want: true},
+		{name: "outside", point: Point{15, 5}, ring: square, want: false},
+		{name: "closed ring", point: Point{5, 5}, ring: append(square, square[0]), want: true},
+		{name: "inside the L", point: Point{2, 8}, ring: lShape, want: true},
+		{name: "in the L's notch", point: Point{8, 8}, ring: lShape, want: false},
+		{name: "too few vertices", point: Point{0, 0}, ring: square[:2], want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InPolygon(tt.point, tt.ring); got != tt.want {
+				t.Fatalf("InPolygon = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
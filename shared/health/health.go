@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+)
+
+// checkTimeout bounds each dependency ping so a hung database can't hang the probe
+const checkTimeout = 2 * time.Second
+
+// Check pings a dependency and returns an error if it's unreachable
+type Check func(ctx context.Context) error
+
+func Postgres(db *gorm.DB) Check {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+func Redis(client *redis.Client) Check {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+func Mongo(db *mongo.Database) Check {
+	return func(ctx context.Context) error {
+		return db.Client().Ping(ctx, nil)
+	}
+}
+
+// Ready is a readiness probe that runs every check and responds 503 naming
+// the failing dependencies; /health remains the liveness probe
+func Ready(service string, checks map[string]Check) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		results := make(map[string]string, len(checks))
+		healthy := true
+
+		for name, check := range checks {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+			err := check(ctx)
+			cancel()
+
+			if err != nil {
+				healthy = false
+				results[name] = err.Error()
+			} else {
+				results[name] = "ok"
+			}
+		}
+
+		if !healthy {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":       "unavailable",
+				"service":      service,
+				"dependencies": results,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":       "ready",
+			"service":      service,
+			"dependencies": results,
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// stubDriver opens connections to the "up" database and refuses any other,
+// standing in for a reachable and an unreachable Postgres
+type stubDriver struct{}
+
+func (stubDriver) Open(name string) (driver.Conn, error) {
+	if name != "up" {
+		return nil, errors.New("connection refused")
+	}
+	return stubConn{}, nil
+}
+
+type stubConn struct{}
+
+func (stubConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (stubConn) Close() error                              { return nil }
+func (stubConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+func (stubConn) Ping(ctx context.Context) error            { return nil }
+
+func init() {
+	sql.Register("health-stub", stubDriver{})
+}
+
+func stubPostgres(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	sqlDB, err := sql.Open("health-stub", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	return db
+}
+
+// closedAddr returns a local address that refuses connections
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func TestReady(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	brokenRedis := redis.NewClient(&redis.Options{Addr: closedAddr(t), MaxRetries: -1})
+	defer brokenRedis.Close()
+
+	tests := []struct {
+		name       string
+		checks     map[string]Check
+		wantStatus int
+		wantFailed []string
+	}{
+		{
+			name:       "database up",
+			checks:     map[string]Check{"postgres": Postgres(stubPostgres(t, "up"))},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "database down",
+			checks:     map[string]Check{"postgres": Postgres(stubPostgres(t, "down"))},
+			wantStatus: http.StatusServiceUnavailable,
+			wantFailed: []string{"postgres"},
+		},
+		{
+			name:       "one of two down",
+			checks:     map[string]Check{"postgres": Postgres(stubPostgres(t, "up")), "redis": Redis(brokenRedis)},
+			wantStatus: http.StatusServiceUnavailable,
+			wantFailed: []string{"redis"},
+		},
+		{
+			name:       "no dependencies",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/health/ready", Ready("payment-service", tt.checks))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var body struct {
+				Status       string            `json:"status"`
+				Service      string            `json:"service"`
+				Dependencies map[string]string `json:"dependencies"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Service != "payment-service" || len(body.Dependencies) != len(tt.checks) {
+				t.Fatalf("body = %+v", body)
+			}
+
+			failed := map[string]bool{}
+			for _, name := range tt.wantFailed {
+				failed[name] = true
+			}
+			for name, result := range body.Dependencies {
+				if (result != "ok") != failed[name] {
+					t.Fatalf("%s = %q, want failed %v", name, result, failed[name])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package httpclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	b := newBreaker(3, time.Minute)
+
+	// Failures below the threshold, or broken by a success, keep it closed
+	b.record(false, now)
+	b.record(false, now)
+	b.record(true, now)
+	b.record(false, now)
+	b.record(false, now)
+	if !b.allow(now) {
+		t.Fatalf("breaker opened before %d consecutive failures", 3)
+	}
+
+	b.record(false, now)
+	if b.allow(now.Add(59 * time.Second)) {
+		t.Fatalf("open breaker let a call through")
+	}
+
+	// After the open period a single trial call is let through
+	trial := now.Add(time.Minute)
+	if !b.allow(trial) {
+		t.Fatalf("no trial call after the open period")
+	}
+	if b.allow(trial) {
+		t.Fatalf("second call allowed while the trial is in flight")
+	}
+
+	// A failed trial reopens it straight away
+	b.record(false, trial)
+	if b.allow(trial.Add(30 * time.Second)) {
+		t.Fatalf("breaker didn't reopen after a failed trial")
+	}
+
+	// A successful trial closes it
+	trial = trial.Add(time.Minute)
+	if !b.allow(trial) {
+		t.Fatalf("no trial call after the second open period")
+	}
+	b.record(true, trial)
+	for i := 0; i < 3; i++ {
+		if !b.allow(trial) {
+			t.Fatalf("breaker still open after a successful trial")
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"glovo-backend/shared/auth"
+	"glovo-backend/shared/logging"
+)
+
+// ErrCircuitOpen is returned without calling the upstream while its breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Config tunes timeouts, retries and the circuit breaker
+type Config struct {
+	Timeout          time.Duration // per attempt
+	MaxRetries       int           // extra attempts for idempotent requests
+	RetryBackoff     time.Duration // base delay, doubled per attempt with jitter
+	FailureThreshold int           // consecutive failures that open the breaker
+	OpenDuration     time.Duration // how long the breaker stays open
+	// ServiceName, when set, signs each request with a service token so it
+	// can reach internal endpoints guarded by middleware.ServiceAuth
+	ServiceName string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:          5 * time.Second,
+		MaxRetries:       2,
+		RetryBackoff:     100 * time.Millisecond,
+		FailureThreshold: 5,
+		OpenDuration:     30 * time.Second,
+	}
+}
+
+// Client wraps http.Client for inter-service calls. Each Client has its own
+// breaker, so use one Client per upstream service.
+type Client struct {
+	http    *http.Client
+	config  Config
+	breaker *breaker
+}
+
+func New(config Config) *Client {
+	if config.FailureThreshold < 1 {
+		config.FailureThreshold = 1
+	}
+	return &Client{
+		http:    &http.Client{Timeout: config.Timeout},
+		config:  config,
+		breaker: newBreaker(config.FailureThreshold, config.OpenDuration),
+	}
+}
+
+func (c *Client) Get(url string) (*http.Response, error) {
+	return c.GetContext(context.Background(), url)
+}
+
+// GetContext is Get with a context; a request ID in ctx is forwarded
+func (c *Client) GetContext(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+func (c *Client) Post(url, contentType string, body io.Reader) (*http.Response, error) {
+	return c.PostContext(context.Background(), url, contentType, body)
+}
+
+// PostContext is Post with a context; a request ID in ctx is forwarded
+func (c *Client) PostContext(ctx context.Context, url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.Do(req)
+}
+
+// Do sends the request through the breaker. Network errors and 5xx responses
+// count as failures; idempotent requests (GET, HEAD, PUT, DELETE) are retried
+// on failure, others are not so a payment is never submitted twice.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if requestID := logging.RequestIDFromContext(req.Context()); requestID != "" && req.Header.Get(logging.HeaderRequestID) == "" {
+		req.Header.Set(logging.HeaderRequestID, requestID)
+	}
+	if c.config.ServiceName != "" {
+		token, err := auth.GenerateServiceToken(c.config.ServiceName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign service token: %w", err)
+		}
+		req.Header.Set(auth.HeaderServiceToken, token)
+	}
+
+	attempts := 1
+	if isIdempotent(req.Method) {
+		attempts += c.config.MaxRetries
+	}
+
+	// Buffer the body so each retry can resend it
+	var body []byte
+	if req.Body != nil && attempts > 1 {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(req.Context(), c.backoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		if !c.breaker.allow(time.Now()) {
+			return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Host, ErrCircuitOpen)
+		}
+
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		resp, err := c.http.Do(req)
+		failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+		c.breaker.record(!failed, time.Now())
+		if !failed {
+			return resp, nil
+		}
+
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if attempt == attempts-1 {
+			// Hand the final 5xx to the caller, which reports the status
+			return resp, nil
+		}
+		lastErr = fmt.Errorf("upstream returned status %d", resp.StatusCode)
+		resp.Body.Close()
+	}
+	return nil, lastErr
+}
+
+// backoff returns the delay before a retry: exponential with full jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	max := c.config.RetryBackoff << (attempt - 1)
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"glovo-backend/shared/auth"
+	"glovo-backend/shared/logging"
+)
+
+func TestClientBreakerOpensAndRecovers(t *testing.T) {
+	var mode atomic.Value // "slow", "failing" or "healthy"
+	mode.Store("slow")
+	var calls atomic.Int32
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch mode.Load() {
+		case "slow":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		case "failing":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			io.WriteString(w, "ok")
+		}
+	}))
+	defer server.Close()
+
+	client := New(Config{Timeout: 50 * time.Millisecond, FailureThreshold: 3, OpenDuration: 200 * time.Millisecond})
+
+	// A hung upstream times out instead of stalling the caller
+	start := time.Now()
+	if _, err := client.Get(server.URL); err == nil {
+		t.Fatalf("expected a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("call took %s despite a 50ms timeout", elapsed)
+	}
+
+	// Then it fails outright until the breaker opens
+	mode.Store("failing")
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+		}
+	}
+
+	before := calls.Load()
+	if _, err := client.Get(server.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected %v, got %v", ErrCircuitOpen, err)
+	}
+	if calls.Load() != before {
+		t.Fatalf("open breaker still called the upstream")
+	}
+
+	// Once the upstream recovers, the trial call closes the breaker
+	mode.Store("healthy")
+	time.Sleep(250 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("call after recovery: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		failures   int32 // 5xx responses before the upstream succeeds
+		wantCalls  int32
+		wantStatus int
+	}{
+		{name: "GET retried until it succeeds", method: http.MethodGet, failures: 2, wantCalls: 3, wantStatus: http.StatusOK},
+		{name: "GET gives up after the retries", method: http.MethodGet, failures: 5, wantCalls: 3, wantStatus: http.StatusBadGateway},
+		{name: "PUT resends its body", method: http.MethodPut, failures: 1, wantCalls: 2, wantStatus: http.StatusOK},
+		{name: "POST is never retried", method: http.MethodPost, failures: 1, wantCalls: 1, wantStatus: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != `{"amount":25}` {
+					t.Errorf("attempt %d got body %q", calls.Load()+1, body)
+				}
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client := New(Config{Timeout: time.Second, MaxRetries: 2, RetryBackoff: time.Millisecond, FailureThreshold: 10, OpenDuration: time.Minute})
+			req, err := http.NewRequest(tt.method, server.URL, strings.NewReader(`{"amount":25}`))
+			if err != nil {
+				t.Fatalf("NewRequest: %v", err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus || calls.Load() != tt.wantCalls {
+				t.Fatalf("status %d after %d calls, want %d after %d", resp.StatusCode, calls.Load(), tt.wantStatus, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClientForwardsHeaders(t *testing.T) {
+	t.Setenv("SERVICE_TOKEN_SECRET", "service-secret")
+	var requestID, service string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = r.Header.Get(logging.HeaderRequestID)
+		if claims, err := auth.ValidateServiceToken(r.Header.Get(auth.HeaderServiceToken)); err == nil {
+			service = claims.Service
+		}
+	}))
+	defer server.Close()
+
+	client := New(Config{Timeout: time.Second, ServiceName: "order-service"})
+	resp, err := client.GetContext(logging.WithRequestID(context.Background(), "req-42"), server.URL)
+	if err != nil {
+		t.Fatalf("GetContext: %v", err)
+	}
+	resp.Body.Close()
+
+	if requestID != "req-42" || service != "order-service" {
+		t.Fatalf("upstream saw request ID %q from %q", requestID, service)
+	}
+}
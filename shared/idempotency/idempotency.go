@@ -0,0 +1,121 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"glovo-backend/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderKey is the request header carrying the client's idempotency key
+const HeaderKey = "Idempotency-Key"
+
+// DefaultTTL is how long a response is replayed for duplicates
+const DefaultTTL = 24 * time.Hour
+
+const maxKeyLength = 255
+
+// Middleware replays the first response for requests that repeat an
+// Idempotency-Key on the same route, without invoking the handler again.
+// Keys are scoped by caller (the user, or the service for internal calls),
+// method and route. Reusing a key with a different body is rejected, as is a
+// duplicate that arrives while the first request is still running. Server
+// errors and panics are not cached, so the client can retry them. Requests
+// without the header pass through.
+func Middleware(store Store, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientKey := c.GetHeader(HeaderKey)
+		if clientKey == "" {
+			c.Next()
+			return
+		}
+		if len(clientKey) > maxKeyLength {
+			response.Error(c, response.CodeInvalidRequest, "Idempotency-Key is too long", nil)
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			response.BadRequest(c, err)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+		caller := c.GetString("user_id")
+		if caller == "" {
+			caller = c.GetString("service")
+		}
+		key := caller + ":" + c.Request.Method + ":" + c.FullPath() + ":" + clientKey
+
+		existing, started, err := store.Begin(key, fingerprint, ttl)
+		if err != nil {
+			response.FromError(c, err)
+			return
+		}
+		if !started {
+			replay(c, existing, fingerprint)
+			return
+		}
+
+		// A panicking handler would otherwise leave the key reserved until
+		// it expires; release it and let the recovery middleware respond
+		defer func() {
+			if r := recover(); r != nil {
+				store.Release(key)
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			store.Release(key)
+			return
+		}
+		if err := store.Complete(key, status, c.Writer.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			store.Release(key)
+		}
+	}
+}
+
+func replay(c *gin.Context, record *Record, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		response.Error(c, response.CodeUnprocessable, "Idempotency-Key was already used with a different request body", nil)
+		return
+	}
+	if !record.Completed {
+		response.Error(c, response.CodeConflict, "A request with this Idempotency-Key is still being processed", nil)
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(record.Status, record.ContentType, record.Body)
+	c.Abort()
+}
+
+// responseRecorder copies the response body while writing it through
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
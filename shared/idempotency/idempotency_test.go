@@ -0,0 +1,163 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRouter serves POST /deliveries with status, counting handler calls.
+// The X-User header stands in for the auth middleware.
+func newRouter(store Store, status int, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.Recovery(), func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-User"))
+	})
+	router.POST("/deliveries", Middleware(store, time.Minute), func(c *gin.Context) {
+		*calls++
+		if status == 0 {
+			panic("handler failed")
+		}
+		c.JSON(status, gin.H{"call": *calls})
+	})
+	return router
+}
+
+func post(router *gin.Engine, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/deliveries", strings.NewReader(body))
+	req.Header.Set("X-User", user)
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareReplaysDuplicates(t *testing.T) {
+	calls := 0
+	router := newRouter(NewMemoryStore(), http.StatusCreated, &calls)
+
+	first := post(router, "user-1", "key-1", `{"order_id":"o1"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", first.Code, http.StatusCreated, first.Body.String())
+	}
+
+	dup := post(router, "user-1", "key-1", `{"order_id":"o1"}`)
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if dup.Code != http.StatusCreated || dup.Body.String() != first.Body.String() {
+		t.Fatalf("replay = %d %s, want %d %s", dup.Code, dup.Body.String(), first.Code, first.Body.String())
+	}
+	if dup.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay is missing the Idempotent-Replayed header")
+	}
+	if got := dup.Header().Get("Content-Type"); got != first.Header().Get("Content-Type") {
+		t.Fatalf("replay content type = %q, want %q", got, first.Header().Get("Content-Type"))
+	}
+
+	// Keys are scoped per caller, and requests without a key aren't cached
+	if w := post(router, "user-2", "key-1", `{"order_id":"o1"}`); w.Code != http.StatusCreated || calls != 2 {
+		t.Fatalf("another user's request: status = %d, calls = %d, want %d, 2", w.Code, calls, http.StatusCreated)
+	}
+	post(router, "user-1", "", `{"order_id":"o1"}`)
+	post(router, "user-1", "", `{"order_id":"o1"}`)
+	if calls != 4 {
+		t.Fatalf("handler ran %d times, want 4", calls)
+	}
+}
+
+func TestMiddlewareRejectsMisuse(t *testing.T) {
+	body := `{"order_id":"o1"}`
+
+	t.Run("different body", func(t *testing.T) {
+		calls := 0
+		router := newRouter(NewMemoryStore(), http.StatusCreated, &calls)
+		post(router, "user-1", "key-1", body)
+
+		w := post(router, "user-1", "key-1", `{"order_id":"o2"}`)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusUnprocessableEntity, w.Body.String())
+		}
+		if calls != 1 {
+			t.Fatalf("handler ran %d times, want 1", calls)
+		}
+	})
+
+	t.Run("still in flight", func(t *testing.T) {
+		store := NewMemoryStore()
+		sum := sha256.Sum256([]byte(body))
+		if _, _, err := store.Begin("user-1:POST:/deliveries:key-1", hex.EncodeToString(sum[:]), time.Minute); err != nil {
+			t.Fatalf("Begin: %v", err)
+		}
+		calls := 0
+		router := newRouter(store, http.StatusCreated, &calls)
+
+		w := post(router, "user-1", "key-1", body)
+		if w.Code != http.StatusConflict {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body.String())
+		}
+		if calls != 0 {
+			t.Fatalf("handler ran %d times, want 0", calls)
+		}
+	})
+
+	t.Run("key too long", func(t *testing.T) {
+		calls := 0
+		router := newRouter(NewMemoryStore(), http.StatusCreated, &calls)
+
+		w := post(router, "user-1", strings.Repeat("k", maxKeyLength+1), body)
+		if w.Code != http.StatusBadRequest || calls != 0 {
+			t.Fatalf("status = %d, calls = %d, want %d, 0", w.Code, calls, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestMiddlewareRetriesFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int // 0 makes the handler panic
+	}{
+		{name: "server error", status: http.StatusInternalServerError},
+		{name: "panic", status: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			router := newRouter(NewMemoryStore(), tt.status, &calls)
+
+			for i := 0; i < 2; i++ {
+				if w := post(router, "user-1", "key-1", `{}`); w.Code != http.StatusInternalServerError {
+					t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+				}
+			}
+			if calls != 2 {
+				t.Fatalf("handler ran %d times, want 2", calls)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	if _, started, _ := store.Begin("k", "f", -time.Second); !started {
+		t.Fatalf("first Begin didn't reserve the key")
+	}
+	// The record is already expired, so the key can be reserved again
+	if _, started, _ := store.Begin("k", "f", time.Minute); !started {
+		t.Fatalf("Begin didn't reserve an expired key")
+	}
+	existing, started, _ := store.Begin("k", "f", time.Minute)
+	if started || existing == nil || existing.Completed {
+		t.Fatalf("Begin = %+v, %v, want the pending record", existing, started)
+	}
+}
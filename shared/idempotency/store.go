@@ -0,0 +1,152 @@
+package idempotency
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Record is a stored idempotency key. Until Completed, the first request is
+// still being processed.
+type Record struct {
+	Key         string `gorm:"primaryKey"`
+	Service     string `gorm:"primaryKey"`
+	Fingerprint string // hash of the request body
+	Completed   bool
+	Status      int
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time `gorm:"index"`
+	CreatedAt   time.Time
+}
+
+func (Record) TableName() string {
+	return "idempotency_keys"
+}
+
+// Store persists idempotency records
+type Store interface {
+	// Begin reserves key for a new request. If the key is already held and
+	// unexpired it returns the existing record and false instead.
+	Begin(key, fingerprint string, ttl time.Duration) (*Record, bool, error)
+	// Complete saves the response for a key reserved by Begin
+	Complete(key string, status int, contentType string, body []byte) error
+	// Release drops a reservation so the request can be retried
+	Release(key string) error
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+}
+
+// NewMemoryStore keeps records in process memory; use it for single-instance
+// services and development
+func NewMemoryStore() Store {
+	return &memoryStore{records: make(map[string]*Record)}
+}
+
+func (s *memoryStore) Begin(key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := s.records[key]; ok && now.Before(existing.ExpiresAt) {
+		copied := *existing
+		return &copied, false, nil
+	}
+
+	// Opportunistically drop expired records
+	for k, r := range s.records {
+		if !now.Before(r.ExpiresAt) {
+			delete(s.records, k)
+		}
+	}
+
+	s.records[key] = &Record{Key: key, Fingerprint: fingerprint, ExpiresAt: now.Add(ttl), CreatedAt: now}
+	return nil, true, nil
+}
+
+func (s *memoryStore) Complete(key string, status int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok {
+		return errors.New("idempotency key not reserved")
+	}
+	record.Completed = true
+	record.Status = status
+	record.ContentType = contentType
+	record.Body = body
+	return nil
+}
+
+func (s *memoryStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+type gormStore struct {
+	db      *gorm.DB
+	service string
+}
+
+// NewGormStore keeps records in the idempotency_keys table, shared by all
+// replicas of a service. Records are also keyed by service, so services
+// configured with the same database can't replay each other's responses.
+// Callers must migrate Record.
+func NewGormStore(db *gorm.DB, service string) Store {
+	return &gormStore{db: db, service: service}
+}
+
+func (s *gormStore) Begin(key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	now := time.Now()
+
+	// Clear an expired record for this key so it can be reserved again
+	if err := s.db.Where("key = ? AND service = ? AND expires_at <= ?", key, s.service, now).
+		Delete(&Record{}).Error; err != nil {
+		return nil, false, err
+	}
+
+	record := &Record{
+		Key:         key,
+		Service:     s.service,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+	}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return nil, true, nil
+	}
+
+	var existing Record
+	if err := s.db.Where("key = ? AND service = ?", key, s.service).First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+func (s *gormStore) Complete(key string, status int, contentType string, body []byte) error {
+	return s.db.Model(&Record{}).
+		Where("key = ? AND service = ?", key, s.service).
+		Updates(map[string]interface{}{
+			"completed":    true,
+			"status":       status,
+			"content_type": contentType,
+			"body":         body,
+		}).Error
+}
+
+func (s *gormStore) Release(key string) error {
+	return s.db.Where("key = ? AND service = ?", key, s.service).Delete(&Record{}).Error
+}
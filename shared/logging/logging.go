@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+// HeaderRequestID carries the request ID between services
+const HeaderRequestID = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// Setup makes structured JSON the default log output, tagging every line with
+// the service name. Output from the standard log package goes through it too.
+func Setup(service string) {
+	handler := slog.NewJSONHandler(os.Stdout, nil)
+	slog.SetDefault(slog.New(handler).With("service", service))
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// FromContext returns the default logger, tagged with the context's request ID
+func FromContext(ctx context.Context) *slog.Logger {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		return slog.Default().With("request_id", requestID)
+	}
+	return slog.Default()
+}
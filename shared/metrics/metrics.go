@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultBuckets are the request duration histogram bounds in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total HTTP requests by route, method and status.",
+	}, []string{"service", "route", "method", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route and method.",
+		Buckets: DefaultBuckets,
+	}, []string{"service", "route", "method"})
+)
+
+// Middleware records request count, status and latency in the default
+// Prometheus registry. Routes are labeled by their pattern to keep
+// cardinality bounded; unmatched requests share the "unmatched" route.
+func Middleware(service string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		requestsTotal.WithLabelValues(service, route, method, strconv.Itoa(c.Writer.Status())).Inc()
+		requestDuration.WithLabelValues(service, route, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the default Prometheus registry for scraping
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareRecordsRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware("test-service"))
+	router.GET("/orders/:id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+	router.GET("/metrics", Handler())
+
+	counter := requestsTotal.WithLabelValues("test-service", "/orders/:id", http.MethodGet, "404")
+	before := testutil.ToFloat64(counter)
+
+	for _, id := range []string{"1", "2"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/"+id, nil))
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 2 {
+		t.Fatalf("request counter grew by %v, want 2", got)
+	}
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read scrape: %v", err)
+	}
+
+	// Routes are labeled by pattern, not by the requested path
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/orders/:id",service="test-service",status="404"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/orders/:id",service="test-service"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/orders/:id",service="test-service",le="+Inf"} 2`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("scrape is missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(string(body), `route="/orders/1"`) {
+		t.Fatalf("scrape labels a raw request path:\n%s", body)
+	}
+}
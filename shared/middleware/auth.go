@@ -3,8 +3,11 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"glovo-backend/shared/auth"
+	"glovo-backend/shared/events"
+	"glovo-backend/shared/logging"
 
 	"github.com/gin-gonic/gin"
 )
@@ -33,17 +36,100 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("role", claims.Role)
-		c.Set("claims", claims)
+		c.Set(auth.ContextUserID, claims.UserID)
+		c.Set(auth.ContextRole, claims.Role)
+		c.Set(auth.ContextClaims, claims)
+
+		if claims.ImpersonatedBy != "" {
+			c.Set("impersonated_by", claims.ImpersonatedBy)
+			c.Next()
+			auditImpersonatedRequest(c, claims)
+			return
+		}
+
 		c.Next()
 	}
 }
 
+// impersonationAudit is where impersonated requests are published; see AuditImpersonation
+var impersonationAudit struct {
+	bus     events.Bus
+	service string
+}
+
+// AuditImpersonation publishes every request made with an impersonation
+// token to bus, for the admin service to write to its audit log. Services
+// call it once at startup, before serving requests.
+func AuditImpersonation(bus events.Bus, service string) {
+	impersonationAudit.bus = bus
+	impersonationAudit.service = service
+}
+
+func auditImpersonatedRequest(c *gin.Context, claims *auth.Claims) {
+	logger := logging.FromContext(c.Request.Context())
+	logger.Info("impersonated request",
+		"admin_id", claims.ImpersonatedBy, "user_id", claims.UserID, "method", c.Request.Method,
+		"path", c.Request.URL.Path, "status", c.Writer.Status(), "token_id", claims.ID)
+
+	if impersonationAudit.bus == nil {
+		logger.Error("impersonated request not audited: AuditImpersonation was not called", "token_id", claims.ID)
+		return
+	}
+
+	payload := events.ImpersonatedRequestPayload{
+		AdminID:    claims.ImpersonatedBy,
+		UserID:     claims.UserID,
+		Role:       claims.Role,
+		Service:    impersonationAudit.service,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		Status:     c.Writer.Status(),
+		TokenID:    claims.ID,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		OccurredAt: time.Now(),
+	}
+	if err := impersonationAudit.bus.Publish(c.Request.Context(), events.ImpersonatedRequest, payload); err != nil {
+		logger.Error("failed to publish impersonated request", "event", events.ImpersonatedRequest, "token_id", claims.ID, "error", err)
+	}
+}
+
+// WebSocketProtocol is the subprotocol browsers offer next to their token,
+// since they can't set headers on a WebSocket handshake:
+// new WebSocket(url, ["bearer", token])
+const WebSocketProtocol = "bearer"
+
+// WebSocketAuth is AuthMiddleware for WebSocket upgrades: without an
+// Authorization header it takes the token offered in Sec-WebSocket-Protocol.
+// The handler must accept WebSocketProtocol in the handshake response.
+func WebSocketAuth() gin.HandlerFunc {
+	authenticate := AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token, ok := webSocketProtocolToken(c.GetHeader("Sec-WebSocket-Protocol")); ok {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		authenticate(c)
+	}
+}
+
+// webSocketProtocolToken returns the protocol offered after WebSocketProtocol
+func webSocketProtocolToken(header string) (string, bool) {
+	protocols := strings.Split(header, ",")
+	for i, protocol := range protocols {
+		if strings.TrimSpace(protocol) == WebSocketProtocol && i+1 < len(protocols) {
+			token := strings.TrimSpace(protocols[i+1])
+			return token, token != ""
+		}
+	}
+	return "", false
+}
+
 // RequireRole validates that the user has the required role
 func RequireRole(role auth.UserRole) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		claims, exists := c.Get("claims")
+		claims, exists := c.Get(auth.ContextClaims)
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "No authentication claims found"})
 			c.Abort()
@@ -70,7 +156,7 @@ func RequireRole(role auth.UserRole) gin.HandlerFunc {
 // RequireRoles validates that the user has one of the required roles
 func RequireRoles(roles []auth.UserRole) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		claims, exists := c.Get("claims")
+		claims, exists := c.Get(auth.ContextClaims)
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "No authentication claims found"})
 			c.Abort()
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig lists what cross-origin browsers are allowed to do
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int // seconds browsers may cache a preflight response
+}
+
+// CORSConfigFromEnv reads CORS_ALLOWED_ORIGINS, CORS_ALLOWED_METHODS,
+// CORS_ALLOWED_HEADERS (comma separated) and CORS_ALLOW_CREDENTIALS.
+// No origins are allowed unless CORS_ALLOWED_ORIGINS is set.
+func CORSConfigFromEnv() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins:   envList("CORS_ALLOWED_ORIGINS", ""),
+		AllowedMethods:   envList("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS"),
+		AllowedHeaders:   envList("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,X-Request-ID,Idempotency-Key"),
+		ExposedHeaders:   []string{"X-Request-ID", "Retry-After"},
+		AllowCredentials: os.Getenv("CORS_ALLOW_CREDENTIALS") != "false",
+		MaxAge:           600,
+	}
+}
+
+// CORS answers preflight requests and sets the CORS response headers. The
+// request's Origin is echoed back only when it is on the allowlist, so
+// credentials are never shared with arbitrary sites.
+func CORS(config CORSConfig) gin.HandlerFunc {
+	origins := make(map[string]bool, len(config.AllowedOrigins))
+	for _, origin := range config.AllowedOrigins {
+		origins[strings.TrimSuffix(origin, "/")] = true
+	}
+	methods := strings.Join(config.AllowedMethods, ",")
+	headers := strings.Join(config.AllowedHeaders, ",")
+	exposed := strings.Join(config.ExposedHeaders, ",")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !origins[origin] {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		if config.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", methods)
+			c.Header("Access-Control-Allow-Headers", headers)
+			if config.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if exposed != "" {
+			c.Header("Access-Control-Expose-Headers", exposed)
+		}
+		c.Next()
+	}
+}
+
+func envList(key, defaultValue string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		value = defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
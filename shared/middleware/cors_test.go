@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.glovo.test, https://admin.glovo.test/")
+	t.Setenv("CORS_ALLOWED_METHODS", "")
+	t.Setenv("CORS_ALLOWED_HEADERS", "")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "")
+
+	router := gin.New()
+	router.Use(CORS(CORSConfigFromEnv()))
+	router.GET("/orders", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantAllowed bool
+	}{
+		{name: "allowed origin", method: http.MethodGet, origin: "https://app.glovo.test", wantStatus: http.StatusOK, wantAllowed: true},
+		{name: "allowlist entry with trailing slash", method: http.MethodGet, origin: "https://admin.glovo.test", wantStatus: http.StatusOK, wantAllowed: true},
+		{name: "disallowed origin", method: http.MethodGet, origin: "https://evil.test", wantStatus: http.StatusOK},
+		{name: "same origin request", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "allowed preflight", method: http.MethodOptions, origin: "https://app.glovo.test", preflight: true, wantStatus: http.StatusNoContent, wantAllowed: true},
+		{name: "disallowed preflight", method: http.MethodOptions, origin: "https://evil.test", preflight: true, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/orders", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			header := w.Header()
+			if !tt.wantAllowed {
+				if got := header.Get("Access-Control-Allow-Origin"); got != "" {
+					t.Fatalf("Access-Control-Allow-Origin = %q, want none", got)
+				}
+				if got := header.Get("Access-Control-Allow-Credentials"); got != "" {
+					t.Fatalf("Access-Control-Allow-Credentials = %q, want none", got)
+				}
+				return
+			}
+
+			if got := header.Get("Access-Control-Allow-Origin"); got != tt.origin {
+				t.Fatalf("Access-Control-Allow-Origin = %q, want %q", got, tt.origin)
+			}
+			if got := header.Get("Access-Control-Allow-Credentials"); got != "true" {
+				t.Fatalf("Access-Control-Allow-Credentials = %q, want true", got)
+			}
+			if got := header.Values("Vary"); !reflect.DeepEqual(got, []string{"Origin"}) {
+				t.Fatalf("Vary = %v, want Origin", got)
+			}
+			if tt.preflight {
+				if got := header.Get("Access-Control-Allow-Methods"); got != "GET,POST,PUT,PATCH,DELETE,OPTIONS" {
+					t.Fatalf("Access-Control-Allow-Methods = %q", got)
+				}
+				if got := header.Get("Access-Control-Max-Age"); got != "600" {
+					t.Fatalf("Access-Control-Max-Age = %q, want 600", got)
+				}
+			} else if got := header.Get("Access-Control-Expose-Headers"); got != "X-Request-ID,Retry-After" {
+				t.Fatalf("Access-Control-Expose-Headers = %q", got)
+			}
+		})
+	}
+}
+
+func TestCORSWithoutCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config := CORSConfig{AllowedOrigins: []string{"https://app.glovo.test"}}
+
+	router := gin.New()
+	router.Use(CORS(config))
+	router.GET("/orders", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("Origin", "https://app.glovo.test")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.glovo.test" {
+		t.Fatalf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("Access-Control-Allow-Credentials = %q, want none", got)
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"glovo-backend/shared/ratelimit"
+	"glovo-backend/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitConfig configures a token bucket per client
+type RateLimitConfig struct {
+	Rate  float64 // tokens refilled per second
+	Burst int     // bucket capacity
+	// KeyByUser keys authenticated requests by user ID instead of client IP;
+	// it requires AuthMiddleware to run first
+	KeyByUser bool
+}
+
+// RateLimit throttles requests with an in-memory token bucket per client and
+// responds 429 with a Retry-After header once a client's bucket is empty.
+// Each call creates an independent limiter, so route groups can be limited
+// separately.
+func RateLimit(config RateLimitConfig) gin.HandlerFunc {
+	limiter := ratelimit.New(config.Rate, config.Burst)
+
+	return func(c *gin.Context) {
+		allowed, retryAfter := limiter.Take(rateLimitKey(c, config), time.Now())
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			response.Error(c, response.CodeTooManyRequests, "Rate limit exceeded", map[string]interface{}{
+				"retry_after_seconds": math.Ceil(retryAfter.Seconds()),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// TrustedProxiesFromEnv reads TRUSTED_PROXIES, the comma separated IPs or
+// CIDRs of the load balancers in front of a service, for
+// gin.Engine.SetTrustedProxies. None are trusted unless it is set, so
+// X-Forwarded-For is ignored and clients can't choose their rate limit key.
+func TrustedProxiesFromEnv() []string {
+	return envList("TRUSTED_PROXIES", "")
+}
+
+// rateLimitKey falls back to the client IP, which comes from X-Forwarded-For
+// only when the request arrived through one of the router's trusted proxies
+func rateLimitKey(c *gin.Context, config RateLimitConfig) string {
+	if config.KeyByUser {
+		if userID := c.GetString("user_id"); userID != "" {
+			return "user:" + userID
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
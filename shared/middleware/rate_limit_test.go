@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/search", RateLimit(RateLimitConfig{Rate: 1, Burst: 3}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	request := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/search", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 3; i++ {
+		if w := request("10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, http.StatusOK)
+		}
+	}
+
+	w := request("10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("Retry-After = %q, want 1", w.Header().Get("Retry-After"))
+	}
+
+	// Other clients aren't affected
+	if w := request("10.0.0.2"); w.Code != http.StatusOK {
+		t.Fatalf("another client: status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestRateLimitKeyByUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID != "" {
+			c.Set("user_id", userID)
+		}
+	})
+	router.GET("/orders", RateLimit(RateLimitConfig{Rate: 1, Burst: 1, KeyByUser: true}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	request := func(userID string) int {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Test-User", userID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Users behind the same IP get a bucket each
+	if request("user-1") != http.StatusOK || request("user-2") != http.StatusOK {
+		t.Fatalf("first request of each user was throttled")
+	}
+	if code := request("user-1"); code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+
+	// Anonymous requests fall back to the IP
+	if request("") != http.StatusOK {
+		t.Fatalf("first anonymous request was throttled")
+	}
+	if code := request(""); code != http.StatusTooManyRequests {
+		t.Fatalf("anonymous status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimitForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(t *testing.T) *gin.Engine {
+		t.Helper()
+		router := gin.New()
+		if err := router.SetTrustedProxies(TrustedProxiesFromEnv()); err != nil {
+			t.Fatalf("SetTrustedProxies: %v", err)
+		}
+		router.POST("/login", RateLimit(RateLimitConfig{Rate: 1, Burst: 1}), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+	request := func(router *gin.Engine, remoteIP, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.RemoteAddr = remoteIP + ":1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("no trusted proxies", func(t *testing.T) {
+		t.Setenv("TRUSTED_PROXIES", "")
+		router := newRouter(t)
+
+		// A fresh spoofed address doesn't buy a fresh bucket
+		if code := request(router, "203.0.113.7", "198.51.100.1"); code != http.StatusOK {
+			t.Fatalf("first request: status = %d, want %d", code, http.StatusOK)
+		}
+		if code := request(router, "203.0.113.7", "198.51.100.2"); code != http.StatusTooManyRequests {
+			t.Fatalf("spoofed X-Forwarded-For: status = %d, want %d", code, http.StatusTooManyRequests)
+		}
+	})
+
+	t.Run("behind a trusted proxy", func(t *testing.T) {
+		t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+		router := newRouter(t)
+
+		// Clients behind the load balancer are told apart by X-Forwarded-For
+		if code := request(router, "10.0.0.5", "198.51.100.1"); code != http.StatusOK {
+			t.Fatalf("first client: status = %d, want %d", code, http.StatusOK)
+		}
+		if code := request(router, "10.0.0.5", "198.51.100.2"); code != http.StatusOK {
+			t.Fatalf("second client: status = %d, want %d", code, http.StatusOK)
+		}
+		if code := request(router, "10.0.0.5", "198.51.100.1"); code != http.StatusTooManyRequests {
+			t.Fatalf("first client again: status = %d, want %d", code, http.StatusTooManyRequests)
+		}
+		// but not when the request bypasses it
+		if code := request(router, "203.0.113.7", "198.51.100.3"); code != http.StatusOK {
+			t.Fatalf("direct client: status = %d, want %d", code, http.StatusOK)
+		}
+		if code := request(router, "203.0.113.7", "198.51.100.4"); code != http.StatusTooManyRequests {
+			t.Fatalf("direct client spoofing X-Forwarded-For: status = %d, want %d", code, http.StatusTooManyRequests)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"time"
+
+	"glovo-backend/shared/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength caps client-supplied IDs so they can't bloat logs
+const maxRequestIDLength = 128
+
+// RequestID reuses the caller's X-Request-ID or generates one, echoes it in
+// the response and stores it in the gin and request contexts so logs and
+// outbound calls can carry it
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(logging.HeaderRequestID)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.New().String()
+		}
+
+		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+		c.Header(logging.HeaderRequestID, requestID)
+
+		c.Next()
+	}
+}
+
+// RequestLogger writes one structured log line per request; it replaces gin's
+// text logger and must run after RequestID
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", c.Writer.Status(),
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		}
+		if userID := c.GetString("user_id"); userID != "" {
+			attrs = append(attrs, "user_id", userID)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+
+		logger := logging.FromContext(c.Request.Context())
+		if c.Writer.Status() >= 500 {
+			logger.Error("request", attrs...)
+		} else {
+			logger.Info("request", attrs...)
+		}
+	}
+}
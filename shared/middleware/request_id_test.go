@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"glovo-backend/shared/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRequestIDAndLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var seen string // request ID outbound calls would forward
+	router := gin.New()
+	router.Use(RequestID(), RequestLogger())
+	router.GET("/orders/:id", func(c *gin.Context) {
+		seen = logging.RequestIDFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name      string
+		header    string
+		wantEcho  bool // the caller's ID is kept
+		wantValid bool // a fresh UUID is generated
+	}{
+		{name: "incoming ID", header: "req-123", wantEcho: true},
+		{name: "no ID", wantValid: true},
+		{name: "oversized ID", header: strings.Repeat("x", maxRequestIDLength+1), wantValid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+			if tt.header != "" {
+				req.Header.Set(logging.HeaderRequestID, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(logging.HeaderRequestID)
+			if tt.wantEcho && requestID != tt.header {
+				t.Fatalf("response ID = %q, want %q", requestID, tt.header)
+			}
+			if tt.wantValid {
+				if _, err := uuid.Parse(requestID); err != nil {
+					t.Fatalf("response ID %q isn't a generated UUID", requestID)
+				}
+			}
+			if seen != requestID {
+				t.Fatalf("handler context has ID %q, response has %q", seen, requestID)
+			}
+
+			var line map[string]interface{}
+			if err := json.Unmarshal(logs.Bytes(), &line); err != nil {
+				t.Fatalf("log line isn't JSON: %v: %s", err, logs.String())
+			}
+			if line["request_id"] != requestID || line["route"] != "/orders/:id" || line["path"] != "/orders/42" || line["status"] != float64(http.StatusOK) {
+				t.Fatalf("log line = %v", line)
+			}
+		})
+	}
+}
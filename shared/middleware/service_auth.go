@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"glovo-backend/shared/auth"
+	"glovo-backend/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceAuth restricts internal endpoints to callers holding a valid service
+// token in X-Service-Token. User JWTs are not accepted. When services are
+// given, only those callers are allowed. The caller's name is stored as
+// "service" in the context.
+func ServiceAuth(services ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(services))
+	for _, service := range services {
+		allowed[service] = true
+	}
+
+	return func(c *gin.Context) {
+		token := c.GetHeader(auth.HeaderServiceToken)
+		if token == "" {
+			response.Error(c, response.CodeUnauthorized, "Service token required", nil)
+			return
+		}
+
+		claims, err := auth.ValidateServiceToken(token)
+		if err != nil {
+			response.Error(c, response.CodeUnauthorized, "Invalid service token", nil)
+			return
+		}
+
+		if len(allowed) > 0 && !allowed[claims.Service] {
+			response.Error(c, response.CodeForbidden, "Service not allowed", nil)
+			return
+		}
+
+		c.Set("service", claims.Service)
+		c.Next()
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"glovo-backend/shared/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestServiceAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "user-secret")
+	t.Setenv("SERVICE_TOKEN_SECRET", "service-secret")
+
+	serviceToken := func(service string) string {
+		token, err := auth.GenerateServiceToken(service)
+		if err != nil {
+			t.Fatalf("GenerateServiceToken: %v", err)
+		}
+		return token
+	}
+	signed := func(secret string, expiresAt time.Time) string {
+		claims := auth.ServiceClaims{
+			Service: "order-service",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+				Audience:  jwt.ClaimStrings{"glovo-internal"},
+			},
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("signing token: %v", err)
+		}
+		return token
+	}
+	userToken, err := auth.GenerateToken("user-1", auth.RoleAdmin)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		allowed     []string
+		token       string
+		wantStatus  int
+		wantService string
+	}{
+		{name: "valid token", token: serviceToken("order-service"), wantStatus: http.StatusOK, wantService: "order-service"},
+		{name: "allowed caller", allowed: []string{"order-service"}, token: serviceToken("order-service"), wantStatus: http.StatusOK, wantService: "order-service"},
+		{name: "caller not allowed", allowed: []string{"order-service"}, token: serviceToken("driver-service"), wantStatus: http.StatusForbidden},
+		{name: "missing token", wantStatus: http.StatusUnauthorized},
+		{name: "malformed token", token: "not-a-token", wantStatus: http.StatusUnauthorized},
+		{name: "signed with another secret", token: signed("other-secret", time.Now().Add(time.Minute)), wantStatus: http.StatusUnauthorized},
+		{name: "expired token", token: signed("service-secret", time.Now().Add(-time.Hour)), wantStatus: http.StatusUnauthorized},
+		{name: "user JWT", token: userToken, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/internal", ServiceAuth(tt.allowed...), func(c *gin.Context) {
+				c.String(http.StatusOK, c.GetString("service"))
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/internal", nil)
+			if tt.token != "" {
+				req.Header.Set(auth.HeaderServiceToken, tt.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantService != "" && rec.Body.String() != tt.wantService {
+				t.Fatalf("service = %q, want %q", rec.Body.String(), tt.wantService)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// ErrInvalidCursor is returned for cursors that weren't produced by Encode
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the sort key of the last row on a page. Rows are ordered newest
+// first by (created_at, id), so the ID breaks ties between equal timestamps.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// Encode returns the opaque cursor string handed to clients
+func Encode(createdAt time.Time, id string) string {
+	data, _ := json.Marshal(Cursor{CreatedAt: createdAt, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses a client cursor; an empty string means the first page
+func Decode(cursor string) (*Cursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// NormalizeLimit clamps a requested page size to [1, MaxLimit]
+func NormalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+// Apply orders query newest first, resumes after cursor and fetches one row
+// more than limit so Trim can tell whether another page exists. Rows inserted
+// after the first page sort before the cursor, so paging never repeats or
+// skips rows.
+func Apply(query *gorm.DB, cursor *Cursor, limit int, createdAtColumn, idColumn string) *gorm.DB {
+	if cursor != nil {
+		query = query.Where("("+createdAtColumn+", "+idColumn+") < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+	return query.Order(createdAtColumn + " DESC").Order(idColumn + " DESC").Limit(limit + 1)
+}
+
+// Trim drops the extra row fetched by Apply and returns the cursor for the
+// next page, or "" on the last page
+func Trim[T any](rows []T, limit int, key func(T) (time.Time, string)) ([]T, string) {
+	if len(rows) <= limit {
+		return rows, ""
+	}
+	rows = rows[:limit]
+	createdAt, id := key(rows[limit-1])
+	return rows, Encode(createdAt, id)
+}
@@ -0,0 +1,138 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"glovo-backend/shared/database/dbtest"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	got, err := Decode(Encode(createdAt, "tx-1"))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !got.CreatedAt.Equal(createdAt) || got.ID != "tx-1" {
+		t.Fatalf("cursor = %+v, want %v tx-1", got, createdAt)
+	}
+
+	if c, err := Decode(""); c != nil || err != nil {
+		t.Fatalf("Decode(\"\") = %v, %v, want the first page", c, err)
+	}
+	for _, cursor := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"t":"2026-03-01T12:00:00Z"}`)),
+	} {
+		if _, err := Decode(cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("Decode(%q) error = %v, want %v", cursor, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestNormalizeLimit(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{limit: 0, want: DefaultLimit},
+		{limit: -5, want: DefaultLimit},
+		{limit: 1, want: 1},
+		{limit: MaxLimit, want: MaxLimit},
+		{limit: MaxLimit + 1, want: MaxLimit},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeLimit(tt.limit); got != tt.want {
+			t.Fatalf("NormalizeLimit(%d) = %d, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
+
+type row struct {
+	ID        string `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+func (r row) key() (time.Time, string) {
+	return r.CreatedAt, r.ID
+}
+
+func TestTrim(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rows := []row{{ID: "c", CreatedAt: base}, {ID: "b", CreatedAt: base}, {ID: "a", CreatedAt: base.Add(-time.Minute)}}
+
+	page, next := Trim(rows, 2, row.key)
+	if len(page) != 2 || page[1].ID != "b" {
+		t.Fatalf("page = %v, want the first 2 rows", page)
+	}
+	if next != Encode(base, "b") {
+		t.Fatalf("next cursor doesn't point at the last row on the page")
+	}
+
+	if page, next := Trim(rows, 3, row.key); len(page) != 3 || next != "" {
+		t.Fatalf("last page = %v, %q, want all rows and no cursor", page, next)
+	}
+}
+
+// TestApplyWithInserts pages through a Postgres table in the test database
+// while rows are inserted between pages
+func TestApplyWithInserts(t *testing.T) {
+	tx := dbtest.Open(t, nil)
+	if err := tx.Exec("CREATE TEMP TABLE rows (id text PRIMARY KEY, created_at timestamptz NOT NULL) ON COMMIT DROP").Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	// Pairs of rows share a timestamp, so pages split ties on the ID
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	want := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		r := row{ID: fmt.Sprintf("row-%02d", i), CreatedAt: base.Add(time.Duration(i/2) * time.Minute)}
+		if err := tx.Create(&r).Error; err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+		want[r.ID] = true
+	}
+
+	seen := make(map[string]bool)
+	var cursor string
+	for page := 0; ; page++ {
+		after, err := Decode(cursor)
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		var rows []row
+		if err := Apply(tx.Model(&row{}), after, 3, "created_at", "id").Find(&rows).Error; err != nil {
+			t.Fatalf("page %d: %v", page, err)
+		}
+		rows, cursor = Trim(rows, 3, row.key)
+		for _, r := range rows {
+			if seen[r.ID] {
+				t.Fatalf("page %d repeats %s", page, r.ID)
+			}
+			seen[r.ID] = true
+		}
+		if cursor == "" {
+			break
+		}
+
+		// A newer row lands between pages; it belongs before the first page
+		newer := row{ID: fmt.Sprintf("new-%d", page), CreatedAt: base.Add(time.Hour + time.Duration(page)*time.Minute)}
+		if err := tx.Create(&newer).Error; err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	for id := range want {
+		if !seen[id] {
+			t.Fatalf("paging skipped %s", id)
+		}
+	}
+	if len(seen) != len(want) {
+		t.Fatalf("paging returned %d rows, want %d", len(seen), len(want))
+	}
+}
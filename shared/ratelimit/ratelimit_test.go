@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterTake(t *testing.T) {
+	start := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	limiter := New(2, 3) // 2 tokens a second, bursts of 3
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.Take("client-1", start); !ok {
+			t.Fatalf("request %d of the burst was throttled", i+1)
+		}
+	}
+	ok, retryAfter := limiter.Take("client-1", start)
+	if ok || retryAfter != 500*time.Millisecond {
+		t.Fatalf("after the burst: allowed %v, retry after %s; want throttled for 500ms", ok, retryAfter)
+	}
+
+	// Other keys have their own bucket
+	if ok, _ := limiter.Take("client-2", start); !ok {
+		t.Fatalf("another client was throttled")
+	}
+
+	// Half a second refills one token
+	if ok, _ := limiter.Take("client-1", start.Add(500*time.Millisecond)); !ok {
+		t.Fatalf("refilled token was refused")
+	}
+	if ok, _ := limiter.Take("client-1", start.Add(500*time.Millisecond)); ok {
+		t.Fatalf("took more tokens than refilled")
+	}
+
+	// Refills stop at the burst size
+	later := start.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.Take("client-1", later); !ok {
+			t.Fatalf("request %d after idling was throttled", i+1)
+		}
+	}
+	if ok, _ := limiter.Take("client-1", later); ok {
+		t.Fatalf("bucket refilled past its burst")
+	}
+}
+
+func TestLimiterSweepsIdleBuckets(t *testing.T) {
+	start := time.Now()
+	limiter := New(1, 2)
+	limiter.Take("idle", start)
+
+	// The sweep runs at most once a minute; by then "idle" has refilled
+	later := start.Add(2 * time.Minute)
+	limiter.Take("busy", later)
+
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Fatalf("idle bucket was not swept")
+	}
+	if _, ok := limiter.buckets["busy"]; !ok {
+		t.Fatalf("busy bucket was swept")
+	}
+}
+
+func TestLimiterWithoutRefill(t *testing.T) {
+	limiter := New(0, 0)
+	now := time.Now()
+	if ok, _ := limiter.Take("client", now); !ok {
+		t.Fatalf("burst is at least one request")
+	}
+	if ok, retryAfter := limiter.Take("client", now); ok || retryAfter != time.Minute {
+		t.Fatalf("allowed %v, retry after %s; want throttled for a minute", ok, retryAfter)
+	}
+}
@@ -0,0 +1,108 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Code is a machine-readable error code returned to clients
+type Code string
+
+const (
+	CodeInvalidRequest  Code = "invalid_request"
+	CodeUnauthorized    Code = "unauthorized"
+	CodeForbidden       Code = "forbidden"
+	CodeNotFound        Code = "not_found"
+	CodeConflict        Code = "conflict"
+	CodeUnprocessable   Code = "unprocessable"
+	CodeTooManyRequests Code = "too_many_requests"
+	CodeInternal        Code = "internal_error"
+	CodeUnavailable     Code = "service_unavailable"
+)
+
+var codeStatus = map[Code]int{
+	CodeInvalidRequest:  http.StatusBadRequest,
+	CodeUnauthorized:    http.StatusUnauthorized,
+	CodeForbidden:       http.StatusForbidden,
+	CodeNotFound:        http.StatusNotFound,
+	CodeConflict:        http.StatusConflict,
+	CodeUnprocessable:   http.StatusUnprocessableEntity,
+	CodeTooManyRequests: http.StatusTooManyRequests,
+	CodeInternal:        http.StatusInternalServerError,
+	CodeUnavailable:     http.StatusServiceUnavailable,
+}
+
+// Status returns the HTTP status for the code; unknown codes are server errors
+func (c Code) Status() int {
+	if status, ok := codeStatus[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrorBody is the payload of the error envelope
+type ErrorBody struct {
+	Code    Code                   `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// ErrorEnvelope is the body of every error response:
+// {"error":{"code":"...","message":"...","details":{...}}}
+type ErrorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+// Error aborts the request with the envelope and the code's status
+func Error(c *gin.Context, code Code, message string, details map[string]interface{}) {
+	c.AbortWithStatusJSON(code.Status(), ErrorEnvelope{
+		Error: ErrorBody{Code: code, Message: message, Details: details},
+	})
+}
+
+// BadRequest reports a malformed request, such as a failed body binding
+func BadRequest(c *gin.Context, err error) {
+	Error(c, CodeInvalidRequest, err.Error(), nil)
+}
+
+type mapping struct {
+	err  error
+	code Code
+}
+
+var (
+	mu       sync.RWMutex
+	mappings = []mapping{
+		{err: gorm.ErrRecordNotFound, code: CodeNotFound},
+	}
+)
+
+// Register maps a sentinel error, matched with errors.Is, to a code.
+// Services register their domain sentinels once at startup.
+func Register(err error, code Code) {
+	mu.Lock()
+	defer mu.Unlock()
+	mappings = append(mappings, mapping{err: err, code: code})
+}
+
+// CodeFor returns the code of the first registered sentinel err wraps,
+// or CodeInternal
+func CodeFor(err error) Code {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, m := range mappings {
+		if errors.Is(err, m.err) {
+			return m.code
+		}
+	}
+	return CodeInternal
+}
+
+// FromError writes err using the status of the sentinel it wraps
+func FromError(c *gin.Context, err error) {
+	Error(c, CodeFor(err), err.Error(), nil)
+}
@@ -0,0 +1,103 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		details    map[string]interface{}
+		wantBody   string
+		wantStatus int
+	}{
+		{
+			name:       "with details",
+			details:    map[string]interface{}{"field": "amount"},
+			wantBody:   `{"error":{"code":"invalid_request","message":"amount must be positive","details":{"field":"amount"}}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "without details",
+			wantBody:   `{"error":{"code":"invalid_request","message":"amount must be positive"}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+
+			Error(c, CodeInvalidRequest, "amount must be positive", tt.details)
+
+			if w.Code != tt.wantStatus || !c.IsAborted() {
+				t.Fatalf("status = %d (aborted %v), want %d", w.Code, c.IsAborted(), tt.wantStatus)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Fatalf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestFromError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	errLocked := errors.New("account locked")
+	Register(errLocked, CodeForbidden)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   Code
+		wantStatus int
+	}{
+		{name: "registered sentinel", err: errLocked, wantCode: CodeForbidden, wantStatus: http.StatusForbidden},
+		{name: "wrapped sentinel", err: fmt.Errorf("withdraw: %w", errLocked), wantCode: CodeForbidden, wantStatus: http.StatusForbidden},
+		{name: "record not found", err: fmt.Errorf("get payment: %w", gorm.ErrRecordNotFound), wantCode: CodeNotFound, wantStatus: http.StatusNotFound},
+		{name: "unknown error", err: errors.New("connection reset"), wantCode: CodeInternal, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			FromError(c, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var envelope ErrorEnvelope
+			if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if envelope.Error.Code != tt.wantCode || envelope.Error.Message != tt.err.Error() {
+				t.Fatalf("envelope = %+v", envelope)
+			}
+		})
+	}
+}
+
+func TestCodeStatus(t *testing.T) {
+	for code, want := range codeStatus {
+		if got := code.Status(); got != want {
+			t.Fatalf("%s.Status() = %d, want %d", code, got, want)
+		}
+	}
+	if got := Code("teapot").Status(); got != http.StatusInternalServerError {
+		t.Fatalf("unknown code status = %d, want %d", got, http.StatusInternalServerError)
+	}
+}
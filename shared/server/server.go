@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownTimeout bounds how long in-flight requests may take to drain
+const DefaultShutdownTimeout = 30 * time.Second
+
+// RunGraceful serves handler on addr until SIGINT or SIGTERM, then stops
+// accepting connections and waits up to shutdownTimeout for in-flight
+// requests to finish. It returns nil after a clean shutdown.
+func RunGraceful(addr string, handler http.Handler, shutdownTimeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return Serve(ctx, &http.Server{Addr: addr, Handler: handler}, shutdownTimeout)
+}
+
+// Serve runs srv until ctx is cancelled and then shuts it down gracefully
+func Serve(ctx context.Context, srv *http.Server, shutdownTimeout time.Duration) error {
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = DefaultShutdownTimeout
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down server, draining in-flight requests (timeout %s)", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	return <-errCh
+}
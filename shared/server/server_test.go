@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a local address nothing is listening on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+// waitUntilServing polls addr until the server accepts connections
+func waitUntilServing(t *testing.T, addr string) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("server on %s never started", addr)
+}
+
+// slowHandler signals when a request arrives and answers once released
+func slowHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		io.WriteString(w, "paid")
+	})
+}
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	addr := freeAddr(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(ctx, &http.Server{Addr: addr, Handler: slowHandler(started, release)}, 5*time.Second)
+	}()
+	waitUntilServing(t, addr)
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/payments")
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{body: string(body), err: err}
+	}()
+	<-started
+
+	// Shut down while the request is in flight
+	cancel()
+	select {
+	case err := <-served:
+		t.Fatalf("Serve returned before the request finished: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if conn, err := net.Dial("tcp", addr); err == nil {
+		conn.Close()
+		t.Fatalf("server still accepts connections while draining")
+	}
+
+	close(release)
+	if got := <-responses; got.err != nil || got.body != "paid" {
+		t.Fatalf("in-flight request = %q, %v; want it to complete", got.body, got.err)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+}
+
+func TestServeShutdownTimeout(t *testing.T) {
+	addr := freeAddr(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(ctx, &http.Server{Addr: addr, Handler: slowHandler(started, release)}, 50*time.Millisecond)
+	}()
+	waitUntilServing(t, addr)
+
+	go http.Get("http://" + addr + "/stuck")
+	<-started
+	cancel()
+
+	if err := <-served; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestServeListenError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	err = Serve(context.Background(), &http.Server{Addr: listener.Addr().String()}, time.Second)
+	if err == nil {
+		t.Fatalf("expected an error for an address in use")
+	}
+}
+
+func TestRunGracefulStopsOnSIGTERM(t *testing.T) {
+	addr := freeAddr(t)
+	served := make(chan error, 1)
+	go func() {
+		served <- RunGraceful(addr, http.NotFoundHandler(), time.Second)
+	}()
+	// The signal handler is installed before the server starts listening
+	waitUntilServing(t, addr)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("send SIGTERM: %v", err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("RunGraceful: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("server didn't stop on SIGTERM")
+	}
+}
@@ -0,0 +1,42 @@
+// Package vehicle ranks driver vehicle types by how much they can carry, so
+// the driver and delivery services agree on which vehicles fit an order.
+package vehicle
+
+// Type is a driver's vehicle type
+type Type string
+
+const (
+	Bicycle    Type = "bicycle"
+	Motorcycle Type = "motorcycle"
+	Car        Type = "car"
+	Van        Type = "van"
+)
+
+// capacity orders vehicle types by how much they can carry
+var capacity = map[Type]int{
+	Bicycle:    1,
+	Motorcycle: 2,
+	Car:        3,
+	Van:        4,
+}
+
+// CanHandle reports whether this vehicle meets the given minimum vehicle type.
+// A vehicle that is unknown or not recorded meets no minimum.
+func (v Type) CanHandle(min Type) bool {
+	if min == "" {
+		return true
+	}
+	have, ok := capacity[v]
+	return ok && have >= capacity[min]
+}
+
+// AtLeast returns the known vehicle types that meet the given minimum
+func AtLeast(min Type) []Type {
+	var types []Type
+	for vehicleType := range capacity {
+		if vehicleType.CanHandle(min) {
+			types = append(types, vehicleType)
+		}
+	}
+	return types
+}
@@ -0,0 +1,28 @@
+package vehicle
+
+import "testing"
+
+func TestCanHandle(t *testing.T) {
+	tests := []struct {
+		vehicle Type
+		min     Type
+		want    bool
+	}{
+		{vehicle: Bicycle, min: "", want: true},
+		{vehicle: Bicycle, min: Bicycle, want: true},
+		{vehicle: Bicycle, min: Car, want: false},
+		{vehicle: Motorcycle, min: Car, want: false},
+		{vehicle: Car, min: Car, want: true},
+		{vehicle: Van, min: Car, want: true},
+		{vehicle: "", min: "", want: true},
+		{vehicle: "", min: Bicycle, want: false},
+		{vehicle: "", min: Car, want: false},
+		{vehicle: "scooter", min: Bicycle, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.vehicle.CanHandle(tt.min); got != tt.want {
+			t.Errorf("%q.CanHandle(%q) = %v, want %v", tt.vehicle, tt.min, got, tt.want)
+		}
+	}
+}